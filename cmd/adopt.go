@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var adoptFrom string
+
+var adoptCmd = &cobra.Command{
+	Use:   "adopt <file>",
+	Short: "🧲 Bind a file to an existing global history",
+	Long: `Attach a file to a global store whose file moved, for example after
+restoring from a backup or when a drive mounts somewhere else. The store's
+metadata and directory are updated, so the file continues its history
+instead of starting a parallel one.
+
+Without --from, the history is chosen among global stores for a file of
+the same name that no longer exists (or whose metadata was lost); if
+there are several, pick one with --from.
+
+Examples:
+  oops adopt notes.md                         Find the history automatically
+  oops adopt notes.md --from /old/notes.md    Take over the history of /old/notes.md
+  oops adopt notes.md --from 1a2b3c4d5e6f7a8b Take over the store in that directory`,
+	Args: cobra.ExactArgs(1),
+	RunE: runAdopt,
+}
+
+func runAdopt(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+	if !utils.IsFile(filePath) {
+		fail("'%s' is not a valid file", filePath)
+		return nil
+	}
+
+	from, ok := adoptSource(filePath)
+	if !ok {
+		return nil
+	}
+
+	s, err := store.Adopt(*from, filePath)
+	if err != nil {
+		if err == store.ErrAlreadyTracked {
+			fail("'%s' already has a global history", filePath)
+			info("Use 'oops done -g %s' first to replace it", filePath)
+			return nil
+		}
+		fail("Failed to adopt: %v", err)
+		return nil
+	}
+
+	_, latest, hasChanges, err := s.Now()
+	if err != nil {
+		fail("Failed to read the adopted history: %v", err)
+		return nil
+	}
+	source := from.FilePath
+	if source == "" {
+		source = "store " + from.HashDir
+	}
+	success("'%s' now continues the history of %s (%d %s)", s.FileName, source, latest, plural(latest, "snapshot"))
+	if hasChanges {
+		info("The file differs from snapshot #%d; use 'oops changes' to see how", latest)
+	}
+	return nil
+}
+
+// adoptSource picks the global store to adopt, reporting why none could
+// be picked
+func adoptSource(filePath string) (*store.GlobalStoreInfo, bool) {
+	if adoptFrom != "" {
+		from, err := store.LookupGlobalStore(adoptFrom)
+		if err != nil {
+			fail("No global history found for %s", adoptFrom)
+			info("Use 'oops files -g' to list global stores")
+			return nil, false
+		}
+		return from, true
+	}
+
+	candidates, err := store.AdoptCandidates(filePath)
+	if err != nil {
+		fail("Error: %v", err)
+		return nil, false
+	}
+	switch len(candidates) {
+	case 0:
+		fail("No detached global history found for '%s'", filePath)
+		info("Use --from <old path> to pick one")
+		return nil, false
+	case 1:
+		return &candidates[0], true
+	}
+
+	fail("Several global histories could belong to '%s':", filePath)
+	for _, c := range candidates {
+		if c.FilePath != "" {
+			fmt.Printf("  - %s\n", c.FilePath)
+		} else {
+			fmt.Printf("  - store %s (metadata missing)\n", c.HashDir)
+		}
+	}
+	info("Use --from <old path or store> to pick one")
+	return nil, false
+}
+
+func init() {
+	adoptCmd.Flags().StringVar(&adoptFrom, "from", "", "Old path of the file, or the store directory name")
+	rootCmd.AddCommand(adoptCmd)
+}
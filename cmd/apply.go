@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"errors"
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var applyCmd = &cobra.Command{
+	Use:     "apply <version>",
+	Aliases: []string{"cherry-pick"},
+	Short:   "🍒 Bring back the change one snapshot made",
+	Long: `Merge the change a snapshot made, compared to the snapshot before it,
+into the file as it is now, without losing newer work: one old edit
+comes back while everything else stays.
+
+Where the file has been changed on the same lines since, both versions
+are kept between conflict markers:
+
+  <<<<<<< working file
+  the lines as they are now
+  =======
+  the lines as the snapshot changed them
+  >>>>>>> snapshot #3
+
+Edit them into what you want, then 'oops save'. The result is not saved
+automatically; 'oops oops!' discards it.
+
+Examples:
+  oops apply 3     Bring back the change saved as snapshot #3`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runApply,
+}
+
+func runApply(cmd *cobra.Command, args []string) error {
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[0])
+		return nil
+	}
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	conflicts, err := s.Apply(num)
+	switch {
+	case err == nil:
+	case err == store.ErrNoChanges:
+		info("%s already has the change of snapshot #%d", s.FileName, num)
+		return nil
+	case err == store.ErrVersionNotFound:
+		fail("Snapshot #%d not found", num)
+		info("Use 'oops history' to see available snapshots")
+		return nil
+	case errors.Is(err, store.ErrBinaryMerge):
+		fail("%s is not a text file, so changes cannot be merged", s.FileName)
+		info("Use 'oops back %d' to restore the whole snapshot", num)
+		return nil
+	default:
+		if !reportLocked(err) && !reportUnsettled(s, err) {
+			fail("Failed to apply: %v", err)
+		}
+		return nil
+	}
+
+	if conflicts > 0 {
+		warn("Applied snapshot #%d with %d %s marked in %s", num, conflicts, plural(conflicts, "conflict"), s.FileName)
+		info("Resolve the <<<<<<< ... >>>>>>> sections, then 'oops save'")
+		return nil
+	}
+	success("Applied the change of snapshot #%d to %s", num, s.FileName)
+	info("Use 'oops changes' to review it and 'oops save' to keep it")
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(applyCmd)
+}
@@ -0,0 +1,130 @@
+package cmd
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/iyulab/oops/internal/attest"
+	"github.com/iyulab/oops/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var (
+	attestOutput string
+	attestVerify string
+)
+
+var attestCmd = &cobra.Command{
+	Use:   "attest",
+	Short: "🔏 Export a signed manifest of all snapshots",
+	Long: `Write a signed manifest listing every snapshot's number, commit, content
+hash, time and message. Keep it (or hand it to an auditor) to prove later
+that history was not rewritten.
+
+Manifests are signed with an ed25519 key created on first use in
+~/.oops/attest.key. Share the key fingerprint shown with the manifest so
+others can tell it came from you.
+
+Examples:
+  oops attest -o attest.json        Write a manifest for the tracked file
+  oops attest --verify attest.json  Check the signature and that every
+                                    listed snapshot is unchanged`,
+	Args: cobra.NoArgs,
+	RunE: runAttest,
+}
+
+func runAttest(cmd *cobra.Command, args []string) error {
+	if attestVerify != "" {
+		return runAttestVerify()
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	configDir, err := config.GetConfigDir()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	key, err := attest.LoadOrCreateKey(configDir)
+	if err != nil {
+		fail("Failed to load signing key: %v", err)
+		return nil
+	}
+
+	m, err := s.Attest()
+	if err != nil {
+		fail("Failed to read history: %v", err)
+		return nil
+	}
+	if err := m.Sign(key); err != nil {
+		fail("Failed to sign manifest: %v", err)
+		return nil
+	}
+
+	output := attestOutput
+	if output == "" {
+		output = s.FileName + ".attest.json"
+	}
+	if err := m.Write(output); err != nil {
+		fail("Failed to write %s: %v", output, err)
+		return nil
+	}
+
+	success("Signed manifest of %d %s written to %s", len(m.Snapshots), plural(len(m.Snapshots), "snapshot"), output)
+	info("Key fingerprint: %s", keyFingerprint(m.PublicKey))
+	info("Verify later with: oops attest --verify %s", output)
+	return nil
+}
+
+func runAttestVerify() error {
+	m, err := attest.Read(attestVerify)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if err := m.VerifySignature(); err != nil {
+		fail("%v", err)
+		return nil
+	}
+	success("Signature valid (key fingerprint %s)", keyFingerprint(m.PublicKey))
+
+	s, err := findStoreForPath(m.File)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	problems, err := s.CheckAttestation(m)
+	if err != nil {
+		fail("Failed to read history: %v", err)
+		return nil
+	}
+	if len(problems) > 0 {
+		fail("History of %s differs from the manifest:", m.File)
+		for _, p := range problems {
+			fmt.Printf("    %s\n", p)
+		}
+		return nil
+	}
+	success("All %d %s listed on %s are unchanged", len(m.Snapshots),
+		plural(len(m.Snapshots), "snapshot"), m.Generated.Local().Format("2006-01-02 15:04"))
+	return nil
+}
+
+// keyFingerprint returns a short, comparable form of a base64 public key
+func keyFingerprint(publicKey string) string {
+	raw, _ := base64.StdEncoding.DecodeString(publicKey)
+	sum := sha256.Sum256(raw)
+	return "SHA256:" + hex.EncodeToString(sum[:8])
+}
+
+func init() {
+	attestCmd.Flags().StringVarP(&attestOutput, "output", "o", "", "Manifest file to write (default <file>.attest.json)")
+	attestCmd.Flags().StringVar(&attestVerify, "verify", "", "Verify a manifest against the current history")
+	rootCmd.AddCommand(attestCmd)
+}
@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iyulab/oops/internal/daemon"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var remoteAutoPushCmd = &cobra.Command{
+	Use:   "auto-push on|off [remote]",
+	Short: "Push every new snapshot to a remote in the background",
+	Long: `Mirror every snapshot to a remote as soon as it is saved, whether by
+save, watch or a schedule. Pushes run in the background, so saving does
+not wait for the network. A push that fails is retried by the daemon
+('oops daemon start') until it succeeds; 'oops remote' shows it.
+
+Examples:
+  oops remote auto-push on          Push to the default remote
+  oops remote auto-push on backup   Push to the remote named backup
+  oops remote auto-push off`,
+	Args:      cobra.RangeArgs(1, 2),
+	ValidArgs: []string{"on", "off"},
+	RunE:      runRemoteAutoPush,
+}
+
+var autoPushCmd = &cobra.Command{
+	Use:    "auto-push <file>",
+	Short:  "Push a file's snapshots to its auto-push remote",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.NewStoreWithOptions(args[0], storeOptions())
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		if n, err := autoPush(s); err != nil {
+			fail("Auto-push failed: %v", err)
+		} else if n > 0 {
+			success("Pushed %d %s", n, plural(n, "snapshot"))
+		}
+		return nil
+	},
+}
+
+func runRemoteAutoPush(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	switch args[0] {
+	case "off":
+		if err := s.SetSyncSettings(store.SyncSettings{}); err != nil {
+			fail("%v", err)
+			return nil
+		}
+		success("Auto-push off for '%s'", s.FileName)
+		return nil
+	case "on":
+	default:
+		fail("Use on or off")
+		return nil
+	}
+
+	settings := store.SyncSettings{AutoPush: true, Remote: firstArg(args[1:])}
+	if err := s.SetSyncSettings(settings); err != nil {
+		reportSyncError(err)
+		return nil
+	}
+	remote, _ := s.ResolveRemote(settings.Remote)
+	success("New snapshots of '%s' will be pushed to %s", s.FileName, remote.Name)
+	if socketPath, err := daemon.SocketPath(); err == nil && !daemon.Running(socketPath) {
+		info("Failed pushes are retried by the daemon; start it with 'oops daemon start'")
+	}
+	return nil
+}
+
+// autoPush pushes s to its auto-push remote, recording a failure for the
+// daemon to retry and clearing it on success
+func autoPush(s *store.Store) (int, error) {
+	n := 0
+	name, auth, err := namedRemoteAuth(s, s.SyncSettings().Remote)
+	if err == nil {
+		n, err = s.Push(name, auth)
+	}
+	s.RecordPush(name, err)
+	return n, err
+}
+
+// setupAutoPush starts a background push after each snapshot of a store
+// with auto-push on, so the command does not wait for the network
+func setupAutoPush(cmd *cobra.Command) {
+	if cmd == autoPushCmd {
+		return
+	}
+	events.Subscribe(func(e events.Event) {
+		if e.Type != events.SnapshotCreated || e.Store == "memory" {
+			return
+		}
+		opts := store.StoreOptions{Global: e.Store == "global", Profile: e.Profile}
+		if opts.Profile == "" {
+			opts.Profile = store.DefaultProfile // not the branch switched to since
+		}
+		s, err := store.NewStoreWithOptions(e.File, opts)
+		if err != nil || !s.SyncSettings().AutoPush {
+			return
+		}
+
+		args := []string{"auto-push", s.FilePath, "--local"}
+		if s.Global {
+			args[2] = "--global"
+		}
+		if !s.IsDefaultProfile() {
+			args = append(args, "--profile", s.Profile)
+		}
+		if _, err := daemon.Detach(args...); err != nil {
+			s.RecordPush(s.SyncSettings().Remote, err)
+		}
+	})
+}
+
+// autoPushService retries automatic pushes that failed
+type autoPushService struct {
+	interval time.Duration
+}
+
+func (s *autoPushService) Name() string { return "auto-push" }
+
+func (s *autoPushService) Run(ctx context.Context, logger *log.Logger) error {
+	for {
+		failed, err := store.FailedPushes()
+		if err != nil {
+			logger.Printf("[%s] %v", s.Name(), err)
+		}
+		for _, st := range failed {
+			n, err := autoPush(st)
+			if err != nil {
+				logger.Printf("[%s] %s: attempt %d failed: %v", s.Name(), st.FilePath, st.FailedPush().Attempts, err)
+				continue
+			}
+			logger.Printf("[%s] %s: pushed %d %s", s.Name(), st.FilePath, n, plural(n, "snapshot"))
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+func init() {
+	remoteCmd.AddCommand(remoteAutoPushCmd)
+	rootCmd.AddCommand(autoPushCmd)
+}
@@ -1,13 +1,22 @@
 package cmd
 
 import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
 	"strconv"
+	"strings"
 
+	"github.com/iyulab/oops/internal/git"
 	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var forceBack bool
+var (
+	forceBack       bool
+	backInteractive bool
+)
 
 var backCmd = &cobra.Command{
 	Use:     "back <version>",
@@ -18,9 +27,20 @@ var backCmd = &cobra.Command{
 Examples:
   oops back 1      Go to snapshot #1
   oops back 3      Go to snapshot #3
-  oops back -f 1   Force (discard unsaved changes)`,
-	Args: cobra.ExactArgs(1),
-	RunE: runBack,
+  oops back -f 1   Force (discard unsaved changes)
+  oops back -i 3   Choose which changes to restore, hunk by hunk
+
+With unsaved changes, back refuses by default. Set back.on_dirty to
+backup (save them as a snapshot first) or discard:
+  oops config --on-dirty back=backup
+
+With --interactive, each change between the file and the snapshot is
+shown in turn; answer y to restore it, n to keep the file's version, a
+to restore it and all the rest, or q to stop. Unsaved changes are kept
+where not restored, and the result is left unsaved.`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runBack,
 }
 
 func runBack(cmd *cobra.Command, args []string) error {
@@ -35,28 +55,129 @@ func runBack(cmd *cobra.Command, args []string) error {
 		fail("%v", err)
 		return nil
 	}
+	if backInteractive {
+		goBackInteractive(s, num)
+		return nil
+	}
+	goBack(s, num, forceBack)
+	return nil
+}
+
+// goBack restores snapshot num of s, reporting the result
+func goBack(s *store.Store, num int, force bool) {
+	if !s.Repo.HasTag(fmt.Sprintf("v%d", num)) {
+		fail("Snapshot #%d not found", num)
+		info("Use 'oops history' to see available snapshots")
+		return
+	}
+	if !handleUnsavedChanges(s, "back", force) {
+		return
+	}
 
-	if err := s.Back(num, forceBack); err != nil {
+	if err := s.Back(num, true); err != nil {
 		if err == store.ErrVersionNotFound {
 			fail("Snapshot #%d not found", num)
 			info("Use 'oops history' to see available snapshots")
-			return nil
+			return
 		}
-		if err == store.ErrUncommittedChanges {
-			warn("You have unsaved changes")
-			info("oops save     Save your changes first")
-			info("oops back -f  Discard changes and go back")
-			return nil
+		if !reportLocked(err) {
+			fail("Failed: %v", err)
 		}
-		fail("Failed: %v", err)
-		return nil
+		return
 	}
 
 	success("Restored to snapshot #%d", num)
-	return nil
+	reportChainFailures(s)
+}
+
+// goBackInteractive restores the hunks of snapshot num the user picks
+func goBackInteractive(s *store.Store, num int) {
+	reader := bufio.NewReader(os.Stdin)
+	all, quit := false, false
+	choose := func(h git.Hunk, i, n int) bool {
+		switch {
+		case all:
+			return true
+		case quit:
+			return false
+		}
+		printHunk(h, i, n, num)
+		for {
+			fmt.Printf("Restore this change? [y,n,a,q]: ")
+			answer, err := reader.ReadString('\n')
+			if err != nil && answer == "" {
+				quit = true
+				return false
+			}
+			switch strings.TrimSpace(strings.ToLower(answer)) {
+			case "y", "yes":
+				return true
+			case "n", "no":
+				return false
+			case "a":
+				all = true
+				return true
+			case "q":
+				quit = true
+				return false
+			}
+			info("y: restore it, n: keep the file's version, a: restore this and the rest, q: stop")
+		}
+	}
+
+	restored, total, err := s.BackPartial(num, choose)
+	if err != nil {
+		switch {
+		case err == store.ErrVersionNotFound:
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+		case errors.Is(err, store.ErrBinaryMerge):
+			fail("%s is not a text file, so it cannot be restored in parts", s.FileName)
+			info("Use 'oops back %d' to restore the whole snapshot", num)
+		default:
+			if !reportLocked(err) && !reportUnsettled(s, err) {
+				fail("Failed: %v", err)
+			}
+		}
+		return
+	}
+
+	switch {
+	case total == 0:
+		info("%s already matches snapshot #%d", s.FileName, num)
+	case restored == total:
+		success("Restored to snapshot #%d", num)
+		reportChainFailures(s)
+	case restored == 0:
+		info("Nothing restored")
+	default:
+		success("Restored %d of %d changes from snapshot #%d", restored, total, num)
+		info("The result is unsaved; 'oops save' keeps it, 'oops changes' shows it")
+	}
+}
+
+// printHunk shows hunk i of n: the file's lines that would go and the
+// lines of snapshot num that would replace them
+func printHunk(h git.Hunk, i, n, num int) {
+	fmt.Printf("\n@@ change %d of %d, line %d @@\n", i, n, h.Start+1)
+	for _, line := range h.Old {
+		fmt.Printf("-%s", withNewline(line))
+	}
+	for _, line := range h.Lines {
+		fmt.Printf("+%s", withNewline(line))
+	}
+}
+
+// withNewline ends line with a newline if it lacks one
+func withNewline(line string) string {
+	if strings.HasSuffix(line, "\n") {
+		return line
+	}
+	return line + "\n"
 }
 
 func init() {
+	backCmd.Flags().BoolVarP(&backInteractive, "interactive", "i", false, "Choose which changes to restore, hunk by hunk")
 	backCmd.Flags().BoolVarP(&forceBack, "force", "f", false, "Discard unsaved changes")
 	rootCmd.AddCommand(backCmd)
 }
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"github.com/iyulab/oops/internal/backup"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	backupTo    string
+	restoreFrom string
+	restorePath string
+)
+
+var backupCmd = &cobra.Command{
+	Use:   "backup --to <target>",
+	Short: "🗄️ Back up a file's history to object storage",
+	Long: `Copy the file's whole history, profiles included, to S3-compatible
+object storage or a folder. Only files the target does not have yet are
+uploaded, so backing up again after a few saves is quick.
+
+S3 credentials and region come from AWS_ACCESS_KEY_ID,
+AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN and AWS_REGION. Set
+AWS_ENDPOINT_URL for other S3-compatible services (MinIO, R2, ...).
+Each file is kept under a key made from its full path, so files with
+the same name in different folders can share a target.
+
+Examples:
+  oops backup --to s3://my-bucket/oops
+  oops backup --to /media/usb/oops-backups
+  oops restore --from s3://my-bucket/oops notes.md
+  oops restore --from /media/usb/oops-backups --path /home/me/notes.md notes.md`,
+	Args: cobra.NoArgs,
+	RunE: runBackup,
+}
+
+var restoreCmd = &cobra.Command{
+	Use:   "restore --from <target> <file>",
+	Short: "📥 Restore a file's history from a backup",
+	Long: `Recreate the history of a file from a backup made with 'oops backup'.
+If the file itself is missing, it is restored too. The file must not be
+tracked already.
+
+Backups are found by the path the file was backed up from. To restore
+to another place, such as on a new machine, give that path with --path.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runRestore,
+}
+
+func runBackup(cmd *cobra.Command, args []string) error {
+	if backupTo == "" {
+		fail("Use --to to say where to back up")
+		return nil
+	}
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	target, err := backup.Open(backupTo)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	r, err := s.BackupTo(target)
+	if err != nil {
+		fail("Backup failed: %v", err)
+		return nil
+	}
+	success("Backed up '%s' to %s", s.FileName, backupTo)
+	info("%d %s uploaded (%s), %d unchanged", r.Transferred, plural(r.Transferred, "file"), formatBytes(r.Bytes), r.Unchanged)
+	return nil
+}
+
+func runRestore(cmd *cobra.Command, args []string) error {
+	if restoreFrom == "" {
+		fail("Use --from to say which backup to restore")
+		return nil
+	}
+	s, err := getStoreForFile(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	target, err := backup.Open(restoreFrom)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	r, err := s.RestoreFrom(target, restorePath)
+	if err != nil {
+		if err == store.ErrAlreadyTracked {
+			fail("'%s' is already tracked", s.FileName)
+			info("Use 'oops done' first to replace its history with the backup")
+			return nil
+		}
+		fail("Restore failed: %v", err)
+		return nil
+	}
+	if !s.Global {
+		s.RegisterLocal()
+	}
+	latest, _ := s.GetLatestVersion()
+	success("Restored the history of '%s' (%d snapshots, %s)", s.FileName, latest, formatBytes(r.Bytes))
+	return nil
+}
+
+func init() {
+	backupCmd.Flags().StringVar(&backupTo, "to", "", "Backup target: s3://bucket/prefix or a folder")
+	restoreCmd.Flags().StringVar(&restoreFrom, "from", "", "Backup target to restore from")
+	restoreCmd.Flags().StringVar(&restorePath, "path", "", "Path the file was backed up from, if it is restored elsewhere")
+	rootCmd.AddCommand(backupCmd, restoreCmd)
+}
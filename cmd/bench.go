@@ -0,0 +1,99 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iyulab/oops/internal/bench"
+	"github.com/iyulab/oops/internal/config"
+	"github.com/spf13/cobra"
+)
+
+var benchCmd = &cobra.Command{
+	Use:   "bench",
+	Short: "⏱️ Measure snapshot speed on this machine",
+	Long: `Snapshot a synthetic text file and report how long save, back and
+changes take, and how much the store grows.
+
+The workload lives in a temporary directory and is removed afterwards;
+your tracked files and config are not touched.
+
+Examples:
+  oops bench                          1MB file, 5% of lines changed, 20 saves
+  oops bench --size 200MB --iterations 5   Try a large file before tracking one
+  oops bench --change-ratio 0.5       Rewrite half the file between saves`,
+	Args: cobra.NoArgs,
+	RunE: runBench,
+}
+
+var (
+	benchSize        string
+	benchChangeRatio float64
+	benchIterations  int
+)
+
+func runBench(cmd *cobra.Command, args []string) error {
+	size, err := config.ParseSize(benchSize)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	info("Saving %d %s of a %s file, %.0f%% of lines changed each time...",
+		benchIterations, plural(benchIterations, "snapshot"), config.FormatSize(size), benchChangeRatio*100)
+
+	start := time.Now()
+	r, err := bench.Run(bench.Options{
+		Size:        size,
+		ChangeRatio: benchChangeRatio,
+		Iterations:  benchIterations,
+		Seed:        time.Now().UnixNano(),
+	})
+	if err != nil {
+		fail("Benchmark failed: %v", err)
+		return nil
+	}
+
+	fmt.Printf("\n⏱️  %-8s %5s  %10s  %10s  %10s  %10s\n", "", "runs", "min", "avg", "p95", "max")
+	for _, row := range []struct {
+		name string
+		t    bench.Timings
+	}{
+		{"save", r.Save},
+		{"back", r.Back},
+		{"changes", r.Diff},
+	} {
+		fmt.Printf("   %-8s %5d  %10s  %10s  %10s  %10s\n", row.name, row.t.Count,
+			formatDuration(row.t.Min), formatDuration(row.t.Avg), formatDuration(row.t.P95), formatDuration(row.t.Max))
+	}
+
+	fmt.Println()
+	fmt.Printf("   Store size:   %s for %s of snapshot content", formatBytes(r.StoreSize), formatBytes(r.ContentSize))
+	if r.ContentSize > 0 {
+		fmt.Printf(" (%.1f%%)", float64(r.StoreSize)*100/float64(r.ContentSize))
+	}
+	fmt.Println()
+	fmt.Printf("   Per snapshot: %s\n", formatBytes(r.StoreSize/int64(r.Iterations+1)))
+	fmt.Println()
+	success("Done in %s", formatDuration(time.Since(start)))
+	return nil
+}
+
+// formatDuration rounds d to a readable precision for display
+func formatDuration(d time.Duration) string {
+	switch {
+	case d >= time.Second:
+		return d.Round(10 * time.Millisecond).String()
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond).String()
+	default:
+		return d.Round(time.Microsecond).String()
+	}
+}
+
+func init() {
+	benchCmd.Flags().StringVar(&benchSize, "size", "1MB", "Size of the synthetic file, e.g. 512KB or 100MB")
+	benchCmd.Flags().Float64Var(&benchChangeRatio, "change-ratio", 0.05, "Share of lines rewritten before each save, 0 to 1")
+	benchCmd.Flags().IntVar(&benchIterations, "iterations", 20, "Number of snapshots to save")
+	rootCmd.AddCommand(benchCmd)
+}
@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	blessRemove   bool
+	forceRollback bool
+)
+
+var blessCmd = &cobra.Command{
+	Use:   "bless [version]",
+	Short: "🏅 Mark a snapshot as known-good",
+	Long: `Mark a snapshot as the known-good version of the file, the current one
+if no number is given. 'oops rollback' then returns to it in one step,
+however many snapshots were saved after it. Blessing another snapshot
+replaces the mark.
+
+Examples:
+  oops bless            Mark the current snapshot as known-good
+  oops bless 4          Mark snapshot #4
+  oops bless --remove   Forget the known-good snapshot`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runBless,
+}
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback",
+	Short: "⏮️ Go back to the known-good snapshot",
+	Long: `Restore the snapshot marked with 'oops bless', however many snapshots
+were saved after it.
+
+Unsaved changes are handled as by 'oops back', following back.on_dirty;
+-f discards them.
+
+Examples:
+  oops rollback      Restore the known-good snapshot
+  oops rollback -f   Discard unsaved changes and restore it`,
+	Args: cobra.NoArgs,
+	RunE: runRollback,
+}
+
+func runBless(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	if blessRemove {
+		if err := s.Unbless(); err != nil {
+			fail("Failed: %v", err)
+			return nil
+		}
+		success("No snapshot of %s is blessed now", s.FileName)
+		return nil
+	}
+
+	var num int
+	if len(args) == 1 {
+		if num, err = strconv.Atoi(args[0]); err != nil || num < 1 {
+			fail("Invalid snapshot number: %s", args[0])
+			return nil
+		}
+	} else if num, _, _, err = s.Now(); err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	if err := s.Bless(num); err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		if !reportLocked(err) {
+			fail("Failed: %v", err)
+		}
+		return nil
+	}
+	success("Snapshot #%d of %s is blessed as known-good", num, s.FileName)
+	info("Use 'oops rollback' to return to it")
+	return nil
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	snap, _, err := s.Blessed()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if snap == nil {
+		fail("No snapshot of %s is blessed", s.FileName)
+		info("Use 'oops bless [version]' to mark a known-good snapshot")
+		return nil
+	}
+	if current, _, hasChanges, err := s.Now(); err == nil && current == snap.Number && !hasChanges {
+		info("%s is already at blessed snapshot #%d", s.FileName, snap.Number)
+		return nil
+	}
+	if !handleUnsavedChanges(s, "back", forceRollback) {
+		return nil
+	}
+
+	num, err := s.Rollback(true)
+	if err != nil {
+		if !reportLocked(err) {
+			fail("Failed: %v", err)
+		}
+		return nil
+	}
+	success("Rolled back to blessed snapshot #%d: %s", num, snap.Message)
+	reportChainFailures(s)
+	return nil
+}
+
+func init() {
+	blessCmd.Flags().BoolVarP(&blessRemove, "remove", "d", false, "Forget the known-good snapshot")
+	rollbackCmd.Flags().BoolVarP(&forceRollback, "force", "f", false, "Discard unsaved changes")
+	rootCmd.AddCommand(blessCmd)
+	rootCmd.AddCommand(rollbackCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var branchCmd = &cobra.Command{
+	Use:   "branch [name]",
+	Short: "🌿 List branches or start a new one",
+	Long: `Start an alternate timeline of the file, or list them.
+
+A branch is a profile of the file (see 'oops start --profile') with its
+own snapshot numbers, starting at #1 with the file as it is now. Use
+'oops switch' to work on it: saves then go to the branch, and switching
+back to the main history ("default", or "main") restores the file to
+where you left it, so neither line is renumbered.
+
+Examples:
+  oops branch                   List the branches, marking the current one
+  oops branch experiment        Start a branch from the file as it is
+  oops switch experiment        Work on it`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: cobra.NoFileCompletions,
+	RunE:              runBranch,
+}
+
+var switchCmd = &cobra.Command{
+	Use:   "switch <branch>",
+	Short: "🌿 Switch the file to another branch",
+	Long: `Restore the file to where a branch was left and save to that branch
+from now on. "default" (or "main") is the main history.
+
+Unsaved changes are handled as by back (see back.on_dirty); save them
+first to keep them on the current branch.
+
+Examples:
+  oops switch experiment
+  oops switch main`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeBranches,
+	RunE:              runSwitch,
+}
+
+var forceSwitch bool
+
+func runBranch(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if len(args) == 0 {
+		listBranches(s)
+		return nil
+	}
+
+	b, err := s.Branch(args[0])
+	if err != nil {
+		if errors.Is(err, store.ErrAlreadyTracked) {
+			fail("Branch '%s' already exists", args[0])
+			info("Use 'oops switch %s' to work on it", args[0])
+			return nil
+		}
+		if !reportLocked(err) {
+			fail("Failed to start the branch: %v", err)
+		}
+		return nil
+	}
+	success("Started branch '%s' of '%s' (snapshot #1)", b.Profile, s.FileName)
+	info("Use 'oops switch %s' to work on it", b.Profile)
+	return nil
+}
+
+// listBranches prints the branches of the file, marking the current one
+func listBranches(s *store.Store) {
+	names, err := s.Profiles()
+	if err != nil {
+		fail("%v", err)
+		return
+	}
+	names = append([]string{store.DefaultProfile}, names...)
+
+	fmt.Printf("🌿 %s branches:\n\n", s.FileName)
+	for _, name := range names {
+		marker := "  "
+		if name == s.ProfileName() {
+			marker = "→ "
+		}
+		latest := 0
+		if b, err := s.OpenProfile(name); err == nil {
+			latest, _ = b.GetLatestVersion()
+		}
+		fmt.Printf("%s%-20s %d %s\n", marker, name, latest, plural(latest, "snapshot"))
+	}
+}
+
+func runSwitch(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	name := args[0]
+	if names, _ := s.Profiles(); name == "main" && !slices.Contains(names, name) {
+		name = store.DefaultProfile
+	}
+	if name == s.ProfileName() {
+		info("Already on branch '%s'", name)
+		return nil
+	}
+	if !handleUnsavedChanges(s, "back", forceSwitch) {
+		return nil
+	}
+
+	b, err := s.Switch(name)
+	if err != nil {
+		if errors.Is(err, store.ErrNotTracked) {
+			fail("'%s' has no branch '%s'", s.FileName, name)
+			info("Use 'oops branch %s' to start it", name)
+			return nil
+		}
+		if !reportLocked(err) {
+			fail("Failed to switch: %v", err)
+		}
+		return nil
+	}
+	position, _ := b.Position()
+	success("Switched to branch '%s' at snapshot #%d", b.ProfileName(), position)
+	reportChainFailures(b)
+	return nil
+}
+
+// completeBranches completes the branches of the tracked file here
+func completeBranches(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	s, err := completionStore()
+	if err != nil {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	names, _ := s.Profiles()
+	return append([]string{store.DefaultProfile}, names...), cobra.ShellCompDirectiveNoFileComp
+}
+
+func init() {
+	switchCmd.Flags().BoolVarP(&forceSwitch, "force", "f", false, "Discard unsaved changes")
+	rootCmd.AddCommand(branchCmd)
+	rootCmd.AddCommand(switchCmd)
+}
@@ -0,0 +1,92 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	catOutput string
+	catForce  bool
+)
+
+var catCmd = &cobra.Command{
+	Use:     "cat <version>",
+	Aliases: []string{"export"},
+	Short:   "📄 Print or export a snapshot",
+	Long: `Print the content of a snapshot, or write it to another file, without
+touching the working file. Useful to inspect or recover old content next
+to the current version.
+
+Examples:
+  oops cat 3                  Print snapshot #3
+  oops cat 3 > old.txt        Same, redirected
+  oops export 3 -o old.txt    Write snapshot #3 to old.txt
+  oops export 3 -o old.txt -f Overwrite old.txt if it exists`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runCat,
+}
+
+func runCat(cmd *cobra.Command, args []string) error {
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[0])
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	content, err := s.Content(num)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		fail("Failed to read snapshot #%d: %v", num, err)
+		return nil
+	}
+
+	if catOutput == "" || catOutput == "-" {
+		os.Stdout.Write(content)
+		return nil
+	}
+
+	out, err := filepath.Abs(catOutput)
+	if err != nil {
+		fail("Invalid output path: %v", err)
+		return nil
+	}
+	if out == s.FilePath {
+		fail("Refusing to overwrite the tracked file")
+		info("Use 'oops back %d' to restore it", num)
+		return nil
+	}
+	if _, err := os.Stat(out); err == nil && !catForce {
+		fail("%s already exists", catOutput)
+		info("Use -f to overwrite it")
+		return nil
+	}
+	if err := os.WriteFile(out, content, 0644); err != nil {
+		fail("Failed to write %s: %v", catOutput, err)
+		return nil
+	}
+
+	success("Wrote snapshot #%d to %s (%s)", num, catOutput, formatBytes(int64(len(content))))
+	return nil
+}
+
+func init() {
+	catCmd.Flags().StringVarP(&catOutput, "output", "o", "", "Write to a file instead of stdout")
+	catCmd.Flags().BoolVarP(&catForce, "force", "f", false, "Overwrite the output file")
+	rootCmd.AddCommand(catCmd)
+}
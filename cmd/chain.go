@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var chainCmd = &cobra.Command{
+	Use:   "chain",
+	Short: "🔗 Save and restore generated files along with their source",
+	Long: `Chain a file generated from a tracked file, like report.pdf built from
+report.md, to it. Every snapshot of the source then also snapshots the
+chained file under the same number, and going back to a snapshot
+restores both, so the output always matches its source.
+
+A chained file is tracked in a store of its own, started when it is
+chained. Snapshots of the source saved before it was chained leave it
+alone when restored.
+
+Examples:
+  oops chain add report.md report.pdf      Chain report.pdf to report.md
+  oops chain remove report.md report.pdf   Stop saving them together
+  oops chain list report.md                Show the files chained to report.md`,
+}
+
+var chainAddCmd = &cobra.Command{
+	Use:   "add <source> <file>...",
+	Short: "Chain generated files to a tracked file",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runChainAdd,
+}
+
+var chainRemoveCmd = &cobra.Command{
+	Use:   "remove <source> <file>...",
+	Short: "Stop saving and restoring files with a tracked file",
+	Args:  cobra.MinimumNArgs(2),
+	RunE:  runChainRemove,
+}
+
+var chainListCmd = &cobra.Command{
+	Use:   "list [source]",
+	Short: "Show the files chained to a tracked file",
+	Args:  cobra.MaximumNArgs(1),
+	RunE:  runChainList,
+}
+
+func runChainAdd(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	for _, path := range args[1:] {
+		artifact, err := s.Chain(path)
+		var ahead *store.ChainAheadError
+		switch {
+		case errors.As(err, &ahead):
+			fail("Cannot chain %s: %v", path, err)
+			info("Its snapshots must stay numbered below the next one of %s", s.FileName)
+		case err != nil:
+			fail("Cannot chain %s: %v", path, err)
+		default:
+			success("%s chained to %s from snapshot #%d", artifact.Path, s.FileName, artifact.Since)
+		}
+	}
+	return nil
+}
+
+func runChainRemove(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	for _, path := range args[1:] {
+		if err := s.Unchain(path); err != nil {
+			if err == store.ErrNotChained {
+				fail("%s is not chained to %s", path, s.FileName)
+				continue
+			}
+			fail("Cannot unchain %s: %v", path, err)
+			continue
+		}
+		success("%s is no longer chained to %s; it stays tracked on its own", path, s.FileName)
+	}
+	return nil
+}
+
+func runChainList(cmd *cobra.Command, args []string) error {
+	var s *store.Store
+	var err error
+	if len(args) == 1 {
+		s, err = findStoreForPath(args[0])
+	} else {
+		s, err = findTrackedStore()
+	}
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	artifacts := s.Artifacts()
+	if len(artifacts) == 0 {
+		info("No files are chained to %s", s.FileName)
+		info("Use 'oops chain add %s <file>' to chain one", s.FileName)
+		return nil
+	}
+	fmt.Printf("🔗 Chained to %s:\n", s.FileName)
+	for _, a := range artifacts {
+		fmt.Printf("  %s  (from #%d)\n", a.Path, a.Since)
+	}
+	return nil
+}
+
+// reportChainFailures warns about chained files the last save or restore
+// of s could not include
+func reportChainFailures(s *store.Store) {
+	for _, err := range s.ChainFailures {
+		warn("Chained file %v", err)
+	}
+}
+
+func init() {
+	chainCmd.AddCommand(chainAddCmd, chainRemoveCmd, chainListCmd)
+	rootCmd.AddCommand(chainCmd)
+}
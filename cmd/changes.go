@@ -1,32 +1,91 @@
 package cmd
 
 import (
+	"bytes"
 	"fmt"
+	"os"
 	"strconv"
+	"time"
 
+	"github.com/iyulab/oops/internal/csvdiff"
+	"github.com/iyulab/oops/internal/git"
+	"github.com/iyulab/oops/internal/imagediff"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
 	"github.com/spf13/cobra"
 )
 
 var changesCmd = &cobra.Command{
 	Use:     "changes [version1] [version2]",
-	Aliases: []string{"diff", "show"},
+	Aliases: []string{"diff"},
 	Short:   "🔍 See what changed",
 	Long: `Show differences between versions.
 
 Examples:
   oops changes         Show unsaved changes
   oops changes 1       Compare current with snapshot #1
-  oops changes 1 3     Compare snapshot #1 with #3`,
-	Args: cobra.MaximumNArgs(2),
-	RunE: runChanges,
+  oops changes 1 3     Compare snapshot #1 with #3
+  oops changes --since today      What did I change today?
+  oops changes --since yesterday  Compare with the last save before yesterday
+  oops changes --ignore-eol       Hide differences in line endings only
+  oops changes 1 --context 10     Show 10 unchanged lines around each change
+  oops changes 1 > fix.patch      Save a patch that 'patch' can apply
+  oops changes 1 --html diff.html Write side-by-side thumbnails (images)
+  oops changes --csv              Compare CSV rows and cells, keyed by column 1
+  oops changes --csv --key sku    Match rows by the "sku" column
+  oops changes --against ~/laptop/app.conf    Compare with another copy
+  oops changes 3 --against other.conf         Compare snapshot #3 with it
+
+--since takes today, yesterday, an age such as 2h or 3d, or a date such
+as 2026-10-15. The working file is compared with the newest snapshot
+older than that time.
+
+For PNG, JPEG and GIF files the dimensions and the share of pixels that
+differ are shown instead of a text diff. For .docx and .odt documents the
+text is extracted and compared; formatting changes are not shown.
+
+--csv matches rows by a key column (a header name or column number), so
+sorted or reordered rows are not reported; added and removed rows and
+columns and changed cells are listed instead.
+
+--against compares the working file, or the one snapshot given, with any
+file on disk, e.g. when reconciling copies of a config across machines.
+It works with text and --csv.`,
+	Args:              cobra.MaximumNArgs(2),
+	ValidArgsFunction: completeSnapshots(2),
+	RunE:              runChanges,
 }
 
+var (
+	changesSince     string
+	changesIgnoreEOL bool
+	changesHTML      string
+	changesCSV       bool
+	changesKey       string
+	changesContext   int
+	changesAgainst   string
+)
+
 func runChanges(cmd *cobra.Command, args []string) error {
 	s, err := findTrackedStore()
 	if err != nil {
 		fail("%v", err)
 		return nil
 	}
+	s.Repo.IgnoreEOL = changesIgnoreEOL
+	if changesContext < 0 {
+		fail("--context must not be negative")
+		return nil
+	}
+	s.Repo.Context = changesContext
+
+	if changesSince != "" {
+		if changesAgainst != "" {
+			fail("--since cannot be combined with --against")
+			return nil
+		}
+		return runChangesSince(s, args)
+	}
 
 	var versions []int
 	for _, arg := range args {
@@ -38,6 +97,76 @@ func runChanges(cmd *cobra.Command, args []string) error {
 		versions = append(versions, num)
 	}
 
+	if changesAgainst != "" {
+		return printChangesAgainst(s, versions...)
+	}
+	return printChanges(s, versions...)
+}
+
+// printChangesAgainst shows the changes from the working file or one
+// snapshot to the file named by --against
+func printChangesAgainst(s *store.Store, versions ...int) error {
+	if len(versions) > 1 {
+		fail("--against compares one snapshot or the working file")
+		return nil
+	}
+	if changesKey != "" && !changesCSV {
+		fail("--key requires --csv")
+		return nil
+	}
+	if !changesCSV && (s.IsImage() || s.IsDocument()) {
+		fail("--against only compares text files")
+		return nil
+	}
+
+	var diff string
+	var err error
+	if changesCSV {
+		var pair *store.ContentPair
+		if pair, err = s.ContentPairAgainst(changesAgainst, versions...); err == nil {
+			return printCSVPair(pair)
+		}
+	} else {
+		diff, err = s.ChangesAgainst(changesAgainst, versions...)
+	}
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", versions[0])
+			return nil
+		}
+		fail("Failed to read %s: %v", changesAgainst, err)
+		return nil
+	}
+
+	if diff == "" {
+		info("No differences")
+		return nil
+	}
+	fmt.Print(diff)
+	return nil
+}
+
+// printChanges shows the changes between versions, as taken by
+// Store.Changes
+func printChanges(s *store.Store, versions ...int) error {
+	if changesKey != "" && !changesCSV {
+		fail("--key requires --csv")
+		return nil
+	}
+	if changesHTML != "" && !s.IsImage() {
+		fail("--html is only supported for images")
+		return nil
+	}
+	if changesCSV {
+		return printCSVChanges(s, versions...)
+	}
+	if s.IsImage() {
+		return printImageChanges(s, versions...)
+	}
+	if s.IsDocument() {
+		return printDocumentChanges(s, versions...)
+	}
+
 	diff, err := s.Changes(versions...)
 	if err != nil {
 		fail("Failed to get changes: %v", err)
@@ -49,10 +178,122 @@ func runChanges(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	fmt.Println(diff)
+	fmt.Print(diff)
 	return nil
 }
 
+// printDocumentChanges diffs the text of an office document
+func printDocumentChanges(s *store.Store, versions ...int) error {
+	diff, err := s.DocumentChanges(versions...)
+	if err != nil {
+		fail("Failed to read document text: %v", err)
+		return nil
+	}
+	if diff == "" {
+		info("No text changes")
+		return nil
+	}
+
+	fmt.Print(diff)
+	return nil
+}
+
+// printCSVChanges lists the rows, columns and cells that changed
+func printCSVChanges(s *store.Store, versions ...int) error {
+	pair, err := s.ContentPair(versions...)
+	if err != nil {
+		fail("Failed to get changes: %v", err)
+		return nil
+	}
+	return printCSVPair(pair)
+}
+
+// printCSVPair lists the rows, columns and cells that differ in pair
+func printCSVPair(pair *store.ContentPair) error {
+	result, err := csvdiff.Diff(pair.Old, pair.New, changesKey)
+	if err != nil {
+		fail("Failed to compare as CSV: %v", err)
+		return nil
+	}
+	if result.Empty() {
+		info("No changes")
+		return nil
+	}
+
+	fmt.Printf("📊 %s → %s, rows matched by %s:\n", pair.OldLabel, pair.NewLabel, result.Key)
+	fmt.Println(result)
+	return nil
+}
+
+// printImageChanges summarizes how an image changed, optionally writing
+// an HTML page with thumbnails of both versions
+func printImageChanges(s *store.Store, versions ...int) error {
+	change, err := s.ImageChanges(versions...)
+	if err != nil {
+		fail("Failed to compare images: %v", err)
+		return nil
+	}
+
+	if bytes.Equal(change.OldData, change.NewData) {
+		info("No changes")
+		return nil
+	}
+	fmt.Printf("🖼️  %s → %s: %s\n", change.OldLabel, change.NewLabel, change)
+
+	if changesHTML != "" {
+		f, err := os.Create(changesHTML)
+		if err != nil {
+			fail("Failed to write %s: %v", changesHTML, err)
+			return nil
+		}
+		defer f.Close()
+		if err := imagediff.WriteHTML(f, s.FileName, change.OldLabel, change.NewLabel,
+			change.OldData, change.NewData, change.Comparison); err != nil {
+			fail("Failed to write %s: %v", changesHTML, err)
+			return nil
+		}
+		success("Side-by-side comparison written to %s", changesHTML)
+	}
+	return nil
+}
+
+// runChangesSince diffs the working file against the newest snapshot
+// older than --since
+func runChangesSince(s *store.Store, args []string) error {
+	if len(args) > 0 {
+		fail("--since cannot be combined with snapshot numbers")
+		return nil
+	}
+
+	since, err := utils.ParseTimeRef(changesSince, time.Now())
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	snap, err := s.SnapshotBefore(since)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			info("No snapshot older than %s", since.Format("2006-01-02 15:04"))
+			info("Use 'oops changes 1' to compare with the first snapshot")
+			return nil
+		}
+		fail("Failed to get changes: %v", err)
+		return nil
+	}
+
+	info("Comparing with snapshot #%d (%s, %s)", snap.Number,
+		snap.Timestamp.Format("2006-01-02 15:04"), snap.Message)
+	return printChanges(s, snap.Number)
+}
+
 func init() {
+	changesCmd.Flags().StringVar(&changesSince, "since", "", "Compare with the last snapshot before this time")
+	changesCmd.Flags().IntVarP(&changesContext, "context", "U", git.DefaultContext, "Lines of unchanged context around each change")
+	changesCmd.Flags().BoolVar(&changesIgnoreEOL, "ignore-eol", false, "Ignore differences in line endings (CRLF vs LF)")
+	changesCmd.Flags().StringVar(&changesHTML, "html", "", "Write an HTML page comparing image thumbnails to this file")
+	changesCmd.Flags().BoolVar(&changesCSV, "csv", false, "Compare as CSV rows and cells instead of lines")
+	changesCmd.Flags().StringVar(&changesAgainst, "against", "", "Compare with this file instead of a snapshot")
+	changesCmd.Flags().StringVar(&changesKey, "key", "", "Column that identifies CSV rows, by name or number (default: first)")
 	rootCmd.AddCommand(changesCmd)
 }
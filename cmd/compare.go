@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var compareYes bool
+
+var compareCmd = &cobra.Command{
+	Use:   "compare-stores [file]",
+	Short: "🔀 Compare local and global histories of a file",
+	Long: `Show how the local and global histories of the same file differ.
+
+When a file is tracked both locally (.oops/) and globally (~/.oops/),
+this lists the snapshots only one of them has and compares their latest
+content. It then offers to merge both histories into the local store
+(or the global store with -g) and stop tracking in the other one, the
+same as 'oops dedupe-tracking'.
+
+Examples:
+  oops compare-stores            Compare the duplicate in this directory
+  oops compare-stores notes.md   Compare a specific file
+  oops compare-stores -g -y      Merge into the global store without asking`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runCompareStores,
+}
+
+func runCompareStores(cmd *cobra.Command, args []string) error {
+	local, global, err := findDuplicateStores(args)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	cmp, err := store.CompareStores(local, global)
+	if err != nil {
+		fail("Failed to compare: %v", err)
+		return nil
+	}
+
+	fmt.Printf("🔀 %s is tracked in both stores\n\n", local.FileName)
+	printStoreSummary("Local ", local.OopsDirPath(), cmp.Local)
+	printStoreSummary("Global", global.OopsDirPath(), cmp.Global)
+	fmt.Println()
+
+	fmt.Printf("  Shared snapshots: %d\n", cmp.Shared)
+	printOnlyIn("local", cmp.OnlyLocal)
+	printOnlyIn("global", cmp.OnlyGlobal)
+	fmt.Println()
+
+	if cmp.LatestDiff == "" {
+		success("Latest snapshots are identical")
+	} else {
+		fmt.Println("🔍 Latest global → latest local:")
+		fmt.Println(cmp.LatestDiff)
+	}
+
+	keepName := "local"
+	if globalFlag {
+		keepName = "global"
+	}
+
+	fmt.Println()
+	if !compareYes {
+		fmt.Printf("Merge both histories into the %s store and stop %s tracking? [y/N]: ", keepName, otherStoreName(keepName))
+		reader := bufio.NewReader(os.Stdin)
+		response, err := reader.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		response = strings.TrimSpace(strings.ToLower(response))
+		if response != "y" && response != "yes" {
+			info("Cancelled")
+			return nil
+		}
+	}
+
+	return mergeDuplicateStores(local, global, keepName)
+}
+
+func printStoreSummary(label, location string, snaps []store.Snapshot) {
+	if len(snaps) == 0 {
+		fmt.Printf("  %s  %s  (no snapshots)\n", label, location)
+		return
+	}
+	latest := snaps[len(snaps)-1]
+	fmt.Printf("  %s  %d snapshot(s), latest #%d %q (%s)\n", label, len(snaps), latest.Number, latest.Message, formatTimeAgo(latest.Timestamp))
+	info("        %s", location)
+}
+
+func printOnlyIn(name string, snaps []store.Snapshot) {
+	if len(snaps) == 0 {
+		fmt.Printf("  Only in %s: none\n", name)
+		return
+	}
+	fmt.Printf("  Only in %s:\n", name)
+	for _, snap := range snaps {
+		fmt.Printf("    #%-3d  %-30s  %s\n", snap.Number, snap.Message, formatTimeAgo(snap.Timestamp))
+	}
+}
+
+func otherStoreName(name string) string {
+	if name == "local" {
+		return "global"
+	}
+	return "local"
+}
+
+func init() {
+	compareCmd.Flags().BoolVarP(&compareYes, "yes", "y", false, "Merge without confirmation")
+	rootCmd.AddCommand(compareCmd)
+}
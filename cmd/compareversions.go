@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var compareVersionsCmd = &cobra.Command{
+	Use:   "compare <N> <N> [N...]",
+	Short: "🔢 Compare several snapshots side by side",
+	Long: `Show how much each pair of snapshots differs, and how each differs from
+the working file, to help pick which one to restore.
+
+Text files are compared by lines changed, images by the share of pixels
+that differ, and documents by the lines of their text.
+
+Examples:
+  oops compare 2 3 4    Matrix of differences among #2, #3, #4 and now
+  oops back 3           Then restore the one you want`,
+	Args: cobra.MinimumNArgs(2),
+	RunE: runCompareVersions,
+}
+
+func runCompareVersions(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	var nums []int
+	for _, arg := range args {
+		num, err := strconv.Atoi(strings.TrimPrefix(arg, "#"))
+		if err != nil || num < 1 {
+			fail("Invalid snapshot number: %s", arg)
+			return nil
+		}
+		if !s.Repo.HasTag(fmt.Sprintf("v%d", num)) {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		nums = append(nums, num)
+	}
+
+	m, err := s.CompareVersions(nums, true)
+	if err != nil {
+		fail("Failed to compare: %v", err)
+		return nil
+	}
+
+	history, _ := s.History()
+	messages := map[int]store.Snapshot{}
+	for _, snap := range history {
+		messages[snap.Number] = snap
+	}
+
+	fmt.Printf("🔢 %s: differences in %s\n\n", s.FileName, m.Unit)
+
+	width := len(store.WorkFileLabel)
+	for _, label := range m.Labels {
+		width = max(width, len(label))
+	}
+	for _, row := range m.Diff {
+		for _, d := range row {
+			width = max(width, len(strconv.Itoa(d)))
+		}
+	}
+
+	fmt.Printf("  %*s", width, "")
+	for _, label := range m.Labels {
+		fmt.Printf("  %*s", width, label)
+	}
+	fmt.Println()
+	for i, label := range m.Labels {
+		fmt.Printf("  %*s", width, label)
+		for j, d := range m.Diff[i] {
+			cell := strconv.Itoa(d)
+			if i == j {
+				cell = "·"
+			}
+			fmt.Printf("  %*s", width, cell)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println()
+	for i, num := range nums {
+		snap := messages[num]
+		closest := m.Closest(i)
+		fmt.Printf("  #%-3d  %-30s  %-14s  closest: %s (%d)\n", num, snap.Message,
+			formatTimeAgo(snap.Timestamp), m.Labels[closest], m.Diff[i][closest])
+	}
+
+	now := len(m.Labels) - 1
+	closest := m.Closest(now)
+	if m.Diff[now][closest] == 0 {
+		info("The working file matches %s", m.Labels[closest])
+	} else {
+		info("The working file is closest to %s; restore with: oops back %s",
+			m.Labels[closest], strings.TrimPrefix(m.Labels[closest], "#"))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(compareVersionsCmd)
+}
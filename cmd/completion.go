@@ -0,0 +1,162 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var completionCmd = &cobra.Command{
+	Use:   "completion bash|zsh|fish|powershell",
+	Short: "⌨️  Print a shell completion script",
+	Long: `Print a script that completes oops commands, flags, snapshot numbers
+and tracked file names in your shell.
+
+Bash (needs the bash-completion package):
+  source <(oops completion bash)
+  oops completion bash > /etc/bash_completion.d/oops     # every session
+
+Zsh:
+  source <(oops completion zsh)
+  oops completion zsh > "${fpath[1]}/_oops"             # every session
+
+Fish:
+  oops completion fish > ~/.config/fish/completions/oops.fish
+
+PowerShell:
+  oops completion powershell | Out-String | Invoke-Expression
+  (add the line to $PROFILE for every session)
+
+Snapshot numbers are completed for back, changes, show, cat, drop and
+note, with each snapshot's message where the shell shows descriptions.
+Commands that take a tracked file complete the files tracked here.`,
+	ValidArgs: []string{"bash", "zsh", "fish", "powershell"},
+	Args:      cobra.MatchAll(cobra.ExactArgs(1), cobra.OnlyValidArgs),
+	RunE:      runCompletion,
+}
+
+func runCompletion(cmd *cobra.Command, args []string) error {
+	var err error
+	switch args[0] {
+	case "bash":
+		err = rootCmd.GenBashCompletionV2(os.Stdout, true)
+	case "zsh":
+		err = rootCmd.GenZshCompletion(os.Stdout)
+	case "fish":
+		err = rootCmd.GenFishCompletion(os.Stdout, true)
+	case "powershell":
+		err = rootCmd.GenPowerShellCompletionWithDesc(os.Stdout)
+	}
+	if err != nil {
+		fail("Failed to write the completion script: %v", err)
+	}
+	return nil
+}
+
+// completeSnapshots returns a completion for commands whose first n
+// arguments are snapshot numbers of the tracked file here
+func completeSnapshots(n int) func(*cobra.Command, []string, string) ([]string, cobra.ShellCompDirective) {
+	return func(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+		if len(args) >= n {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		s, err := completionStore()
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return snapshotNumbers(s), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+}
+
+// completeFileThenSnapshot completes a tracked file, then one of its
+// snapshot numbers
+func completeFileThenSnapshot(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	switch len(args) {
+	case 0:
+		return trackedFileNames(), cobra.ShellCompDirectiveNoFileComp
+	case 1:
+		s, err := findStoreForPath(args[0])
+		if err != nil {
+			return nil, cobra.ShellCompDirectiveNoFileComp
+		}
+		return snapshotNumbers(s), cobra.ShellCompDirectiveNoFileComp | cobra.ShellCompDirectiveKeepOrder
+	}
+	return nil, cobra.ShellCompDirectiveNoFileComp
+}
+
+// completeTrackedFile completes the first argument with a file tracked
+// here, leaving the rest to the shell
+func completeTrackedFile(cmd *cobra.Command, args []string, toComplete string) ([]string, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveDefault
+	}
+	return trackedFileNames(), cobra.ShellCompDirectiveNoFileComp
+}
+
+// completionStore finds the tracked file here like findTrackedStore,
+// without reporting recovered saves into the completion output
+func completionStore() (*store.Store, error) {
+	var s *store.Store
+	var err error
+	if globalFlag {
+		s, err = findGlobalTrackedStore()
+	} else {
+		s, err = findLocalTrackedStore()
+	}
+	if err == nil && profileFlag != "" {
+		s, err = openProfile(s, profileFlag)
+	}
+	return s, err
+}
+
+// snapshotNumbers returns the snapshot numbers of s, newest first, each
+// described by its message
+func snapshotNumbers(s *store.Store) []string {
+	history, err := s.History()
+	if err != nil {
+		return nil
+	}
+	var nums []string
+	for _, snap := range history {
+		if snap.Number == 0 {
+			continue
+		}
+		nums = append(nums, fmt.Sprintf("%d\t%s", snap.Number, snap.Message))
+	}
+	return nums
+}
+
+// trackedFileNames returns the names of the files tracked in the current
+// directory: globally with -g, otherwise locally or globally
+func trackedFileNames() []string {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	seen := map[string]bool{}
+	var names []string
+	add := func(s *store.Store) {
+		if !seen[s.FileName] {
+			seen[s.FileName] = true
+			names = append(names, s.FileName)
+		}
+	}
+	if !globalFlag {
+		for _, s := range localStores(cwd) {
+			add(s)
+		}
+	}
+	for _, s := range globalStores() {
+		if filepath.Dir(s.FilePath) == cwd {
+			add(s)
+		}
+	}
+	return names
+}
+
+func init() {
+	rootCmd.AddCommand(completionCmd)
+}
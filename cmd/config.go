@@ -2,8 +2,13 @@ package cmd
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/redact"
+	"github.com/iyulab/oops/internal/usage"
 	"github.com/spf13/cobra"
 )
 
@@ -17,14 +22,72 @@ Configuration is stored in ~/.oops/config
 Examples:
   oops config                    Show current config
   oops config --default-global   Set global as default mode
-  oops config --default-local    Set local as default mode`,
+  oops config --default-local    Set local as default mode
+  oops config --max-snapshot-size 500MB  Cap snapshot size (0 for no limit)
+  oops config --async-save-size 200MB    Save files this large in the background
+  oops config --max-diff-size 64MB       Diff files line by line up to 64MB
+  oops config --redact 'AKIA[0-9A-Z]{16}'         Mask AWS access keys in snapshots
+  oops config --redact '(?i)password\s*=\s*(\S+)'  Mask only the captured value
+  oops config --unredact 'AKIA[0-9A-Z]{16}'       Stop masking a pattern
+  oops config --eol native       Store LF, restore platform line endings
+  oops config --on-dirty back=backup  Save unsaved changes before 'back'
+  oops config --global-layout content  Let global histories follow moved files
+  oops config --usage-stats on   Count commands and snapshots locally
+  oops config --restore-mtime on  Restore the modification time files had when saved
+  oops config --stale-after 8h   Warn about unsaved work once the last save is 8h old
+
+Redacted text is replaced with [REDACTED] in snapshots; the working file
+keeps the original. Restoring a snapshot writes the masked text.
+
+--eol sets how line endings are handled:
+  off     Store and restore content unchanged (default)
+  lf      Store LF endings and restore them as LF
+  native  Store LF endings and restore CRLF on Windows, LF elsewhere
+With lf or native, a file that only changed line endings has no changes.
+
+--global-layout sets how new global stores are found:
+  path     By a hash of the file's path (default)
+  content  Through an index of paths, so 'oops start -g' on a file that
+           was moved or copied picks up the history it had before
+Existing stores are not changed.
+
+--usage-stats on keeps counts of the commands you run and the snapshots
+they make in ~/.oops/usage.json, shown by 'oops summary --usage'. They
+never leave this machine. Turning it off deletes the file.
+
+Restores always give a file the permissions it had when the snapshot was
+saved, so scripts keep their execute bit. --restore-mtime on also sets
+its modification time back, and --restore-owner on its owner and group
+(which needs the rights to change them, e.g. root).
+
+--stale-after sets when now, files and the interactive menu warn that a
+file with unsaved changes has gone too long without a snapshot (default
+24h, 0 to never).
+
+--on-dirty sets what back and oops! do with unsaved changes:
+  block    Refuse, asking you to save first (default for back)
+  backup   Save them as a snapshot, then continue
+  discard  Overwrite them (default for oops!)
+'back -f' always discards.`,
 	Args: cobra.NoArgs,
 	RunE: runConfig,
 }
 
 var (
-	setDefaultGlobal bool
-	setDefaultLocal  bool
+	setDefaultGlobal   bool
+	setDefaultLocal    bool
+	setMaxSnapshotSize string
+	setAsyncSaveSize   string
+	setMaxDiffSize     string
+	addRedact          []string
+	removeRedact       []string
+	setEOL             string
+	setOnDirty         []string
+	setGlobalLayout    string
+	setUsageStats      string
+	setRestoreMtime    string
+	setRestoreOwner    string
+	setStaleAfter      string
 )
 
 func runConfig(cmd *cobra.Command, args []string) error {
@@ -34,6 +97,129 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if len(addRedact) > 0 || len(removeRedact) > 0 {
+		return runConfigRedact(cfg)
+	}
+
+	if setMaxSnapshotSize != "" {
+		size, err := config.ParseSize(setMaxSnapshotSize)
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cfg.MaxSnapshotSize = size
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		if size == 0 {
+			success("Snapshot size limit removed")
+		} else {
+			success("Snapshot size limit set to: %s", config.FormatSize(size))
+		}
+		return nil
+	}
+
+	if setAsyncSaveSize != "" {
+		size, err := config.ParseSize(setAsyncSaveSize)
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cfg.AsyncSaveSize = size
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		if size == 0 {
+			success("Saves always commit before returning")
+		} else {
+			success("Files of %s or more are saved in the background", config.FormatSize(size))
+		}
+		return nil
+	}
+
+	if setMaxDiffSize != "" {
+		size, err := config.ParseSize(setMaxDiffSize)
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cfg.MaxDiffSize = size
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		if size == 0 {
+			success("Files of any size are diffed line by line")
+		} else {
+			success("Files over %s are summarized instead of diffed", config.FormatSize(size))
+		}
+		return nil
+	}
+
+	if len(setOnDirty) > 0 {
+		return runConfigOnDirty(cfg)
+	}
+
+	if setGlobalLayout != "" {
+		if err := config.ValidateLayout(setGlobalLayout); err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cfg.GlobalLayout = setGlobalLayout
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		success("Global layout set to: %s", setGlobalLayout)
+		if setGlobalLayout == config.LayoutContent {
+			info("'oops start -g' now picks up the history of moved or copied files")
+		}
+		return nil
+	}
+
+	if setUsageStats != "" {
+		return runConfigUsageStats(cfg)
+	}
+
+	if setRestoreMtime != "" || setRestoreOwner != "" {
+		return runConfigRestore(cfg)
+	}
+
+	if setStaleAfter != "" {
+		d, err := config.ParseDuration(setStaleAfter)
+		if err != nil || d < 0 {
+			fail("Invalid --stale-after %q: use a duration such as 24h or 2d", setStaleAfter)
+			return nil
+		}
+		cfg.StaleAfter = d
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		if d == 0 {
+			success("Unsaved changes are never reported as stale")
+		} else {
+			success("Unsaved changes are reported once the last save is %s old", config.FormatDuration(d))
+		}
+		return nil
+	}
+
+	if setEOL != "" {
+		if err := eol.Validate(setEOL); err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cfg.EOL = setEOL
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		success("Line ending mode set to: %s", setEOL)
+		return nil
+	}
+
 	// Handle set operations
 	if setDefaultGlobal || setDefaultLocal {
 		if setDefaultGlobal {
@@ -73,11 +259,260 @@ func runConfig(cmd *cobra.Command, args []string) error {
 		info("Use -g/--global to override")
 	}
 
+	fmt.Println()
+	fmt.Printf("  compact = %v\n", cfg.Compact)
+	if cfg.CompactKeepDaily > 0 {
+		info("When enabled, gc keeps every snapshot for %s, hourly ones up to %s, daily up to %s, then weekly",
+			config.FormatDuration(cfg.CompactKeepAll), config.FormatDuration(cfg.CompactKeepHourly),
+			config.FormatDuration(cfg.CompactKeepDaily))
+	} else {
+		info("When enabled, gc keeps every snapshot for %s, hourly ones up to %s, then daily",
+			config.FormatDuration(cfg.CompactKeepAll), config.FormatDuration(cfg.CompactKeepHourly))
+	}
+
+	fmt.Println()
+	fmt.Printf("  prune_keep_last = %d\n", cfg.PruneKeepLast)
+	fmt.Printf("  prune_older_than = %s\n", config.FormatDuration(cfg.PruneOlderThan))
+	fmt.Printf("  auto_prune = %v\n", cfg.AutoPrune)
+	if cfg.AutoPrune {
+		info("Watch and scheduled snapshots apply these limits (and compaction, if on) as they are saved")
+	} else {
+		info("'oops prune' applies these limits; set auto_prune=true to apply them as files are auto-saved")
+	}
+
+	fmt.Println()
+	fmt.Printf("  max_snapshot_size = %s\n", config.FormatSize(cfg.MaxSnapshotSize))
+	if cfg.MaxSnapshotSize > 0 {
+		info("Larger snapshots are compressed harder, or refused if still too large")
+	} else {
+		info("Snapshots of any size are stored")
+	}
+
+	fmt.Println()
+	fmt.Printf("  max_diff_size = %s\n", config.FormatSize(cfg.MaxDiffSize))
+	if cfg.MaxDiffSize > 0 {
+		info("Changes of larger files, and of binary files, are summarized in one line")
+	} else {
+		info("Text files of any size are diffed line by line")
+	}
+
+	fmt.Println()
+	fmt.Printf("  async_save_size = %s\n", config.FormatSize(cfg.AsyncSaveSize))
+	if cfg.AsyncSaveSize > 0 {
+		info("Saves of files this large are committed in the background")
+	} else {
+		info("Saves commit before returning; use save --async to defer one")
+	}
+
+	fmt.Println()
+	eolMode := cfg.EOL
+	if eolMode == "" {
+		eolMode = eol.ModeOff
+	}
+	fmt.Printf("  eol = %s\n", eolMode)
+	switch eolMode {
+	case eol.ModeLF:
+		info("CRLF line endings are stored as LF")
+	case eol.ModeNative:
+		info("Line endings are stored as LF and restored in the platform's style")
+	default:
+		info("Line endings are stored unchanged; use --eol lf or native to normalize")
+	}
+
+	fmt.Println()
+	fmt.Printf("  global_layout = %s\n", cfg.GlobalLayout)
+	if cfg.GlobalLayout == config.LayoutContent {
+		info("New global stores are indexed by path; moved and copied files find their history")
+	} else {
+		info("New global stores are keyed by a hash of the file's path")
+	}
+
+	fmt.Println()
+	fmt.Printf("  usage_stats = %v\n", cfg.UsageStats)
+	if cfg.UsageStats {
+		info("Commands and snapshots are counted locally; see 'oops summary --usage'")
+	} else {
+		info("Nothing is counted; use --usage-stats on to keep local counts")
+	}
+
+	fmt.Println()
+	fmt.Printf("  restore_mtime = %v\n", cfg.RestoreMtime)
+	fmt.Printf("  restore_owner = %v\n", cfg.RestoreOwner)
+	info("Restores set the file's permissions as saved; these add its modification time and owner")
+
+	fmt.Println()
+	fmt.Printf("  stale_after = %s\n", config.FormatDuration(cfg.StaleAfter))
+	if cfg.StaleAfter > 0 {
+		info("now, files and the menu warn about unsaved changes once the last save is this old")
+	} else {
+		info("Unsaved changes are never reported as stale")
+	}
+
+	fmt.Println()
+	for _, command := range []string{"back", "oops"} {
+		fmt.Printf("  %s.on_dirty = %s\n", command, cfg.OnDirtyPolicy(command))
+	}
+	info("What back and oops! do with unsaved changes: block, backup or discard")
+
+	fmt.Println()
+	if len(cfg.Redact) == 0 {
+		fmt.Println("  redact = (none)")
+	}
+	for _, pattern := range cfg.Redact {
+		fmt.Printf("  redact = %s\n", pattern)
+	}
+	info("Matching text is stored as %s; use --redact to add a pattern", redact.Mask)
+
+	if cfg.EventsFile != "" {
+		fmt.Println()
+		fmt.Printf("  events_file = %s\n", cfg.EventsFile)
+		info("Snapshot and restore events are appended here as JSON lines")
+	}
+
+	return nil
+}
+
+// runConfigRestore sets which file details restores bring back besides
+// the permissions
+func runConfigRestore(cfg *config.Config) error {
+	for _, setting := range []struct {
+		flag, value string
+		target      *bool
+	}{
+		{"--restore-mtime", setRestoreMtime, &cfg.RestoreMtime},
+		{"--restore-owner", setRestoreOwner, &cfg.RestoreOwner},
+	} {
+		switch strings.ToLower(setting.value) {
+		case "":
+		case "on", "true":
+			*setting.target = true
+		case "off", "false":
+			*setting.target = false
+		default:
+			fail("Invalid %s %q: use on or off", setting.flag, setting.value)
+			return nil
+		}
+	}
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+	success("Restores set the modification time: %v, the owner: %v", cfg.RestoreMtime, cfg.RestoreOwner)
+	return nil
+}
+
+// runConfigUsageStats turns local usage counting on or off. Turning it
+// off also deletes the counts.
+func runConfigUsageStats(cfg *config.Config) error {
+	switch strings.ToLower(setUsageStats) {
+	case "on", "true":
+		cfg.UsageStats = true
+	case "off", "false":
+		cfg.UsageStats = false
+	default:
+		fail("Invalid --usage-stats %q: use on or off", setUsageStats)
+		return nil
+	}
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+
+	path, err := usage.Path()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if cfg.UsageStats {
+		success("Usage stats on")
+		info("Counts are kept in %s and never sent anywhere", path)
+		info("Use 'oops summary --usage' to see them")
+		return nil
+	}
+	if err := usage.Reset(path); err != nil {
+		fail("Failed to delete %s: %v", path, err)
+		return nil
+	}
+	success("Usage stats off; recorded counts deleted")
+	return nil
+}
+
+// runConfigOnDirty sets on_dirty policies given as command=policy
+func runConfigOnDirty(cfg *config.Config) error {
+	for _, setting := range setOnDirty {
+		command, value, ok := strings.Cut(setting, "=")
+		command = strings.TrimSuffix(strings.TrimSpace(command), "!")
+		if _, known := config.DirtyCommands[command]; !ok || !known {
+			fail("Invalid --on-dirty %q: use back=<policy> or oops=<policy>", setting)
+			return nil
+		}
+		policy, err := config.ParseDirtyPolicy(strings.TrimSpace(value))
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		if cfg.OnDirty == nil {
+			cfg.OnDirty = map[string]config.DirtyPolicy{}
+		}
+		cfg.OnDirty[command] = policy
+	}
+
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+	for _, command := range []string{"back", "oops"} {
+		if p, ok := cfg.OnDirty[command]; ok {
+			success("%s.on_dirty set to: %s", command, p)
+		}
+	}
+	return nil
+}
+
+// runConfigRedact adds and removes redaction patterns
+func runConfigRedact(cfg *config.Config) error {
+	if _, err := redact.New(addRedact); err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	for _, pattern := range addRedact {
+		if !slices.Contains(cfg.Redact, pattern) {
+			cfg.Redact = append(cfg.Redact, pattern)
+		}
+	}
+	for _, pattern := range removeRedact {
+		i := slices.Index(cfg.Redact, pattern)
+		if i < 0 {
+			warn("Not a redact pattern: %s", pattern)
+			continue
+		}
+		cfg.Redact = slices.Delete(cfg.Redact, i, i+1)
+	}
+
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+	success("%d redact %s configured", len(cfg.Redact), plural(len(cfg.Redact), "pattern"))
+	info("Snapshots saved from now on are masked; existing snapshots are unchanged")
 	return nil
 }
 
 func init() {
 	configCmd.Flags().BoolVar(&setDefaultGlobal, "default-global", false, "Set global as default storage mode")
 	configCmd.Flags().BoolVar(&setDefaultLocal, "default-local", false, "Set local as default storage mode")
+	configCmd.Flags().StringVar(&setMaxSnapshotSize, "max-snapshot-size", "", "Set the snapshot size limit, e.g. 100MB (0 for no limit)")
+	configCmd.Flags().StringVar(&setMaxDiffSize, "max-diff-size", "", "Summarize changes of files larger than this instead of diffing them, e.g. 16MB (0 for no limit)")
+	configCmd.Flags().StringVar(&setAsyncSaveSize, "async-save-size", "", "Save files of this size or more in the background, e.g. 200MB (0 to never)")
+	configCmd.Flags().StringArrayVar(&addRedact, "redact", nil, "Mask matches of this regular expression in snapshots (repeatable)")
+	configCmd.Flags().StringArrayVar(&removeRedact, "unredact", nil, "Remove a redact pattern (repeatable)")
+	configCmd.Flags().StringVar(&setEOL, "eol", "", "Set line ending handling: off, lf or native")
+	configCmd.Flags().StringVar(&setGlobalLayout, "global-layout", "", "Set how new global stores are keyed: path or content")
+	configCmd.Flags().StringVar(&setUsageStats, "usage-stats", "", "Count commands and snapshots locally: on or off")
+	configCmd.Flags().StringVar(&setRestoreMtime, "restore-mtime", "", "Restore the modification time a file had when saved: on or off")
+	configCmd.Flags().StringVar(&setRestoreOwner, "restore-owner", "", "Restore the owner a file had when saved: on or off")
+	configCmd.Flags().StringVar(&setStaleAfter, "stale-after", "", "Warn about unsaved changes once the last save is this old, e.g. 24h (0 to never)")
+	configCmd.Flags().StringArrayVar(&setOnDirty, "on-dirty", nil, "Set what back/oops do with unsaved changes, e.g. back=backup (block, backup, discard)")
 	rootCmd.AddCommand(configCmd)
 }
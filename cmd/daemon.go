@@ -0,0 +1,299 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/daemon"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/iyulab/oops/internal/schedule"
+	"github.com/iyulab/oops/internal/updater"
+	"github.com/iyulab/oops/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	daemonLogLines  int
+	daemonLogFollow bool
+)
+
+var daemonCmd = &cobra.Command{
+	Use:   "daemon",
+	Short: "👻 Manage the background daemon",
+	Long: `Run oops background features in one managed process.
+
+The daemon is controlled through a socket in ~/.oops/ and logs to
+~/.oops/daemon.log. It runs the snapshot schedules ('oops schedule'),
+watches the files handed to it with 'oops watch --daemon', checks for
+updates and retries failed automatic pushes.
+
+Examples:
+  oops daemon start    Start the daemon in the background
+  oops daemon status   Show whether it is running and what it does
+  oops daemon logs -f  Follow the daemon log
+  oops daemon events   Stream snapshot and restore events as JSON
+  oops daemon stop     Stop the daemon`,
+}
+
+var daemonStartCmd = &cobra.Command{
+	Use:   "start",
+	Short: "Start the daemon in the background",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStart,
+}
+
+var daemonStopCmd = &cobra.Command{
+	Use:   "stop",
+	Short: "Stop the daemon",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStop,
+}
+
+var daemonStatusCmd = &cobra.Command{
+	Use:   "status",
+	Short: "Show daemon status",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonStatus,
+}
+
+var daemonLogsCmd = &cobra.Command{
+	Use:   "logs",
+	Short: "Show the daemon log",
+	Args:  cobra.NoArgs,
+	RunE:  runDaemonLogs,
+}
+
+var daemonRunCmd = &cobra.Command{
+	Use:    "run",
+	Short:  "Run the daemon in the foreground",
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runDaemonForeground,
+}
+
+func runDaemonStart(cmd *cobra.Command, args []string) error {
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	if daemon.Running(socketPath) {
+		info("Daemon is already running")
+		return nil
+	}
+
+	pid, err := daemon.Spawn(socketPath, "daemon", "run")
+	if err != nil {
+		fail("Failed to start daemon: %v", err)
+		return nil
+	}
+
+	success("Daemon started (pid %d)", pid)
+	return nil
+}
+
+func runDaemonStop(cmd *cobra.Command, args []string) error {
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	if err := daemon.RequestStop(socketPath); err != nil {
+		if err == daemon.ErrNotRunning {
+			info("Daemon is not running")
+			return nil
+		}
+		fail("Failed to stop daemon: %v", err)
+		return nil
+	}
+
+	success("Daemon stopped")
+	return nil
+}
+
+func runDaemonStatus(cmd *cobra.Command, args []string) error {
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	status, err := daemon.GetStatus(socketPath)
+	if err != nil {
+		if err == daemon.ErrNotRunning {
+			if pid := daemon.ReadPID(socketPath); pid != 0 {
+				warn("The daemon (pid %d) exited without shutting down; see 'oops daemon logs'", pid)
+				os.Remove(daemon.PIDPath(socketPath))
+			}
+			info("Daemon is not running")
+			info("Use 'oops daemon start' to start it")
+			return nil
+		}
+		fail("Failed to get status: %v", err)
+		return nil
+	}
+
+	fmt.Printf("👻 Daemon:   running (pid %d)\n", status.PID)
+	fmt.Printf("⏱  Uptime:   %s\n", time.Since(status.StartedAt).Round(time.Second))
+	fmt.Printf("🔌 Socket:   %s\n", socketPath)
+	if cfg, err := config.Load(); err == nil {
+		scope := "no default"
+		if cfg.ScheduleAll != "" {
+			scope = cfg.ScheduleAll + " for all tracked files"
+		}
+		fmt.Printf("⏰ Schedule: %s, %d per-file\n", scope, len(cfg.Schedules))
+		fmt.Printf("👁  Watch:    %d %s\n", len(cfg.Watches), plural(len(cfg.Watches), "file"))
+	}
+	if len(status.Services) > 0 {
+		fmt.Println()
+		fmt.Println("Services:")
+		for _, s := range status.Services {
+			fmt.Printf("  %-16s %s\n", s.Name, s.State)
+		}
+	}
+	return nil
+}
+
+func runDaemonLogs(cmd *cobra.Command, args []string) error {
+	logPath, err := daemon.LogPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	f, err := os.Open(logPath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			info("No daemon log yet")
+			return nil
+		}
+		fail("Error: %v", err)
+		return nil
+	}
+	defer f.Close()
+
+	content, err := io.ReadAll(f)
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(content), "\n")
+	if len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	if daemonLogLines > 0 && len(lines) > daemonLogLines {
+		lines = lines[len(lines)-daemonLogLines:]
+	}
+	fmt.Print(strings.Join(lines, ""))
+
+	if !daemonLogFollow {
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	for {
+		if _, err := io.Copy(os.Stdout, f); err != nil {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+func runDaemonForeground(cmd *cobra.Command, args []string) error {
+	logPath, err := daemon.LogPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(logPath), 0755); err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	logFile, err := os.OpenFile(logPath, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		fail("Failed to open log: %v", err)
+		return nil
+	}
+	defer logFile.Close()
+
+	d, err := daemon.New(daemon.Options{LogWriter: io.MultiWriter(logFile, os.Stderr)})
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	for _, s := range daemonServices() {
+		d.AddService(s)
+	}
+	events.Subscribe(func(e events.Event) {
+		if data, err := json.Marshal(e); err == nil {
+			d.Publish(data)
+		}
+	})
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := d.Run(ctx); err != nil {
+		fail("%v", err)
+	}
+	return nil
+}
+
+// daemonServices returns the background services the daemon runs
+func daemonServices() []daemon.Service {
+	return []daemon.Service{
+		schedule.Service{},
+		watch.Service{},
+		&updateCheckService{interval: 24 * time.Hour},
+		&autoPushService{interval: 5 * time.Minute},
+	}
+}
+
+// updateCheckService periodically logs when a newer oops release exists
+type updateCheckService struct {
+	interval time.Duration
+}
+
+func (s *updateCheckService) Name() string { return "update-check" }
+
+func (s *updateCheckService) Run(ctx context.Context, logger *log.Logger) error {
+	for {
+		release, hasUpdate, err := updater.CheckForUpdate(Version)
+		switch {
+		case err != nil:
+			logger.Printf("[%s] %v", s.Name(), err)
+		case hasUpdate:
+			logger.Printf("[%s] %s is available (current v%s); run 'oops update'", s.Name(), release.TagName, Version)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(s.interval):
+		}
+	}
+}
+
+func init() {
+	daemonLogsCmd.Flags().IntVarP(&daemonLogLines, "lines", "n", 50, "Number of lines to show")
+	daemonLogsCmd.Flags().BoolVarP(&daemonLogFollow, "follow", "f", false, "Keep printing new log lines")
+	daemonCmd.AddCommand(daemonStartCmd, daemonStopCmd, daemonStatusCmd, daemonLogsCmd, daemonRunCmd)
+	rootCmd.AddCommand(daemonCmd)
+}
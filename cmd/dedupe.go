@@ -0,0 +1,69 @@
+package cmd
+
+import (
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var dedupeKeep string
+
+var dedupeCmd = &cobra.Command{
+	Use:   "dedupe-tracking [file]",
+	Short: "🔗 Merge duplicate local and global stores",
+	Long: `Merge the local and global histories of a file into one store.
+
+Snapshots missing from the kept store are imported from the other one,
+interleaved by time and renumbered. The redundant store is then removed.
+
+Examples:
+  oops dedupe-tracking --keep local            Keep .oops/, remove ~/.oops/ copy
+  oops dedupe-tracking --keep global notes.md  Keep ~/.oops/ for notes.md`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runDedupe,
+}
+
+func runDedupe(cmd *cobra.Command, args []string) error {
+	if dedupeKeep != "local" && dedupeKeep != "global" {
+		fail("--keep must be 'local' or 'global'")
+		return nil
+	}
+
+	local, global, err := findDuplicateStores(args)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	return mergeDuplicateStores(local, global, dedupeKeep)
+}
+
+// mergeDuplicateStores merges both histories into the store named by
+// keepName and removes the other one
+func mergeDuplicateStores(local, global *store.Store, keepName string) error {
+	keep, other := local, global
+	if keepName == "global" {
+		keep, other = global, local
+	}
+
+	imported, err := store.MergeHistories(keep, other)
+	if err != nil {
+		fail("Failed to merge: %v", err)
+		return nil
+	}
+
+	if err := other.Delete(); err != nil {
+		fail("Merged %d snapshot(s) but failed to remove %s store: %v", imported, otherStoreName(keepName), err)
+		return nil
+	}
+
+	latest, _ := keep.GetLatestVersion()
+	success("Merged %d snapshot(s) into the %s store (%d snapshots total)", imported, keepName, latest)
+	info("Stopped %s tracking of '%s'", otherStoreName(keepName), keep.FileName)
+	return nil
+}
+
+func init() {
+	dedupeCmd.Flags().StringVar(&dedupeKeep, "keep", "", "Store to keep: local or global")
+	dedupeCmd.MarkFlagRequired("keep")
+	rootCmd.AddCommand(dedupeCmd)
+}
@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	diffToolOutput string
+	diffToolEditor string
+)
+
+var diffToolCmd = &cobra.Command{
+	Use:   "diff-tool",
+	Short: "🧩 Show oops history inside your editor",
+	Long: `Let editors list a file's snapshots and compare it with one of them.
+
+'oops diff-tool install' prints a ready-to-use setup for an editor; the
+log, show and open commands are what that setup runs, and take the file
+path so they work from any directory.
+
+Editors:
+  vim      :OopsHistory lists snapshots, Enter on one (or :OopsDiff N)
+           opens it in a diff split. Also works in Neovim.
+  vscode   Tasks to list snapshots and open one in VS Code's diff editor.
+           The timeline view only takes history from extensions, so the
+           tasks are run with 'Tasks: Run Task' instead.
+
+Examples:
+  oops diff-tool install vim -o ~/.vim/plugin/oops.vim
+  oops diff-tool install vim -o ~/.config/nvim/plugin/oops.vim
+  oops diff-tool install vscode -o .vscode/tasks.json
+  oops diff-tool log notes.md       One line per snapshot, tab-separated
+  oops diff-tool open notes.md 3    Compare notes.md with snapshot #3 in VS Code`,
+}
+
+var diffToolInstallCmd = &cobra.Command{
+	Use:       "install <vim|vscode>",
+	Short:     "Print or write an editor setup",
+	Args:      cobra.ExactArgs(1),
+	ValidArgs: []string{"vim", "vscode"},
+	RunE:      runDiffToolInstall,
+}
+
+var diffToolLogCmd = &cobra.Command{
+	Use:               "log <file>",
+	Short:             "List snapshots as number, time and message separated by tabs",
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runDiffToolLog,
+}
+
+var diffToolShowCmd = &cobra.Command{
+	Use:               "show <file> <version>",
+	Short:             "Print a snapshot of a file",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFileThenSnapshot,
+	RunE:              runDiffToolShow,
+}
+
+var diffToolOpenCmd = &cobra.Command{
+	Use:               "open <file> <version>",
+	Short:             "Compare a file with a snapshot in a diff editor",
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeFileThenSnapshot,
+	RunE:              runDiffToolOpen,
+}
+
+// editorSetups holds the setup install writes for each editor
+var editorSetups = map[string]string{
+	"vim":    vimSetup,
+	"vscode": vscodeSetup,
+}
+
+func runDiffToolInstall(cmd *cobra.Command, args []string) error {
+	setup, ok := editorSetups[args[0]]
+	if !ok {
+		fail("Unknown editor: %s (use vim or vscode)", args[0])
+		return nil
+	}
+	if diffToolOutput == "" {
+		fmt.Print(setup)
+		return nil
+	}
+
+	if _, err := os.Stat(diffToolOutput); err == nil {
+		fail("%s already exists", diffToolOutput)
+		info("Print the setup with 'oops diff-tool install %s' and merge it by hand", args[0])
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(diffToolOutput), 0755); err != nil {
+		fail("Failed to create %s: %v", filepath.Dir(diffToolOutput), err)
+		return nil
+	}
+	if err := os.WriteFile(diffToolOutput, []byte(setup), 0644); err != nil {
+		fail("Failed to write %s: %v", diffToolOutput, err)
+		return nil
+	}
+	success("Wrote the %s setup to %s", args[0], diffToolOutput)
+	return nil
+}
+
+func runDiffToolLog(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	snapshots, err := s.History()
+	if err != nil {
+		fail("Failed to get history: %v", err)
+		return nil
+	}
+	for _, snap := range snapshots {
+		if snap.Number == 0 {
+			continue
+		}
+		message := strings.Join(strings.Fields(snap.Message), " ")
+		fmt.Printf("%d\t%s\t%s\n", snap.Number, snap.Timestamp.Format("2006-01-02 15:04:05"), message)
+	}
+	return nil
+}
+
+func runDiffToolShow(cmd *cobra.Command, args []string) error {
+	_, content, ok := diffToolSnapshot(args)
+	if !ok {
+		return nil
+	}
+	os.Stdout.Write(content)
+	return nil
+}
+
+func runDiffToolOpen(cmd *cobra.Command, args []string) error {
+	s, content, ok := diffToolSnapshot(args)
+	if !ok {
+		return nil
+	}
+
+	// Keep the extension so the editor highlights the old version too
+	ext := filepath.Ext(s.FileName)
+	base := strings.TrimSuffix(s.FileName, ext)
+	dir, err := os.MkdirTemp("", "oops-diff-")
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	old := filepath.Join(dir, fmt.Sprintf("%s.v%s%s", base, args[1], ext))
+	if err := os.WriteFile(old, content, 0444); err != nil {
+		fail("Failed to write %s: %v", old, err)
+		return nil
+	}
+
+	run := exec.Command(diffToolEditor, "--diff", old, s.FilePath)
+	run.Stdout, run.Stderr = os.Stdout, os.Stderr
+	if err := run.Start(); err != nil {
+		fail("Failed to run %s: %v", diffToolEditor, err)
+		info("Use --editor to name the editor's command")
+		return nil
+	}
+	return nil
+}
+
+// diffToolSnapshot reads the snapshot named by the file and version
+// arguments, reporting failures
+func diffToolSnapshot(args []string) (*store.Store, []byte, bool) {
+	num, err := strconv.Atoi(args[1])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[1])
+		return nil, nil, false
+	}
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil, nil, false
+	}
+	content, err := s.Content(num)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			return nil, nil, false
+		}
+		fail("Failed to read snapshot #%d: %v", num, err)
+		return nil, nil, false
+	}
+	return s, content, true
+}
+
+const vimSetup = `" oops history for Vim and Neovim, written by 'oops diff-tool install vim'
+"   :OopsHistory   list the snapshots of the current file; Enter on one
+"                  compares it with the file in a diff split
+"   :OopsDiff N    compare the file with snapshot #N
+
+command! OopsHistory call s:OopsHistory(expand('%:p'))
+command! -nargs=1 OopsDiff call s:OopsDiff(expand('%:p'), <f-args>)
+
+function! s:OopsHistory(file) abort
+  let l:lines = systemlist('oops diff-tool log ' . shellescape(a:file))
+  if v:shell_error
+    echoerr join(l:lines, ' ')
+    return
+  endif
+  botright new
+  setlocal buftype=nofile bufhidden=wipe noswapfile nowrap
+  let b:oops_file = a:file
+  call setline(1, map(l:lines, {_, l -> '#' . substitute(l, '\t', '  ', 'g')}))
+  setlocal nomodifiable
+  nnoremap <buffer> <silent> <CR> :call <SID>OopsDiffLine()<CR>
+  nnoremap <buffer> <silent> q :close<CR>
+endfunction
+
+function! s:OopsDiffLine() abort
+  let l:num = matchstr(getline('.'), '^#\zs\d\+')
+  if l:num ==# ''
+    return
+  endif
+  let l:file = b:oops_file
+  close
+  execute 'drop ' . fnameescape(l:file)
+  call s:OopsDiff(l:file, l:num)
+endfunction
+
+function! s:OopsDiff(file, num) abort
+  let l:lines = systemlist('oops diff-tool show ' . shellescape(a:file) . ' ' . shellescape(a:num))
+  if v:shell_error
+    echoerr join(l:lines, ' ')
+    return
+  endif
+  let l:filetype = &filetype
+  diffthis
+  vertical leftabove new
+  setlocal buftype=nofile bufhidden=wipe noswapfile
+  call setline(1, l:lines)
+  let &l:filetype = l:filetype
+  execute 'file ' . fnameescape(fnamemodify(a:file, ':t') . ' @ #' . a:num)
+  setlocal nomodifiable
+  diffthis
+endfunction
+`
+
+const vscodeSetup = `{
+  // oops history for VS Code, written by 'oops diff-tool install vscode'.
+  // Run these with 'Tasks: Run Task' while the file is open.
+  "version": "2.0.0",
+  "tasks": [
+    {
+      "label": "oops: history of this file",
+      "type": "process",
+      "command": "oops",
+      "args": ["diff-tool", "log", "${file}"],
+      "presentation": { "reveal": "always", "clear": true },
+      "problemMatcher": []
+    },
+    {
+      "label": "oops: compare with snapshot",
+      "type": "process",
+      "command": "oops",
+      "args": ["diff-tool", "open", "${file}", "${input:oopsSnapshot}"],
+      "presentation": { "reveal": "silent" },
+      "problemMatcher": []
+    }
+  ],
+  "inputs": [
+    {
+      "id": "oopsSnapshot",
+      "type": "promptString",
+      "description": "Snapshot number (see 'oops: history of this file')"
+    }
+  ]
+}
+`
+
+func init() {
+	diffToolInstallCmd.Flags().StringVarP(&diffToolOutput, "output", "o", "", "Write the setup to this file instead of printing it")
+	diffToolOpenCmd.Flags().StringVar(&diffToolEditor, "editor", "code", "Editor command that takes --diff <old> <new>")
+	diffToolCmd.AddCommand(diffToolInstallCmd, diffToolLogCmd, diffToolShowCmd, diffToolOpenCmd)
+	rootCmd.AddCommand(diffToolCmd)
+}
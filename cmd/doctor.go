@@ -0,0 +1,104 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"runtime"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/netfs"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/usage"
+	"github.com/spf13/cobra"
+)
+
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "🩺 Check the environment oops runs in",
+	Long: `Show where oops keeps its files and which filesystems they are on,
+and check the history of the file tracked here, if any.
+
+On network filesystems (NFS, SMB/CIFS and similar) oops works in a
+degraded mode: metadata is flushed to the server as it is written, locks
+are taken by hard link and judged stale only after 30 minutes, and watch
+checks for changes every 2 seconds. Saves and restores are slower, since
+every file access is a round trip to the server.
+
+Examples:
+  oops doctor       Check the current directory
+  oops doctor -g    Check the globally tracked file for this directory`,
+	Args: cobra.NoArgs,
+	RunE: runDoctor,
+}
+
+func runDoctor(cmd *cobra.Command, args []string) error {
+	fmt.Printf("🩺 oops %s (%s/%s)\n\n", Version, runtime.GOOS, runtime.GOARCH)
+
+	if path, err := config.GetConfigPath(); err == nil {
+		if _, err := os.Stat(path); err == nil {
+			fmt.Printf("  %-16s %s\n", "Config:", path)
+		} else {
+			fmt.Printf("  %-16s %s (not created, using defaults)\n", "Config:", path)
+		}
+	}
+
+	if path, err := usage.Path(); err == nil {
+		if cfg, err := config.Load(); err == nil && cfg.UsageStats {
+			fmt.Printf("  %-16s on, kept in %s\n", "Usage stats:", path)
+		} else {
+			fmt.Printf("  %-16s off\n", "Usage stats:")
+		}
+	}
+
+	network := false
+	show := func(label, path string) {
+		fs := netfs.Detect(path)
+		network = network || fs.Network
+		fmt.Printf("  %-16s %s (%s)\n", label, path, describeFS(fs))
+	}
+
+	if cwd, err := os.Getwd(); err == nil {
+		show("Directory:", cwd)
+	}
+	if dir, err := store.GetGlobalOopsDir(); err == nil {
+		show("Global stores:", dir)
+	}
+
+	if s, err := findTrackedStore(); err == nil {
+		fmt.Println()
+		show("Tracked file:", s.FilePath)
+		fmt.Printf("  %-16s %s (%s)\n", "History:", s.GitDir, describeFS(s.Network()))
+		network = network || s.Network().Network
+		if h := s.Verify(); h.State == store.HealthOK {
+			fmt.Printf("  %-16s ✓ OK\n", "Health:")
+		} else {
+			fmt.Printf("  %-16s ✗ %s: %s\n", "Health:", h.State, h.Detail)
+		}
+	}
+
+	fmt.Println()
+	if network {
+		warn("Network filesystem detected: oops runs in degraded mode")
+		info("Metadata is flushed on every write and locks are taken by hard link")
+		info("Expect slower saves and restores than on a local disk")
+	} else {
+		success("All paths are on local filesystems")
+	}
+	return nil
+}
+
+// describeFS names a filesystem for display
+func describeFS(fs netfs.Info) string {
+	name := fs.Type
+	if name == "" {
+		name = "unknown filesystem"
+	}
+	if fs.Network {
+		return name + ", network"
+	}
+	return name + ", local"
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+}
@@ -15,9 +15,11 @@ var doneCmd = &cobra.Command{
 	Use:     "done",
 	Aliases: []string{"untrack", "forget"},
 	Short:   "🗑️ Stop versioning",
-	Long:    `Stop tracking the file and remove all version history. This cannot be undone.`,
-	Args:    cobra.NoArgs,
-	RunE:    runDone,
+	Long: `Stop tracking the file and remove all version history. This cannot be undone.
+
+With --profile, only that profile's history is removed.`,
+	Args: cobra.NoArgs,
+	RunE: runDone,
 }
 
 func runDone(cmd *cobra.Command, args []string) error {
@@ -30,7 +32,11 @@ func runDone(cmd *cobra.Command, args []string) error {
 	latest, _ := s.GetLatestVersion()
 
 	if !yesDone {
-		warn("This will delete all %d snapshots of '%s'", latest, s.FileName)
+		if s.IsDefaultProfile() {
+			warn("This will delete all %d snapshots of '%s'", latest, s.FileName)
+		} else {
+			warn("This will delete profile '%s' of '%s' and its %d snapshots", s.Profile, s.FileName, latest)
+		}
 		fmt.Print("Are you sure? [y/N]: ")
 
 		reader := bufio.NewReader(os.Stdin)
@@ -51,6 +57,10 @@ func runDone(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if !s.IsDefaultProfile() {
+		success("Removed profile '%s' of '%s' (%d snapshots removed)", s.Profile, s.FileName, latest)
+		return nil
+	}
 	success("Stopped tracking '%s' (%d snapshots removed)", s.FileName, latest)
 	return nil
 }
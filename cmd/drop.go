@@ -0,0 +1,87 @@
+package cmd
+
+import (
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var dropYes bool
+
+var dropCmd = &cobra.Command{
+	Use:   "drop <version>",
+	Short: "✂️ Remove a snapshot from history",
+	Long: `Remove one snapshot from the history for good, e.g. one that was saved
+with a password in it. The snapshots after it move down one number, so
+#5 becomes #4 and so on. The working file is not changed.
+
+The history is rewritten, so the content of the dropped snapshot is gone
+from the store. Copies already pushed to a remote or backed up keep it,
+and signed manifests from 'oops attest' list the old numbers.
+
+Examples:
+  oops drop 3      Remove snapshot #3 after confirming
+  oops drop 3 -y   Remove it without asking`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runDrop,
+}
+
+func runDrop(cmd *cobra.Command, args []string) error {
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[0])
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	target, err := findSnapshot(s, num)
+	if err != nil {
+		fail("Snapshot #%d not found", num)
+		info("Use 'oops history' to see available snapshots")
+		return nil
+	}
+
+	info("#%d  %s  (%s)", target.Number, target.Message, formatTimeAgo(target.Timestamp))
+	if freed, err := s.ReclaimEstimate([]store.Snapshot{*target}); err == nil {
+		info("Frees about %s", formatBytes(freed))
+	}
+	if !dropYes && !confirm("Remove this snapshot permanently?") {
+		info("Nothing changed")
+		return nil
+	}
+
+	if _, err := s.Drop(num); err != nil {
+		if err == store.ErrOnlySnapshot {
+			fail("Snapshot #%d is the only one", num)
+			info("Use 'oops done' to stop tracking %s instead", s.FileName)
+			return nil
+		}
+		fail("Failed to drop snapshot #%d: %v", num, err)
+		return nil
+	}
+
+	success("Snapshot #%d dropped", num)
+	switch latest, _ := s.GetLatestVersion(); {
+	case latest == num:
+		info("Snapshot #%d is now #%d", num+1, num)
+	case latest > num:
+		info("Snapshots #%d to #%d are now #%d to #%d", num+1, latest+1, num, latest)
+	}
+	remotes, _ := s.Remotes()
+	for _, remote := range remotes {
+		warn("The remote %s still has the snapshot, and the rewritten history will not push over it", remote.URL)
+	}
+	return nil
+}
+
+func init() {
+	dropCmd.Flags().BoolVarP(&dropYes, "yes", "y", false, "Drop without asking")
+	rootCmd.AddCommand(dropCmd)
+}
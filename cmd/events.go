@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/daemon"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/spf13/cobra"
+)
+
+var daemonEventsCmd = &cobra.Command{
+	Use:   "events",
+	Short: "Stream oops events as JSON lines",
+	Long: `Print snapshot, restore and delete events from every oops process as
+JSON lines, as they happen. Requires a running daemon.
+
+Each line looks like:
+  {"type":"snapshot-created","time":"...","file":"/path/notes.txt","store":"local","snapshot":3,"message":"draft"}
+
+Event types: snapshot-created, restored, store-deleted`,
+	Args: cobra.NoArgs,
+	RunE: runDaemonEvents,
+}
+
+// setupEventSinks routes events from this process to the events file and
+// to the daemon, which relays them to its subscribers
+func setupEventSinks(cmd *cobra.Command, cfg *config.Config) {
+	path := eventsFile
+	if path == "" && cfg != nil {
+		path = cfg.EventsFile
+	}
+	if path != "" {
+		events.Subscribe(events.FileSink(path))
+	}
+
+	// The daemon publishes its own events directly
+	if cmd == daemonRunCmd {
+		return
+	}
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		return
+	}
+	events.Subscribe(func(e events.Event) {
+		data, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		daemon.Publish(socketPath, data) // Nobody is listening if it is not running
+	})
+}
+
+func runDaemonEvents(cmd *cobra.Command, args []string) error {
+	socketPath, err := daemon.SocketPath()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- daemon.Subscribe(socketPath, func(data json.RawMessage) bool {
+			fmt.Println(string(data))
+			return ctx.Err() == nil
+		})
+	}()
+
+	select {
+	case err := <-errc:
+		if err == daemon.ErrNotRunning {
+			fail("Daemon is not running")
+			info("Start it with 'oops daemon start'")
+			return nil
+		}
+		if err != nil {
+			fail("Event stream failed: %v", err)
+		}
+	case <-ctx.Done():
+	}
+	return nil
+}
+
+func init() {
+	daemonCmd.AddCommand(daemonEventsCmd)
+}
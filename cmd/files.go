@@ -5,12 +5,18 @@ import (
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
 )
 
-var filesAllFlag bool
+var (
+	filesAllFlag    bool
+	filesVerifyFlag bool
+	filesLabel      string
+	filesOffline    bool
+)
 
 var filesCmd = &cobra.Command{
 	Use:     "files",
@@ -21,12 +27,35 @@ var filesCmd = &cobra.Command{
 Examples:
   oops files      List locally tracked files
   oops files -g   List globally tracked files
-  oops files -a   List both local and global tracked files`,
+  oops files -a   List both local and global tracked files
+  oops files --label dotfiles  List only files with this label
+  oops files --offline  List global stores of files on unmounted volumes
+  oops files --verify  Also check each store's health (OK, stale lock,
+                       missing metadata, corrupt)
+
+Global files marked 💤 are on a drive or share that is not mounted; their
+history is kept by 'oops gc -g' until the volume comes back. Files marked
+? are missing from a mounted volume, and 'oops gc -g' cleans them up.
+Files with unsaved changes whose last snapshot is older than stale_after
+(24h by default, see 'oops config --stale-after') show ⏰ and its age.`,
 	Args: cobra.NoArgs,
 	RunE: runFiles,
 }
 
+// trackedFile is one row of the files listing
+type trackedFile struct {
+	name       string
+	current    int
+	latest     int
+	hasChanges bool
+	stale      string
+	health     string
+}
+
 func runFiles(cmd *cobra.Command, args []string) error {
+	if filesOffline {
+		return runFilesOffline()
+	}
 	if filesAllFlag {
 		return runFilesAll()
 	}
@@ -37,6 +66,7 @@ func runFiles(cmd *cobra.Command, args []string) error {
 }
 
 func runFilesAll() error {
+	threshold := staleAfter()
 	hasLocal := false
 	hasGlobal := false
 
@@ -46,12 +76,7 @@ func runFilesAll() error {
 		oopsDir := filepath.Join(cwd, store.OopsDir)
 		entries, err := os.ReadDir(oopsDir)
 		if err == nil && len(entries) > 0 {
-			var tracked []struct {
-				name       string
-				current    int
-				latest     int
-				hasChanges bool
-			}
+			var tracked []trackedFile
 
 			for _, entry := range entries {
 				if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
@@ -59,28 +84,31 @@ func runFilesAll() error {
 				}
 
 				fileName := strings.TrimSuffix(entry.Name(), ".git")
+				if store.ValidateFileName(fileName) != nil {
+					continue
+				}
 				filePath := filepath.Join(cwd, fileName)
 
 				s, err := store.NewStore(filePath)
-				if err != nil || !s.Exists() {
+				if err != nil || (!s.Exists() && !filesVerifyFlag) {
+					continue
+				}
+				if filesLabel != "" && !s.HasLabel(filesLabel) {
 					continue
 				}
 
 				current, latest, hasChanges, err := s.Now()
-				if err != nil {
+				if err != nil && !filesVerifyFlag {
 					continue
 				}
 
-				tracked = append(tracked, struct {
-					name       string
-					current    int
-					latest     int
-					hasChanges bool
-				}{
+				tracked = append(tracked, trackedFile{
 					name:       fileName,
 					current:    current,
 					latest:     latest,
 					hasChanges: hasChanges,
+					stale:      staleColumn(s, hasChanges, threshold),
+					health:     healthColumn(s),
 				})
 			}
 
@@ -92,13 +120,13 @@ func runFilesAll() error {
 					if t.hasChanges {
 						status = "✏️"
 					}
-
-					versionInfo := fmt.Sprintf("#%d", t.current)
-					if t.current != t.latest {
-						versionInfo = fmt.Sprintf("#%d (latest #%d)", t.current, t.latest)
+					if t.latest == 0 {
+						status = "✗"
 					}
 
-					fmt.Printf("  %s %s  %s\n", status, t.name, versionInfo)
+					versionInfo := versionColumn(t.current, t.latest)
+
+					fmt.Printf("  %s %s  %s%s%s\n", status, t.name, versionInfo, t.stale, t.health)
 				}
 			}
 		}
@@ -106,20 +134,22 @@ func runFilesAll() error {
 
 	// Show global files
 	globalStores, err := store.ListGlobalStores()
+	globalStores = filterByLabel(globalStores)
 	if err == nil && len(globalStores) > 0 {
 		if hasLocal {
 			fmt.Println()
 		}
 		hasGlobal = true
 		fmt.Println("🌐 Globally tracked files:")
+		var legend globalLegend
 		for _, gInfo := range globalStores {
 			s, err := store.NewGlobalStore(gInfo.FilePath)
-			if err != nil || !s.Exists() {
+			if err != nil || (!s.Exists() && !filesVerifyFlag) {
 				continue
 			}
 
 			current, latest, hasChanges, err := s.Now()
-			if err != nil {
+			if err != nil && !filesVerifyFlag {
 				continue
 			}
 
@@ -127,21 +157,25 @@ func runFilesAll() error {
 			if hasChanges {
 				status = "✏️"
 			}
-
-			if _, err := os.Stat(gInfo.FilePath); os.IsNotExist(err) {
-				status = "?"
+			if latest == 0 {
+				status = "✗"
 			}
 
-			versionInfo := fmt.Sprintf("#%d", current)
-			if current != latest {
-				versionInfo = fmt.Sprintf("#%d (latest #%d)", current, latest)
-			}
+			status = legend.status(gInfo, status)
 
-			fmt.Printf("  %s %s  %s\n", status, gInfo.FilePath, versionInfo)
+			versionInfo := versionColumn(current, latest)
+
+			fmt.Printf("  %s %s  %s%s%s\n", status, gInfo.FilePath, versionInfo, staleColumn(s, hasChanges, threshold), healthColumn(s))
 		}
+		legend.print()
+	}
+	if filesVerifyFlag && hasGlobal {
+		printUnlistedGlobalStores()
 	}
 
-	if !hasLocal && !hasGlobal {
+	if !hasLocal && !hasGlobal && filesLabel != "" {
+		info("No tracked files labeled '%s'", filesLabel)
+	} else if !hasLocal && !hasGlobal {
 		info("No tracked files")
 		info("Use 'oops start <file>' to begin")
 	}
@@ -150,6 +184,7 @@ func runFilesAll() error {
 }
 
 func runFilesLocal() error {
+	threshold := staleAfter()
 	cwd, err := os.Getwd()
 	if err != nil {
 		fail("Error: %v", err)
@@ -168,12 +203,7 @@ func runFilesLocal() error {
 		return nil
 	}
 
-	var tracked []struct {
-		name       string
-		current    int
-		latest     int
-		hasChanges bool
-	}
+	var tracked []trackedFile
 
 	for _, entry := range entries {
 		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
@@ -181,31 +211,38 @@ func runFilesLocal() error {
 		}
 
 		fileName := strings.TrimSuffix(entry.Name(), ".git")
+		if store.ValidateFileName(fileName) != nil {
+			continue
+		}
 		filePath := filepath.Join(cwd, fileName)
 
 		s, err := store.NewStore(filePath)
-		if err != nil || !s.Exists() {
+		if err != nil || (!s.Exists() && !filesVerifyFlag) {
+			continue
+		}
+		if filesLabel != "" && !s.HasLabel(filesLabel) {
 			continue
 		}
 
 		current, latest, hasChanges, err := s.Now()
-		if err != nil {
+		if err != nil && !filesVerifyFlag {
 			continue
 		}
 
-		tracked = append(tracked, struct {
-			name       string
-			current    int
-			latest     int
-			hasChanges bool
-		}{
+		tracked = append(tracked, trackedFile{
 			name:       fileName,
 			current:    current,
 			latest:     latest,
 			hasChanges: hasChanges,
+			stale:      staleColumn(s, hasChanges, threshold),
+			health:     healthColumn(s),
 		})
 	}
 
+	if len(tracked) == 0 && filesLabel != "" {
+		info("No tracked files labeled '%s'", filesLabel)
+		return nil
+	}
 	if len(tracked) == 0 {
 		info("No tracked files")
 		info("Use 'oops start <file>' to begin")
@@ -218,40 +255,52 @@ func runFilesLocal() error {
 		if t.hasChanges {
 			status = "✏️"
 		}
-
-		versionInfo := fmt.Sprintf("#%d", t.current)
-		if t.current != t.latest {
-			versionInfo = fmt.Sprintf("#%d (latest #%d)", t.current, t.latest)
+		if t.latest == 0 {
+			status = "✗"
 		}
 
-		fmt.Printf("  %s %s  %s\n", status, t.name, versionInfo)
+		versionInfo := versionColumn(t.current, t.latest)
+
+		fmt.Printf("  %s %s  %s%s%s\n", status, t.name, versionInfo, t.stale, t.health)
 	}
 
 	return nil
 }
 
 func runFilesGlobal() error {
+	threshold := staleAfter()
 	globalStores, err := store.ListGlobalStores()
 	if err != nil {
 		fail("Error: %v", err)
 		return nil
 	}
+	globalStores = filterByLabel(globalStores)
+	if len(globalStores) == 0 && filesLabel != "" {
+		info("No globally tracked files labeled '%s'", filesLabel)
+		return nil
+	}
 
 	if len(globalStores) == 0 {
+		if unlisted, _ := store.ListUnlistedGlobalStores(); filesVerifyFlag && len(unlisted) > 0 {
+			fmt.Println("🌐 Globally tracked files:")
+			printUnlistedGlobalStores()
+			return nil
+		}
 		info("No globally tracked files")
 		info("Use 'oops start -g <file>' to begin")
 		return nil
 	}
 
 	fmt.Println("🌐 Globally tracked files:")
+	var legend globalLegend
 	for _, info := range globalStores {
 		s, err := store.NewGlobalStore(info.FilePath)
-		if err != nil || !s.Exists() {
+		if err != nil || (!s.Exists() && !filesVerifyFlag) {
 			continue
 		}
 
 		current, latest, hasChanges, err := s.Now()
-		if err != nil {
+		if err != nil && !filesVerifyFlag {
 			continue
 		}
 
@@ -259,24 +308,158 @@ func runFilesGlobal() error {
 		if hasChanges {
 			status = "✏️"
 		}
+		if latest == 0 {
+			status = "✗"
+		}
+
+		status = legend.status(info, status)
 
-		// Check if file still exists
-		if _, err := os.Stat(info.FilePath); os.IsNotExist(err) {
-			status = "?"
+		versionInfo := versionColumn(current, latest)
+
+		fmt.Printf("  %s %s  %s%s%s\n", status, info.FilePath, versionInfo, staleColumn(s, hasChanges, threshold), healthColumn(s))
+	}
+	legend.print()
+	if filesVerifyFlag {
+		printUnlistedGlobalStores()
+	}
+
+	return nil
+}
+
+// runFilesOffline lists the global stores of files on volumes that are
+// not mounted
+func runFilesOffline() error {
+	globalStores, err := store.ListGlobalStores()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	var offline []store.GlobalStoreInfo
+	for _, gInfo := range filterByLabel(globalStores) {
+		if _, err := os.Stat(gInfo.FilePath); os.IsNotExist(err) && isOffline(gInfo) {
+			offline = append(offline, gInfo)
 		}
+	}
+	if len(offline) == 0 {
+		info("No global stores of files on unmounted volumes")
+		return nil
+	}
 
-		versionInfo := fmt.Sprintf("#%d", current)
-		if current != latest {
-			versionInfo = fmt.Sprintf("#%d (latest #%d)", current, latest)
+	fmt.Println("💤 Files on volumes that are not mounted:")
+	for _, gInfo := range offline {
+		latest := 0
+		if s, err := store.NewGlobalStore(gInfo.FilePath); err == nil {
+			latest, _ = s.Repo.GetLatestTagNumber()
+		}
+		where := ""
+		if root := store.OfflineRoot(gInfo.FilePath); root != "" {
+			where = fmt.Sprintf("  (mount %s)", root)
 		}
+		fmt.Printf("  %s  %s%s\n", gInfo.FilePath, versionColumn(latest, latest), where)
+	}
+	info("Their history is kept; plug in or mount the volume to use them")
+	return nil
+}
 
-		fmt.Printf("  %s %s  %s\n", status, info.FilePath, versionInfo)
+// globalLegend marks global files that are missing and remembers which
+// kinds were shown, so the listing can explain them
+type globalLegend struct {
+	offline, orphaned bool
+}
+
+// status returns the marker for a global file: 💤 if it is on a volume
+// that is not mounted, ? if it is gone, or status unchanged
+func (l *globalLegend) status(gInfo store.GlobalStoreInfo, status string) string {
+	if _, err := os.Stat(gInfo.FilePath); !os.IsNotExist(err) {
+		return status
+	}
+	if isOffline(gInfo) {
+		l.offline = true
+		return "💤"
 	}
+	l.orphaned = true
+	return "?"
+}
 
-	return nil
+// print explains the markers that were shown
+func (l *globalLegend) print() {
+	if l.offline || l.orphaned {
+		fmt.Println()
+	}
+	if l.offline {
+		info("💤 Volume not mounted: history is kept ('oops files --offline' lists them)")
+	}
+	if l.orphaned {
+		info("?  File missing: 'oops gc -g' cleans up these stores")
+	}
+}
+
+// filterByLabel keeps the global stores carrying --label, if given
+func filterByLabel(infos []store.GlobalStoreInfo) []store.GlobalStoreInfo {
+	if filesLabel == "" {
+		return infos
+	}
+	var kept []store.GlobalStoreInfo
+	for _, gInfo := range infos {
+		s, err := store.NewGlobalStore(gInfo.FilePath)
+		if err == nil && s.HasLabel(filesLabel) {
+			kept = append(kept, gInfo)
+		}
+	}
+	return kept
+}
+
+// versionColumn describes the current snapshot, "#?" if it is unreadable
+func versionColumn(current, latest int) string {
+	if latest == 0 {
+		return "#?"
+	}
+	if current != latest {
+		return fmt.Sprintf("#%d (latest #%d)", current, latest)
+	}
+	return fmt.Sprintf("#%d", current)
+}
+
+// printUnlistedGlobalStores shows global stores that have lost their
+// metadata, which only --verify lists
+func printUnlistedGlobalStores() {
+	unlisted, err := store.ListUnlistedGlobalStores()
+	if err != nil {
+		return
+	}
+	globalDir, _ := store.GetGlobalOopsDir()
+	for _, u := range unlisted {
+		fmt.Printf("  ✗ %s  #?  [%s: original path unknown]\n",
+			filepath.Join(globalDir, u.HashDir, u.FileName+".git"), store.HealthMissingMetadata)
+	}
+}
+
+// staleColumn notes when a file with unsaved changes was last saved, once
+// that is longer ago than threshold
+func staleColumn(s *store.Store, hasChanges bool, threshold time.Duration) string {
+	if last, stale := staleSince(s, hasChanges, threshold); stale {
+		return "  ⏰ saved " + formatTimeAgo(last)
+	}
+	return ""
+}
+
+// healthColumn returns the health text shown with --verify, or "" without it
+func healthColumn(s *store.Store) string {
+	if !filesVerifyFlag {
+		return ""
+	}
+	h := s.Verify()
+	if h.State == store.HealthOK {
+		return "  [OK]"
+	}
+	return fmt.Sprintf("  [%s: %s]", h.State, h.Detail)
 }
 
 func init() {
 	filesCmd.Flags().BoolVarP(&filesAllFlag, "all", "a", false, "Show both local and global tracked files")
+	filesCmd.Flags().BoolVar(&filesVerifyFlag, "verify", false, "Check the health of each store")
+	filesCmd.Flags().StringVar(&filesLabel, "label", "", "Only list files with this label")
+	filesCmd.Flags().BoolVar(&filesOffline, "offline", false, "Only list global files on volumes that are not mounted")
 	rootCmd.AddCommand(filesCmd)
 }
@@ -0,0 +1,66 @@
+package cmd
+
+import (
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var forwardCmd = &cobra.Command{
+	Use:     "forward",
+	Aliases: []string{"redo"},
+	Short:   "↪️ Return to where you were before going back",
+	Long: `Reverse the last step backwards, so a restore can itself be undone.
+
+After 'oops back 2' from snapshot #5, forward returns to #5, and
+'oops oops!' then goes back to #2. After stepping back with 'oops oops!',
+forward walks forward again like 'oops oops! --redo'.
+
+Each file remembers its last 20 positions. A save or a new restore
+starts a new line, discarding what could have been redone.
+
+Unsaved changes are handled as by back (see back.on_dirty).
+
+Examples:
+  oops back 2      Look at snapshot #2
+  oops forward     Return to the snapshot you were at`,
+	Args: cobra.NoArgs,
+	RunE: runForward,
+}
+
+var forceForward bool
+
+func runForward(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if !s.CanForward() {
+		info("Nothing to go forward to")
+		info("Use 'oops history' to see available snapshots")
+		return nil
+	}
+	if !handleUnsavedChanges(s, "back", forceForward) {
+		return nil
+	}
+
+	num, err := s.Forward()
+	if err != nil {
+		if err == store.ErrNothingToRedo {
+			info("Nothing to go forward to")
+			return nil
+		}
+		if !reportLocked(err) {
+			fail("Failed: %v", err)
+		}
+		return nil
+	}
+	success("Returned to snapshot #%d", num)
+	reportChainFailures(s)
+	return nil
+}
+
+func init() {
+	forwardCmd.Flags().BoolVarP(&forceForward, "force", "f", false, "Discard unsaved changes")
+	rootCmd.AddCommand(forwardCmd)
+}
@@ -5,14 +5,20 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
 
+	"github.com/iyulab/oops/internal/config"
 	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var (
-	gcDryRun bool
-	gcYes    bool
+	gcDryRun  bool
+	gcYes     bool
+	gcCompact bool
+	gcUndo    bool
+
+	gcIncludeOffline bool
 )
 
 var gcCmd = &cobra.Command{
@@ -21,21 +27,186 @@ var gcCmd = &cobra.Command{
 	Long: `Remove stores for files that no longer exist.
 
 For global stores (-g), this removes tracking data for deleted files.
+Files on a volume that is not mounted (an unplugged drive, a missing
+drive letter or network share, an empty mount point) are offline, not
+deleted, and their stores are kept unless --include-offline is given.
 For local stores, this removes .oops entries for missing files.
 
+gc also packs the remaining histories: snapshots are stored as deltas
+against each other, so large files keep little more than their changes.
+Saving does this as snapshots accumulate; gc packs the rest, including
+stores from older versions of oops.
+
+With --compact (or compact=true in ~/.oops/config), long histories are
+also thinned: every snapshot from the last day is kept, then one per
+hour for a week, then one per day. The first and latest snapshots are
+always kept. Adjust the ages with compact_keep_all and
+compact_keep_hourly in the config file; set compact_keep_daily to keep
+only one snapshot per week beyond that age.
+
+Removed stores are moved to ~/.oops/trash and kept for 7 days, so
+--undo-last can bring back the stores of the most recent run, e.g. for
+files that were only missing while a drive was unplugged. Snapshots
+dropped by --compact cannot be restored.
+
 Examples:
   oops gc -g          Clean orphaned global stores
   oops gc -g --dry-run  Preview what would be cleaned
-  oops gc             Clean orphaned local stores`,
+  oops gc             Clean orphaned local stores
+  oops gc --compact   Also thin long histories
+  oops gc --undo-last Restore the stores removed by the last run`,
 	Args: cobra.NoArgs,
 	RunE: runGc,
 }
 
 func runGc(cmd *cobra.Command, args []string) error {
+	if gcUndo {
+		return runGcUndo()
+	}
+	if !gcDryRun {
+		store.PurgeTrash(time.Now())
+	}
+
+	if globalFlag {
+		runGcGlobal()
+	} else {
+		runGcLocal()
+	}
+	runGcRepack()
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	if gcCompact || cfg.Compact {
+		runGcCompact(cfg)
+	}
+	return nil
+}
+
+// runGcRepack packs the histories of local or global stores that have
+// snapshots stored loose, such as those saved by older versions
+func runGcRepack() {
+	var stores []*store.Store
 	if globalFlag {
-		return runGcGlobal()
+		stores = globalStores()
+	} else if cwd, err := os.Getwd(); err == nil {
+		stores = localStores(cwd)
+	}
+
+	var loose []*store.Store
+	for _, s := range stores {
+		if s.NeedsRepack() {
+			loose = append(loose, s)
+		}
+	}
+	if len(loose) == 0 {
+		return
+	}
+
+	fmt.Println()
+	if gcDryRun {
+		info("%d %s would be packed", len(loose), plural(len(loose), "store"))
+		return
+	}
+	var saved int64
+	packed := 0
+	for _, s := range loose {
+		before, after, err := s.Repack()
+		if err != nil {
+			warn("Failed to pack %s: %v", s.FileName, err)
+			continue
+		}
+		saved += before - after
+		packed++
 	}
-	return runGcLocal()
+	success("Packed %d %s, saving %s", packed, plural(packed, "store"), formatBytes(max(saved, 0)))
+}
+
+// runGcCompact thins the histories of local or global stores
+func runGcCompact(cfg *config.Config) {
+	var stores []*store.Store
+	if globalFlag {
+		stores = globalStores()
+	} else if cwd, err := os.Getwd(); err == nil {
+		stores = localStores(cwd)
+	}
+
+	policy := store.CompactPolicy{
+		KeepAll:    cfg.CompactKeepAll,
+		KeepHourly: cfg.CompactKeepHourly,
+		KeepDaily:  cfg.CompactKeepDaily,
+	}
+	now := time.Now()
+
+	type plan struct {
+		s     *store.Store
+		drop  int
+		total int
+		freed int64
+	}
+	var plans []plan
+	for _, s := range stores {
+		drop, err := s.PlanCompaction(policy, now)
+		if err != nil || len(drop) == 0 {
+			continue
+		}
+		total, _ := s.History()
+		freed, _ := s.ReclaimEstimate(drop)
+		plans = append(plans, plan{s, len(drop), len(total), freed})
+	}
+
+	fmt.Println()
+	if len(plans) == 0 {
+		success("No histories need compaction")
+		return
+	}
+
+	fmt.Printf("🗜  History compaction:\n")
+	var freed int64
+	for _, p := range plans {
+		name := p.s.FileName
+		if p.s.Global {
+			name = p.s.FilePath
+		}
+		fmt.Printf("  - %s: remove %d of %d snapshots, about %s\n", name, p.drop, p.total, formatBytes(p.freed))
+		freed += p.freed
+	}
+	info("Frees about %s in all", formatBytes(freed))
+
+	if gcDryRun {
+		info("Dry run - no changes made")
+		return
+	}
+
+	if !gcYes && !confirm("\nThin these histories?") {
+		info("Cancelled")
+		return
+	}
+
+	removed := 0
+	for _, p := range plans {
+		n, err := p.s.Compact(policy, now)
+		if err != nil {
+			warn("Failed to compact %s: %v", p.s.FileName, err)
+			continue
+		}
+		removed += n
+	}
+
+	success("Removed %d snapshot(s) by compaction", removed)
+}
+
+// confirm asks a yes/no question on stdin, defaulting to no
+func confirm(question string) bool {
+	fmt.Printf("%s [y/N]: ", question)
+	reader := bufio.NewReader(os.Stdin)
+	response, err := reader.ReadString('\n')
+	if err != nil {
+		return false
+	}
+	response = strings.TrimSpace(strings.ToLower(response))
+	return response == "y" || response == "yes"
 }
 
 func runGcLocal() error {
@@ -63,6 +234,9 @@ func runGcLocal() error {
 		}
 
 		fileName := strings.TrimSuffix(entry.Name(), ".git")
+		if store.ValidateFileName(fileName) != nil {
+			continue
+		}
 		filePath := cwd + string(os.PathSeparator) + fileName
 
 		if _, err := os.Stat(filePath); os.IsNotExist(err) {
@@ -99,20 +273,29 @@ func runGcLocal() error {
 		}
 	}
 
-	removed := 0
+	run, err := store.NewTrashRun()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
 	for _, name := range orphaned {
 		gitDir := oopsDir + string(os.PathSeparator) + name + ".git"
-		if err := os.RemoveAll(gitDir); err != nil {
+		if err := run.Remove(gitDir, cwd+string(os.PathSeparator)+name); err != nil {
 			warn("Failed to remove %s: %v", name, err)
-		} else {
-			removed++
 		}
 	}
 
-	success("Removed %d orphaned store(s)", removed)
+	success("Removed %d orphaned store(s)", len(run.Entries))
+	reportTrash(run)
 	return nil
 }
 
+// isOffline reports whether a global store's missing file is on a volume
+// that is not mounted, rather than deleted
+func isOffline(info store.GlobalStoreInfo) bool {
+	return info.Removable || store.OfflineRoot(info.FilePath) != ""
+}
+
 func runGcGlobal() error {
 	globalStores, err := store.ListGlobalStores()
 	if err != nil {
@@ -125,11 +308,25 @@ func runGcGlobal() error {
 		return nil
 	}
 
-	var orphaned []store.GlobalStoreInfo
+	var orphaned, offline []store.GlobalStoreInfo
 	for _, info := range globalStores {
-		if _, err := os.Stat(info.FilePath); os.IsNotExist(err) {
-			orphaned = append(orphaned, info)
+		if _, err := os.Stat(info.FilePath); !os.IsNotExist(err) {
+			continue
+		}
+		// A file on a drive or share that is not mounted is not gone
+		if !gcIncludeOffline && isOffline(info) {
+			offline = append(offline, info)
+			continue
 		}
+		orphaned = append(orphaned, info)
+	}
+	if len(offline) > 0 {
+		fmt.Printf("💤 Kept %d offline global %s (volume not mounted):\n", len(offline), plural(len(offline), "store"))
+		for _, info := range offline {
+			fmt.Printf("  - %s\n", info.FilePath)
+		}
+		info("Use --include-offline to remove them too")
+		fmt.Println()
 	}
 
 	if len(orphaned) == 0 {
@@ -141,6 +338,7 @@ func runGcGlobal() error {
 	for _, info := range orphaned {
 		fmt.Printf("  - %s\n", info.FilePath)
 	}
+	info("If a file was moved, 'oops relink <old-path> <new-path>' keeps its history")
 
 	if gcDryRun {
 		info("Dry run - no changes made")
@@ -162,22 +360,60 @@ func runGcGlobal() error {
 	}
 
 	globalDir, _ := store.GetGlobalOopsDir()
-	removed := 0
+	run, err := store.NewTrashRun()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
 	for _, info := range orphaned {
 		hashDir := globalDir + string(os.PathSeparator) + info.HashDir
-		if err := os.RemoveAll(hashDir); err != nil {
+		if err := run.Remove(hashDir, info.FilePath); err != nil {
 			warn("Failed to remove %s: %v", info.FilePath, err)
-		} else {
-			removed++
 		}
 	}
 
-	success("Removed %d orphaned global store(s)", removed)
+	success("Removed %d orphaned global store(s)", len(run.Entries))
+	reportTrash(run)
+	return nil
+}
+
+// reportTrash tells how to undo a gc run that removed stores
+func reportTrash(run *store.TrashRun) {
+	if len(run.Entries) == 0 {
+		return
+	}
+	info("%s moved to the trash for %d days; 'oops gc --undo-last' restores it",
+		formatBytes(run.Size()), int(store.TrashGrace.Hours()/24))
+}
+
+// runGcUndo restores the stores removed by the most recent gc run
+func runGcUndo() error {
+	run, err := store.LastTrashRun()
+	if err == store.ErrTrashEmpty {
+		info("No removed stores to restore")
+		return nil
+	}
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	restored, err := run.Restore()
+	for _, e := range restored {
+		fmt.Printf("  ↩ %s\n", e.FilePath)
+	}
+	if err != nil {
+		warn("Some stores were not restored: %v", err)
+	}
+	success("Restored %d %s removed %s", len(restored), plural(len(restored), "store"), formatTimeAgo(run.Time))
 	return nil
 }
 
 func init() {
 	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Preview what would be cleaned without removing")
 	gcCmd.Flags().BoolVarP(&gcYes, "yes", "y", false, "Skip confirmation")
+	gcCmd.Flags().BoolVar(&gcCompact, "compact", false, "Also thin long snapshot histories")
+	gcCmd.Flags().BoolVar(&gcIncludeOffline, "include-offline", false, "Also remove global stores of files on volumes that are not mounted")
+	gcCmd.Flags().BoolVar(&gcUndo, "undo-last", false, "Restore the stores removed by the last gc run")
 	rootCmd.AddCommand(gcCmd)
 }
@@ -0,0 +1,139 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var globalRemapHome bool
+
+var globalCmd = &cobra.Command{
+	Use:   "global",
+	Short: "🌐 Move global stores between machines",
+	Long: `Export or import every global store (~/.oops/) as a single bundle.
+
+Bundles are gzip-compressed tar archives containing each store and its
+metadata, ready to copy to a new computer. Only gzip is supported: names
+ending in .zst or .tzst are refused, since oops has no zstd compressor.
+
+Examples:
+  oops global export oops-bundle.tar.gz
+  oops global import oops-bundle.tar.gz
+  oops global import --remap-home oops-bundle.tar.gz`,
+}
+
+var globalExportCmd = &cobra.Command{
+	Use:   "export <bundle.tar.gz>",
+	Short: "📦 Export all global stores to a bundle",
+	Long: `Export every global store to a gzip-compressed tar bundle.
+
+Only gzip is supported; a .tar.zst name is refused rather than written
+as gzip under a misleading name.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGlobalExport,
+}
+
+var globalImportCmd = &cobra.Command{
+	Use:   "import <bundle.tar.gz>",
+	Short: "📥 Import global stores from a bundle",
+	Long: `Import global stores from a bundle created with 'oops global export'.
+
+Files already tracked globally on this machine are skipped. If the bundle
+was made on a machine with a different home directory, use --remap-home
+to move tracked paths under this machine's home directory.`,
+	Args: cobra.ExactArgs(1),
+	RunE: runGlobalImport,
+}
+
+func runGlobalExport(cmd *cobra.Command, args []string) error {
+	bundlePath := args[0]
+	if err := checkBundleName(bundlePath); err != nil {
+		fail("%v", err)
+		info("Use a .tar.gz name instead")
+		return nil
+	}
+
+	f, err := os.Create(bundlePath)
+	if err != nil {
+		fail("Failed to create bundle: %v", err)
+		return nil
+	}
+
+	count, err := store.ExportGlobalStores(f)
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		os.Remove(bundlePath)
+		fail("Failed to export: %v", err)
+		return nil
+	}
+
+	success("Exported %d global store(s) to %s", count, bundlePath)
+	info("Copy it to the new machine and run 'oops global import %s'", bundlePath)
+	return nil
+}
+
+func runGlobalImport(cmd *cobra.Command, args []string) error {
+	bundlePath := args[0]
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		fail("Failed to open bundle: %v", err)
+		return nil
+	}
+	defer f.Close()
+
+	result, err := store.ImportGlobalStores(f, globalRemapHome)
+	if err != nil {
+		fail("Failed to import: %v", err)
+		return nil
+	}
+
+	success("Imported %d global store(s)", len(result.Imported))
+	for _, p := range result.Imported {
+		info("+ %s", p)
+	}
+	if len(result.Skipped) > 0 {
+		warn("Skipped %d already tracked file(s):", len(result.Skipped))
+		for _, p := range result.Skipped {
+			info("- %s", p)
+		}
+	}
+
+	// Suggest remapping when the bundle came from a different home directory
+	homeDir, _ := os.UserHomeDir()
+	if !globalRemapHome && result.BundleHome != "" && result.BundleHome != homeDir {
+		var foreign int
+		for _, p := range result.Imported {
+			if strings.HasPrefix(p, result.BundleHome) {
+				foreign++
+			}
+		}
+		if foreign > 0 {
+			fmt.Println()
+			warn("%d store(s) point into %s, but your home is %s", foreign, result.BundleHome, homeDir)
+			info("To move them: oops remap %s %s", result.BundleHome, homeDir)
+		}
+	}
+
+	return nil
+}
+
+// checkBundleName rejects bundle formats oops cannot write
+func checkBundleName(name string) error {
+	if strings.HasSuffix(name, ".zst") || strings.HasSuffix(name, ".tzst") {
+		return fmt.Errorf("zstd bundles are not supported; bundles are gzip-compressed")
+	}
+	return nil
+}
+
+func init() {
+	globalImportCmd.Flags().BoolVar(&globalRemapHome, "remap-home", false, "Rewrite paths from the exporting machine's home directory to this one")
+	globalCmd.AddCommand(globalExportCmd)
+	globalCmd.AddCommand(globalImportCmd)
+	rootCmd.AddCommand(globalCmd)
+}
@@ -0,0 +1,178 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var guardCmd = &cobra.Command{
+	Use:   "guard <command> [args...]",
+	Short: "🛡️ Run a command and roll back what it did to tracked files",
+	Long: `Snapshot every tracked file in the current directory (local, and global
+files that live here), run a command, then show which of them it changed
+and offer to roll them all back - a safety net around risky scripts,
+formatters and installers.
+
+Files with unsaved changes are saved first, so nothing is lost. On roll
+back, what the command wrote is kept as a snapshot too, and can be
+restored with 'oops back'. Files the command deleted are restored.
+oops exits with the command's exit status, whether or not its changes
+are rolled back.
+
+Put -- before a command with flags of its own.
+
+Examples:
+  oops guard ./migrate.sh
+  oops guard -- npm install --force`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runGuard,
+}
+
+// guarded is a tracked file and the snapshot it is compared with after
+// the command
+type guarded struct {
+	store    *store.Store
+	baseline int
+}
+
+func runGuard(cmd *cobra.Command, args []string) error {
+	cwd, err := os.Getwd()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	command := strings.Join(args, " ")
+
+	files := guardBaselines(guardStores(cwd), command)
+	if len(files) == 0 {
+		fail("No tracked files here to guard")
+		info("Use 'oops start <file>' to track the files the command may change")
+		return nil
+	}
+	info("Guarding %d tracked %s", len(files), plural(len(files), "file"))
+
+	run := exec.Command(args[0], args[1:]...)
+	run.Stdin, run.Stdout, run.Stderr = os.Stdin, os.Stdout, os.Stderr
+	status := 0
+	if err := run.Run(); err != nil {
+		var exit *exec.ExitError
+		if errors.As(err, &exit) {
+			warn("%s exited with status %d", args[0], exit.ExitCode())
+			status = exit.ExitCode()
+		} else {
+			fail("Failed to run %s: %v", args[0], err)
+			return nil
+		}
+	}
+	reviewGuarded(files, command)
+	if status != 0 {
+		cmd.SilenceErrors, cmd.SilenceUsage = true, true
+		return &exitStatus{status}
+	}
+	return nil
+}
+
+// reviewGuarded shows what command changed in files and rolls it back if
+// the user agrees
+func reviewGuarded(files []guarded, command string) {
+	var changed []guarded
+	for _, f := range files {
+		if _, _, hasChanges, err := f.store.Now(); err == nil && hasChanges {
+			changed = append(changed, f)
+		}
+	}
+	if len(changed) == 0 {
+		success("No tracked files were changed")
+		return
+	}
+
+	fmt.Printf("\n🛡️  %s changed %d of %d tracked %s:\n", command, len(changed), len(files), plural(len(files), "file"))
+	for _, f := range changed {
+		fmt.Printf("\n── %s (since #%d)\n", f.store.FileName, f.baseline)
+		if !f.store.Repo.WorkFileExists() {
+			info("Deleted")
+			continue
+		}
+		printChanges(f.store, f.baseline)
+	}
+
+	fmt.Println()
+	if !confirm("Roll back these changes?") {
+		info("Changes kept; use 'oops save' to snapshot them")
+		return
+	}
+	rolledBack, kept := 0, 0
+	for _, f := range changed {
+		if f.store.Repo.WorkFileExists() {
+			if _, err := f.store.BackupChanges("After guard: " + command); err != nil {
+				fail("Failed to save %s before rolling back: %v", f.store.FileName, err)
+				continue
+			}
+			kept++
+		}
+		if err := f.store.Back(f.baseline, true); err != nil {
+			fail("Failed to roll back %s: %v", f.store.FileName, err)
+			continue
+		}
+		rolledBack++
+	}
+	success("Rolled back %d %s", rolledBack, plural(rolledBack, "file"))
+	if kept > 0 {
+		info("What the command wrote is kept as the latest snapshot; see 'oops history'")
+	}
+}
+
+// guardStores returns the local stores in dir and the global stores of
+// files in dir, without a global store for a file also tracked locally
+func guardStores(dir string) []*store.Store {
+	stores := localStores(dir)
+	tracked := make(map[string]bool)
+	for _, s := range stores {
+		tracked[s.FilePath] = true
+	}
+	for _, g := range globalStores() {
+		if filepath.Dir(g.FilePath) == dir && !tracked[g.FilePath] {
+			stores = append(stores, g)
+		}
+	}
+	return stores
+}
+
+// guardBaselines snapshots unsaved changes in stores and returns the
+// snapshot each file is at. Files that are missing are left out.
+func guardBaselines(stores []*store.Store, command string) []guarded {
+	var files []guarded
+	for _, s := range stores {
+		if !s.Repo.WorkFileExists() {
+			continue
+		}
+		current, _, hasChanges, err := s.Now()
+		if err != nil {
+			warn("Skipping %s: %v", s.FileName, err)
+			continue
+		}
+		if hasChanges {
+			snap, err := s.Save("Before guard: " + command)
+			if err != nil {
+				warn("Skipping %s: failed to save its changes: %v", s.FileName, err)
+				continue
+			}
+			info("Saved unsaved changes to %s as snapshot #%d", s.FileName, snap.Number)
+			current = snap.Number
+		}
+		files = append(files, guarded{s, current})
+	}
+	return files
+}
+
+func init() {
+	guardCmd.Flags().SetInterspersed(false)
+	rootCmd.AddCommand(guardCmd)
+}
@@ -0,0 +1,78 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/iyulab/oops/internal/store"
+)
+
+// inGuardDir tracks files with the given contents in a new directory,
+// makes it the working directory and answers the roll back prompt
+func inGuardDir(t *testing.T, answer string, files map[string]string) string {
+	t.Helper()
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		os.WriteFile(path, []byte(content), 0644)
+		s, _ := store.NewStore(path)
+		if err := s.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	cwd, _ := os.Getwd()
+	os.Chdir(dir)
+	t.Cleanup(func() { os.Chdir(cwd) })
+
+	r, w, _ := os.Pipe()
+	w.WriteString(answer)
+	w.Close()
+	stdin := os.Stdin
+	os.Stdin = r
+	t.Cleanup(func() { os.Stdin = stdin; r.Close() })
+	return dir
+}
+
+func TestGuardRollsBackChanges(t *testing.T) {
+	dir := inGuardDir(t, "y\n", map[string]string{"a.txt": "original a", "b.txt": "original b"})
+
+	err := runGuard(guardCmd, []string{"sh", "-c", "echo changed > a.txt; rm b.txt"})
+	if err != nil {
+		t.Fatalf("runGuard = %v", err)
+	}
+	for name, want := range map[string]string{"a.txt": "original a", "b.txt": "original b"} {
+		if data, err := os.ReadFile(filepath.Join(dir, name)); string(data) != want {
+			t.Errorf("%s after roll back = %q, %v; want %q", name, data, err, want)
+		}
+	}
+
+	// What the command wrote is kept as a snapshot
+	s, _ := store.NewStore(filepath.Join(dir, "a.txt"))
+	if latest, _ := s.GetLatestVersion(); latest != 2 {
+		t.Errorf("a.txt has %d snapshots, want 2", latest)
+	}
+}
+
+func TestGuardKeepsChangesAndExitStatus(t *testing.T) {
+	dir := inGuardDir(t, "n\n", map[string]string{"a.txt": "original"})
+
+	err := runGuard(guardCmd, []string{"sh", "-c", "echo changed > a.txt; exit 3"})
+	var status *exitStatus
+	if !errors.As(err, &status) || status.code != 3 {
+		t.Fatalf("runGuard = %v, want exit status 3", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dir, "a.txt")); string(data) != "changed\n" {
+		t.Errorf("a.txt = %q after declining the roll back", data)
+	}
+}
@@ -1,20 +1,65 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/netfs"
 	"github.com/iyulab/oops/internal/store"
 )
 
 // findTrackedStore finds a tracked file in the current directory or globally
 func findTrackedStore() (*store.Store, error) {
+	var s *store.Store
+	var err error
 	if globalFlag {
-		return findGlobalTrackedStore()
+		s, err = findGlobalTrackedStore()
+	} else {
+		s, err = findLocalTrackedStore()
+	}
+	if err == nil && profileFlag != "" {
+		s, err = openProfile(s, profileFlag)
+	}
+	if err == nil {
+		reportRecovery(s)
+	}
+	return s, err
+}
+
+// storeOptions returns the options selected by the global flags
+func storeOptions() store.StoreOptions {
+	return store.StoreOptions{Global: globalFlag, Profile: profileFlag}
+}
+
+// openProfile switches a tracked file's store to the named profile, which
+// must have been started
+func openProfile(s *store.Store, name string) (*store.Store, error) {
+	p, err := s.OpenProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if !p.Exists() {
+		return nil, fmt.Errorf("'%s' has no profile '%s'\nUse 'oops start %s --profile %s' to begin it",
+			s.FileName, name, s.FileName, name)
+	}
+	return p, nil
+}
+
+// reportRecovery tells the user about an interrupted save that was
+// finished or undone when the store was opened
+func reportRecovery(s *store.Store) {
+	switch r := s.Recovered; {
+	case r == nil:
+	case r.Completed:
+		warn("An interrupted save of snapshot #%d was completed", r.Number)
+	default:
+		warn("An interrupted save of snapshot #%d was undone; save again if needed", r.Number)
 	}
-	return findLocalTrackedStore()
 }
 
 // findLocalTrackedStore finds a tracked file in the current directory
@@ -24,12 +69,50 @@ func findLocalTrackedStore() (*store.Store, error) {
 		return nil, err
 	}
 
-	oopsDir := filepath.Join(cwd, store.OopsDir)
-	entries, err := os.ReadDir(oopsDir)
-	if err != nil {
+	stores := localStores(cwd)
+	if len(stores) == 0 {
 		return nil, fmt.Errorf("no tracked files found\nUse 'oops start <file>' to begin")
 	}
 
+	if len(stores) > 1 {
+		stores = withoutArtifacts(stores)
+	}
+	if len(stores) > 1 {
+		return nil, fmt.Errorf("multiple tracked files found\nUse 'oops files' to see the list")
+	}
+
+	stores[0].RegisterLocal() // So the default schedule can find it
+	return stores[0], nil
+}
+
+// withoutArtifacts drops the stores of files chained to another of the
+// stores, which are saved and restored through their source
+func withoutArtifacts(stores []*store.Store) []*store.Store {
+	chained := map[string]bool{}
+	for _, s := range stores {
+		for _, a := range s.Artifacts() {
+			chained[s.ArtifactPath(a)] = true
+		}
+	}
+	var sources []*store.Store
+	for _, s := range stores {
+		if !chained[s.FilePath] {
+			sources = append(sources, s)
+		}
+	}
+	if len(sources) == 0 {
+		return stores // Chained to each other; none is the source
+	}
+	return sources
+}
+
+// localStores returns the existing local stores in dir
+func localStores(dir string) []*store.Store {
+	entries, err := os.ReadDir(filepath.Join(dir, store.OopsDir))
+	if err != nil {
+		return nil
+	}
+
 	var stores []*store.Store
 	for _, entry := range entries {
 		if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
@@ -37,7 +120,10 @@ func findLocalTrackedStore() (*store.Store, error) {
 		}
 
 		fileName := strings.TrimSuffix(entry.Name(), ".git")
-		filePath := filepath.Join(cwd, fileName)
+		if store.ValidateFileName(fileName) != nil {
+			continue
+		}
+		filePath := filepath.Join(dir, fileName)
 
 		s, err := store.NewStore(filePath)
 		if err != nil || !s.Exists() {
@@ -46,16 +132,25 @@ func findLocalTrackedStore() (*store.Store, error) {
 
 		stores = append(stores, s)
 	}
+	return stores
+}
 
-	if len(stores) == 0 {
-		return nil, fmt.Errorf("no tracked files found\nUse 'oops start <file>' to begin")
+// globalStores returns every existing global store
+func globalStores() []*store.Store {
+	infos, err := store.ListGlobalStores()
+	if err != nil {
+		return nil
 	}
 
-	if len(stores) > 1 {
-		return nil, fmt.Errorf("multiple tracked files found\nUse 'oops files' to see the list")
+	var stores []*store.Store
+	for _, info := range infos {
+		s, err := store.NewGlobalStore(info.FilePath)
+		if err != nil || !s.Exists() {
+			continue
+		}
+		stores = append(stores, s)
 	}
-
-	return stores[0], nil
+	return stores
 }
 
 // findGlobalTrackedStore finds a globally tracked file for the current directory
@@ -93,7 +188,222 @@ func findGlobalTrackedStore() (*store.Store, error) {
 	return matchingStores[0], nil
 }
 
+// findStoreForPath returns the store tracking filePath. Without -g it
+// prefers local tracking and falls back to global.
+func findStoreForPath(filePath string) (*store.Store, error) {
+	if globalFlag {
+		s, err := store.NewGlobalStore(filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !s.Exists() {
+			return nil, fmt.Errorf("'%s' is not tracked globally", filePath)
+		}
+		return s, nil
+	}
+
+	s, err := store.NewStore(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if s.Exists() {
+		return s, nil
+	}
+	if g, err := store.FindGlobalStore(filePath); err == nil {
+		return g, nil
+	}
+	return nil, fmt.Errorf("'%s' is not tracked\nUse 'oops start %s' to begin", filePath, filePath)
+}
+
 // getStoreForFile returns a store for a specific file path
 func getStoreForFile(filePath string) (*store.Store, error) {
 	return store.NewStoreWithOptions(filePath, store.StoreOptions{Global: globalFlag})
 }
+
+// findDuplicateStores returns the local and global stores of a file tracked
+// in both places. With no file given, the current directory is searched.
+func findDuplicateStores(args []string) (*store.Store, *store.Store, error) {
+	var candidates []string
+	if len(args) > 0 {
+		candidates = []string{args[0]}
+	} else {
+		cwd, err := os.Getwd()
+		if err != nil {
+			return nil, nil, err
+		}
+		entries, _ := os.ReadDir(filepath.Join(cwd, store.OopsDir))
+		for _, entry := range entries {
+			if !entry.IsDir() || !strings.HasSuffix(entry.Name(), ".git") {
+				continue
+			}
+			fileName := strings.TrimSuffix(entry.Name(), ".git")
+			if store.ValidateFileName(fileName) != nil {
+				continue
+			}
+			candidates = append(candidates, filepath.Join(cwd, fileName))
+		}
+	}
+
+	var matches [][2]*store.Store
+	for _, filePath := range candidates {
+		hasLocal, hasGlobal := store.CheckDuplicateTracking(filePath)
+		if !hasLocal || !hasGlobal {
+			continue
+		}
+		local, err := store.NewStore(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		global, err := store.NewGlobalStore(filePath)
+		if err != nil {
+			return nil, nil, err
+		}
+		matches = append(matches, [2]*store.Store{local, global})
+	}
+
+	if len(matches) == 0 {
+		if len(args) > 0 {
+			return nil, nil, fmt.Errorf("'%s' is not tracked both locally and globally", args[0])
+		}
+		return nil, nil, fmt.Errorf("no files tracked both locally and globally in this directory")
+	}
+
+	if len(matches) > 1 {
+		return nil, nil, fmt.Errorf("multiple files are tracked both locally and globally\nSpecify which file to use")
+	}
+
+	return matches[0][0], matches[0][1], nil
+}
+
+// formatBytes formats a byte count for display, e.g. "1.5 MB"
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
+// handleUnsavedChanges applies command's on_dirty policy before the
+// working file is overwritten: block stops, backup saves the changes as a
+// snapshot first, discard lets them be overwritten. force discards them
+// without checking, so it works even when the check would fail.
+// It returns false if the command should stop.
+func handleUnsavedChanges(s *store.Store, command string, force bool) bool {
+	if force {
+		return true
+	}
+	_, _, hasChanges, err := s.Now()
+	if err != nil {
+		fail("%v", err)
+		return false
+	}
+	if !hasChanges {
+		return true
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	switch cfg.OnDirtyPolicy(command) {
+	case config.DirtyBlock:
+		warn("You have unsaved changes")
+		info("oops save     Save your changes first")
+		if command == "back" {
+			info("oops back -f  Discard changes and go back")
+		}
+		info("Or set what %s does with them: oops config --on-dirty %s=backup|discard", command, command)
+		return false
+	case config.DirtyBackup:
+		snap, err := s.BackupChanges("Backup of unsaved changes")
+		if err != nil {
+			fail("Failed to back up unsaved changes: %v", err)
+			return false
+		}
+		info("Unsaved changes backed up as snapshot #%d", snap.Number)
+	}
+	return true
+}
+
+// staleAfter returns how old the last save may get before unsaved changes
+// are reported, or 0 when they never are
+func staleAfter() time.Duration {
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	return cfg.StaleAfter
+}
+
+// staleSince returns when s was last saved if it has unsaved changes and
+// that was longer ago than threshold
+func staleSince(s *store.Store, hasChanges bool, threshold time.Duration) (time.Time, bool) {
+	if !hasChanges || threshold <= 0 {
+		return time.Time{}, false
+	}
+	last, err := s.LastSaved()
+	if err != nil || last.IsZero() || time.Since(last) < threshold {
+		return time.Time{}, false
+	}
+	return last, true
+}
+
+// warnNetwork warns when a tracked file or its history is on a network
+// filesystem, where saves and restores take round trips to the server
+func warnNetwork(s *store.Store) {
+	file := netfs.Detect(s.FilePath)
+	switch {
+	case file.Network:
+		warn("%s is on a network filesystem (%s); saves may be slow", s.FileName, file.Type)
+	case s.Network().Network:
+		warn("History is kept on a network filesystem (%s); saves may be slow", s.Network().Type)
+	default:
+		return
+	}
+	info("Run 'oops doctor' for details")
+}
+
+// reportLocked explains an operation refused because another oops process
+// is changing the same history, returning false for any other error
+func reportLocked(err error) bool {
+	var locked *store.LockedError
+	if !errors.As(err, &locked) {
+		return false
+	}
+	fail("Busy: the %s", locked)
+	info("Try again once it finishes, or use --wait to wait for it")
+	return true
+}
+
+// reportUnsettled reports a work file that vanished or kept changing
+// while it was read, returning whether err was one
+func reportUnsettled(s *store.Store, err error) bool {
+	switch {
+	case errors.Is(err, store.ErrFileMissing):
+		fail("%s is missing; nothing was saved", s.FileName)
+		info("If it was renamed, use 'oops mv' to move its history along")
+	case errors.Is(err, store.ErrFileChanging):
+		fail("%s kept changing while it was being read; nothing was saved", s.FileName)
+		info("Try again once the program writing it has finished")
+	default:
+		return false
+	}
+	return true
+}
+
+// reportConcurrent reports a save that kept losing to saves by another
+// process, returning whether err was one
+func reportConcurrent(err error) bool {
+	if err != store.ErrConcurrentSave {
+		return false
+	}
+	fail("Not saved: another process kept saving the file at the same time")
+	info("Check 'oops history' and save again if your changes are still missing")
+	return true
+}
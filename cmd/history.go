@@ -4,18 +4,36 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
 )
 
 var historyCmd = &cobra.Command{
-	Use:     "history",
+	Use:     "history [--between <from> <to>]",
 	Aliases: []string{"log", "list"},
 	Short:   "📜 View snapshot history",
-	Long:    `Display all saved snapshots with their messages and timestamps.`,
-	Args:    cobra.NoArgs,
-	RunE:    runHistory,
+	Long: `Display all saved snapshots with their messages and timestamps.
+
+Examples:
+  oops history                                 List all snapshots
+  oops history --between 2 5                   Snapshots #2 to #5 with lines changed
+  oops history --between 2 5 --export report.md  Write a markdown change report
+  oops history --export report.md --diffs      Report the whole history, with diffs`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if historyBetween {
+			return cobra.ExactArgs(2)(cmd, args)
+		}
+		return cobra.NoArgs(cmd, args)
+	},
+	RunE: runHistory,
 }
 
+var (
+	historyBetween bool
+	historyExport  string
+	historyDiffs   bool
+)
+
 func runHistory(cmd *cobra.Command, args []string) error {
 	s, err := findTrackedStore()
 	if err != nil {
@@ -23,20 +41,38 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if historyBetween || historyExport != "" {
+		return runHistoryReport(s, args)
+	}
+	if historyDiffs {
+		fail("--diffs requires --export")
+		return nil
+	}
+	showHistory(s)
+	return nil
+}
+
+// showHistory lists the snapshots of s, marking the current one
+func showHistory(s *store.Store) {
 	snapshots, err := s.History()
 	if err != nil {
 		fail("Failed to get history: %v", err)
-		return nil
+		return
 	}
 
 	if len(snapshots) == 0 {
 		info("No snapshots yet")
-		return nil
+		return
 	}
 
 	current, _, _, _ := s.Now()
+	blessed, _, _ := s.Blessed()
 
-	fmt.Printf("📜 %s history:\n\n", s.FileName)
+	if s.IsDefaultProfile() {
+		fmt.Printf("📜 %s history:\n\n", s.FileName)
+	} else {
+		fmt.Printf("📜 %s history on branch '%s':\n\n", s.FileName, s.Profile)
+	}
 
 	for _, snap := range snapshots {
 		marker := "  "
@@ -45,10 +81,22 @@ func runHistory(cmd *cobra.Command, args []string) error {
 		}
 
 		timeAgo := formatTimeAgo(snap.Timestamp)
+		if s.IsImage() {
+			if img, err := s.ImageInfo(snap); err == nil {
+				timeAgo = fmt.Sprintf("%-11s  %s", img.Size(), timeAgo)
+			}
+		}
+		if origin := s.Origin(snap); origin != store.OriginManual {
+			timeAgo += fmt.Sprintf(" (%s)", origin)
+		}
+		if blessed != nil && blessed.Hash == snap.Hash {
+			timeAgo += " 🏅 blessed"
+		}
 		fmt.Printf("%s#%-3d  %-30s  %s\n", marker, snap.Number, snap.Message, timeAgo)
+		if note := s.Note(snap); note != "" {
+			fmt.Printf("        📝 %s\n", note)
+		}
 	}
-
-	return nil
 }
 
 func formatTimeAgo(t time.Time) string {
@@ -81,5 +129,8 @@ func formatTimeAgo(t time.Time) string {
 }
 
 func init() {
+	historyCmd.Flags().BoolVar(&historyBetween, "between", false, "Show only snapshots <from> to <to>, with lines changed")
+	historyCmd.Flags().StringVar(&historyExport, "export", "", "Write a markdown change report to this file")
+	historyCmd.Flags().BoolVar(&historyDiffs, "diffs", false, "Include each snapshot's diff in the exported report")
 	rootCmd.AddCommand(historyCmd)
 }
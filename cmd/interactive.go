@@ -0,0 +1,165 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+// runInteractive runs when oops is called without a command. In a terminal
+// in a directory with tracked files it offers a menu of the everyday
+// commands; otherwise it shows the help.
+func runInteractive(cmd *cobra.Command, args []string) error {
+	if !isTerminal(os.Stdin) || !isTerminal(os.Stdout) {
+		return cmd.Help()
+	}
+	stores := trackedHere()
+	if len(stores) == 0 {
+		return cmd.Help()
+	}
+
+	s := stores[0]
+	if len(stores) > 1 {
+		var ok bool
+		if s, ok = chooseStore(stores); !ok {
+			return nil
+		}
+	}
+
+	for {
+		fmt.Println()
+		current, latest, hasChanges, err := s.Now()
+		if err != nil {
+			fail("Failed to get status: %v", err)
+			return nil
+		}
+		state := "clean"
+		if hasChanges {
+			state = "modified"
+		}
+		if last, stale := staleSince(s, hasChanges, staleAfter()); stale {
+			state += ", last saved " + formatTimeAgo(last)
+		}
+		if current == latest {
+			fmt.Printf("📄 %s — snapshot #%d, %s\n\n", s.FileName, current, state)
+		} else {
+			fmt.Printf("📄 %s — snapshot #%d of %d, %s\n\n", s.FileName, current, latest, state)
+		}
+		fmt.Println("  1) Status   2) Save   3) History   4) Go back   q) Quit")
+
+		choice, ok := prompt("Choose")
+		if !ok {
+			return nil
+		}
+		fmt.Println()
+		switch strings.ToLower(choice) {
+		case "1", "s", "status":
+			showStatus(s)
+		case "2", "save":
+			message, ok := prompt("Message (Enter for none)")
+			if !ok {
+				return nil
+			}
+			saveSnapshot(s, message)
+		case "3", "h", "history":
+			showHistory(s)
+		case "4", "b", "back":
+			interactiveBack(s, hasChanges)
+		case "q", "quit", "exit":
+			return nil
+		case "":
+		default:
+			warn("Unknown choice %q", choice)
+		}
+	}
+}
+
+// interactiveBack asks which snapshot to go back to. Changes the on_dirty
+// policy would block on are discarded only after asking.
+func interactiveBack(s *store.Store, hasChanges bool) {
+	showHistory(s)
+	fmt.Println()
+	answer, ok := prompt("Go back to snapshot #")
+	if !ok || answer == "" {
+		return
+	}
+	num, err := strconv.Atoi(strings.TrimPrefix(answer, "#"))
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", answer)
+		return
+	}
+
+	force := false
+	if cfg, err := config.Load(); err == nil && hasChanges && cfg.OnDirtyPolicy("back") == config.DirtyBlock {
+		if !confirm("You have unsaved changes. Discard them?") {
+			info("Cancelled")
+			return
+		}
+		force = true
+	}
+	goBack(s, num, force)
+}
+
+// trackedHere returns the files tracked in the current directory, locally
+// and globally
+func trackedHere() []*store.Store {
+	cwd, err := os.Getwd()
+	if err != nil {
+		return nil
+	}
+	stores := localStores(cwd)
+	for _, g := range globalStores() {
+		if filepath.Dir(g.FilePath) == cwd {
+			stores = append(stores, g)
+		}
+	}
+	return stores
+}
+
+// chooseStore asks which of several tracked files to work on
+func chooseStore(stores []*store.Store) (*store.Store, bool) {
+	fmt.Println("📂 Tracked files here:")
+	for i, s := range stores {
+		where := ""
+		if s.Global {
+			where = " (global)"
+		}
+		fmt.Printf("  %d) %s%s\n", i+1, s.FileName, where)
+	}
+	for {
+		answer, ok := prompt("File")
+		if !ok || answer == "q" {
+			return nil, false
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(stores) {
+			return stores[n-1], true
+		}
+		warn("Enter a number from 1 to %d, or q to quit", len(stores))
+	}
+}
+
+// prompt reads a line from stdin after showing label. It returns false at
+// the end of input.
+func prompt(label string) (string, bool) {
+	fmt.Printf("%s: ", label)
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Println()
+		return "", false
+	}
+	return strings.TrimSpace(line), true
+}
+
+// isTerminal reports whether f is an interactive terminal rather than a
+// pipe or file
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
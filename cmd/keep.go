@@ -0,0 +1,80 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var keepCmd = &cobra.Command{
+	Use:   "keep <file> [message]",
+	Short: "📌 Start tracking a file if needed, then save a snapshot",
+	Long: `Keep the current state of a file in one step, whether or not it is
+tracked yet. An untracked file is started with the message as snapshot #1;
+a tracked one gets a new snapshot, or nothing if it has not changed.
+
+Safe to run again and again, e.g. from scripts or before every edit.
+Without -g or -l, a file tracked globally is saved globally.
+
+Examples:
+  oops keep config.yaml "before edit"
+  oops keep -g ~/.bashrc`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runKeep,
+}
+
+func runKeep(cmd *cobra.Command, args []string) error {
+	filePath := args[0]
+	message := ""
+	if len(args) > 1 {
+		message = strings.TrimSpace(args[1])
+	}
+
+	if !utils.IsFile(filePath) {
+		fail("'%s' is not a valid file", filePath)
+		return nil
+	}
+
+	explicit := cmd.Flags().Changed("global") || cmd.Flags().Changed("local")
+	s, err := keepStore(filePath, explicit)
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if s.Exists() {
+		reportRecovery(s)
+		return saveSnapshot(s, message)
+	}
+
+	if from, err := s.FindHistory(); err == nil && from != nil {
+		if attachHistory(s, from) {
+			return saveSnapshot(s, message)
+		}
+		return nil
+	}
+	startTracking(s, message)
+	return nil
+}
+
+// keepStore returns the store of filePath: unless the mode was given
+// explicitly, the one already tracking it, otherwise one in the selected
+// mode (which may not exist yet)
+func keepStore(filePath string, explicit bool) (*store.Store, error) {
+	if !explicit {
+		if s, err := store.NewStore(filePath); err == nil && s.Exists() {
+			return s, nil
+		}
+		if s, err := store.FindGlobalStore(filePath); err == nil {
+			return s, nil
+		}
+	}
+	return store.NewStoreWithOptions(filePath, store.StoreOptions{Global: globalFlag})
+}
+
+func init() {
+	keepCmd.Flags().BoolVarP(&saveYes, "yes", "y", false, "Save without checking for credentials")
+	rootCmd.AddCommand(keepCmd)
+}
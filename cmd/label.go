@@ -0,0 +1,132 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var labelCmd = &cobra.Command{
+	Use:   "label",
+	Short: "🏷️ Group tracked files with labels",
+	Long: `Attach labels to tracked files to group them by purpose, then filter
+'oops files' by label.
+
+Examples:
+  oops label add ~/.zshrc dotfiles     Label a tracked file
+  oops label remove ~/.zshrc dotfiles  Remove a label
+  oops label list                      Show labels in use
+  oops files -g --label dotfiles       List only files with a label`,
+}
+
+var labelAddCmd = &cobra.Command{
+	Use:               "add <file> <label>...",
+	Short:             "Add labels to a tracked file",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runLabelAdd,
+}
+
+var labelRemoveCmd = &cobra.Command{
+	Use:               "remove <file> <label>...",
+	Short:             "Remove labels from a tracked file",
+	Args:              cobra.MinimumNArgs(2),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runLabelRemove,
+}
+
+var labelListCmd = &cobra.Command{
+	Use:               "list [file]",
+	Short:             "Show labels of a file, or all labels in use",
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runLabelList,
+}
+
+func runLabelAdd(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if err := s.AddLabels(args[1:]...); err != nil {
+		fail("Failed to add labels: %v", err)
+		return nil
+	}
+	success("%s: %s", s.FileName, strings.Join(s.Labels(), ", "))
+	return nil
+}
+
+func runLabelRemove(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if err := s.RemoveLabels(args[1:]...); err != nil {
+		fail("Failed to remove labels: %v", err)
+		return nil
+	}
+	if labels := s.Labels(); len(labels) > 0 {
+		success("%s: %s", s.FileName, strings.Join(labels, ", "))
+	} else {
+		success("%s has no labels", s.FileName)
+	}
+	return nil
+}
+
+func runLabelList(cmd *cobra.Command, args []string) error {
+	if len(args) == 1 {
+		s, err := findStoreForPath(args[0])
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		labels := s.Labels()
+		if len(labels) == 0 {
+			info("%s has no labels", s.FileName)
+			return nil
+		}
+		fmt.Printf("🏷️  %s: %s\n", s.FileName, strings.Join(labels, ", "))
+		return nil
+	}
+
+	var stores []*store.Store
+	if cwd, err := os.Getwd(); err == nil {
+		stores = append(stores, localStores(cwd)...)
+	}
+	stores = append(stores, globalStores()...)
+
+	counts := make(map[string]int)
+	for _, s := range stores {
+		for _, l := range s.Labels() {
+			counts[l]++
+		}
+	}
+	if len(counts) == 0 {
+		info("No labels yet")
+		info("Use 'oops label add <file> <label>' to add one")
+		return nil
+	}
+
+	names := make([]string, 0, len(counts))
+	for l := range counts {
+		names = append(names, l)
+	}
+	sort.Strings(names)
+
+	fmt.Println("🏷️  Labels:")
+	for _, l := range names {
+		fmt.Printf("  %-20s %d %s\n", l, counts[l], plural(counts[l], "file"))
+	}
+	return nil
+}
+
+func init() {
+	labelCmd.AddCommand(labelAddCmd, labelRemoveCmd, labelListCmd)
+	rootCmd.AddCommand(labelCmd)
+}
@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	"github.com/iyulab/oops/internal/fusefs"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var mountCmd = &cobra.Command{
+	Use:   "mount <file> <dir>",
+	Short: "📂 Browse every snapshot as a read-only file in a folder",
+	Long: `Mount a read-only folder holding every snapshot of a file as its own
+file, v1.txt, v2.txt and so on, named after the file's extension. Browse
+them with any file manager, or grep and diff across versions directly.
+Snapshots saved while mounted appear in the folder too.
+
+The folder must exist. It stays mounted until Ctrl+C, or until unmounted
+with 'fusermount -u <dir>'. Mounting uses FUSE and is available on Linux.
+
+Examples:
+  oops mount notes.md /tmp/notes       Versions as /tmp/notes/v1.md, v2.md, …
+  grep -l TODO /tmp/notes/*            Versions that still mention TODO`,
+	Args: cobra.ExactArgs(2),
+	RunE: runMount,
+}
+
+func runMount(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	dir := args[1]
+
+	srv, err := fusefs.Mount(dir, versionEntries(s))
+	if err != nil {
+		if errors.Is(err, fusefs.ErrUnsupported) {
+			fail("Mounting needs FUSE, which oops supports on Linux only")
+			info("Use 'oops show <version>' or 'oops history' to browse snapshots instead")
+			return nil
+		}
+		fail("Failed to mount %s: %v", dir, err)
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		<-ctx.Done()
+		srv.Unmount()
+	}()
+
+	success("Mounted the snapshots of %s at %s", s.FileName, dir)
+	info("Press Ctrl+C to unmount")
+	if err := srv.Serve(); err != nil {
+		srv.Unmount()
+		fail("Mount failed: %v", err)
+		return nil
+	}
+	fmt.Println()
+	success("Unmounted %s", dir)
+	return nil
+}
+
+// versionEntries lists every snapshot of s as a file named after its
+// number, with the tracked file's extension
+func versionEntries(s *store.Store) fusefs.Lister {
+	ext := filepath.Ext(s.FileName)
+	return func() ([]fusefs.Entry, error) {
+		history, err := s.History()
+		if err != nil {
+			return nil, err
+		}
+		var entries []fusefs.Entry
+		for i := len(history) - 1; i >= 0; i-- { // History is newest first
+			snap := history[i]
+			if snap.Number < 1 {
+				continue
+			}
+			num := snap.Number
+			entries = append(entries, fusefs.Entry{
+				Name:    fmt.Sprintf("v%d%s", num, ext),
+				ModTime: snap.Timestamp,
+				Content: func() ([]byte, error) { return s.Content(num) },
+			})
+		}
+		return entries, nil
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(mountCmd)
+}
@@ -0,0 +1,67 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var mvCmd = &cobra.Command{
+	Use:     "mv <old> <new>",
+	Aliases: []string{"move", "rename"},
+	Short:   "🚚 Rename a tracked file and keep its history",
+	Long: `Rename or move a tracked file, taking its snapshots along.
+
+Renaming a tracked file by other means leaves its history behind under
+the old name. 'oops mv' renames the file and moves the history with it,
+whether it is kept locally or globally. If the file was already renamed,
+only the history is moved; 'oops now' points this out when it sees it.
+
+When <new> is a directory the file keeps its name there.
+
+Examples:
+  oops mv draft.md essay.md
+  oops mv notes.txt ../archive/
+  oops mv -g report.docx report-final.docx`,
+	Args:              cobra.ExactArgs(2),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runMv,
+}
+
+func runMv(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	newPath := args[1]
+	toDir := newPath != "" && os.IsPathSeparator(newPath[len(newPath)-1])
+	if fi, err := os.Stat(newPath); toDir || (err == nil && fi.IsDir()) {
+		newPath = filepath.Join(newPath, s.FileName)
+	}
+
+	moved, err := s.Move(newPath)
+	if err != nil {
+		if errors.Is(err, store.ErrAlreadyTracked) {
+			fail("%s is already tracked", newPath)
+			info("Use 'oops done' on it first if its history can go")
+			return nil
+		}
+		if reportLocked(err) {
+			return nil
+		}
+		fail("Failed to move: %v", err)
+		return nil
+	}
+
+	success("Moved %s to %s with its history", s.FilePath, moved.FilePath)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(mvCmd)
+}
@@ -0,0 +1,142 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/templates"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	newTemplate string
+	newList     bool
+)
+
+var newCmd = &cobra.Command{
+	Use:   "new <file>",
+	Short: "🆕 Create a file and start versioning it",
+	Long: `Create a new file, optionally from a template, and start tracking it.
+
+Templates are files in ~/.oops/templates. Name them by file name, with or
+without the extension.
+
+Examples:
+  oops new notes.md                            Create an empty tracked file
+  oops new report.md --template weekly-report  Start from ~/.oops/templates/weekly-report.md
+  oops new --list                              Show available templates`,
+	Args: func(cmd *cobra.Command, args []string) error {
+		if newList {
+			return cobra.NoArgs(cmd, args)
+		}
+		return cobra.ExactArgs(1)(cmd, args)
+	},
+	RunE: runNew,
+}
+
+func runNew(cmd *cobra.Command, args []string) error {
+	if newList {
+		return runNewList()
+	}
+
+	filePath := args[0]
+	if _, err := os.Stat(filePath); err == nil {
+		fail("'%s' already exists", filePath)
+		info("Use 'oops start %s' to track it", filePath)
+		return nil
+	}
+
+	var content []byte
+	if newTemplate != "" {
+		tmpl, err := templates.Find(newTemplate)
+		if err != nil {
+			fail("%v", err)
+			if errors.Is(err, templates.ErrNotFound) {
+				info("Use 'oops new --list' to see available templates")
+			}
+			return nil
+		}
+		content, err = os.ReadFile(tmpl.Path)
+		if err != nil {
+			fail("Failed to read template: %v", err)
+			return nil
+		}
+		newTemplate = tmpl.Name
+	}
+
+	s, err := store.NewStoreWithOptions(filePath, store.StoreOptions{Global: globalFlag})
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if s.Exists() {
+		fail("'%s' has history from an earlier file with this name", s.FileName)
+		info("Use 'oops done %s' to remove it first", s.FileName)
+		return nil
+	}
+
+	if err := os.WriteFile(s.FilePath, content, 0644); err != nil {
+		fail("Failed to create file: %v", err)
+		return nil
+	}
+
+	if err := s.Initialize(); err != nil {
+		os.Remove(s.FilePath)
+		if reportTooLarge(err, true) {
+			return nil
+		}
+		fail("Failed to start tracking: %v", err)
+		return nil
+	}
+	if newTemplate != "" {
+		if err := s.SetTemplate(newTemplate); err != nil {
+			warn("Could not record template: %v", err)
+		}
+	}
+
+	// Add to .gitignore if present (only for local mode)
+	if !globalFlag {
+		utils.EnsureGitignore(s.BaseDir)
+	}
+
+	if newTemplate != "" {
+		success("Created '%s' from template '%s' (snapshot #1)", s.FileName, newTemplate)
+	} else {
+		success("Created '%s' (snapshot #1)", s.FileName)
+	}
+	if globalFlag {
+		info("Storage: %s", s.OopsDirPath())
+	}
+	info("Use 'oops save \"message\"' to save changes")
+	return nil
+}
+
+func runNewList() error {
+	list, err := templates.List()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+
+	dir, _ := templates.Dir()
+	if len(list) == 0 {
+		info("No templates yet")
+		info("Add files to %s to use them with 'oops new --template'", dir)
+		return nil
+	}
+
+	fmt.Printf("📋 Templates (%s):\n", dir)
+	for _, t := range list {
+		fmt.Printf("  %s\n", t.Name)
+	}
+	return nil
+}
+
+func init() {
+	newCmd.Flags().StringVarP(&newTemplate, "template", "t", "", "Create the file from this template")
+	newCmd.Flags().BoolVar(&newList, "list", false, "List available templates")
+	rootCmd.AddCommand(newCmd)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var noteRemove bool
+
+var noteCmd = &cobra.Command{
+	Use:   "note <version> [text]",
+	Short: "📝 Add a note to a snapshot",
+	Long: `Attach a note to a snapshot after it was saved, e.g. to mark the version
+that was shipped. Notes are shown by 'oops history' and 'oops now'.
+Running note again replaces the note; without text it is shown.
+
+Examples:
+  oops note 4 "this is the version we shipped"
+  oops note 4           Show the note of snapshot #4
+  oops note 4 --remove  Remove it`,
+	Args:              cobra.RangeArgs(1, 2),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runNote,
+}
+
+func runNote(cmd *cobra.Command, args []string) error {
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[0])
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	if len(args) == 1 && !noteRemove {
+		snap, err := findSnapshot(s, num)
+		if err != nil {
+			fail("Snapshot #%d not found", num)
+			return nil
+		}
+		if note := s.Note(*snap); note != "" {
+			fmt.Printf("📝 #%d: %s\n", num, note)
+		} else {
+			info("Snapshot #%d has no note", num)
+			info("Use 'oops note %d \"text\"' to add one", num)
+		}
+		return nil
+	}
+
+	text := ""
+	if !noteRemove {
+		text = args[1]
+	}
+	if err := s.SetNote(num, text); err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		fail("Failed to save the note: %v", err)
+		return nil
+	}
+	if noteRemove {
+		success("Note removed from snapshot #%d", num)
+	} else {
+		success("Note added to snapshot #%d", num)
+	}
+	return nil
+}
+
+// findSnapshot returns snapshot num of s
+func findSnapshot(s *store.Store, num int) (*store.Snapshot, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	for i := range history {
+		if history[i].Number == num {
+			return &history[i], nil
+		}
+	}
+	return nil, store.ErrVersionNotFound
+}
+
+func init() {
+	noteCmd.Flags().BoolVarP(&noteRemove, "remove", "d", false, "Remove the snapshot's note")
+	rootCmd.AddCommand(noteCmd)
+}
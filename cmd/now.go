@@ -2,6 +2,9 @@ package cmd
 
 import (
 	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
 
 	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
@@ -22,11 +25,21 @@ func runNow(cmd *cobra.Command, args []string) error {
 		fail("%v", err)
 		return nil
 	}
+	showStatus(s)
+	return nil
+}
+
+// showStatus prints the tracking status of s
+func showStatus(s *store.Store) {
+	if _, err := os.Stat(s.FilePath); os.IsNotExist(err) {
+		reportMissing(s)
+		return
+	}
 
 	current, latest, hasChanges, err := s.Now()
 	if err != nil {
 		fail("Failed to get status: %v", err)
-		return nil
+		return
 	}
 
 	fmt.Printf("📄 File:     %s\n", s.FileName)
@@ -35,16 +48,58 @@ func runNow(cmd *cobra.Command, args []string) error {
 		fmt.Printf("🌐 Mode:     Global (%s)\n", s.OopsDirPath())
 	}
 
+	if profile, others := profileSummary(s); profile != "" {
+		if len(others) > 0 {
+			fmt.Printf("🔀 Profile:  %s (also %s)\n", profile, strings.Join(others, ", "))
+		} else {
+			fmt.Printf("🔀 Profile:  %s\n", profile)
+		}
+	}
+
+	if tmpl := s.Template(); tmpl != "" {
+		fmt.Printf("📋 Template: %s\n", tmpl)
+	}
+
+	if m := s.Region(); m != nil {
+		fmt.Printf("✂️  Region:   %s\n", m)
+	}
+
+	if labels := s.Labels(); len(labels) > 0 {
+		fmt.Printf("🏷️  Labels:   %s\n", strings.Join(labels, ", "))
+	}
+
 	if current == latest {
 		fmt.Printf("📍 Snapshot: #%d (latest)\n", current)
 	} else {
 		fmt.Printf("📍 Snapshot: #%d (latest is #%d)\n", current, latest)
 	}
+	if snap, err := findSnapshot(s, current); err == nil {
+		if note := s.Note(*snap); note != "" {
+			fmt.Printf("📝 Note:     %s\n", note)
+		}
+	}
+
+	if blessed, when, err := s.Blessed(); err == nil && blessed != nil {
+		fmt.Printf("🏅 Blessed:  #%d (%s)\n", blessed.Number, formatTimeAgo(when))
+	}
+
+	if pending, err := s.Pending(); err == nil && len(pending) > 0 {
+		var size int64
+		for _, p := range pending {
+			size += p.Size
+		}
+		fmt.Printf("⏳ Saving:   %d %s (%s) in the background\n",
+			len(pending), plural(len(pending), "snapshot"), formatBytes(size))
+	}
 
 	if hasChanges {
 		fmt.Printf("✏️  Status:   Modified\n")
 		fmt.Println()
-		info("You have unsaved changes")
+		if last, stale := staleSince(s, hasChanges, staleAfter()); stale {
+			warn("Your changes are unsaved and the last snapshot was taken %s", formatTimeAgo(last))
+		} else {
+			info("You have unsaved changes")
+		}
 		info("  oops save    Save your changes")
 		info("  oops oops!   Undo changes")
 	} else {
@@ -56,11 +111,48 @@ func runNow(cmd *cobra.Command, args []string) error {
 	if hasLocal && hasGlobal {
 		fmt.Println()
 		warn("This file is tracked in both local and global storage!")
-		info("  oops done      Stop local tracking")
-		info("  oops done -g   Stop global tracking")
+		info("  oops compare-stores  Compare and merge the histories")
+		info("  oops done            Stop local tracking")
+		info("  oops done -g         Stop global tracking")
 	}
+}
 
-	return nil
+// reportMissing tells the user the tracked file is gone, pointing at the
+// file it seems to have been renamed to
+func reportMissing(s *store.Store) {
+	mode := ""
+	if s.Global {
+		mode = " -g"
+	}
+	warn("%s no longer exists", s.FileName)
+	moved, _ := s.FindMoved()
+	if moved == "" {
+		info("If it was renamed, move its history along with:")
+		info("  oops mv%s %s <new-name>", mode, s.FileName)
+		return
+	}
+	name := filepath.Base(moved)
+	info("It looks like it was renamed to %s; move its history along with:", name)
+	info("  oops mv%s %s %s", mode, s.FileName, name)
+}
+
+// profileSummary returns the store's profile and the file's other
+// profiles, or "" when the file has only its default history
+func profileSummary(s *store.Store) (string, []string) {
+	names, _ := s.Profiles()
+	if s.IsDefaultProfile() {
+		if len(names) == 0 {
+			return "", nil
+		}
+		return store.DefaultProfile, names
+	}
+	others := []string{store.DefaultProfile}
+	for _, name := range names {
+		if name != s.Profile {
+			others = append(others, name)
+		}
+	}
+	return s.Profile, others
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"fmt"
 	"strconv"
 
 	"github.com/iyulab/oops/internal/store"
@@ -12,13 +13,23 @@ var oopsBackCmd = &cobra.Command{
 	Short: "↩️ Quick undo (go back one snapshot or to specific version)",
 	Long: `Quick way to undo changes or go back.
 
+Repeating oops! keeps stepping back through the states the file was
+recently in, like undo in an editor. Use --redo to walk forward again.
+
 Examples:
-  oops oops!       Go back to previous snapshot
-  oops oops! 2     Go to snapshot #2 (same as 'back 2')`,
+  oops oops!         Go back to previous state
+  oops oops! --redo  Redo the last oops!
+  oops oops! 2       Go to snapshot #2 (same as 'back 2')
+
+Unsaved changes are discarded by default. Set oops.on_dirty to backup to
+save them as a snapshot first, or to block to refuse:
+  oops config --on-dirty oops=backup`,
 	Args: cobra.MaximumNArgs(1),
 	RunE: runOopsBack,
 }
 
+var oopsBackRedo bool
+
 func runOopsBack(cmd *cobra.Command, args []string) error {
 	s, err := findTrackedStore()
 	if err != nil {
@@ -26,6 +37,32 @@ func runOopsBack(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
+	if oopsBackRedo {
+		if len(args) > 0 {
+			fail("--redo does not take a snapshot number")
+			return nil
+		}
+		if !s.CanRedo() {
+			info("Nothing to redo")
+			return nil
+		}
+		if !handleUnsavedChanges(s, "oops", false) {
+			return nil
+		}
+		num, err := s.Redo()
+		if err != nil {
+			if err == store.ErrNothingToRedo {
+				info("Nothing to redo")
+				return nil
+			}
+			fail("Failed to redo: %v", err)
+			return nil
+		}
+		success("Redid: back at snapshot #%d", num)
+		reportChainFailures(s)
+		return nil
+	}
+
 	// If version specified, go to that version
 	if len(args) > 0 {
 		num, err := strconv.Atoi(args[0])
@@ -37,14 +74,17 @@ func runOopsBack(cmd *cobra.Command, args []string) error {
 	}
 
 	// Otherwise, check if there are unsaved changes
-	_, latest, hasChanges, err := s.Now()
+	_, _, hasChanges, err := s.Now()
 	if err != nil {
 		fail("%v", err)
 		return nil
 	}
 
 	if hasChanges {
-		// Undo unsaved changes (restore to HEAD)
+		// Undo unsaved changes (restore the current position)
+		if !handleUnsavedChanges(s, "oops", false) {
+			return nil
+		}
 		if err := s.Undo(); err != nil {
 			fail("Failed to undo: %v", err)
 			return nil
@@ -53,16 +93,30 @@ func runOopsBack(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	// Go to previous snapshot
-	if latest <= 1 {
-		info("Already at the first snapshot")
+	// Step back to the previous state
+	num, err := s.StepBack()
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			info("Already at the first snapshot")
+			return nil
+		}
+		fail("Failed: %v", err)
 		return nil
 	}
-
-	return runBackToVersion(s, latest-1)
+	success("Went back to snapshot #%d", num)
+	reportChainFailures(s)
+	info("Run 'oops oops! --redo' to undo this")
+	return nil
 }
 
 func runBackToVersion(s *store.Store, num int) error {
+	if !s.Repo.HasTag(fmt.Sprintf("v%d", num)) {
+		fail("Snapshot #%d not found", num)
+		return nil
+	}
+	if !handleUnsavedChanges(s, "oops", false) {
+		return nil
+	}
 	if err := s.Back(num, true); err != nil {
 		if err == store.ErrVersionNotFound {
 			fail("Snapshot #%d not found", num)
@@ -72,9 +126,11 @@ func runBackToVersion(s *store.Store, num int) error {
 		return nil
 	}
 	success("Went back to snapshot #%d", num)
+	reportChainFailures(s)
 	return nil
 }
 
 func init() {
+	oopsBackCmd.Flags().BoolVar(&oopsBackRedo, "redo", false, "Walk forward again after oops!")
 	rootCmd.AddCommand(oopsBackCmd)
 }
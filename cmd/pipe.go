@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"os"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var pipeCmd = &cobra.Command{
+	Use:   "pipe <file> [message]",
+	Short: "📥 Write stdin to a file and save a snapshot",
+	Long: `Replace a file with what is piped in and save it as a snapshot in one
+step, e.g. to keep the history of a generated config. A file that is not
+tracked yet is tracked, starting with the piped content.
+
+There is no one to ask about likely credentials since stdin holds the
+content, so they are warned about and saved. Unsaved changes to a tracked
+file are handled as by back (see back.on_dirty) before it is replaced;
+--force discards them. Use 'oops save --stdin' to
+do the same for the tracked file in the current directory.
+
+Examples:
+  kubectl get cm app -o yaml | oops pipe app.yaml
+  ./gen-config | oops pipe nginx.conf "after adding the cache"`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runPipe,
+}
+
+var forcePipe bool
+
+func runPipe(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		// Not tracked yet; it is started from the piped content
+		if s, err = getStoreForFile(args[0]); err != nil {
+			fail("%v", err)
+			return nil
+		}
+	}
+
+	message := ""
+	if len(args) > 1 {
+		message = strings.TrimSpace(args[1])
+	}
+	return pipeSnapshot(s, message, forcePipe)
+}
+
+// pipeSnapshot writes stdin to the file of s and saves it, starting to
+// track the file if needed. Unsaved changes are handled first, unless
+// force discards them.
+func pipeSnapshot(s *store.Store, message string, force bool) error {
+	if isTerminal(os.Stdin) {
+		fail("Nothing is piped in")
+		info("Pipe the new content of %s in, e.g. some-command | oops pipe %s", s.FileName, s.FileName)
+		return nil
+	}
+	if s.Exists() && !handleUnsavedChanges(s, "back", force) {
+		info("Or replace them anyway with --force")
+		return nil
+	}
+	if err := s.WriteFrom(os.Stdin); err != nil {
+		if !reportLocked(err) {
+			fail("Failed to write %s: %v", s.FileName, err)
+		}
+		return nil
+	}
+
+	if !s.Exists() {
+		startTracking(s, message)
+		return nil
+	}
+	if findings, err := s.ScanSecrets(); err == nil && len(findings) > 0 {
+		warn("The input may contain credentials; to mask them use: oops config --redact <regex>")
+	}
+	saveYes = true // stdin held the content, so there is no one to ask
+	return saveSnapshot(s, message)
+}
+
+func init() {
+	pipeCmd.Flags().BoolVarP(&forcePipe, "force", "f", false, "Discard unsaved changes")
+	rootCmd.AddCommand(pipeCmd)
+}
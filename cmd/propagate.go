@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var propagateCmd = &cobra.Command{
+	Use:   "propagate <template-file> <dir-glob>",
+	Short: "🌱 Track copies of a file, each starting from its history",
+	Long: `Start tracking every copy of a tracked file, such as worksheets handed
+out to a class, with a copy of the file's history. Each copy then saves
+its own snapshots and diverges from the others.
+
+The pattern may match the copies or the directories holding them; in a
+directory, the copy is the file with the template's name. A copy that
+already differs from the template's latest snapshot has its changes
+saved as its first own snapshot. Copies that are already tracked are
+skipped. Quote the pattern so oops expands it.
+
+Examples:
+  oops propagate worksheet.docx "students/*"
+  oops propagate -g lab.ini "/srv/pcs/*/config/lab.ini"`,
+	Args: cobra.ExactArgs(2),
+	RunE: runPropagate,
+}
+
+func runPropagate(cmd *cobra.Command, args []string) error {
+	tmpl, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	copies, err := propagateTargets(tmpl, args[1])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if len(copies) == 0 {
+		fail("No copies of %s match %s", tmpl.FileName, args[1])
+		return nil
+	}
+
+	results := make([]startResult, 0, len(copies))
+	for _, path := range copies {
+		results = append(results, propagateOne(tmpl, path))
+	}
+	printStartResults(results)
+
+	started := countOutcome(results, "started")
+	if failed := countOutcome(results, "failed"); failed > 0 {
+		fail("%d of %d copies could not be tracked", failed, len(copies))
+		return nil
+	}
+	success("%d %s of %s now tracked, each with its own history", started, copyNoun(started), tmpl.FileName)
+	return nil
+}
+
+// propagateTargets returns the copies of the template's file matched by
+// pattern: matching files, and the file of the same name in matching
+// directories
+func propagateTargets(tmpl *store.Store, pattern string) ([]string, error) {
+	matches, err := filepath.Glob(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("bad pattern %s: %w", pattern, err)
+	}
+	var copies []string
+	for _, m := range matches {
+		if utils.IsDir(m) {
+			m = filepath.Join(m, tmpl.FileName)
+		}
+		abs, err := filepath.Abs(m)
+		if err != nil || abs == tmpl.FilePath || !utils.IsFile(m) {
+			continue
+		}
+		copies = append(copies, m)
+	}
+	return copies, nil
+}
+
+// propagateOne starts tracking the copy at path from the template's
+// history, for the result table
+func propagateOne(tmpl *store.Store, path string) startResult {
+	r := startResult{path: path}
+	target, err := store.NewStoreWithOptions(path, store.StoreOptions{Global: globalFlag})
+	if err != nil {
+		r.outcome, r.detail = "failed", err.Error()
+		return r
+	}
+	r.store = target
+
+	snap, err := tmpl.Propagate(target)
+	switch {
+	case errors.Is(err, store.ErrAlreadyTracked):
+		r.outcome, r.detail = "skipped", "already tracked"
+	case err != nil:
+		r.outcome, r.detail = "failed", startFailure(err)
+	default:
+		r.outcome = "started"
+		if snap != nil {
+			r.detail = fmt.Sprintf("its changes saved as snapshot #%d", snap.Number)
+		}
+		if !globalFlag {
+			utils.EnsureGitignore(target.BaseDir)
+			target.RegisterLocal()
+		}
+	}
+	return r
+}
+
+// copyNoun returns "copy" or "copies" for n
+func copyNoun(n int) string {
+	if n == 1 {
+		return "copy"
+	}
+	return "copies"
+}
+
+func init() {
+	rootCmd.AddCommand(propagateCmd)
+}
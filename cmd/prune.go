@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	pruneKeepLast  int
+	pruneOlderThan string
+	pruneDryRun    bool
+	pruneYes       bool
+)
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "✂️ Remove old snapshots by retention policy",
+	Long: `Remove snapshots beyond a retention limit, so histories do not grow
+without bound (watch and schedules can save many snapshots). The latest
+snapshot is always kept, and kept snapshots keep their numbers.
+
+Without flags, the retention in ~/.oops/config is applied:
+  prune_keep_last    Most snapshots to keep, e.g. 50
+  prune_older_than   Remove snapshots older than this, e.g. 90d
+  compact=true       Also thin history by age (see 'oops gc --help');
+                     compact_keep_daily=30d keeps one per week after 30 days
+With auto_prune=true, the same retention is applied after every watch or
+scheduled snapshot.
+
+The snapshots to be removed are listed with about how much disk they
+free, counted from the objects only they use, and you are asked to
+confirm unless --yes is given. Pruned snapshots cannot be restored.
+
+Examples:
+  oops prune --keep-last 20      Keep the newest 20 snapshots
+  oops prune --older-than 90d    Remove snapshots older than 90 days
+  oops prune --dry-run           Show what the configured retention removes`,
+	Args: cobra.NoArgs,
+	RunE: runPrune,
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	retention, ok := pruneRetention()
+	if !ok {
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	now := time.Now()
+	drop, err := s.PlanRetention(retention, now)
+	if err != nil {
+		fail("Failed to plan pruning: %v", err)
+		return nil
+	}
+	if len(drop) == 0 {
+		success("Nothing to prune in %s", s.FileName)
+		return nil
+	}
+
+	history, _ := s.History()
+	fmt.Printf("✂️  Remove %d of %d snapshots of %s:\n", len(drop), len(history), s.FileName)
+	for _, snap := range drop {
+		fmt.Printf("    #%-4d %-30s %s\n", snap.Number, snap.Message, formatTimeAgo(snap.Timestamp))
+	}
+	if freed, err := s.ReclaimEstimate(drop); err == nil {
+		info("Frees about %s", formatBytes(freed))
+	}
+
+	if pruneDryRun {
+		info("Dry run - no changes made")
+		return nil
+	}
+	if !pruneYes && !confirm("\nRemove these snapshots?") {
+		info("Cancelled")
+		return nil
+	}
+
+	removed, err := s.Retain(retention, now)
+	if err != nil {
+		fail("Failed to prune: %v", err)
+		return nil
+	}
+	success("Removed %d %s", removed, plural(removed, "snapshot"))
+	return nil
+}
+
+// pruneRetention returns the retention given by the flags, or the
+// configured one without them. It returns false if there is none.
+func pruneRetention() (store.Retention, bool) {
+	var limits store.PrunePolicy
+	limits.KeepLast = pruneKeepLast
+	if pruneOlderThan != "" {
+		d, err := config.ParseDuration(pruneOlderThan)
+		if err != nil || d <= 0 {
+			fail("Invalid age: %s (use e.g. 90d or 12h)", pruneOlderThan)
+			return store.Retention{}, false
+		}
+		limits.OlderThan = d
+	}
+	if pruneKeepLast < 0 {
+		fail("--keep-last must be 1 or more")
+		return store.Retention{}, false
+	}
+	if !limits.IsZero() {
+		return store.Retention{Prune: limits}, true
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		cfg = config.DefaultConfig()
+	}
+	retention := store.RetentionFromConfig(cfg)
+	if retention.IsZero() {
+		fail("No retention to apply")
+		info("Use --keep-last N or --older-than 90d, or set prune_keep_last or prune_older_than in ~/.oops/config")
+		return store.Retention{}, false
+	}
+	return retention, true
+}
+
+func init() {
+	pruneCmd.Flags().IntVar(&pruneKeepLast, "keep-last", 0, "Keep only the newest N snapshots")
+	pruneCmd.Flags().StringVar(&pruneOlderThan, "older-than", "", "Remove snapshots older than this age, e.g. 90d")
+	pruneCmd.Flags().BoolVar(&pruneDryRun, "dry-run", false, "Show what would be removed")
+	pruneCmd.Flags().BoolVarP(&pruneYes, "yes", "y", false, "Remove without asking")
+	rootCmd.AddCommand(pruneCmd)
+}
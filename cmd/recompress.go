@@ -0,0 +1,59 @@
+package cmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+var recompressLevel int
+var recompressZstd bool
+
+var recompressCmd = &cobra.Command{
+	Use:   "recompress",
+	Short: "🗜  Rewrite a history with stronger compression",
+	Long: `Rewrite the stored history of the tracked file in one pack, each
+snapshot kept as a delta against the one before it and compressed at the
+given level, and report the size before and after. Histories saved by
+older versions of oops benefit the most. Snapshots are not changed.
+
+Levels run from 0 (no compression) to 9 (smallest, the default).
+'oops gc' packs histories at the default level.
+
+--zstd is refused: a history is a git repository, whose packs can only
+hold zlib-compressed objects, so oops and git could not read it back.
+
+Examples:
+  oops recompress            Smallest history
+  oops recompress --level 1  Faster to write, larger`,
+	Args: cobra.NoArgs,
+	RunE: runRecompress,
+}
+
+func runRecompress(cmd *cobra.Command, args []string) error {
+	if recompressZstd {
+		fail("--zstd is not supported: git packs hold only zlib-compressed objects")
+		info("Use 'oops recompress --level 9' for the smallest history")
+		return nil
+	}
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	before, after, err := s.Recompress(recompressLevel)
+	if err != nil {
+		fail("Failed to recompress %s: %v", s.FileName, err)
+		return nil
+	}
+	if after < before {
+		success("Recompressed '%s': %s → %s (%d%% smaller)", s.FileName, formatBytes(before), formatBytes(after), (before-after)*100/before)
+	} else {
+		success("Recompressed '%s': %s → %s", s.FileName, formatBytes(before), formatBytes(after))
+	}
+	return nil
+}
+
+func init() {
+	recompressCmd.Flags().IntVar(&recompressLevel, "level", 9, "Compression level, 0 (none) to 9 (smallest)")
+	recompressCmd.Flags().BoolVar(&recompressZstd, "zstd", false, "Not supported; git packs hold only zlib-compressed objects")
+	rootCmd.AddCommand(recompressCmd)
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iyulab/oops/internal/updater"
+	"github.com/spf13/cobra"
+)
+
+var (
+	manifestBrew   bool
+	manifestScoop  bool
+	manifestTag    string
+	manifestOutput string
+)
+
+var releaseManifestCmd = &cobra.Command{
+	Use:   "release-manifest --brew|--scoop",
+	Short: "📦 Generate a Homebrew formula or Scoop manifest for a release",
+	Long: `Generate a Homebrew formula or Scoop manifest from a GitHub release, for
+maintainers publishing oops to those channels. Asset names and URLs come
+from the release, matching what 'oops update' downloads; checksums come
+from a checksums file in the release, or from downloading each asset.
+
+Examples:
+  oops release-manifest --brew -o Formula/oops.rb
+  oops release-manifest --scoop --tag v0.3.0 -o bucket/oops.json`,
+	Hidden: true,
+	Args:   cobra.NoArgs,
+	RunE:   runReleaseManifest,
+}
+
+func runReleaseManifest(cmd *cobra.Command, args []string) error {
+	if manifestBrew == manifestScoop {
+		fail("Choose one of --brew or --scoop")
+		return nil
+	}
+
+	release, err := updater.GetRelease(manifestTag)
+	if err != nil {
+		fail("Failed to get release: %v", err)
+		return nil
+	}
+
+	platforms := updater.BrewPlatforms
+	if manifestScoop {
+		platforms = updater.ScoopPlatforms
+	}
+	assets, err := updater.ReleaseAssets(release, platforms)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	var manifest string
+	if manifestBrew {
+		manifest, err = updater.BrewFormula(release, assets)
+	} else {
+		manifest, err = updater.ScoopManifest(release, assets)
+	}
+	if err != nil {
+		fail("Failed to generate manifest: %v", err)
+		return nil
+	}
+
+	if manifestOutput == "" {
+		fmt.Print(manifest)
+		return nil
+	}
+	if err := os.WriteFile(manifestOutput, []byte(manifest), 0644); err != nil {
+		fail("Failed to write %s: %v", manifestOutput, err)
+		return nil
+	}
+	success("Wrote the manifest for %s to %s", release.TagName, manifestOutput)
+	return nil
+}
+
+func init() {
+	releaseManifestCmd.Flags().BoolVar(&manifestBrew, "brew", false, "Generate a Homebrew formula")
+	releaseManifestCmd.Flags().BoolVar(&manifestScoop, "scoop", false, "Generate a Scoop manifest")
+	releaseManifestCmd.Flags().StringVar(&manifestTag, "tag", "", "Release tag (default: the latest release)")
+	releaseManifestCmd.Flags().StringVarP(&manifestOutput, "output", "o", "", "Write the manifest to this file instead of stdout")
+	rootCmd.AddCommand(releaseManifestCmd)
+}
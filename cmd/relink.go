@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"errors"
+	"os"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var relinkCmd = &cobra.Command{
+	Use:   "relink <old-path> <new-path>",
+	Short: "🔗 Attach a global history to a file that was moved",
+	Long: `Point the global store of a file at the place it was moved to.
+
+A globally tracked file that is moved or renamed outside oops leaves its
+history behind under the old path, where 'oops gc -g' sees it as an
+orphan and removes it. relink updates the store's metadata and hash
+directory so the history follows the file instead.
+
+The file must already be at <new-path>; to move it and its history in
+one step, use 'oops mv -g'. To re-point every file under a directory
+that moved, use 'oops remap'.
+
+Examples:
+  oops relink ~/Documents/plan.md ~/Projects/plan.md
+  oops relink /mnt/old/report.docx /mnt/new/report-2024.docx`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRelink,
+}
+
+func runRelink(cmd *cobra.Command, args []string) error {
+	s, err := store.FindGlobalStore(args[0])
+	if err != nil {
+		if err == store.ErrNotTracked {
+			fail("'%s' is not tracked globally", args[0])
+			info("Use 'oops files -g' to see the globally tracked files")
+			return nil
+		}
+		fail("%v", err)
+		return nil
+	}
+	if _, err := os.Stat(s.FilePath); err == nil {
+		fail("%s is still there", s.FilePath)
+		info("Use 'oops mv -g %s <new-path>' to move it along with its history", args[0])
+		return nil
+	}
+	if _, err := os.Stat(args[1]); err != nil {
+		fail("%s not found", args[1])
+		info("Move the file there first, or use 'oops mv -g' to do both")
+		return nil
+	}
+
+	moved, err := s.Move(args[1])
+	if err != nil {
+		if errors.Is(err, store.ErrAlreadyTracked) {
+			fail("%s already has a global history", args[1])
+			info("Use 'oops done -g' on it first if that history can go")
+			return nil
+		}
+		if reportLocked(err) {
+			return nil
+		}
+		fail("Failed to relink: %v", err)
+		return nil
+	}
+
+	success("The history of %s now follows %s", s.FilePath, moved.FilePath)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(relinkCmd)
+}
@@ -0,0 +1,64 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var remapDryRun bool
+
+var remapCmd = &cobra.Command{
+	Use:   "remap <old-prefix> <new-prefix>",
+	Short: "🚚 Point global stores at moved directories",
+	Long: `Update global stores after tracked files moved to another directory.
+
+Every global store whose file lies under <old-prefix> is rewritten to
+the same relative path under <new-prefix>, so its history follows the file.
+
+Examples:
+  oops remap ~/Documents ~/Dropbox/Documents
+  oops remap --dry-run /old/home/me /home/me`,
+	Args: cobra.ExactArgs(2),
+	RunE: runRemap,
+}
+
+func runRemap(cmd *cobra.Command, args []string) error {
+	entries, err := store.RemapGlobalStores(args[0], args[1], remapDryRun)
+	if err != nil {
+		fail("Failed to remap: %v", err)
+		return nil
+	}
+
+	if len(entries) == 0 {
+		info("No global stores under %s", args[0])
+		return nil
+	}
+
+	moved := 0
+	fmt.Printf("🚚 Remapping %d global store(s):\n", len(entries))
+	for _, e := range entries {
+		if e.Err != nil {
+			fmt.Printf("  ✗ %s\n", e.OldPath)
+			info("    %v", e.Err)
+			continue
+		}
+		moved++
+		fmt.Printf("  %s\n", e.OldPath)
+		fmt.Printf("    → %s\n", e.NewPath)
+	}
+
+	if remapDryRun {
+		info("Dry run - no changes made")
+		return nil
+	}
+
+	success("Remapped %d global store(s)", moved)
+	return nil
+}
+
+func init() {
+	remapCmd.Flags().BoolVar(&remapDryRun, "dry-run", false, "Preview the remapping without changing anything")
+	rootCmd.AddCommand(remapCmd)
+}
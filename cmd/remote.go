@@ -0,0 +1,414 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/iyulab/oops/internal/git"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	remoteSSHKey string
+	pullFrom     string
+	pullRebase   bool
+
+	remoteAddAuth store.RemoteAuth
+)
+
+var remoteCmd = &cobra.Command{
+	Use:   "remote",
+	Short: "☁️ Show or set where snapshots are pushed",
+	Long: `Show, set or remove the Git repositories a file's snapshots are synced
+with, for backup or to share history across machines.
+
+A remote can be a GitHub/GitLab URL or a path to a folder, which is
+created as a bare repository on the first push. Use one remote
+repository per file. A file can have several remotes, each with a name
+such as backup or laptop; a remote added without a name is "origin".
+push and pull use the only remote, or "origin", unless one is named.
+
+HTTPS remotes read a personal access token from OOPS_GIT_TOKEN (and a
+user name from OOPS_GIT_USER, if the host needs one). SSH remotes use
+the SSH agent, or the key given with --ssh-key or OOPS_SSH_KEY.
+Each remote can name its own credentials instead: --token-env reads the
+token from another variable and --token-cmd runs a command that prints
+it, such as a keyring lookup. Only where to find a token is stored,
+never the token. Token commands are kept in ~/.oops/config under the
+file's path rather than with the history, so a history copied from
+elsewhere cannot run one.
+
+Examples:
+  oops remote add git@github.com:me/notes-history.git
+  oops remote add backup /media/usb/backups/notes.git
+  oops remote add laptop https://git.example.com/notes.git --token-env LAPTOP_TOKEN
+  oops remote add work https://github.com/me/notes.git --token-cmd "secret-tool lookup oops work"
+  oops remote                      List the remotes
+  oops remote remove backup`,
+	Args: cobra.NoArgs,
+	RunE: runRemoteShow,
+}
+
+var remoteAddCmd = &cobra.Command{
+	Use:     "add [name] <url>",
+	Aliases: []string{"set"},
+	Short:   "Add a remote repository, or change its URL or credentials",
+	Args:    cobra.RangeArgs(1, 2),
+	RunE:    runRemoteAdd,
+}
+
+var remoteRemoveCmd = &cobra.Command{
+	Use:     "remove [name]",
+	Aliases: []string{"rm"},
+	Short:   "Stop syncing with a remote",
+	Args:    cobra.MaximumNArgs(1),
+	RunE:    runRemoteRemove,
+}
+
+var pushCmd = &cobra.Command{
+	Use:   "push [remote]",
+	Short: "⬆️ Upload new snapshots to a remote",
+	Long: `Upload snapshots the remote does not have yet, to the named remote or
+the default one (see 'oops remote --help').
+
+If snapshots were pushed from another machine since the last sync, run
+'oops pull' first. Labels, schedules and the undo stack stay local.
+
+Examples:
+  oops push
+  oops push backup`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPush,
+}
+
+var pullCmd = &cobra.Command{
+	Use:   "pull [remote]",
+	Short: "⬇️ Download new snapshots from a remote",
+	Long: `Download snapshots pushed from elsewhere and restore the latest one,
+from the named remote or the default one. Unsaved changes are protected:
+save or undo them first.
+
+With --rebase-local, a copy that saved snapshots while the remote got
+others too (edited on the desktop, then on the laptop) puts its own after
+the remote's, renumbered, with the remote's changes merged in. This is
+refused, changing nothing, if both sides changed the same lines.
+
+With --from, start tracking a file from a remote's history, e.g. on a
+new machine; the argument is then the file, which must not exist yet.
+
+Examples:
+  oops pull
+  oops pull laptop
+  oops pull --rebase-local
+  oops pull notes.md --from git@github.com:me/notes-history.git`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runPull,
+}
+
+// remoteAuth collects credentials from the environment and --ssh-key
+func remoteAuth() git.Auth {
+	auth := git.Auth{
+		Username: os.Getenv("OOPS_GIT_USER"),
+		Token:    os.Getenv("OOPS_GIT_TOKEN"),
+		SSHKey:   os.Getenv("OOPS_SSH_KEY"),
+	}
+	if remoteSSHKey != "" {
+		auth.SSHKey = remoteSSHKey
+	}
+	return auth
+}
+
+// namedRemoteAuth resolves a remote of s and collects its credentials:
+// its own sources first, then the environment. --ssh-key overrides both.
+func namedRemoteAuth(s *store.Store, name string) (string, git.Auth, error) {
+	remote, err := s.ResolveRemote(name)
+	if err != nil {
+		return "", git.Auth{}, err
+	}
+	auth, err := remote.Auth.Apply(remoteAuth())
+	if err != nil {
+		return remote.Name, auth, fmt.Errorf("no credentials for %s: %w", remote.Name, err)
+	}
+	if remoteSSHKey != "" {
+		auth.SSHKey = remoteSSHKey
+	}
+	return remote.Name, auth, nil
+}
+
+func runRemoteShow(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	remotes, err := s.Remotes()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if len(remotes) == 0 {
+		info("No remote set for '%s'", s.FileName)
+		info("Use 'oops remote add <url>' to set one")
+		return nil
+	}
+	for _, remote := range remotes {
+		info("☁️  %-10s %s", remote.Name, remote.URL)
+		if sources := describeRemoteAuth(remote.Auth); sources != "" {
+			info("    %s", sources)
+		}
+	}
+
+	if settings := s.SyncSettings(); settings.AutoPush {
+		target := settings.Remote
+		if remote, err := s.ResolveRemote(target); err == nil {
+			target = remote.Name
+		}
+		info("🔁 New snapshots are pushed to %s", target)
+	}
+	if failed := s.FailedPush(); failed != nil {
+		warn("Auto-push to %s failing since %s (%d %s): %s", failed.Remote, formatTimeAgo(failed.Since),
+			failed.Attempts, plural(failed.Attempts, "attempt"), failed.Error)
+		info("The daemon retries it; 'oops push' tries now")
+	}
+	return nil
+}
+
+// describeRemoteAuth lists where a remote's credentials come from
+func describeRemoteAuth(a store.RemoteAuth) string {
+	var parts []string
+	if a.Username != "" {
+		parts = append(parts, "user "+a.Username)
+	}
+	if a.TokenEnv != "" {
+		parts = append(parts, "token from $"+a.TokenEnv)
+	}
+	if a.TokenCmd != "" {
+		parts = append(parts, fmt.Sprintf("token from '%s'", a.TokenCmd))
+	}
+	if a.SSHKey != "" {
+		parts = append(parts, "key "+a.SSHKey)
+	}
+	return strings.Join(parts, ", ")
+}
+
+func runRemoteAdd(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	name, url := git.RemoteName, args[0]
+	if len(args) == 2 {
+		name, url = args[0], args[1]
+	}
+
+	// Credential flags left out keep the remote's current sources
+	auth := remoteAddAuth
+	if existing, err := s.ResolveRemote(name); err == nil {
+		keep := func(flag string, field *string, current string) {
+			if !cmd.Flags().Changed(flag) {
+				*field = current
+			}
+		}
+		keep("user", &auth.Username, existing.Auth.Username)
+		keep("token-env", &auth.TokenEnv, existing.Auth.TokenEnv)
+		keep("token-cmd", &auth.TokenCmd, existing.Auth.TokenCmd)
+		keep("ssh-key", &auth.SSHKey, existing.Auth.SSHKey)
+	}
+
+	if err := s.SetRemote(name, url, auth); err != nil {
+		fail("Failed to set remote: %v", err)
+		return nil
+	}
+	remote, _ := s.ResolveRemote(name)
+	success("Remote %s of '%s' set to %s", name, s.FileName, remote.URL)
+	if name == git.RemoteName {
+		info("Use 'oops push' to upload snapshots")
+	} else {
+		info("Use 'oops push %s' to upload snapshots", name)
+	}
+	return nil
+}
+
+func runRemoteRemove(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	name := ""
+	if len(args) > 0 {
+		name = args[0]
+	}
+	remote, err := s.ResolveRemote(name)
+	if err != nil {
+		reportSyncError(err)
+		return nil
+	}
+	if err := s.RemoveRemote(remote.Name); err != nil {
+		fail("%v", err)
+		return nil
+	}
+	success("Removed the remote %s of '%s'; its copy of the history is kept", remote.Name, s.FileName)
+	return nil
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	n := 0
+	name, auth, err := namedRemoteAuth(s, firstArg(args))
+	if err == nil {
+		n, err = s.Push(name, auth)
+	}
+	if err != nil {
+		reportSyncError(err)
+		return nil
+	}
+	if failed := s.FailedPush(); failed != nil && failed.Remote == name {
+		s.RecordPush(name, nil) // Nothing is left for the daemon to retry
+	}
+	if n == 0 {
+		info("%s is up to date", name)
+		return nil
+	}
+	success("Pushed %d %s to %s", n, plural(n, "snapshot"), name)
+	return nil
+}
+
+func runPull(cmd *cobra.Command, args []string) error {
+	if pullFrom != "" {
+		return runPullNew(args)
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	n := 0
+	var renumbered map[int]int
+	name, auth, err := namedRemoteAuth(s, firstArg(args))
+	if err == nil {
+		if pullRebase {
+			n, renumbered, err = s.PullRebase(name, auth)
+		} else {
+			n, err = s.Pull(name, auth)
+		}
+	}
+	if err != nil {
+		reportSyncError(err)
+		return nil
+	}
+	if n == 0 && len(renumbered) == 0 {
+		info("Already up to date with %s", name)
+		return nil
+	}
+	latest, _ := s.GetLatestVersion()
+	success("Pulled %d %s from %s; '%s' is now at snapshot #%d", n, plural(n, "snapshot"), name, s.FileName, latest)
+	if len(renumbered) > 0 {
+		old := make([]int, 0, len(renumbered))
+		for num := range renumbered {
+			old = append(old, num)
+		}
+		sort.Ints(old)
+		for _, num := range old {
+			info("Your snapshot #%d is now #%d", num, renumbered[num])
+		}
+		info("Use 'oops push' to share your snapshots")
+	}
+	return nil
+}
+
+// firstArg returns args[0], or "" if there are no args
+func firstArg(args []string) string {
+	if len(args) == 0 {
+		return ""
+	}
+	return args[0]
+}
+
+// runPullNew starts tracking a file from a remote's history
+func runPullNew(args []string) error {
+	if len(args) == 0 {
+		fail("Name the file to create from the remote")
+		return nil
+	}
+	s, err := store.NewStoreWithOptions(args[0], storeOptions())
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	n, err := s.Clone(pullFrom, remoteAuth())
+	if err != nil {
+		if err == store.ErrAlreadyTracked {
+			fail("'%s' is already tracked; use 'oops remote add' and 'oops pull'", s.FileName)
+			return nil
+		}
+		reportSyncError(err)
+		return nil
+	}
+	if !s.Global {
+		s.RegisterLocal()
+	}
+	latest, _ := s.GetLatestVersion()
+	success("Now watching '%s' with %d %s from the remote (snapshot #%d)", s.FileName, n, plural(n, "snapshot"), latest)
+	return nil
+}
+
+// reportSyncError explains a failed push or pull
+func reportSyncError(err error) {
+	var conflict *store.TagConflictError
+	var unknown *store.UnknownRemoteError
+	var replay *store.ReplayConflictError
+	switch {
+	case err == store.ErrNoRemote:
+		fail("No remote set")
+		info("Use 'oops remote add <url>' to set one")
+	case err == store.ErrRemoteNotNamed:
+		fail("There are several remotes and none is named origin")
+		info("Name one, e.g. 'oops push backup'; 'oops remote' lists them")
+	case errors.As(err, &unknown):
+		fail("No remote named %s", unknown.Name)
+		info("Use 'oops remote' to see the remotes")
+	case err == store.ErrRemoteAhead:
+		fail("The remote has snapshots that are not here yet")
+		info("Use 'oops pull' first, then push again")
+	case err == store.ErrUncommittedChanges:
+		fail("You have unsaved changes")
+		info("Save them with 'oops save' or discard them with 'oops oops!', then pull")
+	case errors.As(err, &replay):
+		fail("Cannot replay this copy's snapshots after the remote's: %v", err)
+		info("Keep this copy's changes aside, then start over from the remote with 'oops done' and 'oops pull --from'")
+	case err == store.ErrHistoryDiverged, errors.As(err, &conflict):
+		fail("Both this copy and the remote saved snapshots since they last synced: %v", err)
+		if pullRebase {
+			info("Keep this copy's changes aside, then start over from the remote with 'oops done' and 'oops pull --from'")
+		} else {
+			info("Use 'oops pull --rebase-local' to put this copy's snapshots after the remote's")
+		}
+	default:
+		fail("Sync failed: %v", err)
+	}
+}
+
+func init() {
+	for _, c := range []*cobra.Command{pushCmd, pullCmd} {
+		c.Flags().StringVar(&remoteSSHKey, "ssh-key", "", "Private key for SSH remotes (default: SSH agent)")
+	}
+	pullCmd.Flags().StringVar(&pullFrom, "from", "", "Start tracking the file from this remote")
+	pullCmd.Flags().BoolVar(&pullRebase, "rebase-local", false, "Replay this copy's new snapshots after the remote's when both saved some")
+	remoteAddCmd.Flags().StringVar(&remoteAddAuth.Username, "user", "", "User name for HTTPS tokens")
+	remoteAddCmd.Flags().StringVar(&remoteAddAuth.TokenEnv, "token-env", "", "Read the token from this environment variable")
+	remoteAddCmd.Flags().StringVar(&remoteAddAuth.TokenCmd, "token-cmd", "", "Run this command to print the token, e.g. a keyring lookup")
+	remoteAddCmd.Flags().StringVar(&remoteAddAuth.SSHKey, "ssh-key", "", "Private key for this SSH remote")
+	remoteCmd.AddCommand(remoteAddCmd, remoteRemoveCmd)
+	rootCmd.AddCommand(remoteCmd, pushCmd, pullCmd)
+}
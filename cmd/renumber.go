@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	renumberCheck  bool
+	renumberDryRun bool
+	renumberYes    bool
+)
+
+var renumberCmd = &cobra.Command{
+	Use:   "renumber",
+	Short: "🔢 Check and repair snapshot numbering",
+	Long: `Check that snapshots are numbered #1 to #N in the order they were saved,
+and retag them into that sequence if not.
+
+Problems found include commits with no number or several, numbers out of
+order, gaps (compaction leaves these on purpose) and numbers pointing
+outside the history. Renumbering keeps every snapshot's content and
+message; only the numbers change, and a mapping from old to new is shown.
+Signed manifests from 'oops attest' list the old numbers.
+
+Examples:
+  oops renumber --check     Report problems without changing anything
+  oops renumber --dry-run   Show the old → new mapping
+  oops renumber             Renumber after confirming`,
+	Args: cobra.NoArgs,
+	RunE: runRenumber,
+}
+
+func runRenumber(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	problems, err := s.CheckNumbering()
+	if err != nil {
+		fail("Failed to check numbering: %v", err)
+		return nil
+	}
+	if len(problems) == 0 {
+		success("Snapshots of %s are numbered #1 to #N in order", s.FileName)
+		return nil
+	}
+
+	warn("%d numbering %s in %s:", len(problems), plural(len(problems), "problem"), s.FileName)
+	for _, p := range problems {
+		fmt.Printf("    %s\n", p)
+	}
+	if renumberCheck {
+		info("Use 'oops renumber' to fix them")
+		return nil
+	}
+
+	plan, err := s.PlanRenumber()
+	if err != nil {
+		fail("Failed to plan renumbering: %v", err)
+		return nil
+	}
+	fmt.Println()
+	printRenumbering(plan)
+
+	if renumberDryRun {
+		info("Dry run, nothing changed")
+		return nil
+	}
+	if !renumberYes && !confirm("Renumber snapshots?") {
+		info("Nothing changed")
+		return nil
+	}
+
+	if _, err := s.Renumber(); err != nil {
+		fail("Failed to renumber: %v", err)
+		return nil
+	}
+	latest, _ := s.GetLatestVersion()
+	success("Snapshots renumbered #1 to #%d", latest)
+	return nil
+}
+
+// printRenumbering lists the commits whose numbers change
+func printRenumbering(plan []store.Renumbering) {
+	fmt.Println("🔢 Mapping:")
+	for _, r := range plan {
+		if !r.Changed() {
+			continue
+		}
+		old := "(none)"
+		if len(r.Old) > 0 {
+			var tags []string
+			for _, num := range r.Old {
+				tags = append(tags, fmt.Sprintf("#%d", num))
+			}
+			old = strings.Join(tags, ", ")
+		}
+		if r.New == 0 {
+			fmt.Printf("    %-10s → removed    %s (not in history)\n", old, r.Hash)
+			continue
+		}
+		fmt.Printf("    %-10s → #%-8d %s %s\n", old, r.New, r.Hash, r.Message)
+	}
+	fmt.Println()
+}
+
+func init() {
+	renumberCmd.Flags().BoolVar(&renumberCheck, "check", false, "Only report numbering problems")
+	renumberCmd.Flags().BoolVar(&renumberDryRun, "dry-run", false, "Show the mapping without renumbering")
+	renumberCmd.Flags().BoolVarP(&renumberYes, "yes", "y", false, "Renumber without asking")
+	rootCmd.AddCommand(renumberCmd)
+}
@@ -0,0 +1,155 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/store"
+)
+
+// runHistoryReport handles history --between and --export
+func runHistoryReport(s *store.Store, args []string) error {
+	from, to := 1, 0
+	if len(args) == 2 {
+		var err1, err2 error
+		from, err1 = strconv.Atoi(args[0])
+		to, err2 = strconv.Atoi(args[1])
+		if err1 != nil || err2 != nil || from < 1 || to < 1 {
+			fail("Invalid snapshot range: %s %s", args[0], args[1])
+			return nil
+		}
+		if from > to {
+			from, to = to, from
+		}
+	} else {
+		latest, err := s.GetLatestVersion()
+		if err != nil {
+			fail("Failed to get history: %v", err)
+			return nil
+		}
+		to = latest
+	}
+
+	entries, err := s.HistoryBetween(from, to)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshots #%d to #%d not found", from, to)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		fail("Failed to get history: %v", err)
+		return nil
+	}
+
+	if historyExport == "" {
+		printHistoryRange(s, entries)
+		return nil
+	}
+
+	report, err := historyReport(s, entries, historyDiffs)
+	if err != nil {
+		fail("Failed to build report: %v", err)
+		return nil
+	}
+	if err := os.WriteFile(historyExport, report, 0644); err != nil {
+		fail("Failed to write %s: %v", historyExport, err)
+		return nil
+	}
+	success("Report for #%d to #%d written to %s", from, to, historyExport)
+	return nil
+}
+
+// printHistoryRange lists a range of snapshots with lines changed
+func printHistoryRange(s *store.Store, entries []store.RangeEntry) {
+	fmt.Printf("📜 %s #%d to #%d:\n\n", s.FileName, entries[0].Number, entries[len(entries)-1].Number)
+	for i, e := range entries {
+		fmt.Printf("  #%-3d  %-30s  %-10s  %s\n", e.Number, e.Message,
+			entryChanges(s, e, i == 0), formatTimeAgo(e.Timestamp))
+	}
+}
+
+// entryChanges describes the lines an entry changed, for the terminal and
+// the report table
+func entryChanges(s *store.Store, e store.RangeEntry, baseline bool) string {
+	switch {
+	case baseline:
+		return "baseline"
+	case s.IsImage():
+		return "image"
+	}
+	return fmt.Sprintf("+%d -%d", e.Added, e.Removed)
+}
+
+// historyReport renders a markdown change report for entries, optionally
+// with each snapshot's diff against the previous entry
+func historyReport(s *store.Store, entries []store.RangeEntry, withDiffs bool) ([]byte, error) {
+	first, last := entries[0], entries[len(entries)-1]
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "# Changes to %s: #%d → #%d\n\n", s.FileName, first.Number, last.Number)
+	fmt.Fprintf(&b, "Generated %s. %d %s saved from %s to %s.\n\n",
+		time.Now().Format("2006-01-02 15:04"), len(entries)-1, plural(len(entries)-1, "snapshot"),
+		first.Timestamp.Format("2006-01-02 15:04"), last.Timestamp.Format("2006-01-02 15:04"))
+
+	b.WriteString("| # | Message | Saved | Lines |\n")
+	b.WriteString("|---|---------|-------|-------|\n")
+	added, removed := 0, 0
+	for i, e := range entries {
+		fmt.Fprintf(&b, "| %d | %s | %s | %s |\n", e.Number, markdownCell(e.Message),
+			e.Timestamp.Format("2006-01-02 15:04"), entryChanges(s, e, i == 0))
+		added += e.Added
+		removed += e.Removed
+	}
+	if !s.IsImage() && len(entries) > 1 {
+		fmt.Fprintf(&b, "\n**Total:** +%d -%d lines from #%d to #%d\n", added, removed, first.Number, last.Number)
+	}
+
+	if withDiffs {
+		for i := 1; i < len(entries); i++ {
+			diff, err := changesText(s, entries[i-1].Number, entries[i].Number)
+			if err != nil {
+				return nil, err
+			}
+			fmt.Fprintf(&b, "\n## #%d %s\n\n", entries[i].Number, entries[i].Message)
+			if diff == "" {
+				b.WriteString("No changes.\n")
+				continue
+			}
+			fence := "```"
+			for strings.Contains(diff, fence) {
+				fence += "`"
+			}
+			fmt.Fprintf(&b, "%sdiff\n%s\n%s\n", fence, strings.TrimRight(diff, "\n"), fence)
+		}
+	}
+	return b.Bytes(), nil
+}
+
+// changesText returns the changes between two snapshots as text: a diff,
+// or a summary for images
+func changesText(s *store.Store, from, to int) (string, error) {
+	switch {
+	case s.IsImage():
+		change, err := s.ImageChanges(from, to)
+		if err != nil {
+			return "", err
+		}
+		if bytes.Equal(change.OldData, change.NewData) {
+			return "", nil
+		}
+		return change.String(), nil
+	case s.IsDocument():
+		return s.DocumentChanges(from, to)
+	}
+	return s.Changes(from, to)
+}
+
+// markdownCell escapes text for a markdown table cell
+func markdownCell(text string) string {
+	text = strings.ReplaceAll(text, "|", "\\|")
+	return strings.ReplaceAll(text, "\n", " ")
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	resetTemplateSave  bool
+	forceResetTemplate bool
+)
+
+var resetTemplateCmd = &cobra.Command{
+	Use:   "reset-to-template [file]",
+	Short: "📋 Put the file back to its first snapshot",
+	Long: `Restore snapshot #1 of a file whose first snapshot is its canonical
+baseline, such as a config or worksheet reset on lab and classroom
+machines after every session.
+
+With --save, what the file holds now is saved as a snapshot first, so
+it stays in the history and 'oops forward' returns to it. Otherwise
+unsaved changes are handled as by back (see back.on_dirty); -f discards
+them.
+
+Examples:
+  oops reset-to-template                 Reset the tracked file here
+  oops reset-to-template --save app.ini  Keep the current state, then reset`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runResetTemplate,
+}
+
+func runResetTemplate(cmd *cobra.Command, args []string) error {
+	var s *store.Store
+	var err error
+	if len(args) == 1 {
+		s, err = findStoreForPath(args[0])
+	} else {
+		s, err = findTrackedStore()
+	}
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	if current, _, hasChanges, err := s.Now(); err == nil && current == 1 && !hasChanges {
+		info("%s already matches its template (snapshot #1)", s.FileName)
+		return nil
+	}
+	if !resetTemplateSave && !handleUnsavedChanges(s, "back", forceResetTemplate) {
+		return nil
+	}
+
+	saved, err := s.ResetToTemplate(resetTemplateSave)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #1 of %s no longer exists", s.FileName)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		if !reportLocked(err) && !reportUnsettled(s, err) {
+			fail("Failed: %v", err)
+		}
+		return nil
+	}
+	if saved != nil {
+		info("Saved the current state as snapshot #%d", saved.Number)
+	}
+	success("Reset %s to its template (snapshot #1)", s.FileName)
+	if saved != nil {
+		info("Use 'oops forward' to return to it")
+	}
+	reportChainFailures(s)
+	return nil
+}
+
+func init() {
+	resetTemplateCmd.Flags().BoolVarP(&resetTemplateSave, "save", "s", false, "Save the current state as a snapshot before resetting")
+	resetTemplateCmd.Flags().BoolVarP(&forceResetTemplate, "force", "f", false, "Discard unsaved changes")
+	rootCmd.AddCommand(resetTemplateCmd)
+}
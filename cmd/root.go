@@ -1,10 +1,14 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"time"
 
 	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/timing"
 	"github.com/spf13/cobra"
 )
 
@@ -13,6 +17,10 @@ var Version = "0.3.0"
 // Global flags
 var globalFlag bool
 var localFlag bool // Explicit local flag to override config
+var eventsFile string
+var profileFlag string
+var waitFlag time.Duration
+var timingsFlag bool
 
 var rootCmd = &cobra.Command{
 	Use:     "oops",
@@ -29,12 +37,17 @@ Quick Start:
   oops back 1               ⏪ Go back to snapshot #1
   oops oops!                ↩️  Undo last change
 
+Run 'oops' on its own in a directory with tracked files for a menu of
+the everyday commands.
+
 For developers, Git-style aliases also work:
   track, commit, log, checkout, diff, status, untrack`,
+	RunE: runInteractive,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
+		cfg, _ := config.Load()
+
 		// Apply config defaults if no explicit flag set
 		if !globalFlag && !localFlag {
-			cfg, _ := config.Load()
 			if cfg != nil && cfg.DefaultGlobal {
 				globalFlag = true
 			}
@@ -43,19 +56,85 @@ For developers, Git-style aliases also work:
 		if localFlag {
 			globalFlag = false
 		}
+		store.DefaultLockWait = waitFlag
+		if timingsFlag {
+			timing.Enable()
+		}
+
+		setupEventSinks(cmd, cfg)
+		setupAutoPush(cmd)
+		if cfg != nil && cfg.UsageStats {
+			recordUsage(cmd)
+		}
 	},
 }
 
+// exitStatus is returned by a command that passes on the exit status of
+// a program it ran, such as guard; oops exits with it without printing
+type exitStatus struct {
+	code int
+}
+
+func (e *exitStatus) Error() string {
+	return fmt.Sprintf("exit status %d", e.code)
+}
+
 func Execute() {
-	if err := rootCmd.Execute(); err != nil {
+	err := rootCmd.Execute()
+	if timing.Enabled() {
+		reportTimings()
+	}
+	var status *exitStatus
+	if errors.As(err, &status) {
+		os.Exit(status.code)
+	}
+	if err != nil {
 		os.Exit(1)
 	}
 }
 
+// reportTimings prints where the command spent its time, for --timings.
+// It goes to stderr so output meant for other programs is unchanged.
+func reportTimings() {
+	phases, total := timing.Report()
+	fmt.Fprintf(os.Stderr, "\n⏱  %s in total\n", roundDuration(total))
+	var accounted time.Duration
+	for _, p := range phases {
+		fmt.Fprintf(os.Stderr, "  %-17s %10s %4.0f%%  %d×\n",
+			p.Name, roundDuration(p.Time), percentOf(p.Time, total), p.Calls)
+		accounted += p.Time
+	}
+	other := max(total-accounted, 0)
+	fmt.Fprintf(os.Stderr, "  %-17s %10s %4.0f%%\n", "other", roundDuration(other), percentOf(other, total))
+}
+
+// roundDuration rounds d for display, keeping three significant digits
+// of short durations
+func roundDuration(d time.Duration) time.Duration {
+	switch {
+	case d >= time.Second:
+		return d.Round(time.Millisecond)
+	case d >= time.Millisecond:
+		return d.Round(10 * time.Microsecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+func percentOf(d, total time.Duration) float64 {
+	if total <= 0 {
+		return 0
+	}
+	return 100 * float64(d) / float64(total)
+}
+
 func init() {
-	rootCmd.CompletionOptions.DisableDefaultCmd = true
 	rootCmd.PersistentFlags().BoolVarP(&globalFlag, "global", "g", false, "Use global storage (~/.oops/) instead of local (.oops/)")
 	rootCmd.PersistentFlags().BoolVarP(&localFlag, "local", "l", false, "Use local storage (.oops/) - overrides config default")
+	rootCmd.PersistentFlags().StringVar(&profileFlag, "profile", "", "Use a named history of the file instead of the default one")
+	rootCmd.PersistentFlags().StringVar(&eventsFile, "events", "", "Append JSON event lines to this file")
+	rootCmd.PersistentFlags().DurationVar(&waitFlag, "wait", 0, "Wait this long for another oops process to finish with the file (alone: 10m)")
+	rootCmd.PersistentFlags().Lookup("wait").NoOptDefVal = "10m"
+	rootCmd.PersistentFlags().BoolVar(&timingsFlag, "timings", false, "Print where the command spent its time when it finishes")
 }
 
 // Helper for friendly output
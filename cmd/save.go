@@ -1,19 +1,52 @@
 package cmd
 
 import (
+	"errors"
+	"fmt"
+	"os"
 	"strings"
 
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/daemon"
 	"github.com/iyulab/oops/internal/store"
 	"github.com/spf13/cobra"
 )
 
+var (
+	saveYes   bool
+	saveAsync bool
+	saveStdin bool
+	saveForce bool
+)
+
 var saveCmd = &cobra.Command{
 	Use:     "save [message]",
 	Aliases: []string{"commit", "snap"},
 	Short:   "📸 Save a snapshot",
-	Long:    `Save the current state of the file as a new snapshot.`,
-	Args:    cobra.MaximumNArgs(1),
-	RunE:    runSave,
+	Long: `Save the current state of the file as a new snapshot.
+
+Before saving, the file is checked for likely credentials such as API keys,
+private keys and long random tokens. Snapshots are kept forever, so you are
+asked to confirm before one containing them is stored.
+
+If a validate hook is set ('oops validate'), the snapshot is only saved
+when the hook accepts the file.
+
+With --async, or for files over async_save_size in ~/.oops/config, the
+content is captured and the snapshot is committed in the background, so
+you get control back right away. 'oops now' shows snapshots still being
+saved; other commands wait for them to finish.
+
+With --stdin, the file is first replaced with what is piped in, as with
+'oops pipe'; --force discards unsaved changes it would replace.
+
+Examples:
+  oops save "new draft"   Save with a message
+  oops save -y            Save without the credentials check prompt
+  oops save --async       Capture now, commit in the background
+  gen | oops save --stdin Save the output of gen as the file's new content`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runSave,
 }
 
 func runSave(cmd *cobra.Command, args []string) error {
@@ -27,6 +60,22 @@ func runSave(cmd *cobra.Command, args []string) error {
 	if len(args) > 0 {
 		message = strings.TrimSpace(args[0])
 	}
+	if saveStdin {
+		return pipeSnapshot(s, message, saveForce)
+	}
+	return saveSnapshot(s, message)
+}
+
+// saveSnapshot saves the changes to s as a snapshot, reporting the result
+func saveSnapshot(s *store.Store, message string) error {
+	if !saveYes && !confirmSecrets(s) {
+		info("Snapshot not saved")
+		return nil
+	}
+
+	if saveAsync || asyncBySize(s) {
+		return runSaveDeferred(s, message)
+	}
 
 	snapshot, err := s.Save(message)
 	if err != nil {
@@ -34,14 +83,153 @@ func runSave(cmd *cobra.Command, args []string) error {
 			info("No changes to save")
 			return nil
 		}
+		if reportTooLarge(err, false) || reportRegionMissing(s, err) || reportInvalid(err) || reportLocked(err) || reportConcurrent(err) || reportUnsettled(s, err) {
+			return nil
+		}
 		fail("Failed to save: %v", err)
 		return nil
 	}
 
 	success("Snapshot #%d saved: %s", snapshot.Number, snapshot.Message)
+	reportChainFailures(s)
+	if snapshot.Packed {
+		warn("This snapshot is over the %s size limit and was stored with maximum compression",
+			formatBytes(s.MaxSnapshotSize))
+	}
 	return nil
 }
 
+// asyncBySize reports whether the file is over the configured
+// async_save_size
+func asyncBySize(s *store.Store) bool {
+	cfg, err := config.Load()
+	if err != nil || cfg.AsyncSaveSize <= 0 {
+		return false
+	}
+	fi, err := os.Stat(s.FilePath)
+	return err == nil && fi.Size() >= cfg.AsyncSaveSize
+}
+
+// runSaveDeferred captures the file and starts a background worker to
+// commit it, committing in the foreground if no worker can be started
+func runSaveDeferred(s *store.Store, message string) error {
+	pending, err := s.SaveDeferred(message)
+	if err != nil {
+		if err == store.ErrNoChanges {
+			info("No changes to save")
+			return nil
+		}
+		if !reportRegionMissing(s, err) && !reportInvalid(err) && !reportUnsettled(s, err) {
+			fail("Failed to save: %v", err)
+		}
+		return nil
+	}
+
+	mode := "--local"
+	if s.Global {
+		mode = "--global"
+	}
+	// The worker waits its turn behind other processes using the file
+	args := []string{"flush-pending", s.FilePath, mode, "--wait"}
+	if !s.IsDefaultProfile() {
+		args = append(args, "--profile", s.Profile)
+	}
+	if _, err := daemon.Detach(args...); err != nil {
+		warn("Could not save in the background: %v", err)
+		return runFlushPending(s)
+	}
+
+	success("Captured %s; the snapshot is being saved in the background", formatBytes(pending.Size))
+	info("Use 'oops now' to check on it")
+	return nil
+}
+
+// runFlushPending commits captured snapshots, reporting the result
+func runFlushPending(s *store.Store) error {
+	n, err := s.FlushPending()
+	if err != nil {
+		if !reportTooLarge(err, false) {
+			fail("Failed to save: %v", err)
+		}
+		return nil
+	}
+	if n > 0 {
+		latest, _ := s.GetLatestVersion()
+		success("%d %s saved, latest is #%d", n, plural(n, "snapshot"), latest)
+	}
+	return nil
+}
+
+var flushPendingCmd = &cobra.Command{
+	Use:    "flush-pending <file>",
+	Short:  "Commit snapshots captured by save --async",
+	Hidden: true,
+	Args:   cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s, err := store.NewStoreWithOptions(args[0], storeOptions())
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		return runFlushPending(s)
+	},
+}
+
+// confirmSecrets warns about likely credentials in the pending snapshot and
+// asks whether to save it anyway. It returns true when there is nothing to
+// warn about.
+func confirmSecrets(s *store.Store) bool {
+	if changed, err := s.Repo.HasChanges(); err != nil || !changed {
+		return true // Save reports these
+	}
+	findings, err := s.ScanSecrets()
+	if err != nil || len(findings) == 0 {
+		return true
+	}
+
+	warn("%s may contain credentials:", s.FileName)
+	for _, f := range findings {
+		fmt.Printf("    line %d: %s (%s)\n", f.Line, f.Kind, f.Match)
+	}
+	info("Snapshots are kept forever; to mask values use: oops config --redact <regex>")
+	return confirm("Save anyway?")
+}
+
+// reportTooLarge explains a snapshot refused for its size, returning false
+// for any other error. starting is set when the file is not tracked yet.
+func reportTooLarge(err error, starting bool) bool {
+	var tooLarge *store.SnapshotTooLargeError
+	if !errors.As(err, &tooLarge) {
+		return false
+	}
+	fail("Snapshot too large: %s (%s compressed), limit is %s",
+		formatBytes(tooLarge.Size), formatBytes(tooLarge.Compressed), formatBytes(tooLarge.Limit))
+	if starting {
+		info("Is this a generated file? It may not need a history")
+	} else {
+		info("Is this a generated file? Consider 'oops done' to stop tracking it")
+	}
+	info("Or raise the limit: oops config --max-snapshot-size 500MB")
+	return true
+}
+
+// reportRegionMissing explains a save refused because the markers of a
+// region-tracked file are gone, returning false for any other error
+func reportRegionMissing(s *store.Store, err error) bool {
+	if err != store.ErrRegionNotFound {
+		return false
+	}
+	m := s.Region()
+	fail("%s has no region %s", s.FileName, m)
+	info("Put the tracked lines between a line containing %q and one containing %q", m.Start, m.End)
+	return true
+}
+
 func init() {
+	saveCmd.Flags().BoolVarP(&saveYes, "yes", "y", false, "Save without checking for credentials")
+	saveCmd.Flags().BoolVar(&saveAsync, "async", false, "Capture the file and commit the snapshot in the background")
+	saveCmd.Flags().BoolVar(&saveStdin, "stdin", false, "Replace the file with what is piped in before saving")
+	saveCmd.Flags().BoolVarP(&saveForce, "force", "f", false, "With --stdin, discard unsaved changes")
 	rootCmd.AddCommand(saveCmd)
+	rootCmd.AddCommand(flushPendingCmd)
 }
@@ -0,0 +1,287 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/schedule"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	scheduleMessage string
+	scheduleOnce    bool
+)
+
+var scheduleCmd = &cobra.Command{
+	Use:   "schedule",
+	Short: "⏰ Save snapshots on a schedule",
+	Long: `Define cron-style schedules that save snapshots automatically.
+
+Schedules are stored in ~/.oops/config and run by the daemon
+('oops daemon start'). To use your system's cron instead, run
+'oops schedule run --once' every minute. Files without changes are
+skipped.
+
+A default schedule can cover every tracked file, local and global. A
+file's own schedules replace the default for that file; schedule it as
+"off" to leave it out.
+
+Cron format: minute hour day month weekday (or @hourly, @daily, ...)
+
+Examples:
+  oops schedule add "0 18 * * *" journal.md      Every day at 18:00
+  oops schedule add "*/30 9-17 * * 1-5" notes.md -m "Work autosave"
+  oops schedule default @hourly                  Snapshot all tracked files hourly
+  oops schedule add off big.csv                  Leave a file out of the default
+  oops schedule default off                      Remove the default schedule
+  oops schedule list
+  oops schedule remove 1`,
+}
+
+var scheduleDefaultCmd = &cobra.Command{
+	Use:   "default <cron|off>",
+	Short: "Set the schedule for all tracked files",
+	Args:  cobra.ExactArgs(1),
+	RunE:  runScheduleDefault,
+}
+
+var scheduleAddCmd = &cobra.Command{
+	Use:   "add <cron> <file>",
+	Short: "Add a snapshot schedule",
+	Args:  cobra.ExactArgs(2),
+	RunE:  runScheduleAdd,
+}
+
+var scheduleListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List snapshot schedules",
+	Args:    cobra.NoArgs,
+	RunE:    runScheduleList,
+}
+
+var scheduleRemoveCmd = &cobra.Command{
+	Use:     "remove <number>",
+	Aliases: []string{"rm"},
+	Short:   "Remove a snapshot schedule",
+	Args:    cobra.ExactArgs(1),
+	RunE:    runScheduleRemove,
+}
+
+var scheduleRunCmd = &cobra.Command{
+	Use:   "run",
+	Short: "Run schedules in the foreground",
+	Long: `Run snapshot schedules without the daemon.
+
+With --once, saves snapshots for schedules due this minute and exits,
+for use from an external cron or task scheduler.`,
+	Args: cobra.NoArgs,
+	RunE: runScheduleRun,
+}
+
+func runScheduleDefault(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+		return nil
+	}
+
+	if args[0] == schedule.Off {
+		cfg.ScheduleAll = ""
+		if err := cfg.Save(); err != nil {
+			fail("Failed to save config: %v", err)
+			return nil
+		}
+		success("Default schedule removed")
+		return nil
+	}
+
+	c, err := schedule.ParseCron(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	cfg.ScheduleAll = c.String()
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+	success("All tracked files will be snapshotted at %q", c.String())
+	if next := c.Next(time.Now()); !next.IsZero() {
+		info("Next run: %s", next.Format("Mon Jan 2 15:04"))
+	}
+	info("Files with their own schedules keep them; local files are found once oops has used them")
+	info("Schedules run while the daemon is running ('oops daemon start')")
+	return nil
+}
+
+func runScheduleAdd(cmd *cobra.Command, args []string) error {
+	cronExpr, filePath := args[0], args[1]
+
+	cronValue := schedule.Off
+	if cronExpr != schedule.Off {
+		c, err := schedule.ParseCron(cronExpr)
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		cronValue = c.String()
+	}
+
+	if !utils.IsFile(filePath) {
+		fail("'%s' is not a valid file", filePath)
+		return nil
+	}
+
+	s, err := getStoreForFile(filePath)
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if !s.Exists() {
+		fail("'%s' is not tracked", s.FileName)
+		if globalFlag {
+			info("Use 'oops start -g %s' first", filePath)
+		} else {
+			info("Use 'oops start %s' first", filePath)
+		}
+		return nil
+	}
+
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+		return nil
+	}
+
+	cfg.Schedules = append(cfg.Schedules, config.Schedule{
+		Cron:     cronValue,
+		FilePath: s.FilePath,
+		Global:   s.Global,
+		Message:  scheduleMessage,
+	})
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+
+	if cronValue == schedule.Off {
+		success("'%s' is left out of the default schedule (schedule #%d)", s.FileName, len(cfg.Schedules))
+		return nil
+	}
+	success("Scheduled '%s' at %q (schedule #%d)", s.FileName, cronValue, len(cfg.Schedules))
+	if c, err := schedule.ParseCron(cronValue); err == nil {
+		if next := c.Next(time.Now()); !next.IsZero() {
+			info("Next run: %s", next.Format("Mon Jan 2 15:04"))
+		}
+	}
+	info("Schedules run while the daemon is running ('oops daemon start')")
+	return nil
+}
+
+func runScheduleList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+		return nil
+	}
+
+	if len(cfg.Schedules) == 0 && cfg.ScheduleAll == "" {
+		info("No schedules")
+		info("Use 'oops schedule add \"0 18 * * *\" <file>' to add one")
+		return nil
+	}
+
+	if cfg.ScheduleAll != "" {
+		fmt.Printf("⏰ Default: %s for every tracked file without its own schedule\n", cfg.ScheduleAll)
+		if len(cfg.Schedules) == 0 {
+			return nil
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("⏰ Snapshot schedules:")
+	for i, sched := range cfg.Schedules {
+		next := "invalid expression"
+		if sched.Cron == schedule.Off {
+			next = "left out of the default schedule"
+		}
+		if c, err := schedule.ParseCron(sched.Cron); err == nil {
+			if t := c.Next(time.Now()); !t.IsZero() {
+				next = "next " + t.Format("Mon Jan 2 15:04")
+			}
+		}
+		mode := ""
+		if sched.Global {
+			mode = " (global)"
+		}
+		fmt.Printf("  #%-2d %-16s %s%s\n", i+1, sched.Cron, sched.FilePath, mode)
+		if sched.Message != "" {
+			info("      %q, %s", sched.Message, next)
+		} else {
+			info("      %s", next)
+		}
+	}
+	return nil
+}
+
+func runScheduleRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+		return nil
+	}
+
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 || num > len(cfg.Schedules) {
+		fail("Invalid schedule number: %s", args[0])
+		info("Use 'oops schedule list' to see schedules")
+		return nil
+	}
+
+	removed := cfg.Schedules[num-1]
+	cfg.Schedules = append(cfg.Schedules[:num-1], cfg.Schedules[num:]...)
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+
+	success("Removed schedule #%d (%s %s)", num, removed.Cron, removed.FilePath)
+	return nil
+}
+
+func runScheduleRun(cmd *cobra.Command, args []string) error {
+	logger := log.New(os.Stdout, "", log.LstdFlags)
+
+	if scheduleOnce {
+		results, err := schedule.RunDue(time.Now())
+		if err != nil {
+			fail("Failed to load config: %v", err)
+			return nil
+		}
+		for _, r := range results {
+			schedule.LogResult(logger, "schedule", r)
+		}
+		return nil
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	info("Running schedules (Ctrl+C to stop)")
+	return schedule.Service{}.Run(ctx, logger)
+}
+
+func init() {
+	scheduleAddCmd.Flags().StringVarP(&scheduleMessage, "message", "m", "", "Snapshot message (default \""+schedule.DefaultMessage+"\")")
+	scheduleRunCmd.Flags().BoolVar(&scheduleOnce, "once", false, "Run schedules due now and exit")
+	scheduleCmd.AddCommand(scheduleAddCmd, scheduleDefaultCmd, scheduleListCmd, scheduleRemoveCmd, scheduleRunCmd)
+	rootCmd.AddCommand(scheduleCmd)
+}
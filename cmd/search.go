@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	searchIgnoreCase bool
+	searchMaxLines   int
+)
+
+var searchCmd = &cobra.Command{
+	Use:     "search <text>",
+	Aliases: []string{"grep"},
+	Short:   "🔎 Find snapshots containing text",
+	Long: `Search every snapshot of the tracked file for text, in its content,
+message and note, and list the snapshots that contain it with the
+matching lines, newest first.
+
+Examples:
+  oops search "TODO"           Snapshots with TODO, and where
+  oops search -i "draft"       Ignore case
+  oops search "api" --lines 0  List only the snapshot numbers`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSearch,
+}
+
+func runSearch(cmd *cobra.Command, args []string) error {
+	query := args[0]
+	if query == "" {
+		fail("Nothing to search for")
+		return nil
+	}
+	if searchMaxLines < 0 {
+		fail("--lines must be 0 or more")
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	matches, err := s.Search(query, searchIgnoreCase)
+	if err != nil {
+		fail("Failed to search history: %v", err)
+		return nil
+	}
+	if len(matches) == 0 {
+		info("No snapshot of %s contains %q", s.FileName, query)
+		return nil
+	}
+
+	fmt.Printf("🔎 %q in %d %s of %s:\n\n", query, len(matches), plural(len(matches), "snapshot"), s.FileName)
+	for _, m := range matches {
+		where := fmt.Sprintf("%d %s", len(m.Lines), plural(len(m.Lines), "line"))
+		if m.InMessage {
+			if len(m.Lines) == 0 {
+				where = "message"
+			} else {
+				where += ", message"
+			}
+		}
+		fmt.Printf("#%-3d  %-30s  %s  (%s)\n", m.Number, m.Message, formatTimeAgo(m.Timestamp), where)
+		for i, l := range m.Lines {
+			if i == searchMaxLines {
+				info("    ... %d more", len(m.Lines)-i)
+				break
+			}
+			fmt.Printf("  %5d: %s\n", l.Line, clip(strings.TrimSpace(l.Text), 70))
+		}
+	}
+	return nil
+}
+
+func init() {
+	searchCmd.Flags().BoolVarP(&searchIgnoreCase, "ignore-case", "i", false, "Match regardless of case")
+	searchCmd.Flags().IntVar(&searchMaxLines, "lines", 3, "Matching lines to show per snapshot")
+	rootCmd.AddCommand(searchCmd)
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var showNoDiff bool
+
+var showCmd = &cobra.Command{
+	Use:   "show <version>",
+	Short: "🔎 Show one snapshot in detail",
+	Long: `Show a snapshot's message, time, hash, size and how it is stored, with
+its note and origin, followed by what changed since the snapshot before
+it.
+
+Examples:
+  oops show 4             Details and diff of snapshot #4
+  oops show 4 --no-diff   Details only`,
+	Args:              cobra.ExactArgs(1),
+	ValidArgsFunction: completeSnapshots(1),
+	RunE:              runShow,
+}
+
+func runShow(cmd *cobra.Command, args []string) error {
+	num, err := strconv.Atoi(args[0])
+	if err != nil || num < 1 {
+		fail("Invalid snapshot number: %s", args[0])
+		return nil
+	}
+
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	d, err := s.Details(num)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops history' to see available snapshots")
+			return nil
+		}
+		fail("Failed to read snapshot #%d: %v", num, err)
+		return nil
+	}
+
+	fmt.Printf("📸 Snapshot: #%d of %s\n", d.Number, s.FileName)
+	fmt.Printf("💬 Message:  %s\n", d.Message)
+	fmt.Printf("🕐 Saved:    %s (%s)\n", d.Timestamp.Format("2006-01-02 15:04:05"), formatTimeAgo(d.Timestamp))
+	fmt.Printf("🔑 Commit:   %s\n", d.Commit)
+	if d.Packed {
+		fmt.Printf("📦 Size:     %s, packed to %s to fit the size limit\n", formatBytes(d.Size), formatBytes(d.StoredSize))
+	} else {
+		fmt.Printf("📦 Size:     %s\n", formatBytes(d.Size))
+	}
+	if d.Origin != store.OriginManual {
+		fmt.Printf("🤖 Origin:   %s\n", d.Origin)
+	}
+	if d.Note != "" {
+		fmt.Printf("📝 Note:     %s\n", d.Note)
+	}
+
+	if showNoDiff {
+		return nil
+	}
+	fmt.Println()
+	if d.Previous == 0 {
+		info("The first snapshot; there is nothing before it to compare with")
+		return nil
+	}
+	info("Changes since #%d:", d.Previous)
+	return printChanges(s, d.Previous, d.Number)
+}
+
+func init() {
+	showCmd.Flags().BoolVar(&showNoDiff, "no-diff", false, "Leave out the changes since the snapshot before")
+	rootCmd.AddCommand(showCmd)
+}
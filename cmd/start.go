@@ -1,21 +1,52 @@
 package cmd
 
 import (
+	"github.com/iyulab/oops/internal/region"
 	"github.com/iyulab/oops/internal/store"
 	"github.com/iyulab/oops/internal/utils"
 	"github.com/spf13/cobra"
 )
 
+var (
+	startAuto        bool
+	startRegion      bool
+	startRegionStart string
+	startRegionEnd   string
+)
+
 var startCmd = &cobra.Command{
-	Use:     "start <file>",
-	Aliases: []string{"track", "watch"},
+	Use:     "start <file>...",
+	Aliases: []string{"track"},
 	Short:   "👀 Start versioning a file",
-	Long:    `Start tracking a file for versioning. Creates the first snapshot automatically.`,
-	Args:    cobra.ExactArgs(1),
-	RunE:    runStart,
+	Long: `Start tracking a file for versioning. Creates the first snapshot automatically.
+
+With --auto, keep running and save a snapshot whenever the file changes,
+like 'oops watch'.
+
+With --region, only the lines between two marker lines are versioned,
+for files that are mostly managed by something else. Markers can sit in
+any comment, e.g. "# oops:start" and "# oops:end". Restoring a snapshot
+replaces just those lines.
+
+Several files, directories (every file under them, skipping hidden
+directories) and patterns can be started at once. Each file's result is
+listed in a table at the end. When some fail, the histories the run
+created are removed after asking, or right away with
+--rollback-on-error, so a directory is not left half tracked.
+
+Examples:
+  oops start notes.md                Track the whole file
+  oops start docs/ "*.yaml"          Track every file under docs/ and each YAML file
+  oops start ~/.bashrc --region      Track the lines between oops:start and oops:end
+  oops start app.yaml --region-start "BEGIN mine" --region-end "END mine"`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runStart,
 }
 
 func runStart(cmd *cobra.Command, args []string) error {
+	if isBatchStart(args) {
+		return runStartBatch(args)
+	}
 	filePath := args[0]
 
 	if !utils.IsFile(filePath) {
@@ -23,17 +54,78 @@ func runStart(cmd *cobra.Command, args []string) error {
 		return nil
 	}
 
-	s, err := store.NewStoreWithOptions(filePath, store.StoreOptions{Global: globalFlag})
+	s, err := store.NewStoreWithOptions(filePath, storeOptions())
 	if err != nil {
 		fail("Error: %v", err)
 		return nil
 	}
 
 	if s.Exists() {
-		warn("'%s' is already being tracked", s.FileName)
+		if s.IsDefaultProfile() {
+			warn("'%s' is already being tracked", s.FileName)
+		} else {
+			warn("Profile '%s' of '%s' already exists", s.Profile, s.FileName)
+		}
 		info("Use 'oops now' to see current status")
 		return nil
 	}
+	if main, _ := s.OpenProfile(store.DefaultProfile); !main.Exists() && !s.IsDefaultProfile() {
+		fail("'%s' is not tracked yet", s.FileName)
+		info("Use 'oops start %s' first, then start the profile", s.FileName)
+		return nil
+	}
+
+	if startRegion || startRegionStart != "" || startRegionEnd != "" {
+		markers := region.Default
+		if startRegionStart != "" {
+			markers.Start = startRegionStart
+		}
+		if startRegionEnd != "" {
+			markers.End = startRegionEnd
+		}
+		if err := s.SetRegion(&markers); err != nil {
+			fail("%v", err)
+			return nil
+		}
+	}
+
+	if from, err := s.FindHistory(); err == nil && from != nil {
+		if !attachHistory(s, from) {
+			return nil
+		}
+	} else if !startTracking(s, "") {
+		return nil
+	}
+	if startAuto {
+		return watchStore(s)
+	}
+	info("Use 'oops save \"message\"' to save changes")
+	return nil
+}
+
+// attachHistory continues the history of a file that was moved or copied
+// here, reporting the result
+func attachHistory(s *store.Store, from *store.GlobalStoreInfo) bool {
+	moved, err := s.Attach(from)
+	if err != nil {
+		fail("Failed to attach history of %s: %v", from.FilePath, err)
+		return false
+	}
+	latest, _ := s.Repo.GetLatestTagNumber()
+	if moved {
+		success("Found the history of '%s', which moved here (snapshot #%d)", from.FilePath, latest)
+	} else {
+		success("Copied the history of '%s' (snapshot #%d)", from.FilePath, latest)
+	}
+	info("Storage: %s", s.OopsDirPath())
+	warnNetwork(s)
+	return true
+}
+
+// startTracking creates the store's first snapshot with message ("" for
+// the default), reporting the result
+func startTracking(s *store.Store, message string) bool {
+	filePath := s.FilePath
 
 	// Check for duplicate tracking (file tracked in both local and global)
 	hasLocal, hasGlobal := store.CheckDuplicateTracking(filePath)
@@ -45,26 +137,40 @@ func runStart(cmd *cobra.Command, args []string) error {
 		info("Consider using 'oops done -g' to stop global tracking first")
 	}
 
-	if err := s.Initialize(); err != nil {
-		fail("Failed to start tracking: %v", err)
-		return nil
+	if err := s.InitializeWithMessage(message); err != nil {
+		if reportRegionMissing(s, err) {
+			return false
+		}
+		if !reportTooLarge(err, true) {
+			fail("Failed to start tracking: %v", err)
+		}
+		return false
 	}
 
 	// Add to .gitignore if present (only for local mode)
 	if !globalFlag {
 		utils.EnsureGitignore(s.BaseDir)
+		s.RegisterLocal()
 	}
 
-	if globalFlag {
+	if !s.IsDefaultProfile() {
+		success("Started profile '%s' of '%s' (snapshot #1)", s.Profile, s.FileName)
+		info("Use --profile %s with other commands to work in it", s.Profile)
+	} else if globalFlag {
 		success("Now watching '%s' globally (snapshot #1)", s.FileName)
 		info("Storage: %s", s.OopsDirPath())
 	} else {
 		success("Now watching '%s' (snapshot #1)", s.FileName)
 	}
-	info("Use 'oops save \"message\"' to save changes")
-	return nil
+	warnNetwork(s)
+	return true
 }
 
 func init() {
+	startCmd.Flags().BoolVar(&startRegion, "region", false, "Track only the lines between oops:start and oops:end markers")
+	startCmd.Flags().StringVar(&startRegionStart, "region-start", "", "Marker starting the tracked region (implies --region)")
+	startCmd.Flags().StringVar(&startRegionEnd, "region-end", "", "Marker ending the tracked region (implies --region)")
+	startCmd.Flags().BoolVar(&startRollback, "rollback-on-error", false, "When starting several files and some fail, remove the histories the run created")
+	startCmd.Flags().BoolVar(&startAuto, "auto", false, "Keep running and save a snapshot whenever the file changes")
 	rootCmd.AddCommand(startCmd)
 }
@@ -0,0 +1,254 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+)
+
+var startRollback bool
+
+// startMarks marks each outcome in the table of a batch start
+var startMarks = map[string]string{"started": "✓", "attached": "✓", "skipped": "–", "failed": "✗"}
+
+// startResult is how starting one file of a batch went
+type startResult struct {
+	path    string
+	outcome string // started, attached, skipped or failed
+	detail  string
+	store   *store.Store
+	created bool // the run created its history, so rollback removes it
+}
+
+// isBatchStart reports whether args name more than one file to start
+func isBatchStart(args []string) bool {
+	return len(args) > 1 || utils.IsDir(args[0]) || isPattern(args[0])
+}
+
+// isPattern reports whether arg is a glob the shell left unexpanded, as
+// Windows shells do
+func isPattern(arg string) bool {
+	return strings.ContainsAny(arg, "*?[") && !utils.IsFile(arg)
+}
+
+// expandStartArgs returns the files named by args: files as given, the
+// files under directories, skipping hidden directories such as .oops,
+// and the matches of patterns
+func expandStartArgs(args []string) ([]string, error) {
+	seen := map[string]bool{}
+	var files []string
+	add := func(path string) {
+		if !seen[path] {
+			seen[path] = true
+			files = append(files, path)
+		}
+	}
+
+	for _, arg := range args {
+		switch {
+		case utils.IsDir(arg):
+			err := filepath.WalkDir(arg, func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+				if d.IsDir() {
+					if path != arg && strings.HasPrefix(d.Name(), ".") {
+						return filepath.SkipDir
+					}
+					return nil
+				}
+				if d.Type().IsRegular() {
+					add(path)
+				}
+				return nil
+			})
+			if err != nil {
+				return nil, err
+			}
+		case isPattern(arg):
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("bad pattern %s: %w", arg, err)
+			}
+			for _, m := range matches {
+				if utils.IsFile(m) {
+					add(m)
+				}
+			}
+		default:
+			add(arg)
+		}
+	}
+	return files, nil
+}
+
+// runStartBatch starts tracking every file named by args, then reports
+// each one in a table. If some fail, the histories the run created are
+// removed with --rollback-on-error, or after asking.
+func runStartBatch(args []string) error {
+	if startAuto || startRegion || startRegionStart != "" || startRegionEnd != "" {
+		fail("--auto and --region work with a single file")
+		return nil
+	}
+	files, err := expandStartArgs(args)
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if len(files) == 0 {
+		fail("No files found in %s", strings.Join(args, " "))
+		return nil
+	}
+
+	results := make([]startResult, 0, len(files))
+	failed := 0
+	for _, path := range files {
+		r := startOne(path)
+		if r.outcome == "failed" {
+			failed++
+		}
+		results = append(results, r)
+	}
+	printStartResults(results)
+
+	var created []startResult
+	for _, r := range results {
+		if r.created {
+			created = append(created, r)
+		}
+	}
+	if failed == 0 {
+		success("Started %d of %d %s", len(files)-countOutcome(results, "skipped"), len(files), plural(len(files), "file"))
+		return nil
+	}
+
+	fail("%d of %d %s could not be started", failed, len(files), plural(len(files), "file"))
+	if len(created) == 0 {
+		return nil
+	}
+	switch {
+	case startRollback:
+	case isTerminal(os.Stdin):
+		if !confirm(fmt.Sprintf("Remove the histories of the %d %s started in this run?", len(created), plural(len(created), "file"))) {
+			info("Kept them; fix the failed files and run 'oops start' on them again")
+			return nil
+		}
+	default:
+		info("Use --rollback-on-error to remove the histories a partly failed run started")
+		return nil
+	}
+	rollbackStart(created)
+	return nil
+}
+
+// startOne starts tracking path, quietly, for a batch
+func startOne(path string) startResult {
+	r := startResult{path: path}
+	if !utils.IsFile(path) {
+		r.outcome, r.detail = "failed", "not a file"
+		return r
+	}
+	s, err := store.NewStoreWithOptions(path, storeOptions())
+	if err != nil {
+		r.outcome, r.detail = "failed", err.Error()
+		return r
+	}
+	r.store = s
+	if s.Exists() {
+		r.outcome, r.detail = "skipped", "already tracked"
+		return r
+	}
+
+	if from, err := s.FindHistory(); err == nil && from != nil {
+		// An attached history was not made by this run; rollback keeps it
+		if _, err := s.Attach(from); err != nil {
+			r.outcome, r.detail = "failed", fmt.Sprintf("attaching the history of %s: %v", from.FilePath, err)
+			return r
+		}
+		r.outcome, r.detail = "attached", "continues the history of "+from.FilePath
+		return r
+	}
+
+	existed := pathExists(s.GitDir)
+	err = s.InitializeWithMessage("")
+	r.created = !existed && pathExists(s.GitDir) // including what a failed start left
+	if err != nil {
+		r.outcome, r.detail = "failed", startFailure(err)
+		return r
+	}
+	if !globalFlag {
+		utils.EnsureGitignore(s.BaseDir)
+		s.RegisterLocal()
+	}
+	r.outcome = "started"
+	return r
+}
+
+// startFailure describes why a file could not be started, in a line
+func startFailure(err error) string {
+	var tooLarge *store.SnapshotTooLargeError
+	var locked *store.LockedError
+	switch {
+	case errors.As(err, &tooLarge):
+		return fmt.Sprintf("too large: %s, limit is %s", formatBytes(tooLarge.Size), formatBytes(tooLarge.Limit))
+	case err == store.ErrNotTracked:
+		return "the file itself is not tracked yet"
+	case errors.As(err, &locked):
+		return "busy: the " + locked.Error()
+	}
+	return err.Error()
+}
+
+// printStartResults lists how starting each file of a batch went
+func printStartResults(results []startResult) {
+	width := len("File")
+	for _, r := range results {
+		width = max(width, len(r.path))
+	}
+	fmt.Printf("  %-*s  %s\n", width, "File", "Result")
+	for _, r := range results {
+		result := startMarks[r.outcome] + " " + r.outcome
+		if r.detail != "" {
+			result += ": " + r.detail
+		}
+		fmt.Printf("  %-*s  %s\n", width, r.path, result)
+	}
+}
+
+// rollbackStart removes the histories a partly failed batch created
+func rollbackStart(created []startResult) {
+	removed := 0
+	for _, r := range created {
+		if err := r.store.Delete(); err != nil {
+			fail("Failed to remove the history of %s: %v", r.path, err)
+			continue
+		}
+		if !r.store.Global {
+			os.Remove(r.store.OopsDirPath()) // Only if nothing else is tracked there
+		}
+		removed++
+	}
+	success("Removed the histories of the %d %s started in this run", removed, plural(removed, "file"))
+}
+
+// countOutcome counts the results with outcome
+func countOutcome(results []startResult, outcome string) int {
+	n := 0
+	for _, r := range results {
+		if r.outcome == outcome {
+			n++
+		}
+	}
+	return n
+}
+
+func pathExists(path string) bool {
+	_, err := os.Stat(path)
+	return err == nil
+}
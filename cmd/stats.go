@@ -0,0 +1,317 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/promtext"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	statsTop        int
+	statsAll        bool
+	statsPrometheus bool
+	statsOutput     string
+)
+
+var statsCmd = &cobra.Command{
+	Use:     "stats [file]",
+	Aliases: []string{"churn", "hotspots"},
+	Short:   "🔥 Show which lines change most and what the history takes on disk",
+	Long: `Count how many snapshots changed each line of the current version,
+and list the hotspots: the lines and sections rewritten most often.
+Then report the store's size: the snapshot count, the disk it takes
+against every snapshot kept in full, how the file grew over time, and
+the size of the latest snapshots.
+
+A rewritten line keeps the count of the line it replaced. Sections are
+Markdown headings in .md files and [table] headers in others.
+Diffs between snapshots are cached, so later runs only compare the
+snapshots saved since.
+
+With --all, the sizes of every local and global store are listed with
+their total instead.
+
+With --prometheus, every store's snapshot count, size on disk, time
+since the last save and whether it has unsaved changes are written in
+Prometheus text format, to stdout or with -o to a file that
+node_exporter's textfile collector reads. Run it from cron to alert when
+an important file has not been saved for a while.
+
+Examples:
+  oops stats              Top 10 lines and sections, and the store's size
+  oops stats notes.md --top 25
+  oops stats --all        Size of every store
+  oops stats --prometheus -o /var/lib/node_exporter/oops.prom`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runStats,
+}
+
+func runStats(cmd *cobra.Command, args []string) error {
+	if statsTop < 1 {
+		fail("--top must be at least 1")
+		return nil
+	}
+	if statsPrometheus {
+		if len(args) > 0 {
+			fail("--prometheus covers every store and cannot be combined with a file")
+			return nil
+		}
+		return runStatsPrometheus()
+	}
+	if statsAll {
+		if len(args) > 0 {
+			fail("--all cannot be combined with a file")
+			return nil
+		}
+		return runStatsAll()
+	}
+
+	var s *store.Store
+	var err error
+	if len(args) == 1 {
+		s, err = findStoreForPath(args[0])
+	} else {
+		s, err = findTrackedStore()
+	}
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	if content, err := os.ReadFile(s.FilePath); err == nil && eol.IsBinary(content) {
+		info("%s is a binary file; line hotspots are only counted for text", s.FileName)
+		return printStorage(s)
+	}
+
+	churn, err := s.ChurnReport()
+	if err != nil {
+		fail("Failed to analyze history: %v", err)
+		return nil
+	}
+
+	lines := churn.TopLines(statsTop)
+	fmt.Printf("🔥 Most changed lines of %s (%d %s)\n\n", s.FileName, churn.Snapshots, plural(churn.Snapshots, "snapshot"))
+	if len(lines) == 0 {
+		info("No line of the current version has changed since snapshot #1")
+		return printStorage(s)
+	}
+	fmt.Printf("  %7s  %5s  %s\n", "Changes", "Line", "Text")
+	for _, l := range lines {
+		fmt.Printf("  %7d  %5d  %s\n", l.Changes, l.Line, clip(strings.TrimSpace(l.Text), 60))
+	}
+
+	if sections := churn.TopSections(statsTop); len(sections) > 0 {
+		fmt.Printf("\n📑 Most changed sections\n\n")
+		fmt.Printf("  %7s  %5s  %s\n", "Changes", "Line", "Section")
+		for _, sec := range sections {
+			heading, line := sec.Heading, fmt.Sprint(sec.Line)
+			if heading == "" {
+				heading, line = "(before the first heading)", "-"
+			}
+			fmt.Printf("  %7d  %5s  %s\n", sec.Changes, line, clip(heading, 60))
+		}
+	}
+	return printStorage(s)
+}
+
+// printStorage reports the store's size: its disk use against its
+// snapshots in full, its growth, and the sizes of the latest snapshots
+func printStorage(s *store.Store) error {
+	usage, err := s.Storage()
+	if err != nil {
+		fail("Failed to measure storage: %v", err)
+		return nil
+	}
+	if usage.Snapshots == 0 {
+		return nil
+	}
+
+	fmt.Printf("\n💾 Storage\n\n")
+	fmt.Printf("  %-22s %d\n", "Snapshots:", usage.Snapshots)
+	fmt.Printf("  %-22s %s\n", "All in full:", formatBytes(usage.FullSize))
+	if !s.Memory {
+		fmt.Printf("  %-22s %s\n", "On disk:", formatBytes(usage.DiskSize))
+		if saved := usage.Saved(); saved > 0 {
+			fmt.Printf("  %-22s %s (%.1fx smaller)\n", "Saved:", formatBytes(saved), usage.Ratio())
+		}
+	}
+
+	first, last := usage.Sizes[0], usage.Sizes[len(usage.Sizes)-1]
+	if len(usage.Sizes) > 1 {
+		fmt.Printf("  %-22s %s (#%d) → %s (#%d), %s over %s\n", "Growth:",
+			formatBytes(first.Size), first.Number, formatBytes(last.Size), last.Number,
+			signedBytes(last.Size-first.Size), formatSpan(last.Timestamp.Sub(first.Timestamp)))
+	}
+
+	sizes := usage.Sizes
+	if len(sizes) > statsTop {
+		sizes = sizes[len(sizes)-statsTop:]
+	}
+	fmt.Printf("\n  %5s  %-16s  %10s  %10s  %10s\n", "#", "Saved", "Size", "Stored", "Change")
+	for i := len(sizes) - 1; i >= 0; i-- {
+		snap := sizes[i]
+		change := "-"
+		if k := len(usage.Sizes) - len(sizes) + i; k > 0 {
+			change = signedBytes(snap.Size - usage.Sizes[k-1].Size)
+		}
+		fmt.Printf("  %5d  %-16s  %10s  %10s  %10s\n", snap.Number, snap.Timestamp.Format("2006-01-02 15:04"),
+			formatBytes(snap.Size), formatBytes(snap.Stored), change)
+	}
+	return nil
+}
+
+// runStatsAll lists the size of every local and global store with the
+// total
+func runStatsAll() error {
+	tracked, err := store.ListTrackedFiles()
+	if err != nil {
+		fail("Failed to list tracked files: %v", err)
+		return nil
+	}
+
+	var total store.StorageUsage
+	stores := 0
+	for _, file := range tracked {
+		s, err := store.NewStoreWithOptions(file.FilePath, store.StoreOptions{Global: file.Global})
+		if err != nil || !s.Exists() {
+			continue
+		}
+		usage, err := s.Storage()
+		if err != nil {
+			warn("%s: %v", file.FilePath, err)
+			continue
+		}
+		if stores == 0 {
+			fmt.Printf("💾 Storage of every store\n\n")
+			fmt.Printf("  %-40s  %9s  %10s  %10s  %6s\n", "File", "Snapshots", "In full", "On disk", "Ratio")
+		}
+		stores++
+		total.Add(usage)
+		name := file.FilePath
+		if file.Global {
+			name += " (global)"
+		}
+		fmt.Printf("  %-40s  %9d  %10s  %10s  %5.1fx\n", clipLeft(name, 40), usage.Snapshots,
+			formatBytes(usage.FullSize), formatBytes(usage.DiskSize), usage.Ratio())
+	}
+
+	if stores == 0 {
+		info("No tracked files")
+		info("Use 'oops start <file>' to begin")
+		return nil
+	}
+	fmt.Printf("  %-40s  %9d  %10s  %10s  %5.1fx\n", fmt.Sprintf("Total (%d %s)", stores, plural(stores, "store")),
+		total.Snapshots, formatBytes(total.FullSize), formatBytes(total.DiskSize), total.Ratio())
+	return nil
+}
+
+// runStatsPrometheus writes metrics of every local and global store in
+// Prometheus text format to stdout or the --output file
+func runStatsPrometheus() error {
+	tracked, err := store.ListTrackedFiles()
+	if err != nil {
+		fail("Failed to list tracked files: %v", err)
+		return nil
+	}
+
+	snapshots := promtext.Metric{Name: "oops_snapshots", Help: "Snapshots kept of the file"}
+	diskBytes := promtext.Metric{Name: "oops_store_bytes", Help: "Disk space taken by the file's history"}
+	lastSave := promtext.Metric{Name: "oops_last_save_timestamp_seconds", Help: "Unix time of the latest snapshot"}
+	saveAge := promtext.Metric{Name: "oops_last_save_age_seconds", Help: "Seconds since the latest snapshot"}
+	dirty := promtext.Metric{Name: "oops_dirty", Help: "1 if the file has unsaved changes"}
+
+	now := time.Now()
+	for _, file := range tracked {
+		s, err := store.NewStoreWithOptions(file.FilePath, store.StoreOptions{Global: file.Global})
+		if err != nil || !s.Exists() {
+			continue
+		}
+		usage, err := s.Storage()
+		if err != nil {
+			warn("%s: %v", file.FilePath, err)
+			continue
+		}
+		labels := []promtext.Label{{Name: "file", Value: file.FilePath}, {Name: "store", Value: s.Kind()}}
+		snapshots.Samples = append(snapshots.Samples, promtext.Sample{Labels: labels, Value: float64(usage.Snapshots)})
+		diskBytes.Samples = append(diskBytes.Samples, promtext.Sample{Labels: labels, Value: float64(usage.DiskSize)})
+		if len(usage.Sizes) > 0 {
+			latest := usage.Sizes[len(usage.Sizes)-1].Timestamp
+			lastSave.Samples = append(lastSave.Samples, promtext.Sample{Labels: labels, Value: float64(latest.Unix())})
+			saveAge.Samples = append(saveAge.Samples, promtext.Sample{Labels: labels, Value: now.Sub(latest).Round(time.Second).Seconds()})
+		}
+		if _, _, hasChanges, err := s.Now(); err == nil {
+			value := 0.0
+			if hasChanges {
+				value = 1
+			}
+			dirty.Samples = append(dirty.Samples, promtext.Sample{Labels: labels, Value: value})
+		}
+	}
+
+	metrics := []promtext.Metric{snapshots, diskBytes, lastSave, saveAge, dirty}
+	if statsOutput == "" {
+		return promtext.Write(os.Stdout, metrics)
+	}
+	if err := promtext.WriteFile(statsOutput, metrics); err != nil {
+		fail("Failed to write %s: %v", statsOutput, err)
+		return nil
+	}
+	success("Metrics of %d %s written to %s", len(snapshots.Samples), plural(len(snapshots.Samples), "store"), statsOutput)
+	return nil
+}
+
+// signedBytes formats a change in size with its sign
+func signedBytes(n int64) string {
+	switch {
+	case n > 0:
+		return "+" + formatBytes(n)
+	case n < 0:
+		return "-" + formatBytes(-n)
+	}
+	return "0 B"
+}
+
+// formatSpan formats a duration in days, or hours when shorter than one
+func formatSpan(d time.Duration) string {
+	if days := int(d.Hours() / 24); days > 0 {
+		return fmt.Sprintf("%d %s", days, plural(days, "day"))
+	}
+	if hours := int(d.Hours()); hours > 0 {
+		return fmt.Sprintf("%d %s", hours, plural(hours, "hour"))
+	}
+	return "under an hour"
+}
+
+// clip shortens text to at most n characters, marking the cut
+func clip(text string, n int) string {
+	if utf8.RuneCountInString(text) <= n {
+		return text
+	}
+	return string([]rune(text)[:n-1]) + "…"
+}
+
+// clipLeft shortens text to at most n characters, cutting its start, so a
+// path keeps its file name
+func clipLeft(text string, n int) string {
+	runes := []rune(text)
+	if len(runes) <= n {
+		return text
+	}
+	return "…" + string(runes[len(runes)-n+1:])
+}
+
+func init() {
+	statsCmd.Flags().IntVar(&statsTop, "top", 10, "Number of lines, sections and latest snapshots to list")
+	statsCmd.Flags().BoolVar(&statsAll, "all", false, "List the size of every local and global store")
+	statsCmd.Flags().BoolVar(&statsPrometheus, "prometheus", false, "Write metrics of every store in Prometheus text format")
+	statsCmd.Flags().StringVarP(&statsOutput, "output", "o", "", "With --prometheus, write to this file instead of stdout")
+	rootCmd.AddCommand(statsCmd)
+}
@@ -0,0 +1,176 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/usage"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	summaryToday bool
+	summarySince string
+	summaryUsage bool
+)
+
+var summaryCmd = &cobra.Command{
+	Use:   "summary",
+	Short: "📅 Summarize recent snapshots across files",
+	Long: `Show how many snapshots were made, lines added and removed, and which
+files were touched, across files tracked in this directory and globally.
+
+Examples:
+  oops summary                    What happened today
+  oops summary --since yesterday  Since the start of yesterday
+  oops summary --since 2h         In the last two hours
+  oops summary --usage            Commands used and snapshots made
+
+--usage shows counts kept in ~/.oops/usage.json once usage_stats is on
+('oops config --usage-stats on'). They stay on this machine: oops never
+sends them anywhere.`,
+	Args: cobra.NoArgs,
+	RunE: runSummary,
+}
+
+func runSummary(cmd *cobra.Command, args []string) error {
+	if summaryUsage {
+		return runSummaryUsage()
+	}
+
+	ref := "today"
+	if summarySince != "" {
+		if summaryToday {
+			fail("--today and --since cannot be combined")
+			return nil
+		}
+		ref = summarySince
+	}
+	since, err := utils.ParseTimeRef(ref, time.Now())
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	var stores []*store.Store
+	if cwd, err := os.Getwd(); err == nil {
+		stores = append(stores, localStores(cwd)...)
+	}
+	stores = append(stores, globalStores()...)
+
+	fmt.Printf("📅 Summary since %s\n", since.Format("Mon 2006-01-02 15:04"))
+	fmt.Println()
+
+	var touched, snapshots, added, removed int
+	for _, s := range stores {
+		act, err := s.ActivitySince(since)
+		if err != nil {
+			warn("%s: %v", summaryName(s), err)
+			continue
+		}
+		if act.Snapshots == 0 {
+			continue
+		}
+
+		touched++
+		snapshots += act.Snapshots
+		added += act.Added
+		removed += act.Removed
+		fmt.Printf("  %-40s %3d %-9s  +%d -%d\n", summaryName(s),
+			act.Snapshots, plural(act.Snapshots, "snapshot"), act.Added, act.Removed)
+	}
+
+	if touched == 0 {
+		info("No snapshots in this period")
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Printf("  %d %s touched, %d %s, +%d -%d lines\n",
+		touched, plural(touched, "file"), snapshots, plural(snapshots, "snapshot"), added, removed)
+	return nil
+}
+
+// runSummaryUsage shows the locally kept usage counts
+func runSummaryUsage() error {
+	path, err := usage.Path()
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	stats, err := usage.Load(path)
+	if err != nil {
+		fail("Failed to read %s: %v", path, err)
+		return nil
+	}
+
+	cfg, err := config.Load()
+	enabled := err == nil && cfg.UsageStats
+	if stats.Since.IsZero() {
+		if enabled {
+			info("No usage recorded yet")
+		} else {
+			info("Usage stats are off")
+			info("Use 'oops config --usage-stats on' to count commands and snapshots on this machine")
+		}
+		return nil
+	}
+
+	fmt.Printf("📊 Usage since %s\n\n", stats.Since.Format("Mon 2006-01-02"))
+	for _, c := range stats.TopCommands() {
+		fmt.Printf("  %-24s %5d\n", c.Command, c.Count)
+	}
+	fmt.Println()
+	fmt.Printf("  %d %s made, %d %s\n", stats.Snapshots, plural(stats.Snapshots, "snapshot"),
+		stats.Restores, plural(stats.Restores, "restore"))
+	fmt.Println()
+	info("Kept only in %s; nothing is sent anywhere", path)
+	if !enabled {
+		info("Usage stats are off now; these are the counts from before")
+	}
+	return nil
+}
+
+// recordUsage counts a run of cmd and the snapshots and restores it makes
+func recordUsage(cmd *cobra.Command) {
+	path, err := usage.Path()
+	if err != nil || cmd.Hidden {
+		return
+	}
+	name := strings.TrimPrefix(cmd.CommandPath(), cmd.Root().Name()+" ")
+	if !cmd.HasParent() {
+		name = "(menu)"
+	}
+	usage.RecordCommand(path, name)
+	events.Subscribe(usage.Sink(path))
+}
+
+// summaryName names a store in the summary: local files by name, global
+// files by full path
+func summaryName(s *store.Store) string {
+	if s.Global {
+		return s.FilePath + " (global)"
+	}
+	return s.FileName
+}
+
+// plural returns word with an "s" unless n is 1
+func plural(n int, word string) string {
+	if n == 1 {
+		return word
+	}
+	return word + "s"
+}
+
+func init() {
+	summaryCmd.Flags().BoolVar(&summaryToday, "today", false, "Summarize today (the default)")
+	summaryCmd.Flags().StringVar(&summarySince, "since", "", "Summarize since this time (yesterday, 2h, 3d, 2026-10-15)")
+	summaryCmd.Flags().BoolVar(&summaryUsage, "usage", false, "Show local usage counts (see usage_stats)")
+	rootCmd.AddCommand(summaryCmd)
+}
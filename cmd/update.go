@@ -1,19 +1,30 @@
 package cmd
 
 import (
+	"errors"
 	"fmt"
 
 	"github.com/iyulab/oops/internal/updater"
 	"github.com/spf13/cobra"
 )
 
-var checkOnly bool
+var (
+	checkOnly       bool
+	forceSelfUpdate bool
+)
 
 var updateCmd = &cobra.Command{
 	Use:   "update",
 	Short: "🔄 Update oops to the latest version",
 	Long: `Check for updates and optionally install the latest version.
 
+The new version is checked by running it once installed; if it does not
+run, the current version is put back.
+
+If oops was installed with Homebrew, Scoop or apt, the package manager's
+update command is shown instead, so the managed binary is not replaced
+behind its back. Use --force-self-update to replace it anyway.
+
 Examples:
   oops update          Download and install the latest version
   oops update --check  Only check if an update is available`,
@@ -25,6 +36,13 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	info("Checking for updates...")
 
 	release, hasUpdate, err := updater.CheckForUpdate(Version)
+	var limited *updater.RateLimitError
+	if errors.As(err, &limited) {
+		fail("GitHub is limiting requests from this network")
+		info("Try again after %s, or download from https://github.com/%s/releases",
+			limited.Until.Local().Format("15:04"), updater.GitHubRepo)
+		return nil
+	}
 	if err != nil {
 		fail("Failed to check for updates: %v", err)
 		return nil
@@ -39,6 +57,14 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 	info("New version available: %s (current: v%s)", release.TagName, Version)
 	info("Release: %s", release.HTMLURL)
 
+	manager := updater.InstalledBy()
+	if manager != nil && !forceSelfUpdate {
+		fmt.Printf("\n")
+		info("oops was installed with %s; update it with:", manager.Name)
+		info("  %s", manager.UpdateCommand)
+		return nil
+	}
+
 	if checkOnly {
 		fmt.Printf("\n")
 		info("Run 'oops update' to install")
@@ -71,5 +97,6 @@ func runUpdate(cmd *cobra.Command, args []string) error {
 
 func init() {
 	updateCmd.Flags().BoolVarP(&checkOnly, "check", "c", false, "Only check for updates, don't install")
+	updateCmd.Flags().BoolVar(&forceSelfUpdate, "force-self-update", false, "Replace the binary even if a package manager installed it")
 	rootCmd.AddCommand(updateCmd)
 }
@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var validateOff bool
+
+var validateCmd = &cobra.Command{
+	Use:   "validate <file> [command...]",
+	Short: "✅ Check every snapshot with a tool before it is saved",
+	Long: `Set a command that must succeed on a file's content before a snapshot of
+it is saved, such as a linter or a config test. When it fails the
+snapshot is refused and the tool's output is shown, so the history of a
+critical config file only holds versions that at least parse.
+
+The command runs without a shell. {} is replaced by a temporary copy of
+the content to check, keeping the file's extension; without {} the copy
+is passed as the last argument. It runs in the file's folder. Put --
+before a command that has options of its own.
+
+Without a command, the hook is shown and run against the file as it is.
+
+Hooks are kept in ~/.oops/config under the file's path, not with its
+history, so a history shared through a committed .oops folder, a bundle
+or a backup never brings commands to run. 'oops mv' takes the hook along.
+
+Examples:
+  oops validate config.yaml yamllint {}       Lint YAML before every save
+  oops validate nginx.conf -- nginx -t -c {}  Test nginx config
+  oops validate config.yaml                   Show the hook and check the file
+  oops validate config.yaml --off             Remove the hook`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runValidate,
+}
+
+func runValidate(cmd *cobra.Command, args []string) error {
+	s, err := findStoreForPath(args[0])
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+
+	switch {
+	case validateOff:
+		if err := s.SetValidateHook(""); err != nil {
+			fail("Failed to remove the validate hook: %v", err)
+			return nil
+		}
+		success("%s is saved without validation", s.FileName)
+	case len(args) > 1:
+		command := strings.Join(args[1:], " ")
+		if err := s.SetValidateHook(command); err != nil {
+			fail("Failed to set the validate hook: %v", err)
+			return nil
+		}
+		success("Snapshots of %s must pass: %s", s.FileName, command)
+	default:
+		hook := s.ValidateHook()
+		if hook == "" {
+			info("%s has no validate hook", s.FileName)
+			info("Use 'oops validate %s <command>' to set one", s.FileName)
+			return nil
+		}
+		fmt.Printf("✅ %s: %s\n", s.FileName, hook)
+		content, err := s.Repo.ReadWorkFile()
+		if err != nil {
+			fail("Cannot read %s: %v", s.FileName, err)
+			return nil
+		}
+		if err := s.Validate(content); err != nil {
+			if !reportInvalid(err) {
+				fail("Failed to validate: %v", err)
+			}
+			return nil
+		}
+		success("%s passes", s.FileName)
+	}
+	return nil
+}
+
+// reportInvalid explains a snapshot refused by the validate hook, showing
+// the tool's output, and returns false for any other error
+func reportInvalid(err error) bool {
+	var invalid *store.ValidationError
+	if !errors.As(err, &invalid) {
+		return false
+	}
+	fail("Validation failed: %s", invalid.Command)
+	for _, line := range strings.Split(invalid.Output, "\n") {
+		if line != "" {
+			fmt.Printf("    %s\n", line)
+		}
+	}
+	info("Fix the file and save again, or remove the hook with 'oops validate <file> --off'")
+	return true
+}
+
+func init() {
+	validateCmd.Flags().BoolVar(&validateOff, "off", false, "Remove the validate hook")
+	rootCmd.AddCommand(validateCmd)
+}
@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/spf13/cobra"
+)
+
+var (
+	verifyAll    bool
+	verifyRepair bool
+)
+
+var verifyCmd = &cobra.Command{
+	Use:     "verify [file]",
+	Aliases: []string{"fsck"},
+	Short:   "🩺 Check a store's integrity, and repair what can be",
+	Long: `Check a tracked file's history thoroughly:
+
+  objects    every stored object is present and matches its hash, and
+             every snapshot reads back
+  numbering  snapshots are numbered v1 to vN without gaps
+  metadata   a global store has its metadata.txt, naming the file
+  checkout   no second copy of the file is kept beside the history, as
+             older versions did
+  lock       no lock file was left behind by a crashed process
+
+With --repair, snapshots are renumbered, metadata.txt is rewritten, an
+old copy is deleted and stale locks are removed. Damaged objects can only be
+reported; restore them from a backup or a remote.
+
+Examples:
+  oops verify               Check the tracked file here
+  oops verify --all         Check every local and global store
+  oops verify --repair      Check and repair`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runVerify,
+}
+
+func runVerify(cmd *cobra.Command, args []string) error {
+	var stores []*store.Store
+	switch {
+	case verifyAll && len(args) > 0:
+		fail("--all cannot be combined with a file")
+		return nil
+	case verifyAll:
+		tracked, err := store.ListTrackedFiles()
+		if err != nil {
+			fail("Failed to list tracked files: %v", err)
+			return nil
+		}
+		for _, file := range tracked {
+			if s, err := store.NewStoreWithOptions(file.FilePath, store.StoreOptions{Global: file.Global}); err == nil && s.Exists() {
+				stores = append(stores, s)
+			}
+		}
+		if len(stores) == 0 {
+			info("No tracked files")
+			return nil
+		}
+	default:
+		var s *store.Store
+		var err error
+		if len(args) == 1 {
+			s, err = findStoreForPath(args[0])
+		} else {
+			s, err = findTrackedStore()
+		}
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		stores = []*store.Store{s}
+	}
+
+	unhealthy, unrepaired := 0, 0
+	for _, s := range stores {
+		name := s.FilePath
+		if s.Global {
+			name += " (global)"
+		}
+		problems, err := s.Fsck(verifyRepair)
+		if err != nil {
+			fail("%s: %v", name, err)
+			unhealthy++
+			unrepaired++
+			continue
+		}
+		if len(problems) == 0 {
+			success("%s: OK", name)
+			continue
+		}
+
+		unhealthy++
+		fmt.Printf("✗ %s: %d %s\n", name, len(problems), plural(len(problems), "problem"))
+		left := 0
+		for _, p := range problems {
+			if p.Repaired {
+				fmt.Printf("    ✓ %-9s %s (repaired)\n", p.Check, p.Detail)
+			} else {
+				fmt.Printf("    ✗ %-9s %s\n", p.Check, p.Detail)
+				left++
+			}
+		}
+		if left > 0 {
+			unrepaired++
+		}
+	}
+
+	switch {
+	case unhealthy == 0:
+		return nil
+	case !verifyRepair:
+		again := "oops verify --repair"
+		if verifyAll {
+			again += " --all"
+		}
+		fmt.Println()
+		info("Run '%s' to fix what can be fixed", again)
+	case unrepaired > 0:
+		fmt.Println()
+		warn("Some problems could not be repaired; restore the history from a backup or a remote")
+	}
+	return nil
+}
+
+func init() {
+	verifyCmd.Flags().BoolVar(&verifyAll, "all", false, "Check every local and global store")
+	verifyCmd.Flags().BoolVar(&verifyRepair, "repair", false, "Renumber snapshots, rewrite metadata, delete an old copy and remove stale locks")
+	rootCmd.AddCommand(verifyCmd)
+}
@@ -0,0 +1,115 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/spf13/cobra"
+)
+
+var versionsOpen int
+
+var versionsCmd = &cobra.Command{
+	Use:   "versions [--open <N>]",
+	Short: "🗂 List previous versions, or open one in the file manager",
+	Long: `List the snapshots of the tracked file as previous versions, with
+the date each was saved and its size, like Windows' "Restore previous
+versions" tab.
+
+With --open, the version is copied under the name of the file into a
+folder of its own in the temp directory, and that folder is opened in
+Explorer (Finder on macOS, the default file manager on Linux) with the
+copy selected. Open it, compare it, or copy what you need from it; the
+working file is left alone.
+
+Examples:
+  oops versions             Every version, newest first
+  oops versions --open 3    Open snapshot #3 in Explorer`,
+	Args: cobra.NoArgs,
+	RunE: runVersions,
+}
+
+func runVersions(cmd *cobra.Command, args []string) error {
+	s, err := findTrackedStore()
+	if err != nil {
+		fail("%v", err)
+		return nil
+	}
+	if cmd.Flags().Changed("open") {
+		return openVersion(s, versionsOpen)
+	}
+
+	usage, err := s.Storage()
+	if err != nil {
+		fail("Failed to read snapshots: %v", err)
+		return nil
+	}
+	if usage.Snapshots == 0 {
+		info("No snapshots yet")
+		return nil
+	}
+
+	history, err := s.History()
+	if err != nil {
+		fail("Failed to get history: %v", err)
+		return nil
+	}
+	messages := map[int]string{}
+	for _, snap := range history {
+		messages[snap.Number] = snap.Message
+	}
+
+	fmt.Printf("🗂 Previous versions of %s\n\n", s.FileName)
+	fmt.Printf("  %5s  %-16s  %10s  %s\n", "#", "Date modified", "Size", "Message")
+	for i := len(usage.Sizes) - 1; i >= 0; i-- {
+		snap := usage.Sizes[i]
+		fmt.Printf("  %5d  %-16s  %10s  %s\n", snap.Number, snap.Timestamp.Format("2006-01-02 15:04"),
+			formatBytes(snap.Size), clip(messages[snap.Number], 50))
+	}
+	fmt.Println()
+	info("Use 'oops versions --open <N>' to open one in the file manager")
+	return nil
+}
+
+// openVersion copies snapshot num into a temp folder of its own and
+// reveals it in the file manager
+func openVersion(s *store.Store, num int) error {
+	content, err := s.Content(num)
+	if err != nil {
+		if err == store.ErrVersionNotFound {
+			fail("Snapshot #%d not found", num)
+			info("Use 'oops versions' to see available versions")
+			return nil
+		}
+		fail("Failed to read snapshot #%d: %v", num, err)
+		return nil
+	}
+
+	stem := strings.TrimSuffix(s.FileName, filepath.Ext(s.FileName))
+	dir := filepath.Join(os.TempDir(), "oops-versions", fmt.Sprintf("%s #%d", stem, num))
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		fail("Failed to create %s: %v", dir, err)
+		return nil
+	}
+	path := filepath.Join(dir, s.FileName)
+	os.Chmod(path, 0644) // An earlier copy is read-only
+	if err := os.WriteFile(path, content, 0444); err != nil {
+		fail("Failed to write %s: %v", path, err)
+		return nil
+	}
+
+	success("Copied snapshot #%d to %s", num, path)
+	if err := utils.Reveal(path); err != nil {
+		warn("Could not open the file manager: %v", err)
+	}
+	return nil
+}
+
+func init() {
+	versionsCmd.Flags().IntVar(&versionsOpen, "open", 0, "Copy version N to a temp folder and open it in the file manager")
+	rootCmd.AddCommand(versionsCmd)
+}
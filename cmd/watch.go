@@ -0,0 +1,198 @@
+package cmd
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/daemon"
+	"github.com/iyulab/oops/internal/store"
+	"github.com/iyulab/oops/internal/utils"
+	"github.com/iyulab/oops/internal/watch"
+	"github.com/spf13/cobra"
+)
+
+var (
+	watchDebounce time.Duration
+	watchDaemon   bool
+	watchOff      bool
+)
+
+var watchCmd = &cobra.Command{
+	Use:   "watch [file]",
+	Short: "👁️ Save snapshots automatically as a file changes",
+	Long: `Keep running and save a snapshot each time the file changes, once it
+has stayed unchanged for the debounce interval (watch_debounce in
+~/.oops/config, 2s by default). An untracked file is tracked first.
+
+Editors that save by writing a temporary file and renaming it over the
+original are followed through the swap and saved once, with the final
+content. A file that stays gone, e.g. after being renamed, is reported.
+
+Automatic snapshots are marked "auto" in 'oops history'. Press Ctrl+C to
+stop; a change that has not been saved yet is saved before exiting.
+
+With --daemon the file is handed to the daemon ('oops daemon start')
+instead, which keeps watching it in the background. The files it
+watches are the watch entries in ~/.oops/config; it picks up changes to
+them within a minute.
+
+Examples:
+  oops watch notes.md               Watch a file, tracking it if needed
+  oops watch                        Watch the tracked file here
+  oops watch notes.md --debounce 10s  Wait for 10s of quiet before saving
+  oops watch notes.md --daemon      Let the daemon watch it
+  oops watch notes.md --daemon --off  Stop the daemon watching it`,
+	Args:              cobra.MaximumNArgs(1),
+	ValidArgsFunction: completeTrackedFile,
+	RunE:              runWatch,
+}
+
+func runWatch(cmd *cobra.Command, args []string) error {
+	if watchOff && !watchDaemon {
+		fail("--off only applies with --daemon")
+		return nil
+	}
+	if len(args) == 0 || watchOff {
+		var s *store.Store
+		var err error
+		if len(args) == 0 {
+			s, err = findTrackedStore()
+		} else {
+			s, err = findStoreForPath(args[0])
+		}
+		if err != nil {
+			fail("%v", err)
+			return nil
+		}
+		if watchDaemon {
+			return setDaemonWatch(s, !watchOff)
+		}
+		return watchStore(s)
+	}
+
+	if !utils.IsFile(args[0]) {
+		fail("'%s' is not a valid file", args[0])
+		return nil
+	}
+	s, err := store.NewStoreWithOptions(args[0], storeOptions())
+	if err != nil {
+		fail("Error: %v", err)
+		return nil
+	}
+	if !s.Exists() && !startTracking(s, "") {
+		return nil
+	}
+	reportRecovery(s)
+	if watchDaemon {
+		return setDaemonWatch(s, true)
+	}
+	return watchStore(s)
+}
+
+// setDaemonWatch adds s to the files the daemon watches, or removes it
+func setDaemonWatch(s *store.Store, on bool) error {
+	cfg, err := config.Load()
+	if err != nil {
+		fail("Failed to load config: %v", err)
+		return nil
+	}
+
+	var watches []config.Watch
+	found := false
+	for _, w := range cfg.Watches {
+		if w.FilePath == s.FilePath && w.Global == s.Global {
+			found = true
+			continue
+		}
+		watches = append(watches, w)
+	}
+	if on {
+		watches = append(watches, config.Watch{FilePath: s.FilePath, Global: s.Global, Debounce: watchDebounce})
+	} else if !found {
+		info("The daemon does not watch %s", s.FileName)
+		return nil
+	}
+	cfg.Watches = watches
+	if err := cfg.Save(); err != nil {
+		fail("Failed to save config: %v", err)
+		return nil
+	}
+
+	if !on {
+		success("The daemon no longer watches %s", s.FileName)
+		return nil
+	}
+	success("The daemon saves %s as it changes", s.FileName)
+	if socketPath, err := daemon.SocketPath(); err == nil && daemon.Running(socketPath) {
+		info("It starts watching within a minute")
+	} else {
+		info("Use 'oops daemon start' to start the daemon")
+	}
+	return nil
+}
+
+// watchStore saves auto snapshots of s until interrupted
+func watchStore(s *store.Store) error {
+	debounce := watchDebounce
+	if debounce <= 0 {
+		cfg, err := config.Load()
+		if err != nil {
+			cfg = config.DefaultConfig()
+		}
+		debounce = cfg.WatchDebounce
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	info("Watching %s, saving %s after each change (Ctrl+C to stop)", s.FileName, debounce)
+	saved := 0
+	watch.Watch(ctx, s.FilePath, watch.Options{
+		Interval: watch.IntervalFor(s.FilePath),
+		Debounce: debounce,
+		OnChange: func() error {
+			snap, err := watch.Save(s)
+			if snap == nil || err != nil {
+				return err
+			}
+			saved++
+			success("Snapshot #%d saved at %s", snap.Number, time.Now().Format("15:04:05"))
+			if findings, err := s.ScanSecrets(); err == nil && len(findings) > 0 {
+				warn("It may contain credentials; to mask them use: oops config --redact <regex>")
+			}
+			return nil
+		},
+		OnError: func(err error) {
+			if !reportTooLarge(err, false) && !reportInvalid(err) {
+				warn("Auto save failed: %v", err)
+			}
+		},
+		OnMissing: func() {
+			if moved, _ := s.FindMoved(); moved != "" {
+				warn("%s was renamed to %s; its changes are no longer saved", s.FileName, filepath.Base(moved))
+				mode := ""
+				if s.Global {
+					mode = " -g"
+				}
+				info("Stop watching and run 'oops mv%s %s %s' to move its history along", mode, s.FileName, filepath.Base(moved))
+				return
+			}
+			warn("%s is gone; waiting for it to come back", s.FileName)
+		},
+	})
+
+	info("Stopped watching %s; %d %s saved", s.FileName, saved, plural(saved, "snapshot"))
+	return nil
+}
+
+func init() {
+	watchCmd.Flags().DurationVar(&watchDebounce, "debounce", 0, "Quiet time before saving a change (default from config, 2s)")
+	watchCmd.Flags().BoolVar(&watchDaemon, "daemon", false, "Let the daemon watch the file in the background")
+	watchCmd.Flags().BoolVar(&watchOff, "off", false, "With --daemon, stop the daemon watching the file")
+	rootCmd.AddCommand(watchCmd)
+}
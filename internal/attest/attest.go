@@ -0,0 +1,138 @@
+// Package attest produces signed manifests of a store's snapshots, so an
+// auditor can later check that history was not rewritten.
+package attest
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+// FormatVersion is the manifest format written by this package
+const FormatVersion = 1
+
+// KeyFileName is the signing key file in the config directory
+const KeyFileName = "attest.key"
+
+// ErrBadSignature is returned when a manifest's signature does not match
+var ErrBadSignature = errors.New("manifest signature does not match its content")
+
+// Manifest lists every snapshot of a store at one point in time
+type Manifest struct {
+	Version   int       `json:"version"`
+	File      string    `json:"file"`
+	Generated time.Time `json:"generated"`
+	Snapshots []Entry   `json:"snapshots"`
+	PublicKey string    `json:"public_key,omitempty"` // base64 ed25519 key
+	Signature string    `json:"signature,omitempty"`  // base64, over the manifest without it
+}
+
+// Entry identifies one snapshot
+type Entry struct {
+	Number    int       `json:"number"`
+	Commit    string    `json:"commit"`
+	SHA256    string    `json:"sha256"` // hex digest of the stored content
+	Timestamp time.Time `json:"timestamp"`
+	Message   string    `json:"message"`
+}
+
+// signedBytes returns the bytes the signature covers: the manifest
+// without its signature
+func (m *Manifest) signedBytes() ([]byte, error) {
+	unsigned := *m
+	unsigned.Signature = ""
+	return json.Marshal(unsigned)
+}
+
+// Sign sets the manifest's public key and signature
+func (m *Manifest) Sign(key ed25519.PrivateKey) error {
+	m.PublicKey = base64.StdEncoding.EncodeToString(key.Public().(ed25519.PublicKey))
+	data, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	m.Signature = base64.StdEncoding.EncodeToString(ed25519.Sign(key, data))
+	return nil
+}
+
+// VerifySignature checks the signature against the manifest's public key.
+// Callers that trust a particular key should also compare PublicKey.
+func (m *Manifest) VerifySignature() error {
+	pub, err := base64.StdEncoding.DecodeString(m.PublicKey)
+	if err != nil || len(pub) != ed25519.PublicKeySize {
+		return fmt.Errorf("manifest has no valid public key")
+	}
+	sig, err := base64.StdEncoding.DecodeString(m.Signature)
+	if err != nil || len(sig) == 0 {
+		return fmt.Errorf("manifest is not signed")
+	}
+	data, err := m.signedBytes()
+	if err != nil {
+		return err
+	}
+	if !ed25519.Verify(pub, data, sig) {
+		return ErrBadSignature
+	}
+	return nil
+}
+
+// Write saves the manifest as indented JSON
+func (m *Manifest) Write(path string) error {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0644)
+}
+
+// Read loads a manifest written by Write
+func Read(path string) (*Manifest, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	m := &Manifest{}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if m.Version != FormatVersion {
+		return nil, fmt.Errorf("unsupported manifest version %d", m.Version)
+	}
+	return m, nil
+}
+
+// LoadOrCreateKey reads the ed25519 signing key in dir, creating one
+// the first time. The key file is readable only by its owner.
+func LoadOrCreateKey(dir string) (ed25519.PrivateKey, error) {
+	path := filepath.Join(dir, KeyFileName)
+	data, err := os.ReadFile(path)
+	if err == nil {
+		seed, err := base64.StdEncoding.DecodeString(string(data))
+		if err != nil || len(seed) != ed25519.SeedSize {
+			return nil, fmt.Errorf("invalid signing key in %s", path)
+		}
+		return ed25519.NewKeyFromSeed(seed), nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	_, key, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	encoded := base64.StdEncoding.EncodeToString(key.Seed())
+	if err := os.WriteFile(path, []byte(encoded), 0600); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
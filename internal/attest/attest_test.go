@@ -0,0 +1,46 @@
+package attest
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	key, err := LoadOrCreateKey(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, err := LoadOrCreateKey(dir)
+	if err != nil || !again.Equal(key) {
+		t.Fatal("LoadOrCreateKey should reuse the stored key")
+	}
+
+	m := &Manifest{
+		Version:   FormatVersion,
+		File:      "/home/me/notes.md",
+		Generated: time.Date(2026, 10, 16, 9, 0, 0, 0, time.UTC),
+		Snapshots: []Entry{{Number: 1, Commit: "abc", SHA256: "def", Message: "first"}},
+	}
+	if err := m.Sign(key); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(dir, "attest.json")
+	if err := m.Write(path); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Read(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := loaded.VerifySignature(); err != nil {
+		t.Errorf("VerifySignature = %v", err)
+	}
+
+	loaded.Snapshots[0].Message = "edited"
+	if err := loaded.VerifySignature(); err != ErrBadSignature {
+		t.Errorf("VerifySignature after tampering = %v, want ErrBadSignature", err)
+	}
+}
@@ -0,0 +1,253 @@
+// Package backup copies store directories to object storage and back.
+// Uploads are incremental: a manifest of content hashes records what the
+// backend holds, and only files that are new or changed are sent. Git
+// objects never change once written, so after the first backup only new
+// snapshots are uploaded.
+package backup
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// ManifestKey is the key of the manifest listing a backup's files
+const ManifestKey = "manifest.json"
+
+// filesPrefix is the key prefix of backed-up files
+const filesPrefix = "files/"
+
+var ErrNotFound = errors.New("not found")
+
+// Backend stores objects under keys relative to the backup location
+type Backend interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, error) // ErrNotFound if the key does not exist
+	List(prefix string) ([]string, error)
+	Delete(key string) error
+}
+
+// Opener opens a backend for a parsed target URL
+type Opener func(target *url.URL) (Backend, error)
+
+var (
+	mu       sync.Mutex
+	backends = map[string]Opener{}
+)
+
+// Register makes a backend available for targets with the given URL scheme
+func Register(scheme string, open Opener) {
+	mu.Lock()
+	defer mu.Unlock()
+	backends[scheme] = open
+}
+
+func init() {
+	Register("s3", openS3)
+	Register("file", openDir)
+}
+
+// Open returns the backend for target, e.g. "s3://bucket/prefix" or a
+// local directory path
+func Open(target string) (Backend, error) {
+	u, err := url.Parse(target)
+	if err != nil || u.Scheme == "" || len(u.Scheme) == 1 { // "C:\..." is a path
+		return openDir(&url.URL{Scheme: "file", Path: target})
+	}
+	mu.Lock()
+	open, ok := backends[u.Scheme]
+	mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("unsupported backup target %q (supported: %s)", u.Scheme, schemes())
+	}
+	return open(u)
+}
+
+func schemes() string {
+	mu.Lock()
+	defer mu.Unlock()
+	var names []string
+	for scheme := range backends {
+		names = append(names, scheme+"://")
+	}
+	sort.Strings(names)
+	return strings.Join(names, ", ")
+}
+
+// Sub returns a backend whose keys live under prefix in b
+func Sub(b Backend, prefix string) Backend {
+	return &subBackend{b, strings.TrimSuffix(prefix, "/") + "/"}
+}
+
+type subBackend struct {
+	Backend
+	prefix string
+}
+
+func (s *subBackend) Put(key string, data []byte) error { return s.Backend.Put(s.prefix+key, data) }
+func (s *subBackend) Get(key string) ([]byte, error)    { return s.Backend.Get(s.prefix + key) }
+func (s *subBackend) Delete(key string) error           { return s.Backend.Delete(s.prefix + key) }
+
+func (s *subBackend) List(prefix string) ([]string, error) {
+	keys, err := s.Backend.List(s.prefix + prefix)
+	for i, key := range keys {
+		keys[i] = strings.TrimPrefix(key, s.prefix)
+	}
+	return keys, err
+}
+
+// Manifest lists the files of a backup
+type Manifest struct {
+	Version int                  `json:"version"`
+	Source  string               `json:"source,omitempty"` // what was backed up, as named by the caller
+	Files   map[string]FileEntry `json:"files"`            // by slash-separated path
+}
+
+// FileEntry describes one backed-up file
+type FileEntry struct {
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+// LoadManifest reads the backend's manifest; a backend without one has
+// an empty manifest
+func LoadManifest(b Backend) (*Manifest, error) {
+	m := &Manifest{Version: 1, Files: map[string]FileEntry{}}
+	data, err := b.Get(ManifestKey)
+	if err == ErrNotFound {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, m); err != nil {
+		return nil, fmt.Errorf("reading backup manifest: %w", err)
+	}
+	if m.Files == nil {
+		m.Files = map[string]FileEntry{}
+	}
+	return m, nil
+}
+
+// Result summarizes a backup or restore
+type Result struct {
+	Transferred int   // files uploaded or downloaded
+	Unchanged   int   // files the backend already had
+	Removed     int   // backend files no longer in the directory
+	Bytes       int64 // bytes transferred
+}
+
+// Backup uploads the files under dir that the backend lacks or holds an
+// older version of, then the manifest naming source, then removes files
+// dir no longer has. An interrupted backup leaves the previous manifest
+// valid.
+func Backup(b Backend, dir, source string) (*Result, error) {
+	old, err := LoadManifest(b)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{}
+	next := &Manifest{Version: 1, Source: source, Files: map[string]FileEntry{}}
+	err = filepath.Walk(dir, func(p string, fi os.FileInfo, err error) error {
+		if err != nil || !fi.Mode().IsRegular() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		entry := FileEntry{SHA256: hex.EncodeToString(sum[:]), Size: int64(len(data))}
+		next.Files[name] = entry
+
+		if old.Files[name] == entry {
+			result.Unchanged++
+			return nil
+		}
+		if err := b.Put(filesPrefix+name, data); err != nil {
+			return fmt.Errorf("uploading %s: %w", name, err)
+		}
+		result.Transferred++
+		result.Bytes += entry.Size
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.MarshalIndent(next, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := b.Put(ManifestKey, data); err != nil {
+		return nil, fmt.Errorf("uploading manifest: %w", err)
+	}
+
+	for name := range old.Files {
+		if _, ok := next.Files[name]; ok {
+			continue
+		}
+		if err := b.Delete(filesPrefix + name); err != nil && err != ErrNotFound {
+			return nil, fmt.Errorf("removing %s: %w", name, err)
+		}
+		result.Removed++
+	}
+	return result, nil
+}
+
+// Restore downloads the backup into dir, which must not exist yet, and
+// checks every file against the manifest
+func Restore(b Backend, dir string) (*Result, error) {
+	if _, err := os.Stat(dir); err == nil {
+		return nil, fmt.Errorf("%s already exists", dir)
+	}
+	m, err := LoadManifest(b)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Files) == 0 {
+		return nil, ErrNotFound
+	}
+
+	result := &Result{}
+	for name, entry := range m.Files {
+		if !filepath.IsLocal(filepath.FromSlash(name)) || path.Clean(name) != name {
+			return nil, fmt.Errorf("invalid path in backup: %s", name)
+		}
+		data, err := b.Get(filesPrefix + name)
+		if err != nil {
+			return nil, fmt.Errorf("downloading %s: %w", name, err)
+		}
+		sum := sha256.Sum256(data)
+		if hex.EncodeToString(sum[:]) != entry.SHA256 {
+			return nil, fmt.Errorf("%s is corrupt in the backup", name)
+		}
+
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			return nil, err
+		}
+		if err := os.WriteFile(p, data, 0644); err != nil {
+			return nil, err
+		}
+		result.Transferred++
+		result.Bytes += entry.Size
+	}
+	return result, nil
+}
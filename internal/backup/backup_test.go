@@ -0,0 +1,109 @@
+package backup
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// countingBackend records the keys written to a backend
+type countingBackend struct {
+	Backend
+	puts []string
+}
+
+func (c *countingBackend) Put(key string, data []byte) error {
+	c.puts = append(c.puts, key)
+	return c.Backend.Put(key, data)
+}
+
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		os.MkdirAll(filepath.Dir(p), 0755)
+		if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestBackupIncrementalAndRestore(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{
+		"objects/ab/cdef": "object 1",
+		"refs/tags/v1":    "hash1",
+		"HEAD":            "ref: refs/heads/master",
+	})
+
+	dir, err := Open(filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	b := &countingBackend{Backend: Sub(dir, "notes.txt.git")}
+
+	r, err := Backup(b, src, "/home/me/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Transferred != 3 || r.Unchanged != 0 {
+		t.Errorf("first backup = %+v, want 3 files sent", r)
+	}
+
+	// Only new and changed files are sent the second time
+	writeFiles(t, src, map[string]string{
+		"objects/12/3456": "object 2",
+		"refs/tags/v2":    "hash2",
+		"HEAD":            "ref: refs/heads/main",
+	})
+	os.Remove(filepath.Join(src, "refs/tags/v1"))
+	b.puts = nil
+	r, err = Backup(b, src, "/home/me/notes.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Transferred != 3 || r.Unchanged != 1 || r.Removed != 1 {
+		t.Errorf("second backup = %+v, want 3 sent, 1 unchanged, 1 removed", r)
+	}
+	for _, key := range b.puts {
+		if key == "files/objects/ab/cdef" {
+			t.Error("unchanged object uploaded again")
+		}
+	}
+	if _, err := b.Get("files/refs/tags/v1"); err != ErrNotFound {
+		t.Errorf("removed file still in backup: %v", err)
+	}
+	if m, _ := LoadManifest(b); m.Source != "/home/me/notes.txt" {
+		t.Errorf("manifest source = %q", m.Source)
+	}
+
+	dst := filepath.Join(t.TempDir(), "restored")
+	r, err = Restore(b, dst)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Transferred != 4 {
+		t.Errorf("restore = %+v, want 4 files", r)
+	}
+	if data, _ := os.ReadFile(filepath.Join(dst, "HEAD")); string(data) != "ref: refs/heads/main" {
+		t.Errorf("restored HEAD = %q", data)
+	}
+	if _, err := Restore(b, dst); err == nil {
+		t.Error("Restore overwrote an existing directory")
+	}
+
+	// A corrupted object is caught
+	b.Put("files/objects/12/3456", []byte("tampered"))
+	if _, err := Restore(b, filepath.Join(t.TempDir(), "again")); err == nil {
+		t.Error("Restore accepted a corrupt file")
+	}
+}
+
+func TestOpenUnknownScheme(t *testing.T) {
+	if _, err := Open("ftp://host/path"); err == nil {
+		t.Error("Open accepted an unsupported scheme")
+	}
+	if _, err := Open("s3:///prefix"); err == nil {
+		t.Error("Open accepted an s3 target without a bucket")
+	}
+}
@@ -0,0 +1,88 @@
+package backup
+
+import (
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Dir is a backend keeping objects as files under a local directory, such
+// as a mounted drive or a synced folder
+type Dir struct {
+	Root string
+}
+
+func openDir(target *url.URL) (Backend, error) {
+	root := target.Path
+	if target.Host != "" {
+		root = target.Host + root
+	}
+	if root == "" {
+		return nil, fmt.Errorf("backup directory is empty")
+	}
+	abs, err := filepath.Abs(root)
+	if err != nil {
+		return nil, err
+	}
+	return &Dir{Root: abs}, nil
+}
+
+func (d *Dir) path(key string) string {
+	return filepath.Join(d.Root, filepath.FromSlash(key))
+}
+
+func (d *Dir) Put(key string, data []byte) error {
+	p := d.path(key)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return err
+	}
+	// Write beside the target and rename, so a reader never sees half a file
+	tmp := p + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, p)
+}
+
+func (d *Dir) Get(key string) ([]byte, error) {
+	data, err := os.ReadFile(d.path(key))
+	if os.IsNotExist(err) {
+		return nil, ErrNotFound
+	}
+	return data, err
+}
+
+func (d *Dir) List(prefix string) ([]string, error) {
+	var keys []string
+	err := filepath.WalkDir(d.Root, func(p string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return filepath.SkipAll
+			}
+			return err
+		}
+		if entry.IsDir() || strings.HasSuffix(p, ".tmp") {
+			return nil
+		}
+		rel, err := filepath.Rel(d.Root, p)
+		if err != nil {
+			return err
+		}
+		if key := filepath.ToSlash(rel); strings.HasPrefix(key, prefix) {
+			keys = append(keys, key)
+		}
+		return nil
+	})
+	return keys, err
+}
+
+func (d *Dir) Delete(key string) error {
+	err := os.Remove(d.path(key))
+	if os.IsNotExist(err) {
+		return ErrNotFound
+	}
+	return err
+}
@@ -0,0 +1,201 @@
+// Package bench measures snapshot operations on a synthetic file, so
+// regressions can be quantified and users can try oops on their hardware.
+package bench
+
+import (
+	"fmt"
+	"io/fs"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/store"
+)
+
+// Options describes the workload
+type Options struct {
+	Size        int64   // size of the synthetic file in bytes
+	ChangeRatio float64 // share of lines rewritten before each save, 0 to 1
+	Iterations  int     // snapshots to save after the initial one
+	Dir         string  // directory for the workload; a temporary one if empty
+	Seed        int64   // seed for the synthetic content
+}
+
+// Timings summarizes repeated measurements of one operation
+type Timings struct {
+	Count         int
+	Min, Avg, Max time.Duration
+	P95           time.Duration
+}
+
+func summarize(samples []time.Duration) Timings {
+	if len(samples) == 0 {
+		return Timings{}
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	var total time.Duration
+	for _, d := range sorted {
+		total += d
+	}
+	return Timings{
+		Count: len(sorted),
+		Min:   sorted[0],
+		Avg:   total / time.Duration(len(sorted)),
+		Max:   sorted[len(sorted)-1],
+		P95:   sorted[(len(sorted)*95-1)/100],
+	}
+}
+
+// Result holds the measurements of one run
+type Result struct {
+	Options
+	Save, Back, Diff Timings
+	StoreSize        int64 // bytes on disk after all snapshots
+	ContentSize      int64 // bytes of all snapshot contents combined
+}
+
+// Run creates the synthetic file, tracks it, and times Save for every
+// iteration, then Back and Changes across the history. The workload is
+// removed afterwards.
+func Run(opts Options) (*Result, error) {
+	if opts.Size <= 0 || opts.Iterations < 1 {
+		return nil, fmt.Errorf("size and iterations must be positive")
+	}
+	if opts.ChangeRatio < 0 || opts.ChangeRatio > 1 {
+		return nil, fmt.Errorf("change ratio must be between 0 and 1")
+	}
+
+	dir := opts.Dir
+	if dir == "" {
+		tmp, err := os.MkdirTemp("", "oops-bench-")
+		if err != nil {
+			return nil, err
+		}
+		defer os.RemoveAll(tmp)
+		dir = tmp
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed))
+	lines := generateLines(rng, opts.Size)
+	filePath := filepath.Join(dir, "bench.txt")
+	if err := writeLines(filePath, lines); err != nil {
+		return nil, err
+	}
+
+	s, err := store.NewStore(filePath)
+	if err != nil {
+		return nil, err
+	}
+	s.MaxSnapshotSize = 0 // Measure the workload as given
+	if err := s.Initialize(); err != nil {
+		return nil, err
+	}
+	defer s.Delete()
+
+	r := &Result{Options: opts}
+	r.ContentSize = contentSize(lines)
+
+	var saves []time.Duration
+	for i := 0; i < opts.Iterations; i++ {
+		mutate(rng, lines, opts.ChangeRatio)
+		if err := writeLines(filePath, lines); err != nil {
+			return nil, err
+		}
+		start := time.Now()
+		if _, err := s.Save(fmt.Sprintf("iteration %d", i+1)); err != nil && err != store.ErrNoChanges {
+			return nil, err
+		}
+		saves = append(saves, time.Since(start))
+		r.ContentSize += contentSize(lines)
+	}
+	r.Save = summarize(saves)
+	if r.StoreSize, err = dirSize(s.GitDir); err != nil {
+		return nil, err
+	}
+
+	latest, err := s.GetLatestVersion()
+	if err != nil {
+		return nil, err
+	}
+	var backs, diffs []time.Duration
+	for num := latest; num >= 1; num -= max(1, latest/10) {
+		start := time.Now()
+		if _, err := s.Changes(num, latest); err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, time.Since(start))
+
+		start = time.Now()
+		if err := s.Back(num, true); err != nil {
+			return nil, err
+		}
+		backs = append(backs, time.Since(start))
+	}
+	r.Back, r.Diff = summarize(backs), summarize(diffs)
+	return r, nil
+}
+
+// lineLength is the average length of a synthetic line
+const lineLength = 64
+
+const alphabet = "abcdefghijklmnopqrstuvwxyz      ABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789.,"
+
+func generateLines(rng *rand.Rand, size int64) []string {
+	n := max(1, int(size/lineLength))
+	lines := make([]string, n)
+	for i := range lines {
+		lines[i] = randomLine(rng)
+	}
+	return lines
+}
+
+func randomLine(rng *rand.Rand) string {
+	b := make([]byte, lineLength-1+rng.Intn(9)-4)
+	for i := range b {
+		b[i] = alphabet[rng.Intn(len(alphabet))]
+	}
+	return string(b)
+}
+
+// mutate rewrites a share of the lines, always at least one
+func mutate(rng *rand.Rand, lines []string, ratio float64) {
+	n := max(1, int(float64(len(lines))*ratio))
+	for i := 0; i < n; i++ {
+		lines[rng.Intn(len(lines))] = randomLine(rng)
+	}
+}
+
+func writeLines(path string, lines []string) error {
+	return os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0644)
+}
+
+func contentSize(lines []string) int64 {
+	var n int64
+	for _, l := range lines {
+		n += int64(len(l)) + 1
+	}
+	return n
+}
+
+func dirSize(dir string) (int64, error) {
+	var total int64
+	err := filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			info, err := d.Info()
+			if err != nil {
+				return err
+			}
+			total += info.Size()
+		}
+		return nil
+	})
+	return total, err
+}
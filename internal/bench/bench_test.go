@@ -0,0 +1,33 @@
+package bench
+
+import "testing"
+
+func TestRun(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+
+	r, err := Run(Options{Size: 8 << 10, ChangeRatio: 0.1, Iterations: 5, Dir: t.TempDir()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Save.Count != 5 || r.Back.Count == 0 || r.Diff.Count == 0 {
+		t.Errorf("counts: save %d, back %d, diff %d", r.Save.Count, r.Back.Count, r.Diff.Count)
+	}
+	if r.Save.Min > r.Save.Avg || r.Save.Avg > r.Save.Max {
+		t.Errorf("save timings out of order: %+v", r.Save)
+	}
+	if r.StoreSize <= 0 || r.ContentSize < 6*(8<<10)*9/10 {
+		t.Errorf("sizes: store %d, content %d", r.StoreSize, r.ContentSize)
+	}
+}
+
+func TestRunRejectsBadOptions(t *testing.T) {
+	for _, opts := range []Options{
+		{Size: 0, Iterations: 1},
+		{Size: 100, Iterations: 0},
+		{Size: 100, Iterations: 1, ChangeRatio: 2},
+	} {
+		if _, err := Run(opts); err == nil {
+			t.Errorf("Run(%+v) should fail", opts)
+		}
+	}
+}
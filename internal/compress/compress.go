@@ -154,3 +154,46 @@ func SmartDecompress(data []byte) []byte {
 
 	return decompressed
 }
+
+// packedMagic marks content stored with Pack. A plain gzip header is not
+// enough, since tracked files may themselves be gzip data.
+var packedMagic = []byte("oops-packed\x00")
+
+// Pack compresses data as small as gzip allows and marks it so Unpack can
+// recognize it
+func Pack(data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := PackTo(&buf, bytes.NewReader(data)); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// PackTo writes what Pack returns for the data read from src to dst,
+// without holding it in memory
+func PackTo(dst io.Writer, src io.Reader) error {
+	if _, err := dst.Write(packedMagic); err != nil {
+		return err
+	}
+	w, err := gzip.NewWriterLevel(dst, gzip.BestCompression)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(w, src); err != nil {
+		return err
+	}
+	return w.Close()
+}
+
+// IsPacked checks if data was produced by Pack
+func IsPacked(data []byte) bool {
+	return bytes.HasPrefix(data, packedMagic)
+}
+
+// Unpack reverses Pack, returning data unchanged if it is not packed
+func Unpack(data []byte) ([]byte, error) {
+	if !IsPacked(data) {
+		return data, nil
+	}
+	return Decompress(data[len(packedMagic):])
+}
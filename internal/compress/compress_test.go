@@ -2,6 +2,7 @@ package compress
 
 import (
 	"bytes"
+	"strings"
 	"testing"
 )
 
@@ -162,3 +163,35 @@ func TestCompressIncompressibleData(t *testing.T) {
 		}
 	}
 }
+
+func TestPackUnpack(t *testing.T) {
+	original := []byte(strings.Repeat("generated output line\n", 500))
+
+	packed, err := Pack(original)
+	if err != nil {
+		t.Fatalf("Pack failed: %v", err)
+	}
+	if !IsPacked(packed) {
+		t.Error("Pack output should be recognized as packed")
+	}
+	if len(packed) >= len(original) {
+		t.Errorf("packed size %d not smaller than %d", len(packed), len(original))
+	}
+
+	unpacked, err := Unpack(packed)
+	if err != nil {
+		t.Fatalf("Unpack failed: %v", err)
+	}
+	if !bytes.Equal(unpacked, original) {
+		t.Error("Unpack did not restore the original data")
+	}
+
+	// Plain gzip data is left alone
+	gz, _ := Compress(original)
+	if IsPacked(gz) {
+		t.Error("plain gzip data should not be treated as packed")
+	}
+	if same, _ := Unpack(gz); !bytes.Equal(same, gz) {
+		t.Error("Unpack should return unpacked data unchanged")
+	}
+}
@@ -2,9 +2,15 @@ package config
 
 import (
 	"bufio"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/eol"
 )
 
 const (
@@ -13,13 +19,207 @@ const (
 
 // Config represents oops configuration
 type Config struct {
-	DefaultGlobal bool // Use global storage by default
+	DefaultGlobal     bool                         // Use global storage by default
+	Schedules         []Schedule                   // Cron-style snapshot schedules
+	ScheduleAll       string                       // Cron expression for tracked files without their own schedule
+	Compact           bool                         // Thin old history during gc
+	CompactKeepAll    time.Duration                // Keep every snapshot younger than this
+	CompactKeepHourly time.Duration                // Keep hourly snapshots up to this age, daily after
+	CompactKeepDaily  time.Duration                // Keep daily snapshots up to this age, weekly after; 0 for daily forever
+	PruneKeepLast     int                          // Keep at most this many snapshots per file, 0 for no limit
+	PruneOlderThan    time.Duration                // Remove snapshots older than this, 0 for no limit
+	AutoPrune         bool                         // Apply retention after every automatic snapshot
+	EventsFile        string                       // Append JSON event lines to this file, if set
+	MaxSnapshotSize   int64                        // Largest snapshot to store in bytes, 0 for no limit
+	MaxDiffSize       int64                        // Larger files get a summary instead of a line diff, 0 for no limit
+	AsyncSaveSize     int64                        // Commit saves of files this large in the background, 0 to never
+	Redact            []string                     // Regular expressions masked before content is stored
+	EOL               string                       // Line ending mode: off, lf or native (see package eol)
+	OnDirty           map[string]DirtyPolicy       // Per command, from <command>.on_dirty keys
+	WatchDebounce     time.Duration                // Quiet time before oops watch saves a change
+	Watches           []Watch                      // Files the daemon saves as they change
+	GlobalLayout      string                       // How new global stores are keyed: LayoutPath or LayoutContent
+	UsageStats        bool                         // Count commands and snapshots in ~/.oops/usage.json (never sent anywhere)
+	RestoreMtime      bool                         // Restores set the modification time the file had when saved
+	RestoreOwner      bool                         // Restores set the owner the file had when saved
+	StaleAfter        time.Duration                // Warn about unsaved changes once the last snapshot is this old, 0 to never
+	Validate          map[string]string            // Validate hook commands by the path of the file they check
+	TokenCmds         map[string]map[string]string // Remote token commands by file path, then remote name
+}
+
+// Global store layouts
+const (
+	LayoutPath    = "path"    // keyed by a hash of the file's path
+	LayoutContent = "content" // keyed by the history, found through a path index
+)
+
+// ValidateLayout checks a global_layout value
+func ValidateLayout(layout string) error {
+	switch layout {
+	case LayoutPath, LayoutContent:
+		return nil
+	}
+	return fmt.Errorf("invalid global layout %q (use path or content)", layout)
+}
+
+// DirtyPolicy is what a command does when it would overwrite unsaved changes
+type DirtyPolicy string
+
+// Dirty policies
+const (
+	DirtyBlock   DirtyPolicy = "block"   // refuse, asking the user to save first
+	DirtyBackup  DirtyPolicy = "backup"  // save the changes as a snapshot, then continue
+	DirtyDiscard DirtyPolicy = "discard" // overwrite the changes
+)
+
+// DirtyCommands lists the commands with an on_dirty key and their defaults
+var DirtyCommands = map[string]DirtyPolicy{
+	"back": DirtyBlock,
+	"oops": DirtyDiscard,
+}
+
+// ParseDirtyPolicy parses an on_dirty value
+func ParseDirtyPolicy(value string) (DirtyPolicy, error) {
+	switch p := DirtyPolicy(strings.ToLower(value)); p {
+	case DirtyBlock, DirtyBackup, DirtyDiscard:
+		return p, nil
+	}
+	return "", fmt.Errorf("invalid on_dirty value %q (use block, backup or discard)", value)
+}
+
+// OnDirtyPolicy returns the configured policy for command, or its default
+func (c *Config) OnDirtyPolicy(command string) DirtyPolicy {
+	if p, ok := c.OnDirty[command]; ok {
+		return p
+	}
+	return DirtyCommands[command]
+}
+
+// DefaultMaxSnapshotSize is the snapshot size cap used when none is
+// configured: none, so files of any size are tracked as they always were
+const DefaultMaxSnapshotSize = 0
+
+// DefaultMaxDiffSize is the size above which diffs are summarized when
+// none is configured
+const DefaultMaxDiffSize = 16 << 20
+
+// ValidateHook returns the validate hook command for the file at path,
+// or "" if none is set
+func (c *Config) ValidateHook(path string) string {
+	return c.Validate[path]
+}
+
+// SetValidateHook sets the validate hook command for the file at path;
+// "" removes it
+func (c *Config) SetValidateHook(path, command string) {
+	if command == "" {
+		delete(c.Validate, path)
+		return
+	}
+	if c.Validate == nil {
+		c.Validate = map[string]string{}
+	}
+	c.Validate[path] = command
+}
+
+// Watch is a file the daemon saves automatically as it changes
+type Watch struct {
+	FilePath string        // absolute path of the tracked file
+	Global   bool          // file is tracked in global storage
+	Debounce time.Duration // quiet time before a change is saved, 0 for WatchDebounce
+}
+
+// String formats the watch as stored in the config file
+func (w Watch) String() string {
+	mode := "local"
+	if w.Global {
+		mode = "global"
+	}
+	debounce := ""
+	if w.Debounce > 0 {
+		debounce = FormatDuration(w.Debounce)
+	}
+	return strings.Join([]string{w.FilePath, mode, debounce}, " | ")
+}
+
+// parseWatch parses a watch config value written by Watch.String
+func parseWatch(value string) (Watch, bool) {
+	parts := strings.SplitN(value, "|", 3)
+	w := Watch{FilePath: strings.TrimSpace(parts[0])}
+	if len(parts) > 1 {
+		w.Global = strings.TrimSpace(parts[1]) == "global"
+	}
+	if len(parts) > 2 && strings.TrimSpace(parts[2]) != "" {
+		d, err := ParseDuration(strings.TrimSpace(parts[2]))
+		if err != nil || d < 0 {
+			return Watch{}, false
+		}
+		w.Debounce = d
+	}
+	return w, w.FilePath != ""
+}
+
+// SetTokenCmds sets the commands printing the tokens of the remotes of the
+// file at path, by remote name; an empty map removes them
+func (c *Config) SetTokenCmds(path string, cmds map[string]string) {
+	if len(cmds) == 0 {
+		delete(c.TokenCmds, path)
+		return
+	}
+	if c.TokenCmds == nil {
+		c.TokenCmds = map[string]map[string]string{}
+	}
+	c.TokenCmds[path] = cmds
+}
+
+// Schedule is a cron-style snapshot schedule for one file
+type Schedule struct {
+	Cron     string // five-field cron expression
+	FilePath string // absolute path of the tracked file
+	Global   bool   // file is tracked in global storage
+	Message  string // snapshot message, empty for the default
+}
+
+// String formats the schedule as stored in the config file
+func (s Schedule) String() string {
+	mode := "local"
+	if s.Global {
+		mode = "global"
+	}
+	return strings.Join([]string{s.Cron, s.FilePath, mode, s.Message}, " | ")
+}
+
+// parseSchedule parses a schedule config value written by Schedule.String
+func parseSchedule(value string) (Schedule, bool) {
+	parts := strings.SplitN(value, "|", 4)
+	if len(parts) < 3 {
+		return Schedule{}, false
+	}
+	sched := Schedule{
+		Cron:     strings.TrimSpace(parts[0]),
+		FilePath: strings.TrimSpace(parts[1]),
+		Global:   strings.TrimSpace(parts[2]) == "global",
+	}
+	if len(parts) == 4 {
+		sched.Message = strings.TrimSpace(parts[3])
+	}
+	if sched.Cron == "" || sched.FilePath == "" {
+		return Schedule{}, false
+	}
+	return sched, true
 }
 
 // DefaultConfig returns default configuration
 func DefaultConfig() *Config {
 	return &Config{
-		DefaultGlobal: false,
+		DefaultGlobal:     false,
+		CompactKeepAll:    24 * time.Hour,
+		CompactKeepHourly: 7 * 24 * time.Hour,
+		MaxSnapshotSize:   DefaultMaxSnapshotSize,
+		MaxDiffSize:       DefaultMaxDiffSize,
+		WatchDebounce:     2 * time.Second,
+		GlobalLayout:      LayoutPath,
+		StaleAfter:        24 * time.Hour,
 	}
 }
 
@@ -77,7 +277,113 @@ func Load() (*Config, error) {
 
 		switch key {
 		case "default_global":
-			cfg.DefaultGlobal = value == "true" || value == "1" || value == "yes"
+			cfg.DefaultGlobal = parseBool(value)
+		case "compact":
+			cfg.Compact = parseBool(value)
+		case "compact_keep_all":
+			if d, err := ParseDuration(value); err == nil {
+				cfg.CompactKeepAll = d
+			}
+		case "compact_keep_hourly":
+			if d, err := ParseDuration(value); err == nil {
+				cfg.CompactKeepHourly = d
+			}
+		case "compact_keep_daily":
+			if d, err := ParseDuration(value); err == nil {
+				cfg.CompactKeepDaily = d
+			}
+		case "prune_keep_last":
+			if n, err := strconv.Atoi(value); err == nil && n >= 0 {
+				cfg.PruneKeepLast = n
+			}
+		case "prune_older_than":
+			if d, err := ParseDuration(value); err == nil {
+				cfg.PruneOlderThan = d
+			}
+		case "auto_prune":
+			cfg.AutoPrune = parseBool(value)
+		case "max_snapshot_size", "store.max_snapshot_size":
+			if n, err := ParseSize(value); err == nil {
+				cfg.MaxSnapshotSize = n
+			}
+		case "max_diff_size":
+			if n, err := ParseSize(value); err == nil {
+				cfg.MaxDiffSize = n
+			}
+		case "watch_debounce":
+			if d, err := ParseDuration(value); err == nil && d > 0 {
+				cfg.WatchDebounce = d
+			}
+		case "async_save_size":
+			if n, err := ParseSize(value); err == nil {
+				cfg.AsyncSaveSize = n
+			}
+		case "events_file":
+			cfg.EventsFile = value
+		case "eol":
+			if eol.Validate(value) == nil {
+				cfg.EOL = value
+			}
+		case "global_layout":
+			if ValidateLayout(value) == nil {
+				cfg.GlobalLayout = value
+			}
+		case "usage_stats":
+			cfg.UsageStats = parseBool(value)
+		case "restore_mtime":
+			cfg.RestoreMtime = parseBool(value)
+		case "restore_owner":
+			cfg.RestoreOwner = parseBool(value)
+		case "stale_after":
+			if d, err := ParseDuration(value); err == nil {
+				cfg.StaleAfter = d
+			}
+		default:
+			command, ok := strings.CutSuffix(key, ".on_dirty")
+			if _, known := DirtyCommands[command]; !ok || !known {
+				continue
+			}
+			if p, err := ParseDirtyPolicy(value); err == nil {
+				if cfg.OnDirty == nil {
+					cfg.OnDirty = map[string]DirtyPolicy{}
+				}
+				cfg.OnDirty[command] = p
+			}
+		case "redact":
+			if value != "" {
+				cfg.Redact = append(cfg.Redact, value)
+			}
+		case "schedule_all":
+			cfg.ScheduleAll = value
+		case "schedule":
+			if sched, ok := parseSchedule(value); ok {
+				cfg.Schedules = append(cfg.Schedules, sched)
+			}
+		case "watch":
+			if w, ok := parseWatch(value); ok {
+				cfg.Watches = append(cfg.Watches, w)
+			}
+		case "token_cmd":
+			parts := strings.SplitN(value, "|", 3)
+			if len(parts) != 3 {
+				continue
+			}
+			path, remote, command := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), strings.TrimSpace(parts[2])
+			if path == "" || remote == "" || command == "" {
+				continue
+			}
+			cmds := cfg.TokenCmds[path]
+			if cmds == nil {
+				cmds = map[string]string{}
+			}
+			cmds[remote] = command
+			cfg.SetTokenCmds(path, cmds)
+		case "validate":
+			path, command, ok := strings.Cut(value, "|")
+			path, command = strings.TrimSpace(path), strings.TrimSpace(command)
+			if ok && path != "" && command != "" {
+				cfg.SetValidateHook(path, command)
+			}
 		}
 	}
 
@@ -100,14 +406,162 @@ func (c *Config) Save() error {
 	var lines []string
 	lines = append(lines, "# Oops configuration file")
 	lines = append(lines, "# default_global: Use global storage by default (true/false)")
+	lines = append(lines, "# compact: Thin old history during gc (true/false)")
+	lines = append(lines, "# compact_keep_all / compact_keep_hourly / compact_keep_daily: Ages such as 24h or 7d (keep_daily 0: never weekly)")
+	lines = append(lines, "# prune_keep_last: Most snapshots to keep per file (0 for no limit)")
+	lines = append(lines, "# prune_older_than: Remove snapshots older than this, such as 90d (0 for no limit)")
+	lines = append(lines, "# auto_prune: Apply compaction and prune limits after every watch or scheduled snapshot (true/false)")
+	lines = append(lines, "# max_snapshot_size: Largest snapshot to store, such as 100MB (0 for no limit)")
+	lines = append(lines, "# max_diff_size: Larger files are summarized instead of diffed line by line, such as 16MB (0 for no limit)")
+	lines = append(lines, "# async_save_size: Commit saves of files this large in the background (0 to never)")
+	lines = append(lines, "# watch_debounce: How long a file must stay unchanged before oops watch saves it, such as 2s")
+	lines = append(lines, "# events_file: Append JSON event lines to this file (empty to disable)")
+	lines = append(lines, "# eol: Line endings: off (unchanged), lf (store LF), native (store LF, restore platform endings)")
+	lines = append(lines, "# global_layout: Key new global stores by path (default) or by content, so moved files find their history")
+	lines = append(lines, "# usage_stats: Count commands and snapshots locally in usage.json; nothing is sent anywhere (true/false)")
+	lines = append(lines, "# restore_mtime / restore_owner: Restores also set the modification time / owner the file had when saved (true/false)")
+	lines = append(lines, "# stale_after: Warn when a file has unsaved changes and was last saved longer ago than this, such as 24h (0 to never)")
+	lines = append(lines, "# <command>.on_dirty: back/oops with unsaved changes: block, backup or discard")
+	lines = append(lines, "# redact: Regular expression masked in snapshots; groups mask only their text (repeatable)")
+	lines = append(lines, "# schedule_all: cron for every tracked file without its own schedule (empty to disable)")
+	lines = append(lines, "# schedule: cron | file | local|global | message (repeatable); cron \"off\" skips the file")
+	lines = append(lines, "# watch: file | local|global | debounce (repeatable); saved by the daemon as it changes, debounce defaults to watch_debounce")
+	lines = append(lines, "# validate: file | command every snapshot of the file must pass (repeatable; see 'oops validate')")
+	lines = append(lines, "# token_cmd: file | remote | command printing the remote's token (repeatable; see 'oops remote add')")
 	lines = append(lines, "")
 
-	if c.DefaultGlobal {
-		lines = append(lines, "default_global=true")
-	} else {
-		lines = append(lines, "default_global=false")
+	lines = append(lines, "default_global="+strconv.FormatBool(c.DefaultGlobal))
+	lines = append(lines, "compact="+strconv.FormatBool(c.Compact))
+	lines = append(lines, "compact_keep_all="+FormatDuration(c.CompactKeepAll))
+	lines = append(lines, "compact_keep_hourly="+FormatDuration(c.CompactKeepHourly))
+	lines = append(lines, "compact_keep_daily="+FormatDuration(c.CompactKeepDaily))
+	lines = append(lines, "prune_keep_last="+strconv.Itoa(c.PruneKeepLast))
+	lines = append(lines, "prune_older_than="+FormatDuration(c.PruneOlderThan))
+	lines = append(lines, "auto_prune="+strconv.FormatBool(c.AutoPrune))
+	lines = append(lines, "max_snapshot_size="+FormatSize(c.MaxSnapshotSize))
+	lines = append(lines, "max_diff_size="+FormatSize(c.MaxDiffSize))
+	lines = append(lines, "async_save_size="+FormatSize(c.AsyncSaveSize))
+	lines = append(lines, "watch_debounce="+FormatDuration(c.WatchDebounce))
+	lines = append(lines, "events_file="+c.EventsFile)
+	lines = append(lines, "eol="+c.EOL)
+	lines = append(lines, "global_layout="+c.GlobalLayout)
+	lines = append(lines, "usage_stats="+strconv.FormatBool(c.UsageStats))
+	lines = append(lines, "restore_mtime="+strconv.FormatBool(c.RestoreMtime))
+	lines = append(lines, "restore_owner="+strconv.FormatBool(c.RestoreOwner))
+	lines = append(lines, "stale_after="+FormatDuration(c.StaleAfter))
+
+	var dirtyCommands []string
+	for command := range c.OnDirty {
+		dirtyCommands = append(dirtyCommands, command)
+	}
+	sort.Strings(dirtyCommands)
+	for _, command := range dirtyCommands {
+		lines = append(lines, command+".on_dirty="+string(c.OnDirty[command]))
+	}
+
+	for _, pattern := range c.Redact {
+		lines = append(lines, "redact="+pattern)
+	}
+
+	lines = append(lines, "schedule_all="+c.ScheduleAll)
+	for _, sched := range c.Schedules {
+		lines = append(lines, "schedule="+sched.String())
+	}
+
+	for _, w := range c.Watches {
+		lines = append(lines, "watch="+w.String())
+	}
+
+	var validated []string
+	for path := range c.Validate {
+		validated = append(validated, path)
+	}
+	sort.Strings(validated)
+	for _, path := range validated {
+		lines = append(lines, "validate="+path+" | "+c.Validate[path])
+	}
+
+	var withTokens []string
+	for path := range c.TokenCmds {
+		withTokens = append(withTokens, path)
+	}
+	sort.Strings(withTokens)
+	for _, path := range withTokens {
+		var remotes []string
+		for remote := range c.TokenCmds[path] {
+			remotes = append(remotes, remote)
+		}
+		sort.Strings(remotes)
+		for _, remote := range remotes {
+			lines = append(lines, "token_cmd="+path+" | "+remote+" | "+c.TokenCmds[path][remote])
+		}
 	}
 
 	content := strings.Join(lines, "\n") + "\n"
 	return os.WriteFile(configPath, []byte(content), 0644)
 }
+
+// parseBool parses a boolean config value
+func parseBool(value string) bool {
+	return value == "true" || value == "1" || value == "yes"
+}
+
+// ParseDuration parses a duration, additionally accepting whole days ("7d")
+func ParseDuration(value string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", value)
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(value)
+}
+
+// FormatDuration formats a duration, using days when it is a whole number of them
+func FormatDuration(d time.Duration) string {
+	if d > 0 && d%(24*time.Hour) == 0 {
+		return fmt.Sprintf("%dd", d/(24*time.Hour))
+	}
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return d.String()
+}
+
+// sizeUnits maps size suffixes to byte multipliers, longest suffix first
+var sizeUnits = []struct {
+	suffix string
+	bytes  int64
+}{
+	{"GB", 1 << 30}, {"MB", 1 << 20}, {"KB", 1 << 10},
+	{"G", 1 << 30}, {"M", 1 << 20}, {"K", 1 << 10}, {"B", 1},
+}
+
+// ParseSize parses a byte size such as "512KB", "100MB" or "2G"
+func ParseSize(value string) (int64, error) {
+	v := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := int64(1)
+	for _, unit := range sizeUnits {
+		if num, ok := strings.CutSuffix(v, unit.suffix); ok {
+			v = strings.TrimSpace(num)
+			multiplier = unit.bytes
+			break
+		}
+	}
+	n, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid size %q", value)
+	}
+	return n * multiplier, nil
+}
+
+// FormatSize formats a byte size using the largest unit that divides it
+func FormatSize(n int64) string {
+	for _, unit := range sizeUnits[:3] {
+		if n > 0 && n%unit.bytes == 0 {
+			return fmt.Sprintf("%d%s", n/unit.bytes, unit.suffix)
+		}
+	}
+	return strconv.FormatInt(n, 10)
+}
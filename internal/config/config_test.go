@@ -0,0 +1,135 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{"24h", 24 * time.Hour},
+		{"7d", 7 * 24 * time.Hour},
+		{"90m", 90 * time.Minute},
+	}
+	for _, tt := range tests {
+		got, err := ParseDuration(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseDuration(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+	if _, err := ParseDuration("xd"); err == nil {
+		t.Error("ParseDuration(\"xd\") should fail")
+	}
+}
+
+func TestParseSize(t *testing.T) {
+	tests := []struct {
+		input string
+		want  int64
+	}{
+		{"0", 0},
+		{"512", 512},
+		{"64KB", 64 << 10},
+		{"100MB", 100 << 20},
+		{"2g", 2 << 30},
+	}
+	for _, tt := range tests {
+		got, err := ParseSize(tt.input)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSize(%q) = %d, %v; want %d", tt.input, got, err, tt.want)
+		}
+		if back, _ := ParseSize(FormatSize(tt.want)); back != tt.want {
+			t.Errorf("FormatSize(%d) = %q does not round-trip", tt.want, FormatSize(tt.want))
+		}
+	}
+	if _, err := ParseSize("MB"); err == nil {
+		t.Error("ParseSize(\"MB\") should fail")
+	}
+}
+
+func TestSaveAndLoad(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cfg := DefaultConfig()
+	cfg.DefaultGlobal = true
+	cfg.Compact = true
+	cfg.CompactKeepHourly = 14 * 24 * time.Hour
+	cfg.Redact = []string{`AKIA[0-9A-Z]{16}`, `(?i)password\s*=\s*(\S+)`}
+	cfg.Schedules = []Schedule{
+		{Cron: "0 18 * * *", FilePath: "/home/me/journal.md", Global: true, Message: "Evening | wrap-up"},
+		{Cron: "@hourly", FilePath: "/home/me/notes.md"},
+	}
+	cfg.Watches = []Watch{
+		{FilePath: "/home/me/notes.md"},
+		{FilePath: "/home/me/journal.md", Global: true, Debounce: 10 * time.Second},
+	}
+	cfg.SetValidateHook("/etc/nginx/nginx.conf", "nginx -t -c {}")
+	cfg.SetTokenCmds("/home/me/notes.md", map[string]string{"origin": "pass show gh", "work": "secret-tool lookup oops work"})
+	if err := cfg.Save(); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	loaded, err := Load()
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if !loaded.DefaultGlobal || !loaded.Compact {
+		t.Errorf("loaded = %+v, want DefaultGlobal and Compact", loaded)
+	}
+	if loaded.CompactKeepHourly != cfg.CompactKeepHourly || loaded.CompactKeepAll != cfg.CompactKeepAll {
+		t.Errorf("compact ages = %v/%v, want %v/%v", loaded.CompactKeepAll, loaded.CompactKeepHourly, cfg.CompactKeepAll, cfg.CompactKeepHourly)
+	}
+	if len(loaded.Redact) != 2 || loaded.Redact[1] != cfg.Redact[1] {
+		t.Errorf("redact = %q, want %q", loaded.Redact, cfg.Redact)
+	}
+	if len(loaded.Schedules) != 2 {
+		t.Fatalf("loaded %d schedules, want 2", len(loaded.Schedules))
+	}
+	if loaded.Schedules[0] != cfg.Schedules[0] || loaded.Schedules[1] != cfg.Schedules[1] {
+		t.Errorf("schedules = %+v, want %+v", loaded.Schedules, cfg.Schedules)
+	}
+	if len(loaded.Watches) != 2 || loaded.Watches[0] != cfg.Watches[0] || loaded.Watches[1] != cfg.Watches[1] {
+		t.Errorf("watches = %+v, want %+v", loaded.Watches, cfg.Watches)
+	}
+	if got := loaded.ValidateHook("/etc/nginx/nginx.conf"); got != "nginx -t -c {}" {
+		t.Errorf("validate hook = %q, want %q", got, "nginx -t -c {}")
+	}
+	if got := loaded.TokenCmds["/home/me/notes.md"]; len(got) != 2 || got["work"] != "secret-tool lookup oops work" {
+		t.Errorf("token commands = %v, want %v", got, cfg.TokenCmds["/home/me/notes.md"])
+	}
+}
+
+func TestOnDirty(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cfg := DefaultConfig()
+	if got := cfg.OnDirtyPolicy("back"); got != DirtyBlock {
+		t.Errorf("default back policy = %s, want block", got)
+	}
+	if got := cfg.OnDirtyPolicy("oops"); got != DirtyDiscard {
+		t.Errorf("default oops policy = %s, want discard", got)
+	}
+
+	cfg.OnDirty = map[string]DirtyPolicy{"back": DirtyBackup}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+	loaded, err := Load()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := loaded.OnDirtyPolicy("back"); got != DirtyBackup {
+		t.Errorf("loaded back policy = %s, want backup", got)
+	}
+
+	if _, err := ParseDirtyPolicy("sometimes"); err == nil {
+		t.Error("ParseDirtyPolicy should reject unknown values")
+	}
+}
@@ -0,0 +1,227 @@
+// Package csvdiff compares two versions of a CSV file by row and cell,
+// matching rows by a key column rather than by line position.
+package csvdiff
+
+import (
+	"encoding/csv"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/iyulab/oops/internal/textenc"
+)
+
+// Result lists how the rows and columns of a CSV file changed
+type Result struct {
+	Key            string   // name of the column rows are matched by
+	AddedColumns   []string // columns only in the new version
+	RemovedColumns []string // columns only in the old version
+	Added          []Row    // rows only in the new version
+	Removed        []Row    // rows only in the old version
+	Changed        []RowChange
+}
+
+// Row is a data row, identified by its key value
+type Row struct {
+	Key    string
+	Values []string
+}
+
+// RowChange lists the cells that changed in a row present in both versions
+type RowChange struct {
+	Key   string
+	Cells []CellChange
+}
+
+// CellChange is one changed cell
+type CellChange struct {
+	Column   string
+	Old, New string
+}
+
+// Empty reports whether the versions have the same rows and columns
+func (r *Result) Empty() bool {
+	return len(r.AddedColumns) == 0 && len(r.RemovedColumns) == 0 &&
+		len(r.Added) == 0 && len(r.Removed) == 0 && len(r.Changed) == 0
+}
+
+// String formats the result for the terminal
+func (r *Result) String() string {
+	var b strings.Builder
+	for _, col := range r.AddedColumns {
+		fmt.Fprintf(&b, "+ column %s\n", col)
+	}
+	for _, col := range r.RemovedColumns {
+		fmt.Fprintf(&b, "- column %s\n", col)
+	}
+	for _, row := range r.Removed {
+		fmt.Fprintf(&b, "- %s=%s: %s\n", r.Key, row.Key, strings.Join(row.Values, ", "))
+	}
+	for _, row := range r.Added {
+		fmt.Fprintf(&b, "+ %s=%s: %s\n", r.Key, row.Key, strings.Join(row.Values, ", "))
+	}
+	for _, row := range r.Changed {
+		cells := make([]string, len(row.Cells))
+		for i, c := range row.Cells {
+			cells[i] = fmt.Sprintf("%s: %q → %q", c.Column, c.Old, c.New)
+		}
+		fmt.Fprintf(&b, "~ %s=%s: %s\n", r.Key, row.Key, strings.Join(cells, "; "))
+	}
+	fmt.Fprintf(&b, "%d added, %d removed, %d changed", len(r.Added), len(r.Removed), len(r.Changed))
+	return b.String()
+}
+
+// table is a parsed CSV file
+type table struct {
+	header []string
+	rows   [][]string
+}
+
+// Diff compares two versions of a CSV file. The first line of each is the
+// header. key names the column rows are matched by, either a header name
+// or a 1-based column number; empty means the first column.
+func Diff(oldData, newData []byte, key string) (*Result, error) {
+	oldTable, err := parse(oldData)
+	if err != nil {
+		return nil, fmt.Errorf("old version: %w", err)
+	}
+	newTable, err := parse(newData)
+	if err != nil {
+		return nil, fmt.Errorf("new version: %w", err)
+	}
+
+	keyName, err := keyColumn(newTable.header, key)
+	if err != nil {
+		return nil, err
+	}
+	oldKey := slices.Index(oldTable.header, keyName)
+	if oldKey < 0 {
+		return nil, fmt.Errorf("key column %q is not in the old version", keyName)
+	}
+	newKey := slices.Index(newTable.header, keyName)
+
+	r := &Result{Key: keyName}
+	for _, col := range newTable.header {
+		if !slices.Contains(oldTable.header, col) {
+			r.AddedColumns = append(r.AddedColumns, col)
+		}
+	}
+	for _, col := range oldTable.header {
+		if !slices.Contains(newTable.header, col) {
+			r.RemovedColumns = append(r.RemovedColumns, col)
+		}
+	}
+
+	oldRows, oldOrder := index(oldTable.rows, oldKey)
+	newRows, newOrder := index(newTable.rows, newKey)
+
+	for _, k := range oldOrder {
+		if _, ok := newRows[k]; !ok {
+			r.Removed = append(r.Removed, Row{Key: k, Values: oldRows[k]})
+		}
+	}
+	for _, k := range newOrder {
+		oldRow, ok := oldRows[k]
+		if !ok {
+			r.Added = append(r.Added, Row{Key: k, Values: newRows[k]})
+			continue
+		}
+		if cells := changedCells(oldTable.header, oldRow, newTable.header, newRows[k]); len(cells) > 0 {
+			r.Changed = append(r.Changed, RowChange{Key: k, Cells: cells})
+		}
+	}
+	return r, nil
+}
+
+// changedCells compares the cells of columns present in both versions
+func changedCells(oldHeader, oldRow, newHeader, newRow []string) []CellChange {
+	var cells []CellChange
+	for i, col := range newHeader {
+		j := slices.Index(oldHeader, col)
+		if j < 0 {
+			continue // Added column, reported once for the whole file
+		}
+		if oldVal, newVal := cell(oldRow, j), cell(newRow, i); oldVal != newVal {
+			cells = append(cells, CellChange{Column: col, Old: oldVal, New: newVal})
+		}
+	}
+	return cells
+}
+
+func cell(row []string, i int) string {
+	if i < len(row) {
+		return row[i]
+	}
+	return ""
+}
+
+// index maps each row by its key value, in file order. Repeated keys get
+// a "#2", "#3", ... suffix so every row can be matched.
+func index(rows [][]string, key int) (map[string][]string, []string) {
+	byKey := make(map[string][]string, len(rows))
+	var order []string
+	seen := map[string]int{}
+	for _, row := range rows {
+		k := cell(row, key)
+		seen[k]++
+		if n := seen[k]; n > 1 {
+			k += "#" + strconv.Itoa(n)
+		}
+		byKey[k] = row
+		order = append(order, k)
+	}
+	return byKey, order
+}
+
+// keyColumn resolves key to a header name
+func keyColumn(header []string, key string) (string, error) {
+	if len(header) == 0 {
+		return "", fmt.Errorf("the file has no header row")
+	}
+	if key == "" {
+		return header[0], nil
+	}
+	if slices.Contains(header, key) {
+		return key, nil
+	}
+	if n, err := strconv.Atoi(key); err == nil && n >= 1 && n <= len(header) {
+		return header[n-1], nil
+	}
+	return "", fmt.Errorf("no column %q (columns: %s)", key, strings.Join(header, ", "))
+}
+
+// parse reads CSV content in any encoding textenc detects, guessing the
+// delimiter from the header line
+func parse(data []byte) (*table, error) {
+	text, _ := textenc.Decode(data)
+	if text == "" {
+		return &table{}, nil
+	}
+
+	reader := csv.NewReader(strings.NewReader(text))
+	reader.Comma = delimiter(text)
+	reader.FieldsPerRecord = -1
+	reader.LazyQuotes = true
+
+	records, err := reader.ReadAll()
+	if err != nil {
+		return nil, err
+	}
+	if len(records) == 0 {
+		return &table{}, nil
+	}
+	return &table{header: records[0], rows: records[1:]}, nil
+}
+
+// delimiter picks comma, semicolon or tab, whichever the first line uses most
+func delimiter(text string) rune {
+	first, _, _ := strings.Cut(text, "\n")
+	best, count := ',', strings.Count(first, ",")
+	for _, d := range []rune{';', '\t'} {
+		if n := strings.Count(first, string(d)); n > count {
+			best, count = d, n
+		}
+	}
+	return best
+}
@@ -0,0 +1,56 @@
+package csvdiff
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	oldCSV := "id,name,price\n1,apple,10\n2,pear,20\n3,plum,30\n"
+	newCSV := "id,name,price,stock\n2,pear,25,5\n1,apple,10,7\n4,kiwi,40,1\n"
+
+	r, err := Diff([]byte(oldCSV), []byte(newCSV), "")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Reordered rows match by key; only real edits are reported
+	want := `+ column stock
+- id=3: 3, plum, 30
++ id=4: 4, kiwi, 40, 1
+~ id=2: price: "20" → "25"
+1 added, 1 removed, 1 changed`
+	if got := r.String(); got != want {
+		t.Errorf("Diff =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestDiffKeyColumn(t *testing.T) {
+	oldCSV := "name;price\napple;10\npear;20\n"
+	newCSV := "name;price\napple;11\npear;20\n"
+
+	for _, key := range []string{"name", "1"} {
+		r, err := Diff([]byte(oldCSV), []byte(newCSV), key)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(r.Changed) != 1 || r.Changed[0].Key != "apple" {
+			t.Errorf("key %q: Changed = %+v", key, r.Changed)
+		}
+	}
+
+	if _, err := Diff([]byte(oldCSV), []byte(newCSV), "sku"); err == nil || !strings.Contains(err.Error(), "name, price") {
+		t.Errorf("unknown key error = %v", err)
+	}
+}
+
+func TestDiffUnchanged(t *testing.T) {
+	data := []byte("a,b\n1,2\n1,2\n")
+	r, err := Diff(data, data, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Empty() {
+		t.Errorf("identical files should be Empty: %+v", r)
+	}
+}
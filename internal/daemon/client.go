@@ -0,0 +1,121 @@
+package daemon
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"time"
+)
+
+// dialTimeout bounds how long a client waits to reach the daemon
+const dialTimeout = 2 * time.Second
+
+// Call sends a single request to the daemon listening on socketPath
+func Call(socketPath string, req Request) (*Response, error) {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return nil, ErrNotRunning
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(10 * time.Second))
+
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return nil, err
+	}
+
+	line, err := bufio.NewReader(conn).ReadBytes('\n')
+	if err != nil {
+		return nil, fmt.Errorf("no reply from daemon: %w", err)
+	}
+
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return nil, fmt.Errorf("invalid reply from daemon: %w", err)
+	}
+	if !resp.OK {
+		return &resp, errors.New(resp.Error)
+	}
+	return &resp, nil
+}
+
+// Publish forwards an event to subscribers of the daemon on socketPath
+func Publish(socketPath string, data json.RawMessage) error {
+	_, err := Call(socketPath, Request{Command: "publish", Data: data})
+	return err
+}
+
+// Subscribe streams events published to the daemon on socketPath, calling
+// fn with each one until the connection closes or fn returns false
+func Subscribe(socketPath string, fn func(json.RawMessage) bool) error {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return ErrNotRunning
+	}
+	defer conn.Close()
+
+	if err := json.NewEncoder(conn).Encode(Request{Command: "subscribe"}); err != nil {
+		return err
+	}
+
+	reader := bufio.NewReader(conn)
+	line, err := reader.ReadBytes('\n')
+	if err != nil {
+		return fmt.Errorf("no reply from daemon: %w", err)
+	}
+	var resp Response
+	if err := json.Unmarshal(line, &resp); err != nil {
+		return fmt.Errorf("invalid reply from daemon: %w", err)
+	}
+	if !resp.OK {
+		return errors.New(resp.Error)
+	}
+
+	for {
+		line, err := reader.ReadBytes('\n')
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if !fn(json.RawMessage(line[:len(line)-1])) {
+			return nil
+		}
+	}
+}
+
+// Running reports whether a daemon answers on socketPath
+func Running(socketPath string) bool {
+	conn, err := net.DialTimeout("unix", socketPath, dialTimeout)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// GetStatus asks the daemon for its status
+func GetStatus(socketPath string) (*Status, error) {
+	resp, err := Call(socketPath, Request{Command: "status"})
+	if err != nil {
+		return nil, err
+	}
+	return resp.Status, nil
+}
+
+// RequestStop asks the daemon to shut down and waits for it to exit
+func RequestStop(socketPath string) error {
+	if _, err := Call(socketPath, Request{Command: "stop"}); err != nil {
+		return err
+	}
+	for i := 0; i < 50; i++ {
+		if !Running(socketPath) {
+			return nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return fmt.Errorf("daemon did not stop in time")
+}
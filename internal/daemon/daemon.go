@@ -0,0 +1,371 @@
+package daemon
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+const (
+	SocketFileName = "daemon.sock"
+	LogFileName    = "daemon.log"
+	PIDFileName    = "daemon.pid"
+)
+
+var ErrNotRunning = errors.New("daemon is not running")
+
+// Service is a background job managed by the daemon
+type Service interface {
+	Name() string
+	Run(ctx context.Context, logger *log.Logger) error
+}
+
+// Request is a control message sent to the daemon
+type Request struct {
+	Command string          `json:"command"`
+	Args    []string        `json:"args,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+}
+
+// Response is the daemon's reply to a Request
+type Response struct {
+	OK     bool            `json:"ok"`
+	Error  string          `json:"error,omitempty"`
+	Status *Status         `json:"status,omitempty"`
+	Data   json.RawMessage `json:"data,omitempty"`
+}
+
+// Status describes a running daemon
+type Status struct {
+	PID       int             `json:"pid"`
+	StartedAt time.Time       `json:"started_at"`
+	Services  []ServiceStatus `json:"services"`
+}
+
+// ServiceStatus describes the state of one service
+type ServiceStatus struct {
+	Name  string `json:"name"`
+	State string `json:"state"`
+}
+
+// HandlerFunc handles a control command
+type HandlerFunc func(req Request) Response
+
+// Options configures a Daemon
+type Options struct {
+	SocketPath string    // defaults to ~/.oops/daemon.sock
+	LogWriter  io.Writer // defaults to os.Stderr
+}
+
+// Daemon runs background services and answers control requests on a socket
+type Daemon struct {
+	socketPath string
+	logger     *log.Logger
+	services   []Service
+	handlers   map[string]HandlerFunc
+	started    time.Time
+
+	mu          sync.Mutex
+	states      map[string]string
+	cancel      context.CancelFunc
+	subscribers map[chan json.RawMessage]struct{}
+}
+
+// subscriberBuffer is how many events a slow subscriber may lag behind
+// before further events are dropped for it
+const subscriberBuffer = 64
+
+// SocketPath returns the default control socket path
+func SocketPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, SocketFileName), nil
+}
+
+// LogPath returns the daemon log file path
+func LogPath() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, LogFileName), nil
+}
+
+// PIDPath returns the file where the daemon listening on socketPath
+// records its process id
+func PIDPath(socketPath string) string {
+	return filepath.Join(filepath.Dir(socketPath), PIDFileName)
+}
+
+// ReadPID returns the process id recorded for the daemon on socketPath,
+// or 0 if none is recorded. A recorded id while the daemon does not answer
+// means it exited without cleaning up.
+func ReadPID(socketPath string) int {
+	data, err := os.ReadFile(PIDPath(socketPath))
+	if err != nil {
+		return 0
+	}
+	pid, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0
+	}
+	return pid
+}
+
+// New creates a daemon with the given options
+func New(opts Options) (*Daemon, error) {
+	if opts.SocketPath == "" {
+		path, err := SocketPath()
+		if err != nil {
+			return nil, err
+		}
+		opts.SocketPath = path
+	}
+	if opts.LogWriter == nil {
+		opts.LogWriter = os.Stderr
+	}
+
+	d := &Daemon{
+		socketPath:  opts.SocketPath,
+		logger:      log.New(opts.LogWriter, "", log.LstdFlags),
+		handlers:    make(map[string]HandlerFunc),
+		states:      make(map[string]string),
+		subscribers: make(map[chan json.RawMessage]struct{}),
+	}
+	d.Handle("status", func(Request) Response {
+		return Response{OK: true, Status: d.Status()}
+	})
+	d.Handle("stop", func(Request) Response {
+		d.Stop()
+		return Response{OK: true}
+	})
+	d.Handle("publish", func(req Request) Response {
+		if len(req.Data) == 0 {
+			return Response{Error: "publish requires data"}
+		}
+		d.Publish(req.Data)
+		return Response{OK: true}
+	})
+	return d, nil
+}
+
+// AddService registers a service to run when the daemon starts
+func (d *Daemon) AddService(s Service) {
+	d.services = append(d.services, s)
+	d.setState(s.Name(), "pending")
+}
+
+// Handle registers a handler for a control command
+func (d *Daemon) Handle(command string, fn HandlerFunc) {
+	d.handlers[command] = fn
+}
+
+// Logger returns the daemon's logger
+func (d *Daemon) Logger() *log.Logger {
+	return d.logger
+}
+
+// Status returns the current daemon status
+func (d *Daemon) Status() *Status {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	st := &Status{PID: os.Getpid(), StartedAt: d.started}
+	for _, s := range d.services {
+		st.Services = append(st.Services, ServiceStatus{Name: s.Name(), State: d.states[s.Name()]})
+	}
+	return st
+}
+
+// Publish sends an event to every subscriber. Subscribers that have
+// fallen too far behind miss the event rather than blocking the daemon.
+func (d *Daemon) Publish(data json.RawMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	for ch := range d.subscribers {
+		select {
+		case ch <- data:
+		default:
+		}
+	}
+}
+
+func (d *Daemon) subscribe() chan json.RawMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	ch := make(chan json.RawMessage, subscriberBuffer)
+	d.subscribers[ch] = struct{}{}
+	return ch
+}
+
+func (d *Daemon) unsubscribe(ch chan json.RawMessage) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	delete(d.subscribers, ch)
+}
+
+// Stop asks a running daemon to shut down
+func (d *Daemon) Stop() {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.cancel != nil {
+		d.cancel()
+	}
+}
+
+// Run starts all services and serves control requests until ctx is
+// cancelled or a stop request arrives
+func (d *Daemon) Run(ctx context.Context) error {
+	if Running(d.socketPath) {
+		return fmt.Errorf("daemon is already running")
+	}
+	os.Remove(d.socketPath) // Remove stale socket from a crashed daemon
+
+	ctx, cancel := context.WithCancel(ctx)
+	d.mu.Lock()
+	d.cancel = cancel
+	d.started = time.Now()
+	d.mu.Unlock()
+	defer cancel()
+
+	if err := os.MkdirAll(filepath.Dir(d.socketPath), 0755); err != nil {
+		return err
+	}
+	ln, err := net.Listen("unix", d.socketPath)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", d.socketPath, err)
+	}
+	defer os.Remove(d.socketPath)
+
+	pidPath := PIDPath(d.socketPath)
+	if err := os.WriteFile(pidPath, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		return fmt.Errorf("failed to write %s: %w", pidPath, err)
+	}
+	defer os.Remove(pidPath)
+
+	d.logger.Printf("daemon started (pid %d)", os.Getpid())
+
+	var wg sync.WaitGroup
+	for _, s := range d.services {
+		wg.Add(1)
+		go func(s Service) {
+			defer wg.Done()
+			d.runService(ctx, s)
+		}(s)
+	}
+
+	go func() {
+		<-ctx.Done()
+		ln.Close()
+	}()
+
+	for {
+		conn, err := ln.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			d.logger.Printf("accept failed: %v", err)
+			continue
+		}
+		go d.serveConn(ctx, conn)
+	}
+
+	wg.Wait()
+	d.logger.Printf("daemon stopped")
+	return nil
+}
+
+// runService runs a service and records its state
+func (d *Daemon) runService(ctx context.Context, s Service) {
+	d.setState(s.Name(), "running")
+	d.logger.Printf("[%s] started", s.Name())
+
+	err := s.Run(ctx, d.logger)
+	switch {
+	case err != nil && ctx.Err() == nil:
+		d.setState(s.Name(), "failed: "+err.Error())
+		d.logger.Printf("[%s] failed: %v", s.Name(), err)
+	default:
+		d.setState(s.Name(), "stopped")
+		d.logger.Printf("[%s] stopped", s.Name())
+	}
+}
+
+// serveConn answers requests on a single connection, one JSON line each.
+// A subscribe request turns the connection into an event stream.
+func (d *Daemon) serveConn(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+
+	scanner := bufio.NewScanner(conn)
+	enc := json.NewEncoder(conn)
+	for scanner.Scan() {
+		var req Request
+		var resp Response
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			resp = Response{Error: fmt.Sprintf("invalid request: %v", err)}
+		} else if req.Command == "subscribe" {
+			d.streamEvents(ctx, conn, enc)
+			return
+		} else if fn, ok := d.handlers[req.Command]; ok {
+			resp = fn(req)
+		} else {
+			resp = Response{Error: fmt.Sprintf("unknown command: %s", req.Command)}
+		}
+		if err := enc.Encode(resp); err != nil {
+			return
+		}
+	}
+}
+
+// streamEvents acknowledges a subscription, then writes each published
+// event as a JSON line until the client disconnects or the daemon stops
+func (d *Daemon) streamEvents(ctx context.Context, conn net.Conn, enc *json.Encoder) {
+	ch := d.subscribe()
+	defer d.unsubscribe(ch)
+
+	if err := enc.Encode(Response{OK: true}); err != nil {
+		return
+	}
+
+	// Notice disconnects even while no events arrive
+	closed := make(chan struct{})
+	go func() {
+		io.Copy(io.Discard, conn)
+		close(closed)
+	}()
+
+	for {
+		select {
+		case data := <-ch:
+			if _, err := conn.Write(append(data, '\n')); err != nil {
+				return
+			}
+		case <-closed:
+			return
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (d *Daemon) setState(name, state string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.states[name] = state
+}
@@ -0,0 +1,136 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/watch"
+)
+
+type blockingService struct{}
+
+func (blockingService) Name() string { return "blocker" }
+
+func (blockingService) Run(ctx context.Context, logger *log.Logger) error {
+	<-ctx.Done()
+	return nil
+}
+
+func startTestDaemon(t *testing.T, services ...Service) (*Daemon, string, chan error) {
+	socketPath := filepath.Join(t.TempDir(), "d.sock")
+	d, err := New(Options{SocketPath: socketPath, LogWriter: io.Discard})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.AddService(blockingService{})
+	for _, s := range services {
+		d.AddService(s)
+	}
+
+	done := make(chan error, 1)
+	go func() { done <- d.Run(context.Background()) }()
+
+	for i := 0; i < 50 && !Running(socketPath); i++ {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if !Running(socketPath) {
+		t.Fatal("daemon did not start")
+	}
+	return d, socketPath, done
+}
+
+func TestDaemonStatusAndStop(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	_, socketPath, done := startTestDaemon(t, watch.Service{})
+
+	status, err := GetStatus(socketPath)
+	if err != nil {
+		t.Fatalf("GetStatus failed: %v", err)
+	}
+	if pid := ReadPID(socketPath); pid != status.PID {
+		t.Errorf("ReadPID = %d, want %d", pid, status.PID)
+	}
+	if len(status.Services) != 2 || status.Services[0].Name != "blocker" || status.Services[1].Name != "watcher" {
+		t.Fatalf("Services = %+v, want the blocker and watcher services", status.Services)
+	}
+	// Services start alongside the socket
+	for i := 0; i < 50 && status.Services[1].State != "running"; i++ {
+		time.Sleep(20 * time.Millisecond)
+		status, _ = GetStatus(socketPath)
+	}
+	if state := status.Services[1].State; state != "running" {
+		t.Errorf("watcher is %s, want running", state)
+	}
+
+	if err := RequestStop(socketPath); err != nil {
+		t.Fatalf("RequestStop failed: %v", err)
+	}
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("daemon did not exit")
+	}
+	if pid := ReadPID(socketPath); pid != 0 {
+		t.Errorf("ReadPID after stop = %d, want 0", pid)
+	}
+}
+
+func TestDaemonUnknownCommand(t *testing.T) {
+	d, socketPath, done := startTestDaemon(t)
+	defer func() { d.Stop(); <-done }()
+
+	if _, err := Call(socketPath, Request{Command: "bogus"}); err == nil {
+		t.Error("Expected error for unknown command")
+	}
+}
+
+func TestCallNotRunning(t *testing.T) {
+	_, err := Call(filepath.Join(t.TempDir(), "none.sock"), Request{Command: "status"})
+	if err != ErrNotRunning {
+		t.Errorf("Expected ErrNotRunning, got %v", err)
+	}
+}
+
+func TestPublishSubscribe(t *testing.T) {
+	d, socketPath, _ := startTestDaemon(t)
+	defer d.Stop()
+
+	received := make(chan string, 1)
+	go Subscribe(socketPath, func(data json.RawMessage) bool {
+		received <- string(data)
+		return false
+	})
+
+	// Wait for the subscription to register before publishing
+	for i := 0; i < 50; i++ {
+		d.mu.Lock()
+		n := len(d.subscribers)
+		d.mu.Unlock()
+		if n > 0 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+
+	if err := Publish(socketPath, json.RawMessage(`{"type":"restored"}`)); err != nil {
+		t.Fatalf("Publish failed: %v", err)
+	}
+	select {
+	case got := <-received:
+		if got != `{"type":"restored"}` {
+			t.Errorf("received %s", got)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no event received")
+	}
+}
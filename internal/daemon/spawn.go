@@ -0,0 +1,43 @@
+package daemon
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"time"
+)
+
+// Spawn starts the daemon as a detached background process by re-running
+// the current executable with args, then waits for its socket to answer
+func Spawn(socketPath string, args ...string) (int, error) {
+	pid, err := Detach(args...)
+	if err != nil {
+		return 0, err
+	}
+
+	for i := 0; i < 50; i++ {
+		if Running(socketPath) {
+			return pid, nil
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	return pid, fmt.Errorf("daemon did not start; see 'oops daemon logs'")
+}
+
+// Detach re-runs the current executable with args as a background process
+// that outlives this one, without waiting for it
+func Detach(args ...string) (int, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return 0, err
+	}
+
+	cmd := exec.Command(exe, args...)
+	cmd.SysProcAttr = detachAttr()
+	if err := cmd.Start(); err != nil {
+		return 0, err
+	}
+	pid := cmd.Process.Pid
+	cmd.Process.Release()
+	return pid, nil
+}
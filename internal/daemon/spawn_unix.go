@@ -0,0 +1,10 @@
+//go:build !windows
+
+package daemon
+
+import "syscall"
+
+// detachAttr starts the daemon in its own session so it outlives the shell
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{Setsid: true}
+}
@@ -0,0 +1,15 @@
+//go:build windows
+
+package daemon
+
+import "syscall"
+
+const detachedProcess = 0x00000008
+
+// detachAttr starts the daemon without a console so it outlives the shell
+func detachAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		CreationFlags: detachedProcess | syscall.CREATE_NEW_PROCESS_GROUP,
+		HideWindow:    true,
+	}
+}
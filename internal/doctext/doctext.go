@@ -0,0 +1,124 @@
+// Package doctext extracts the plain text of office documents (docx, odt)
+// so versions can be compared as prose. Documents are stored unchanged;
+// text is only extracted for display.
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strings"
+)
+
+// Namespaces of the elements that carry text
+const (
+	nsWord = "http://schemas.openxmlformats.org/wordprocessingml/2006/main"
+	nsODF  = "urn:oasis:names:tc:opendocument:xmlns:text:1.0"
+)
+
+// bodyParts maps each supported extension to the archive member holding
+// the document body
+var bodyParts = map[string]string{
+	".docx": "word/document.xml",
+	".odt":  "content.xml",
+}
+
+// IsDocument reports whether fileName is an office document this package
+// can extract text from
+func IsDocument(fileName string) bool {
+	_, ok := bodyParts[strings.ToLower(filepath.Ext(fileName))]
+	return ok
+}
+
+// Extract returns the text of a document, one paragraph per line.
+// fileName selects the format by its extension.
+func Extract(fileName string, data []byte) (string, error) {
+	part, ok := bodyParts[strings.ToLower(filepath.Ext(fileName))]
+	if !ok {
+		return "", fmt.Errorf("%s is not a supported document", fileName)
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", fmt.Errorf("not a valid document: %w", err)
+	}
+	f, err := archive.Open(part)
+	if err != nil {
+		return "", fmt.Errorf("not a valid document: %w", err)
+	}
+	defer f.Close()
+
+	return extractXML(f)
+}
+
+// extractXML walks the body XML of either format. Both mark paragraphs,
+// tabs and line breaks with elements and keep text in character data:
+// inside w:t for Word, anywhere in a text:p or text:h for ODF.
+func extractXML(r io.Reader) (string, error) {
+	var b strings.Builder
+	decoder := xml.NewDecoder(r)
+	inWordText := false
+	odfDepth := 0 // nesting of ODF paragraphs and headings
+
+	for {
+		tok, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			switch t.Name {
+			case xml.Name{Space: nsWord, Local: "t"}:
+				inWordText = true
+			case xml.Name{Space: nsODF, Local: "p"}, xml.Name{Space: nsODF, Local: "h"}:
+				odfDepth++
+			case xml.Name{Space: nsWord, Local: "tab"}, xml.Name{Space: nsODF, Local: "tab"}:
+				b.WriteByte('\t')
+			case xml.Name{Space: nsWord, Local: "br"}, xml.Name{Space: nsODF, Local: "line-break"}:
+				b.WriteByte('\n')
+			case xml.Name{Space: nsODF, Local: "s"}:
+				b.WriteString(strings.Repeat(" ", spaceCount(t)))
+			}
+		case xml.EndElement:
+			switch t.Name {
+			case xml.Name{Space: nsWord, Local: "t"}:
+				inWordText = false
+			case xml.Name{Space: nsWord, Local: "p"}:
+				b.WriteByte('\n')
+			case xml.Name{Space: nsODF, Local: "p"}, xml.Name{Space: nsODF, Local: "h"}:
+				odfDepth--
+				if odfDepth == 0 {
+					b.WriteByte('\n')
+				}
+			}
+		case xml.CharData:
+			if inWordText || odfDepth > 0 {
+				b.Write(t)
+			}
+		}
+	}
+	return b.String(), nil
+}
+
+// spaceCount reads the text:c attribute of an ODF text:s element
+func spaceCount(el xml.StartElement) int {
+	for _, attr := range el.Attr {
+		if attr.Name.Space == nsODF && attr.Name.Local == "c" {
+			var n int
+			if _, err := fmt.Sscanf(attr.Value, "%d", &n); err == nil && n > 0 {
+				return n
+			}
+		}
+	}
+	return 1
+}
@@ -0,0 +1,63 @@
+package doctext
+
+import (
+	"archive/zip"
+	"bytes"
+	"testing"
+)
+
+// zipWith builds an archive holding one member
+func zipWith(t *testing.T, name, content string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	f, err := w.Create(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Write([]byte(content))
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestExtractDocx(t *testing.T) {
+	doc := zipWith(t, "word/document.xml", `<?xml version="1.0"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+  <w:body>
+    <w:p><w:r><w:t>Dear </w:t></w:r><w:r><w:t>reader,</w:t></w:r></w:p>
+    <w:p><w:r><w:t>Name</w:t><w:tab/><w:t>Value</w:t></w:r></w:p>
+  </w:body>
+</w:document>`)
+
+	got, err := Extract("letter.DOCX", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Dear reader,\nName\tValue\n"; got != want {
+		t.Errorf("Extract = %q, want %q", got, want)
+	}
+}
+
+func TestExtractODT(t *testing.T) {
+	doc := zipWith(t, "content.xml", `<?xml version="1.0"?>
+<office:document-content xmlns:office="urn:oasis:names:tc:opendocument:xmlns:office:1.0" xmlns:text="urn:oasis:names:tc:opendocument:xmlns:text:1.0"><office:body><office:text><text:h>Title</text:h><text:p>One<text:s text:c="3"/>two <text:span>three</text:span></text:p></office:text></office:body></office:document-content>`)
+
+	got, err := Extract("notes.odt", doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "Title\nOne   two three\n"; got != want {
+		t.Errorf("Extract = %q, want %q", got, want)
+	}
+}
+
+func TestExtractInvalid(t *testing.T) {
+	if _, err := Extract("broken.docx", []byte("not a zip")); err == nil {
+		t.Error("Extract should fail for non-zip content")
+	}
+	if IsDocument("notes.txt") || !IsDocument("report.odt") {
+		t.Error("IsDocument misclassified a file")
+	}
+}
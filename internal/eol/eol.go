@@ -0,0 +1,74 @@
+// Package eol converts line endings so a file stored on one platform
+// round-trips cleanly on another.
+package eol
+
+import (
+	"bytes"
+	"fmt"
+	"runtime"
+)
+
+// Line ending modes, set with the eol config key
+const (
+	ModeOff    = "off"    // store and restore content unchanged
+	ModeLF     = "lf"     // store LF, restore LF
+	ModeNative = "native" // store LF, restore the platform's line endings
+)
+
+// Modes lists the valid modes
+var Modes = []string{ModeOff, ModeLF, ModeNative}
+
+// Validate returns an error if mode is not a valid mode. An empty mode
+// means ModeOff.
+func Validate(mode string) error {
+	switch mode {
+	case "", ModeOff, ModeLF, ModeNative:
+		return nil
+	}
+	return fmt.Errorf("invalid eol mode %q (use off, lf or native)", mode)
+}
+
+// IsBinary reports whether data looks binary. Binary content is never
+// converted.
+func IsBinary(data []byte) bool {
+	const sniffLen = 8000
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	return bytes.IndexByte(data, 0) >= 0
+}
+
+// ToLF converts CRLF line endings in text to LF
+func ToLF(data []byte) []byte {
+	if IsBinary(data) || !bytes.Contains(data, []byte("\r\n")) {
+		return data
+	}
+	return bytes.ReplaceAll(data, []byte("\r\n"), []byte("\n"))
+}
+
+// ToCRLF converts LF line endings in text to CRLF, leaving existing CRLF
+// endings alone
+func ToCRLF(data []byte) []byte {
+	if IsBinary(data) || !bytes.Contains(data, []byte("\n")) {
+		return data
+	}
+	return bytes.ReplaceAll(ToLF(data), []byte("\n"), []byte("\r\n"))
+}
+
+// Clean returns the filter applied to content before it is stored in
+// mode, or nil if content is stored unchanged
+func Clean(mode string) func([]byte) []byte {
+	if mode == ModeLF || mode == ModeNative {
+		return ToLF
+	}
+	return nil
+}
+
+// Smudge returns the filter applied to stored content when it is
+// restored in mode, or nil if it is restored unchanged
+func Smudge(mode string) func([]byte) []byte {
+	if mode == ModeNative && runtime.GOOS == "windows" {
+		return ToCRLF
+	}
+	return nil
+}
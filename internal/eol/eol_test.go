@@ -0,0 +1,47 @@
+package eol
+
+import "testing"
+
+func TestToLF(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"a\r\nb\r\n", "a\nb\n"},
+		{"a\nb\r\n", "a\nb\n"},
+		{"a\nb\n", "a\nb\n"},
+		{"lone\rcr\r\n", "lone\rcr\n"},
+		{"bin\x00\r\n", "bin\x00\r\n"},
+	}
+	for _, tt := range tests {
+		if got := string(ToLF([]byte(tt.input))); got != tt.want {
+			t.Errorf("ToLF(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestToCRLF(t *testing.T) {
+	tests := []struct {
+		input, want string
+	}{
+		{"a\nb\n", "a\r\nb\r\n"},
+		{"a\r\nb\n", "a\r\nb\r\n"},
+		{"no newline", "no newline"},
+		{"bin\x00\n", "bin\x00\n"},
+	}
+	for _, tt := range tests {
+		if got := string(ToCRLF([]byte(tt.input))); got != tt.want {
+			t.Errorf("ToCRLF(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, mode := range append(Modes, "") {
+		if err := Validate(mode); err != nil {
+			t.Errorf("Validate(%q) = %v", mode, err)
+		}
+	}
+	if err := Validate("crlf"); err == nil {
+		t.Error("Validate should reject unknown modes")
+	}
+}
@@ -0,0 +1,101 @@
+// Package events publishes structured notifications about oops activity so
+// external tools can react to snapshots and restores as they happen.
+package events
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// Event types
+const (
+	SnapshotCreated = "snapshot-created"
+	Restored        = "restored"
+	StoreDeleted    = "store-deleted"
+)
+
+// Event describes one change to a tracked file's history
+type Event struct {
+	Type     string    `json:"type"`
+	Time     time.Time `json:"time"`
+	File     string    `json:"file"`
+	Store    string    `json:"store"`             // local, global or memory
+	Profile  string    `json:"profile,omitempty"` // set for profiles other than the default
+	Snapshot int       `json:"snapshot,omitempty"`
+	Message  string    `json:"message,omitempty"`
+}
+
+// Sink receives published events
+type Sink func(Event)
+
+var (
+	mu     sync.Mutex
+	sinks  = make(map[int]Sink)
+	nextID int
+)
+
+// Subscribe registers a sink and returns a function that removes it
+func Subscribe(fn Sink) (unsubscribe func()) {
+	mu.Lock()
+	defer mu.Unlock()
+	id := nextID
+	nextID++
+	sinks[id] = fn
+	return func() {
+		mu.Lock()
+		defer mu.Unlock()
+		delete(sinks, id)
+	}
+}
+
+// Emit delivers an event to every registered sink
+func Emit(e Event) {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	mu.Lock()
+	current := make([]Sink, 0, len(sinks))
+	for _, fn := range sinks {
+		current = append(current, fn)
+	}
+	mu.Unlock()
+
+	for _, fn := range current {
+		fn(e)
+	}
+}
+
+// WriterSink returns a sink that writes each event to w as a JSON line
+func WriterSink(w io.Writer) Sink {
+	var wmu sync.Mutex
+	enc := json.NewEncoder(w)
+	return func(e Event) {
+		wmu.Lock()
+		defer wmu.Unlock()
+		enc.Encode(e)
+	}
+}
+
+// FileSink returns a sink that appends events to the file at path as JSON
+// lines. The file is opened per event so several processes can share it.
+func FileSink(path string) Sink {
+	return func(e Event) {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return
+		}
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+		if err != nil {
+			return
+		}
+		defer f.Close()
+		data, err := json.Marshal(e)
+		if err != nil {
+			return
+		}
+		f.Write(append(data, '\n'))
+	}
+}
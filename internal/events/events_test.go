@@ -0,0 +1,56 @@
+package events
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestSubscribeAndEmit(t *testing.T) {
+	var got []Event
+	unsubscribe := Subscribe(func(e Event) { got = append(got, e) })
+
+	Emit(Event{Type: SnapshotCreated, File: "/tmp/a.txt", Snapshot: 2})
+	unsubscribe()
+	Emit(Event{Type: Restored, File: "/tmp/a.txt", Snapshot: 1})
+
+	if len(got) != 1 {
+		t.Fatalf("received %d events, want 1", len(got))
+	}
+	if got[0].Type != SnapshotCreated || got[0].Time.IsZero() {
+		t.Errorf("event = %+v, want snapshot-created with a timestamp", got[0])
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := WriterSink(&buf)
+	sink(Event{Type: StoreDeleted, File: "/tmp/a.txt", Store: "local"})
+
+	var e Event
+	if err := json.Unmarshal(buf.Bytes(), &e); err != nil {
+		t.Fatalf("output is not JSON: %v", err)
+	}
+	if e.Type != StoreDeleted || e.Store != "local" {
+		t.Errorf("decoded = %+v", e)
+	}
+}
+
+func TestFileSinkAppends(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "logs", "events.jsonl")
+	sink := FileSink(path)
+	sink(Event{Type: SnapshotCreated, Snapshot: 1})
+	sink(Event{Type: SnapshotCreated, Snapshot: 2})
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if len(lines) != 2 {
+		t.Errorf("file has %d lines, want 2", len(lines))
+	}
+}
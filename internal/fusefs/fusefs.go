@@ -0,0 +1,432 @@
+// Package fusefs serves a flat, read-only directory of files over FUSE,
+// speaking the kernel protocol directly so no FUSE library is needed.
+// Mounting is supported on Linux; elsewhere Mount returns ErrUnsupported.
+package fusefs
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"syscall"
+	"time"
+)
+
+// ErrUnsupported is returned by Mount where FUSE is not available
+var ErrUnsupported = errors.New("mounting is only supported on Linux")
+
+// Entry is one file of a mounted directory
+type Entry struct {
+	Name    string
+	ModTime time.Time
+	Content func() ([]byte, error) // read once, when the file is first used
+}
+
+// Lister returns the files of the directory. It is called again each time
+// the directory is listed, so files added meanwhile show up.
+type Lister func() ([]Entry, error)
+
+// Server answers the kernel's requests for one mounted directory
+type Server struct {
+	dir  string
+	conn io.ReadWriteCloser
+	list Lister
+
+	uid, gid uint32
+	names    map[string]uint64 // inode by file name
+	files    map[uint64]*file  // by inode
+	order    []uint64          // inodes in listing order
+	nextIno  uint64
+	listed   time.Time // when the directory was last listed
+	unmount  func() error
+}
+
+// file is an entry with its inode and, once read, its content
+type file struct {
+	Entry
+	content []byte
+	loaded  bool
+}
+
+// Protocol constants, from the kernel's include/uapi/linux/fuse.h
+const (
+	opLookup      = 1
+	opForget      = 2
+	opGetattr     = 3
+	opOpen        = 14
+	opRead        = 15
+	opStatfs      = 17
+	opRelease     = 18
+	opFlush       = 25
+	opInit        = 26
+	opOpendir     = 27
+	opReaddir     = 28
+	opReleasedir  = 29
+	opAccess      = 34
+	opInterrupt   = 36
+	opDestroy     = 38
+	opBatchForget = 42
+
+	inHeaderSize  = 40
+	outHeaderSize = 16
+	attrSize      = 88
+
+	rootIno   = 1
+	maxWrite  = 128 << 10
+	bufSize   = maxWrite + 4096
+	keepCache = 1 << 1 // FOPEN_KEEP_CACHE: versions never change
+
+	modeDir  = 0o040000
+	modeFile = 0o100000
+
+	// Linux errno values, as the kernel expects them on every platform
+	errNoEnt  = 2
+	errIO     = 5
+	errNotDir = 20
+	errIsDir  = 21
+	errROFS   = 30
+	errNoSys  = 38
+)
+
+// validity is how long the kernel may cache names and attributes before
+// asking again
+const validity = time.Second
+
+var order = binary.NativeEndian
+
+// newServer returns a server for conn, a FUSE device already mounted at
+// dir
+func newServer(dir string, conn io.ReadWriteCloser, list Lister, uid, gid uint32) *Server {
+	return &Server{
+		dir:     dir,
+		conn:    conn,
+		list:    list,
+		uid:     uid,
+		gid:     gid,
+		names:   map[string]uint64{},
+		files:   map[uint64]*file{},
+		nextIno: rootIno + 1,
+	}
+}
+
+// Dir returns the directory the server is mounted at
+func (s *Server) Dir() string {
+	return s.dir
+}
+
+// Unmount detaches the directory, which makes Serve return
+func (s *Server) Unmount() error {
+	if s.unmount == nil {
+		return nil
+	}
+	return s.unmount()
+}
+
+// Serve answers requests until the directory is unmounted
+func (s *Server) Serve() error {
+	defer s.conn.Close()
+	buf := make([]byte, bufSize)
+	for {
+		n, err := s.conn.Read(buf)
+		if err != nil {
+			if retry(err) {
+				continue
+			}
+			if unmounted(err) {
+				return nil
+			}
+			return err
+		}
+		if n < inHeaderSize {
+			return fmt.Errorf("short FUSE request: %d bytes", n)
+		}
+		if err := s.handle(buf[:n]); err != nil && !unmounted(err) {
+			return err
+		}
+	}
+}
+
+// handle answers one request
+func (s *Server) handle(req []byte) error {
+	opcode := order.Uint32(req[4:])
+	unique := order.Uint64(req[8:])
+	node := order.Uint64(req[16:])
+	body := req[inHeaderSize:]
+
+	switch opcode {
+	case opInit:
+		return s.reply(unique, 0, s.init(body))
+	case opLookup:
+		return s.lookup(unique, node, cString(body))
+	case opGetattr:
+		attr, errno := s.attr(node)
+		if errno != 0 {
+			return s.reply(unique, errno, nil)
+		}
+		out := make([]byte, 16, 16+attrSize)
+		putValidity(out[0:], out[8:])
+		return s.reply(unique, 0, append(out, attr...))
+	case opOpendir:
+		if node != rootIno {
+			return s.reply(unique, errNotDir, nil)
+		}
+		return s.reply(unique, 0, make([]byte, 16))
+	case opOpen:
+		if node == rootIno {
+			return s.reply(unique, errIsDir, nil)
+		}
+		if len(body) >= 4 && order.Uint32(body)&3 != 0 { // O_ACCMODE other than O_RDONLY
+			return s.reply(unique, errROFS, nil)
+		}
+		if _, ok := s.files[node]; !ok {
+			return s.reply(unique, errNoEnt, nil)
+		}
+		out := make([]byte, 16)
+		order.PutUint32(out[8:], keepCache)
+		return s.reply(unique, 0, out)
+	case opRead:
+		return s.read(unique, node, body)
+	case opReaddir:
+		return s.readdir(unique, node, body)
+	case opStatfs:
+		out := make([]byte, 80)
+		order.PutUint32(out[40:], 4096) // bsize
+		order.PutUint32(out[44:], 255)  // namelen
+		order.PutUint32(out[48:], 4096) // frsize
+		return s.reply(unique, 0, out)
+	case opRelease, opReleasedir, opFlush, opAccess, opDestroy:
+		return s.reply(unique, 0, nil)
+	case opForget, opBatchForget, opInterrupt:
+		return nil // no reply expected
+	default:
+		return s.reply(unique, errNoSys, nil)
+	}
+}
+
+// init answers the kernel's INIT with the protocol version served
+func (s *Server) init(body []byte) []byte {
+	minor, readahead := uint32(0), uint32(0)
+	if len(body) >= 12 {
+		minor, readahead = order.Uint32(body[4:]), order.Uint32(body[8:])
+	}
+	out := make([]byte, 64)
+	order.PutUint32(out[0:], 7)  // major
+	order.PutUint32(out[4:], 31) // minor; the kernel settles on the lower of ours and its own
+	order.PutUint32(out[8:], readahead)
+	order.PutUint32(out[20:], maxWrite) // max_write
+	order.PutUint32(out[24:], 1)        // time_gran
+	if minor < 23 {
+		return out[:24] // the reply older kernels expect
+	}
+	return out
+}
+
+// lookup answers LOOKUP of name in the root directory
+func (s *Server) lookup(unique, node uint64, name string) error {
+	if node != rootIno {
+		return s.reply(unique, errNotDir, nil)
+	}
+	if time.Since(s.listed) > validity {
+		if err := s.refresh(); err != nil {
+			return s.reply(unique, errIO, nil)
+		}
+	}
+	ino, ok := s.names[name]
+	if !ok {
+		return s.reply(unique, errNoEnt, nil)
+	}
+	attr, errno := s.attr(ino)
+	if errno != 0 {
+		return s.reply(unique, errno, nil)
+	}
+	out := make([]byte, 40, 40+attrSize)
+	order.PutUint64(out[0:], ino)
+	putValidity(out[16:], out[32:])
+	putValidity(out[24:], out[36:])
+	return s.reply(unique, 0, append(out, attr...))
+}
+
+// read answers READ of a file
+func (s *Server) read(unique, node uint64, body []byte) error {
+	if len(body) < 20 {
+		return s.reply(unique, errIO, nil)
+	}
+	f, ok := s.files[node]
+	if !ok {
+		return s.reply(unique, errNoEnt, nil)
+	}
+	content, err := f.load()
+	if err != nil {
+		return s.reply(unique, errIO, nil)
+	}
+	offset := order.Uint64(body[8:])
+	size := uint64(order.Uint32(body[16:]))
+	if offset >= uint64(len(content)) {
+		return s.reply(unique, 0, nil)
+	}
+	end := min(offset+size, uint64(len(content)))
+	return s.reply(unique, 0, content[offset:end])
+}
+
+// readdir answers READDIR of the root directory. The offset in a request
+// is the index of the entry to continue from.
+func (s *Server) readdir(unique, node uint64, body []byte) error {
+	if node != rootIno {
+		return s.reply(unique, errNotDir, nil)
+	}
+	if len(body) < 20 {
+		return s.reply(unique, errIO, nil)
+	}
+	offset := order.Uint64(body[8:])
+	size := int(order.Uint32(body[16:]))
+	if offset == 0 {
+		if err := s.refresh(); err != nil {
+			return s.reply(unique, errIO, nil)
+		}
+	}
+
+	type dirent struct {
+		ino  uint64
+		name string
+		mode uint32
+	}
+	entries := []dirent{{rootIno, ".", modeDir}, {rootIno, "..", modeDir}}
+	for _, ino := range s.order {
+		entries = append(entries, dirent{ino, s.files[ino].Name, modeFile})
+	}
+
+	var out []byte
+	for i := int(offset); i < len(entries); i++ {
+		e := entries[i]
+		rec := make([]byte, (24+len(e.name)+7)&^7)
+		order.PutUint64(rec[0:], e.ino)
+		order.PutUint64(rec[8:], uint64(i+1))
+		order.PutUint32(rec[16:], uint32(len(e.name)))
+		order.PutUint32(rec[20:], e.mode>>12) // DT_DIR or DT_REG
+		copy(rec[24:], e.name)
+		if len(out)+len(rec) > size {
+			break
+		}
+		out = append(out, rec...)
+	}
+	return s.reply(unique, 0, out)
+}
+
+// refresh lists the directory again, keeping the inode and content of
+// files that are still there unchanged
+func (s *Server) refresh() error {
+	entries, err := s.list()
+	if err != nil {
+		return err
+	}
+	names := make(map[string]uint64, len(entries))
+	s.order = s.order[:0]
+	for _, e := range entries {
+		ino, ok := s.names[e.Name]
+		if !ok || !s.files[ino].ModTime.Equal(e.ModTime) {
+			ino = s.nextIno
+			s.nextIno++
+			s.files[ino] = &file{Entry: e}
+		}
+		names[e.Name] = ino
+		s.order = append(s.order, ino)
+	}
+	for name, ino := range s.names {
+		if names[name] != ino {
+			delete(s.files, ino)
+		}
+	}
+	s.names = names
+	s.listed = time.Now()
+	return nil
+}
+
+// attr encodes the attributes of node, reading a file's content for its
+// size
+func (s *Server) attr(node uint64) ([]byte, int32) {
+	out := make([]byte, attrSize)
+	order.PutUint64(out[0:], node)
+	mode, nlink, size := uint32(modeDir|0o555), uint32(2), uint64(0)
+	var mtime time.Time
+	if node != rootIno {
+		f, ok := s.files[node]
+		if !ok {
+			return nil, errNoEnt
+		}
+		content, err := f.load()
+		if err != nil {
+			return nil, errIO
+		}
+		mode, nlink, size, mtime = modeFile|0o444, 1, uint64(len(content)), f.ModTime
+	}
+	order.PutUint64(out[8:], size)
+	order.PutUint64(out[16:], (size+511)/512) // blocks
+	if !mtime.IsZero() {
+		for _, at := range []int{24, 32, 40} { // atime, mtime, ctime
+			order.PutUint64(out[at:], uint64(mtime.Unix()))
+		}
+		for _, at := range []int{48, 52, 56} {
+			order.PutUint32(out[at:], uint32(mtime.Nanosecond()))
+		}
+	}
+	order.PutUint32(out[60:], mode)
+	order.PutUint32(out[64:], nlink)
+	order.PutUint32(out[68:], s.uid)
+	order.PutUint32(out[72:], s.gid)
+	order.PutUint32(out[80:], 4096) // blksize
+	return out, 0
+}
+
+// load returns the file's content, reading it the first time
+func (f *file) load() ([]byte, error) {
+	if !f.loaded {
+		content, err := f.Content()
+		if err != nil {
+			return nil, err
+		}
+		f.content, f.loaded = content, true
+	}
+	return f.content, nil
+}
+
+// reply sends the answer to request unique: payload, or errno when it is
+// not zero
+func (s *Server) reply(unique uint64, errno int32, payload []byte) error {
+	if errno != 0 {
+		payload = nil
+	}
+	msg := make([]byte, outHeaderSize, outHeaderSize+len(payload))
+	order.PutUint32(msg[0:], uint32(outHeaderSize+len(payload)))
+	order.PutUint32(msg[4:], uint32(-errno))
+	order.PutUint64(msg[8:], unique)
+	_, err := s.conn.Write(append(msg, payload...))
+	return err
+}
+
+// putValidity encodes how long a reply may be cached as seconds and
+// nanoseconds
+func putValidity(secs, nsecs []byte) {
+	order.PutUint64(secs, uint64(validity/time.Second))
+	order.PutUint32(nsecs, uint32(validity%time.Second))
+}
+
+// cString returns the NUL-terminated string at the start of b
+func cString(b []byte) string {
+	for i, c := range b {
+		if c == 0 {
+			return string(b[:i])
+		}
+	}
+	return string(b)
+}
+
+// retry reports whether a failed read of the device should just be tried
+// again, as when the request read was interrupted
+func retry(err error) bool {
+	return errors.Is(err, syscall.EINTR) || errors.Is(err, syscall.EAGAIN) || errors.Is(err, syscall.ENOENT)
+}
+
+// unmounted reports whether err means the directory was unmounted
+func unmounted(err error) bool {
+	return errors.Is(err, syscall.ENODEV) || errors.Is(err, io.EOF)
+}
@@ -0,0 +1,143 @@
+package fusefs
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDevice plays the kernel: it hands requests to the server and keeps
+// its replies
+type fakeDevice struct {
+	requests [][]byte
+	replies  [][]byte
+}
+
+func (d *fakeDevice) Read(p []byte) (int, error) {
+	if len(d.requests) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(p, d.requests[0])
+	d.requests = d.requests[1:]
+	return n, nil
+}
+
+func (d *fakeDevice) Write(p []byte) (int, error) {
+	d.replies = append(d.replies, append([]byte(nil), p...))
+	return len(p), nil
+}
+
+func (d *fakeDevice) Close() error { return nil }
+
+// request encodes a request for node with the given body
+func request(opcode uint32, unique, node uint64, body []byte) []byte {
+	req := make([]byte, inHeaderSize, inHeaderSize+len(body))
+	order.PutUint32(req[0:], uint32(inHeaderSize+len(body)))
+	order.PutUint32(req[4:], opcode)
+	order.PutUint64(req[8:], unique)
+	order.PutUint64(req[16:], node)
+	return append(req, body...)
+}
+
+// readIn encodes the body of READ and READDIR
+func readIn(offset uint64, size uint32) []byte {
+	body := make([]byte, 40)
+	order.PutUint64(body[8:], offset)
+	order.PutUint32(body[16:], size)
+	return body
+}
+
+// serve runs a server over requests and returns its replies by request
+func serve(t *testing.T, list Lister, requests ...[]byte) map[uint64][]byte {
+	t.Helper()
+	dev := &fakeDevice{requests: requests}
+	if err := newServer("mnt", dev, list, 1000, 1000).Serve(); err != nil {
+		t.Fatal(err)
+	}
+	replies := map[uint64][]byte{}
+	for _, r := range dev.replies {
+		if int(order.Uint32(r)) != len(r) {
+			t.Fatalf("reply length %d, header says %d", len(r), order.Uint32(r))
+		}
+		replies[order.Uint64(r[8:])] = r
+	}
+	return replies
+}
+
+func errnoOf(reply []byte) int32 {
+	return -int32(order.Uint32(reply[4:]))
+}
+
+func TestServerReadsVersions(t *testing.T) {
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	reads := 0
+	list := func() ([]Entry, error) {
+		return []Entry{
+			{Name: "v1.txt", ModTime: when, Content: func() ([]byte, error) { reads++; return []byte("first version\n"), nil }},
+			{Name: "v2.txt", ModTime: when, Content: func() ([]byte, error) { return []byte("second\n"), nil }},
+		}, nil
+	}
+
+	replies := serve(t, list,
+		request(opInit, 1, 0, []byte{7, 0, 0, 0, 31, 0, 0, 0, 0, 0, 2, 0}),
+		request(opLookup, 2, rootIno, []byte("v1.txt\x00")),
+		request(opLookup, 3, rootIno, []byte("v9.txt\x00")),
+		request(opReaddir, 4, rootIno, readIn(0, 4096)),
+	)
+
+	if r := replies[1]; errnoOf(r) != 0 || order.Uint32(r[outHeaderSize:]) != 7 {
+		t.Errorf("INIT reply = %v", r)
+	}
+
+	entry := replies[2][outHeaderSize:]
+	if errnoOf(replies[2]) != 0 || len(entry) != 40+attrSize {
+		t.Fatalf("LOOKUP v1.txt failed: %v", replies[2])
+	}
+	ino, attr := order.Uint64(entry), entry[40:]
+	if size := order.Uint64(attr[8:]); size != uint64(len("first version\n")) {
+		t.Errorf("size = %d", size)
+	}
+	if mode := order.Uint32(attr[60:]); mode != modeFile|0o444 {
+		t.Errorf("mode = %o, want a read-only file", mode)
+	}
+	if mtime := order.Uint64(attr[32:]); int64(mtime) != when.Unix() {
+		t.Errorf("mtime = %d, want the snapshot time", mtime)
+	}
+	if errnoOf(replies[3]) != errNoEnt {
+		t.Errorf("LOOKUP of a missing version: errno %d", errnoOf(replies[3]))
+	}
+
+	var names []string
+	for rec := replies[4][outHeaderSize:]; len(rec) > 0; {
+		n := int(order.Uint32(rec[16:]))
+		names = append(names, string(rec[24:24+n]))
+		rec = rec[(24+n+7)&^7:]
+	}
+	if got := strings.Join(names, " "); got != ". .. v1.txt v2.txt" {
+		t.Errorf("READDIR = %q", names)
+	}
+
+	// Reading reuses the content loaded for the lookup
+	dev := &fakeDevice{}
+	s := newServer("mnt", dev, list, 0, 0)
+	dev.requests = [][]byte{
+		request(opLookup, 1, rootIno, []byte("v1.txt\x00")),
+		request(opOpen, 2, ino, make([]byte, 8)),
+		request(opRead, 3, ino, readIn(6, 7)),
+		request(opOpen, 4, ino, []byte{2, 0, 0, 0, 0, 0, 0, 0}), // O_RDWR
+	}
+	reads = 0
+	if err := s.Serve(); err != nil {
+		t.Fatal(err)
+	}
+	if got := string(dev.replies[2][outHeaderSize:]); got != "version" {
+		t.Errorf("READ at offset 6 = %q", got)
+	}
+	if errnoOf(dev.replies[3]) != errROFS {
+		t.Errorf("opening for writing: errno %d, want EROFS", errnoOf(dev.replies[3]))
+	}
+	if reads != 1 {
+		t.Errorf("content read %d times, want once", reads)
+	}
+}
@@ -0,0 +1,112 @@
+package fusefs
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+	"syscall"
+)
+
+// Mount mounts a read-only directory of the files list returns at dir,
+// which must exist. Requests are answered once Serve is called. Without
+// root privileges the mount goes through fusermount, as for any FUSE
+// filesystem.
+func Mount(dir string, list Lister) (*Server, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	var dev *os.File
+	var unmount func() error
+	if os.Geteuid() == 0 {
+		dev, err = mountDirect(dir)
+		unmount = func() error { return syscall.Unmount(dir, syscall.MNT_DETACH) }
+	} else {
+		var bin string
+		bin, err = fusermountPath()
+		if err == nil {
+			dev, err = mountFusermount(bin, dir)
+		}
+		unmount = func() error { return exec.Command(bin, "-u", "-z", dir).Run() }
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s := newServer(dir, dev, list, uint32(os.Getuid()), uint32(os.Getgid()))
+	s.unmount = unmount
+	return s, nil
+}
+
+// mountDirect opens the FUSE device and mounts it at dir itself, which
+// needs root
+func mountDirect(dir string) (*os.File, error) {
+	fd, err := syscall.Open("/dev/fuse", syscall.O_RDWR|syscall.O_CLOEXEC, 0)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open /dev/fuse: %w", err)
+	}
+	opts := fmt.Sprintf("fd=%d,rootmode=40000,user_id=%d,group_id=%d", fd, os.Getuid(), os.Getgid())
+	flags := uintptr(syscall.MS_NOSUID | syscall.MS_NODEV | syscall.MS_RDONLY)
+	if err := syscall.Mount("oops", dir, "fuse.oops", flags, opts); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("mount %s: %w", dir, err)
+	}
+	return os.NewFile(uintptr(fd), "/dev/fuse"), nil
+}
+
+// fusermountPath finds the fusermount helper of FUSE 3 or FUSE 2
+func fusermountPath() (string, error) {
+	for _, name := range []string{"fusermount3", "fusermount"} {
+		if path, err := exec.LookPath(name); err == nil {
+			return path, nil
+		}
+	}
+	return "", fmt.Errorf("fusermount not found; install FUSE (e.g. the fuse3 package)")
+}
+
+// mountFusermount has the setuid fusermount helper mount dir, receiving
+// the opened FUSE device from it over a socket
+func mountFusermount(bin, dir string) (*os.File, error) {
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_STREAM|syscall.SOCK_CLOEXEC, 0)
+	if err != nil {
+		return nil, err
+	}
+	local := os.NewFile(uintptr(fds[0]), "fusermount")
+	remote := os.NewFile(uintptr(fds[1]), "fusermount")
+	defer local.Close()
+
+	var stderr bytes.Buffer
+	cmd := exec.Command(bin, "-o", "ro,nosuid,nodev,fsname=oops,subtype=oops", "--", dir)
+	cmd.ExtraFiles = []*os.File{remote} // descriptor 3 in the helper
+	cmd.Env = append(os.Environ(), "_FUSE_COMMFD=3")
+	cmd.Stderr = &stderr
+	err = cmd.Run()
+	remote.Close()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("%s", msg)
+		}
+		return nil, fmt.Errorf("fusermount: %w", err)
+	}
+
+	oob := make([]byte, syscall.CmsgSpace(4))
+	_, oobn, _, _, err := syscall.Recvmsg(fds[0], make([]byte, 4), oob, 0)
+	if err != nil {
+		return nil, fmt.Errorf("fusermount: %w", err)
+	}
+	msgs, err := syscall.ParseSocketControlMessage(oob[:oobn])
+	if err != nil || len(msgs) == 0 {
+		return nil, fmt.Errorf("fusermount sent no device")
+	}
+	devs, err := syscall.ParseUnixRights(&msgs[0])
+	if err != nil || len(devs) == 0 {
+		return nil, fmt.Errorf("fusermount sent no device")
+	}
+	return os.NewFile(uintptr(devs[0]), "/dev/fuse"), nil
+}
@@ -0,0 +1,8 @@
+//go:build !linux
+
+package fusefs
+
+// Mount is only supported on Linux
+func Mount(dir string, list Lister) (*Server, error) {
+	return nil, ErrUnsupported
+}
@@ -0,0 +1,117 @@
+package git
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/iyulab/oops/internal/compress"
+	"github.com/iyulab/oops/internal/eol"
+)
+
+// binaryDeltaMagic starts a blob that holds a large binary file as a delta
+// against the version before it rather than in full. Git keeps every loose
+// blob whole until the history is packed, so without it each snapshot of a
+// database or image file costs its full size on disk.
+var binaryDeltaMagic = []byte("oops-delta\x00")
+
+const (
+	binaryDeltaMinSize  = 64 << 10 // smaller files are cheap to store whole
+	binaryDeltaMaxChain = 16       // deltas read to restore one version, at most
+)
+
+// binaryDeltaHeader is the size of what precedes the delta itself: the
+// magic, the hash of the base blob and the length of the chain
+var binaryDeltaHeader = len(binaryDeltaMagic) + len(plumbing.ZeroHash) + 1
+
+// deltaContent returns the blob to store for content: a delta against the
+// tracked file at HEAD when content is a large binary file that delta
+// shrinks to at most half, content itself otherwise
+func (r *Repo) deltaContent(repo *git.Repository, content []byte) []byte {
+	if len(content) < binaryDeltaMinSize || compress.IsPacked(content) || !eol.IsBinary(content) {
+		return content
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return content
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return content
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return content
+	}
+	raw, err := readBlob(repo, file.Hash)
+	if err != nil {
+		return content
+	}
+
+	chain := 0
+	if IsBinaryDelta(raw) && len(raw) >= binaryDeltaHeader {
+		chain = int(raw[binaryDeltaHeader-1])
+	}
+	if chain >= binaryDeltaMaxChain {
+		return content
+	}
+	base, err := storedContent(repo, raw)
+	if err != nil || bytes.Equal(base, content) {
+		return content
+	}
+	delta := packfile.DiffDelta(base, content)
+	if len(delta) > len(content)/2 {
+		return content
+	}
+
+	stored := make([]byte, 0, binaryDeltaHeader+len(delta))
+	stored = append(stored, binaryDeltaMagic...)
+	stored = append(stored, file.Hash[:]...)
+	stored = append(stored, byte(chain+1))
+	return append(stored, delta...)
+}
+
+// IsBinaryDelta reports whether stored content is a delta against an
+// earlier version
+func IsBinaryDelta(stored []byte) bool {
+	return bytes.HasPrefix(stored, binaryDeltaMagic)
+}
+
+// storedContent returns the content a stored blob stands for, unpacking
+// it or applying it to its base when it is a delta
+func storedContent(repo *git.Repository, stored []byte) ([]byte, error) {
+	if !IsBinaryDelta(stored) {
+		return compress.Unpack(stored)
+	}
+	if len(stored) < binaryDeltaHeader {
+		return nil, fmt.Errorf("corrupt delta: %d bytes", len(stored))
+	}
+	var hash plumbing.Hash
+	copy(hash[:], stored[len(binaryDeltaMagic):])
+	raw, err := readBlob(repo, hash)
+	if err != nil {
+		return nil, fmt.Errorf("delta base %s: %w", hash, err)
+	}
+	base, err := storedContent(repo, raw)
+	if err != nil {
+		return nil, err
+	}
+	return packfile.PatchDelta(base, stored[binaryDeltaHeader:])
+}
+
+// readBlob reads a blob as stored
+func readBlob(repo *git.Repository, hash plumbing.Hash) ([]byte, error) {
+	blob, err := repo.BlobObject(hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := blob.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
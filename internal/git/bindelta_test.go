@@ -0,0 +1,138 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/compress"
+)
+
+func TestRepoBinaryDeltas(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	content := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(content)
+	content[0] = 0 // binary
+	versions := map[string][]byte{}
+	for v := 1; v <= binaryDeltaMaxChain+4; v++ {
+		content = append([]byte(nil), content...)
+		copy(content[v*1000:], fmt.Sprintf("page %d rewritten", v))
+		if _, err := repo.CommitContent(content, "save", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		tag := fmt.Sprintf("v%d", v)
+		if err := repo.Tag(tag); err != nil {
+			t.Fatal(err)
+		}
+		versions[tag] = content
+	}
+
+	full, deltas := 0, 0
+	for tag := range versions {
+		if size, _, err := repo.StoredSize(tag); err != nil {
+			t.Fatal(err)
+		} else if size < binaryDeltaMinSize {
+			deltas++
+		} else {
+			full++
+		}
+	}
+	if full != 2 || deltas != len(versions)-2 {
+		t.Errorf("%d versions stored in full and %d as deltas, want the first and one after a full chain in full", full, deltas)
+	}
+
+	check := func(when string) {
+		for tag, want := range versions {
+			if got, err := repo.Show(tag); err != nil || !bytes.Equal(got, want) {
+				t.Errorf("%s: %s differs: %v", when, tag, err)
+			}
+		}
+	}
+	check("loose")
+	if err := repo.Repack(zlib.DefaultCompression); err != nil {
+		t.Fatal(err)
+	}
+	check("packed")
+}
+
+func TestRepoBinaryDeltasSkipText(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	text := bytes.Repeat([]byte("a line of text\n"), 10000)
+	for v := 1; v <= 2; v++ {
+		text = append(text, "one more line\n"...)
+		if _, err := repo.CommitContent(text, "save", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Tag(fmt.Sprintf("v%d", v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if size, _, _ := repo.StoredSize("v2"); size != int64(len(text)) {
+		t.Errorf("text stored in %d bytes, want it whole (%d)", size, len(text))
+	}
+}
+
+func TestRepoStreamsFilesTooLargeForMemory(t *testing.T) {
+	repo, dir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+	defer func(max int64) { MaxInMemorySize = max }(MaxInMemorySize)
+	MaxInMemorySize = 128 << 10
+
+	content := make([]byte, 256<<10)
+	rand.New(rand.NewSource(1)).Read(content)
+	content[0] = 0 // binary
+	path := filepath.Join(dir, repo.FileName)
+	for v := 1; v <= 2; v++ {
+		copy(content[v*1000:], fmt.Sprintf("page %d rewritten", v))
+		os.WriteFile(path, content, 0644)
+		if err := repo.Add(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := repo.Commit("save"); err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Tag(fmt.Sprintf("v%d", v)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Above MaxInMemorySize the file is streamed whole, not read for a delta
+	if size, _, _ := repo.StoredSize("v2"); size != int64(len(content)) {
+		t.Errorf("stored in %d bytes, want it whole (%d)", size, len(content))
+	}
+	if got, err := repo.Show("v2"); err != nil || !bytes.Equal(got, content) {
+		t.Errorf("v2 differs: %v", err)
+	}
+
+	// Packing streams through a temporary file too
+	packed, err := repo.AddPacked(1 << 30)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var want bytes.Buffer
+	compress.PackTo(&want, bytes.NewReader(content))
+	if packed != int64(want.Len()) {
+		t.Errorf("packed to %d bytes, want %d", packed, want.Len())
+	}
+	if tooSmall, _ := repo.AddPacked(10); tooSmall != packed {
+		t.Errorf("AddPacked over the limit = %d, want the packed size %d", tooSmall, packed)
+	}
+}
@@ -0,0 +1,234 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/filemode"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage"
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/timing"
+)
+
+// ErrHeadMoved is returned by CommitOnto when another commit was made
+// since the one it was asked to build on
+var ErrHeadMoved = errors.New("the history changed while committing")
+
+// Snapshots are committed straight into the object store: the blob is
+// written from the tracked file or the given content, and the commit's
+// tree holds that one blob. Nothing is copied into the repository's own
+// work tree, so saving a large file does not take its size twice on disk.
+
+// lazyWriter is implemented by object stores that can stream an object to
+// disk without holding it in memory
+type lazyWriter interface {
+	LazyWriter() (io.WriteCloser, func(plumbing.ObjectType, int64) error, error)
+}
+
+// writeBlob stores the size bytes read from src as a blob and returns its
+// hash. On disk the content streams into the object store.
+func writeBlob(repo *git.Repository, src io.Reader, size int64) (plumbing.Hash, error) {
+	if lazy, ok := repo.Storer.(lazyWriter); ok {
+		w, writeHeader, err := lazy.LazyWriter()
+		if err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if err := writeHeader(plumbing.BlobObject, size); err != nil {
+			return plumbing.ZeroHash, err
+		}
+		// A short copy leaves the object unwritten rather than storing a
+		// truncated one under the hash of what was read
+		if n, err := io.CopyN(w, src, size); err != nil {
+			return plumbing.ZeroHash, fmt.Errorf("file changed while saving (%d of %d bytes read): %w", n, size, err)
+		}
+		if err := w.Close(); err != nil {
+			return plumbing.ZeroHash, err
+		}
+		if hashed, ok := w.(interface{ Hash() plumbing.Hash }); ok {
+			return hashed.Hash(), nil
+		}
+		return plumbing.ZeroHash, fmt.Errorf("object writer did not report a hash")
+	}
+
+	obj := repo.Storer.NewEncodedObject()
+	obj.SetType(plumbing.BlobObject)
+	obj.SetSize(size)
+	w, err := obj.Writer()
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	if _, err := io.CopyN(w, src, size); err != nil {
+		w.Close()
+		return plumbing.ZeroHash, err
+	}
+	if err := w.Close(); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	return repo.Storer.SetEncodedObject(obj)
+}
+
+// MaxInMemorySize is the largest work file read into memory to be stored
+// as a delta or packed; larger ones stream into the history whole
+var MaxInMemorySize int64 = 64 << 20
+
+// stageWorkFile stores the tracked file as a blob streamed from the work
+// tree. Binary files from binaryDeltaMinSize up to MaxInMemorySize go
+// through memory to be stored as deltas.
+func (r *Repo) stageWorkFile(repo *git.Repository) (plumbing.Hash, error) {
+	var blob plumbing.Hash
+	err := r.stableRead(func() (err error) {
+		blob, err = r.streamWorkFile(repo)
+		return err
+	})
+	return blob, err
+}
+
+// streamWorkFile makes one attempt at stageWorkFile
+func (r *Repo) streamWorkFile(repo *git.Repository) (plumbing.Hash, error) {
+	f, err := r.workFS.Open(r.FileName)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	defer f.Close()
+	info, err := r.workFS.Stat(r.FileName)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	src := bufio.NewReaderSize(f, 8000)
+	if info.Size() >= binaryDeltaMinSize && info.Size() <= MaxInMemorySize {
+		if head, _ := src.Peek(8000); eol.IsBinary(head) {
+			content, err := io.ReadAll(src)
+			if err != nil {
+				return plumbing.ZeroHash, err
+			}
+			content = r.deltaContent(repo, content)
+			return writeBlob(repo, bytes.NewReader(content), int64(len(content)))
+		}
+	}
+	return writeBlob(repo, src, info.Size())
+}
+
+// headBlob returns the blob of the tracked file at HEAD, or the zero hash
+// before the first commit
+func (r *Repo) headBlob(repo *git.Repository) plumbing.Hash {
+	file, err := r.headFile(repo)
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return file.Hash
+}
+
+// headHash returns the commit HEAD points to, or the zero hash before the
+// first commit
+func headHash(repo *git.Repository) plumbing.Hash {
+	head, err := repo.Head()
+	if err != nil {
+		return plumbing.ZeroHash
+	}
+	return head.Hash()
+}
+
+// commitFile returns the tracked file in commit. Commits made before the
+// file was renamed hold it under its old name, as the tree's only entry.
+func (r *Repo) commitFile(commit *object.Commit) (*object.File, error) {
+	file, err := commit.File(r.FileName)
+	if err != object.ErrFileNotFound {
+		return file, err
+	}
+	tree, terr := commit.Tree()
+	if terr != nil || len(tree.Entries) != 1 || !tree.Entries[0].Mode.IsFile() {
+		return nil, err
+	}
+	return tree.TreeEntryFile(&tree.Entries[0])
+}
+
+// commitBlob commits blob as the tracked file on top of parent, the zero
+// hash for the first commit, and moves the branch to the new commit. The
+// branch only moves if it still points to parent; otherwise ErrHeadMoved
+// is returned and the new commit is left unreferenced.
+func (r *Repo) commitBlob(repo *git.Repository, blob, parent plumbing.Hash, message string, when time.Time) (plumbing.Hash, error) {
+	defer timing.Start(timing.Commit)()
+
+	tree := &object.Tree{Entries: []object.TreeEntry{{Name: r.FileName, Mode: filemode.Regular, Hash: blob}}}
+	treeObj := repo.Storer.NewEncodedObject()
+	if err := tree.Encode(treeObj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	treeHash, err := repo.Storer.SetEncodedObject(treeObj)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	sig := object.Signature{Name: "oops", Email: "oops@local", When: when}
+	commit := &object.Commit{Author: sig, Committer: sig, Message: message, TreeHash: treeHash}
+	if !parent.IsZero() {
+		commit.ParentHashes = []plumbing.Hash{parent}
+	}
+	commitObj := repo.Storer.NewEncodedObject()
+	if err := commit.Encode(commitObj); err != nil {
+		return plumbing.ZeroHash, err
+	}
+	hash, err := repo.Storer.SetEncodedObject(commitObj)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+
+	branch, err := r.branch(repo)
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	var expected *plumbing.Reference
+	if !parent.IsZero() {
+		expected = plumbing.NewHashReference(branch, parent)
+	}
+	err = repo.Storer.CheckAndSetReference(plumbing.NewHashReference(branch, hash), expected)
+	if errors.Is(err, storage.ErrReferenceHasChanged) {
+		return plumbing.ZeroHash, ErrHeadMoved
+	}
+	if err != nil {
+		return plumbing.ZeroHash, err
+	}
+	r.RemoveCheckout() // verify reports the copy if it stays behind
+	return hash, nil
+}
+
+// checkoutPath returns where histories made by earlier versions kept their
+// own copy of the tracked file
+func (r *Repo) checkoutPath() string {
+	return filepath.Join(r.GitDir, r.FileName)
+}
+
+// HasCheckout reports whether the repository still holds the copy of the
+// file, and its index, that earlier versions kept beside the history
+func (r *Repo) HasCheckout() bool {
+	if r.inMemory {
+		return false
+	}
+	_, err := os.Lstat(r.checkoutPath())
+	return err == nil
+}
+
+// RemoveCheckout deletes the copy of the file earlier versions kept
+// beside the history
+func (r *Repo) RemoveCheckout() error {
+	if r.inMemory {
+		return nil
+	}
+	if err := os.Remove(r.checkoutPath()); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if err := os.Remove(filepath.Join(r.GitDir, ".git", "index")); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
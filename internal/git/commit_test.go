@@ -0,0 +1,96 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestCommitKeepsNoCopy(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	// A copy left beside the history by an earlier version goes away
+	os.WriteFile(filepath.Join(repo.GitDir, repo.FileName), []byte("old copy"), 0644)
+	if !repo.HasCheckout() {
+		t.Fatal("HasCheckout = false with a copy present")
+	}
+
+	if err := repo.Add(); err != nil {
+		t.Fatalf("Add failed: %v", err)
+	}
+	if _, err := repo.Commit("first"); err != nil {
+		t.Fatalf("Commit failed: %v", err)
+	}
+	repo.Tag("v1")
+	if repo.HasCheckout() {
+		t.Error("the history still holds a copy of the file after a commit")
+	}
+
+	// Committing the same content again is refused
+	if err := repo.Add(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("again"); err == nil {
+		t.Error("Commit of unchanged content should fail")
+	}
+
+	// A large file streams in and reads back whole
+	large := bytes.Repeat([]byte("0123456789abcdef\n"), 1<<16)
+	os.WriteFile(filepath.Join(tmpDir, repo.FileName), large, 0644)
+	if err := repo.Add(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("large"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Tag("v2")
+	content, err := repo.Show("v2")
+	if err != nil || !bytes.Equal(content, large) {
+		t.Errorf("Show(v2) = %d bytes, %v; want %d bytes", len(content), err, len(large))
+	}
+	if changed, _ := repo.HasChanges(); changed {
+		t.Error("HasChanges = true right after a commit")
+	}
+	if first, _ := repo.Show("v1"); string(first) != "initial content" {
+		t.Errorf("Show(v1) = %q", first)
+	}
+}
+
+func TestCommitOntoMovedHead(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo.Init()
+	repo.Add()
+	first, err := repo.CommitOnto("", "first")
+	if err != nil {
+		t.Fatalf("CommitOnto on an empty history failed: %v", err)
+	}
+
+	// Another save lands after first was read as the head
+	path := filepath.Join(tmpDir, repo.FileName)
+	os.WriteFile(path, []byte("theirs"), 0644)
+	repo.Add()
+	theirs, err := repo.Commit("theirs")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(path, []byte("ours"), 0644)
+	repo.Add()
+	if _, err := repo.CommitOnto(first, "ours"); err != ErrHeadMoved {
+		t.Fatalf("CommitOnto a stale head = %v, want ErrHeadMoved", err)
+	}
+	if head, _ := repo.HeadCommit(); head != theirs {
+		t.Errorf("HEAD = %s after a refused commit, want %s", head, theirs)
+	}
+
+	repo.Add()
+	if _, err := repo.CommitOnto(theirs, "ours"); err != nil {
+		t.Errorf("CommitOnto the current head failed: %v", err)
+	}
+}
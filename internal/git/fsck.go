@@ -0,0 +1,134 @@
+package git
+
+import (
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// CheckObjects reads every object reachable from HEAD and the tags,
+// checking that it exists and that its content matches its hash, then
+// reads back the file of every vN tag. Returns a description of each
+// problem found.
+func (r *Repo) CheckObjects() ([]string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	var queue []plumbing.Hash
+	refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			queue = append(queue, ref.Hash())
+		}
+		return nil
+	})
+
+	var problems []string
+	seen := map[plumbing.Hash]bool{}
+	for len(queue) > 0 {
+		hash := queue[0]
+		queue = queue[1:]
+		if seen[hash] {
+			continue
+		}
+		seen[hash] = true
+
+		obj, err := checkObject(repo, hash)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("object %s: %v", hash.String()[:7], err))
+			continue
+		}
+		switch obj.Type() {
+		case plumbing.CommitObject:
+			commit, err := object.DecodeCommit(repo.Storer, obj)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("commit %s: %v", hash.String()[:7], err))
+				continue
+			}
+			queue = append(queue, commit.TreeHash)
+			queue = append(queue, commit.ParentHashes...)
+		case plumbing.TreeObject:
+			tree, err := object.DecodeTree(repo.Storer, obj)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("tree %s: %v", hash.String()[:7], err))
+				continue
+			}
+			for _, entry := range tree.Entries {
+				queue = append(queue, entry.Hash)
+			}
+		}
+	}
+
+	tags, err := r.VersionTags()
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(tags))
+	for name := range tags {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if err := r.checkVersion(repo, plumbing.NewHash(tags[name])); err != nil {
+			problems = append(problems, fmt.Sprintf("%s: %v", name, err))
+		}
+	}
+	return problems, nil
+}
+
+// checkObject reads the object named hash and checks that its content
+// hashes to that name
+func checkObject(repo *git.Repository, hash plumbing.Hash) (plumbing.EncodedObject, error) {
+	obj, err := repo.Storer.EncodedObject(plumbing.AnyObject, hash)
+	if err != nil {
+		return nil, err
+	}
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, err
+	}
+	if actual := plumbing.ComputeHash(obj.Type(), data); actual != hash {
+		return nil, fmt.Errorf("content hashes to %s", actual.String()[:7])
+	}
+	return obj, nil
+}
+
+// checkVersion reads the tracked file of commit hash, following deltas
+func (r *Repo) checkVersion(repo *git.Repository, hash plumbing.Hash) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return err
+	}
+	_, err = readFileContent(repo, file)
+	return err
+}
+
+// headFile returns the tracked file as committed at HEAD
+func (r *Repo) headFile(repo *git.Repository) (*object.File, error) {
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(head.Hash())
+	if err != nil {
+		return nil, err
+	}
+	return r.commitFile(commit)
+}
@@ -0,0 +1,57 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRepoCheckObjects(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := repo.AddContent([]byte("first")); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.Commit("first"); err != nil {
+		t.Fatal(err)
+	}
+	repo.Tag("v1")
+
+	if problems, err := repo.CheckObjects(); err != nil || len(problems) != 0 {
+		t.Fatalf("CheckObjects = %v, %v", problems, err)
+	}
+	if repo.HasCheckout() {
+		t.Fatal("a commit left a copy of the file beside the history")
+	}
+
+	// Replace the stored content with other content under the same name
+	content, err := repo.Show("v1")
+	if err != nil || string(content) != "first" {
+		t.Fatalf("Show = %q, %v", content, err)
+	}
+	file, _ := repo.headFile(repo.repo)
+	hash := file.Hash.String()
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte("blob 6\x00second"))
+	w.Close()
+	path := filepath.Join(repo.GitDir, ".git", "objects", hash[:2], hash[2:])
+	os.Chmod(path, 0644)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+	repo.repo = nil
+
+	problems, err := repo.CheckObjects()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) == 0 {
+		t.Error("CheckObjects found no problem with tampered content")
+	}
+}
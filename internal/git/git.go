@@ -6,30 +6,59 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"slices"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/go-git/go-billy/v5"
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/osfs"
+	"github.com/go-git/go-billy/v5/util"
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
 	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/go-git/go-git/v5/storage/memory"
+	"github.com/iyulab/oops/internal/compress"
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/textenc"
+	"github.com/iyulab/oops/internal/timing"
 	"github.com/sergi/go-diff/diffmatchpatch"
 )
 
 // Repo represents a Git repository for a single file
 type Repo struct {
-	GitDir   string // .oops/filename.git
-	WorkTree string // directory containing the file
-	FileName string // the tracked file name
-	repo     *git.Repository
+	GitDir      string // .oops/filename.git
+	WorkTree    string // directory containing the file
+	FileName    string // the tracked file name
+	IgnoreEOL   bool   // Diff ignores line ending differences
+	Context     int    // lines of context around changes in Diff, DefaultContext from NewRepo
+	SyncMeta    bool   // WriteMeta flushes to stable storage before returning
+	MaxDiffSize int64  // Diff summarizes files larger than this instead of comparing lines, 0 for no limit
+	workFS      billy.Filesystem
+	inMemory    bool
+	memMeta     map[string][]byte
+	staged      plumbing.Hash // blob stored by Add or AddContent for the next Commit
+	clean       func([]byte) []byte
+	smudge      func([]byte) []byte
+	repo        *git.Repository
 }
 
+// DefaultContext is the number of unchanged lines shown around changes in
+// unified diffs, as in diff -u
+const DefaultContext = 3
+
+// metaDirName is the directory inside .git holding oops metadata files
+const metaDirName = "oops"
+
 // Snapshot represents a version snapshot
 type Snapshot struct {
 	Number    int
 	Message   string
 	Timestamp time.Time
 	Hash      string
+	Packed    bool // stored with maximum compression to fit the size cap (set by Save)
 }
 
 // NewRepo creates a new Repo instance
@@ -38,15 +67,43 @@ func NewRepo(gitDir, workTree, fileName string) *Repo {
 		GitDir:   gitDir,
 		WorkTree: workTree,
 		FileName: fileName,
+		Context:  DefaultContext,
+		workFS:   osfs.New(workTree),
+	}
+}
+
+// NewMemoryRepo creates a Repo whose history lives entirely in memory.
+// The tracked file is read from and restored to workFS.
+func NewMemoryRepo(workFS billy.Filesystem, fileName string) *Repo {
+	return &Repo{
+		WorkTree: workFS.Root(),
+		FileName: fileName,
+		Context:  DefaultContext,
+		workFS:   workFS,
+		inMemory: true,
 	}
 }
 
+// InMemory reports whether the repository is memory-backed
+func (r *Repo) InMemory() bool {
+	return r.inMemory
+}
+
+// WorkFS returns the filesystem holding the tracked file
+func (r *Repo) WorkFS() billy.Filesystem {
+	return r.workFS
+}
+
 // openRepo opens the repository if not already open
 func (r *Repo) openRepo() (*git.Repository, error) {
 	if r.repo != nil {
 		return r.repo, nil
 	}
+	if r.inMemory {
+		return nil, git.ErrRepositoryNotExists
+	}
 
+	defer timing.Start(timing.RepoOpen)()
 	repo, err := git.PlainOpen(r.GitDir)
 	if err != nil {
 		return nil, err
@@ -57,6 +114,15 @@ func (r *Repo) openRepo() (*git.Repository, error) {
 
 // Init initializes a Git repository
 func (r *Repo) Init() error {
+	if r.inMemory {
+		repo, err := git.Init(memory.NewStorage(), memfs.New())
+		if err != nil {
+			return fmt.Errorf("git init failed: %w", err)
+		}
+		r.repo = repo
+		return nil
+	}
+
 	// Create directory if not exists
 	if err := os.MkdirAll(r.GitDir, 0755); err != nil {
 		return fmt.Errorf("failed to create git dir: %w", err)
@@ -74,71 +140,334 @@ func (r *Repo) Init() error {
 
 // Exists checks if the repository exists
 func (r *Repo) Exists() bool {
+	if r.inMemory {
+		return r.repo != nil
+	}
 	_, err := git.PlainOpen(r.GitDir)
 	return err == nil
 }
 
-// Add stages the tracked file
+// Discard drops an in-memory repository; it has no effect on disk repositories
+func (r *Repo) Discard() {
+	if r.inMemory {
+		r.repo = nil
+		r.memMeta = nil
+	}
+}
+
+// MetaDir returns the directory holding oops metadata for this repository
+func (r *Repo) MetaDir() string {
+	return filepath.Join(r.GitDir, ".git", metaDirName)
+}
+
+// ReadMeta reads a metadata file kept alongside the repository.
+// Returns an error satisfying os.IsNotExist if it has not been written.
+func (r *Repo) ReadMeta(name string) ([]byte, error) {
+	if r.inMemory {
+		data, ok := r.memMeta[name]
+		if !ok {
+			return nil, os.ErrNotExist
+		}
+		return data, nil
+	}
+	return os.ReadFile(filepath.Join(r.MetaDir(), name))
+}
+
+// WriteMeta writes a metadata file kept alongside the repository
+func (r *Repo) WriteMeta(name string, data []byte) error {
+	if r.inMemory {
+		if r.memMeta == nil {
+			r.memMeta = make(map[string][]byte)
+		}
+		r.memMeta[name] = data
+		return nil
+	}
+	if err := os.MkdirAll(r.MetaDir(), 0755); err != nil {
+		return err
+	}
+	if !r.SyncMeta {
+		return os.WriteFile(filepath.Join(r.MetaDir(), name), data, 0644)
+	}
+	f, err := os.OpenFile(filepath.Join(r.MetaDir(), name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// RemoveMeta deletes a metadata file; a missing file is not an error
+func (r *Repo) RemoveMeta(name string) error {
+	if r.inMemory {
+		delete(r.memMeta, name)
+		return nil
+	}
+	if err := os.Remove(filepath.Join(r.MetaDir(), name)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+// MetaNames returns the names of metadata files starting with prefix, sorted
+func (r *Repo) MetaNames(prefix string) ([]string, error) {
+	var names []string
+	if r.inMemory {
+		for name := range r.memMeta {
+			if strings.HasPrefix(name, prefix) {
+				names = append(names, name)
+			}
+		}
+	} else {
+		entries, err := os.ReadDir(r.MetaDir())
+		if err != nil && !os.IsNotExist(err) {
+			return nil, err
+		}
+		for _, entry := range entries {
+			if !entry.IsDir() && strings.HasPrefix(entry.Name(), prefix) {
+				names = append(names, entry.Name())
+			}
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// WorkFileExists checks if the tracked file is present in the work tree
+func (r *Repo) WorkFileExists() bool {
+	_, err := r.workFS.Stat(r.FileName)
+	return err == nil
+}
+
+// SetCleanFilter sets a transformation applied to the work file before it
+// is stored or compared with snapshots. Restores write stored content
+// back unchanged unless a smudge filter is set.
+func (r *Repo) SetCleanFilter(fn func([]byte) []byte) {
+	r.clean = fn
+}
+
+// HasCleanFilter reports whether the work file is transformed before it
+// is stored
+func (r *Repo) HasCleanFilter() bool {
+	return r.clean != nil
+}
+
+// SetSmudgeFilter sets a transformation applied to stored content when it
+// is restored to the work file
+func (r *Repo) SetSmudgeFilter(fn func([]byte) []byte) {
+	r.smudge = fn
+}
+
+// readWorkFile reads the tracked file from the work tree, in the form it
+// is stored (after the clean filter)
+func (r *Repo) readWorkFile() ([]byte, error) {
+	var content []byte
+	err := r.stableRead(func() (err error) {
+		content, err = util.ReadFile(r.workFS, r.FileName)
+		return err
+	})
+	if err != nil || r.clean == nil {
+		return content, err
+	}
+	return r.clean(content), nil
+}
+
+// ReadWorkFile returns the current content of the tracked file, in the
+// form it would be stored (after the clean filter)
+func (r *Repo) ReadWorkFile() ([]byte, error) {
+	return r.readWorkFile()
+}
+
+// writeWorkFile writes stored content to the tracked file in the work
+// tree, after the smudge filter. On disk the file is replaced atomically.
+func (r *Repo) writeWorkFile(content []byte) error {
+	if r.smudge != nil {
+		content = r.smudge(content)
+	}
+	if r.inMemory {
+		return util.WriteFile(r.workFS, r.FileName, content, 0644)
+	}
+	return replaceFile(filepath.Join(r.WorkTree, r.FileName), content)
+}
+
+// WriteWorkFileFrom replaces the tracked file with the content of src,
+// as it is and without holding it in memory. On disk the file is replaced
+// atomically.
+func (r *Repo) WriteWorkFileFrom(src io.Reader) error {
+	if r.inMemory {
+		content, err := io.ReadAll(src)
+		if err != nil {
+			return err
+		}
+		return util.WriteFile(r.workFS, r.FileName, content, 0644)
+	}
+	return replaceFileFrom(filepath.Join(r.WorkTree, r.FileName), src)
+}
+
+// Add stages the tracked file, streaming it into the object store
 func (r *Repo) Add() error {
+	defer timing.Start(timing.Stage)()
+
 	repo, err := r.openRepo()
 	if err != nil {
 		return err
 	}
 
-	wt, err := repo.Worktree()
+	if r.clean != nil {
+		content, err := r.readWorkFile()
+		if err != nil {
+			return err
+		}
+		return r.AddContent(content)
+	}
+	blob, err := r.stageWorkFile(repo)
 	if err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	r.staged = blob
+	return nil
+}
+
+// AddPacked stages the tracked file packed as compress.Pack would pack
+// it, streamed through a temporary file rather than memory. It returns
+// the packed size; if that is over limit, nothing is staged.
+func (r *Repo) AddPacked(limit int64) (int64, error) {
+	defer timing.Start(timing.Stage)()
+
+	repo, err := r.openRepo()
+	if err != nil {
+		return 0, err
+	}
+	tmp, err := os.CreateTemp("", "oops-pack-*")
+	if err != nil {
+		return 0, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	var size int64
+	err = r.stableRead(func() error {
+		if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+			return err
+		}
+		if err := tmp.Truncate(0); err != nil {
+			return err
+		}
+		f, err := r.workFS.Open(r.FileName)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		if err := compress.PackTo(tmp, f); err != nil {
+			return err
+		}
+		size, err = tmp.Seek(0, io.SeekCurrent)
 		return err
+	})
+	if err != nil || size > limit {
+		return size, err
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return 0, err
 	}
+	blob, err := writeBlob(repo, tmp, size)
+	if err != nil {
+		return 0, fmt.Errorf("failed to store file: %w", err)
+	}
+	r.staged = blob
+	return size, nil
+}
 
-	// Copy file from WorkTree to repo's worktree
-	srcPath := filepath.Join(r.WorkTree, r.FileName)
-	dstPath := filepath.Join(r.GitDir, r.FileName)
+// AddContent stages content as the tracked file, leaving the work tree
+// alone. Used to store content in a different form than the work file.
+func (r *Repo) AddContent(content []byte) error {
+	defer timing.Start(timing.Stage)()
 
-	if err := copyFile(srcPath, dstPath); err != nil {
-		return fmt.Errorf("failed to copy file: %w", err)
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
 	}
 
-	_, err = wt.Add(r.FileName)
-	return err
+	content = r.deltaContent(repo, content)
+	blob, err := writeBlob(repo, bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return fmt.Errorf("failed to store file: %w", err)
+	}
+	r.staged = blob
+	return nil
 }
 
-// Commit creates a new commit with the given message
+// Commit creates a new commit of the staged file with the given message
 func (r *Repo) Commit(message string) (string, error) {
 	repo, err := r.openRepo()
 	if err != nil {
 		return "", err
 	}
+	return r.commitStaged(repo, headHash(repo), message)
+}
 
-	wt, err := repo.Worktree()
+// CommitOnto is Commit on top of the commit with hash parent, "" before
+// the first commit. It returns ErrHeadMoved without committing if HEAD
+// has moved on from parent, e.g. because another process saved.
+func (r *Repo) CommitOnto(parent, message string) (string, error) {
+	repo, err := r.openRepo()
 	if err != nil {
 		return "", err
 	}
+	expected := plumbing.NewHash(parent)
+	if headHash(repo) != expected {
+		r.staged = plumbing.ZeroHash
+		return "", ErrHeadMoved
+	}
+	return r.commitStaged(repo, expected, message)
+}
+
+// commitStaged commits the blob staged by Add or AddContent onto parent
+func (r *Repo) commitStaged(repo *git.Repository, parent plumbing.Hash, message string) (string, error) {
+	blob := r.staged
+	r.staged = plumbing.ZeroHash
+	if blob.IsZero() || blob == r.headBlob(repo) {
+		return "", fmt.Errorf("no changes to save")
+	}
 
-	status, err := wt.Status()
+	hash, err := r.commitBlob(repo, blob, parent, message, time.Now())
 	if err != nil {
 		return "", err
 	}
+	return hash.String(), nil
+}
 
-	if status.IsClean() {
-		return "", fmt.Errorf("no changes to save")
+// CommitContent commits content as the tracked file without touching the
+// work tree, recording when as the snapshot time
+func (r *Repo) CommitContent(content []byte, message string, when time.Time) (string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return "", err
 	}
 
-	hash, err := wt.Commit(message, &git.CommitOptions{
-		Author: &object.Signature{
-			Name:  "oops",
-			Email: "oops@local",
-			When:  time.Now(),
-		},
-	})
+	content = r.deltaContent(repo, content)
+	blob, err := writeBlob(repo, bytes.NewReader(content), int64(len(content)))
 	if err != nil {
-		return "", err
+		return "", fmt.Errorf("failed to store file: %w", err)
 	}
 
+	hash, err := r.commitBlob(repo, blob, headHash(repo), message, when)
+	if err != nil {
+		return "", err
+	}
 	return hash.String(), nil
 }
 
 // Tag creates a tag for the given commit
 func (r *Repo) Tag(name string) error {
+	defer timing.Start(timing.Tag)()
+
 	repo, err := r.openRepo()
 	if err != nil {
 		return err
@@ -153,6 +482,170 @@ func (r *Repo) Tag(name string) error {
 	return err
 }
 
+// TagAt creates a tag pointing at the commit with the given full hash
+func (r *Repo) TagAt(name, hash string) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	_, err = repo.CreateTag(name, plumbing.NewHash(hash), nil)
+	return err
+}
+
+// DeleteTag removes a tag
+func (r *Repo) DeleteTag(name string) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	return repo.DeleteTag(name)
+}
+
+// VersionTags returns the full commit hash of every vN tag, by tag name
+func (r *Repo) VersionTags() (map[string]string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	tags, err := repo.Tags()
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string)
+	err = tags.ForEach(func(ref *plumbing.Reference) error {
+		name := ref.Name().Short()
+		if _, ok := ParseVersionTag(name); ok {
+			result[name] = ref.Hash().String()
+		}
+		return nil
+	})
+	return result, err
+}
+
+// ParseVersionTag returns N for a tag named vN
+func ParseVersionTag(name string) (int, bool) {
+	digits, ok := strings.CutPrefix(name, "v")
+	if !ok {
+		return 0, false
+	}
+	num, err := strconv.Atoi(digits)
+	if err != nil || num < 1 || strconv.Itoa(num) != digits {
+		return 0, false
+	}
+	return num, true
+}
+
+// CommitOrder returns the full hashes of the commits reachable from HEAD,
+// oldest first
+func (r *Repo) CommitOrder() ([]string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		return nil, err
+	}
+	commits, err := repo.Log(&git.LogOptions{From: head.Hash()})
+	if err != nil {
+		return nil, err
+	}
+
+	var hashes []string
+	err = commits.ForEach(func(c *object.Commit) error {
+		hashes = append(hashes, c.Hash.String())
+		return nil
+	})
+	slices.Reverse(hashes)
+	return hashes, err
+}
+
+// Verify checks that HEAD and every tag resolve to a commit containing the
+// tracked file, and that the file's content object is present. It is a
+// light subset of git fsck that avoids reading file content.
+func (r *Repo) Verify() error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+
+	head, err := repo.Head()
+	if err != nil {
+		return fmt.Errorf("HEAD: %w", err)
+	}
+	if err := r.verifyCommit(repo, head.Hash()); err != nil {
+		return fmt.Errorf("HEAD: %w", err)
+	}
+
+	tags, err := repo.Tags()
+	if err != nil {
+		return err
+	}
+	return tags.ForEach(func(ref *plumbing.Reference) error {
+		if err := r.verifyCommit(repo, ref.Hash()); err != nil {
+			return fmt.Errorf("%s: %w", ref.Name().Short(), err)
+		}
+		return nil
+	})
+}
+
+// verifyCommit checks that a commit and its copy of the tracked file exist
+func (r *Repo) verifyCommit(repo *git.Repository, hash plumbing.Hash) error {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return err
+	}
+	if err := repo.Storer.HasEncodedObject(file.Hash); err != nil {
+		return fmt.Errorf("content %s: %w", file.Hash.String()[:7], err)
+	}
+	return nil
+}
+
+// HasTag checks if a tag exists
+func (r *Repo) HasTag(name string) bool {
+	repo, err := r.openRepo()
+	if err != nil {
+		return false
+	}
+	_, err = repo.Tag(name)
+	return err == nil
+}
+
+// TagCommit returns the full hash of the commit a tag points to
+func (r *Repo) TagCommit(tag string) (string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return "", err
+	}
+	ref, err := repo.Tag(tag)
+	if err != nil {
+		return "", fmt.Errorf("tag not found: %s", tag)
+	}
+	return ref.Hash().String(), nil
+}
+
+// HeadCommit returns the full hash of the latest commit, or "" if there
+// are no commits yet
+func (r *Repo) HeadCommit() (string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return "", err
+	}
+	head, err := repo.Head()
+	if err != nil {
+		if err == plumbing.ErrReferenceNotFound {
+			return "", nil
+		}
+		return "", err
+	}
+	return head.Hash().String(), nil
+}
+
 // GetLatestTagNumber returns the highest tag number (vN format)
 func (r *Repo) GetLatestTagNumber() (int, error) {
 	repo, err := r.openRepo()
@@ -183,94 +676,204 @@ func (r *Repo) GetLatestTagNumber() (int, error) {
 	return maxNum, nil
 }
 
-// Checkout restores a file from a specific tag
-func (r *Repo) Checkout(tag string) error {
+// Show returns the tracked file content stored at a specific tag
+func (r *Repo) Show(tag string) ([]byte, error) {
 	repo, err := r.openRepo()
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get tag reference
 	ref, err := repo.Tag(tag)
 	if err != nil {
-		return fmt.Errorf("tag not found: %s", tag)
+		return nil, fmt.Errorf("tag not found: %s", tag)
 	}
 
 	// Get commit from tag
 	commit, err := repo.CommitObject(ref.Hash())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	// Get file from commit
-	file, err := commit.File(r.FileName)
+	file, err := r.commitFile(commit)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	// Read content
-	reader, err := file.Reader()
+	return readFileContent(repo, file)
+}
+
+// ShowCommit returns the tracked file content stored in the commit with
+// the given full hash, such as a fetched remote head
+func (r *Repo) ShowCommit(hash string) ([]byte, error) {
+	repo, err := r.openRepo()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer reader.Close()
+	commit, err := repo.CommitObject(plumbing.NewHash(hash))
+	if err != nil {
+		return nil, err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return nil, err
+	}
+	return readFileContent(repo, file)
+}
 
-	content, err := io.ReadAll(reader)
+// ForEachVersion calls fn with the number and stored content of every vN
+// tag, oldest first, stopping at the first error fn returns. A version with
+// the same content as the one before it is not read again.
+func (r *Repo) ForEachVersion(fn func(num int, content []byte) error) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	tags, err := r.VersionTags()
 	if err != nil {
 		return err
 	}
 
-	// Write to work tree
-	dstPath := filepath.Join(r.WorkTree, r.FileName)
-	return os.WriteFile(dstPath, content, 0644)
+	nums := make(map[int]string, len(tags))
+	for name, hash := range tags {
+		num, _ := ParseVersionTag(name)
+		nums[num] = hash
+	}
+	order := make([]int, 0, len(nums))
+	for num := range nums {
+		order = append(order, num)
+	}
+	sort.Ints(order)
+
+	var last plumbing.Hash
+	var content []byte
+	for _, num := range order {
+		commit, err := repo.CommitObject(plumbing.NewHash(nums[num]))
+		if err != nil {
+			return err
+		}
+		file, err := r.commitFile(commit)
+		if err != nil {
+			return err
+		}
+		if file.Hash != last || content == nil {
+			if content, err = readFileContent(repo, file); err != nil {
+				return err
+			}
+			last = file.Hash
+		}
+		if err := fn(num, content); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-// CheckoutHead restores the file to HEAD
-func (r *Repo) CheckoutHead() error {
+// StoredSize returns the size of the tracked file as stored at tag, and
+// whether it was stored with compress.Pack
+func (r *Repo) StoredSize(tag string) (int64, bool, error) {
 	repo, err := r.openRepo()
 	if err != nil {
-		return err
+		return 0, false, err
+	}
+	ref, err := repo.Tag(tag)
+	if err != nil {
+		return 0, false, fmt.Errorf("tag not found: %s", tag)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return 0, false, err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return 0, false, err
 	}
 
-	head, err := repo.Head()
+	reader, err := file.Reader()
 	if err != nil {
-		return err
+		return 0, false, err
 	}
+	defer reader.Close()
+	head := make([]byte, 16)
+	n, _ := io.ReadFull(reader, head)
+	return file.Size, compress.IsPacked(head[:n]), nil
+}
 
-	commit, err := repo.CommitObject(head.Hash())
+// Checkout restores a file from a specific tag
+func (r *Repo) Checkout(tag string) error {
+	defer timing.Start(timing.Restore)()
+
+	repo, err := r.openRepo()
 	if err != nil {
 		return err
 	}
+	ref, err := repo.Tag(tag)
+	if err != nil {
+		return fmt.Errorf("tag not found: %s", tag)
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return err
+	}
+	return r.checkoutFile(repo, file)
+}
 
-	file, err := commit.File(r.FileName)
+// ShowWork returns the file at tag as Checkout would write it, after the
+// smudge filter, without touching the work tree
+func (r *Repo) ShowWork(tag string) ([]byte, error) {
+	content, err := r.Show(tag)
+	if err != nil {
+		return nil, err
+	}
+	if r.smudge != nil {
+		content = r.smudge(content)
+	}
+	return content, nil
+}
+
+// CheckoutHead restores the file to HEAD
+func (r *Repo) CheckoutHead() error {
+	defer timing.Start(timing.Restore)()
+
+	repo, err := r.openRepo()
 	if err != nil {
 		return err
 	}
 
-	reader, err := file.Reader()
+	head, err := repo.Head()
 	if err != nil {
 		return err
 	}
-	defer reader.Close()
 
-	content, err := io.ReadAll(reader)
+	commit, err := repo.CommitObject(head.Hash())
 	if err != nil {
 		return err
 	}
 
-	dstPath := filepath.Join(r.WorkTree, r.FileName)
-	return os.WriteFile(dstPath, content, 0644)
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return err
+	}
+	return r.checkoutFile(repo, file)
 }
 
-// Diff returns the diff between working file and HEAD (or between two refs)
+// Diff returns the diff between working file and HEAD (or between two refs).
+// Binary files, and files larger than MaxDiffSize, get a one-line summary
+// instead of a line diff.
 func (r *Repo) Diff(refs ...string) (string, error) {
+	defer timing.Start(timing.Diff)()
+
 	repo, err := r.openRepo()
 	if err != nil {
 		return "", err
 	}
 
-	var oldContent, newContent string
-
+	var old, new diffSide
 	switch len(refs) {
 	case 0:
 		// Working file vs HEAD
@@ -278,130 +881,311 @@ func (r *Repo) Diff(refs ...string) (string, error) {
 		if err != nil {
 			return "", err
 		}
-		commit, err := repo.CommitObject(head.Hash())
-		if err != nil {
+		if old, err = r.commitSide(repo, head.Hash()); err != nil {
 			return "", err
 		}
-		file, err := commit.File(r.FileName)
-		if err != nil {
-			oldContent = ""
-		} else {
-			reader, _ := file.Reader()
-			content, _ := io.ReadAll(reader)
-			reader.Close()
-			oldContent = string(content)
-		}
-
-		// Read working file
-		workPath := filepath.Join(r.WorkTree, r.FileName)
-		workContent, err := os.ReadFile(workPath)
-		if err != nil {
+		if new, err = r.workSide(); err != nil {
 			return "", err
 		}
-		newContent = string(workContent)
 
 	case 1:
 		// Working file vs ref
-		ref, err := repo.Tag(refs[0])
-		if err != nil {
+		if old, err = r.tagSide(repo, refs[0]); err != nil {
 			return "", err
 		}
-		commit, err := repo.CommitObject(ref.Hash())
-		if err != nil {
+		if new, err = r.workSide(); err != nil {
 			return "", err
 		}
-		file, err := commit.File(r.FileName)
-		if err != nil {
-			oldContent = ""
-		} else {
-			reader, _ := file.Reader()
-			content, _ := io.ReadAll(reader)
-			reader.Close()
-			oldContent = string(content)
-		}
-
-		workPath := filepath.Join(r.WorkTree, r.FileName)
-		workContent, err := os.ReadFile(workPath)
-		if err != nil {
-			return "", err
-		}
-		newContent = string(workContent)
 
 	case 2:
 		// ref1 vs ref2
-		ref1, err := repo.Tag(refs[0])
-		if err != nil {
+		if old, err = r.tagSide(repo, refs[0]); err != nil {
 			return "", err
 		}
-		commit1, err := repo.CommitObject(ref1.Hash())
-		if err != nil {
+		if new, err = r.tagSide(repo, refs[1]); err != nil {
 			return "", err
 		}
-		file1, err := commit1.File(r.FileName)
-		if err != nil {
-			oldContent = ""
-		} else {
-			reader, _ := file1.Reader()
-			content, _ := io.ReadAll(reader)
-			reader.Close()
-			oldContent = string(content)
-		}
+	}
 
-		ref2, err := repo.Tag(refs[1])
-		if err != nil {
-			return "", err
+	if summary, ok, err := r.summarizeDiff(repo, old, new); err != nil || ok {
+		return summary, err
+	}
+	oldContent, err := r.sideContent(repo, old)
+	if err != nil {
+		return "", err
+	}
+	newContent, err := r.sideContent(repo, new)
+	if err != nil {
+		return "", err
+	}
+	return diffText(r.FileName, string(oldContent), string(newContent), r.IgnoreEOL, r.Context), nil
+}
+
+// tagSide describes the file at tag for Diff
+func (r *Repo) tagSide(repo *git.Repository, tag string) (diffSide, error) {
+	ref, err := repo.Tag(tag)
+	if err != nil {
+		return diffSide{}, err
+	}
+	return r.commitSide(repo, ref.Hash())
+}
+
+// commitSide describes the file in a commit for Diff; a commit without
+// the file compares as empty
+func (r *Repo) commitSide(repo *git.Repository, hash plumbing.Hash) (diffSide, error) {
+	commit, err := repo.CommitObject(hash)
+	if err != nil {
+		return diffSide{}, err
+	}
+	file, err := r.commitFile(commit)
+	if err != nil {
+		return diffSide{}, nil
+	}
+	return storedSide(repo, file)
+}
+
+// DiffContent returns a unified diff between two versions of a file's
+// content, with DefaultContext lines of context
+func DiffContent(filename, oldContent, newContent string) string {
+	return diffText(filename, oldContent, newContent, false, DefaultContext)
+}
+
+// DiffContentContext is DiffContent with context lines of context
+func DiffContentContext(filename, oldContent, newContent string, context int) string {
+	return diffText(filename, oldContent, newContent, false, context)
+}
+
+// diffText decodes both versions to UTF-8 and returns their unified diff,
+// or "" if they are the same. The stored bytes are never changed; only the
+// displayed text is transcoded.
+func diffText(filename, oldContent, newContent string, ignoreEOL bool, context int) string {
+	if oldContent == newContent {
+		return ""
+	}
+
+	oldText, oldEnc := textenc.Decode([]byte(oldContent))
+	newText, newEnc := textenc.Decode([]byte(newContent))
+	if ignoreEOL {
+		oldText = string(eol.ToLF([]byte(oldText)))
+		newText = string(eol.ToLF([]byte(newText)))
+	}
+	if oldText == newText && oldEnc == newEnc {
+		return ""
+	}
+
+	return generateUnifiedDiff(diffLabel("a/"+filename, oldEnc), diffLabel("b/"+filename, newEnc), oldText, newText, context)
+}
+
+// DiffOther returns the diff from old, a version of the tracked file, to
+// the content of another file named otherName, or "" if they are the same
+func (r *Repo) DiffOther(otherName string, old, other []byte) string {
+	if bytes.Equal(old, other) {
+		return ""
+	}
+	oldText, oldEnc := textenc.Decode(old)
+	newText, newEnc := textenc.Decode(other)
+	if r.IgnoreEOL {
+		oldText = string(eol.ToLF([]byte(oldText)))
+		newText = string(eol.ToLF([]byte(newText)))
+	}
+	if oldText == newText && oldEnc == newEnc {
+		return ""
+	}
+	return generateUnifiedDiff(diffLabel("a/"+r.FileName, oldEnc), diffLabel(otherName, newEnc), oldText, newText, r.Context)
+}
+
+// diffLabel names one side of a diff, noting encodings other than UTF-8
+func diffLabel(name string, enc textenc.Encoding) string {
+	if enc == textenc.UTF8 {
+		return name
+	}
+	return fmt.Sprintf("%s (%s)", name, enc)
+}
+
+// CountLineChanges returns how many lines were added and removed going
+// from oldContent to newContent, comparing whole lines
+func CountLineChanges(oldContent, newContent string) (added, removed int) {
+	for _, e := range LineEdits(oldContent, newContent) {
+		switch e.Op {
+		case EditInsert:
+			added += e.Lines
+		case EditDelete:
+			removed += e.Lines
 		}
-		commit2, err := repo.CommitObject(ref2.Hash())
-		if err != nil {
-			return "", err
+	}
+	return added, removed
+}
+
+// Line edit operations
+const (
+	EditEqual  = '='
+	EditDelete = '-'
+	EditInsert = '+'
+)
+
+// LineEdit is a run of lines kept, deleted or inserted by a line diff
+type LineEdit struct {
+	Op    byte // EditEqual, EditDelete or EditInsert
+	Lines int
+}
+
+// LineEdits returns the line diff from oldContent to newContent as runs
+// of whole lines, in order. Deletions come before the insertions that
+// replace them.
+func LineEdits(oldContent, newContent string) []LineEdit {
+	if oldContent == newContent {
+		if n := countLines(oldContent); n > 0 {
+			return []LineEdit{{EditEqual, n}}
 		}
-		file2, err := commit2.File(r.FileName)
-		if err != nil {
-			newContent = ""
-		} else {
-			reader, _ := file2.Reader()
-			content, _ := io.ReadAll(reader)
-			reader.Close()
-			newContent = string(content)
+		return nil
+	}
+	oldText, _ := textenc.Decode([]byte(oldContent))
+	newText, _ := textenc.Decode([]byte(newContent))
+
+	dmp := diffmatchpatch.New()
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldText, newText)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var edits []LineEdit
+	for _, diff := range diffs {
+		op := byte(EditEqual)
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			op = EditInsert
+		case diffmatchpatch.DiffDelete:
+			op = EditDelete
 		}
+		edits = append(edits, LineEdit{op, countLines(diff.Text)})
 	}
+	return edits
+}
 
-	if oldContent == newContent {
-		return "", nil
+// countLines counts the lines of text, including a last line without a
+// trailing newline
+func countLines(text string) int {
+	n := strings.Count(text, "\n")
+	if text != "" && !strings.HasSuffix(text, "\n") {
+		n++
 	}
+	return n
+}
 
-	return generateUnifiedDiff(r.FileName, oldContent, newContent), nil
+// diffLine is one line of a line diff, with its newline if it has one
+type diffLine struct {
+	op   byte // EditEqual, EditDelete or EditInsert
+	text string
 }
 
-// generateUnifiedDiff creates a unified diff output
-func generateUnifiedDiff(filename, oldContent, newContent string) string {
+// diffLines compares oldText and newText line by line
+func diffLines(oldText, newText string) []diffLine {
 	dmp := diffmatchpatch.New()
-	diffs := dmp.DiffMain(oldContent, newContent, true)
+	oldChars, newChars, lines := dmp.DiffLinesToChars(oldText, newText)
+	diffs := dmp.DiffCharsToLines(dmp.DiffMain(oldChars, newChars, false), lines)
+
+	var out []diffLine
+	for _, diff := range diffs {
+		op := byte(EditEqual)
+		switch diff.Type {
+		case diffmatchpatch.DiffInsert:
+			op = EditInsert
+		case diffmatchpatch.DiffDelete:
+			op = EditDelete
+		}
+		text := diff.Text
+		for text != "" {
+			line := text
+			if i := strings.IndexByte(text, '\n'); i >= 0 {
+				line = text[:i+1]
+			}
+			out = append(out, diffLine{op, line})
+			text = text[len(line):]
+		}
+	}
+	return out
+}
+
+// generateUnifiedDiff creates a unified diff in the format of diff -u,
+// with context lines of context around each hunk, which patch can apply
+func generateUnifiedDiff(oldLabel, newLabel, oldContent, newContent string, context int) string {
+	if context < 0 {
+		context = 0
+	}
+	lines := diffLines(oldContent, newContent)
+
+	// oldAt[i] and newAt[i] count the lines of each side before lines[i]
+	oldAt := make([]int, len(lines)+1)
+	newAt := make([]int, len(lines)+1)
+	for i, l := range lines {
+		oldAt[i+1], newAt[i+1] = oldAt[i], newAt[i]
+		if l.op != EditInsert {
+			oldAt[i+1]++
+		}
+		if l.op != EditDelete {
+			newAt[i+1]++
+		}
+	}
 
 	var buf bytes.Buffer
-	buf.WriteString(fmt.Sprintf("--- a/%s\n", filename))
-	buf.WriteString(fmt.Sprintf("+++ b/%s\n", filename))
+	buf.WriteString(fmt.Sprintf("--- %s\n", oldLabel))
+	buf.WriteString(fmt.Sprintf("+++ %s\n", newLabel))
 
-	for _, diff := range diffs {
-		lines := strings.Split(diff.Text, "\n")
-		for i, line := range lines {
-			if i == len(lines)-1 && line == "" {
+	for i := 0; i < len(lines); {
+		if lines[i].op == EditEqual {
+			i++
+			continue
+		}
+
+		// A hunk runs from context lines before the first change to context
+		// lines after the last, taking in changes closer than that
+		start, end := max(0, i-context), i
+		for {
+			for end < len(lines) && lines[end].op != EditEqual {
+				end++
+			}
+			next := end
+			for next < len(lines) && lines[next].op == EditEqual {
+				next++
+			}
+			if next < len(lines) && next-end <= 2*context {
+				end = next
 				continue
 			}
-			switch diff.Type {
-			case diffmatchpatch.DiffEqual:
-				buf.WriteString(fmt.Sprintf(" %s\n", line))
-			case diffmatchpatch.DiffDelete:
-				buf.WriteString(fmt.Sprintf("-%s\n", line))
-			case diffmatchpatch.DiffInsert:
-				buf.WriteString(fmt.Sprintf("+%s\n", line))
+			end = min(len(lines), end+context)
+			break
+		}
+
+		buf.WriteString(fmt.Sprintf("@@ -%s +%s @@\n",
+			hunkRange(oldAt[start], oldAt[end]-oldAt[start]), hunkRange(newAt[start], newAt[end]-newAt[start])))
+		for _, l := range lines[start:end] {
+			prefix := byte(' ')
+			if l.op != EditEqual {
+				prefix = l.op
+			}
+			buf.WriteByte(prefix)
+			buf.WriteString(strings.TrimSuffix(l.text, "\n"))
+			buf.WriteByte('\n')
+			if !strings.HasSuffix(l.text, "\n") {
+				buf.WriteString("\\ No newline at end of file\n")
 			}
 		}
+		i = end
 	}
 
 	return buf.String()
 }
 
+// hunkRange formats the lines of one side of a hunk, starting after line
+// before, as diff -u does: "start,count", or just "start" for one line
+func hunkRange(before, count int) string {
+	switch count {
+	case 0:
+		return fmt.Sprintf("%d,0", before)
+	case 1:
+		return fmt.Sprintf("%d", before+1)
+	}
+	return fmt.Sprintf("%d,%d", before+1, count)
+}
+
 // Log returns commit history
 func (r *Repo) Log() ([]Snapshot, error) {
 	repo, err := r.openRepo()
@@ -457,6 +1241,8 @@ func (r *Repo) Log() ([]Snapshot, error) {
 
 // HasChanges checks if working file differs from HEAD
 func (r *Repo) HasChanges() (bool, error) {
+	defer timing.Start(timing.Status)()
+
 	repo, err := r.openRepo()
 	if err != nil {
 		return false, err
@@ -473,26 +1259,22 @@ func (r *Repo) HasChanges() (bool, error) {
 		return false, err
 	}
 
-	file, err := commit.File(r.FileName)
+	file, err := r.commitFile(commit)
 	if err != nil {
 		// File not in commit, so yes there are changes
 		return true, nil
 	}
-
-	reader, err := file.Reader()
-	if err != nil {
-		return false, err
+	if changed, ok, err := r.workChanged(repo, file); err != nil || ok {
+		return changed, err
 	}
-	defer reader.Close()
 
-	commitContent, err := io.ReadAll(reader)
+	commitContent, err := readFileContent(repo, file)
 	if err != nil {
 		return false, err
 	}
 
 	// Read working file
-	workPath := filepath.Join(r.WorkTree, r.FileName)
-	workContent, err := os.ReadFile(workPath)
+	workContent, err := r.readWorkFile()
 	if err != nil {
 		return false, err
 	}
@@ -541,25 +1323,18 @@ func (r *Repo) GetFilePath() string {
 	return filepath.Join(r.WorkTree, r.FileName)
 }
 
-// copyFile copies a file from src to dst
-func copyFile(src, dst string) error {
-	// Ensure parent directory exists
-	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
-		return err
-	}
-
-	sourceFile, err := os.Open(src)
+// readFileContent reads a committed file, unpacking content that was
+// stored with compress.Pack or as a binary delta
+func readFileContent(repo *git.Repository, file *object.File) ([]byte, error) {
+	reader, err := file.Reader()
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer sourceFile.Close()
+	defer reader.Close()
 
-	destFile, err := os.Create(dst)
+	content, err := io.ReadAll(reader)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	defer destFile.Close()
-
-	_, err = io.Copy(destFile, sourceFile)
-	return err
+	return storedContent(repo, content)
 }
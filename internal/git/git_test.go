@@ -1,9 +1,16 @@
 package git
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/iyulab/oops/internal/compress"
 )
 
 func setupTestRepo(t *testing.T) (*Repo, string, func()) {
@@ -243,3 +250,202 @@ func TestRepoCheckoutHead(t *testing.T) {
 		t.Errorf("Content = %q, want %q", string(content), "initial content")
 	}
 }
+
+// --- Memory Repo Tests ---
+
+func TestMemoryRepoCheckout(t *testing.T) {
+	fs := memfs.New()
+	util.WriteFile(fs, "test.txt", []byte("version 1"), 0644)
+
+	repo := NewMemoryRepo(fs, "test.txt")
+	if repo.Exists() {
+		t.Error("Memory repo should not exist before Init")
+	}
+
+	if err := repo.Init(); err != nil {
+		t.Fatalf("Init failed: %v", err)
+	}
+	repo.Add()
+	repo.Commit("v1")
+	repo.Tag("v1")
+
+	util.WriteFile(fs, "test.txt", []byte("version 2"), 0644)
+	hasChanges, _ := repo.HasChanges()
+	if !hasChanges {
+		t.Error("Should have changes after modification")
+	}
+	repo.Add()
+	repo.Commit("v2")
+	repo.Tag("v2")
+
+	if err := repo.Checkout("v1"); err != nil {
+		t.Fatalf("Checkout failed: %v", err)
+	}
+
+	content, _ := util.ReadFile(fs, "test.txt")
+	if string(content) != "version 1" {
+		t.Errorf("Content = %q, want %q", string(content), "version 1")
+	}
+
+	repo.Discard()
+	if repo.Exists() {
+		t.Error("Memory repo should not exist after Discard")
+	}
+}
+
+func TestCountLineChanges(t *testing.T) {
+	tests := []struct {
+		old, new       string
+		added, removed int
+	}{
+		{"a\nb\n", "a\nb\n", 0, 0},
+		{"", "a\nb\n", 2, 0},
+		{"a\nb\nc\n", "a\nx\nc\nd\n", 2, 1},
+		{"a\nb", "a\n", 0, 1},
+	}
+	for _, tt := range tests {
+		added, removed := CountLineChanges(tt.old, tt.new)
+		if added != tt.added || removed != tt.removed {
+			t.Errorf("CountLineChanges(%q, %q) = +%d -%d, want +%d -%d",
+				tt.old, tt.new, added, removed, tt.added, tt.removed)
+		}
+	}
+}
+
+func TestDiffContentTranscodes(t *testing.T) {
+	utf16le := func(s string) string {
+		out := []byte{0xFF, 0xFE}
+		for _, c := range []byte(s) {
+			out = append(out, c, 0)
+		}
+		return string(out)
+	}
+
+	diff := DiffContent("settings.ini", utf16le("a=1\nb=2\n"), utf16le("a=1\nb=3\n"))
+	if strings.ContainsRune(diff, 0) || !strings.Contains(diff, " a=1\n") {
+		t.Errorf("diff should show decoded text, got %q", diff)
+	}
+	if !strings.Contains(diff, "--- a/settings.ini (UTF-16LE)") {
+		t.Errorf("diff should name the encoding, got %q", diff)
+	}
+
+	// Same text in another encoding is still a change
+	if DiffContent("settings.ini", "a=1\n", utf16le("a=1\n")) == "" {
+		t.Error("an encoding change should be reported")
+	}
+}
+
+func TestUnifiedDiffHunks(t *testing.T) {
+	var oldLines, newLines []string
+	for i := 1; i <= 20; i++ {
+		line := fmt.Sprintf("line %d", i)
+		oldLines = append(oldLines, line)
+		switch i {
+		case 2:
+			newLines = append(newLines, "changed 2")
+		case 15:
+			// removed
+		default:
+			newLines = append(newLines, line)
+		}
+	}
+	oldText := strings.Join(oldLines, "\n") + "\n"
+	newText := strings.Join(newLines, "\n") + "\n"
+
+	want := `--- a/f
++++ b/f
+@@ -1,5 +1,5 @@
+ line 1
+-line 2
++changed 2
+ line 3
+ line 4
+ line 5
+@@ -12,7 +12,6 @@
+ line 12
+ line 13
+ line 14
+-line 15
+ line 16
+ line 17
+ line 18
+`
+	if got := generateUnifiedDiff("a/f", "b/f", oldText, newText, 3); got != want {
+		t.Errorf("diff =\n%s\nwant\n%s", got, want)
+	}
+
+	// Wide context joins the changes into one hunk
+	if got := generateUnifiedDiff("a/f", "b/f", oldText, newText, 10); strings.Count(got, "@@ ") != 1 ||
+		!strings.Contains(got, "@@ -1,20 +1,19 @@") {
+		t.Errorf("diff with context 10 =\n%s", got)
+	}
+
+	// No context
+	if got := generateUnifiedDiff("a/f", "b/f", oldText, newText, 0); !strings.Contains(got, "@@ -2 +2 @@\n-line 2\n+changed 2\n@@ -15 +14,0 @@\n-line 15\n") {
+		t.Errorf("diff with context 0 =\n%s", got)
+	}
+}
+
+func TestUnifiedDiffMissingNewline(t *testing.T) {
+	got := generateUnifiedDiff("a/f", "b/f", "a\nb", "a\nb\nc\n", 3)
+	want := "--- a/f\n+++ b/f\n@@ -1,2 +1,3 @@\n a\n-b\n\\ No newline at end of file\n+b\n+c\n"
+	if got != want {
+		t.Errorf("diff =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestRepoForEachVersion(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	testFilePath := filepath.Join(tmpDir, "test.txt")
+
+	repo.Init()
+	for i, content := range []string{"one", "two", "two"} {
+		os.WriteFile(testFilePath, []byte(content), 0644)
+		repo.Add()
+		repo.Commit(content)
+		repo.Tag(fmt.Sprintf("v%d", i+1))
+	}
+
+	var got []string
+	err := repo.ForEachVersion(func(num int, content []byte) error {
+		got = append(got, fmt.Sprintf("%d:%s", num, content))
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEachVersion failed: %v", err)
+	}
+	if want := "1:one 2:two 3:two"; strings.Join(got, " ") != want {
+		t.Errorf("versions = %q, want %q", strings.Join(got, " "), want)
+	}
+}
+
+func TestRepoStoredSize(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+
+	repo.Init()
+	repo.Add()
+	repo.Commit("plain")
+	repo.Tag("v1")
+
+	packed, err := compress.Pack([]byte(strings.Repeat("packed ", 100)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := repo.CommitContent(packed, "packed", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	repo.Tag("v2")
+
+	if size, isPacked, err := repo.StoredSize("v1"); err != nil || size != int64(len("initial content")) || isPacked {
+		t.Errorf("StoredSize(v1) = %d, %v, %v", size, isPacked, err)
+	}
+	if size, isPacked, err := repo.StoredSize("v2"); err != nil || size != int64(len(packed)) || !isPacked {
+		t.Errorf("StoredSize(v2) = %d, %v, %v", size, isPacked, err)
+	}
+	if _, _, err := repo.StoredSize("v3"); err == nil {
+		t.Error("StoredSize(v3) found a missing tag")
+	}
+}
@@ -0,0 +1,35 @@
+package git
+
+// Hunk is one change between two texts: old lines [Start, End) replaced
+// by Lines
+type Hunk struct {
+	Start, End int
+	Old        []string // the old lines replaced
+	Lines      []string
+}
+
+// Hunks returns the changes turning from into to, in order. Lines keep
+// their newlines.
+func Hunks(from, to string) []Hunk {
+	fromLines := splitLines(from)
+	var hunks []Hunk
+	for _, h := range changeHunks(from, to) {
+		hunks = append(hunks, Hunk{Start: h.start, End: h.end, Old: fromLines[h.start:h.end], Lines: h.lines})
+	}
+	return hunks
+}
+
+// ApplyHunks returns from with hunks, some of those Hunks returned for
+// it, applied
+func ApplyHunks(from string, hunks []Hunk) string {
+	fromLines := splitLines(from)
+	changes := make([]mergeHunk, len(hunks))
+	for i, h := range hunks {
+		changes[i] = mergeHunk{start: h.Start, end: h.End, lines: h.Lines}
+	}
+	var out []byte
+	for _, line := range applyHunks(fromLines, 0, len(fromLines), changes) {
+		out = append(out, line...)
+	}
+	return string(out)
+}
@@ -0,0 +1,26 @@
+package git
+
+import "testing"
+
+func TestApplySomeHunks(t *testing.T) {
+	from := "one\ntwo\nthree\nfour\n"
+	to := "ONE\ntwo\nthree\nFOUR\nfive\n"
+
+	hunks := Hunks(from, to)
+	if len(hunks) != 2 {
+		t.Fatalf("Hunks = %+v, want 2", hunks)
+	}
+	if got := hunks[1].Old; len(got) != 1 || got[0] != "four\n" {
+		t.Errorf("second hunk replaces %q", got)
+	}
+
+	if got := ApplyHunks(from, hunks); got != to {
+		t.Errorf("all hunks = %q, want %q", got, to)
+	}
+	if got, want := ApplyHunks(from, hunks[1:]), "one\ntwo\nthree\nFOUR\nfive\n"; got != want {
+		t.Errorf("second hunk only = %q, want %q", got, want)
+	}
+	if got := ApplyHunks(from, nil); got != from {
+		t.Errorf("no hunks = %q", got)
+	}
+}
@@ -0,0 +1,266 @@
+package git
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/object"
+	"github.com/iyulab/oops/internal/compress"
+	"github.com/iyulab/oops/internal/eol"
+)
+
+// Files stored as they are can be compared and restored in chunks: the
+// work file is hashed as a blob and checked against the stored hash, and
+// restores copy the blob straight into the file. Content that is filtered,
+// packed or stored as a binary delta still passes through memory.
+
+// sniffLen is how much of a file is read to tell text from binary, as in
+// eol.IsBinary
+const sniffLen = 8000
+
+// workFileHash returns the blob hash and size of the work file, read in
+// chunks. It is only meaningful without a clean filter.
+func (r *Repo) workFileHash() (hash plumbing.Hash, size int64, err error) {
+	err = r.stableRead(func() (err error) {
+		hash, size, err = r.hashWorkFile()
+		return err
+	})
+	return hash, size, err
+}
+
+// hashWorkFile makes one attempt at workFileHash
+func (r *Repo) hashWorkFile() (plumbing.Hash, int64, error) {
+	f, err := r.workFS.Open(r.FileName)
+	if err != nil {
+		return plumbing.ZeroHash, 0, err
+	}
+	defer f.Close()
+	info, err := r.workFS.Stat(r.FileName)
+	if err != nil {
+		return plumbing.ZeroHash, 0, err
+	}
+
+	hasher := plumbing.NewHasher(plumbing.BlobObject, info.Size())
+	if n, err := io.Copy(hasher, f); err != nil {
+		return plumbing.ZeroHash, 0, err
+	} else if n != info.Size() {
+		return plumbing.ZeroHash, 0, fmt.Errorf("file changed while reading (%d of %d bytes)", n, info.Size())
+	}
+	return hasher.Sum(), info.Size(), nil
+}
+
+// blobPrefix returns up to n bytes from the start of a stored blob
+func blobPrefix(repo *git.Repository, file *object.File, n int) ([]byte, error) {
+	reader, err := file.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	prefix := make([]byte, n)
+	read, err := io.ReadFull(reader, prefix)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return prefix[:read], nil
+}
+
+// storedAsIs reports whether a blob holds the file's content unchanged,
+// neither packed nor a binary delta
+func storedAsIs(prefix []byte) bool {
+	return !compress.IsPacked(prefix) && !IsBinaryDelta(prefix)
+}
+
+// contentSize returns the size of the content a stored blob stands for.
+// A delta records its target size in its header; a packed blob is counted
+// at its stored size, which is less than the content's.
+func contentSize(file *object.File, prefix []byte) int64 {
+	if IsBinaryDelta(prefix) && len(prefix) > binaryDeltaHeader {
+		delta := bytes.NewReader(prefix[binaryDeltaHeader:])
+		if _, err := binary.ReadUvarint(delta); err == nil { // source size
+			if size, err := binary.ReadUvarint(delta); err == nil {
+				return int64(size)
+			}
+		}
+	}
+	return file.Size
+}
+
+// workChanged reports whether the work file differs from the stored file,
+// hashing it in chunks when both are kept as they are. ok is false when
+// the contents have to be compared in memory instead.
+func (r *Repo) workChanged(repo *git.Repository, file *object.File) (changed, ok bool, err error) {
+	if r.clean != nil {
+		return false, false, nil
+	}
+	hash, _, err := r.workFileHash()
+	if err != nil {
+		return false, false, err
+	}
+	if hash == file.Hash {
+		return false, true, nil
+	}
+	prefix, err := blobPrefix(repo, file, binaryDeltaHeader)
+	if err != nil {
+		return false, false, err
+	}
+	if storedAsIs(prefix) {
+		return true, true, nil
+	}
+	return false, false, nil
+}
+
+// checkoutFile restores a stored file to the work tree. A blob stored as
+// it is streams into the file; other content is unpacked in memory.
+func (r *Repo) checkoutFile(repo *git.Repository, file *object.File) error {
+	if !r.inMemory && r.smudge == nil {
+		prefix, err := blobPrefix(repo, file, binaryDeltaHeader)
+		if err != nil {
+			return err
+		}
+		if storedAsIs(prefix) {
+			reader, err := file.Reader()
+			if err != nil {
+				return err
+			}
+			defer reader.Close()
+			return replaceFileFrom(r.GetFilePath(), reader)
+		}
+	}
+
+	content, err := readFileContent(repo, file)
+	if err != nil {
+		return err
+	}
+	return r.writeWorkFile(content)
+}
+
+// diffSide is one version compared by Diff: a stored blob or the work file
+type diffSide struct {
+	file   *object.File // nil for the work file or a version without the file
+	work   bool
+	size   int64 // content size; a packed blob's stored size is less
+	packed bool
+	binary bool
+}
+
+// storedSide describes a stored version of the file for Diff, reading
+// only the start of its blob
+func storedSide(repo *git.Repository, file *object.File) (diffSide, error) {
+	if file == nil {
+		return diffSide{}, nil
+	}
+	prefix, err := blobPrefix(repo, file, sniffLen)
+	if err != nil {
+		return diffSide{}, err
+	}
+	return diffSide{
+		file:   file,
+		size:   contentSize(file, prefix),
+		packed: compress.IsPacked(prefix),
+		binary: IsBinaryDelta(prefix) || (!compress.IsPacked(prefix) && eol.IsBinary(prefix)),
+	}, nil
+}
+
+// workSide describes the work file for Diff, reading only its start
+func (r *Repo) workSide() (diffSide, error) {
+	info, err := r.workFS.Stat(r.FileName)
+	if os.IsNotExist(err) {
+		return diffSide{}, ErrFileMissing
+	}
+	if err != nil {
+		return diffSide{}, err
+	}
+	f, err := r.workFS.Open(r.FileName)
+	if err != nil {
+		return diffSide{}, err
+	}
+	defer f.Close()
+	prefix, err := bufio.NewReaderSize(f, sniffLen).Peek(sniffLen)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return diffSide{}, err
+	}
+	return diffSide{work: true, size: info.Size(), binary: eol.IsBinary(prefix)}, nil
+}
+
+// summarizeDiff returns the one-line summary Diff shows instead of a line
+// diff when either side is binary or larger than MaxDiffSize, "" if they
+// are the same, and ok false when a line diff should be shown
+func (r *Repo) summarizeDiff(repo *git.Repository, old, new diffSide) (summary string, ok bool, err error) {
+	large := r.MaxDiffSize > 0 && (old.size > r.MaxDiffSize || new.size > r.MaxDiffSize)
+	if !large && !old.binary && !new.binary {
+		return "", false, nil
+	}
+
+	same, err := r.sameContent(repo, old, new)
+	if err != nil {
+		return "", false, err
+	}
+	switch {
+	case same:
+		return "", true, nil
+	case large:
+		return fmt.Sprintf("Large files a/%s and b/%s differ (%s → %s; no line diff over %s)\n",
+			r.FileName, r.FileName, sizeLabel(old.size), sizeLabel(new.size), sizeLabel(r.MaxDiffSize)), true, nil
+	}
+	return fmt.Sprintf("Binary files a/%s and b/%s differ (%s → %s)\n",
+		r.FileName, r.FileName, sizeLabel(old.size), sizeLabel(new.size)), true, nil
+}
+
+// sameContent reports whether two sides hold the same content, comparing
+// hashes when that settles it and contents otherwise
+func (r *Repo) sameContent(repo *git.Repository, old, new diffSide) (bool, error) {
+	// Packing and the clean filter change sizes, so they only settle it
+	// when neither applies
+	filtered := r.clean != nil && (old.work || new.work)
+	if old.size != new.size && !filtered && !old.packed && !new.packed {
+		return false, nil
+	}
+	if old.file != nil && new.file != nil && old.file.Hash == new.file.Hash {
+		return true, nil
+	}
+	if old.file != nil && new.work {
+		if changed, ok, err := r.workChanged(repo, old.file); err != nil || ok {
+			return !changed, err
+		}
+	}
+
+	oldContent, err := r.sideContent(repo, old)
+	if err != nil {
+		return false, err
+	}
+	newContent, err := r.sideContent(repo, new)
+	if err != nil {
+		return false, err
+	}
+	return bytes.Equal(oldContent, newContent), nil
+}
+
+// sideContent reads the whole content of one side of a diff
+func (r *Repo) sideContent(repo *git.Repository, side diffSide) ([]byte, error) {
+	switch {
+	case side.work:
+		return r.readWorkFile()
+	case side.file != nil:
+		return readFileContent(repo, side.file)
+	}
+	return nil, nil
+}
+
+// sizeLabel formats a byte count for diff summaries
+func sizeLabel(n int64) string {
+	switch {
+	case n >= 1<<30:
+		return fmt.Sprintf("%.1f GB", float64(n)/(1<<30))
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1f KB", float64(n)/(1<<10))
+	}
+	return fmt.Sprintf("%d B", n)
+}
@@ -0,0 +1,75 @@
+package git
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLargeFileDiffSummary(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo.Init()
+	repo.Add()
+	repo.Commit("first")
+	repo.Tag("v1")
+	repo.MaxDiffSize = 1 << 10
+
+	path := filepath.Join(tmpDir, repo.FileName)
+	large := bytes.Repeat([]byte("line of text\n"), 200)
+	os.WriteFile(path, large, 0644)
+	if changed, err := repo.HasChanges(); err != nil || !changed {
+		t.Fatalf("HasChanges = %v, %v; want true", changed, err)
+	}
+	diff, err := repo.Diff()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(diff, "Large files a/test.txt and b/test.txt differ") {
+		t.Errorf("Diff = %q, want a large file summary", diff)
+	}
+
+	repo.Add()
+	repo.Commit("large")
+	repo.Tag("v2")
+	if changed, _ := repo.HasChanges(); changed {
+		t.Error("HasChanges = true right after saving")
+	}
+	if diff, _ := repo.Diff(); diff != "" {
+		t.Errorf("Diff of unchanged large file = %q, want none", diff)
+	}
+
+	// Restores stream the blob back into the file
+	if err := repo.Checkout("v1"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "initial content" {
+		t.Errorf("after Checkout(v1) content = %q", data)
+	}
+	if err := repo.Checkout("v2"); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Equal(data, large) {
+		t.Errorf("after Checkout(v2) content is %d bytes, want %d", len(data), len(large))
+	}
+}
+
+func TestBinaryDiffSummary(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo.Init()
+	repo.Add()
+	repo.Commit("first")
+	repo.Tag("v1")
+
+	os.WriteFile(filepath.Join(tmpDir, repo.FileName), []byte("bin\x00ary"), 0644)
+	diff, err := repo.Diff("v1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if diff != "Binary files a/test.txt and b/test.txt differ (15 B → 7 B)\n" {
+		t.Errorf("Diff = %q, want a binary file summary", diff)
+	}
+}
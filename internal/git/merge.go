@@ -0,0 +1,165 @@
+package git
+
+import (
+	"slices"
+	"strings"
+)
+
+// mergeHunk replaces base lines [start, end) with lines
+type mergeHunk struct {
+	start, end int
+	lines      []string
+}
+
+// changeHunks returns the changes from base to text as hunks of base
+// lines, in order
+func changeHunks(base, text string) []mergeHunk {
+	var hunks []mergeHunk
+	var open *mergeHunk
+	pos := 0
+	for _, l := range diffLines(base, text) {
+		if l.op == EditEqual {
+			if open != nil {
+				hunks = append(hunks, *open)
+				open = nil
+			}
+			pos++
+			continue
+		}
+		if open == nil {
+			open = &mergeHunk{start: pos, end: pos}
+		}
+		if l.op == EditDelete {
+			pos++
+			open.end = pos
+		} else {
+			open.lines = append(open.lines, l.text)
+		}
+	}
+	if open != nil {
+		hunks = append(hunks, *open)
+	}
+	return hunks
+}
+
+// Merge3 combines the changes ours and theirs each made to base, line by
+// line. It returns false if they changed the same or neighbouring lines
+// differently, where no merge is safe.
+func Merge3(base, ours, theirs string) (string, bool) {
+	return merge3(base, ours, theirs, func(start, end int, a, b []mergeHunk) (mergeHunk, bool) {
+		return mergeHunk{}, false
+	})
+}
+
+// Merge3Marked combines the changes like Merge3, but where they conflict
+// it keeps both sides between conflict markers naming them oursLabel and
+// theirsLabel, as git does. Returns the merged text and the number of
+// conflicts marked.
+func Merge3Marked(base, ours, theirs, oursLabel, theirsLabel string) (string, int) {
+	baseLines := splitLines(base)
+	conflicts := 0
+	merged, _ := merge3(base, ours, theirs, func(start, end int, a, b []mergeHunk) (mergeHunk, bool) {
+		conflicts++
+		h := mergeHunk{start: start, end: end}
+		h.lines = append(h.lines, "<<<<<<< "+oursLabel+"\n")
+		h.lines = appendSide(h.lines, applyHunks(baseLines, start, end, a))
+		h.lines = append(h.lines, "=======\n")
+		h.lines = appendSide(h.lines, applyHunks(baseLines, start, end, b))
+		h.lines = append(h.lines, ">>>>>>> "+theirsLabel+"\n")
+		return h, true
+	})
+	return merged, conflicts
+}
+
+// merge3 merges the changes ours and theirs made to base, passing each
+// run of overlapping changes, base lines [start, end), to conflict. It
+// returns false if conflict does.
+func merge3(base, ours, theirs string, conflict func(start, end int, a, b []mergeHunk) (mergeHunk, bool)) (string, bool) {
+	switch {
+	case ours == theirs, theirs == base:
+		return ours, true
+	case ours == base:
+		return theirs, true
+	}
+
+	a, b := changeHunks(base, ours), changeHunks(base, theirs)
+	var hunks []mergeHunk
+	for len(a) > 0 || len(b) > 0 {
+		switch {
+		case len(b) == 0 || len(a) > 0 && a[0].end < b[0].start:
+			hunks, a = append(hunks, a[0]), a[1:]
+		case len(a) == 0 || b[0].end < a[0].start:
+			hunks, b = append(hunks, b[0]), b[1:]
+		case a[0].start == b[0].start && a[0].end == b[0].end && slices.Equal(a[0].lines, b[0].lines):
+			hunks, a, b = append(hunks, a[0]), a[1:], b[1:] // Both made the same change
+		default:
+			// Take every change touching the run, on either side
+			start, end := min(a[0].start, b[0].start), max(a[0].end, b[0].end)
+			var ca, cb []mergeHunk
+			for len(a) > 0 && a[0].start <= end || len(b) > 0 && b[0].start <= end {
+				if len(a) > 0 && a[0].start <= end {
+					ca, a = append(ca, a[0]), a[1:]
+					end = max(end, ca[len(ca)-1].end)
+				} else {
+					cb, b = append(cb, b[0]), b[1:]
+					end = max(end, cb[len(cb)-1].end)
+				}
+			}
+			h, ok := conflict(start, end, ca, cb)
+			if !ok {
+				return "", false
+			}
+			hunks = append(hunks, h)
+		}
+	}
+
+	var out strings.Builder
+	baseLines := splitLines(base)
+	pos := 0
+	for _, h := range hunks {
+		for _, line := range baseLines[pos:h.start] {
+			out.WriteString(line)
+		}
+		for _, line := range h.lines {
+			out.WriteString(line)
+		}
+		pos = h.end
+	}
+	for _, line := range baseLines[pos:] {
+		out.WriteString(line)
+	}
+	return out.String(), true
+}
+
+// applyHunks returns base lines [start, end) with hunks, which lie within
+// them, applied
+func applyHunks(baseLines []string, start, end int, hunks []mergeHunk) []string {
+	var lines []string
+	pos := start
+	for _, h := range hunks {
+		lines = append(lines, baseLines[pos:h.start]...)
+		lines = append(lines, h.lines...)
+		pos = h.end
+	}
+	return append(lines, baseLines[pos:end]...)
+}
+
+// appendSide adds one side of a conflict, ending its last line so the
+// marker after it starts a line of its own
+func appendSide(out, side []string) []string {
+	out = append(out, side...)
+	if n := len(out); n > 0 && !strings.HasSuffix(out[n-1], "\n") {
+		out[n-1] += "\n"
+	}
+	return out
+}
+
+// splitLines splits text after each newline, keeping a last line without
+// one
+func splitLines(text string) []string {
+	lines := strings.SplitAfter(text, "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+	return lines
+}
@@ -0,0 +1,86 @@
+package git
+
+import "testing"
+
+func TestMerge3(t *testing.T) {
+	base := "title\none\ntwo\nthree\nfour\nfive\n"
+
+	tests := []struct {
+		name         string
+		ours, theirs string
+		want         string
+		ok           bool
+	}{
+		{
+			name:   "separate lines",
+			ours:   "title\nONE\ntwo\nthree\nfour\nfive\n",
+			theirs: "title\none\ntwo\nthree\nfour\nFIVE\n",
+			want:   "title\nONE\ntwo\nthree\nfour\nFIVE\n",
+			ok:     true,
+		},
+		{
+			name:   "insert and delete",
+			ours:   "title\none\ntwo\nadded\nthree\nfour\nfive\n",
+			theirs: "one\ntwo\nthree\nfour\nfive\n",
+			want:   "one\ntwo\nadded\nthree\nfour\nfive\n",
+			ok:     true,
+		},
+		{
+			name:   "same change on both sides",
+			ours:   "title\none\n2\nthree\nfour\nfive\nsix\n",
+			theirs: "title\none\n2\nthree\nfour\nfive\n",
+			want:   "title\none\n2\nthree\nfour\nfive\nsix\n",
+			ok:     true,
+		},
+		{
+			name:   "same line changed differently",
+			ours:   "title\none\nTWO\nthree\nfour\nfive\n",
+			theirs: "title\none\nDos\nthree\nfour\nfive\n",
+			ok:     false,
+		},
+		{
+			name:   "neighbouring lines",
+			ours:   "title\none\nTWO\nthree\nfour\nfive\n",
+			theirs: "title\none\ntwo\nTHREE\nfour\nfive\n",
+			ok:     false,
+		},
+		{
+			name:   "only one side changed",
+			ours:   base,
+			theirs: "title\n",
+			want:   "title\n",
+			ok:     true,
+		},
+		{
+			name:   "last line without newline",
+			ours:   "title\none\ntwo\nthree\nfour\nfive",
+			theirs: "TITLE\none\ntwo\nthree\nfour\nfive\n",
+			want:   "TITLE\none\ntwo\nthree\nfour\nfive",
+			ok:     true,
+		},
+	}
+	for _, tt := range tests {
+		got, ok := Merge3(base, tt.ours, tt.theirs)
+		if ok != tt.ok || got != tt.want {
+			t.Errorf("%s: Merge3 = %q, %v; want %q, %v", tt.name, got, ok, tt.want, tt.ok)
+		}
+	}
+}
+
+func TestMerge3Marked(t *testing.T) {
+	base := "title\none\ntwo\nthree\nfour\nfive\n"
+	ours := "TITLE\none\n2\nthree\nfour\nfive\n"
+	theirs := "title\none\nzwei\nthree\nfour\nFIVE"
+
+	got, conflicts := Merge3Marked(base, ours, theirs, "working file", "snapshot #3")
+	want := "TITLE\none\n" +
+		"<<<<<<< working file\n2\n=======\nzwei\n>>>>>>> snapshot #3\n" +
+		"three\nfour\nFIVE"
+	if got != want || conflicts != 1 {
+		t.Errorf("Merge3Marked = %q, %d conflicts; want %q, 1", got, conflicts, want)
+	}
+
+	if got, conflicts := Merge3Marked(base, ours, base, "a", "b"); got != ours || conflicts != 0 {
+		t.Errorf("Merge3Marked with one side unchanged = %q, %d", got, conflicts)
+	}
+}
@@ -0,0 +1,158 @@
+package git
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/idxfile"
+	"github.com/go-git/go-git/v5/plumbing/object"
+)
+
+// packTrailer is the checksum at the end of a pack file
+const packTrailer = 20
+
+// ReclaimableSize estimates the bytes on disk freed by removing the
+// versions tagged drop while keeping those tagged keep: the objects of
+// the dropped versions that no kept version shares, at the size each
+// takes loose or in its pack. Kept versions stored as deltas against a
+// dropped one take more once it is gone, so this is an upper bound.
+func (r *Repo) ReclaimableSize(drop, keep []string) (int64, error) {
+	if r.inMemory {
+		return 0, nil
+	}
+	kept := map[plumbing.Hash]bool{}
+	for _, tag := range keep {
+		hashes, err := r.versionObjects(tag)
+		if err != nil {
+			return 0, err
+		}
+		for _, h := range hashes {
+			kept[h] = true
+		}
+	}
+	sizes, err := r.objectDiskSizes()
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	counted := map[plumbing.Hash]bool{}
+	for _, tag := range drop {
+		hashes, err := r.versionObjects(tag)
+		if err != nil {
+			return 0, err
+		}
+		for _, h := range hashes {
+			if !kept[h] && !counted[h] {
+				counted[h] = true
+				total += sizes[h]
+			}
+		}
+	}
+	return total, nil
+}
+
+// versionObjects returns the commit, tree and blob of the version at tag
+func (r *Repo) versionObjects(tag string) ([]plumbing.Hash, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	ref, err := repo.Tag(tag)
+	if err != nil {
+		return nil, err
+	}
+	commit, err := repo.CommitObject(ref.Hash())
+	if err != nil {
+		return nil, err
+	}
+	hashes := []plumbing.Hash{commit.Hash, commit.TreeHash}
+	if file, err := r.commitFile(commit); err == nil {
+		hashes = append(hashes, file.Hash)
+	} else if err != object.ErrFileNotFound {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// objectDiskSizes returns the bytes each object takes on disk: the file
+// of a loose object, or the span of a packed one up to the next object in
+// its pack
+func (r *Repo) objectDiskSizes() (map[plumbing.Hash]int64, error) {
+	objects := filepath.Join(r.GitDir, ".git", "objects")
+	sizes := map[plumbing.Hash]int64{}
+
+	dirs, err := os.ReadDir(objects)
+	if err != nil {
+		return nil, err
+	}
+	for _, dir := range dirs {
+		if !dir.IsDir() || len(dir.Name()) != 2 {
+			continue
+		}
+		files, err := os.ReadDir(filepath.Join(objects, dir.Name()))
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range files {
+			if info, err := f.Info(); err == nil {
+				sizes[plumbing.NewHash(dir.Name()+f.Name())] = info.Size()
+			}
+		}
+	}
+
+	packs, err := filepath.Glob(filepath.Join(objects, "pack", "*.idx"))
+	if err != nil {
+		return nil, err
+	}
+	for _, idxPath := range packs {
+		if err := packedSizes(idxPath, sizes); err != nil {
+			return nil, err
+		}
+	}
+	return sizes, nil
+}
+
+// packedSizes adds the size of each object in the pack indexed at idxPath
+func packedSizes(idxPath string, sizes map[plumbing.Hash]int64) error {
+	info, err := os.Stat(strings.TrimSuffix(idxPath, ".idx") + ".pack")
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(idxPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	idx := idxfile.NewMemoryIndex()
+	if err := idxfile.NewDecoder(f).Decode(idx); err != nil {
+		return err
+	}
+
+	entries, err := idx.EntriesByOffset()
+	if err != nil {
+		return err
+	}
+	defer entries.Close()
+	var prev *idxfile.Entry
+	for {
+		entry, err := entries.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if prev != nil {
+			sizes[prev.Hash] = int64(entry.Offset - prev.Offset)
+		}
+		prev = entry
+	}
+	if prev != nil {
+		sizes[prev.Hash] = info.Size() - packTrailer - int64(prev.Offset)
+	}
+	return nil
+}
@@ -0,0 +1,429 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-git/go-git/v5"
+	gitconfig "github.com/go-git/go-git/v5/config"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/http"
+	"github.com/go-git/go-git/v5/plumbing/transport/ssh"
+)
+
+// RemoteName is the name of the remote used when none is named
+const RemoteName = "origin"
+
+// remoteTagPrefix is where tags fetched from remote name are kept, apart
+// from the local vN tags they are compared with
+func remoteTagPrefix(name string) string {
+	return "refs/remotes/" + name + "/tags/"
+}
+
+// Auth holds credentials for a remote. Empty fields are not used; SSH
+// without a key falls back to the SSH agent.
+type Auth struct {
+	Username   string // for HTTPS tokens; defaults to "oops"
+	Token      string // HTTPS personal access token
+	SSHKey     string // path to a private key file
+	Passphrase string // for SSHKey
+}
+
+// method returns the go-git auth method for url
+func (a Auth) method(url string) (transport.AuthMethod, error) {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return nil, err
+	}
+	switch ep.Protocol {
+	case "http", "https":
+		if a.Token == "" {
+			return nil, nil
+		}
+		user := a.Username
+		if user == "" {
+			user = "oops"
+		}
+		return &http.BasicAuth{Username: user, Password: a.Token}, nil
+	case "ssh":
+		if a.SSHKey == "" {
+			return nil, nil
+		}
+		user := ep.User
+		if user == "" {
+			user = "git"
+		}
+		return ssh.NewPublicKeysFromFile(user, a.SSHKey, a.Passphrase)
+	}
+	return nil, nil
+}
+
+// RemoteState is the remote history as of the last Fetch
+type RemoteState struct {
+	Head string            // full hash of the remote branch, "" if the remote is empty
+	Tags map[string]string // full commit hash by vN tag name
+}
+
+// SetRemote points remote name at url, replacing any previous URL. A
+// relative local path is made absolute.
+func (r *Repo) SetRemote(name, url string) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return fmt.Errorf("invalid remote URL %q: %w", url, err)
+	}
+	if ep.Protocol == "file" && !strings.HasPrefix(url, "file://") {
+		if url, err = filepath.Abs(url); err != nil {
+			return err
+		}
+	}
+	if err := repo.DeleteRemote(name); err != nil && err != git.ErrRemoteNotFound {
+		return err
+	}
+	_, err = repo.CreateRemote(&gitconfig.RemoteConfig{Name: name, URLs: []string{url}})
+	return err
+}
+
+// RemoteURL returns the URL of remote name, or "" if it is not set
+func (r *Repo) RemoteURL(name string) (string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return "", err
+	}
+	remote, err := repo.Remote(name)
+	if err == git.ErrRemoteNotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return remote.Config().URLs[0], nil
+}
+
+// Remotes returns the URL of every remote, by name
+func (r *Repo) Remotes() (map[string]string, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	remotes, err := repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	urls := make(map[string]string, len(remotes))
+	for _, remote := range remotes {
+		urls[remote.Config().Name] = remote.Config().URLs[0]
+	}
+	return urls, nil
+}
+
+// RemoveRemote forgets remote name and the history fetched from it
+func (r *Repo) RemoveRemote(name string) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	if err := repo.DeleteRemote(name); err != nil {
+		return err
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return err
+	}
+	return refs.ForEach(func(ref *plumbing.Reference) error {
+		if strings.HasPrefix(ref.Name().String(), "refs/remotes/"+name+"/") {
+			return repo.Storer.RemoveReference(ref.Name())
+		}
+		return nil
+	})
+}
+
+// branch returns the branch HEAD points to
+func (r *Repo) branch(repo *git.Repository) (plumbing.ReferenceName, error) {
+	head, err := repo.Storer.Reference(plumbing.HEAD)
+	if err != nil {
+		return "", err
+	}
+	if head.Type() != plumbing.SymbolicReference {
+		return "", fmt.Errorf("HEAD is not on a branch")
+	}
+	return head.Target(), nil
+}
+
+// Fetch downloads the history of remote name and returns its state.
+// Remote tags are kept apart from local ones, so nothing local changes.
+func (r *Repo) Fetch(name string, auth Auth) (*RemoteState, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return nil, err
+	}
+	url, err := r.RemoteURL(name)
+	if err != nil {
+		return nil, err
+	}
+	if url == "" {
+		return nil, fmt.Errorf("no remote named %s", name)
+	}
+	method, err := auth.method(url)
+	if err != nil {
+		return nil, err
+	}
+	branch, err := r.branch(repo)
+	if err != nil {
+		return nil, err
+	}
+	tracking := plumbing.NewRemoteReferenceName(name, branch.Short())
+	tagPrefix := remoteTagPrefix(name)
+
+	err = repo.Fetch(&git.FetchOptions{
+		RemoteName: name,
+		Auth:       method,
+		RefSpecs: []gitconfig.RefSpec{
+			gitconfig.RefSpec("+" + branch + ":" + tracking),
+			gitconfig.RefSpec("+refs/tags/*:" + tagPrefix + "*"),
+		},
+		Tags: git.NoTags,
+	})
+	switch {
+	case err == nil, err == git.NoErrAlreadyUpToDate:
+	case errors.Is(err, transport.ErrEmptyRemoteRepository), isNoMatchingRefSpec(err), missingLocalRemote(url, err):
+		return &RemoteState{Tags: map[string]string{}}, nil
+	default:
+		return nil, err
+	}
+
+	state := &RemoteState{Tags: map[string]string{}}
+	if ref, err := repo.Reference(tracking, true); err == nil {
+		state.Head = ref.Hash().String()
+	}
+	refs, err := repo.References()
+	if err != nil {
+		return nil, err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		tag, ok := strings.CutPrefix(ref.Name().String(), tagPrefix)
+		if !ok {
+			return nil
+		}
+		if _, ok := ParseVersionTag(tag); ok {
+			state.Tags[tag] = ref.Hash().String()
+		}
+		return nil
+	})
+	return state, err
+}
+
+// isNoMatchingRefSpec reports a fetch from a remote lacking the branch,
+// such as a shared repository other files were pushed to
+func isNoMatchingRefSpec(err error) bool {
+	var noMatch git.NoMatchingRefSpecError
+	return errors.As(err, &noMatch)
+}
+
+// Push uploads the branch and the vN tags to remote name. Only objects the
+// remote lacks are sent, in a thin pack (see thinPack), so a push sends
+// little more than the changes of the new snapshots. A local path that
+// does not exist yet is created as a bare repository. Updates that would
+// drop remote commits or move remote tags are refused.
+func (r *Repo) Push(name string, auth Auth) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	url, err := r.RemoteURL(name)
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return fmt.Errorf("no remote named %s", name)
+	}
+	if err := initLocalRemote(url); err != nil {
+		return err
+	}
+	method, err := auth.method(url)
+	if err != nil {
+		return err
+	}
+	branch, err := r.branch(repo)
+	if err != nil {
+		return err
+	}
+	head, err := repo.Reference(branch, true)
+	if err != nil {
+		return err
+	}
+	tags, err := r.VersionTags()
+	if err != nil {
+		return err
+	}
+
+	ep, err := transport.NewEndpoint(url)
+	if err != nil {
+		return err
+	}
+	cli, err := client.NewClient(ep)
+	if err != nil {
+		return err
+	}
+	sess, err := cli.NewReceivePackSession(ep, method)
+	if err != nil {
+		return err
+	}
+	defer sess.Close()
+	ctx := context.Background()
+	ar, err := sess.AdvertisedReferencesContext(ctx)
+	if err != nil {
+		return err
+	}
+	remoteRefs, err := ar.AllReferences()
+	if err != nil {
+		return err
+	}
+
+	// The remote's objects we have need not be sent
+	var haves, wants []plumbing.Hash
+	for _, ref := range remoteRefs {
+		if repo.Storer.HasEncodedObject(ref.Hash()) == nil {
+			haves = append(haves, ref.Hash())
+		}
+	}
+	req := packp.NewReferenceUpdateRequestFromCapabilities(ar.Capabilities)
+	update := func(ref plumbing.ReferenceName, hash plumbing.Hash) error {
+		old := plumbing.ZeroHash
+		if remoteRef, ok := remoteRefs[ref]; ok {
+			old = remoteRef.Hash()
+		}
+		if old == hash {
+			return nil
+		}
+		if !old.IsZero() {
+			if ref.IsTag() {
+				return fmt.Errorf("tag %s already exists on the remote", ref.Short())
+			}
+			if ok, err := r.IsAncestor(old.String(), hash.String()); err != nil || !ok {
+				return fmt.Errorf("non-fast-forward update: %s", ref)
+			}
+		}
+		req.Commands = append(req.Commands, &packp.Command{Name: ref, Old: old, New: hash})
+		wants = append(wants, hash)
+		return nil
+	}
+	if err := update(branch, head.Hash()); err != nil {
+		return err
+	}
+	for tag, hash := range tags {
+		if err := update(plumbing.NewTagReferenceName(tag), plumbing.NewHash(hash)); err != nil {
+			return err
+		}
+	}
+	if len(req.Commands) == 0 {
+		return nil
+	}
+
+	objects, err := revlist.Objects(repo.Storer, wants, haves)
+	if err != nil {
+		return err
+	}
+	pack, err := r.thinPack(repo, objects)
+	if err != nil {
+		return err
+	}
+	req.Packfile = io.NopCloser(bytes.NewReader(pack))
+	status, err := sess.ReceivePack(ctx, req)
+	if err != nil {
+		return err
+	}
+	if status != nil {
+		return status.Error()
+	}
+	return nil
+}
+
+// missingLocalRemote reports a fetch from a local path that Push has not
+// created yet
+func missingLocalRemote(url string, err error) bool {
+	if !errors.Is(err, transport.ErrRepositoryNotFound) {
+		return false
+	}
+	ep, epErr := transport.NewEndpoint(url)
+	if epErr != nil || ep.Protocol != "file" {
+		return false
+	}
+	_, statErr := os.Stat(ep.Path)
+	return os.IsNotExist(statErr)
+}
+
+// initLocalRemote creates a bare repository for a local path remote that
+// does not exist yet
+func initLocalRemote(url string) error {
+	ep, err := transport.NewEndpoint(url)
+	if err != nil || ep.Protocol != "file" {
+		return err
+	}
+	if _, err := os.Stat(ep.Path); err == nil {
+		return nil
+	}
+	if _, err := git.PlainInit(ep.Path, true); err != nil {
+		return fmt.Errorf("creating remote repository: %w", err)
+	}
+	return nil
+}
+
+// IsAncestor reports whether commit a is reachable from commit b. Both are
+// full hashes; a commit is its own ancestor.
+func (r *Repo) IsAncestor(a, b string) (bool, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return false, err
+	}
+	if a == b {
+		return true, nil
+	}
+	ca, err := repo.CommitObject(plumbing.NewHash(a))
+	if err != nil {
+		return false, err
+	}
+	cb, err := repo.CommitObject(plumbing.NewHash(b))
+	if err != nil {
+		return false, err
+	}
+	return ca.IsAncestor(cb)
+}
+
+// FastForward moves the branch to the fetched commit head and creates the
+// given tags. Callers check first that head descends from the branch.
+func (r *Repo) FastForward(head string, tags map[string]string) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	branch, err := r.branch(repo)
+	if err != nil {
+		return err
+	}
+	ref := plumbing.NewHashReference(branch, plumbing.NewHash(head))
+	if err := repo.Storer.SetReference(ref); err != nil {
+		return err
+	}
+	for name, hash := range tags {
+		if _, err := repo.CreateTag(name, plumbing.NewHash(hash), nil); err != nil {
+			return err
+		}
+	}
+
+	// A copy left by an earlier version would no longer match HEAD
+	return r.RemoveCheckout()
+}
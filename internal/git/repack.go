@@ -0,0 +1,126 @@
+package git
+
+import (
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+)
+
+// LooseObjects returns how many objects are stored one file each. Loose
+// objects are compressed, but each version of the file is a full copy.
+func (r *Repo) LooseObjects() (int, error) {
+	repo, err := r.openRepo()
+	if err != nil {
+		return 0, err
+	}
+	loose, ok := repo.Storer.(storer.LooseObjectStorer)
+	if !ok {
+		return 0, nil // In memory
+	}
+	n := 0
+	err = loose.ForEachObjectHash(func(plumbing.Hash) error {
+		n++
+		return nil
+	})
+	return n, err
+}
+
+// Repack moves the history into a single pack compressed at the given
+// zlib level, where each version of the file is stored as a delta against
+// the one before it. The loose copies and older packs are removed only
+// once the new pack is complete. Content reads back unchanged.
+func (r *Repo) Repack(level int) error {
+	repo, err := r.openRepo()
+	if err != nil {
+		return err
+	}
+	loose, ok := repo.Storer.(storer.LooseObjectStorer)
+	if !ok {
+		return nil // In memory
+	}
+	packs, ok := repo.Storer.(storer.PackedObjectStorer)
+	if !ok {
+		return nil
+	}
+	writer, ok := repo.Storer.(storer.PackfileWriter)
+	if !ok {
+		return nil
+	}
+	// The open repository keeps track of packs; reopen it afterwards
+	defer func() { r.repo = nil }()
+
+	// Everything reachable from a reference is packed; nothing else
+	var tips []plumbing.Hash
+	refs, err := repo.References()
+	if err != nil {
+		return err
+	}
+	err = refs.ForEach(func(ref *plumbing.Reference) error {
+		if ref.Type() == plumbing.HashReference {
+			tips = append(tips, ref.Hash())
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	objects, err := revlist.Objects(repo.Storer, tips, nil)
+	if err != nil {
+		return err
+	}
+	if len(objects) == 0 {
+		return nil
+	}
+	old, err := packs.ObjectPacks()
+	if err != nil {
+		return err
+	}
+
+	pack, err := r.encodePack(repo, objects, level)
+	if err != nil {
+		return err
+	}
+	w, err := writer.PackfileWriter()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(pack); err != nil {
+		w.Close()
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	var hash plumbing.Hash // The pack is named by its trailing checksum
+	copy(hash[:], pack[len(pack)-len(hash):])
+
+	packed := make(map[plumbing.Hash]bool, len(objects))
+	for _, h := range objects {
+		packed[h] = true
+	}
+	var remove []plumbing.Hash
+	err = loose.ForEachObjectHash(func(h plumbing.Hash) error {
+		if packed[h] {
+			remove = append(remove, h)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, h := range remove {
+		if err := loose.DeleteLooseObject(h); err != nil {
+			return err
+		}
+	}
+	for _, h := range old {
+		if h != hash {
+			if err := packs.DeleteOldObjectPackAndIndex(h, time.Time{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
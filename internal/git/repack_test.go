@@ -0,0 +1,96 @@
+package git
+
+import (
+	"compress/zlib"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRepoRepack(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i := 0; i < 3000; i++ {
+		lines = append(lines, fmt.Sprintf("%d: some configuration value", i))
+	}
+	versions := map[string]string{}
+	for v := 1; v <= 10; v++ {
+		lines[v*100] = fmt.Sprintf("edited in version %d", v)
+		content := strings.Join(lines, "\n")
+		if _, err := repo.CommitContent([]byte(content), "save", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		tag := fmt.Sprintf("v%d", v)
+		if err := repo.Tag(tag); err != nil {
+			t.Fatal(err)
+		}
+		versions[tag] = content
+	}
+
+	// Reverting back and forth must not make deltas depend on each other
+	for v, from := range []string{"v9", "v10", "v9"} {
+		if _, err := repo.CommitContent([]byte(versions[from]), "revert", time.Now()); err != nil {
+			t.Fatal(err)
+		}
+		tag := fmt.Sprintf("v%d", 11+v)
+		if err := repo.Tag(tag); err != nil {
+			t.Fatal(err)
+		}
+		versions[tag] = versions[from]
+	}
+
+	if n, err := repo.LooseObjects(); err != nil || n < 30 {
+		t.Fatalf("LooseObjects = %d, %v; want every object loose", n, err)
+	}
+	before := gitDirSize(t, repo.GitDir)
+	for i := 0; i < 2; i++ {
+		if err := repo.Repack(zlib.DefaultCompression); err != nil {
+			t.Fatalf("Repack #%d: %v", i+1, err)
+		}
+	}
+	if n, _ := repo.LooseObjects(); n != 0 {
+		t.Errorf("LooseObjects after Repack = %d", n)
+	}
+	packs, _ := filepath.Glob(filepath.Join(repo.GitDir, ".git", "objects", "pack", "*.pack"))
+	if len(packs) != 1 {
+		t.Errorf("packs after repacking twice = %d, want 1", len(packs))
+	}
+	if after := gitDirSize(t, repo.GitDir); after*3 > before {
+		t.Errorf("history is %d bytes after Repack, %d before", after, before)
+	}
+
+	for tag, want := range versions {
+		if got, err := repo.Show(tag); err != nil || string(got) != want {
+			t.Errorf("%s after Repack differs: %v", tag, err)
+		}
+	}
+
+	// Snapshots saved after a repack are stored as before
+	if _, err := repo.CommitContent([]byte("new"), "save", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if n, _ := repo.LooseObjects(); n == 0 {
+		t.Error("a new snapshot was not stored loose")
+	}
+}
+
+func gitDirSize(t *testing.T, dir string) int64 {
+	var size int64
+	filepath.WalkDir(filepath.Join(dir, ".git", "objects"), func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
@@ -0,0 +1,87 @@
+package git
+
+import (
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// replaceFile writes content to the file at path through a temporary file
+// in the same directory that is renamed over it, so an interrupted write
+// leaves either the old content or the new, never a truncated file. The
+// file keeps its permissions and, where the platform allows, its owner.
+// Symbolic links are written through, since renaming would replace the
+// link itself.
+func replaceFile(path string, content []byte) error {
+	return replaceFileFrom(path, bytes.NewReader(content))
+}
+
+// replaceFileFrom is replaceFile with the content streamed from src, so a
+// large file is restored without holding it in memory
+func replaceFileFrom(path string, src io.Reader) error {
+	mode := os.FileMode(0644)
+	info, err := os.Lstat(path)
+	switch {
+	case err == nil && info.Mode()&os.ModeSymlink != 0:
+		return writeFileFrom(path, src, mode)
+	case err == nil:
+		mode = info.Mode().Perm()
+	case !os.IsNotExist(err):
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".oops-*")
+	if err != nil {
+		return err
+	}
+	name := tmp.Name()
+	if _, err := io.Copy(tmp, src); err != nil {
+		tmp.Close()
+		os.Remove(name)
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		os.Remove(name)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if err := os.Chmod(name, mode); err != nil {
+		os.Remove(name)
+		return err
+	}
+	if info != nil {
+		keepOwner(name, info)
+	}
+
+	if err := os.Rename(name, path); err != nil {
+		defer os.Remove(name)
+		// Windows cannot replace a file another program holds open;
+		// writing it in place is the best that can be done then
+		written, err := os.Open(name)
+		if err != nil {
+			return err
+		}
+		defer written.Close()
+		return writeFileFrom(path, written, mode)
+	}
+	return nil
+}
+
+// writeFileFrom writes the content of src to path in place, like
+// os.WriteFile
+func writeFileFrom(path string, src io.Reader, mode os.FileMode) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, src); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
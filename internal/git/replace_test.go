@@ -0,0 +1,58 @@
+package git
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestReplaceFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.txt")
+	os.WriteFile(path, []byte("old content"), 0600)
+
+	if err := replaceFile(path, []byte("new")); err != nil {
+		t.Fatalf("replaceFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Errorf("content = %q, want %q", data, "new")
+	}
+	if runtime.GOOS != "windows" {
+		if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+			t.Errorf("mode = %v, want 0600 kept", info.Mode().Perm())
+		}
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want no temporary file left", len(entries))
+	}
+
+	// A missing file is created
+	created := filepath.Join(dir, "new.txt")
+	if err := replaceFile(created, []byte("fresh")); err != nil {
+		t.Fatalf("replaceFile of missing file failed: %v", err)
+	}
+	if data, _ := os.ReadFile(created); string(data) != "fresh" {
+		t.Errorf("created content = %q, want %q", data, "fresh")
+	}
+}
+
+func TestReplaceFileSymlink(t *testing.T) {
+	dir := t.TempDir()
+	target := filepath.Join(dir, "target.txt")
+	link := filepath.Join(dir, "link.txt")
+	os.WriteFile(target, []byte("old"), 0644)
+	if err := os.Symlink(target, link); err != nil {
+		t.Skip("symlinks not supported")
+	}
+
+	if err := replaceFile(link, []byte("new")); err != nil {
+		t.Fatalf("replaceFile failed: %v", err)
+	}
+	if info, _ := os.Lstat(link); info.Mode()&os.ModeSymlink == 0 {
+		t.Error("link was replaced by a regular file")
+	}
+	if data, _ := os.ReadFile(target); string(data) != "new" {
+		t.Errorf("target content = %q, want %q", data, "new")
+	}
+}
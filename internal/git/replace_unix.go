@@ -0,0 +1,17 @@
+//go:build !windows
+
+package git
+
+import (
+	"os"
+	"syscall"
+)
+
+// keepOwner gives the file at path the owner and group of info, which
+// only succeeds when running as root or as the owner keeping the group.
+// Failure is ignored: the file then belongs to whoever restored it.
+func keepOwner(path string, info os.FileInfo) {
+	if st, ok := info.Sys().(*syscall.Stat_t); ok {
+		os.Lchown(path, int(st.Uid), int(st.Gid))
+	}
+}
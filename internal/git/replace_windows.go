@@ -0,0 +1,9 @@
+//go:build windows
+
+package git
+
+import "os"
+
+// keepOwner does nothing on Windows, where a file created in a directory
+// inherits its access control from it
+func keepOwner(path string, info os.FileInfo) {}
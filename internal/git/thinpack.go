@@ -0,0 +1,191 @@
+package git
+
+import (
+	"bytes"
+	"compress/zlib"
+	"crypto/sha1"
+	"encoding/binary"
+	"io"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/format/packfile"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/storage/memory"
+)
+
+// deltaBases returns, for the file's content at each commit among objects,
+// its content at the parent commit, which it can be sent as a delta
+// against
+func (r *Repo) deltaBases(repo *git.Repository, objects []plumbing.Hash) map[plumbing.Hash]plumbing.Hash {
+	bases := map[plumbing.Hash]plumbing.Hash{}
+	for _, h := range objects {
+		commit, err := repo.CommitObject(h)
+		if err != nil || commit.NumParents() == 0 {
+			continue // Not a commit, or the first one
+		}
+		parent, err := commit.Parent(0)
+		if err != nil {
+			continue
+		}
+		file, err := r.commitFile(commit)
+		if err != nil {
+			continue
+		}
+		if prev, err := r.commitFile(parent); err == nil && !dependsOn(bases, prev.Hash, file.Hash) {
+			bases[file.Hash] = prev.Hash
+		}
+	}
+	return bases
+}
+
+// dependsOn reports whether blob is base, or needs base through a chain of
+// deltas, as when a later version reverts to an earlier one
+func dependsOn(bases map[plumbing.Hash]plumbing.Hash, blob, base plumbing.Hash) bool {
+	for {
+		if blob == base {
+			return true
+		}
+		next, ok := bases[blob]
+		if !ok {
+			return false
+		}
+		blob = next
+	}
+}
+
+// thinPack encodes objects as a pack in which each version of the file is
+// a delta against the version before it, when that is smaller. The pack is
+// thin: the versions deltas refer to need not be in it, as the receiver
+// has them already.
+func (r *Repo) thinPack(repo *git.Repository, objects []plumbing.Hash) ([]byte, error) {
+	return r.encodePack(repo, objects, zlib.DefaultCompression)
+}
+
+// encodePack encodes objects as thinPack does, compressed at the given
+// zlib level. Given every object of a history, the pack is complete.
+func (r *Repo) encodePack(repo *git.Repository, objects []plumbing.Hash, level int) ([]byte, error) {
+	bases := r.deltaBases(repo, objects)
+
+	// Deltas come after their bases, which the receiver may need first
+	inPack := map[plumbing.Hash]bool{}
+	for _, h := range objects {
+		inPack[h] = true
+	}
+	written := map[plumbing.Hash]bool{}
+	var order []plumbing.Hash
+	for len(order) < len(objects) {
+		for _, h := range objects {
+			base, ok := bases[h]
+			if !written[h] && (!ok || !inPack[base] || written[base]) {
+				written[h] = true
+				order = append(order, h)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	buf.WriteString("PACK")
+	binary.Write(&buf, binary.BigEndian, uint32(2))
+	binary.Write(&buf, binary.BigEndian, uint32(len(order)))
+	for _, h := range order {
+		obj, err := repo.Storer.EncodedObject(plumbing.AnyObject, h)
+		if err != nil {
+			return nil, err
+		}
+		content, err := readObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		if base, ok := bases[h]; ok {
+			if baseObj, err := repo.Storer.EncodedObject(plumbing.AnyObject, base); err == nil {
+				baseContent, err := readObject(baseObj)
+				if err != nil {
+					return nil, err
+				}
+				if delta := packfile.DiffDelta(baseContent, content); len(delta) < len(content) {
+					if err := writePackObject(&buf, plumbing.REFDeltaObject, delta, base, level); err != nil {
+						return nil, err
+					}
+					continue
+				}
+			}
+		}
+		if err := writePackObject(&buf, obj.Type(), content, plumbing.ZeroHash, level); err != nil {
+			return nil, err
+		}
+	}
+	sum := sha1.Sum(buf.Bytes())
+	buf.Write(sum[:])
+	return buf.Bytes(), nil
+}
+
+// writePackObject writes one pack entry: the type and size, the base of a
+// delta, and the data compressed at level
+func writePackObject(buf *bytes.Buffer, typ plumbing.ObjectType, data []byte, base plumbing.Hash, level int) error {
+	size := len(data)
+	c := byte(typ)<<4 | byte(size&0x0f)
+	for size >>= 4; size != 0; size >>= 7 {
+		buf.WriteByte(c | 0x80)
+		c = byte(size & 0x7f)
+	}
+	buf.WriteByte(c)
+	if typ == plumbing.REFDeltaObject {
+		buf.Write(base[:])
+	}
+	zw, err := zlib.NewWriterLevel(buf, level)
+	if err != nil {
+		return err
+	}
+	if _, err := zw.Write(data); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func readObject(obj plumbing.EncodedObject) ([]byte, error) {
+	reader, err := obj.Reader()
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return io.ReadAll(reader)
+}
+
+// fixThinPack reads a thin pack and returns a complete one, taking the
+// objects its deltas refer to from known, as git index-pack --fix-thin does
+func fixThinPack(pack io.Reader, known storer.EncodedObjectStorer) ([]byte, error) {
+	received := &knownObjects{ObjectStorage: &memory.NewStorage().ObjectStorage, known: known}
+	parser, err := packfile.NewParserWithStorage(packfile.NewScanner(pack), received)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := parser.Parse(); err != nil {
+		return nil, err
+	}
+
+	hashes := make([]plumbing.Hash, 0, len(received.Objects))
+	for h := range received.Objects {
+		hashes = append(hashes, h)
+	}
+	var buf bytes.Buffer
+	if _, err := packfile.NewEncoder(&buf, received, false).Encode(hashes, 10); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// knownObjects holds received objects in memory, looking up the ones it
+// lacks in known
+type knownObjects struct {
+	*memory.ObjectStorage
+	known storer.EncodedObjectStorer
+}
+
+func (k *knownObjects) EncodedObject(t plumbing.ObjectType, h plumbing.Hash) (plumbing.EncodedObject, error) {
+	obj, err := k.ObjectStorage.EncodedObject(t, h)
+	if err == plumbing.ErrObjectNotFound {
+		return k.known.EncodedObject(t, h)
+	}
+	return obj, err
+}
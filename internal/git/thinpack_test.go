@@ -0,0 +1,97 @@
+package git
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/revlist"
+)
+
+func TestPushSendsThinPacks(t *testing.T) {
+	repo, _, cleanup := setupTestRepo(t)
+	defer cleanup()
+	if err := repo.Init(); err != nil {
+		t.Fatal(err)
+	}
+	remote := filepath.Join(t.TempDir(), "backup.git")
+	if err := repo.SetRemote(RemoteName, remote); err != nil {
+		t.Fatal(err)
+	}
+
+	var lines []string
+	for i := 0; i < 5000; i++ {
+		lines = append(lines, fmt.Sprintf("line %d of a long history", i))
+	}
+	content := strings.Join(lines, "\n")
+	commit := func(num int, text string) string {
+		hash, err := repo.CommitContent([]byte(text), fmt.Sprintf("v%d", num), time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := repo.Tag(fmt.Sprintf("v%d", num)); err != nil {
+			t.Fatal(err)
+		}
+		return hash
+	}
+	first := commit(1, content)
+	if err := repo.Push(RemoteName, Auth{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Two small edits send two small deltas, one against a blob only the
+	// remote side has
+	commit(2, strings.Replace(content, "line 10 ", "line ten ", 1))
+	second := commit(3, strings.Replace(content, "line 4000 ", "line four thousand ", 1))
+	r, err := repo.openRepo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	objects, err := revlist.Objects(r.Storer, []plumbing.Hash{plumbing.NewHash(second)}, []plumbing.Hash{plumbing.NewHash(first)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	pack, err := repo.thinPack(r, objects)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pack) > 2000 {
+		t.Errorf("pack of two small edits = %d bytes, want a thin one", len(pack))
+	}
+
+	if err := repo.Push(RemoteName, Auth{}); err != nil {
+		t.Fatalf("Push of a thin pack failed: %v", err)
+	}
+	state, err := repo.Fetch(RemoteName, Auth{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if state.Head != second || len(state.Tags) != 3 {
+		t.Errorf("remote after push = %s with %d tags, want %s with 3", state.Head, len(state.Tags), second)
+	}
+
+	// Another copy gets complete snapshots from the remote
+	other := NewRepo(filepath.Join(t.TempDir(), "test.txt.git"), t.TempDir(), "test.txt")
+	if err := other.Init(); err != nil {
+		t.Fatal(err)
+	}
+	if err := other.SetRemote(RemoteName, remote); err != nil {
+		t.Fatal(err)
+	}
+	state, err = other.Fetch(RemoteName, Auth{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := other.FastForward(state.Head, state.Tags); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range []string{"v1", "v2", "v3"} {
+		want, _ := repo.Show(tag)
+		if got, err := other.Show(tag); err != nil || string(got) != string(want) {
+			t.Errorf("%s from the remote differs: %v", tag, err)
+		}
+	}
+}
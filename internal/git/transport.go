@@ -0,0 +1,87 @@
+package git
+
+import (
+	"bytes"
+	"context"
+	"io"
+
+	"github.com/go-git/go-git/v5/plumbing"
+	"github.com/go-git/go-git/v5/plumbing/protocol/packp"
+	"github.com/go-git/go-git/v5/plumbing/storer"
+	"github.com/go-git/go-git/v5/plumbing/transport"
+	"github.com/go-git/go-git/v5/plumbing/transport/client"
+	"github.com/go-git/go-git/v5/plumbing/transport/server"
+)
+
+func init() {
+	// Serve local paths in-process, so backing up to a folder or drive
+	// works without git installed
+	client.InstallProtocol("file", localServer{server.DefaultServer})
+}
+
+// localServer is go-git's in-process server, except that fetches may name
+// commits the served repository lacks, as they do once histories diverge
+type localServer struct {
+	transport.Transport
+}
+
+func (l localServer) NewUploadPackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.UploadPackSession, error) {
+	sto, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		return nil, err
+	}
+	session, err := l.Transport.NewUploadPackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	return knownHaves{session, sto}, nil
+}
+
+// knownHaves drops the commits the client has that the server does not
+// know, which the server would otherwise fail on
+type knownHaves struct {
+	transport.UploadPackSession
+	objects storer.EncodedObjectStorer
+}
+
+func (k knownHaves) UploadPack(ctx context.Context, req *packp.UploadPackRequest) (*packp.UploadPackResponse, error) {
+	var haves []plumbing.Hash
+	for _, h := range req.Haves {
+		if k.objects.HasEncodedObject(h) == nil {
+			haves = append(haves, h)
+		}
+	}
+	req.Haves = haves
+	return k.UploadPackSession.UploadPack(ctx, req)
+}
+
+func (l localServer) NewReceivePackSession(ep *transport.Endpoint, auth transport.AuthMethod) (transport.ReceivePackSession, error) {
+	sto, err := server.DefaultLoader.Load(ep)
+	if err != nil {
+		return nil, err
+	}
+	session, err := l.Transport.NewReceivePackSession(ep, auth)
+	if err != nil {
+		return nil, err
+	}
+	return thinPacks{session, sto}, nil
+}
+
+// thinPacks completes the thin packs pushes send before they are stored,
+// as go-git's server stores packs as they are
+type thinPacks struct {
+	transport.ReceivePackSession
+	objects storer.EncodedObjectStorer
+}
+
+func (t thinPacks) ReceivePack(ctx context.Context, req *packp.ReferenceUpdateRequest) (*packp.ReportStatus, error) {
+	if req.Packfile != nil {
+		pack, err := fixThinPack(req.Packfile, t.objects)
+		req.Packfile.Close()
+		if err != nil {
+			return nil, err
+		}
+		req.Packfile = io.NopCloser(bytes.NewReader(pack))
+	}
+	return t.ReceivePackSession.ReceivePack(ctx, req)
+}
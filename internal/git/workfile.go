@@ -0,0 +1,74 @@
+package git
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// Editors that save by writing a new file and renaming it over the old
+// one, or by truncating and rewriting it, can leave the work file missing
+// or half written for a moment. Reads of the work file check that it was
+// the same file, unchanged, from start to end, and try again if not, so a
+// partial copy is never stored.
+
+var (
+	// ErrFileMissing is returned when the work file is not there to read.
+	// It matches os.ErrNotExist with errors.Is.
+	ErrFileMissing = fmt.Errorf("the file is missing: %w", os.ErrNotExist)
+
+	// ErrFileChanging is returned when the work file was replaced or
+	// written to every time it was read
+	ErrFileChanging = errors.New("the file kept changing while it was read")
+)
+
+// readAttempts is how many times a read of the work file is tried
+const readAttempts = 3
+
+// readRetryDelay is how long to wait before reading a changing file again
+var readRetryDelay = 100 * time.Millisecond
+
+// sameVersion reports whether two stats of the work file are of the same
+// file with the same content, as far as can be told without reading it
+func (r *Repo) sameVersion(a, b os.FileInfo) bool {
+	if a.Size() != b.Size() {
+		return false
+	}
+	// In-memory filesystems stamp every stat with the current time
+	if r.inMemory {
+		return true
+	}
+	// A file renamed over the old one may keep its size and time
+	return a.ModTime().Equal(b.ModTime()) && os.SameFile(a, b)
+}
+
+// stableRead runs read on the work file, retrying while the file is
+// replaced or changed during the read. Returns ErrFileMissing if the file
+// is gone and ErrFileChanging if it never held still.
+func (r *Repo) stableRead(read func() error) error {
+	for attempt := 1; ; attempt++ {
+		before, err := r.workFS.Stat(r.FileName)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				return err
+			}
+			if attempt == readAttempts {
+				return ErrFileMissing
+			}
+		} else {
+			err = read()
+			after, statErr := r.workFS.Stat(r.FileName)
+			if statErr == nil && r.sameVersion(before, after) {
+				return err
+			}
+			if attempt == readAttempts {
+				if statErr != nil && os.IsNotExist(statErr) {
+					return ErrFileMissing
+				}
+				return ErrFileChanging
+			}
+		}
+		time.Sleep(readRetryDelay)
+	}
+}
@@ -0,0 +1,49 @@
+package git
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestStableRead(t *testing.T) {
+	repo, tmpDir, cleanup := setupTestRepo(t)
+	defer cleanup()
+	repo.Init()
+	defer func(d time.Duration) { readRetryDelay = d }(readRetryDelay)
+	readRetryDelay = time.Millisecond
+	path := filepath.Join(tmpDir, repo.FileName)
+
+	// Written to during the first read only: the second read is kept
+	reads := 0
+	err := repo.stableRead(func() error {
+		if reads++; reads == 1 {
+			os.WriteFile(path, []byte("rewritten by an editor"), 0644)
+		}
+		return nil
+	})
+	if err != nil || reads != 2 {
+		t.Errorf("stableRead = %v after %d reads, want success after 2", err, reads)
+	}
+
+	// Written to during every read
+	err = repo.stableRead(func() error {
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0644)
+		f.WriteString("more")
+		return f.Close()
+	})
+	if !errors.Is(err, ErrFileChanging) {
+		t.Errorf("stableRead of a changing file = %v, want ErrFileChanging", err)
+	}
+
+	// Gone before it is staged
+	os.Remove(path)
+	if err := repo.Add(); !errors.Is(err, ErrFileMissing) {
+		t.Errorf("Add of a missing file = %v, want ErrFileMissing", err)
+	}
+	if _, err := repo.ReadWorkFile(); !errors.Is(err, ErrFileMissing) {
+		t.Errorf("ReadWorkFile of a missing file = %v, want ErrFileMissing", err)
+	}
+}
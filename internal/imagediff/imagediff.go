@@ -0,0 +1,275 @@
+// Package imagediff describes and compares image snapshots: dimensions,
+// a perceptual hash, and how many pixels differ between two versions.
+package imagediff
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"html/template"
+	"image"
+	"image/color"
+	_ "image/gif" // register decoders for image.Decode
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math/bits"
+	"path/filepath"
+	"strings"
+)
+
+// Info describes one version of an image
+type Info struct {
+	Format string `json:"format"` // "png", "jpeg" or "gif"
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+	Hash   uint64 `json:"hash"` // perceptual (difference) hash
+}
+
+// Size formats the dimensions, e.g. "1920x1080"
+func (i Info) Size() string {
+	return fmt.Sprintf("%dx%d", i.Width, i.Height)
+}
+
+// Comparison describes how two versions of an image differ
+type Comparison struct {
+	Old, New     Info
+	DiffRatio    float64 // fraction of pixels that differ, 0 to 1
+	HashDistance int     // bits that differ between the perceptual hashes
+}
+
+// String summarizes the comparison, e.g.
+// "1920x1080 → 1280x720, 43% pixels differ"
+func (c *Comparison) String() string {
+	size := c.Old.Size()
+	if c.New.Size() != size {
+		size += " → " + c.New.Size()
+	}
+	if c.New.Format != c.Old.Format {
+		size += fmt.Sprintf(" (%s → %s)", c.Old.Format, c.New.Format)
+	}
+	return fmt.Sprintf("%s, %s pixels differ", size, formatPercent(c.DiffRatio))
+}
+
+func formatPercent(ratio float64) string {
+	pct := ratio * 100
+	switch {
+	case ratio == 0:
+		return "0%"
+	case pct < 1:
+		return "<1%"
+	}
+	return fmt.Sprintf("%.0f%%", pct)
+}
+
+// IsImage reports whether fileName has an image extension this package
+// can decode
+func IsImage(fileName string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".png", ".jpg", ".jpeg", ".gif":
+		return true
+	}
+	return false
+}
+
+// Inspect decodes data and describes the image
+func Inspect(data []byte) (Info, error) {
+	img, format, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return Info{}, err
+	}
+	return describe(img, format), nil
+}
+
+func describe(img image.Image, format string) Info {
+	b := img.Bounds()
+	return Info{
+		Format: format,
+		Width:  b.Dx(),
+		Height: b.Dy(),
+		Hash:   dHash(img),
+	}
+}
+
+// Compare decodes two versions of an image and compares them
+func Compare(oldData, newData []byte) (*Comparison, error) {
+	oldImg, oldFormat, err := image.Decode(bytes.NewReader(oldData))
+	if err != nil {
+		return nil, fmt.Errorf("old version: %w", err)
+	}
+	newImg, newFormat, err := image.Decode(bytes.NewReader(newData))
+	if err != nil {
+		return nil, fmt.Errorf("new version: %w", err)
+	}
+
+	c := &Comparison{
+		Old:       describe(oldImg, oldFormat),
+		New:       describe(newImg, newFormat),
+		DiffRatio: diffRatio(oldImg, newImg),
+	}
+	c.HashDistance = bits.OnesCount64(c.Old.Hash ^ c.New.Hash)
+	return c, nil
+}
+
+// maxCompareSide caps the grid pixels are compared on, so large images
+// are sampled rather than compared pixel by pixel
+const maxCompareSide = 1024
+
+// channelTolerance is how far a color channel (0-65535) may move before
+// the pixel counts as different, absorbing lossy re-encoding noise
+const channelTolerance = 0x1000
+
+// diffRatio samples both images on a common grid and returns the
+// fraction of samples that differ. Images of different sizes are
+// compared after scaling to the same grid.
+func diffRatio(a, b image.Image) float64 {
+	w := min(a.Bounds().Dx(), b.Bounds().Dx(), maxCompareSide)
+	h := min(a.Bounds().Dy(), b.Bounds().Dy(), maxCompareSide)
+	if w == 0 || h == 0 {
+		return 1
+	}
+
+	differ := 0
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if !similar(sample(a, x, y, w, h), sample(b, x, y, w, h)) {
+				differ++
+			}
+		}
+	}
+	return float64(differ) / float64(w*h)
+}
+
+// sample returns the pixel of img at grid cell (x, y) of a w by h grid
+func sample(img image.Image, x, y, w, h int) color.Color {
+	b := img.Bounds()
+	return img.At(b.Min.X+x*b.Dx()/w, b.Min.Y+y*b.Dy()/h)
+}
+
+func similar(c1, c2 color.Color) bool {
+	r1, g1, b1, a1 := c1.RGBA()
+	r2, g2, b2, a2 := c2.RGBA()
+	return near(r1, r2) && near(g1, g2) && near(b1, b2) && near(a1, a2)
+}
+
+func near(a, b uint32) bool {
+	if a > b {
+		return a-b <= channelTolerance
+	}
+	return b-a <= channelTolerance
+}
+
+// dHash computes a 64-bit difference hash: the image is shrunk to 9x8
+// grayscale and each bit records whether a pixel is brighter than its
+// right neighbour. Similar-looking images have hashes a few bits apart.
+func dHash(img image.Image) uint64 {
+	b := img.Bounds()
+	if b.Empty() {
+		return 0
+	}
+	var hash uint64
+	for y := 0; y < 8; y++ {
+		for x := 0; x < 8; x++ {
+			left := gray(sample(img, x, y, 9, 8))
+			right := gray(sample(img, x+1, y, 9, 8))
+			hash <<= 1
+			if left > right {
+				hash |= 1
+			}
+		}
+	}
+	return hash
+}
+
+func gray(c color.Color) uint32 {
+	r, g, b, _ := c.RGBA()
+	return (299*r + 587*g + 114*b) / 1000
+}
+
+// thumbnailSide is the longest side of thumbnails in HTML reports
+const thumbnailSide = 320
+
+// Thumbnail decodes data and returns a PNG scaled to fit within
+// thumbnailSide pixels
+func Thumbnail(data []byte) ([]byte, error) {
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	b := img.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w > thumbnailSide || h > thumbnailSide {
+		if w >= h {
+			w, h = thumbnailSide, max(1, h*thumbnailSide/w)
+		} else {
+			w, h = max(1, w*thumbnailSide/h), thumbnailSide
+		}
+	}
+
+	thumb := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			thumb.Set(x, y, sample(img, x, y, w, h))
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, thumb); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+var reportTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>{{.Name}}: {{.OldLabel}} → {{.NewLabel}}</title>
+<style>
+body { font-family: sans-serif; margin: 2em; }
+.side { display: inline-block; margin-right: 2em; vertical-align: top; }
+img { border: 1px solid #ccc; image-rendering: pixelated; }
+</style>
+</head>
+<body>
+<h1>{{.Name}}</h1>
+<p>{{.Summary}}</p>
+<div class="side"><h2>{{.OldLabel}}</h2><img src="{{.OldThumb}}"><p>{{.Old.Size}} {{.Old.Format}}</p></div>
+<div class="side"><h2>{{.NewLabel}}</h2><img src="{{.NewThumb}}"><p>{{.New.Size}} {{.New.Format}}</p></div>
+</body>
+</html>
+`))
+
+// WriteHTML writes a page showing thumbnails of both versions side by
+// side with the comparison summary. The page is self-contained.
+func WriteHTML(w io.Writer, name, oldLabel, newLabel string, oldData, newData []byte, c *Comparison) error {
+	oldThumb, err := Thumbnail(oldData)
+	if err != nil {
+		return err
+	}
+	newThumb, err := Thumbnail(newData)
+	if err != nil {
+		return err
+	}
+
+	return reportTemplate.Execute(w, struct {
+		Name, OldLabel, NewLabel string
+		Summary                  string
+		Old, New                 Info
+		OldThumb, NewThumb       template.URL
+	}{
+		Name:     name,
+		OldLabel: oldLabel,
+		NewLabel: newLabel,
+		Summary:  c.String(),
+		Old:      c.Old,
+		New:      c.New,
+		OldThumb: dataURL(oldThumb),
+		NewThumb: dataURL(newThumb),
+	})
+}
+
+func dataURL(png []byte) template.URL {
+	return template.URL("data:image/png;base64," + base64.StdEncoding.EncodeToString(png))
+}
@@ -0,0 +1,90 @@
+package imagediff
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+// encodePNG returns a w by h PNG, filled white with the right fill
+// columns black
+func encodePNG(t *testing.T, w, h, fill int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{255, 255, 255, 255}
+			if x >= w-fill {
+				c = color.RGBA{0, 0, 0, 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestInspect(t *testing.T) {
+	info, err := Inspect(encodePNG(t, 40, 20, 0))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Format != "png" || info.Size() != "40x20" {
+		t.Errorf("Inspect = %+v, want 40x20 png", info)
+	}
+
+	if _, err := Inspect([]byte("not an image")); err == nil {
+		t.Error("Inspect should fail for non-image data")
+	}
+}
+
+func TestCompare(t *testing.T) {
+	c, err := Compare(encodePNG(t, 100, 10, 0), encodePNG(t, 100, 10, 25))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.String(); got != "100x10, 25% pixels differ" {
+		t.Errorf("String = %q", got)
+	}
+	if c.HashDistance == 0 {
+		t.Error("perceptual hashes should differ")
+	}
+
+	c, _ = Compare(encodePNG(t, 100, 10, 0), encodePNG(t, 50, 5, 0))
+	if got := c.String(); got != "100x10 → 50x5, 0% pixels differ" {
+		t.Errorf("String after resize = %q", got)
+	}
+}
+
+func TestWriteHTML(t *testing.T) {
+	oldData, newData := encodePNG(t, 800, 600, 0), encodePNG(t, 800, 600, 400)
+	c, _ := Compare(oldData, newData)
+
+	var buf bytes.Buffer
+	if err := WriteHTML(&buf, "logo.png", "#1", "#2", oldData, newData, c); err != nil {
+		t.Fatal(err)
+	}
+	page := buf.String()
+	if strings.Count(page, "data:image/png;base64,") != 2 {
+		t.Error("page should embed two thumbnails")
+	}
+	if !strings.Contains(page, "50% pixels differ") {
+		t.Error("page should include the summary")
+	}
+}
+
+func TestIsImage(t *testing.T) {
+	for name, want := range map[string]bool{
+		"logo.PNG": true, "photo.jpeg": true, "anim.gif": true, "notes.md": false,
+	} {
+		if got := IsImage(name); got != want {
+			t.Errorf("IsImage(%q) = %v", name, got)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Package netfs detects files on network filesystems (NFS, SMB/CIFS and
+// similar), where locking, durability and latency differ from local disks
+// and the store adjusts how it works.
+package netfs
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Info describes the filesystem holding a path
+type Info struct {
+	Network bool
+	Type    string // e.g. "nfs", "smb", "ext4"; "" if unknown
+}
+
+// Detect reports the filesystem of path, or of its nearest existing
+// parent when path does not exist yet. Detection never fails: a
+// filesystem that cannot be identified is reported as local.
+func Detect(path string) Info {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Info{}
+	}
+	if isUNC(abs) {
+		return Info{Network: true, Type: "smb"}
+	}
+	for {
+		if _, err := os.Stat(abs); err == nil {
+			return detect(abs)
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return Info{}
+		}
+		abs = parent
+	}
+}
+
+// isUNC reports whether path names a Windows share, \\server\share
+func isUNC(path string) bool {
+	p := strings.ReplaceAll(path, `\`, "/")
+	return strings.HasPrefix(p, "//") && !strings.HasPrefix(p, "//?/") && !strings.HasPrefix(p, "//./")
+}
+
+// networkTypes are filesystem type names of network filesystems
+var networkTypes = map[string]bool{
+	"nfs": true, "nfs4": true, "smb": true, "smbfs": true, "smb2": true,
+	"smb3": true, "cifs": true, "afpfs": true, "webdav": true, "afs": true,
+	"ncpfs": true, "9p": true, "ceph": true, "glusterfs": true,
+	"fuse.sshfs": true, "sshfs": true,
+}
+
+// typeInfo returns the Info for a filesystem type name
+func typeInfo(name string) Info {
+	return Info{Network: networkTypes[name], Type: name}
+}
@@ -0,0 +1,18 @@
+package netfs
+
+import "syscall"
+
+func detect(path string) Info {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return Info{}
+	}
+	var name []byte
+	for _, c := range st.Fstypename {
+		if c == 0 {
+			break
+		}
+		name = append(name, byte(c))
+	}
+	return typeInfo(string(name))
+}
@@ -0,0 +1,30 @@
+package netfs
+
+import "syscall"
+
+// magics maps statfs filesystem magic numbers to type names
+var magics = map[int64]string{
+	0x6969:     "nfs",
+	0x517b:     "smb",
+	0xff534d42: "cifs",
+	0xfe534d42: "smb2",
+	0x5346414f: "afs",
+	0x564c:     "ncpfs",
+	0x01021997: "9p",
+	0x00c36400: "ceph",
+	0xef53:     "ext4",
+	0x58465342: "xfs",
+	0x9123683e: "btrfs",
+	0x01021994: "tmpfs",
+	0x794c7630: "overlay",
+	0x2fc12fc1: "zfs",
+	0x65735546: "fuse",
+}
+
+func detect(path string) Info {
+	var st syscall.Statfs_t
+	if err := syscall.Statfs(path, &st); err != nil {
+		return Info{}
+	}
+	return typeInfo(magics[int64(st.Type)&0xffffffff])
+}
@@ -0,0 +1,7 @@
+//go:build !linux && !darwin && !windows
+
+package netfs
+
+func detect(path string) Info {
+	return Info{}
+}
@@ -0,0 +1,41 @@
+package netfs
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestIsUNC(t *testing.T) {
+	tests := map[string]bool{
+		`\\server\share\notes.txt`: true,
+		"//server/share/notes.txt": true,
+		`\\?\C:\notes.txt`:         false,
+		`\\.\pipe\oops`:            false,
+		"/home/user/notes.txt":     false,
+		`C:\notes.txt`:             false,
+	}
+	for path, want := range tests {
+		if got := isUNC(path); got != want {
+			t.Errorf("isUNC(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
+
+func TestTypeInfo(t *testing.T) {
+	for _, name := range []string{"nfs", "smbfs", "cifs"} {
+		if !typeInfo(name).Network {
+			t.Errorf("%s should be a network filesystem", name)
+		}
+	}
+	if info := typeInfo("ext4"); info.Network || info.Type != "ext4" {
+		t.Errorf("typeInfo(ext4) = %+v", info)
+	}
+}
+
+func TestDetectMissingPath(t *testing.T) {
+	dir := t.TempDir()
+	// A path not created yet is judged by its nearest existing parent
+	if got, want := Detect(filepath.Join(dir, "a", "b.txt")), Detect(dir); got != want {
+		t.Errorf("Detect(missing) = %+v, want %+v", got, want)
+	}
+}
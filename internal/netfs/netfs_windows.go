@@ -0,0 +1,26 @@
+package netfs
+
+import (
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var procGetDriveType = syscall.NewLazyDLL("kernel32.dll").NewProc("GetDriveTypeW")
+
+const driveRemote = 4 // DRIVE_REMOTE, a mapped network drive
+
+func detect(path string) Info {
+	drive := filepath.VolumeName(path)
+	if len(drive) != 2 || drive[1] != ':' {
+		return Info{}
+	}
+	root, err := syscall.UTF16PtrFromString(drive + `\`)
+	if err != nil {
+		return Info{}
+	}
+	if t, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(root))); t == driveRemote {
+		return Info{Network: true, Type: "smb"}
+	}
+	return Info{}
+}
@@ -0,0 +1,89 @@
+// Package promtext writes metrics in the Prometheus text exposition
+// format, for node_exporter's textfile collector to pick up.
+package promtext
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Label is one name="value" pair of a sample
+type Label struct {
+	Name, Value string
+}
+
+// Sample is one value of a metric
+type Sample struct {
+	Labels []Label
+	Value  float64
+}
+
+// Metric is a gauge with its samples
+type Metric struct {
+	Name    string
+	Help    string
+	Samples []Sample
+}
+
+// Write writes metrics to w in the text format, each with its HELP and
+// TYPE lines. Metrics without samples are left out.
+func Write(w io.Writer, metrics []Metric) error {
+	var buf bytes.Buffer
+	for _, m := range metrics {
+		if len(m.Samples) == 0 {
+			continue
+		}
+		fmt.Fprintf(&buf, "# HELP %s %s\n", m.Name, escapeHelp(m.Help))
+		fmt.Fprintf(&buf, "# TYPE %s gauge\n", m.Name)
+		for _, s := range m.Samples {
+			buf.WriteString(m.Name)
+			if len(s.Labels) > 0 {
+				labels := make([]string, len(s.Labels))
+				for i, l := range s.Labels {
+					labels[i] = l.Name + `="` + escapeValue(l.Value) + `"`
+				}
+				sort.Strings(labels)
+				buf.WriteString("{" + strings.Join(labels, ",") + "}")
+			}
+			buf.WriteString(" " + strconv.FormatFloat(s.Value, 'f', -1, 64) + "\n")
+		}
+	}
+	_, err := w.Write(buf.Bytes())
+	return err
+}
+
+// WriteFile writes metrics to path through a temporary file renamed over
+// it, as the textfile collector may read the file at any moment
+func WriteFile(path string, metrics []Metric) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := Write(tmp, metrics); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// The collector often runs as another user
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+var (
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+	valueEscaper = strings.NewReplacer(`\`, `\\`, "\n", `\n`, `"`, `\"`)
+)
+
+func escapeHelp(text string) string   { return helpEscaper.Replace(text) }
+func escapeValue(value string) string { return valueEscaper.Replace(value) }
@@ -0,0 +1,47 @@
+package promtext
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWrite(t *testing.T) {
+	var out strings.Builder
+	err := Write(&out, []Metric{
+		{Name: "oops_snapshots", Help: "Snapshots kept", Samples: []Sample{
+			{Labels: []Label{{"store", "local"}, {"file", `C:\notes "a".md`}}, Value: 12},
+		}},
+		{Name: "oops_empty", Help: "Left out"},
+		{Name: "oops_age_seconds", Help: "Age", Samples: []Sample{{Value: 1.5}}},
+	})
+	if err != nil {
+		t.Fatalf("Write failed: %v", err)
+	}
+	want := `# HELP oops_snapshots Snapshots kept
+# TYPE oops_snapshots gauge
+oops_snapshots{file="C:\\notes \"a\".md",store="local"} 12
+# HELP oops_age_seconds Age
+# TYPE oops_age_seconds gauge
+oops_age_seconds 1.5
+`
+	if out.String() != want {
+		t.Errorf("Write =\n%s\nwant\n%s", out.String(), want)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "oops.prom")
+	metrics := []Metric{{Name: "oops_up", Help: "Up", Samples: []Sample{{Value: 1}}}}
+	if err := WriteFile(path, metrics); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	if data, _ := os.ReadFile(path); !strings.Contains(string(data), "oops_up 1\n") {
+		t.Errorf("file = %q, want the metric", data)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d entries, want no temporary file left", len(entries))
+	}
+}
@@ -0,0 +1,77 @@
+// Package redact masks sensitive text, such as credentials, before
+// content is stored in a snapshot.
+package redact
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+)
+
+// Mask replaces redacted text
+const Mask = "[REDACTED]"
+
+// Redactor masks every match of a set of patterns
+type Redactor struct {
+	patterns []*regexp.Regexp
+}
+
+// New compiles patterns into a Redactor. A pattern with capture groups
+// masks only the groups, so "(?i)password\s*=\s*(\S+)" keeps the key name.
+func New(patterns []string) (*Redactor, error) {
+	r := &Redactor{}
+	for _, p := range patterns {
+		re, err := regexp.Compile(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redact pattern %q: %w", p, err)
+		}
+		r.patterns = append(r.patterns, re)
+	}
+	return r, nil
+}
+
+// Empty reports whether the Redactor has no patterns
+func (r *Redactor) Empty() bool {
+	return len(r.patterns) == 0
+}
+
+// Apply returns data with every match masked
+func (r *Redactor) Apply(data []byte) []byte {
+	for _, re := range r.patterns {
+		data = apply(re, data)
+	}
+	return data
+}
+
+func apply(re *regexp.Regexp, data []byte) []byte {
+	matches := re.FindAllSubmatchIndex(data, -1)
+	if matches == nil {
+		return data
+	}
+
+	var out bytes.Buffer
+	last := 0
+	for _, m := range matches {
+		// Spans to mask: the capture groups if any, otherwise the whole match
+		spans := [][2]int{{m[0], m[1]}}
+		if re.NumSubexp() > 0 {
+			spans = spans[:0]
+			for g := 1; g <= re.NumSubexp(); g++ {
+				start, end := m[2*g], m[2*g+1]
+				if start >= 0 && end > start && start >= last {
+					spans = append(spans, [2]int{start, end})
+				}
+			}
+		}
+		for _, span := range spans {
+			if span[0] < last {
+				continue // Nested group already masked
+			}
+			out.Write(data[last:span[0]])
+			out.WriteString(Mask)
+			last = span[1]
+		}
+	}
+	out.Write(data[last:])
+	return out.Bytes()
+}
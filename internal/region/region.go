@@ -0,0 +1,98 @@
+// Package region extracts and replaces a delimited block of lines, so a
+// part of a larger file can be versioned on its own.
+//
+// The region is made of the lines strictly between the first line
+// containing the start marker and the next line containing the end
+// marker. Markers are matched anywhere on their line, so they can sit in
+// whatever comment syntax the file uses, e.g. "# oops:start" or
+// "<!-- oops:start -->".
+package region
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Markers delimit a region
+type Markers struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Default markers
+var Default = Markers{Start: "oops:start", End: "oops:end"}
+
+// Validate checks that the markers can delimit a region
+func (m Markers) Validate() error {
+	switch {
+	case m.Start == "" || m.End == "":
+		return fmt.Errorf("region markers cannot be empty")
+	case m.Start == m.End:
+		return fmt.Errorf("region start and end markers must differ")
+	case strings.ContainsAny(m.Start+m.End, "\r\n"):
+		return fmt.Errorf("region markers must fit on one line")
+	}
+	return nil
+}
+
+// String describes the markers for display
+func (m Markers) String() string {
+	return fmt.Sprintf("between %q and %q", m.Start, m.End)
+}
+
+// find returns the byte offsets of the region in content
+func (m Markers) find(content []byte) (start, end int, ok bool) {
+	i := bytes.Index(content, []byte(m.Start))
+	if i < 0 {
+		return 0, 0, false
+	}
+	nl := bytes.IndexByte(content[i:], '\n')
+	if nl < 0 {
+		return 0, 0, false
+	}
+	start = i + nl + 1
+
+	j := bytes.Index(content[start:], []byte(m.End))
+	if j < 0 {
+		return 0, 0, false
+	}
+	// The region ends where the end marker's line begins
+	end = start + bytes.LastIndexByte(content[start:start+j], '\n') + 1
+	return start, end, true
+}
+
+// Extract returns the region of content
+func (m Markers) Extract(content []byte) ([]byte, bool) {
+	start, end, ok := m.find(content)
+	if !ok {
+		return nil, false
+	}
+	return content[start:end], true
+}
+
+// Splice returns content with its region replaced by region. If content
+// has no region, the markers and region are appended.
+func (m Markers) Splice(content, region []byte) []byte {
+	if len(region) > 0 && region[len(region)-1] != '\n' {
+		region = append(region[:len(region):len(region)], '\n')
+	}
+
+	start, end, ok := m.find(content)
+	if !ok {
+		var b bytes.Buffer
+		b.Write(content)
+		if len(content) > 0 && content[len(content)-1] != '\n' {
+			b.WriteByte('\n')
+		}
+		b.WriteString(m.Start + "\n")
+		b.Write(region)
+		b.WriteString(m.End + "\n")
+		return b.Bytes()
+	}
+
+	out := make([]byte, 0, len(content)-(end-start)+len(region))
+	out = append(out, content[:start]...)
+	out = append(out, region...)
+	return append(out, content[end:]...)
+}
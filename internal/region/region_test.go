@@ -0,0 +1,56 @@
+package region
+
+import "testing"
+
+const shared = `# managed by tool
+setting=1
+# oops:start
+alias ll='ls -l'
+alias gs='git status'
+# oops:end
+# more managed lines
+`
+
+func TestExtract(t *testing.T) {
+	got, ok := Default.Extract([]byte(shared))
+	want := "alias ll='ls -l'\nalias gs='git status'\n"
+	if !ok || string(got) != want {
+		t.Errorf("Extract = %q, %v; want %q", got, ok, want)
+	}
+
+	empty, ok := Default.Extract([]byte("# oops:start\n# oops:end\n"))
+	if !ok || len(empty) != 0 {
+		t.Errorf("empty region = %q, %v", empty, ok)
+	}
+
+	for _, content := range []string{"no markers\n", "# oops:start\nonly start\n", "# oops:end\n# oops:start"} {
+		if _, ok := Default.Extract([]byte(content)); ok {
+			t.Errorf("Extract(%q) should not find a region", content)
+		}
+	}
+}
+
+func TestSplice(t *testing.T) {
+	got := Default.Splice([]byte(shared), []byte("alias x=y"))
+	want := "# managed by tool\nsetting=1\n# oops:start\nalias x=y\n# oops:end\n# more managed lines\n"
+	if string(got) != want {
+		t.Errorf("Splice = %q, want %q", got, want)
+	}
+
+	// Without markers the region is appended
+	got = Default.Splice([]byte("top"), []byte("a\n"))
+	if want := "top\noops:start\na\noops:end\n"; string(got) != want {
+		t.Errorf("Splice without markers = %q, want %q", got, want)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	for _, m := range []Markers{{"", "end"}, {"x", "x"}, {"a\nb", "c"}} {
+		if m.Validate() == nil {
+			t.Errorf("Validate(%+v) should fail", m)
+		}
+	}
+	if err := (Markers{"BEGIN", "END"}).Validate(); err != nil {
+		t.Error(err)
+	}
+}
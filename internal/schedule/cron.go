@@ -0,0 +1,153 @@
+package schedule
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Cron is a parsed five-field cron expression (minute hour day month weekday)
+type Cron struct {
+	expr    string
+	minute  []bool
+	hour    []bool
+	day     []bool
+	month   []bool
+	weekday []bool
+	anyDay  bool // day-of-month field was "*"
+	anyWday bool // weekday field was "*"
+}
+
+// Shorthand expressions accepted in place of five fields
+var cronAliases = map[string]string{
+	"@hourly":   "0 * * * *",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@weekly":   "0 0 * * 0",
+	"@monthly":  "0 0 1 * *",
+	"@yearly":   "0 0 1 1 *",
+}
+
+// ParseCron parses a standard five-field cron expression
+func ParseCron(expr string) (*Cron, error) {
+	expr = strings.TrimSpace(expr)
+	fieldsExpr := expr
+	if alias, ok := cronAliases[expr]; ok {
+		fieldsExpr = alias
+	}
+
+	fields := strings.Fields(fieldsExpr)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("invalid cron expression %q: expected 5 fields", expr)
+	}
+
+	c := &Cron{expr: expr}
+	var err error
+	if c.minute, err = parseField(fields[0], 0, 59); err != nil {
+		return nil, fmt.Errorf("invalid minute in %q: %w", expr, err)
+	}
+	if c.hour, err = parseField(fields[1], 0, 23); err != nil {
+		return nil, fmt.Errorf("invalid hour in %q: %w", expr, err)
+	}
+	if c.day, err = parseField(fields[2], 1, 31); err != nil {
+		return nil, fmt.Errorf("invalid day in %q: %w", expr, err)
+	}
+	if c.month, err = parseField(fields[3], 1, 12); err != nil {
+		return nil, fmt.Errorf("invalid month in %q: %w", expr, err)
+	}
+	if c.weekday, err = parseField(fields[4], 0, 7); err != nil {
+		return nil, fmt.Errorf("invalid weekday in %q: %w", expr, err)
+	}
+	c.weekday[0] = c.weekday[0] || c.weekday[7] // 7 is also Sunday
+	c.anyDay = fields[2] == "*"
+	c.anyWday = fields[4] == "*"
+
+	return c, nil
+}
+
+// String returns the original expression
+func (c *Cron) String() string {
+	return c.expr
+}
+
+// Matches reports whether t falls in a minute selected by the expression
+func (c *Cron) Matches(t time.Time) bool {
+	if !c.minute[t.Minute()] || !c.hour[t.Hour()] || !c.month[int(t.Month())] {
+		return false
+	}
+
+	// Like classic cron, when both day fields are restricted either may match
+	dayMatch := c.day[t.Day()]
+	wdayMatch := c.weekday[int(t.Weekday())]
+	switch {
+	case c.anyDay && c.anyWday:
+		return true
+	case c.anyDay:
+		return wdayMatch
+	case c.anyWday:
+		return dayMatch
+	default:
+		return dayMatch || wdayMatch
+	}
+}
+
+// Next returns the first matching minute strictly after t, or the zero
+// time if none occurs within five years
+func (c *Cron) Next(t time.Time) time.Time {
+	next := t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for next.Before(limit) {
+		if c.Matches(next) {
+			return next
+		}
+		next = next.Add(time.Minute)
+	}
+	return time.Time{}
+}
+
+// parseField parses one cron field into a lookup table indexed by value
+func parseField(field string, min, max int) ([]bool, error) {
+	set := make([]bool, max+1)
+	for _, part := range strings.Split(field, ",") {
+		step := 1
+		if i := strings.Index(part, "/"); i >= 0 {
+			n, err := strconv.Atoi(part[i+1:])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("bad step %q", part)
+			}
+			step = n
+			part = part[:i]
+		}
+
+		lo, hi := min, max
+		switch {
+		case part == "*":
+		case strings.Contains(part, "-"):
+			bounds := strings.SplitN(part, "-", 2)
+			a, err1 := strconv.Atoi(bounds[0])
+			b, err2 := strconv.Atoi(bounds[1])
+			if err1 != nil || err2 != nil || a > b {
+				return nil, fmt.Errorf("bad range %q", part)
+			}
+			lo, hi = a, b
+		default:
+			n, err := strconv.Atoi(part)
+			if err != nil {
+				return nil, fmt.Errorf("bad value %q", part)
+			}
+			lo, hi = n, n
+			if step > 1 {
+				hi = max // "5/15" means from 5 every 15
+			}
+		}
+
+		if lo < min || hi > max {
+			return nil, fmt.Errorf("%d-%d out of range %d-%d", lo, hi, min, max)
+		}
+		for v := lo; v <= hi; v += step {
+			set[v] = true
+		}
+	}
+	return set, nil
+}
@@ -0,0 +1,54 @@
+package schedule
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseCronInvalid(t *testing.T) {
+	for _, expr := range []string{"", "* * * *", "60 * * * *", "* 24 * * *", "*/0 * * * *", "5-1 * * * *", "a * * * *"} {
+		if _, err := ParseCron(expr); err == nil {
+			t.Errorf("ParseCron(%q) should fail", expr)
+		}
+	}
+}
+
+func TestCronMatches(t *testing.T) {
+	// Friday 2026-10-16 18:00
+	fri := time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+
+	tests := []struct {
+		expr string
+		t    time.Time
+		want bool
+	}{
+		{"0 18 * * *", fri, true},
+		{"0 18 * * *", fri.Add(time.Minute), false},
+		{"*/15 * * * *", fri.Add(30 * time.Minute), true},
+		{"*/15 * * * *", fri.Add(20 * time.Minute), false},
+		{"0 9-17 * * 1-5", fri.Add(-time.Hour), true},
+		{"0 18 * * 0,6", fri, false},
+		{"0 18 * * 7", fri.AddDate(0, 0, 2), true},
+		{"0 18 1 * 5", fri, true}, // weekday matches, day does not
+		{"@daily", time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local), true},
+	}
+
+	for _, tt := range tests {
+		c, err := ParseCron(tt.expr)
+		if err != nil {
+			t.Fatalf("ParseCron(%q) failed: %v", tt.expr, err)
+		}
+		if got := c.Matches(tt.t); got != tt.want {
+			t.Errorf("%q.Matches(%v) = %v, want %v", tt.expr, tt.t, got, tt.want)
+		}
+	}
+}
+
+func TestCronNext(t *testing.T) {
+	c, _ := ParseCron("30 6 * * *")
+	from := time.Date(2026, 10, 16, 7, 0, 0, 0, time.Local)
+	want := time.Date(2026, 10, 17, 6, 30, 0, 0, time.Local)
+	if got := c.Next(from); !got.Equal(want) {
+		t.Errorf("Next = %v, want %v", got, want)
+	}
+}
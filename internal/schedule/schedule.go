@@ -0,0 +1,157 @@
+package schedule
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+)
+
+// DefaultMessage is used for scheduled snapshots without a message
+const DefaultMessage = "Scheduled snapshot"
+
+// Off is the cron value of a per-file schedule that exempts the file from
+// the default schedule (schedule_all) without scheduling it
+const Off = "off"
+
+// Result is the outcome of running one schedule
+type Result struct {
+	Schedule config.Schedule
+	Snapshot *store.Snapshot // nil when skipped or failed
+	Skipped  bool            // file had no changes
+	Err      error
+}
+
+// Due returns the schedules whose cron expression matches t.
+// Schedules with invalid expressions are never due.
+func Due(schedules []config.Schedule, t time.Time) []config.Schedule {
+	var due []config.Schedule
+	for _, sched := range schedules {
+		c, err := ParseCron(sched.Cron)
+		if err != nil {
+			continue
+		}
+		if c.Matches(t) {
+			due = append(due, sched)
+		}
+	}
+	return due
+}
+
+// Run saves a snapshot for a schedule, skipping files without changes
+func Run(sched config.Schedule) Result {
+	result := Result{Schedule: sched}
+
+	s, err := store.NewStoreWithOptions(sched.FilePath, store.StoreOptions{Global: sched.Global})
+	if err != nil {
+		result.Err = err
+		return result
+	}
+
+	message := sched.Message
+	if message == "" {
+		message = DefaultMessage
+	}
+
+	snapshot, err := s.SaveAs(message, store.OriginSchedule)
+	switch {
+	case err == store.ErrNoChanges:
+		result.Skipped = true
+	case err != nil:
+		result.Err = err
+	default:
+		result.Snapshot = snapshot
+	}
+	return result
+}
+
+// WithDefault returns the schedules in effect: the per-file schedules,
+// other than Off, plus the default cron for every tracked file that has
+// no schedule of its own
+func WithDefault(cfg *config.Config, tracked []store.TrackedFile) []config.Schedule {
+	type key struct {
+		path   string
+		global bool
+	}
+	own := map[key]bool{}
+	var schedules []config.Schedule
+	for _, sched := range cfg.Schedules {
+		own[key{sched.FilePath, sched.Global}] = true
+		if sched.Cron != Off {
+			schedules = append(schedules, sched)
+		}
+	}
+
+	if cfg.ScheduleAll == "" {
+		return schedules
+	}
+	for _, f := range tracked {
+		if !own[key{f.FilePath, f.Global}] {
+			schedules = append(schedules, config.Schedule{Cron: cfg.ScheduleAll, FilePath: f.FilePath, Global: f.Global})
+		}
+	}
+	return schedules
+}
+
+// RunDue runs every schedule in effect that is due at t
+func RunDue(t time.Time) ([]Result, error) {
+	cfg, err := config.Load()
+	if err != nil {
+		return nil, err
+	}
+
+	var tracked []store.TrackedFile
+	if cfg.ScheduleAll != "" {
+		if tracked, err = store.ListTrackedFiles(); err != nil {
+			return nil, err
+		}
+	}
+
+	var results []Result
+	for _, sched := range Due(WithDefault(cfg, tracked), t) {
+		results = append(results, Run(sched))
+	}
+	return results, nil
+}
+
+// Service runs due schedules once a minute inside the daemon.
+// The config is re-read every minute, so added schedules apply immediately.
+type Service struct{}
+
+func (Service) Name() string { return "scheduler" }
+
+func (s Service) Run(ctx context.Context, logger *log.Logger) error {
+	for {
+		now := time.Now()
+		next := now.Truncate(time.Minute).Add(time.Minute)
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(next.Sub(now)):
+		}
+
+		results, err := RunDue(next)
+		if err != nil {
+			logger.Printf("[%s] failed to load config: %v", s.Name(), err)
+			continue
+		}
+		for _, r := range results {
+			LogResult(logger, s.Name(), r)
+		}
+	}
+}
+
+// LogResult writes a one-line summary of a schedule run
+func LogResult(logger *log.Logger, prefix string, r Result) {
+	switch {
+	case r.Err != nil:
+		logger.Printf("[%s] %s: %v", prefix, r.Schedule.FilePath, r.Err)
+	case r.Skipped:
+		logger.Printf("[%s] %s: no changes, skipped", prefix, r.Schedule.FilePath)
+	default:
+		logger.Printf("[%s] %s: snapshot #%d saved", prefix, r.Schedule.FilePath, r.Snapshot.Number)
+	}
+}
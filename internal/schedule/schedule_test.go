@@ -0,0 +1,92 @@
+package schedule
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+)
+
+func TestDue(t *testing.T) {
+	at := time.Date(2026, 10, 16, 18, 0, 0, 0, time.Local)
+	schedules := []config.Schedule{
+		{Cron: "0 18 * * *", FilePath: "/a"},
+		{Cron: "0 9 * * *", FilePath: "/b"},
+		{Cron: "bogus", FilePath: "/c"},
+	}
+
+	due := Due(schedules, at)
+	if len(due) != 1 || due[0].FilePath != "/a" {
+		t.Errorf("Due = %+v, want only /a", due)
+	}
+}
+
+func TestRunSkipsCleanFiles(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "journal.md")
+	os.WriteFile(testFile, []byte("day 1"), 0644)
+	s, _ := store.NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	sched := config.Schedule{Cron: "* * * * *", FilePath: testFile, Message: "evening"}
+
+	if r := Run(sched); !r.Skipped || r.Err != nil {
+		t.Errorf("Run on clean file = %+v, want skipped", r)
+	}
+
+	os.WriteFile(testFile, []byte("day 2"), 0644)
+	r := Run(sched)
+	if r.Err != nil || r.Snapshot == nil {
+		t.Fatalf("Run = %+v, want snapshot", r)
+	}
+	if r.Snapshot.Number != 2 || r.Snapshot.Message != "evening" {
+		t.Errorf("Snapshot = %+v, want #2 \"evening\"", r.Snapshot)
+	}
+}
+
+func TestWithDefault(t *testing.T) {
+	cfg := &config.Config{
+		ScheduleAll: "@hourly",
+		Schedules: []config.Schedule{
+			{Cron: "0 18 * * *", FilePath: "/own"},
+			{Cron: Off, FilePath: "/skip"},
+		},
+	}
+	tracked := []store.TrackedFile{
+		{FilePath: "/own"},
+		{FilePath: "/skip"},
+		{FilePath: "/plain"},
+		{FilePath: "/own", Global: true}, // a different store of the same file
+	}
+
+	got := map[string]string{}
+	for _, sched := range WithDefault(cfg, tracked) {
+		name := sched.FilePath
+		if sched.Global {
+			name += " (global)"
+		}
+		got[name] = sched.Cron
+	}
+	want := map[string]string{
+		"/own":          "0 18 * * *",
+		"/plain":        "@hourly",
+		"/own (global)": "@hourly",
+	}
+	if len(got) != len(want) {
+		t.Fatalf("WithDefault = %v, want %v", got, want)
+	}
+	for name, cron := range want {
+		if got[name] != cron {
+			t.Errorf("%s: cron = %q, want %q", name, got[name], cron)
+		}
+	}
+
+	cfg.ScheduleAll = ""
+	if n := len(WithDefault(cfg, tracked)); n != 1 {
+		t.Errorf("without schedule_all got %d schedules, want 1", n)
+	}
+}
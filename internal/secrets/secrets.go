@@ -0,0 +1,112 @@
+// Package secrets detects likely credentials, such as API keys and
+// private keys, in file content.
+package secrets
+
+import (
+	"bufio"
+	"bytes"
+	"math"
+	"regexp"
+)
+
+// Finding is a likely credential found in content
+type Finding struct {
+	Line  int    // 1-based line number
+	Kind  string // what the match looks like, e.g. "AWS access key"
+	Match string // the matched text, shortened so it is safe to print
+}
+
+// knownKeys are credential formats recognized by their prefix
+var knownKeys = []struct {
+	kind string
+	re   *regexp.Regexp
+}{
+	{"private key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----`)},
+	{"AWS access key", regexp.MustCompile(`\b(AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"GitHub token", regexp.MustCompile(`\b(gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})`)},
+	{"Slack token", regexp.MustCompile(`\bxox[abposr]-[A-Za-z0-9-]{10,}`)},
+	{"Stripe key", regexp.MustCompile(`\b[sr]k_(live|test)_[A-Za-z0-9]{16,}`)},
+	{"Google API key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"OpenAI key", regexp.MustCompile(`\bsk-(proj-)?[A-Za-z0-9_-]{32,}`)},
+}
+
+// candidate matches strings that could be random tokens
+var candidate = regexp.MustCompile(`[A-Za-z0-9+/_=-]{20,}`)
+
+// entropyThreshold is the Shannon entropy in bits per character above
+// which a candidate is treated as a random token
+const entropyThreshold = 4.0
+
+// Scan returns the likely credentials in content, at most one per line
+func Scan(content []byte) []Finding {
+	var findings []Finding
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	scanner.Buffer(make([]byte, 0, 64*1024), len(content)+1)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+		if f, ok := scanLine(scanner.Bytes()); ok {
+			f.Line = line
+			findings = append(findings, f)
+		}
+	}
+	return findings
+}
+
+func scanLine(text []byte) (Finding, bool) {
+	for _, k := range knownKeys {
+		if m := k.re.Find(text); m != nil {
+			return Finding{Kind: k.kind, Match: shorten(m)}, true
+		}
+	}
+	for _, m := range candidate.FindAll(text, -1) {
+		if looksRandom(m) {
+			return Finding{Kind: "high-entropy string", Match: shorten(m)}, true
+		}
+	}
+	return Finding{}, false
+}
+
+// looksRandom reports whether s mixes letters and digits with high entropy,
+// which rules out words, paths and plain numbers
+func looksRandom(s []byte) bool {
+	var hasLetter, hasDigit bool
+	for _, c := range s {
+		switch {
+		case c >= '0' && c <= '9':
+			hasDigit = true
+		case c >= 'a' && c <= 'z', c >= 'A' && c <= 'Z':
+			hasLetter = true
+		}
+	}
+	return hasLetter && hasDigit && entropy(s) > entropyThreshold
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s []byte) float64 {
+	var counts [256]int
+	for _, c := range s {
+		counts[c]++
+	}
+	var h float64
+	n := float64(len(s))
+	for _, c := range counts {
+		if c == 0 {
+			continue
+		}
+		p := float64(c) / n
+		h -= p * math.Log2(p)
+	}
+	return h
+}
+
+// shorten keeps the start of a match so a finding can be shown without
+// printing the whole secret
+func shorten(m []byte) string {
+	const keep = 8
+	if len(m) <= keep {
+		return string(m)
+	}
+	return string(m[:keep]) + "…"
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"time"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// Activity summarizes what happened to one store within a time window
+type Activity struct {
+	Snapshots int // snapshots taken in the window
+	Added     int // lines added
+	Removed   int // lines removed
+}
+
+// ActivitySince reports the snapshots taken at or after since. Line counts
+// compare the newest of them with the last snapshot before since, or with
+// an empty file if the history started inside the window.
+func (s *Store) ActivitySince(since time.Time) (*Activity, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+
+	act := &Activity{}
+	var before, newest *Snapshot
+	for i := range history {
+		snap := &history[i]
+		if snap.Number == 0 {
+			continue
+		}
+		if snap.Timestamp.Before(since) {
+			if before == nil || laterSnapshot(snap, before) {
+				before = snap
+			}
+			continue
+		}
+		act.Snapshots++
+		if newest == nil || laterSnapshot(snap, newest) {
+			newest = snap
+		}
+	}
+	if newest == nil {
+		return act, nil
+	}
+
+	var oldContent []byte
+	if before != nil {
+		if oldContent, err = s.Repo.Show(versionTag(before.Number)); err != nil {
+			return nil, err
+		}
+	}
+	newContent, err := s.Repo.Show(versionTag(newest.Number))
+	if err != nil {
+		return nil, err
+	}
+	act.Added, act.Removed = git.CountLineChanges(string(oldContent), string(newContent))
+	return act, nil
+}
+
+// laterSnapshot reports whether a was taken after b, using the snapshot
+// number to order snapshots with the same timestamp
+func laterSnapshot(a, b *Snapshot) bool {
+	if a.Timestamp.Equal(b.Timestamp) {
+		return a.Number > b.Number
+	}
+	return a.Timestamp.After(b.Timestamp)
+}
+
+// LastSaved returns when the newest snapshot was taken, counting saves
+// still being committed in the background. It is zero before the first
+// snapshot.
+func (s *Store) LastSaved() (time.Time, error) {
+	var last time.Time
+	if pending, err := s.Pending(); err == nil && len(pending) > 0 {
+		last = pending[len(pending)-1].Timestamp
+	}
+	history, err := s.History()
+	if err != nil {
+		return time.Time{}, err
+	}
+	for _, snap := range history {
+		if snap.Number > 0 && snap.Timestamp.After(last) {
+			last = snap.Timestamp
+		}
+	}
+	return last, nil
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"testing"
+	"time"
+)
+
+func TestActivitySince(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	now := time.Now()
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	err := s.rebuildHistory([]historyEntry{
+		{Snapshot{Number: 1, Message: "yesterday", Timestamp: midnight.Add(-time.Hour)}, []byte("a\nb\n")},
+		{Snapshot{Number: 2, Message: "morning", Timestamp: midnight.Add(time.Minute)}, []byte("a\nb\nc\n")},
+		{Snapshot{Number: 3, Message: "later", Timestamp: midnight.Add(2 * time.Minute)}, []byte("a\nc\nd\ne\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	act, err := s.ActivitySince(midnight)
+	if err != nil {
+		t.Fatalf("ActivitySince failed: %v", err)
+	}
+	if act.Snapshots != 2 || act.Added != 3 || act.Removed != 1 {
+		t.Errorf("activity = %+v, want 2 snapshots +3 -1", act)
+	}
+
+	// A history that starts inside the window counts from an empty file
+	act, _ = s.ActivitySince(midnight.Add(-2 * time.Hour))
+	if act.Snapshots != 3 || act.Added != 4 || act.Removed != 0 {
+		t.Errorf("whole history = %+v, want 3 snapshots +4 -0", act)
+	}
+
+	act, _ = s.ActivitySince(midnight.Add(time.Hour))
+	if act.Snapshots != 0 {
+		t.Errorf("empty window = %+v, want no snapshots", act)
+	}
+}
+
+func TestLastSaved(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	day := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	err := s.rebuildHistory([]historyEntry{
+		{Snapshot{Number: 1, Message: "first", Timestamp: day}, []byte("a\n")},
+		{Snapshot{Number: 2, Message: "second", Timestamp: day.Add(time.Hour)}, []byte("a\nb\n")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	last, err := s.LastSaved()
+	if err != nil {
+		t.Fatalf("LastSaved failed: %v", err)
+	}
+	if !last.Equal(day.Add(time.Hour)) {
+		t.Errorf("LastSaved = %v, want %v", last, day.Add(time.Hour))
+	}
+}
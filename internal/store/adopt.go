@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// AdoptCandidates returns the global stores filePath could be bound to:
+// those of a file with the same name that no longer exists at its
+// recorded path, and those whose metadata was lost
+func AdoptCandidates(filePath string) ([]GlobalStoreInfo, error) {
+	name := filepath.Base(filePath)
+	listed, err := ListGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+	unlisted, err := ListUnlistedGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+
+	var candidates []GlobalStoreInfo
+	for _, info := range listed {
+		if info.FileName != name {
+			continue
+		}
+		if _, err := os.Stat(info.FilePath); os.IsNotExist(err) {
+			candidates = append(candidates, info)
+		}
+	}
+	for _, info := range unlisted {
+		if info.FileName == name {
+			candidates = append(candidates, info)
+		}
+	}
+	return candidates, nil
+}
+
+// LookupGlobalStore returns the global store recorded for path, or the one
+// in the store directory of that name
+func LookupGlobalStore(ref string) (*GlobalStoreInfo, error) {
+	stores, err := ListGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+	unlisted, err := ListUnlistedGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+	stores = append(stores, unlisted...)
+
+	abs, _ := filepath.Abs(ref)
+	for _, info := range stores {
+		if info.HashDir == ref || (info.FilePath != "" && normalizePath(info.FilePath) == normalizePath(abs)) {
+			found := info
+			return &found, nil
+		}
+	}
+	return nil, ErrNotTracked
+}
+
+// Adopt binds filePath to an existing global store, so it continues that
+// history instead of starting a new one. The store's metadata is rewritten
+// and its directory renamed to the one filePath is found under.
+func Adopt(from GlobalStoreInfo, filePath string) (*Store, error) {
+	absPath, err := filepath.Abs(filePath)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := os.Stat(absPath); err != nil {
+		return nil, fmt.Errorf("file not found: %s", absPath)
+	}
+	if s, err := NewGlobalStore(absPath); err == nil && s.Exists() {
+		return nil, ErrAlreadyTracked
+	}
+
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := remapGlobalStore(globalDir, from, absPath, false); err != nil {
+		return nil, err
+	}
+	return NewGlobalStore(absPath)
+}
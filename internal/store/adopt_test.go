@@ -0,0 +1,63 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestAdopt(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old", "notes.txt")
+	os.MkdirAll(filepath.Dir(oldPath), 0755)
+	os.WriteFile(oldPath, []byte("v1"), 0644)
+	s, _ := NewGlobalStore(oldPath)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(oldPath, []byte("v2"), 0644)
+	s.Save("")
+	oldDir := s.OopsDirPath()
+
+	// The file comes back somewhere else
+	newPath := filepath.Join(dir, "restored", "notes.txt")
+	os.MkdirAll(filepath.Dir(newPath), 0755)
+	os.Rename(oldPath, newPath)
+
+	candidates, err := AdoptCandidates(newPath)
+	if err != nil || len(candidates) != 1 || candidates[0].FilePath != oldPath {
+		t.Fatalf("AdoptCandidates = %+v, %v", candidates, err)
+	}
+	if found, err := LookupGlobalStore(filepath.Base(oldDir)); err != nil || found.FilePath != oldPath {
+		t.Errorf("LookupGlobalStore(store dir) = %+v, %v", found, err)
+	}
+
+	adopted, err := Adopt(candidates[0], newPath)
+	if err != nil {
+		t.Fatalf("Adopt failed: %v", err)
+	}
+	if snaps, _ := adopted.History(); len(snaps) != 2 {
+		t.Errorf("adopted history has %d snapshots, want 2", len(snaps))
+	}
+	if _, err := os.Stat(oldDir); !os.IsNotExist(err) {
+		t.Error("the old store directory should be renamed")
+	}
+	if data, _ := os.ReadFile(filepath.Join(adopted.OopsDirPath(), "metadata.txt")); string(data) != newPath {
+		t.Errorf("metadata.txt = %q, want %q", data, newPath)
+	}
+
+	// A file with a history of its own is not rebound
+	other := filepath.Join(dir, "other", "notes.txt")
+	os.MkdirAll(filepath.Dir(other), 0755)
+	os.WriteFile(other, []byte("x"), 0644)
+	o, _ := NewGlobalStore(other)
+	o.Initialize()
+	info, _ := LookupGlobalStore(newPath)
+	if _, err := Adopt(*info, other); err != ErrAlreadyTracked {
+		t.Errorf("Adopt onto a tracked file = %v, want ErrAlreadyTracked", err)
+	}
+}
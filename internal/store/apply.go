@@ -0,0 +1,56 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// ErrBinaryMerge is returned when changes to a binary file would have to
+// be merged line by line
+var ErrBinaryMerge = errors.New("binary files cannot be merged")
+
+// Apply brings the change snapshot num made, compared to the snapshot
+// before it, into the working file, like a cherry-pick: newer work in
+// the file is kept. Where the two changed the same lines, both versions
+// are kept between conflict markers. The result is left unsaved. Returns
+// the number of conflicts, or ErrNoChanges if the file already has the
+// change.
+func (s *Store) Apply(num int) (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	theirs, err := s.Content(num)
+	if err != nil {
+		return 0, err
+	}
+	var base []byte
+	if prev, ok := s.previousSnapshot(num); ok {
+		if base, err = s.Content(prev); err != nil {
+			return 0, err
+		}
+	}
+	ours, err := s.readRawWorkFile()
+	if err != nil {
+		return 0, err
+	}
+	if eol.IsBinary(base) || eol.IsBinary(ours) || eol.IsBinary(theirs) {
+		return 0, ErrBinaryMerge
+	}
+
+	merged, conflicts := git.Merge3Marked(string(base), string(ours), string(theirs),
+		"working file", fmt.Sprintf("snapshot #%d", num))
+	if merged == string(ours) {
+		return 0, ErrNoChanges
+	}
+	return conflicts, s.Repo.WriteWorkFileFrom(bytes.NewReader([]byte(merged)))
+}
@@ -0,0 +1,45 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\nb\nc\nd\n")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("a\nB\nc\nd\n"), 0644)
+	s.Save("fix b") // #2
+	os.WriteFile(testFile, []byte("a\nB\nc\nD\n"), 0644)
+	s.Save("fix d") // #3
+
+	// Undo both, then bring back only the fix to b, keeping newer work
+	s.Back(1, false)
+	os.WriteFile(testFile, []byte("a\nb\nc\nd\ne\n"), 0644)
+	conflicts, err := s.Apply(2)
+	if err != nil || conflicts != 0 {
+		t.Fatalf("Apply(2) = %d, %v", conflicts, err)
+	}
+	assertContent(t, testFile, "a\nB\nc\nd\ne\n")
+
+	// A change to lines edited since is kept beside them
+	os.WriteFile(testFile, []byte("a\nB\nc\nfour\ne\n"), 0644)
+	conflicts, err = s.Apply(3)
+	if err != nil || conflicts != 1 {
+		t.Fatalf("Apply(3) = %d, %v; want 1 conflict", conflicts, err)
+	}
+	content, _ := os.ReadFile(testFile)
+	if !strings.Contains(string(content), "<<<<<<< working file\nfour\ne\n=======\nD\n>>>>>>> snapshot #3\n") {
+		t.Errorf("content = %q, want conflict markers", content)
+	}
+
+	if _, err := s.Apply(2); err != ErrNoChanges {
+		t.Errorf("Apply(2) again = %v, want ErrNoChanges", err)
+	}
+	if _, err := s.Apply(9); err != ErrVersionNotFound {
+		t.Errorf("Apply(9) = %v, want ErrVersionNotFound", err)
+	}
+}
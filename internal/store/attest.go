@@ -0,0 +1,99 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/iyulab/oops/internal/attest"
+)
+
+// Attest returns an unsigned manifest of every snapshot, oldest first,
+// with its commit and a digest of its content
+func (s *Store) Attest() (*attest.Manifest, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(history, func(i, j int) bool { return history[i].Number < history[j].Number })
+
+	m := &attest.Manifest{
+		Version:   attest.FormatVersion,
+		File:      s.FilePath,
+		Generated: time.Now().UTC(),
+	}
+	for _, snap := range history {
+		if snap.Number == 0 {
+			continue // Untagged commit, not a snapshot
+		}
+		commit, err := s.Repo.TagCommit(versionTag(snap.Number))
+		if err != nil {
+			return nil, err
+		}
+		digest, err := s.contentDigest(snap.Number)
+		if err != nil {
+			return nil, err
+		}
+		m.Snapshots = append(m.Snapshots, attest.Entry{
+			Number:    snap.Number,
+			Commit:    commit,
+			SHA256:    digest,
+			Timestamp: snap.Timestamp.UTC(),
+			Message:   snap.Message,
+		})
+	}
+	return m, nil
+}
+
+// CheckAttestation compares the store's history with a manifest and
+// returns how it differs. Snapshots saved after the manifest are fine;
+// missing or changed ones mean history was rewritten.
+func (s *Store) CheckAttestation(m *attest.Manifest) ([]string, error) {
+	current, err := s.Attest()
+	if err != nil {
+		return nil, err
+	}
+	byNumber := map[int]attest.Entry{}
+	for _, e := range current.Snapshots {
+		byNumber[e.Number] = e
+	}
+
+	var problems []string
+	for _, want := range m.Snapshots {
+		got, ok := byNumber[want.Number]
+		switch {
+		case !ok:
+			problems = append(problems, fmt.Sprintf("#%d is missing", want.Number))
+		case got.SHA256 != want.SHA256:
+			problems = append(problems, fmt.Sprintf("#%d content changed", want.Number))
+		case got.Commit != want.Commit:
+			problems = append(problems, fmt.Sprintf("#%d was recommitted (%s, was %s)",
+				want.Number, shortHash(got.Commit), shortHash(want.Commit)))
+		case !got.Timestamp.Equal(want.Timestamp) || got.Message != want.Message:
+			problems = append(problems, fmt.Sprintf("#%d message or time changed", want.Number))
+		}
+	}
+	return problems, nil
+}
+
+// contentDigest returns the hex SHA-256 of snapshot num's content
+func (s *Store) contentDigest(num int) (string, error) {
+	content, err := s.Repo.Show(versionTag(num))
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(content)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+func shortHash(hash string) string {
+	if len(hash) > 7 {
+		return hash[:7]
+	}
+	return hash
+}
@@ -0,0 +1,54 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStoreAttest(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("second")
+
+	m, err := s.Attest()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Snapshots) != 2 || m.Snapshots[0].Number != 1 || len(m.Snapshots[0].Commit) != 40 {
+		t.Fatalf("Attest = %+v", m.Snapshots)
+	}
+
+	// Later snapshots do not invalidate an attestation
+	os.WriteFile(testFile, []byte("v3"), 0644)
+	s.Save("third")
+	if problems, err := s.CheckAttestation(m); err != nil || len(problems) != 0 {
+		t.Errorf("CheckAttestation = %v, %v, want no problems", problems, err)
+	}
+
+	// Rewriting a snapshot does
+	history, _ := s.History()
+	var entries []historyEntry
+	for i := len(history) - 1; i >= 0; i-- {
+		content, _ := s.Repo.Show(versionTag(history[i].Number))
+		if history[i].Number == 2 {
+			content = []byte("forged")
+		}
+		entries = append(entries, historyEntry{snap: history[i], content: content})
+	}
+	if err := s.rebuildHistory(entries); err != nil {
+		t.Fatal(err)
+	}
+
+	problems, err := s.CheckAttestation(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) != 1 || !strings.Contains(strings.Join(problems, "\n"), "#2 content changed") {
+		t.Errorf("CheckAttestation after rewrite = %v", problems)
+	}
+}
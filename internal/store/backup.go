@@ -0,0 +1,101 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/backup"
+)
+
+// restoreSuffix is added to the name of a history while it is downloaded
+const restoreSuffix = ".restore"
+
+// backupKey is where the history of the file at absPath lives in a backup
+// target, so several files can share one. Like a global store it is keyed
+// by a hash of the path, so files of the same name do not collide, and
+// named after the file so it can be told apart by eye.
+func backupKey(absPath string) string {
+	return hashFilePath(absPath) + "/" + filepath.Base(absPath) + ".git"
+}
+
+// BackupTo uploads the store, with all its profiles, to b. Files the
+// backend already holds are not sent again.
+func (s *Store) BackupTo(b backup.Backend) (*backup.Result, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if s.Memory {
+		return nil, fmt.Errorf("in-memory stores cannot be backed up")
+	}
+	return backup.Backup(backup.Sub(b, backupKey(s.FilePath)), s.mainGitDir, s.FilePath)
+}
+
+// RestoreFrom recreates the store from a backup made with BackupTo. from
+// is the path the file had when it was backed up, or "" if it is the
+// file's own path; a backup made of another path is refused. The file is
+// restored to its last position if it does not exist.
+func (s *Store) RestoreFrom(b backup.Backend, from string) (*backup.Result, error) {
+	if s.mainExists() {
+		return nil, ErrAlreadyTracked
+	}
+	if s.Memory {
+		return nil, fmt.Errorf("in-memory stores cannot be restored")
+	}
+	source := s.FilePath
+	if from != "" {
+		abs, err := filepath.Abs(from)
+		if err != nil {
+			return nil, err
+		}
+		source = abs
+	}
+	sub := backup.Sub(b, backupKey(source))
+	m, err := backup.LoadManifest(sub)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.Files) == 0 {
+		return nil, fmt.Errorf("no backup of %s found", source)
+	}
+	if normalizePath(m.Source) != normalizePath(source) {
+		return nil, fmt.Errorf("the backup found for %s is of %q", source, m.Source)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if s.mainExists() {
+		return nil, ErrAlreadyTracked // Restored by another process meanwhile
+	}
+
+	// Downloaded beside the history and moved into place once complete,
+	// so an interrupted restore leaves no half-written history
+	tmpDir := s.mainGitDir + restoreSuffix
+	os.RemoveAll(tmpDir)
+	result, err := backup.Restore(sub, tmpDir)
+	if err == nil {
+		if err = adoptStray(s.mainGitDir, tmpDir); err == nil {
+			err = os.Rename(tmpDir, s.mainGitDir)
+		}
+	}
+	if err != nil {
+		os.RemoveAll(tmpDir)
+		return nil, err
+	}
+	if err := s.saveMetadata(); err != nil {
+		return nil, err
+	}
+
+	// Start over with the restored repository
+	s.Repo = s.newRepo(s.GitDir)
+	s.loadRegion()
+	if !s.Repo.WorkFileExists() {
+		if err := s.Undo(); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
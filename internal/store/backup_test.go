@@ -0,0 +1,125 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iyulab/oops/internal/backup"
+)
+
+func TestBackupAndRestore(t *testing.T) {
+	testFile, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("two\n"), 0644)
+	s.Save("two")
+
+	target, err := backup.Open(filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, err := s.BackupTo(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	os.WriteFile(testFile, []byte("three\n"), 0644)
+	s.Save("three")
+	second, err := s.BackupTo(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if second.Unchanged == 0 || second.Transferred >= first.Transferred+first.Unchanged {
+		t.Errorf("second backup sent %d files and kept %d; want only new ones sent", second.Transferred, second.Unchanged)
+	}
+
+	// Restore on another machine, where the file does not exist. The
+	// backup is found by the path it was made from.
+	other := filepath.Join(t.TempDir(), filepath.Base(testFile))
+	restored, _ := NewStore(other)
+	if _, err := restored.RestoreFrom(target, ""); err == nil {
+		t.Fatal("restored the backup of another path")
+	}
+	if _, err := restored.RestoreFrom(target, testFile); err != nil {
+		t.Fatal(err)
+	}
+	if latest, _ := restored.GetLatestVersion(); latest != 3 {
+		t.Errorf("restored latest = %d, want 3", latest)
+	}
+	if content, _ := os.ReadFile(other); string(content) != "three\n" {
+		t.Errorf("restored file = %q", content)
+	}
+	if _, err := restored.RestoreFrom(target, testFile); err != ErrAlreadyTracked {
+		t.Errorf("second restore = %v, want ErrAlreadyTracked", err)
+	}
+
+	missing, _ := NewStore(filepath.Join(t.TempDir(), "nothing.txt"))
+	if _, err := missing.RestoreFrom(target, ""); err == nil {
+		t.Error("restored a file that was never backed up")
+	}
+}
+
+func TestBackupSameNameFiles(t *testing.T) {
+	target, err := backup.Open(filepath.Join(t.TempDir(), "backups"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Two files called notes.txt in different folders share one target
+	var paths []string
+	for _, content := range []string{"first\n", "second\n"} {
+		path := filepath.Join(t.TempDir(), "notes.txt")
+		os.WriteFile(path, []byte(content), 0644)
+		s, _ := NewStore(path)
+		if err := s.Initialize(); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := s.BackupTo(target); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	for i, want := range []string{"first\n", "second\n"} {
+		s, _ := NewStore(paths[i])
+		if err := s.Delete(); err != nil {
+			t.Fatal(err)
+		}
+		os.Remove(paths[i])
+		if _, err := s.RestoreFrom(target, ""); err != nil {
+			t.Fatalf("restoring %s: %v", paths[i], err)
+		}
+		if content, _ := os.ReadFile(paths[i]); string(content) != want {
+			t.Errorf("%s restored as %q, want %q", paths[i], content, want)
+		}
+	}
+}
+
+func TestRestoreRefusesOtherSource(t *testing.T) {
+	testFile, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	target, _ := backup.Open(filepath.Join(t.TempDir(), "backups"))
+	if _, err := s.BackupTo(target); err != nil {
+		t.Fatal(err)
+	}
+
+	// A manifest naming another file is not restored in its place
+	sub := backup.Sub(target, backupKey(testFile))
+	m, _ := backup.LoadManifest(sub)
+	m.Source = filepath.Join(filepath.Dir(testFile), "other.txt")
+	data, _ := json.Marshal(m)
+	sub.Put(backup.ManifestKey, data)
+
+	s.Delete()
+	if _, err := s.RestoreFrom(target, ""); err == nil {
+		t.Error("restored a backup made of another file")
+	}
+	if s.mainExists() {
+		t.Error("history written by a refused restore")
+	}
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"time"
+)
+
+// blessedMeta is the metadata file naming the known-good snapshot, keyed
+// by commit hash like notes.json so it follows the snapshot through drops
+// and renumbering. It holds at most one entry.
+const blessedMeta = "blessed.json"
+
+// ErrNotBlessed is returned by Rollback when no snapshot is marked known-good
+var ErrNotBlessed = errors.New("no snapshot is blessed")
+
+// Bless marks snapshot num as the known-good version Rollback returns
+// to, replacing any earlier one
+func (s *Store) Bless(num int) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	hash, err := s.Repo.TagCommit(versionTag(num))
+	if err != nil {
+		return ErrVersionNotFound
+	}
+
+	data, err := json.Marshal(map[string]time.Time{shortHash(hash): time.Now()})
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(blessedMeta, data)
+}
+
+// Unbless forgets the known-good snapshot
+func (s *Store) Unbless() error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.Repo.RemoveMeta(blessedMeta)
+}
+
+// Blessed returns the known-good snapshot and when it was blessed, or nil
+// if there is none or it has since been removed
+func (s *Store) Blessed() (*Snapshot, time.Time, error) {
+	data, err := s.Repo.ReadMeta(blessedMeta)
+	if err != nil {
+		return nil, time.Time{}, nil
+	}
+	var blessed map[string]time.Time
+	if json.Unmarshal(data, &blessed) != nil {
+		return nil, time.Time{}, nil
+	}
+
+	history, err := s.History()
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	for i := range history {
+		if when, ok := blessed[history[i].Hash]; ok && history[i].Number > 0 {
+			return &history[i], when, nil
+		}
+	}
+	return nil, time.Time{}, nil
+}
+
+// Rollback restores the blessed snapshot however many were saved after
+// it, as Back does, and returns its number
+func (s *Store) Rollback(force bool) (int, error) {
+	snap, _, err := s.Blessed()
+	if err != nil {
+		return 0, err
+	}
+	if snap == nil {
+		return 0, ErrNotBlessed
+	}
+	return snap.Number, s.Back(snap.Number, force)
+}
@@ -0,0 +1,51 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBlessAndRollback(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	if _, err := s.Rollback(false); err != ErrNotBlessed {
+		t.Errorf("Rollback without blessing = %v, want ErrNotBlessed", err)
+	}
+
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	s.Save("good")
+	if err := s.Bless(2); err != nil {
+		t.Fatalf("Bless failed: %v", err)
+	}
+	if err := s.Bless(9); err != ErrVersionNotFound {
+		t.Errorf("Bless(9) = %v, want ErrVersionNotFound", err)
+	}
+	for _, content := range []string{"v3\n", "v4\n"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save("")
+	}
+
+	num, err := s.Rollback(false)
+	if err != nil || num != 2 {
+		t.Fatalf("Rollback = %d, %v, want 2", num, err)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "v2\n" {
+		t.Errorf("content after rollback = %q, want v2", data)
+	}
+
+	// The mark follows its snapshot when an earlier one is dropped
+	if _, err := s.Drop(1); err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	if blessed, _, _ := s.Blessed(); blessed == nil || blessed.Number != 1 || blessed.Message != "good" {
+		t.Errorf("Blessed after drop = %+v, want the renumbered #1", blessed)
+	}
+
+	s.Unbless()
+	if blessed, _, _ := s.Blessed(); blessed != nil {
+		t.Errorf("Blessed after Unbless = %+v, want nil", blessed)
+	}
+}
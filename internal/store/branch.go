@@ -0,0 +1,113 @@
+package store
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// activeBranchMeta is the metadata file of the main history naming the
+// profile stores open when none is asked for
+const activeBranchMeta = "branch"
+
+// activeProfile returns the profile switched to for the history kept in
+// mainGitDir, or "" for the default. A profile that was removed since
+// counts as the default.
+func activeProfile(mainGitDir string) string {
+	data, err := git.NewRepo(mainGitDir, "", "").ReadMeta(activeBranchMeta)
+	if err != nil {
+		return ""
+	}
+	name := strings.TrimSpace(string(data))
+	if ValidateProfileName(name) != nil {
+		return ""
+	}
+	if !git.NewRepo(filepath.Join(profilesDir(mainGitDir), name+".git"), "", "").Exists() {
+		return ""
+	}
+	return name
+}
+
+// ProfileName returns the name of the store's profile, DefaultProfile for
+// the main history
+func (s *Store) ProfileName() string {
+	if s.IsDefaultProfile() {
+		return DefaultProfile
+	}
+	return s.Profile
+}
+
+// Branch starts profile name of the file from the file as it is now, as
+// an alternate timeline with its own snapshot numbers. The first snapshot
+// records where it branched off.
+func (s *Store) Branch(name string) (*Store, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if name == DefaultProfile {
+		return nil, fmt.Errorf("%q is the main history", name)
+	}
+	b, err := s.OpenProfile(name)
+	if err != nil {
+		return nil, err
+	}
+	if b.Exists() {
+		return nil, ErrAlreadyTracked
+	}
+	position, err := s.Position()
+	if err != nil {
+		return nil, err
+	}
+	message := fmt.Sprintf("Branched from %s at #%d", s.ProfileName(), position)
+	if err := b.InitializeWithMessage(message); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// Switch makes profile name the one the file's stores open from now on
+// and restores the file to where that profile was left. Unsaved changes
+// are overwritten; callers check for them first. The main history, which
+// records the switch, is locked meanwhile.
+func (s *Store) Switch(name string) (*Store, error) {
+	main, err := s.OpenProfile(DefaultProfile)
+	if err != nil {
+		return nil, err
+	}
+	main.LockWait = s.LockWait
+	unlock, err := main.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	target := main
+	if name != DefaultProfile {
+		if target, err = s.OpenProfile(name); err != nil {
+			return nil, err
+		}
+		target.LockWait = s.LockWait
+	}
+	if !target.Exists() {
+		return nil, ErrNotTracked
+	}
+	position, err := target.Position()
+	if err != nil {
+		return nil, err
+	}
+	if err := target.Back(position, true); err != nil {
+		return nil, err
+	}
+
+	if target.IsDefaultProfile() {
+		err = main.Repo.RemoveMeta(activeBranchMeta)
+	} else {
+		err = main.Repo.WriteMeta(activeBranchMeta, []byte(target.Profile+"\n"))
+	}
+	if err != nil {
+		return nil, err
+	}
+	return target, nil
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestBranchAndSwitch(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	main, _ := NewStore(testFile)
+	if err := main.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	main.Save("second")
+
+	b, err := main.Branch("experiment")
+	if err != nil {
+		t.Fatal(err)
+	}
+	first, _ := b.History()
+	if len(first) != 1 || first[0].Message != "Branched from default at #2" {
+		t.Errorf("branch history = %+v", first)
+	}
+	if _, err := main.Branch("experiment"); err != ErrAlreadyTracked {
+		t.Errorf("second Branch = %v, want ErrAlreadyTracked", err)
+	}
+
+	// After switching, stores opened without a profile use the branch
+	if _, err := main.Switch("experiment"); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("try\n"), 0644)
+	reopened, _ := NewStore(testFile)
+	if reopened.Profile != "experiment" {
+		t.Fatalf("Profile after Switch = %q", reopened.Profile)
+	}
+	if snap, err := reopened.Save("try"); err != nil || snap.Number != 2 {
+		t.Fatalf("Save on the branch = %v, %v", snap, err)
+	}
+	if latest, _ := main.GetLatestVersion(); latest != 2 {
+		t.Errorf("main latest = %d, want 2", latest)
+	}
+
+	// Switching back restores where the main history was left
+	back, err := reopened.Switch(DefaultProfile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !back.IsDefaultProfile() {
+		t.Errorf("Switch(default) opened %q", back.Profile)
+	}
+	assertContent(t, testFile, "v2\n")
+	if s, _ := NewStore(testFile); !s.IsDefaultProfile() {
+		t.Errorf("Profile after switching back = %q", s.Profile)
+	}
+
+	if _, err := main.Switch("nope"); err != ErrNotTracked {
+		t.Errorf("Switch to a missing branch = %v, want ErrNotTracked", err)
+	}
+}
+
+func TestRemovedBranchFallsBackToMain(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	main, _ := NewStore(testFile)
+	main.Initialize()
+	main.Branch("gone")
+	b, _ := main.Switch("gone")
+	if err := b.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if s, _ := NewStore(testFile); !s.IsDefaultProfile() || !s.Exists() {
+		t.Errorf("store after removing the branch = %q, exists %v", s.Profile, s.Exists())
+	}
+}
+
+func TestBranchSurvivesDrop(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	main, _ := NewStore(testFile)
+	if err := main.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"v2\n", "v3\n"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		main.Save("")
+	}
+	if _, err := main.Branch("exp"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dropping rebuilds the main history, which holds the branches
+	if _, err := main.Drop(2); err != nil {
+		t.Fatal(err)
+	}
+	b, err := main.OpenProfile("exp")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !b.Exists() {
+		t.Fatal("branch lost when a snapshot was dropped")
+	}
+	if _, err := main.Switch("exp"); err != nil {
+		t.Errorf("Switch after Drop: %v", err)
+	}
+}
@@ -0,0 +1,301 @@
+package store
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// BundleManifest is the name of the manifest entry at the root of a bundle
+const BundleManifest = "oops-bundle.txt"
+
+// BundleImport reports the outcome of importing a global store bundle
+type BundleImport struct {
+	BundleHome string   // home directory of the machine that exported the bundle
+	Imported   []string // file paths of imported stores
+	Skipped    []string // file paths already tracked globally on this machine
+}
+
+// ExportGlobalStores writes every global store and its metadata into a
+// gzip-compressed tar bundle. Returns the number of stores exported.
+func ExportGlobalStores(w io.Writer) (int, error) {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return 0, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return 0, err
+	}
+
+	stores, err := ListGlobalStores()
+	if err != nil {
+		return 0, err
+	}
+
+	gzw := gzip.NewWriter(w)
+	tw := tar.NewWriter(gzw)
+
+	manifest := fmt.Sprintf("version=1\nhome=%s\n", homeDir)
+	if err := writeTarFile(tw, BundleManifest, []byte(manifest)); err != nil {
+		return 0, err
+	}
+
+	for _, info := range stores {
+		hashDir := filepath.Join(globalDir, info.HashDir)
+		err := filepath.Walk(hashDir, func(p string, fi os.FileInfo, err error) error {
+			if err != nil {
+				return err
+			}
+			rel, err := filepath.Rel(globalDir, p)
+			if err != nil {
+				return err
+			}
+			name := filepath.ToSlash(rel)
+
+			switch {
+			case fi.IsDir():
+				return tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeDir,
+					Name:     name + "/",
+					Mode:     0755,
+					ModTime:  fi.ModTime(),
+				})
+			case fi.Mode().IsRegular():
+				hdr, err := tar.FileInfoHeader(fi, "")
+				if err != nil {
+					return err
+				}
+				hdr.Name = name
+				if err := tw.WriteHeader(hdr); err != nil {
+					return err
+				}
+				f, err := os.Open(p)
+				if err != nil {
+					return err
+				}
+				defer f.Close()
+				_, err = io.Copy(tw, f)
+				return err
+			}
+			return nil // Skip symlinks and special files
+		})
+		if err != nil {
+			return 0, fmt.Errorf("failed to export %s: %w", info.FilePath, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return 0, err
+	}
+	if err := gzw.Close(); err != nil {
+		return 0, err
+	}
+
+	return len(stores), nil
+}
+
+// ImportGlobalStores unpacks a bundle written by ExportGlobalStores into the
+// global store directory. Stores for files already tracked globally are
+// skipped. With remapHome, paths under the exporting machine's home
+// directory are rewritten to this machine's home directory.
+func ImportGlobalStores(r io.Reader, remapHome bool) (*BundleImport, error) {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return nil, err
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(globalDir, 0755); err != nil {
+		return nil, err
+	}
+
+	// Stage next to the real stores so the final move is a rename
+	stageDir, err := os.MkdirTemp(globalDir, ".import-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(stageDir)
+
+	result := &BundleImport{}
+	if err := extractBundle(r, stageDir, result); err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(stageDir)
+	if err != nil {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		staged := filepath.Join(stageDir, entry.Name())
+		data, err := os.ReadFile(filepath.Join(staged, "metadata.txt"))
+		if err != nil {
+			continue // Not a store
+		}
+
+		filePath := string(data)
+		if remapHome && result.BundleHome != "" {
+			filePath = remapPathPrefix(filePath, result.BundleHome, homeDir)
+		}
+		if err := validateMetadataPath(filePath); err != nil {
+			return nil, fmt.Errorf("bundle entry %s: %w", entry.Name(), err)
+		}
+
+		// Stores from removable media keep their volume key, so they match
+		// the drive wherever it is mounted here
+		key := globalKey(filePath)
+		if v, ok := readVolume(staged); ok {
+			key = v.key()
+		}
+		target := filepath.Join(globalDir, key)
+		if _, err := os.Stat(target); err == nil {
+			result.Skipped = append(result.Skipped, filePath)
+			continue
+		}
+
+		if err := os.WriteFile(filepath.Join(staged, "metadata.txt"), []byte(filePath), 0644); err != nil {
+			return nil, err
+		}
+		if err := os.Rename(staged, target); err != nil {
+			return nil, err
+		}
+		result.Imported = append(result.Imported, filePath)
+	}
+
+	return result, nil
+}
+
+// extractBundle unpacks bundle entries into dir, rejecting any entry that
+// would land outside it
+func extractBundle(r io.Reader, dir string, result *BundleImport) error {
+	gzr, err := gzip.NewReader(r)
+	if err != nil {
+		return fmt.Errorf("not an oops bundle: %w", err)
+	}
+	defer gzr.Close()
+
+	tr := tar.NewReader(gzr)
+	sawManifest := false
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := path.Clean(hdr.Name)
+		if name == BundleManifest {
+			sawManifest = true
+			if err := readManifest(tr, result); err != nil {
+				return err
+			}
+			continue
+		}
+		if err := validateBundleEntry(name); err != nil {
+			return err
+		}
+
+		target := filepath.Join(dir, filepath.FromSlash(name))
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			f, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(f, tr); err != nil {
+				f.Close()
+				return err
+			}
+			if err := f.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("bundle entry %q: unsupported type", hdr.Name)
+		}
+	}
+
+	if !sawManifest {
+		return fmt.Errorf("not an oops bundle: missing %s", BundleManifest)
+	}
+	return nil
+}
+
+// validateBundleEntry rejects tar entry names that are absolute, climb out
+// of the extraction directory or contain unsafe components
+func validateBundleEntry(name string) error {
+	if name == "." || strings.HasPrefix(name, "/") || name == ".." || strings.HasPrefix(name, "../") {
+		return fmt.Errorf("%w: bundle entry %q escapes the store directory", ErrInvalidFileName, name)
+	}
+	for _, part := range strings.Split(name, "/") {
+		if err := ValidateFileName(part); err != nil {
+			return fmt.Errorf("bundle entry %q: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// readManifest parses the bundle manifest
+func readManifest(r io.Reader, result *BundleImport) error {
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok {
+			continue
+		}
+		switch key {
+		case "version":
+			if value != "1" {
+				return fmt.Errorf("unsupported bundle version %s", value)
+			}
+		case "home":
+			result.BundleHome = value
+		}
+	}
+	return scanner.Err()
+}
+
+// remapPathPrefix replaces oldPrefix with newPrefix when p is under oldPrefix
+func remapPathPrefix(p, oldPrefix, newPrefix string) string {
+	oldPrefix = strings.TrimRight(oldPrefix, `/\`)
+	if p == oldPrefix {
+		return newPrefix
+	}
+	if strings.HasPrefix(p, oldPrefix+"/") || strings.HasPrefix(p, oldPrefix+`\`) {
+		return filepath.Join(newPrefix, filepath.FromSlash(strings.ReplaceAll(p[len(oldPrefix)+1:], `\`, "/")))
+	}
+	return p
+}
+
+// writeTarFile writes a single regular file entry
+func writeTarFile(tw *tar.Writer, name string, content []byte) error {
+	if err := tw.WriteHeader(&tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0644,
+		Size:     int64(len(content)),
+	}); err != nil {
+		return err
+	}
+	_, err := tw.Write(content)
+	return err
+}
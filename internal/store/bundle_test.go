@@ -0,0 +1,91 @@
+package store
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGlobalBundleRoundTrip(t *testing.T) {
+	oldHome := t.TempDir()
+	t.Setenv("HOME", oldHome)
+	t.Setenv("USERPROFILE", oldHome)
+
+	testFile := filepath.Join(oldHome, "notes.txt")
+	os.WriteFile(testFile, []byte("v1"), 0644)
+	s, _ := NewGlobalStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("second")
+
+	var buf bytes.Buffer
+	count, err := ExportGlobalStores(&buf)
+	if err != nil {
+		t.Fatalf("ExportGlobalStores failed: %v", err)
+	}
+	if count != 1 {
+		t.Errorf("exported = %d, want 1", count)
+	}
+
+	newHome := t.TempDir()
+	t.Setenv("HOME", newHome)
+	t.Setenv("USERPROFILE", newHome)
+
+	result, err := ImportGlobalStores(bytes.NewReader(buf.Bytes()), true)
+	if err != nil {
+		t.Fatalf("ImportGlobalStores failed: %v", err)
+	}
+	if result.BundleHome != oldHome {
+		t.Errorf("BundleHome = %q, want %q", result.BundleHome, oldHome)
+	}
+
+	wantPath := filepath.Join(newHome, "notes.txt")
+	if len(result.Imported) != 1 || result.Imported[0] != wantPath {
+		t.Fatalf("Imported = %v, want [%s]", result.Imported, wantPath)
+	}
+
+	imported, _ := NewGlobalStore(wantPath)
+	latest, _ := imported.GetLatestVersion()
+	if latest != 2 {
+		t.Errorf("latest = %d, want 2", latest)
+	}
+
+	// A second import skips the existing store
+	result, err = ImportGlobalStores(bytes.NewReader(buf.Bytes()), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(result.Skipped) != 1 {
+		t.Errorf("Skipped = %v, want 1 entry", result.Skipped)
+	}
+}
+
+func TestValidateBundleEntry(t *testing.T) {
+	for _, name := range []string{"abc/metadata.txt", "abc/f.txt.git/objects/ab/cd"} {
+		if err := validateBundleEntry(name); err != nil {
+			t.Errorf("validateBundleEntry(%q) = %v, want nil", name, err)
+		}
+	}
+	for _, name := range []string{"../evil", "/etc/passwd", "..", "a/../../b"} {
+		if err := validateBundleEntry(name); !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("validateBundleEntry(%q) = %v, want ErrInvalidFileName", name, err)
+		}
+	}
+}
+
+func TestRemapPathPrefix(t *testing.T) {
+	tests := []struct{ p, old, new, want string }{
+		{"/home/a/doc.txt", "/home/a", "/home/b", filepath.Join("/home/b", "doc.txt")},
+		{"/home/ab/doc.txt", "/home/a", "/home/b", "/home/ab/doc.txt"},
+		{"/srv/doc.txt", "/home/a", "/home/b", "/srv/doc.txt"},
+	}
+	for _, tt := range tests {
+		if got := remapPathPrefix(tt.p, tt.old, tt.new); got != tt.want {
+			t.Errorf("remapPathPrefix(%q, %q, %q) = %q, want %q", tt.p, tt.old, tt.new, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/events"
+)
+
+// chainMeta is the metadata file listing the files chained to the tracked
+// file: outputs generated from it, snapshotted and restored along with it
+const chainMeta = "chain.json"
+
+var ErrNotChained = errors.New("file is not chained")
+
+// ChainAheadError is returned when chaining a file whose own history
+// already reaches the numbers the source's next snapshots would take
+type ChainAheadError struct {
+	Artifact string
+	Latest   int // the artifact's newest snapshot
+	Source   int // the source's newest snapshot
+}
+
+func (e *ChainAheadError) Error() string {
+	return fmt.Sprintf("%s already has snapshots up to #%d, past the source's #%d", e.Artifact, e.Latest, e.Source)
+}
+
+// Artifact is a file chained to a tracked file
+type Artifact struct {
+	Path  string `json:"path"`  // relative to the source's directory when inside it
+	Since int    `json:"since"` // first snapshot of the source saved with it
+}
+
+// Artifacts returns the files chained to the store, by path
+func (s *Store) Artifacts() []Artifact {
+	var artifacts []Artifact
+	if data, err := s.Repo.ReadMeta(chainMeta); err == nil {
+		json.Unmarshal(data, &artifacts)
+	}
+	sort.Slice(artifacts, func(i, j int) bool { return artifacts[i].Path < artifacts[j].Path })
+	return artifacts
+}
+
+// ArtifactPath returns the absolute path of a chained file
+func (s *Store) ArtifactPath(a Artifact) string {
+	if filepath.IsAbs(a.Path) {
+		return a.Path
+	}
+	return filepath.Join(filepath.Dir(s.FilePath), a.Path)
+}
+
+// Chain pairs the file at path with the store, so every later snapshot
+// of the tracked file also snapshots it under the same number, and
+// restoring a snapshot restores it too. The file is tracked, in the same
+// kind of store, if it is not already.
+func (s *Store) Chain(path string) (Artifact, error) {
+	if !s.Exists() {
+		return Artifact{}, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return Artifact{}, err
+	}
+	defer unlock()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if abs == s.FilePath {
+		return Artifact{}, fmt.Errorf("a file cannot be chained to itself")
+	}
+	if info, err := os.Stat(abs); err != nil {
+		return Artifact{}, err
+	} else if info.IsDir() {
+		return Artifact{}, fmt.Errorf("%s is a directory", path)
+	}
+
+	latest, err := s.Repo.GetLatestTagNumber()
+	if err != nil {
+		return Artifact{}, err
+	}
+	artifact := Artifact{Path: abs, Since: latest + 1}
+	if rel, err := filepath.Rel(filepath.Dir(s.FilePath), abs); err == nil && !strings.HasPrefix(rel, "..") {
+		artifact.Path = rel
+	}
+
+	a, err := s.artifactStore(artifact)
+	if err != nil {
+		return Artifact{}, err
+	}
+	if a.Exists() {
+		if aLatest, err := a.Repo.GetLatestTagNumber(); err != nil {
+			return Artifact{}, err
+		} else if aLatest > latest {
+			return Artifact{}, &ChainAheadError{Artifact: a.FileName, Latest: aLatest, Source: latest}
+		}
+	} else if err := a.InitializeWithMessage(fmt.Sprintf("Chained to %s", s.FileName)); err != nil {
+		return Artifact{}, err
+	}
+
+	var artifacts []Artifact
+	for _, existing := range s.Artifacts() {
+		if s.ArtifactPath(existing) != abs {
+			artifacts = append(artifacts, existing)
+		}
+	}
+	if err := s.writeArtifacts(append(artifacts, artifact)); err != nil {
+		return Artifact{}, err
+	}
+	return artifact, nil
+}
+
+// Unchain stops saving and restoring the file at path with the store.
+// The file stays tracked on its own.
+func (s *Store) Unchain(path string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return err
+	}
+	var kept []Artifact
+	for _, a := range s.Artifacts() {
+		if s.ArtifactPath(a) != abs {
+			kept = append(kept, a)
+		}
+	}
+	if len(kept) == len(s.Artifacts()) {
+		return ErrNotChained
+	}
+	return s.writeArtifacts(kept)
+}
+
+func (s *Store) writeArtifacts(artifacts []Artifact) error {
+	data, err := json.Marshal(artifacts)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(chainMeta, data)
+}
+
+// artifactStore opens the store of a chained file, global if s is
+func (s *Store) artifactStore(a Artifact) (*Store, error) {
+	return NewStoreWithOptions(s.ArtifactPath(a), StoreOptions{Global: s.Global})
+}
+
+// saveArtifacts snapshots every chained file as snapshot num of its own
+// store, recording failures in ChainFailures
+func (s *Store) saveArtifacts(num int, message string) {
+	s.ChainFailures = nil
+	for _, artifact := range s.Artifacts() {
+		a, err := s.artifactStore(artifact)
+		if err == nil {
+			err = a.snapshotAs(num, message)
+		}
+		if err != nil {
+			s.ChainFailures = append(s.ChainFailures, fmt.Errorf("%s: %w", artifact.Path, err))
+		}
+	}
+}
+
+// restoreArtifacts restores every chained file to its snapshot num,
+// recording failures in ChainFailures. Files chained after num was saved
+// are left alone.
+func (s *Store) restoreArtifacts(num int) {
+	s.ChainFailures = nil
+	for _, artifact := range s.Artifacts() {
+		if num < artifact.Since {
+			continue
+		}
+		a, err := s.artifactStore(artifact)
+		if err == nil {
+			err = a.restoreAs(num)
+		}
+		if err != nil {
+			s.ChainFailures = append(s.ChainFailures, fmt.Errorf("%s: %w", artifact.Path, err))
+		}
+	}
+}
+
+// snapshotAs saves the working file as snapshot num, even if it has not
+// changed, so it keeps the number of the snapshot it was saved with
+func (s *Store) snapshotAs(num int, message string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return err
+	}
+	latest, err := s.Repo.GetLatestTagNumber()
+	if err != nil {
+		return err
+	}
+	if latest >= num {
+		return fmt.Errorf("already has snapshot #%d", latest)
+	}
+
+	content, _, err := s.snapshotContent()
+	if err != nil {
+		return err
+	}
+	if _, err := s.Repo.CommitContent(content, message, time.Now()); err != nil {
+		return err
+	}
+	if err := s.Repo.Tag(versionTag(num)); err != nil {
+		return err
+	}
+	if err := s.recordPosition(num); err != nil {
+		return err
+	}
+	s.repackIfLoose()
+	s.emit(events.SnapshotCreated, num, message)
+	return nil
+}
+
+// restoreAs restores snapshot num like Back, leaving the store's own
+// chained files alone so chains cannot restore each other in a loop
+func (s *Store) restoreAs(num int) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	tag := versionTag(num)
+	if !s.Repo.HasTag(tag) {
+		return ErrVersionNotFound
+	}
+	if err := s.Repo.Checkout(tag); err != nil {
+		return err
+	}
+	s.restoreFileMeta(num)
+	if err := s.recordPosition(num); err != nil {
+		return err
+	}
+	s.emit(events.Restored, num, "")
+	return nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChainSavesAndRestoresArtifact(t *testing.T) {
+	source, cleanup := setupTestFile(t, "# Report v1")
+	defer cleanup()
+	output := filepath.Join(filepath.Dir(source), "report.pdf")
+	os.WriteFile(output, []byte("PDF v1"), 0644)
+
+	s, _ := NewStore(source)
+	if _, err := s.Chain(output); err != ErrNotTracked {
+		t.Errorf("Chain on untracked = %v, want ErrNotTracked", err)
+	}
+	s.Initialize()
+	os.WriteFile(source, []byte("# Report v2"), 0644)
+	s.Save("")
+
+	artifact, err := s.Chain(output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if artifact.Path != "report.pdf" || artifact.Since != 3 {
+		t.Errorf("Chain = %+v, want report.pdf since #3", artifact)
+	}
+	if _, err := s.Chain(source); err == nil {
+		t.Error("chaining a file to itself succeeded")
+	}
+
+	// #3 regenerates the output, #4 leaves it alone
+	os.WriteFile(source, []byte("# Report v3"), 0644)
+	os.WriteFile(output, []byte("PDF v3"), 0644)
+	if _, err := s.Save("third"); err != nil || len(s.ChainFailures) > 0 {
+		t.Fatalf("Save = %v, chain failures %v", err, s.ChainFailures)
+	}
+	os.WriteFile(source, []byte("# Report v4"), 0644)
+	if _, err := s.Save("fourth"); err != nil || len(s.ChainFailures) > 0 {
+		t.Fatalf("Save = %v, chain failures %v", err, s.ChainFailures)
+	}
+
+	a, _ := NewStore(output)
+	for num, want := range map[int]string{3: "PDF v3", 4: "PDF v3"} {
+		if got, err := a.Content(num); err != nil || string(got) != want {
+			t.Errorf("artifact #%d = %q, %v; want %q", num, got, err, want)
+		}
+	}
+
+	os.WriteFile(output, []byte("PDF v5"), 0644)
+	if err := s.Back(3, true); err != nil || len(s.ChainFailures) > 0 {
+		t.Fatalf("Back = %v, chain failures %v", err, s.ChainFailures)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "PDF v3" {
+		t.Errorf("artifact after Back(3) = %q", got)
+	}
+
+	// Snapshots saved before the artifact was chained leave it alone
+	if err := s.Back(1, true); err != nil || len(s.ChainFailures) > 0 {
+		t.Fatalf("Back = %v, chain failures %v", err, s.ChainFailures)
+	}
+	if got, _ := os.ReadFile(output); string(got) != "PDF v3" {
+		t.Errorf("artifact after Back(1) = %q, want it untouched", got)
+	}
+	if _, err := s.StepBack(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.Unchain(output); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Unchain(output); err != ErrNotChained {
+		t.Errorf("Unchain twice = %v, want ErrNotChained", err)
+	}
+	if len(s.Artifacts()) != 0 {
+		t.Errorf("Artifacts after Unchain = %v", s.Artifacts())
+	}
+}
+
+func TestChainRefusesArtifactAhead(t *testing.T) {
+	source, cleanup := setupTestFile(t, "source")
+	defer cleanup()
+	output := filepath.Join(filepath.Dir(source), "out.bin")
+	os.WriteFile(output, []byte("1"), 0644)
+
+	s, _ := NewStore(source)
+	s.Initialize()
+	a, _ := NewStore(output)
+	a.Initialize()
+	os.WriteFile(output, []byte("2"), 0644)
+	a.Save("")
+
+	var ahead *ChainAheadError
+	if _, err := s.Chain(output); !errors.As(err, &ahead) || ahead.Latest != 2 || ahead.Source != 1 {
+		t.Errorf("Chain = %v, want ChainAheadError", err)
+	}
+}
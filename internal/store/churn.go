@@ -0,0 +1,278 @@
+package store
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/iyulab/oops/internal/git"
+	"github.com/iyulab/oops/internal/textenc"
+)
+
+// churnCacheMeta caches the line diff between consecutive snapshots, so
+// churn only diffs snapshots saved since it last ran
+const churnCacheMeta = "churn.json"
+
+// LineChurn is how often a line of the latest snapshot was changed
+type LineChurn struct {
+	Line    int // 1-based line number in the latest snapshot
+	Text    string
+	Changes int // snapshots that added or rewrote the line
+	Section string
+}
+
+// SectionChurn totals the changes to the lines of one section
+type SectionChurn struct {
+	Heading string // the heading line, "" for lines before the first heading
+	Line    int    // line of the heading, 0 if there is none
+	Changes int
+}
+
+// Churn reports where a file's history concentrates
+type Churn struct {
+	Snapshots int
+	Lines     []LineChurn // every line of the latest snapshot, in order
+}
+
+// ChurnReport counts, for each line of the latest snapshot, how many
+// snapshots added or rewrote it. A rewritten line inherits the count of
+// the line it replaced.
+func (s *Store) ChurnReport() (*Churn, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, snap := range history {
+		if snap.Number > 0 {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Number < snaps[j].Number })
+	if len(snaps) == 0 {
+		return &Churn{}, nil
+	}
+
+	// Content is only read for pairs missing from the cache
+	shown := map[int][]byte{}
+	show := func(num int) ([]byte, error) {
+		if content, ok := shown[num]; ok {
+			return content, nil
+		}
+		content, err := s.Repo.Show(versionTag(num))
+		shown = map[int][]byte{num: content}
+		return content, err
+	}
+
+	cache := s.loadChurnCache()
+	used := map[string]string{}
+	var counts []int
+	for i := 1; i < len(snaps); i++ {
+		key := snaps[i-1].Hash + ".." + snaps[i].Hash
+		edits, ok := parseEdits(cache[key])
+		if !ok {
+			prev, err := show(snaps[i-1].Number)
+			if err != nil {
+				return nil, err
+			}
+			content, err := show(snaps[i].Number)
+			if err != nil {
+				return nil, err
+			}
+			edits = git.LineEdits(string(prev), string(content))
+		}
+		used[key] = formatEdits(edits)
+		if counts == nil {
+			counts = make([]int, oldLines(edits))
+		}
+		counts = applyChurn(counts, edits)
+	}
+	s.saveChurnCache(used)
+
+	prev, err := show(snaps[len(snaps)-1].Number)
+	if err != nil {
+		return nil, err
+	}
+	lines := splitLines(prev)
+	churn := &Churn{Snapshots: len(snaps)}
+	section := ""
+	for i, text := range lines {
+		if isHeading(s.FileName, text) {
+			section = strings.TrimSpace(text)
+		}
+		n := 0
+		if i < len(counts) {
+			n = counts[i]
+		}
+		churn.Lines = append(churn.Lines, LineChurn{Line: i + 1, Text: text, Changes: n, Section: section})
+	}
+	return churn, nil
+}
+
+// TopLines returns up to n of the most changed lines, most changed first.
+// Lines that never changed are left out.
+func (c *Churn) TopLines(n int) []LineChurn {
+	var top []LineChurn
+	for _, l := range c.Lines {
+		if l.Changes > 0 && strings.TrimSpace(l.Text) != "" {
+			top = append(top, l)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Changes > top[j].Changes })
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// TopSections returns up to n of the most changed sections, most changed
+// first. Files without headings have no sections.
+func (c *Churn) TopSections(n int) []SectionChurn {
+	var sections []SectionChurn
+	for _, l := range c.Lines {
+		if len(sections) == 0 || sections[len(sections)-1].Heading != l.Section {
+			line := 0
+			if l.Section != "" {
+				line = l.Line
+			}
+			sections = append(sections, SectionChurn{Heading: l.Section, Line: line})
+		}
+		sections[len(sections)-1].Changes += l.Changes
+	}
+	if len(sections) == 1 && sections[0].Heading == "" {
+		return nil
+	}
+
+	var top []SectionChurn
+	for _, sec := range sections {
+		if sec.Changes > 0 {
+			top = append(top, sec)
+		}
+	}
+	sort.SliceStable(top, func(i, j int) bool { return top[i].Changes > top[j].Changes })
+	if len(top) > n {
+		top = top[:n]
+	}
+	return top
+}
+
+// applyChurn carries line counts across one snapshot's edits. Inserted
+// lines count one more change than the deleted line they replace.
+func applyChurn(counts []int, edits []git.LineEdit) []int {
+	var next, deleted []int
+	i := 0
+	for _, e := range edits {
+		switch e.Op {
+		case git.EditEqual:
+			next = append(next, counts[i:min(i+e.Lines, len(counts))]...)
+			i += e.Lines
+			deleted = nil
+		case git.EditDelete:
+			deleted = counts[i:min(i+e.Lines, len(counts))]
+			i += e.Lines
+		case git.EditInsert:
+			for k := 0; k < e.Lines; k++ {
+				base := 0
+				if k < len(deleted) {
+					base = deleted[k]
+				}
+				next = append(next, base+1)
+			}
+			deleted = nil
+		}
+	}
+	return next
+}
+
+// oldLines returns the number of lines edits start from
+func oldLines(edits []git.LineEdit) int {
+	n := 0
+	for _, e := range edits {
+		if e.Op != git.EditInsert {
+			n += e.Lines
+		}
+	}
+	return n
+}
+
+// isHeading reports whether a line starts a section: a Markdown heading
+// in Markdown files, an INI/TOML table header in others
+func isHeading(fileName, line string) bool {
+	switch strings.ToLower(filepath.Ext(fileName)) {
+	case ".md", ".markdown":
+		rest := strings.TrimLeft(line, "#")
+		level := len(line) - len(rest)
+		return level >= 1 && level <= 6 && strings.HasPrefix(rest, " ")
+	}
+	trimmed := strings.TrimSpace(line)
+	return len(trimmed) > 2 && strings.HasPrefix(trimmed, "[") && strings.HasSuffix(trimmed, "]")
+}
+
+// splitLines splits content into lines without their line endings,
+// decoding UTF-16 and legacy encodings as the line diff does
+func splitLines(content []byte) []string {
+	text, _ := textenc.Decode(content)
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return nil
+	}
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// formatEdits encodes edits compactly, e.g. "=3-1+2"
+func formatEdits(edits []git.LineEdit) string {
+	var b strings.Builder
+	for _, e := range edits {
+		fmt.Fprintf(&b, "%c%d", e.Op, e.Lines)
+	}
+	return b.String()
+}
+
+// parseEdits decodes formatEdits output
+func parseEdits(s string) ([]git.LineEdit, bool) {
+	if s == "" {
+		return nil, false
+	}
+	var edits []git.LineEdit
+	for s != "" {
+		op := s[0]
+		if op != git.EditEqual && op != git.EditDelete && op != git.EditInsert {
+			return nil, false
+		}
+		end := 1
+		for end < len(s) && s[end] >= '0' && s[end] <= '9' {
+			end++
+		}
+		n, err := strconv.Atoi(s[1:end])
+		if err != nil {
+			return nil, false
+		}
+		edits = append(edits, git.LineEdit{Op: op, Lines: n})
+		s = s[end:]
+	}
+	return edits, true
+}
+
+func (s *Store) loadChurnCache() map[string]string {
+	cache := map[string]string{}
+	if data, err := s.Repo.ReadMeta(churnCacheMeta); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveChurnCache keeps the diffs of the current history, dropping those of
+// snapshots that were compacted or renumbered away. Failing to cache is
+// not an error.
+func (s *Store) saveChurnCache(cache map[string]string) {
+	if data, err := json.Marshal(cache); err == nil {
+		s.Repo.WriteMeta(churnCacheMeta, data)
+	}
+}
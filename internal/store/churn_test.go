@@ -0,0 +1,83 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestChurnReport(t *testing.T) {
+	dir := t.TempDir()
+	testFile := filepath.Join(dir, "notes.md")
+	versions := []string{
+		"# Intro\nhello\n# Status\nstate: draft\n",
+		"# Intro\nhello\n# Status\nstate: review\n",
+		"# Intro\nhello there\n# Status\nstate: final\n",
+		"# Intro\nhello there\n# Status\nstate: final\nowner: me\n",
+	}
+	os.WriteFile(testFile, []byte(versions[0]), 0644)
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, v := range versions[1:] {
+		os.WriteFile(testFile, []byte(v), 0644)
+		if _, err := s.Save(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	check := func(c *Churn) {
+		t.Helper()
+		if c.Snapshots != 4 {
+			t.Errorf("Snapshots = %d, want 4", c.Snapshots)
+		}
+		want := []int{0, 1, 0, 2, 1}
+		if len(c.Lines) != len(want) {
+			t.Fatalf("got %d lines, want %d", len(c.Lines), len(want))
+		}
+		for i, n := range want {
+			if c.Lines[i].Changes != n {
+				t.Errorf("line %d (%q) changed %d times, want %d", i+1, c.Lines[i].Text, c.Lines[i].Changes, n)
+			}
+		}
+
+		top := c.TopLines(1)
+		if len(top) != 1 || top[0].Text != "state: final" || top[0].Line != 4 {
+			t.Errorf("TopLines(1) = %+v", top)
+		}
+		sections := c.TopSections(5)
+		if len(sections) != 2 || sections[0].Heading != "# Status" || sections[0].Changes != 3 || sections[0].Line != 3 {
+			t.Errorf("TopSections = %+v", sections)
+		}
+	}
+
+	c, err := s.ChurnReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(c)
+
+	// The second run uses the cached diffs and agrees
+	if _, err := s.Repo.ReadMeta(churnCacheMeta); err != nil {
+		t.Fatalf("no churn cache written: %v", err)
+	}
+	c, err = s.ChurnReport()
+	if err != nil {
+		t.Fatal(err)
+	}
+	check(c)
+}
+
+func TestChurnEditsRoundTrip(t *testing.T) {
+	edits, ok := parseEdits("=3-1+2=10")
+	if !ok || len(edits) != 4 || edits[3].Lines != 10 {
+		t.Fatalf("parseEdits = %v, %v", edits, ok)
+	}
+	if got := formatEdits(edits); got != "=3-1+2=10" {
+		t.Errorf("formatEdits = %q", got)
+	}
+	if _, ok := parseEdits("=3x1"); ok {
+		t.Error("parseEdits accepted a bad op")
+	}
+}
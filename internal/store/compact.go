@@ -0,0 +1,128 @@
+package store
+
+import (
+	"fmt"
+	"time"
+)
+
+// CompactPolicy controls how history is thinned as snapshots age
+type CompactPolicy struct {
+	KeepAll    time.Duration // keep every snapshot younger than this
+	KeepHourly time.Duration // then keep the last snapshot of each hour up to this age; one per day after
+	KeepDaily  time.Duration // then one per day up to this age and one per week after; 0 for daily forever
+}
+
+// DefaultCompactPolicy keeps everything for a day, hourly for a week and
+// daily after that
+func DefaultCompactPolicy() CompactPolicy {
+	return CompactPolicy{
+		KeepAll:    24 * time.Hour,
+		KeepHourly: 7 * 24 * time.Hour,
+	}
+}
+
+// PlanCompaction returns the snapshots that Compact would remove, oldest first
+func (s *Store) PlanCompaction(policy CompactPolicy, now time.Time) ([]Snapshot, error) {
+	snaps, _, err := s.snapshotHashes()
+	if err != nil {
+		return nil, err
+	}
+	return selectCompaction(snaps, policy, now), nil
+}
+
+// Compact thins the history according to policy. The first and latest
+// snapshots are always kept, and kept snapshots retain their numbers.
+// Returns the number of snapshots removed.
+func (s *Store) Compact(policy CompactPolicy, now time.Time) (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+
+	snaps, _, err := s.snapshotHashes()
+	if err != nil {
+		return 0, err
+	}
+	drop := selectCompaction(snaps, policy, now)
+	if len(drop) == 0 {
+		return 0, nil
+	}
+
+	if err := s.removeSnapshots(snaps, drop); err != nil {
+		return 0, err
+	}
+	return len(drop), nil
+}
+
+// removeSnapshots rebuilds the history of snaps without those in drop.
+// Kept snapshots retain their numbers.
+func (s *Store) removeSnapshots(snaps, drop []Snapshot) error {
+	dropped := make(map[int]bool)
+	for _, snap := range drop {
+		dropped[snap.Number] = true
+	}
+
+	var entries []historyEntry
+	for _, snap := range snaps {
+		if dropped[snap.Number] {
+			continue
+		}
+		content, err := s.Repo.Show(versionTag(snap.Number))
+		if err != nil {
+			return err
+		}
+		entries = append(entries, historyEntry{snap, content})
+	}
+	return s.rebuildHistory(entries)
+}
+
+// selectCompaction picks the snapshots to drop from snaps (oldest first).
+// Within each hourly, daily or weekly bucket the newest snapshot survives.
+func selectCompaction(snaps []Snapshot, policy CompactPolicy, now time.Time) []Snapshot {
+	if len(snaps) <= 2 {
+		return nil
+	}
+
+	bucketOf := func(t time.Time) (string, bool) {
+		age := now.Sub(t)
+		switch {
+		case age < policy.KeepAll:
+			return "", false
+		case age < policy.KeepHourly:
+			return t.Format("2006-01-02T15"), true
+		case policy.KeepDaily == 0 || age < policy.KeepDaily:
+			return t.Format("2006-01-02"), true
+		default:
+			year, week := t.ISOWeek()
+			return fmt.Sprintf("%d-W%02d", year, week), true
+		}
+	}
+
+	// Walk newest first so the first snapshot seen in a bucket is kept
+	seen := make(map[string]bool)
+	keep := make(map[int]bool)
+	for i := len(snaps) - 1; i >= 0; i-- {
+		snap := snaps[i]
+		bucket, thinned := bucketOf(snap.Timestamp)
+		if !thinned || !seen[bucket] {
+			keep[snap.Number] = true
+		}
+		if thinned {
+			seen[bucket] = true
+		}
+	}
+	keep[snaps[0].Number] = true
+	keep[snaps[len(snaps)-1].Number] = true
+
+	var drop []Snapshot
+	for _, snap := range snaps {
+		if !keep[snap.Number] {
+			drop = append(drop, snap)
+		}
+	}
+	return drop
+}
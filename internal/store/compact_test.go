@@ -0,0 +1,91 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSelectCompaction(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	at := func(d time.Duration) time.Time { return now.Add(-d) }
+
+	snaps := []Snapshot{
+		{Number: 1, Timestamp: at(30 * 24 * time.Hour)},             // first: always kept
+		{Number: 2, Timestamp: at(10*24*time.Hour + time.Hour)},     // daily bucket, older
+		{Number: 3, Timestamp: at(10 * 24 * time.Hour)},             // daily bucket, newest: kept
+		{Number: 4, Timestamp: at(3*24*time.Hour + 20*time.Minute)}, // hourly bucket, older
+		{Number: 5, Timestamp: at(3*24*time.Hour + 10*time.Minute)}, // hourly bucket, newest: kept
+		{Number: 6, Timestamp: at(2 * time.Hour)},                   // recent: kept
+		{Number: 7, Timestamp: at(time.Hour)},                       // recent: kept
+	}
+
+	drop := selectCompaction(snaps, DefaultCompactPolicy(), now)
+	var got []int
+	for _, snap := range drop {
+		got = append(got, snap.Number)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("dropped = %v, want [2 4]", got)
+	}
+}
+
+func TestCompactKeepsNumbers(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2", "v3", "v4"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save(content)
+	}
+
+	// Pretend everything happened long ago, all in one day
+	future := time.Now().Add(30 * 24 * time.Hour)
+	removed, err := s.Compact(DefaultCompactPolicy(), future)
+	if err != nil {
+		t.Fatalf("Compact failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+
+	history, _ := s.History()
+	if len(history) != 2 {
+		t.Fatalf("history has %d snapshots, want 2", len(history))
+	}
+	latest, _ := s.GetLatestVersion()
+	if latest != 4 {
+		t.Errorf("latest = %d, want 4", latest)
+	}
+	if err := s.Back(1, true); err != nil {
+		t.Errorf("Back(1) failed: %v", err)
+	}
+	if err := s.Back(2, true); err != ErrVersionNotFound {
+		t.Errorf("Back(2) = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestSelectCompactionWeekly(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC) // a Friday
+	day := 24 * time.Hour
+	snaps := []Snapshot{
+		{Number: 1, Timestamp: now.Add(-90 * day)},           // first: always kept
+		{Number: 2, Timestamp: now.Add(-46 * day)},           // Mon Aug 31
+		{Number: 3, Timestamp: now.Add(-45 * day)},           // Tue, same week: kept
+		{Number: 4, Timestamp: now.Add(-10*day - time.Hour)}, // daily bucket, older
+		{Number: 5, Timestamp: now.Add(-10 * day)},           // daily bucket, newest: kept
+		{Number: 6, Timestamp: now.Add(-time.Hour)},          // recent: kept
+	}
+
+	policy := DefaultCompactPolicy()
+	policy.KeepDaily = 30 * day
+	var got []int
+	for _, snap := range selectCompaction(snaps, policy, now) {
+		got = append(got, snap.Number)
+	}
+	if len(got) != 2 || got[0] != 2 || got[1] != 4 {
+		t.Errorf("dropped = %v, want [2 4]", got)
+	}
+}
@@ -0,0 +1,176 @@
+package store
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"sort"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// StoreComparison describes how two histories of the same file differ
+type StoreComparison struct {
+	Local      []Snapshot // oldest first
+	Global     []Snapshot // oldest first
+	Shared     int        // snapshots whose content exists in both stores
+	OnlyLocal  []Snapshot
+	OnlyGlobal []Snapshot
+	LatestDiff string // diff from latest global to latest local, empty if identical
+}
+
+// CompareStores compares the local and global histories of the same file.
+// Snapshots are matched by content, since numbering and messages may differ.
+func CompareStores(local, global *Store) (*StoreComparison, error) {
+	localSnaps, localHashes, err := local.snapshotHashes()
+	if err != nil {
+		return nil, fmt.Errorf("local store: %w", err)
+	}
+	globalSnaps, globalHashes, err := global.snapshotHashes()
+	if err != nil {
+		return nil, fmt.Errorf("global store: %w", err)
+	}
+
+	cmp := &StoreComparison{Local: localSnaps, Global: globalSnaps}
+
+	inGlobal := make(map[string]bool)
+	for _, snap := range globalSnaps {
+		inGlobal[globalHashes[snap.Number]] = true
+	}
+	inLocal := make(map[string]bool)
+	for _, snap := range localSnaps {
+		h := localHashes[snap.Number]
+		inLocal[h] = true
+		if inGlobal[h] {
+			cmp.Shared++
+		} else {
+			cmp.OnlyLocal = append(cmp.OnlyLocal, snap)
+		}
+	}
+	for _, snap := range globalSnaps {
+		if !inLocal[globalHashes[snap.Number]] {
+			cmp.OnlyGlobal = append(cmp.OnlyGlobal, snap)
+		}
+	}
+
+	if len(localSnaps) > 0 && len(globalSnaps) > 0 {
+		localLatest, err := local.Repo.Show(versionTag(localSnaps[len(localSnaps)-1].Number))
+		if err != nil {
+			return nil, err
+		}
+		globalLatest, err := global.Repo.Show(versionTag(globalSnaps[len(globalSnaps)-1].Number))
+		if err != nil {
+			return nil, err
+		}
+		cmp.LatestDiff = git.DiffContent(local.FileName, string(globalLatest), string(localLatest))
+	}
+
+	return cmp, nil
+}
+
+// MergeHistories rebuilds keep's history so it also contains the snapshots
+// of other whose content keep lacks. Snapshots from both stores are
+// interleaved by timestamp and renumbered from #1, keeping their messages.
+// other is left untouched. Returns the number of snapshots imported.
+func MergeHistories(keep, other *Store) (int, error) {
+	if !keep.Exists() || !other.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := keep.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	var entries []historyEntry
+	have := make(map[string]bool)
+	keepSnaps, _, err := keep.snapshotHashes()
+	if err != nil {
+		return 0, err
+	}
+	for _, snap := range keepSnaps {
+		content, err := keep.Repo.Show(versionTag(snap.Number))
+		if err != nil {
+			return 0, err
+		}
+		have[contentHash(content)] = true
+		entries = append(entries, historyEntry{snap, content})
+	}
+
+	otherSnaps, _, err := other.snapshotHashes()
+	if err != nil {
+		return 0, err
+	}
+	imported := 0
+	for _, snap := range otherSnaps {
+		content, err := other.Repo.Show(versionTag(snap.Number))
+		if err != nil {
+			return 0, err
+		}
+		h := contentHash(content)
+		if have[h] {
+			continue
+		}
+		have[h] = true
+		entries = append(entries, historyEntry{snap, content})
+		imported++
+	}
+
+	if imported == 0 {
+		return 0, nil
+	}
+
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].snap.Timestamp.Before(entries[j].snap.Timestamp)
+	})
+
+	for i := range entries {
+		entries[i].snap.Number = i + 1
+	}
+	if err := keep.rebuildHistory(entries); err != nil {
+		return 0, err
+	}
+	// Snapshots were renumbered, so remembered positions are stale
+	if err := keep.resetPositions(); err != nil {
+		return 0, err
+	}
+
+	return imported, nil
+}
+
+// snapshotHashes returns numbered snapshots oldest first, with a content
+// hash for each snapshot number
+func (s *Store) snapshotHashes() ([]Snapshot, map[int]string, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var snaps []Snapshot
+	hashes := make(map[int]string)
+	for _, snap := range history {
+		if snap.Number == 0 {
+			continue // Untagged commit
+		}
+		content, err := s.Repo.Show(versionTag(snap.Number))
+		if err != nil {
+			return nil, nil, err
+		}
+		snaps = append(snaps, snap)
+		hashes[snap.Number] = contentHash(content)
+	}
+
+	sort.Slice(snaps, func(i, j int) bool {
+		return snaps[i].Number < snaps[j].Number
+	})
+
+	return snaps, hashes, nil
+}
+
+// contentHash returns a hex digest of file content
+func contentHash(content []byte) string {
+	return fmt.Sprintf("%x", sha256.Sum256(content))
+}
+
+// versionTag returns the tag name for a snapshot number
+func versionTag(num int) string {
+	return fmt.Sprintf("v%d", num)
+}
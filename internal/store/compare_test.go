@@ -0,0 +1,84 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestCompareAndImportSnapshots(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	local, _ := NewStore(testFile)
+	local.Initialize()
+	global, _ := NewGlobalStore(testFile)
+	defer global.Delete()
+	global.Initialize()
+
+	os.WriteFile(testFile, []byte("local v2"), 0644)
+	local.Save("local change")
+	os.WriteFile(testFile, []byte("global v2"), 0644)
+	global.Save("global change")
+
+	cmp, err := CompareStores(local, global)
+	if err != nil {
+		t.Fatalf("CompareStores failed: %v", err)
+	}
+	if cmp.Shared != 1 {
+		t.Errorf("Shared = %d, want 1", cmp.Shared)
+	}
+	if len(cmp.OnlyLocal) != 1 || len(cmp.OnlyGlobal) != 1 {
+		t.Errorf("OnlyLocal=%d OnlyGlobal=%d, want 1,1", len(cmp.OnlyLocal), len(cmp.OnlyGlobal))
+	}
+	if cmp.LatestDiff == "" {
+		t.Error("LatestDiff should not be empty")
+	}
+
+}
+
+func TestMergeHistories(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	local, _ := NewStore(testFile)
+	local.Initialize()
+	global, _ := NewGlobalStore(testFile)
+	defer global.Delete()
+	global.Initialize()
+
+	os.WriteFile(testFile, []byte("local v2"), 0644)
+	local.Save("local change")
+	time.Sleep(1100 * time.Millisecond) // commit times have second precision
+	os.WriteFile(testFile, []byte("global v2"), 0644)
+	global.Save("global change")
+
+	imported, err := MergeHistories(local, global)
+	if err != nil {
+		t.Fatalf("MergeHistories failed: %v", err)
+	}
+	if imported != 1 {
+		t.Errorf("imported = %d, want 1", imported)
+	}
+
+	latest, _ := local.GetLatestVersion()
+	if latest != 3 {
+		t.Errorf("latest = %d, want 3", latest)
+	}
+
+	for tag, want := range map[string]string{"v1": "v1", "v2": "local v2", "v3": "global v2"} {
+		content, err := local.Repo.Show(tag)
+		if err != nil {
+			t.Fatalf("Show %s failed: %v", tag, err)
+		}
+		if string(content) != want {
+			t.Errorf("%s content = %q, want %q", tag, string(content), want)
+		}
+	}
+
+	// Merging again adds nothing
+	imported, _ = MergeHistories(local, global)
+	if imported != 0 {
+		t.Errorf("second merge = %d, want 0", imported)
+	}
+}
@@ -0,0 +1,55 @@
+package store
+
+// SnapshotDetails describes one snapshot for oops show
+type SnapshotDetails struct {
+	Snapshot
+	Commit     string // full commit hash
+	Size       int64  // size of the content as restored
+	StoredSize int64  // size as stored, smaller when packed
+	Previous   int    // number of the snapshot before it, 0 for the first
+	Origin     Origin
+	Note       string
+}
+
+// Details returns the details of snapshot num
+func (s *Store) Details(num int) (*SnapshotDetails, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+
+	var details *SnapshotDetails
+	for _, snap := range history {
+		switch {
+		case snap.Number == num:
+			details = &SnapshotDetails{Snapshot: snap}
+		case snap.Number > 0 && snap.Number < num && details != nil && details.Previous == 0:
+			details.Previous = snap.Number // History is newest first
+		}
+	}
+	if details == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	tag := versionTag(num)
+	if details.Commit, err = s.Repo.TagCommit(tag); err != nil {
+		return nil, err
+	}
+	if details.StoredSize, details.Packed, err = s.Repo.StoredSize(tag); err != nil {
+		return nil, err
+	}
+	content, err := s.Repo.ShowWork(tag)
+	if err != nil {
+		return nil, err
+	}
+	details.Size = int64(len(content))
+	details.Origin = s.Origin(details.Snapshot)
+	details.Note = s.Note(details.Snapshot)
+	return details, nil
+}
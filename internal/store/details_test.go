@@ -0,0 +1,44 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestDetails(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "one\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"two\n", "three\n"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save(content)
+	}
+	s.SetNote(3, "final")
+	s.Drop(2)
+
+	d, err := s.Details(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d.Message != "three" {
+		t.Errorf("Message = %q", d.Message)
+	}
+	if d.Previous != 1 || d.Size != 6 || d.StoredSize != 6 || d.Packed {
+		t.Errorf("Details(2) = %+v", d)
+	}
+	if len(d.Commit) != 40 || d.Commit[:7] != d.Hash {
+		t.Errorf("Commit = %q, Hash = %q", d.Commit, d.Hash)
+	}
+	if d.Note != "final" || d.Origin != OriginManual {
+		t.Errorf("Note = %q, Origin = %q", d.Note, d.Origin)
+	}
+
+	if d, _ := s.Details(1); d.Previous != 0 {
+		t.Errorf("Previous of #1 = %d, want 0", d.Previous)
+	}
+	if _, err := s.Details(5); err != ErrVersionNotFound {
+		t.Errorf("Details(5) = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,32 @@
+package store
+
+import (
+	"github.com/iyulab/oops/internal/doctext"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// IsDocument reports whether the tracked file is an office document whose
+// text oops can extract
+func (s *Store) IsDocument() bool {
+	return doctext.IsDocument(s.FileName)
+}
+
+// DocumentChanges diffs the text of two versions of an office document,
+// taking versions like Changes. It returns "" if the text is unchanged,
+// even when formatting changed.
+func (s *Store) DocumentChanges(versions ...int) (string, error) {
+	pair, err := s.ContentPair(versions...)
+	if err != nil {
+		return "", err
+	}
+
+	oldText, err := doctext.Extract(s.FileName, pair.Old)
+	if err != nil {
+		return "", err
+	}
+	newText, err := doctext.Extract(s.FileName, pair.New)
+	if err != nil {
+		return "", err
+	}
+	return git.DiffContentContext(s.FileName, oldText, newText, s.Repo.Context), nil
+}
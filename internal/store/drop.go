@@ -0,0 +1,70 @@
+package store
+
+import (
+	"errors"
+)
+
+// ErrOnlySnapshot is returned when dropping the only snapshot of a history
+var ErrOnlySnapshot = errors.New("cannot drop the only snapshot")
+
+// Drop removes snapshot num from the history, and the snapshots after it
+// move down one number. The history is rebuilt without it, so its content
+// is gone from the store afterwards, e.g. a secret saved by accident. The
+// undo stack follows the new numbers. Returns the dropped snapshot.
+func (s *Store) Drop(num int) (*Snapshot, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+
+	snaps, _, err := s.snapshotHashes()
+	if err != nil {
+		return nil, err
+	}
+	var dropped *Snapshot
+	for i := range snaps {
+		if snaps[i].Number == num {
+			dropped = &snaps[i]
+		}
+	}
+	if dropped == nil {
+		return nil, ErrVersionNotFound
+	}
+	if len(snaps) == 1 {
+		return nil, ErrOnlySnapshot
+	}
+
+	var entries []historyEntry
+	renumbered := map[int]int{}
+	for _, snap := range snaps {
+		if snap.Number == num {
+			continue
+		}
+		content, err := s.Repo.Show(versionTag(snap.Number))
+		if err != nil {
+			return nil, err
+		}
+		renumbered[snap.Number] = snap.Number
+		if snap.Number > num {
+			renumbered[snap.Number] = snap.Number - 1
+			snap.Number--
+		}
+		entries = append(entries, historyEntry{snap, content})
+	}
+
+	stack := s.loadPositions()
+	if err := s.rebuildHistory(entries); err != nil {
+		return nil, err
+	}
+	if err := s.savePositions(stack.renumber(renumbered)); err != nil {
+		return nil, err
+	}
+	return dropped, nil
+}
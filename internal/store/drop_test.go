@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestDrop(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"password=hunter2", "v3", "v4"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save(content)
+	}
+	s.Back(3, true)
+
+	dropped, err := s.Drop(2)
+	if err != nil {
+		t.Fatalf("Drop failed: %v", err)
+	}
+	if dropped.Message != "password=hunter2" {
+		t.Errorf("dropped = %q, want the secret snapshot", dropped.Message)
+	}
+
+	history, _ := s.History()
+	var got []string
+	for _, snap := range history {
+		content, _ := s.Content(snap.Number)
+		got = append(got, string(content))
+		if strings.Contains(string(content), "hunter2") {
+			t.Errorf("snapshot #%d still holds the secret", snap.Number)
+		}
+	}
+	if len(got) != 3 {
+		t.Fatalf("history has %d snapshots, want 3", len(got))
+	}
+	if content, _ := s.Content(2); string(content) != "v3" {
+		t.Errorf("#2 = %q, want v3 moved down", content)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 3 {
+		t.Errorf("latest = %d, want 3", latest)
+	}
+
+	// The undo stack followed #3 to #2
+	if stack := s.loadPositions(); len(stack.Positions) == 0 || stack.Positions[stack.Cursor] != 2 {
+		t.Errorf("positions = %+v, want cursor on #2", stack)
+	}
+}
+
+func TestDropErrors(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	if _, err := s.Drop(1); err != ErrOnlySnapshot {
+		t.Errorf("Drop(1) = %v, want ErrOnlySnapshot", err)
+	}
+	if _, err := s.Drop(5); err != ErrVersionNotFound {
+		t.Errorf("Drop(5) = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iyulab/oops/internal/eol"
+)
+
+func TestStoreEOLNormalization(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\r\nb\r\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	if err := s.SetEOL(eol.ModeLF); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	stored, _ := s.Repo.Show("v1")
+	if string(stored) != "a\nb\n" {
+		t.Errorf("stored = %q, want LF line endings", stored)
+	}
+
+	// Converting the file to LF is not a change
+	os.WriteFile(testFile, []byte("a\nb\n"), 0644)
+	if _, _, hasChanges, _ := s.Now(); hasChanges {
+		t.Error("line ending change should not count as a change")
+	}
+	if _, err := s.Save("eol only"); err != ErrNoChanges {
+		t.Errorf("Save = %v, want ErrNoChanges", err)
+	}
+
+	if err := s.SetEOL("crlf"); err == nil {
+		t.Error("SetEOL should reject unknown modes")
+	}
+}
+
+func TestStoreChangesIgnoreEOL(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\nb\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	os.WriteFile(testFile, []byte("a\r\nb\r\n"), 0644)
+	if diff, _ := s.Changes(); diff == "" {
+		t.Fatal("Changes should report line ending differences by default")
+	}
+
+	s.Repo.IgnoreEOL = true
+	if diff, _ := s.Changes(); diff != "" {
+		t.Errorf("Changes with IgnoreEOL = %q, want none", diff)
+	}
+
+	os.WriteFile(testFile, []byte("a\r\nc\r\n"), 0644)
+	if diff, _ := s.Changes(); diff == "" {
+		t.Error("Changes with IgnoreEOL should still report content changes")
+	}
+}
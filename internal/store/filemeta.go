@@ -0,0 +1,85 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// fileMetaMeta is the metadata file recording the work file's mode,
+// modification time and owner when each snapshot was saved, keyed by
+// commit hash like notes.json
+const fileMetaMeta = "filemeta.json"
+
+// FileMeta is what a snapshot records about the work file besides its
+// content
+type FileMeta struct {
+	Mode    os.FileMode `json:"mode"`            // permission bits
+	ModTime time.Time   `json:"mtime"`           // last modification
+	UID     int         `json:"uid,omitempty"`   // owner, where the platform has one
+	GID     int         `json:"gid,omitempty"`   // group, where the platform has one
+	Owner   bool        `json:"owner,omitempty"` // UID and GID were recorded
+}
+
+func (s *Store) loadFileMetas() map[string]FileMeta {
+	metas := map[string]FileMeta{}
+	if data, err := s.Repo.ReadMeta(fileMetaMeta); err == nil {
+		json.Unmarshal(data, &metas)
+	}
+	return metas
+}
+
+// FileMeta returns the file details recorded with a snapshot; ok is false
+// for snapshots saved before they were recorded
+func (s *Store) FileMeta(snap Snapshot) (meta FileMeta, ok bool) {
+	meta, ok = s.loadFileMetas()[snap.Hash]
+	return meta, ok
+}
+
+// recordFileMeta records the work file's details with snapshot num
+func (s *Store) recordFileMeta(num int) {
+	if s.Memory {
+		return
+	}
+	info, err := os.Stat(s.FilePath)
+	if err != nil {
+		return
+	}
+	hash, err := s.Repo.TagCommit(versionTag(num))
+	if err != nil {
+		return
+	}
+
+	meta := FileMeta{Mode: info.Mode().Perm(), ModTime: info.ModTime()}
+	meta.UID, meta.GID, meta.Owner = fileOwner(info)
+	metas := s.loadFileMetas()
+	metas[shortHash(hash)] = meta
+	if data, err := json.Marshal(metas); err == nil {
+		s.Repo.WriteMeta(fileMetaMeta, data)
+	}
+}
+
+// restoreFileMeta gives the work file the mode recorded with snapshot num
+// and, if configured, its modification time and owner. Snapshots without
+// a record leave the file as the restore wrote it.
+func (s *Store) restoreFileMeta(num int) {
+	if s.Memory {
+		return
+	}
+	hash, err := s.Repo.TagCommit(versionTag(num))
+	if err != nil {
+		return
+	}
+	meta, ok := s.loadFileMetas()[shortHash(hash)]
+	if !ok {
+		return
+	}
+
+	os.Chmod(s.FilePath, meta.Mode)
+	if s.restoreOwner && meta.Owner {
+		os.Chown(s.FilePath, meta.UID, meta.GID) // Needs privileges to give away files
+	}
+	if s.restoreMtime && !meta.ModTime.IsZero() {
+		os.Chtimes(s.FilePath, time.Now(), meta.ModTime)
+	}
+}
@@ -0,0 +1,49 @@
+package store
+
+import (
+	"os"
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestRestoreFileMeta(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not kept on Windows")
+	}
+	testFile, cleanup := setupTestFile(t, "#!/bin/sh\necho v1\n")
+	defer cleanup()
+	os.Chmod(testFile, 0755)
+	saved := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	os.Chtimes(testFile, saved, saved)
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	snaps, _ := s.History()
+	if meta, ok := s.FileMeta(snaps[0]); !ok || meta.Mode != 0755 || !meta.ModTime.Equal(saved) {
+		t.Fatalf("FileMeta = %+v, %v, want mode 0755 and the saved time", meta, ok)
+	}
+
+	// Deleted and restored, the script is executable again
+	os.Remove(testFile)
+	if err := s.Back(1, true); err != nil {
+		t.Fatalf("Back failed: %v", err)
+	}
+	info, _ := os.Stat(testFile)
+	if info.Mode().Perm() != 0755 {
+		t.Errorf("mode after Back = %v, want 0755", info.Mode().Perm())
+	}
+	if info.ModTime().Equal(saved) {
+		t.Error("modification time should only be restored when configured")
+	}
+
+	s.restoreMtime = true
+	os.Chmod(testFile, 0600)
+	if err := s.Undo(); err != nil {
+		t.Fatalf("Undo failed: %v", err)
+	}
+	info, _ = os.Stat(testFile)
+	if info.Mode().Perm() != 0755 || !info.ModTime().Equal(saved) {
+		t.Errorf("after Undo mode = %v, mtime = %v; want 0755 and %v", info.Mode().Perm(), info.ModTime(), saved)
+	}
+}
@@ -0,0 +1,11 @@
+//go:build windows
+
+package store
+
+import "os"
+
+// fileOwner reports no owner on Windows, where files carry access control
+// lists instead of an owner and group id
+func fileOwner(info os.FileInfo) (uid, gid int, ok bool) {
+	return 0, 0, false
+}
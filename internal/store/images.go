@@ -0,0 +1,92 @@
+package store
+
+import (
+	"encoding/json"
+
+	"github.com/iyulab/oops/internal/imagediff"
+)
+
+// imagesMeta is the metadata file caching image details per snapshot,
+// keyed by commit hash so rebuilt histories are re-inspected
+const imagesMeta = "images.json"
+
+// ImageChange compares two versions of a tracked image
+type ImageChange struct {
+	*imagediff.Comparison
+	OldLabel, NewLabel string // as in ContentPair
+	OldData, NewData   []byte
+}
+
+// IsImage reports whether the tracked file is an image oops can inspect
+func (s *Store) IsImage() bool {
+	return imagediff.IsImage(s.FileName)
+}
+
+func (s *Store) loadImageInfos() map[string]imagediff.Info {
+	infos := map[string]imagediff.Info{}
+	if data, err := s.Repo.ReadMeta(imagesMeta); err == nil {
+		json.Unmarshal(data, &infos)
+	}
+	return infos
+}
+
+// ImageInfo returns the dimensions and perceptual hash of an image
+// snapshot, inspecting and recording it if it is not recorded yet
+func (s *Store) ImageInfo(snap Snapshot) (imagediff.Info, error) {
+	infos := s.loadImageInfos()
+	if info, ok := infos[snap.Hash]; ok {
+		return info, nil
+	}
+
+	content, err := s.Repo.Show(versionTag(snap.Number))
+	if err != nil {
+		return imagediff.Info{}, err
+	}
+	info, err := imagediff.Inspect(content)
+	if err != nil {
+		return imagediff.Info{}, err
+	}
+
+	infos[snap.Hash] = info
+	if data, err := json.Marshal(infos); err == nil {
+		s.Repo.WriteMeta(imagesMeta, data)
+	}
+	return info, nil
+}
+
+// recordImageInfo records image details for snapshot num of an image
+// file. Files that fail to decode are stored like any other file.
+func (s *Store) recordImageInfo(num int) {
+	if !s.IsImage() {
+		return
+	}
+	snaps, err := s.Repo.Log()
+	if err != nil {
+		return
+	}
+	for _, snap := range snaps {
+		if snap.Number == num {
+			s.ImageInfo(snap)
+			return
+		}
+	}
+}
+
+// ImageChanges compares image versions, taking versions like Changes
+func (s *Store) ImageChanges(versions ...int) (*ImageChange, error) {
+	pair, err := s.ContentPair(versions...)
+	if err != nil {
+		return nil, err
+	}
+
+	change := &ImageChange{
+		OldLabel: pair.OldLabel,
+		NewLabel: pair.NewLabel,
+		OldData:  pair.Old,
+		NewData:  pair.New,
+	}
+	if change.Comparison, err = imagediff.Compare(pair.Old, pair.New); err != nil {
+		return nil, err
+	}
+	return change, nil
+}
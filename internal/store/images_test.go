@@ -0,0 +1,77 @@
+package store
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writePNG(t *testing.T, path string, w, h int) {
+	t.Helper()
+	img := image.NewGray(image.Rect(0, 0, w, h))
+	for x := 0; x < w/2; x++ {
+		for y := 0; y < h; y++ {
+			img.Set(x, y, color.White)
+		}
+	}
+	var buf bytes.Buffer
+	png.Encode(&buf, img)
+	if err := os.WriteFile(path, buf.Bytes(), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestStoreImageChanges(t *testing.T) {
+	testFile := filepath.Join(t.TempDir(), "logo.png")
+	writePNG(t, testFile, 64, 32)
+
+	s, _ := NewStore(testFile)
+	if !s.IsImage() {
+		t.Fatal("logo.png should be an image")
+	}
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	writePNG(t, testFile, 32, 16)
+	if _, err := s.Save("smaller"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Details are recorded when snapshots are saved
+	if data, err := s.Repo.ReadMeta(imagesMeta); err != nil || !bytes.Contains(data, []byte(`"width":32`)) {
+		t.Errorf("image details not recorded: %s", data)
+	}
+
+	var first Snapshot
+	snaps, _ := s.History()
+	for _, snap := range snaps {
+		if snap.Number == 1 {
+			first = snap
+		}
+	}
+	info, err := s.ImageInfo(first)
+	if err != nil || info.Size() != "64x32" {
+		t.Errorf("ImageInfo(#1) = %+v, %v", info, err)
+	}
+
+	change, err := s.ImageChanges(1, 2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := change.String(); got != "64x32 → 32x16, 0% pixels differ" {
+		t.Errorf("ImageChanges = %q", got)
+	}
+	if change.OldLabel != "#1" || change.NewLabel != "#2" {
+		t.Errorf("labels = %s, %s", change.OldLabel, change.NewLabel)
+	}
+
+	change, err = s.ImageChanges()
+	if err != nil || change.NewLabel != "working file" || change.DiffRatio != 0 {
+		t.Errorf("ImageChanges() = %+v, %v", change, err)
+	}
+}
@@ -0,0 +1,98 @@
+package store
+
+import (
+	"encoding/json"
+	"os"
+	"time"
+)
+
+// saveJournal is the metadata file written before a save commits and
+// removed once its tag and position are recorded. If it is found when the
+// store is opened, the save was interrupted and is finished or undone.
+const saveJournal = "save.journal"
+
+// journalEntry records a save in progress
+type journalEntry struct {
+	Number         int       `json:"number"` // snapshot being created
+	Parent         string    `json:"parent"` // HEAD before the commit, "" if none
+	Message        string    `json:"message"`
+	UpdatePosition bool      `json:"update_position"` // position moves to Number
+	Started        time.Time `json:"started"`
+}
+
+// Recovery describes an interrupted save found when the store was opened
+type Recovery struct {
+	Number    int  // snapshot the save was creating
+	Completed bool // the commit was made and the save was finished; otherwise it was undone
+}
+
+// beginSave journals a save of snapshot num before anything is committed
+func (s *Store) beginSave(num int, message string, updatePosition bool) error {
+	parent, err := s.Repo.HeadCommit()
+	if err != nil {
+		return err
+	}
+	data, err := json.Marshal(journalEntry{
+		Number:         num,
+		Parent:         parent,
+		Message:        message,
+		UpdatePosition: updatePosition,
+		Started:        time.Now(),
+	})
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(saveJournal, data)
+}
+
+// endSave marks the journaled save as complete
+func (s *Store) endSave() {
+	s.Repo.RemoveMeta(saveJournal)
+}
+
+// recoverSave finishes or undoes a save interrupted before endSave. A
+// save whose commit was made gets its missing tag and position; one that
+// never committed leaves no trace but the journal, which is dropped.
+func (s *Store) recoverSave() (*Recovery, error) {
+	data, err := s.Repo.ReadMeta(saveJournal)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var j journalEntry
+	if err := json.Unmarshal(data, &j); err != nil || j.Number < 1 {
+		// Torn while being written, so nothing was committed after it
+		s.endSave()
+		return nil, nil
+	}
+
+	head, err := s.Repo.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
+	if head == j.Parent {
+		s.endSave()
+		return &Recovery{Number: j.Number}, nil
+	}
+
+	tag := versionTag(j.Number)
+	if !s.Repo.HasTag(tag) {
+		if err := s.Repo.Tag(tag); err != nil {
+			return nil, err
+		}
+	}
+	if j.UpdatePosition {
+		if current, err := s.Position(); err != nil || current != j.Number {
+			if err := s.recordPosition(j.Number); err != nil {
+				return nil, err
+			}
+		}
+	}
+	s.recordImageInfo(j.Number)
+	s.recordFileMeta(j.Number)
+	s.endSave()
+	return &Recovery{Number: j.Number, Completed: true}, nil
+}
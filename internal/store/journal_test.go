@@ -0,0 +1,69 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestRecoverSaveCompletesCommit(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	// Crash after the commit, before the tag
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	if err := s.beginSave(2, "second", true); err != nil {
+		t.Fatal(err)
+	}
+	s.Repo.AddContent([]byte("v2\n"))
+	if _, err := s.Repo.Commit("second"); err != nil {
+		t.Fatal(err)
+	}
+
+	reopened, err := NewStore(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := reopened.Recovered; r == nil || r.Number != 2 || !r.Completed {
+		t.Fatalf("Recovered = %+v, want #2 completed", r)
+	}
+	if !reopened.Repo.HasTag("v2") {
+		t.Error("tag v2 should have been created")
+	}
+	if current, _, hasChanges, _ := reopened.Now(); current != 2 || hasChanges {
+		t.Errorf("Now = #%d, changed %v; want clean at #2", current, hasChanges)
+	}
+
+	// The journal is gone, so opening again recovers nothing
+	if again, _ := NewStore(testFile); again.Recovered != nil {
+		t.Errorf("second open Recovered = %+v", again.Recovered)
+	}
+}
+
+func TestRecoverSaveRollsBack(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	// Crash before the commit
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	s.beginSave(2, "second", true)
+	s.Repo.AddContent([]byte("v2\n"))
+
+	reopened, _ := NewStore(testFile)
+	if r := reopened.Recovered; r == nil || r.Number != 2 || r.Completed {
+		t.Fatalf("Recovered = %+v, want #2 undone", r)
+	}
+	if reopened.Repo.HasTag("v2") {
+		t.Error("no tag should exist for the undone save")
+	}
+
+	snap, err := reopened.Save("again")
+	if err != nil || snap.Number != 2 {
+		t.Fatalf("Save after rollback = %+v, %v; want #2", snap, err)
+	}
+}
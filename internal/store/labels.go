@@ -0,0 +1,103 @@
+package store
+
+import (
+	"errors"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// labelsMeta is the metadata file listing the store's labels, one per line
+const labelsMeta = "labels"
+
+var ErrInvalidLabel = errors.New("labels must be non-empty and contain no spaces or commas")
+
+// ValidateLabel checks that a label can be stored and typed on a command line
+func ValidateLabel(label string) error {
+	if label == "" || strings.ContainsRune(label, ',') || strings.IndexFunc(label, unicode.IsSpace) >= 0 {
+		return ErrInvalidLabel
+	}
+	return nil
+}
+
+// Labels returns the store's labels, sorted
+func (s *Store) Labels() []string {
+	data, err := s.Repo.ReadMeta(labelsMeta)
+	if err != nil {
+		return nil
+	}
+	var labels []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			labels = append(labels, line)
+		}
+	}
+	sort.Strings(labels)
+	return labels
+}
+
+// HasLabel reports whether the store carries label
+func (s *Store) HasLabel(label string) bool {
+	for _, l := range s.Labels() {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// AddLabels adds labels to the store, ignoring ones it already has
+func (s *Store) AddLabels(labels ...string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	set := make(map[string]bool)
+	for _, l := range s.Labels() {
+		set[l] = true
+	}
+	for _, l := range labels {
+		if err := ValidateLabel(l); err != nil {
+			return err
+		}
+		set[l] = true
+	}
+	return s.writeLabels(set)
+}
+
+// RemoveLabels removes labels from the store, ignoring ones it lacks
+func (s *Store) RemoveLabels(labels ...string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	set := make(map[string]bool)
+	for _, l := range s.Labels() {
+		set[l] = true
+	}
+	for _, l := range labels {
+		delete(set, l)
+	}
+	return s.writeLabels(set)
+}
+
+func (s *Store) writeLabels(set map[string]bool) error {
+	labels := make([]string, 0, len(set))
+	for l := range set {
+		labels = append(labels, l)
+	}
+	sort.Strings(labels)
+	data := strings.Join(labels, "\n")
+	if data != "" {
+		data += "\n"
+	}
+	return s.Repo.WriteMeta(labelsMeta, []byte(data))
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestLabels(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "export PATH")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	if err := s.AddLabels("dotfiles"); err != ErrNotTracked {
+		t.Errorf("AddLabels on untracked = %v, want ErrNotTracked", err)
+	}
+	s.Initialize()
+
+	if err := s.AddLabels("shell", "dotfiles", "shell"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Labels(); !reflect.DeepEqual(got, []string{"dotfiles", "shell"}) {
+		t.Errorf("Labels = %v, want [dotfiles shell]", got)
+	}
+	if !s.HasLabel("dotfiles") || s.HasLabel("work") {
+		t.Error("HasLabel gave the wrong answer")
+	}
+
+	if err := s.RemoveLabels("shell", "missing"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.Labels(); !reflect.DeepEqual(got, []string{"dotfiles"}) {
+		t.Errorf("Labels = %v, want [dotfiles]", got)
+	}
+
+	for _, bad := range []string{"", "two words", "a,b"} {
+		if err := s.AddLabels(bad); err != ErrInvalidLabel {
+			t.Errorf("AddLabels(%q) = %v, want ErrInvalidLabel", bad, err)
+		}
+	}
+}
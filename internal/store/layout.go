@@ -0,0 +1,252 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// pathIndexFile, in the global directory, maps file paths to the store
+// directories of content-keyed global stores. A path in the index opens
+// the store it names whatever the layout, so histories stay attached to
+// files that moved.
+const pathIndexFile = "paths.json"
+
+// loadPathIndex reads the path index, keyed by normalized path. A missing
+// or unreadable index is empty.
+func loadPathIndex(globalDir string) map[string]string {
+	index := map[string]string{}
+	data, err := os.ReadFile(filepath.Join(globalDir, pathIndexFile))
+	if err == nil {
+		json.Unmarshal(data, &index)
+	}
+	return index
+}
+
+// updatePathIndex applies update to the path index and writes it back
+func updatePathIndex(globalDir string, update func(index map[string]string)) error {
+	index := loadPathIndex(globalDir)
+	update(index)
+	data, err := json.MarshalIndent(index, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(globalDir, 0755); err != nil {
+		return err
+	}
+	path := filepath.Join(globalDir, pathIndexFile)
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// indexedStoreDir returns the store directory the path index names for
+// absPath, if there is one and it still exists
+func indexedStoreDir(globalDir, absPath string) (string, bool) {
+	key, ok := loadPathIndex(globalDir)[normalizePath(absPath)]
+	if !ok || !isHashDirName(key) {
+		return "", false
+	}
+	dir := filepath.Join(globalDir, key)
+	if _, err := os.Stat(dir); err != nil {
+		return "", false
+	}
+	return dir, true
+}
+
+// newContentKey returns a store directory name for a new history whose
+// first snapshot is content. The time makes histories started from the
+// same content distinct.
+func newContentKey(content []byte) string {
+	sum := sha256.Sum256(content)
+	hash := sha256.Sum256([]byte(fmt.Sprintf("content:%x:%d", sum, time.Now().UnixNano())))
+	return hex.EncodeToString(hash[:8])
+}
+
+// contentKeyed reports whether a new global store for s should be keyed
+// by content and listed in the path index
+func (s *Store) contentKeyed() bool {
+	return s.Global && s.IsDefaultProfile() && s.globalLayout == config.LayoutContent
+}
+
+// useStoreDir points s at the global store in hashDir
+func (s *Store) useStoreDir(hashDir string) {
+	s.mainGitDir = filepath.Join(hashDir, s.FileName+".git")
+	s.GitDir = s.mainGitDir
+	s.Repo = s.newRepo(s.GitDir)
+}
+
+// indexPath lists s's file in the path index under its store directory
+func (s *Store) indexPath() error {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return err
+	}
+	key := filepath.Base(s.OopsDirPath())
+	return updatePathIndex(globalDir, func(index map[string]string) {
+		index[normalizePath(s.FilePath)] = key
+	})
+}
+
+// unindexPath drops s's file from the path index
+func (s *Store) unindexPath() error {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return err
+	}
+	if _, ok := loadPathIndex(globalDir)[normalizePath(s.FilePath)]; !ok {
+		return nil
+	}
+	return updatePathIndex(globalDir, func(index map[string]string) {
+		delete(index, normalizePath(s.FilePath))
+	})
+}
+
+// FindHistory looks for the global history of a file that was moved or
+// copied here: a store for a file of the same name elsewhere whose latest
+// snapshot holds the file's current content. Returns nil if there is
+// none, or if new global stores are not keyed by content.
+func (s *Store) FindHistory() (*GlobalStoreInfo, error) {
+	if !s.contentKeyed() || s.Exists() {
+		return nil, nil
+	}
+	content, _, err := s.snapshotContent()
+	if err != nil {
+		return nil, err
+	}
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return nil, err
+	}
+	stores, err := ListGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, info := range stores {
+		if info.FileName != s.FileName || info.FilePath == s.FilePath {
+			continue
+		}
+		repo := git.NewRepo(filepath.Join(globalDir, info.HashDir, info.FileName+".git"), "", info.FileName)
+		latest, err := repo.GetLatestTagNumber()
+		if err != nil || latest < 1 {
+			continue
+		}
+		if stored, err := repo.Show(versionTag(latest)); err == nil && string(stored) == string(content) {
+			found := info
+			return &found, nil
+		}
+	}
+	return nil, nil
+}
+
+// Attach takes over the history found by FindHistory. If the file it
+// belonged to is gone, the file was moved and the history follows it;
+// otherwise it was copied and the copy starts from a copy of the history.
+// Either way the path index records where the history now lives.
+func (s *Store) Attach(from *GlobalStoreInfo) (moved bool, err error) {
+	if s.Exists() {
+		return false, ErrAlreadyTracked
+	}
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return false, err
+	}
+	source := filepath.Join(globalDir, from.HashDir)
+
+	// Hold the lock of the history taken over or copied
+	src := &Store{Repo: git.NewRepo(filepath.Join(source, s.FileName+".git"), "", ""), LockWait: s.LockWait}
+	unlock, err := src.lock()
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	moved = true
+	if _, err := os.Stat(from.FilePath); err == nil {
+		moved = false
+	}
+
+	hashDir := source
+	if !moved {
+		content, _, err := s.snapshotContent()
+		if err != nil {
+			return false, err
+		}
+		hashDir = filepath.Join(globalDir, newContentKey(content))
+		if err := copyTree(source, hashDir); err != nil {
+			os.RemoveAll(hashDir)
+			return false, err
+		}
+		os.Remove(filepath.Join(hashDir, s.FileName+".git"+lockSuffix))
+	}
+
+	s.useStoreDir(hashDir)
+	if err := s.saveMetadata(); err != nil {
+		return false, err
+	}
+	err = updatePathIndex(globalDir, func(index map[string]string) {
+		if moved {
+			delete(index, normalizePath(from.FilePath))
+		}
+		index[normalizePath(s.FilePath)] = filepath.Base(hashDir)
+	})
+	if err != nil {
+		return false, err
+	}
+	s.loadRegion()
+
+	// The file holds the latest snapshot, so that is where it stands
+	latest, err := s.Repo.GetLatestTagNumber()
+	if err != nil {
+		return moved, err
+	}
+	return moved, s.recordPosition(latest)
+}
+
+// copyTree copies the directory tree at src to dst, which must not exist
+func copyTree(src, dst string) error {
+	return copyTreeSkipping(src, dst, "")
+}
+
+// copyTreeSkipping copies the directory tree at src to dst, which must
+// not exist, leaving out the directory skip inside it
+func copyTreeSkipping(src, dst, skip string) error {
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	}
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() && path == skip {
+			return filepath.SkipDir
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dst, rel)
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return os.WriteFile(target, data, 0644)
+	})
+}
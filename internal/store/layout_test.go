@@ -0,0 +1,108 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+func TestContentLayoutFollowsMovedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	cfg := config.DefaultConfig()
+	cfg.GlobalLayout = config.LayoutContent
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	original := filepath.Join(dir, "a", "notes.txt")
+	os.MkdirAll(filepath.Dir(original), 0755)
+	os.WriteFile(original, []byte("v1"), 0644)
+	s, _ := NewGlobalStore(original)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(original, []byte("v2"), 0644)
+	s.Save("")
+
+	globalDir, _ := GetGlobalOopsDir()
+	if got := filepath.Base(s.OopsDirPath()); got == hashFilePath(original) {
+		t.Error("content-keyed store should not use the path hash")
+	}
+	if _, ok := indexedStoreDir(globalDir, original); !ok {
+		t.Fatal("new store should be in the path index")
+	}
+
+	// Moved: the history follows the file
+	moved := filepath.Join(dir, "b", "notes.txt")
+	os.MkdirAll(filepath.Dir(moved), 0755)
+	os.Rename(original, moved)
+	m, _ := NewGlobalStore(moved)
+	from, err := m.FindHistory()
+	if err != nil || from == nil || from.FilePath != original {
+		t.Fatalf("FindHistory = %+v, %v", from, err)
+	}
+	wasMoved, err := m.Attach(from)
+	if err != nil || !wasMoved {
+		t.Fatalf("Attach = %v, %v; want a move", wasMoved, err)
+	}
+	if m.OopsDirPath() != s.OopsDirPath() {
+		t.Error("a moved file should keep its store directory")
+	}
+	reopened, _ := NewGlobalStore(moved)
+	if snaps, _ := reopened.History(); len(snaps) != 2 {
+		t.Errorf("moved file has %d snapshots, want 2", len(snaps))
+	}
+	if _, ok := indexedStoreDir(globalDir, original); ok {
+		t.Error("the old path should leave the index")
+	}
+
+	// Copied: the copy gets its own copy of the history
+	copied := filepath.Join(dir, "c", "notes.txt")
+	os.MkdirAll(filepath.Dir(copied), 0755)
+	os.WriteFile(copied, []byte("v2"), 0644)
+	c, _ := NewGlobalStore(copied)
+	from, _ = c.FindHistory()
+	if from == nil || from.FilePath != moved {
+		t.Fatalf("FindHistory for copy = %+v", from)
+	}
+	if wasMoved, err := c.Attach(from); err != nil || wasMoved {
+		t.Fatalf("Attach = %v, %v; want a copy", wasMoved, err)
+	}
+	if c.OopsDirPath() == m.OopsDirPath() {
+		t.Error("a copy should get its own store directory")
+	}
+	c.Save("") // no changes, but the copy's history works
+	os.WriteFile(copied, []byte("v3"), 0644)
+	if _, err := c.Save(""); err != nil {
+		t.Fatal(err)
+	}
+	if snaps, _ := reopened.History(); len(snaps) != 2 {
+		t.Error("saving the copy should not change the original's history")
+	}
+
+	// Deleting a store drops it from the index
+	if err := c.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := loadPathIndex(globalDir)[normalizePath(copied)]; ok {
+		t.Error("deleted store should leave the index")
+	}
+}
+
+func TestPathLayoutDoesNotSearch(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	testFile, cleanup := setupTestFile(t, "content")
+	defer cleanup()
+	s, _ := NewGlobalStore(testFile)
+	if from, err := s.FindHistory(); from != nil || err != nil {
+		t.Errorf("FindHistory = %+v, %v; want nothing with the path layout", from, err)
+	}
+}
@@ -0,0 +1,159 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The store lock is held while an operation changes the history, so that
+// two processes saving, restoring or rewriting the same store at once
+// cannot interleave their commits and tags. It is advisory: only oops
+// honours it. It is kept beside the history directory rather than in it,
+// so that a rebuild swapping the directory cannot move or remove it.
+const lockSuffix = ".lock"
+
+// lockPath returns where the store lock of s is kept
+func (s *Store) lockPath() string {
+	return s.Repo.GitDir + lockSuffix
+}
+
+// DefaultLockWait is how long stores opened from now on wait for another
+// process to release the store lock before giving up. 0 gives up at once.
+var DefaultLockWait time.Duration
+
+// LockedError is returned when the store lock is held by another process
+// for longer than the store is willing to wait
+type LockedError struct {
+	Host  string    // machine the holder runs on, "" if unknown
+	PID   int       // holder's process id, 0 if unknown
+	Since time.Time // when the lock was taken
+}
+
+func (e *LockedError) Error() string {
+	holder := "another oops process"
+	if e.PID > 0 {
+		holder = fmt.Sprintf("oops process %d", e.PID)
+		if host, _ := os.Hostname(); e.Host != "" && e.Host != host {
+			holder += " on " + e.Host
+		}
+	}
+	return fmt.Sprintf("file is being changed by %s (since %s)", holder, e.Since.Format("15:04:05"))
+}
+
+// lock takes the store lock for an operation that changes the history,
+// waiting up to LockWait while another process holds it. A lock left by a
+// process that has exited, or older than staleLockAge when the holder is
+// on another machine, is broken. Nested calls on the same store share the
+// lock. The returned function releases it.
+func (s *Store) lock() (func(), error) {
+	if s.Repo.InMemory() || s.locked > 0 {
+		s.locked++
+		return func() { s.locked-- }, nil
+	}
+	path := s.lockPath()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return nil, err
+	}
+
+	deadline := time.Now().Add(s.LockWait)
+	for {
+		err := s.createLock(path)
+		if err == nil {
+			s.locked = 1
+			return func() {
+				s.locked = 0
+				os.Remove(path)
+			}, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		holder, ok := s.lockHolder(path)
+		if !ok {
+			continue // Released or broken while we looked
+		}
+		stale := func() bool {
+			holder, ok := s.lockHolder(path)
+			return ok && s.lockIsStale(holder)
+		}
+		if s.lockIsStale(holder) && s.breakLock(path, stale) {
+			continue
+		}
+		if !time.Now().Before(deadline) {
+			return nil, holder
+		}
+		time.Sleep(s.lockPoll())
+	}
+}
+
+// tryLock takes the store lock if it is free, reporting whether it did
+func (s *Store) tryLock() (func(), bool) {
+	wait := s.LockWait
+	s.LockWait = 0
+	defer func() { s.LockWait = wait }()
+	unlock, err := s.lock()
+	return unlock, err == nil
+}
+
+// breakLockAge is how old the guard taken to break a lock must be before
+// it is assumed to be left by a crash; breaking takes a moment
+const breakLockAge = time.Minute
+
+// breakLock removes the lock at path if stale still reports it stale,
+// returning false if another process is breaking it meanwhile. Two
+// processes can find the same stale lock; removing it without a check
+// could remove the fresh lock the first of them took in its place. So
+// breaking takes a guard lock of its own and looks at the lock again
+// while holding it: the second process then finds the first one's lock,
+// which is not stale, and leaves it.
+func (s *Store) breakLock(path string, stale func() bool) bool {
+	guard := path + ".break"
+	if err := s.createLock(guard); err != nil {
+		if info, err := os.Stat(guard); err == nil && time.Since(info.ModTime()) > breakLockAge {
+			os.Remove(guard)
+		}
+		return false
+	}
+	defer os.Remove(guard)
+	if stale() {
+		os.Remove(path)
+	}
+	return true
+}
+
+// lockHolder reads who holds the lock at path. ok is false if it is gone.
+func (s *Store) lockHolder(path string) (holder *LockedError, ok bool) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, false
+	}
+	holder = &LockedError{Since: info.ModTime()}
+	if data, err := os.ReadFile(path); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 2 {
+			holder.Host = fields[0]
+			holder.PID, _ = strconv.Atoi(fields[1])
+		}
+	}
+	return holder, true
+}
+
+// lockIsStale reports whether holder's lock was left behind. A holder on
+// this machine is checked directly; otherwise only the age of the lock
+// tells, since a long operation elsewhere cannot be told from a crash.
+func (s *Store) lockIsStale(holder *LockedError) bool {
+	if host, _ := os.Hostname(); holder.PID > 0 && holder.Host == host {
+		return !processAlive(holder.PID)
+	}
+	return time.Since(holder.Since) > s.staleLockAge()
+}
+
+// lockOwner is the content of a lock file, naming the process holding it
+func lockOwner() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s %d\n", host, os.Getpid())
+}
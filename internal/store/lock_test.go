@@ -0,0 +1,214 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStoreLock(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+
+	// Another process on this machine is saving
+	os.MkdirAll(s.Repo.MetaDir(), 0755)
+	path := s.lockPath()
+	host, _ := os.Hostname()
+	os.WriteFile(path, []byte(fmt.Sprintf("%s %d\n", host, os.Getpid())), 0644)
+
+	_, err := s.Save("blocked")
+	var locked *LockedError
+	if !errors.As(err, &locked) || locked.PID != os.Getpid() {
+		t.Fatalf("Save while locked = %v, want LockedError naming the holder", err)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 1 {
+		t.Errorf("latest = %d after refused save, want 1", latest)
+	}
+
+	// With LockWait the save goes ahead once the holder is done
+	s.LockWait = 5 * time.Second
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		os.Remove(path)
+	}()
+	if _, err := s.Save("waited"); err != nil {
+		t.Fatalf("Save with LockWait failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("lock should be released after the save")
+	}
+}
+
+func TestStoreLockStale(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+
+	// Left by a process that has exited
+	os.MkdirAll(s.Repo.MetaDir(), 0755)
+	path := s.lockPath()
+	host, _ := os.Hostname()
+	os.WriteFile(path, []byte(fmt.Sprintf("%s %d\n", host, 1<<30)), 0644)
+	if _, err := s.Save("after crash"); err != nil {
+		t.Errorf("Save with dead holder failed: %v", err)
+	}
+
+	// Held on another machine: only age makes it stale
+	os.WriteFile(testFile, []byte("v3\n"), 0644)
+	os.WriteFile(path, []byte("elsewhere 123\n"), 0644)
+	var locked *LockedError
+	if _, err := s.Save("remote holder"); !errors.As(err, &locked) || locked.Host != "elsewhere" {
+		t.Fatalf("Save with remote holder = %v, want LockedError", err)
+	}
+	old := time.Now().Add(-2 * staleLockAge)
+	os.Chtimes(path, old, old)
+	if _, err := s.Save("stale remote"); err != nil {
+		t.Errorf("Save with stale remote lock failed: %v", err)
+	}
+}
+
+func TestMergeHistoriesLocks(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	local, _ := NewStore(testFile)
+	local.Initialize()
+	global, _ := NewGlobalStore(testFile)
+	defer global.Delete()
+	global.Initialize()
+	os.WriteFile(testFile, []byte("global v2"), 0644)
+	global.Save("global change")
+
+	// Refused while another process changes the history
+	path := local.lockPath()
+	host, _ := os.Hostname()
+	os.WriteFile(path, []byte(fmt.Sprintf("%s %d\n", host, os.Getpid())), 0644)
+	var locked *LockedError
+	if _, err := MergeHistories(local, global); !errors.As(err, &locked) {
+		t.Fatalf("MergeHistories while locked = %v, want LockedError", err)
+	}
+	os.Remove(path)
+
+	// Saves running meanwhile are neither lost nor interleaved with the
+	// rebuild
+	saver, _ := NewStore(testFile)
+	saver.LockWait = 10 * time.Second
+	local.LockWait = 10 * time.Second
+	const saves = 5
+	done := make(chan error)
+	go func() {
+		for i := 0; i < saves; i++ {
+			os.WriteFile(testFile, []byte(fmt.Sprintf("local v%d", i+2)), 0644)
+			if _, err := saver.Save(""); err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	if _, err := MergeHistories(local, global); err != nil {
+		t.Fatalf("MergeHistories failed: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Fatalf("Save during the merge failed: %v", err)
+	}
+
+	reopened, _ := NewStore(testFile)
+	history, _ := reopened.History()
+	var numbered int
+	for _, snap := range history {
+		if snap.Number > 0 {
+			numbered++
+		}
+	}
+	if numbered != saves+2 {
+		t.Errorf("%d snapshots after merging during saves, want %d", numbered, saves+2)
+	}
+}
+
+func TestStaleLockBrokenOnce(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	// Several processes find the same lock, left by one that has exited
+	os.MkdirAll(s.Repo.MetaDir(), 0755)
+	path := s.lockPath()
+	host, _ := os.Hostname()
+	os.WriteFile(path, []byte(fmt.Sprintf("%s %d\n", host, 1<<30)), 0644)
+
+	var mu sync.Mutex
+	holding, most := 0, 0
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			waiter, _ := NewStore(testFile)
+			waiter.LockWait = 10 * time.Second
+			unlock, err := waiter.lock()
+			if err != nil {
+				t.Error(err)
+				return
+			}
+			mu.Lock()
+			holding++
+			most = max(most, holding)
+			mu.Unlock()
+			time.Sleep(20 * time.Millisecond)
+			mu.Lock()
+			holding--
+			mu.Unlock()
+			unlock()
+		}()
+	}
+	wg.Wait()
+	if most != 1 {
+		t.Errorf("%d processes held the lock at once after it was broken, want 1", most)
+	}
+}
+
+func TestBreakLockRechecksHolder(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.MkdirAll(s.Repo.MetaDir(), 0755)
+	path := s.lockPath()
+	host, _ := os.Hostname()
+	os.WriteFile(path, []byte(fmt.Sprintf("%s %d\n", host, 1<<30)), 0644)
+
+	// One waiter breaks the stale lock and takes its own
+	first, _ := NewStore(testFile)
+	unlock, err := first.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer unlock()
+
+	// Another, which found the stale lock too, comes to break it late
+	second, _ := NewStore(testFile)
+	second.breakLock(path, func() bool {
+		holder, ok := second.lockHolder(path)
+		return ok && second.lockIsStale(holder)
+	})
+	if _, err := os.Stat(path); err != nil {
+		t.Fatal("breaking a stale lock late removed the lock taken in its place")
+	}
+	if _, err := os.Stat(path + ".break"); !os.IsNotExist(err) {
+		t.Error("guard left behind after breaking")
+	}
+}
@@ -0,0 +1,23 @@
+//go:build windows
+
+package store
+
+import "os"
+
+// linkCount returns the number of hard links to a file, 0 if unknown.
+// os.Stat does not report it on Windows, so the result of the link call
+// decides.
+func linkCount(info os.FileInfo) uint64 {
+	return 0
+}
+
+// processAlive reports whether a process with the given id is running.
+// Finding a process opens a handle to it, which fails once it has exited.
+func processAlive(pid int) bool {
+	p, err := os.FindProcess(pid)
+	if err != nil {
+		return false
+	}
+	p.Release()
+	return true
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/iyulab/oops/internal/git"
+	"github.com/iyulab/oops/internal/imagediff"
+)
+
+// WorkFileLabel labels the working file among compared versions
+const WorkFileLabel = "now"
+
+// VersionMatrix holds the pairwise differences among several versions
+type VersionMatrix struct {
+	Labels []string // "#2", "#3", ... and WorkFileLabel if included
+	Unit   string   // what Diff counts: "lines" or "% pixels"
+	Diff   [][]int  // Diff[i][j] is the difference between versions i and j
+}
+
+// Closest returns the index of the version most similar to version i,
+// or -1 if there is no other version
+func (m *VersionMatrix) Closest(i int) int {
+	best := -1
+	for j := range m.Labels {
+		if j != i && (best < 0 || m.Diff[i][j] < m.Diff[i][best]) {
+			best = j
+		}
+	}
+	return best
+}
+
+// CompareVersions measures how much each pair of snapshots differs, and
+// the working file too if withWorkFile is set. Text is compared by lines
+// changed (added plus removed), images by the share of pixels that differ.
+func (s *Store) CompareVersions(nums []int, withWorkFile bool) (*VersionMatrix, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+
+	m := &VersionMatrix{Unit: "lines"}
+	if s.IsImage() {
+		m.Unit = "% pixels"
+	}
+
+	var contents [][]byte
+	for _, num := range nums {
+		content, err := s.Repo.Show(versionTag(num))
+		if err != nil {
+			return nil, fmt.Errorf("%w: #%d", ErrVersionNotFound, num)
+		}
+		contents = append(contents, content)
+		m.Labels = append(m.Labels, fmt.Sprintf("#%d", num))
+	}
+	if withWorkFile {
+		content, err := s.Repo.ReadWorkFile()
+		if err != nil {
+			return nil, err
+		}
+		contents = append(contents, content)
+		m.Labels = append(m.Labels, WorkFileLabel)
+	}
+
+	m.Diff = make([][]int, len(contents))
+	for i := range m.Diff {
+		m.Diff[i] = make([]int, len(contents))
+	}
+	for i := range contents {
+		for j := i + 1; j < len(contents); j++ {
+			d, err := s.difference(contents[i], contents[j])
+			if err != nil {
+				return nil, err
+			}
+			m.Diff[i][j], m.Diff[j][i] = d, d
+		}
+	}
+	return m, nil
+}
+
+// difference measures how much two versions differ, in the unit
+// CompareVersions reports
+func (s *Store) difference(a, b []byte) (int, error) {
+	if s.IsImage() {
+		c, err := imagediff.Compare(a, b)
+		if err != nil {
+			return 0, err
+		}
+		return int(c.DiffRatio*100 + 0.5), nil
+	}
+
+	textA, err := s.contentText(a)
+	if err != nil {
+		return 0, err
+	}
+	textB, err := s.contentText(b)
+	if err != nil {
+		return 0, err
+	}
+	added, removed := git.CountLineChanges(textA, textB)
+	return added + removed, nil
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStoreCompareVersions(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\nb\nc\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("a\nb\nc\nd\n"), 0644)
+	s.Save("")
+	os.WriteFile(testFile, []byte("x\ny\n"), 0644)
+	s.Save("")
+	os.WriteFile(testFile, []byte("a\nb\nc\nd\ne\n"), 0644)
+
+	m, err := s.CompareVersions([]int{1, 2, 3}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(m.Labels) != 4 || m.Labels[3] != WorkFileLabel {
+		t.Fatalf("Labels = %v", m.Labels)
+	}
+	if m.Diff[0][1] != 1 || m.Diff[1][0] != 1 || m.Diff[0][0] != 0 {
+		t.Errorf("#1 vs #2 = %d, want 1 line", m.Diff[0][1])
+	}
+	if got := m.Labels[m.Closest(3)]; got != "#2" {
+		t.Errorf("closest to the working file = %s, want #2", got)
+	}
+
+	if _, err := s.CompareVersions([]int{1, 9}, false); err == nil {
+		t.Error("CompareVersions should fail for a missing snapshot")
+	}
+}
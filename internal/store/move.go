@@ -0,0 +1,175 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+// Move renames the tracked file to newPath and moves its history along,
+// so snapshots taken under the old name stay with the file. A file that
+// was already moved by hand, leaving nothing at the old path, only has
+// its history moved. Returns the store for the file at its new path.
+func (s *Store) Move(newPath string) (*Store, error) {
+	if s.Memory || !s.IsDefaultProfile() {
+		return nil, fmt.Errorf("only a file's main history can be moved")
+	}
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	newAbs, err := filepath.Abs(newPath)
+	if err != nil {
+		return nil, err
+	}
+	if normalizePath(newAbs) == normalizePath(s.FilePath) {
+		return nil, fmt.Errorf("%s is already the file's path", newPath)
+	}
+	target, err := NewStoreWithOptions(newAbs, StoreOptions{Global: s.Global})
+	if err != nil {
+		return nil, err
+	}
+	if target.Exists() {
+		return nil, fmt.Errorf("%s: %w", newAbs, ErrAlreadyTracked)
+	}
+
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	// A global store's lock moves with its directory, so it is also
+	// removed at its new place
+	released := false
+	defer func() {
+		if !released {
+			unlock()
+		}
+	}()
+
+	renamed, err := moveWorkFile(s.FilePath, newAbs)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.moveHistory(target); err != nil {
+		if renamed {
+			os.Rename(newAbs, s.FilePath)
+		}
+		return nil, err
+	}
+	unlock()
+	released = true
+	if err := moveFileSettings(s.FilePath, newAbs); err != nil {
+		return nil, fmt.Errorf("moved, but not the file's settings in the config: %w", err)
+	}
+
+	moved, err := NewStoreWithOptions(newAbs, StoreOptions{Global: s.Global})
+	if err != nil {
+		return nil, err
+	}
+	if s.Global {
+		os.Remove(filepath.Join(filepath.Dir(moved.mainGitDir), s.FileName+".git"+lockSuffix))
+	} else {
+		os.Remove(filepath.Dir(s.mainGitDir)) // Only if nothing else is tracked there
+	}
+	moved.RegisterLocal()
+	return moved, nil
+}
+
+// moveFileSettings moves what the user's config keeps for the file at
+// from, its validate hook and remote token commands, to the file at to
+func moveFileSettings(from, to string) error {
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	from, to = normalizePath(from), normalizePath(to)
+	hook, cmds := cfg.ValidateHook(from), cfg.TokenCmds[from]
+	if hook == "" && len(cmds) == 0 {
+		return nil
+	}
+	cfg.SetValidateHook(from, "")
+	cfg.SetValidateHook(to, hook)
+	cfg.SetTokenCmds(from, nil)
+	cfg.SetTokenCmds(to, cmds)
+	return cfg.Save()
+}
+
+// moveWorkFile renames the file at oldPath to newPath, reporting whether
+// it did. Nothing is renamed if the file is already at newPath.
+func moveWorkFile(oldPath, newPath string) (bool, error) {
+	_, oldErr := os.Stat(oldPath)
+	_, newErr := os.Stat(newPath)
+	switch {
+	case oldErr == nil && newErr == nil:
+		return false, fmt.Errorf("%s already exists", newPath)
+	case oldErr == nil:
+		return true, os.Rename(oldPath, newPath)
+	case newErr == nil:
+		return false, nil
+	}
+	return false, fmt.Errorf("%s not found", newPath)
+}
+
+// moveHistory moves the store's history to where target keeps it
+func (s *Store) moveHistory(target *Store) error {
+	if !s.Global {
+		if err := os.MkdirAll(filepath.Dir(target.mainGitDir), 0755); err != nil {
+			return err
+		}
+		return os.Rename(s.mainGitDir, target.mainGitDir)
+	}
+
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return err
+	}
+	info := GlobalStoreInfo{
+		FilePath: s.FilePath,
+		FileName: s.FileName,
+		HashDir:  filepath.Base(s.OopsDirPath()),
+	}
+	if err := checkGlobalTarget(globalDir, info, target.FilePath); err != nil {
+		return err
+	}
+	return relocateGlobalStore(globalDir, info, target.FilePath)
+}
+
+// FindMoved looks for where the tracked file went when it is missing: an
+// untracked file in the same directory holding its latest snapshot, as
+// left by renaming the file outside oops. Returns "" if the file is still
+// there or no such file is found.
+func (s *Store) FindMoved() (string, error) {
+	if s.Memory {
+		return "", nil
+	}
+	if _, err := os.Stat(s.FilePath); err == nil {
+		return "", nil
+	}
+	latest, err := s.Repo.GetLatestTagNumber()
+	if err != nil || latest < 1 {
+		return "", err
+	}
+	stored, err := s.Repo.Show(versionTag(latest))
+	if err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(s.BaseDir)
+	if err != nil {
+		return "", err
+	}
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || ValidateFileName(entry.Name()) != nil {
+			continue
+		}
+		candidate, err := NewStoreWithOptions(filepath.Join(s.BaseDir, entry.Name()), StoreOptions{Global: s.Global})
+		if err != nil || candidate.Exists() {
+			continue
+		}
+		if content, _, err := candidate.snapshotContent(); err == nil && string(content) == string(stored) {
+			return candidate.FilePath, nil
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,112 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMove(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	if _, err := s.Save("second"); err != nil {
+		t.Fatal(err)
+	}
+
+	newPath := filepath.Join(filepath.Dir(testFile), "renamed.txt")
+	moved, err := s.Move(newPath)
+	if err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if _, err := os.Stat(testFile); !os.IsNotExist(err) {
+		t.Error("the file is still at its old path")
+	}
+	if s.Exists() {
+		t.Error("the history is still at the old path")
+	}
+
+	// Snapshots taken under the old name read back under the new one
+	if content, err := moved.Repo.Show("v1"); err != nil || string(content) != "v1" {
+		t.Errorf("Show(v1) = %q, %v", content, err)
+	}
+	if _, _, hasChanges, err := moved.Now(); err != nil || hasChanges {
+		t.Errorf("Now() hasChanges=%v err=%v, want clean", hasChanges, err)
+	}
+	os.WriteFile(newPath, []byte("v3"), 0644)
+	if snap, err := moved.Save("third"); err != nil || snap.Number != 3 {
+		t.Fatalf("Save after moving = %+v, %v", snap, err)
+	}
+	if err := moved.Back(1, true); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(newPath); string(data) != "v1" {
+		t.Errorf("after Back(1) content = %q, want v1", data)
+	}
+}
+
+func TestFindMoved(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testFile, cleanup := setupTestFile(t, "tracked content")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if moved, _ := s.FindMoved(); moved != "" {
+		t.Errorf("FindMoved = %q with the file in place", moved)
+	}
+
+	// Renamed outside oops
+	dir := filepath.Dir(testFile)
+	os.WriteFile(filepath.Join(dir, "other.txt"), []byte("something else"), 0644)
+	newPath := filepath.Join(dir, "renamed.txt")
+	os.Rename(testFile, newPath)
+	moved, err := s.FindMoved()
+	if err != nil || moved != newPath {
+		t.Fatalf("FindMoved = %q, %v; want %q", moved, err, newPath)
+	}
+
+	// Only the history has to follow
+	if _, err := s.Move(newPath); err != nil {
+		t.Fatalf("Move of a file moved by hand failed: %v", err)
+	}
+	if data, _ := os.ReadFile(newPath); string(data) != "tracked content" {
+		t.Errorf("content = %q after moving the history", data)
+	}
+}
+
+func TestMoveGlobal(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	dir := filepath.Join(home, "docs")
+	os.MkdirAll(dir, 0755)
+	testFile := filepath.Join(dir, "notes.txt")
+	os.WriteFile(testFile, []byte("v1"), 0644)
+
+	s, _ := NewGlobalStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := s.Move(filepath.Join(dir, "journal.txt"))
+	if err != nil {
+		t.Fatalf("Move failed: %v", err)
+	}
+	if !moved.Exists() || s.Exists() {
+		t.Fatalf("after Move: new exists=%v, old exists=%v", moved.Exists(), s.Exists())
+	}
+	stores, _ := ListGlobalStores()
+	if len(stores) != 1 || stores[0].FilePath != moved.FilePath {
+		t.Errorf("ListGlobalStores = %+v, want only %s", stores, moved.FilePath)
+	}
+	locks, _ := filepath.Glob(filepath.Join(moved.OopsDirPath(), "*"+lockSuffix))
+	if len(locks) != 0 {
+		t.Errorf("locks left after Move: %v", locks)
+	}
+}
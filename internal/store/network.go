@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/iyulab/oops/internal/netfs"
+)
+
+// On network filesystems lock files are checked less often, since each
+// check is a round trip to the server, and judged stale later, since
+// their modification time comes from the server's clock rather than ours
+const (
+	networkPoll         = 250 * time.Millisecond
+	networkStaleLockAge = 30 * time.Minute
+)
+
+// Network reports the filesystem holding the store. Stores on network
+// filesystems flush metadata as it is written and lock by hard link.
+func (s *Store) Network() netfs.Info {
+	return s.network
+}
+
+// lockPoll returns how long to wait between attempts to take a lock
+func (s *Store) lockPoll() time.Duration {
+	if s.network.Network {
+		return networkPoll
+	}
+	return pendingPoll
+}
+
+// staleLockAge returns how old a lock file must be before it is assumed
+// to be left behind by a crashed process
+func (s *Store) staleLockAge() time.Duration {
+	if s.network.Network {
+		return networkStaleLockAge
+	}
+	return staleLockAge
+}
+
+// createLock creates the lock file at path naming this process as its
+// holder, failing with an error satisfying os.IsExist if it is held.
+// Exclusive create is not atomic over older NFS versions, so on network
+// filesystems the lock is taken by hard-linking a uniquely named file to
+// it, which is; shares without hard links fall back to exclusive create.
+func (s *Store) createLock(path string) error {
+	if s.network.Network {
+		err := linkLock(path)
+		if err == nil || os.IsExist(err) {
+			return err
+		}
+	}
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	f.WriteString(lockOwner())
+	return f.Close()
+}
+
+// linkLock takes the lock at path by hard-linking a temporary file to it.
+// A link that reports an error may still have been made if the server's
+// reply was lost, which the temporary file's link count reveals.
+func linkLock(path string) error {
+	host, _ := os.Hostname()
+	tmp := filepath.Join(filepath.Dir(path), fmt.Sprintf(".%s.%s.%d", filepath.Base(path), host, os.Getpid()))
+	if err := os.WriteFile(tmp, []byte(lockOwner()), 0644); err != nil {
+		return err
+	}
+	defer os.Remove(tmp)
+
+	err := os.Link(tmp, path)
+	if err == nil {
+		return nil
+	}
+	if info, statErr := os.Stat(tmp); statErr == nil && linkCount(info) == 2 {
+		return nil
+	}
+	return err
+}
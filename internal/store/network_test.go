@@ -0,0 +1,39 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iyulab/oops/internal/netfs"
+)
+
+func TestNetworkLock(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "content")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.network = netfs.Info{Network: true, Type: "nfs"}
+	path := filepath.Join(t.TempDir(), pendingLock)
+
+	if err := s.createLock(path); err != nil {
+		t.Fatalf("createLock failed: %v", err)
+	}
+	if err := s.createLock(path); !os.IsExist(err) {
+		t.Errorf("second createLock = %v, want an exists error", err)
+	}
+
+	// The temporary link source is cleaned up
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	if len(entries) != 1 {
+		t.Errorf("lock directory holds %d entries, want only the lock", len(entries))
+	}
+
+	os.Remove(path)
+	if err := s.createLock(path); err != nil {
+		t.Errorf("createLock after release failed: %v", err)
+	}
+	if s.staleLockAge() != networkStaleLockAge {
+		t.Errorf("staleLockAge = %v, want %v", s.staleLockAge(), networkStaleLockAge)
+	}
+}
@@ -0,0 +1,81 @@
+package store
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// notesMeta is the metadata file holding notes added to snapshots after
+// they were saved, keyed by commit hash like origins.json
+const notesMeta = "notes.json"
+
+func (s *Store) loadNotes() map[string]string {
+	notes := map[string]string{}
+	if data, err := s.Repo.ReadMeta(notesMeta); err == nil {
+		json.Unmarshal(data, &notes)
+	}
+	return notes
+}
+
+// SetNote attaches a note to snapshot num, replacing any earlier one. An
+// empty note removes it.
+func (s *Store) SetNote(num int, note string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	hash, err := s.Repo.TagCommit(versionTag(num))
+	if err != nil {
+		return ErrVersionNotFound
+	}
+
+	notes := s.loadNotes()
+	if note = strings.TrimSpace(note); note == "" {
+		delete(notes, shortHash(hash))
+	} else {
+		notes[shortHash(hash)] = note
+	}
+	data, err := json.Marshal(notes)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(notesMeta, data)
+}
+
+// Note returns the note attached to a snapshot, "" if there is none
+func (s *Store) Note(snap Snapshot) string {
+	return s.loadNotes()[snap.Hash]
+}
+
+// hashKeyedMeta lists the metadata files keyed by commit hash, which are
+// rekeyed when the history is rewritten
+var hashKeyedMeta = []string{notesMeta, originsMeta, blessedMeta, fileMetaMeta}
+
+// rekeyMeta moves the entries of the hash-keyed metadata file name in repo
+// to the commits they were rebuilt as, dropping those of removed commits
+func rekeyMeta(repo *git.Repo, name string, rebuilt map[string]string) error {
+	data, err := repo.ReadMeta(name)
+	if err != nil {
+		return nil // Nothing recorded
+	}
+	var entries map[string]json.RawMessage
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil
+	}
+	moved := map[string]json.RawMessage{}
+	for hash, value := range entries {
+		if newHash, ok := rebuilt[hash]; ok {
+			moved[newHash] = value
+		}
+	}
+	if data, err = json.Marshal(moved); err != nil {
+		return err
+	}
+	return repo.WriteMeta(name, data)
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+)
+
+func TestSetNote(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("v2")
+
+	if err := s.SetNote(1, "the version we shipped"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SetNote(9, "nope"); err != ErrVersionNotFound {
+		t.Errorf("SetNote(9) = %v, want ErrVersionNotFound", err)
+	}
+
+	notes := func() map[int]string {
+		history, _ := s.History()
+		got := map[int]string{}
+		for _, snap := range history {
+			if note := s.Note(snap); note != "" {
+				got[snap.Number] = note
+			}
+		}
+		return got
+	}
+	if got := notes(); len(got) != 1 || got[1] != "the version we shipped" {
+		t.Errorf("notes = %v", got)
+	}
+
+	s.SetNote(1, "")
+	if got := notes(); len(got) != 0 {
+		t.Errorf("notes after removal = %v", got)
+	}
+}
+
+func TestNotesSurviveRebuild(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2", "v3"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save(content)
+	}
+	s.SetNote(3, "shipped")
+
+	if _, err := s.Retain(Retention{Prune: PrunePolicy{KeepLast: 2}}, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Drop(2); err != nil {
+		t.Fatal(err)
+	}
+
+	history, _ := s.History()
+	if len(history) != 1 || s.Note(history[0]) != "shipped" {
+		t.Errorf("note of the remaining snapshot = %q, want shipped", s.Note(history[0]))
+	}
+}
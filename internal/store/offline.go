@@ -0,0 +1,44 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// OfflineRoot returns the root of the volume holding absPath if that
+// volume is not mounted right now: a drive letter or network share that
+// is not there, or a mount point under /media, /run/media, /mnt or
+// /Volumes that is missing or empty. It returns "" when the volume is
+// present, so a missing file there is really gone.
+func OfflineRoot(absPath string) string {
+	root := mountRoot(absPath, runtime.GOOS, os.Getenv("USER"))
+	if root == "" {
+		return ""
+	}
+	entries, err := os.ReadDir(root)
+	if err != nil || len(entries) == 0 {
+		return root
+	}
+	return ""
+}
+
+// mountRoot returns the directory absPath's volume is mounted at, when
+// that can be told from the path: its drive or share on Windows, a
+// removable volume's mount point, or /mnt/<name> on Linux
+func mountRoot(absPath, goos, user string) string {
+	if vol := filepath.VolumeName(absPath); vol != "" {
+		return vol + string(filepath.Separator)
+	}
+	if root, _, ok := mountedVolume(absPath, goos, user); ok {
+		return root
+	}
+	if goos == "linux" {
+		parts := strings.Split(filepath.ToSlash(absPath), "/")
+		if len(parts) > 3 && parts[0] == "" && parts[1] == "mnt" && parts[2] != "" {
+			return "/mnt/" + parts[2]
+		}
+	}
+	return ""
+}
@@ -0,0 +1,36 @@
+package store
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestMountRoot(t *testing.T) {
+	tests := []struct {
+		path, goos, want string
+	}{
+		{"/mnt/nas/docs/a.txt", "linux", "/mnt/nas"},
+		{"/media/alice/USB/a.txt", "linux", "/media/alice/USB"},
+		{"/Volumes/Backup/a.txt", "darwin", "/Volumes/Backup"},
+		{"/home/alice/a.txt", "linux", ""},
+		{"/mnt/a.txt", "linux", ""},
+		{"/mnt/nas/a.txt", "darwin", ""},
+	}
+	for _, tt := range tests {
+		if got := mountRoot(tt.path, tt.goos, "alice"); got != tt.want {
+			t.Errorf("mountRoot(%q, %s) = %q, want %q", tt.path, tt.goos, got, tt.want)
+		}
+	}
+}
+
+func TestOfflineRoot(t *testing.T) {
+	if got := OfflineRoot(filepath.Join(t.TempDir(), "gone.txt")); got != "" {
+		t.Errorf("OfflineRoot of a path on a mounted disk = %q, want none", got)
+	}
+	if runtime.GOOS == "linux" {
+		if got := OfflineRoot("/mnt/oops-test-not-mounted/docs/a.txt"); got != "/mnt/oops-test-not-mounted" {
+			t.Errorf("OfflineRoot of an absent mount = %q", got)
+		}
+	}
+}
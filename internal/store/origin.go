@@ -0,0 +1,56 @@
+package store
+
+import (
+	"encoding/json"
+)
+
+// Origin records what created a snapshot
+type Origin string
+
+// Snapshot origins
+const (
+	OriginManual   Origin = "manual"   // oops save and other commands
+	OriginAuto     Origin = "auto"     // oops watch, after the file changed
+	OriginSchedule Origin = "schedule" // a snapshot schedule in the daemon
+)
+
+// originsMeta is the metadata file holding the origin of snapshots not
+// saved by hand, keyed by commit hash like images.json
+const originsMeta = "origins.json"
+
+func (s *Store) loadOrigins() map[string]Origin {
+	origins := map[string]Origin{}
+	if data, err := s.Repo.ReadMeta(originsMeta); err == nil {
+		json.Unmarshal(data, &origins)
+	}
+	return origins
+}
+
+// SaveAs saves a snapshot like Save, recording what created it
+func (s *Store) SaveAs(message string, origin Origin) (*Snapshot, error) {
+	snap, err := s.Save(message)
+	if err != nil || origin == OriginManual {
+		return snap, err
+	}
+	defer s.autoRetain()
+
+	hash, err := s.Repo.TagCommit(versionTag(snap.Number))
+	if err != nil {
+		return snap, nil // The snapshot is saved; only its origin is lost
+	}
+	origins := s.loadOrigins()
+	origins[shortHash(hash)] = origin
+	if data, err := json.Marshal(origins); err == nil {
+		s.Repo.WriteMeta(originsMeta, data)
+	}
+	return snap, nil
+}
+
+// Origin returns what created a snapshot. Snapshots with no recorded
+// origin were saved by hand.
+func (s *Store) Origin(snap Snapshot) Origin {
+	if origin, ok := s.loadOrigins()[snap.Hash]; ok {
+		return origin
+	}
+	return OriginManual
+}
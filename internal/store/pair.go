@@ -0,0 +1,91 @@
+package store
+
+import (
+	"fmt"
+	"os"
+)
+
+// ContentPair holds the stored content of two versions being compared
+type ContentPair struct {
+	OldLabel, NewLabel string // e.g. "#3" or "working file"
+	Old, New           []byte
+}
+
+// ContentPair returns the content of the versions Changes would compare:
+// none compares the working file with the latest snapshot, one compares
+// it with that snapshot, and two compare the snapshots
+func (s *Store) ContentPair(versions ...int) (*ContentPair, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+
+	pair := &ContentPair{}
+	var err error
+	switch len(versions) {
+	case 0, 1:
+		num := 0
+		if len(versions) == 1 {
+			num = versions[0]
+		} else if num, err = s.Repo.GetLatestTagNumber(); err != nil {
+			return nil, err
+		}
+		if pair.Old, err = s.Repo.Show(versionTag(num)); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		if pair.New, err = s.Repo.ReadWorkFile(); err != nil {
+			return nil, err
+		}
+		pair.OldLabel, pair.NewLabel = fmt.Sprintf("#%d", num), "working file"
+	case 2:
+		if pair.Old, err = s.Repo.Show(versionTag(versions[0])); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		if pair.New, err = s.Repo.Show(versionTag(versions[1])); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		pair.OldLabel, pair.NewLabel = fmt.Sprintf("#%d", versions[0]), fmt.Sprintf("#%d", versions[1])
+	default:
+		return nil, fmt.Errorf("too many versions")
+	}
+	return pair, nil
+}
+
+// ContentPairAgainst returns the content of snapshot versions[0], or of
+// the working file if no version is given, and of the file at path, such
+// as a copy of the tracked file from another machine
+func (s *Store) ContentPairAgainst(path string, versions ...int) (*ContentPair, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+
+	pair := &ContentPair{NewLabel: path}
+	var err error
+	switch len(versions) {
+	case 0:
+		if pair.Old, err = s.Repo.ReadWorkFile(); err != nil {
+			return nil, err
+		}
+		pair.OldLabel = "working file"
+	case 1:
+		if pair.Old, err = s.Repo.Show(versionTag(versions[0])); err != nil {
+			return nil, ErrVersionNotFound
+		}
+		pair.OldLabel = fmt.Sprintf("#%d", versions[0])
+	default:
+		return nil, fmt.Errorf("too many versions")
+	}
+	if pair.New, err = os.ReadFile(path); err != nil {
+		return nil, err
+	}
+	return pair, nil
+}
+
+// ChangesAgainst returns the diff from the version ContentPairAgainst
+// takes to the file at path
+func (s *Store) ChangesAgainst(path string, versions ...int) (string, error) {
+	pair, err := s.ContentPairAgainst(path, versions...)
+	if err != nil {
+		return "", err
+	}
+	return s.Repo.DiffOther(path, pair.Old, pair.New), nil
+}
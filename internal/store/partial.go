@@ -0,0 +1,53 @@
+package store
+
+import (
+	"bytes"
+
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// BackPartial restores the parts of snapshot num that choose accepts,
+// leaving the rest of the working file as it is. choose is asked about
+// each change from the working file to the snapshot in turn, as hunk i
+// of n. Choosing every hunk restores the snapshot as Back does; otherwise
+// the result is left unsaved. Returns the number of hunks restored and
+// the number there were.
+func (s *Store) BackPartial(num int, choose func(h git.Hunk, i, n int) bool) (restored, total int, err error) {
+	if !s.Exists() {
+		return 0, 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+
+	snapshot, err := s.Content(num)
+	if err != nil {
+		return 0, 0, err
+	}
+	current, err := s.readRawWorkFile()
+	if err != nil {
+		return 0, 0, err
+	}
+	if eol.IsBinary(current) || eol.IsBinary(snapshot) {
+		return 0, 0, ErrBinaryMerge
+	}
+
+	hunks := git.Hunks(string(current), string(snapshot))
+	var chosen []git.Hunk
+	for i, h := range hunks {
+		if choose(h, i+1, len(hunks)) {
+			chosen = append(chosen, h)
+		}
+	}
+	switch len(chosen) {
+	case 0:
+		return 0, len(hunks), nil
+	case len(hunks):
+		return len(chosen), len(hunks), s.Back(num, true)
+	}
+	restoredText := git.ApplyHunks(string(current), chosen)
+	return len(chosen), len(hunks), s.Repo.WriteWorkFileFrom(bytes.NewReader([]byte(restoredText)))
+}
@@ -0,0 +1,37 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+func TestBackPartial(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\nb\nc\nd\n")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("A\nb\nc\nD\n"), 0644)
+	s.Save("caps")
+
+	// Restore only the first line of #1
+	first := func(h git.Hunk, i, n int) bool { return i == 1 }
+	restored, total, err := s.BackPartial(1, first)
+	if err != nil || restored != 1 || total != 2 {
+		t.Fatalf("BackPartial = %d of %d, %v; want 1 of 2", restored, total, err)
+	}
+	assertContent(t, testFile, "a\nb\nc\nD\n")
+	if _, _, hasChanges, _ := s.Now(); !hasChanges {
+		t.Error("a partial restore should be left unsaved")
+	}
+
+	// Choosing the rest is a full restore of #1
+	restored, total, err = s.BackPartial(1, func(git.Hunk, int, int) bool { return true })
+	if err != nil || restored != 1 || total != 1 {
+		t.Fatalf("BackPartial of the rest = %d of %d, %v", restored, total, err)
+	}
+	if current, _, hasChanges, _ := s.Now(); current != 1 || hasChanges {
+		t.Errorf("Now = #%d changed=%v, want #1 clean", current, hasChanges)
+	}
+}
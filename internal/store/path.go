@@ -0,0 +1,82 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+)
+
+// ErrInvalidFileName is returned for file names that cannot be stored safely
+var ErrInvalidFileName = errors.New("invalid file name")
+
+// Device names reserved by Windows regardless of extension
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// ValidateFileName checks that a tracked file name is safe to use as a
+// store directory name. It rejects names that could escape the .oops
+// directory or that the host filesystem would silently rewrite.
+func ValidateFileName(name string) error {
+	return validateFileName(name, runtime.GOOS == "windows")
+}
+
+func validateFileName(name string, windows bool) error {
+	switch {
+	case name == "":
+		return fmt.Errorf("%w: empty name", ErrInvalidFileName)
+	case name == "." || name == "..":
+		return fmt.Errorf("%w: %q", ErrInvalidFileName, name)
+	case strings.ContainsRune(name, 0):
+		return fmt.Errorf("%w: contains NUL byte", ErrInvalidFileName)
+	case strings.ContainsAny(name, `/\`):
+		return fmt.Errorf("%w: %q contains a path separator", ErrInvalidFileName, name)
+	}
+
+	if windows {
+		if strings.HasSuffix(name, ".") || strings.HasSuffix(name, " ") {
+			return fmt.Errorf("%w: %q ends with a dot or space", ErrInvalidFileName, name)
+		}
+		if strings.ContainsAny(name, `<>:"|?*`) {
+			return fmt.Errorf("%w: %q contains a reserved character", ErrInvalidFileName, name)
+		}
+		base := strings.ToUpper(strings.SplitN(name, ".", 2)[0])
+		if windowsReservedNames[strings.TrimRight(base, " ")] {
+			return fmt.Errorf("%w: %q is a reserved device name", ErrInvalidFileName, name)
+		}
+	}
+
+	return nil
+}
+
+// validateFilePath checks a user-supplied path before it is resolved
+func validateFilePath(path string) error {
+	if path == "" {
+		return fmt.Errorf("%w: empty path", ErrInvalidFileName)
+	}
+	if strings.ContainsRune(path, 0) {
+		return fmt.Errorf("%w: path contains NUL byte", ErrInvalidFileName)
+	}
+	return nil
+}
+
+// validateMetadataPath checks a file path read back from global metadata.
+// Paths must be absolute and name a valid file.
+func validateMetadataPath(path string) error {
+	if err := validateFilePath(path); err != nil {
+		return err
+	}
+	if !filepath.IsAbs(path) {
+		return fmt.Errorf("%w: %q is not absolute", ErrInvalidFileName, path)
+	}
+	if filepath.Clean(path) != path {
+		return fmt.Errorf("%w: %q is not clean", ErrInvalidFileName, path)
+	}
+	return ValidateFileName(filepath.Base(path))
+}
@@ -0,0 +1,69 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateFileName(t *testing.T) {
+	tests := []struct {
+		name    string
+		windows bool
+		valid   bool
+	}{
+		{"notes.txt", false, true},
+		{".hidden", false, true},
+		{"", false, false},
+		{".", false, false},
+		{"..", false, false},
+		{"a/b.txt", false, false},
+		{`a\b.txt`, false, false},
+		{"nul\x00byte", false, false},
+		{"trailing.", false, true},
+		{"trailing.", true, false},
+		{"trailing ", true, false},
+		{"CON", true, false},
+		{"con.txt", true, false},
+		{"console.txt", true, true},
+		{"what?.txt", true, false},
+	}
+
+	for _, tt := range tests {
+		err := validateFileName(tt.name, tt.windows)
+		if tt.valid && err != nil {
+			t.Errorf("validateFileName(%q, %v) = %v, want nil", tt.name, tt.windows, err)
+		}
+		if !tt.valid && !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("validateFileName(%q, %v) = %v, want ErrInvalidFileName", tt.name, tt.windows, err)
+		}
+	}
+}
+
+func TestNewStoreRejectsHostilePaths(t *testing.T) {
+	for _, p := range []string{"", "bad\x00name.txt", "/"} {
+		if _, err := NewStore(p); !errors.Is(err, ErrInvalidFileName) {
+			t.Errorf("NewStore(%q) = %v, want ErrInvalidFileName", p, err)
+		}
+	}
+}
+
+func TestValidateMetadataPath(t *testing.T) {
+	abs := filepath.Join(t.TempDir(), "file.txt")
+
+	if err := validateMetadataPath(abs); err != nil {
+		t.Errorf("validateMetadataPath(%q) = %v, want nil", abs, err)
+	}
+
+	for _, p := range []string{"relative/file.txt", abs + "/../../etc/passwd", "", abs + "\x00"} {
+		if err := validateMetadataPath(p); err == nil {
+			t.Errorf("validateMetadataPath(%q) = nil, want error", p)
+		}
+	}
+}
+
+func TestHashFilePathDotSegments(t *testing.T) {
+	if hashFilePath("/path/to/./sub/../file.txt") != hashFilePath("/path/to/file.txt") {
+		t.Error("Equivalent paths should have same hash")
+	}
+}
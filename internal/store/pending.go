@@ -0,0 +1,254 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/events"
+)
+
+// Deferred saves are journaled as a pair of metadata files per snapshot,
+// pending-<id>.data holding the captured content and pending-<id>.json
+// describing it. The description is written last, so an entry is only
+// picked up once its content is complete. Ids sort in capture order.
+const (
+	pendingPrefix = "pending-"
+	pendingLock   = "pending.lock"
+	pendingPoll   = 50 * time.Millisecond
+)
+
+// PendingSnapshot is content captured by SaveDeferred that has not been
+// committed yet
+type PendingSnapshot struct {
+	ID        string    `json:"id"`
+	Message   string    `json:"message"`
+	Timestamp time.Time `json:"timestamp"`
+	Size      int64     `json:"size"`
+}
+
+func pendingData(id string) string { return pendingPrefix + id + ".data" }
+func pendingInfo(id string) string { return pendingPrefix + id + ".json" }
+
+// Pending returns the snapshots waiting to be committed, oldest first
+func (s *Store) Pending() ([]PendingSnapshot, error) {
+	names, err := s.Repo.MetaNames(pendingPrefix)
+	if err != nil {
+		return nil, err
+	}
+
+	var pending []PendingSnapshot
+	for _, name := range names {
+		if !strings.HasSuffix(name, ".json") {
+			continue
+		}
+		data, err := s.Repo.ReadMeta(name)
+		if err != nil {
+			continue
+		}
+		var p PendingSnapshot
+		if json.Unmarshal(data, &p) != nil || p.ID == "" {
+			continue // Still being written
+		}
+		pending = append(pending, p)
+	}
+	return pending, nil
+}
+
+// SaveDeferred captures the work file into the journal and returns without
+// committing it. FlushPending commits it later, keeping the capture time.
+func (s *Store) SaveDeferred(message string) (*PendingSnapshot, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if err := s.checkRegion(); err != nil {
+		return nil, err
+	}
+
+	content, err := s.Repo.ReadWorkFile()
+	if err != nil {
+		return nil, err
+	}
+	changed, err := s.changedSincePending(content)
+	if err != nil {
+		return nil, err
+	}
+	if !changed {
+		return nil, ErrNoChanges
+	}
+	if err := s.Validate(content); err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	p := &PendingSnapshot{
+		ID:        fmt.Sprintf("%020d", now.UnixNano()),
+		Message:   message,
+		Timestamp: now,
+		Size:      int64(len(content)),
+	}
+	if err := s.Repo.WriteMeta(pendingData(p.ID), content); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.WriteMeta(pendingInfo(p.ID), data); err != nil {
+		s.Repo.RemoveMeta(pendingData(p.ID))
+		return nil, err
+	}
+	return p, nil
+}
+
+// changedSincePending reports whether content differs from the newest
+// pending snapshot, or from the latest commit when nothing is pending
+func (s *Store) changedSincePending(content []byte) (bool, error) {
+	pending, err := s.Pending()
+	if err != nil {
+		return false, err
+	}
+	if len(pending) == 0 {
+		return s.Repo.HasChanges()
+	}
+	last, err := s.Repo.ReadMeta(pendingData(pending[len(pending)-1].ID))
+	if err != nil {
+		return false, err
+	}
+	return !bytes.Equal(content, last), nil
+}
+
+// FlushPending commits the journaled snapshots in capture order and
+// returns how many were committed. Only one process flushes at a time;
+// others wait for it to finish, as long as the store lock allows.
+func (s *Store) FlushPending() (int, error) {
+	if pending, err := s.Pending(); err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	// The store lock is taken first, as saves flushing the journal hold it
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	unlockPending, err := s.lockPending()
+	if err != nil {
+		return 0, err
+	}
+	defer unlockPending()
+
+	// Another process may have flushed while we waited
+	pending, err := s.Pending()
+	if err != nil || len(pending) == 0 {
+		return 0, err
+	}
+
+	committed := 0
+	for _, p := range pending {
+		ok, err := s.commitPending(p)
+		if err != nil {
+			var tooLarge *SnapshotTooLargeError
+			if errors.As(err, &tooLarge) {
+				s.removePending(p) // It can never be committed
+			}
+			return committed, err
+		}
+		if ok {
+			committed++
+		}
+		s.removePending(p)
+	}
+	return committed, nil
+}
+
+func (s *Store) removePending(p PendingSnapshot) {
+	s.Repo.RemoveMeta(pendingInfo(p.ID))
+	s.Repo.RemoveMeta(pendingData(p.ID))
+}
+
+// commitPending commits one journaled snapshot, skipping it when it
+// matches the latest snapshot
+func (s *Store) commitPending(p PendingSnapshot) (bool, error) {
+	content, err := s.Repo.ReadMeta(pendingData(p.ID))
+	if err != nil {
+		return false, err
+	}
+
+	latestNum, err := s.Repo.GetLatestTagNumber()
+	if err != nil {
+		return false, err
+	}
+	if latestNum > 0 {
+		if latest, err := s.Repo.Show(versionTag(latestNum)); err == nil && bytes.Equal(latest, content) {
+			return false, nil
+		}
+	}
+	nextNum := latestNum + 1
+
+	message := p.Message
+	if message == "" {
+		message = fmt.Sprintf("Snapshot #%d", nextNum)
+	}
+
+	stored, _, err := s.fitContent(content)
+	if err != nil {
+		return false, err
+	}
+	if err := s.beginSave(nextNum, message, true); err != nil {
+		return false, err
+	}
+	if _, err := s.Repo.CommitContent(stored, message, p.Timestamp); err != nil {
+		s.endSave()
+		return false, err
+	}
+	if err := s.Repo.Tag(versionTag(nextNum)); err != nil {
+		return false, err
+	}
+	if err := s.recordPosition(nextNum); err != nil {
+		return false, err
+	}
+	s.endSave()
+	s.recordImageInfo(nextNum)
+	s.recordFileMeta(nextNum)
+	s.emit(events.SnapshotCreated, nextNum, message)
+	return true, nil
+}
+
+// pendingLockStale reports whether the journal lock at path is old enough
+// to have been left behind
+func (s *Store) pendingLockStale(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && time.Since(info.ModTime()) > s.staleLockAge()
+}
+
+// lockPending takes the journal lock, waiting while another process holds
+// it unless the lock has gone stale. The returned function releases it.
+func (s *Store) lockPending() (func(), error) {
+	if s.Repo.InMemory() {
+		return func() {}, nil
+	}
+	if err := os.MkdirAll(s.Repo.MetaDir(), 0755); err != nil {
+		return nil, err
+	}
+
+	path := filepath.Join(s.Repo.MetaDir(), pendingLock)
+	for {
+		err := s.createLock(path)
+		if err == nil {
+			return func() { os.Remove(path) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+		if s.pendingLockStale(path) && s.breakLock(path, func() bool { return s.pendingLockStale(path) }) {
+			continue
+		}
+		time.Sleep(s.lockPoll())
+	}
+}
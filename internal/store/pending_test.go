@@ -0,0 +1,75 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSaveDeferred(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	if _, err := s.SaveDeferred("second"); err != nil {
+		t.Fatalf("SaveDeferred failed: %v", err)
+	}
+	os.WriteFile(testFile, []byte("v3\n"), 0644)
+	if _, err := s.SaveDeferred(""); err != nil {
+		t.Fatalf("SaveDeferred failed: %v", err)
+	}
+	if _, err := s.SaveDeferred(""); err != ErrNoChanges {
+		t.Errorf("unchanged SaveDeferred = %v, want ErrNoChanges", err)
+	}
+
+	// Nothing is committed yet, but the file counts as saved
+	pending, _ := s.Pending()
+	if len(pending) != 2 || pending[0].Message != "second" {
+		t.Fatalf("Pending = %+v, want two entries oldest first", pending)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 1 {
+		t.Errorf("latest = %d before flush, want 1", latest)
+	}
+	if _, _, hasChanges, _ := s.Now(); hasChanges {
+		t.Error("work file should match its pending snapshot")
+	}
+
+	n, err := s.FlushPending()
+	if err != nil || n != 2 {
+		t.Fatalf("FlushPending = %d, %v; want 2", n, err)
+	}
+	if pending, _ := s.Pending(); len(pending) != 0 {
+		t.Errorf("Pending after flush = %+v", pending)
+	}
+	current, latest, hasChanges, _ := s.Now()
+	if current != 3 || latest != 3 || hasChanges {
+		t.Errorf("Now = #%d of #%d, changed %v; want clean at #3", current, latest, hasChanges)
+	}
+	for num, want := range map[int]string{2: "v2\n", 3: "v3\n"} {
+		if got, _ := s.Repo.Show(versionTag(num)); string(got) != want {
+			t.Errorf("snapshot #%d = %q, want %q", num, got, want)
+		}
+	}
+}
+
+func TestSaveFlushesPending(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	s.SaveDeferred("deferred")
+	os.WriteFile(testFile, []byte("v3\n"), 0644)
+
+	snap, err := s.Save("direct")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Number != 3 {
+		t.Errorf("direct save = #%d, want #3 after the deferred one", snap.Number)
+	}
+}
@@ -0,0 +1,18 @@
+package store
+
+import "io"
+
+// WriteFrom replaces the tracked file with the content read from src, such
+// as a command's output, ready to be saved. The file need not be tracked
+// yet. Nothing is saved; a restore or save by another process waits for
+// the write to finish.
+func (s *Store) WriteFrom(src io.Reader) error {
+	if s.Exists() {
+		unlock, err := s.lock()
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	return s.Repo.WriteWorkFileFrom(src)
+}
@@ -0,0 +1,38 @@
+package store
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestWriteFrom(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+
+	// An untracked file is written without creating a store
+	if err := s.WriteFrom(strings.NewReader("generated v1")); err != nil {
+		t.Fatalf("WriteFrom of an untracked file failed: %v", err)
+	}
+	if s.Exists() {
+		t.Error("WriteFrom started tracking the file")
+	}
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := s.WriteFrom(strings.NewReader("generated v2")); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(testFile); string(data) != "generated v2" {
+		t.Errorf("content = %q after WriteFrom", data)
+	}
+	snap, err := s.Save("")
+	if err != nil || snap.Number != 2 {
+		t.Fatalf("Save after WriteFrom = %+v, %v", snap, err)
+	}
+	if content, _ := s.Repo.Show("v1"); string(content) != "generated v1" {
+		t.Errorf("Show(v1) = %q", content)
+	}
+}
@@ -0,0 +1,277 @@
+package store
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/iyulab/oops/internal/events"
+)
+
+// ErrNothingToRedo is returned when there is no undone position to return to
+var ErrNothingToRedo = errors.New("nothing to redo")
+
+// positionsMeta is the metadata file holding the undo stack
+const positionsMeta = "positions.json"
+
+// maxPositions bounds how many recent positions are remembered
+const maxPositions = 20
+
+// positionStack records the snapshots the working file has recently been
+// restored to. Cursor indexes the current position; entries after it can
+// be redone.
+type positionStack struct {
+	Positions []int `json:"positions"`
+	Cursor    int   `json:"cursor"`
+}
+
+func (s *Store) loadPositions() *positionStack {
+	stack := &positionStack{}
+	data, err := s.Repo.ReadMeta(positionsMeta)
+	if err != nil {
+		return stack
+	}
+	if err := json.Unmarshal(data, stack); err != nil {
+		return &positionStack{}
+	}
+
+	// Drop positions whose snapshots no longer exist (e.g. after compaction)
+	var valid []int
+	cursor := 0
+	for i, num := range stack.Positions {
+		if !s.Repo.HasTag(versionTag(num)) {
+			continue
+		}
+		if i <= stack.Cursor {
+			cursor = len(valid)
+		}
+		valid = append(valid, num)
+	}
+	stack.Positions = valid
+	stack.Cursor = cursor
+	return stack
+}
+
+// renumber returns the stack with snapshots given new numbers, dropping
+// those that are not in renumbered
+func (stack *positionStack) renumber(renumbered map[int]int) *positionStack {
+	var positions []int
+	cursor := 0
+	for i, num := range stack.Positions {
+		if renumbered[num] == 0 {
+			continue
+		}
+		if i <= stack.Cursor {
+			cursor = len(positions)
+		}
+		positions = append(positions, renumbered[num])
+	}
+	return &positionStack{Positions: positions, Cursor: cursor}
+}
+
+func (s *Store) savePositions(stack *positionStack) error {
+	if over := len(stack.Positions) - maxPositions; over > 0 {
+		// Trim from whichever end is furthest from the cursor
+		if stack.Cursor >= len(stack.Positions)/2 {
+			stack.Positions = stack.Positions[over:]
+			stack.Cursor -= over
+			if stack.Cursor < 0 {
+				stack.Cursor = 0
+			}
+		} else {
+			stack.Positions = stack.Positions[:maxPositions]
+		}
+	}
+	data, err := json.Marshal(stack)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(positionsMeta, data)
+}
+
+// resetPositions forgets the undo stack
+func (s *Store) resetPositions() error {
+	return s.savePositions(&positionStack{})
+}
+
+// Position returns the snapshot number the working file was last restored
+// to or saved as. Falls back to the latest snapshot.
+func (s *Store) Position() (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	stack := s.loadPositions()
+	if len(stack.Positions) > 0 {
+		return stack.Positions[stack.Cursor], nil
+	}
+	return s.Repo.GetLatestTagNumber()
+}
+
+// recordPosition pushes a new position, discarding anything that could
+// have been redone
+func (s *Store) recordPosition(num int) error {
+	stack := s.loadPositions()
+	if len(stack.Positions) == 0 {
+		if current, err := s.Repo.GetLatestTagNumber(); err == nil && current > 0 && current != num {
+			stack.Positions = []int{current}
+		}
+	} else {
+		stack.Positions = stack.Positions[:stack.Cursor+1]
+	}
+	if n := len(stack.Positions); n == 0 || stack.Positions[n-1] != num {
+		stack.Positions = append(stack.Positions, num)
+	}
+	stack.Cursor = len(stack.Positions) - 1
+	return s.savePositions(stack)
+}
+
+// StepBack undoes the most recent restore, returning the working file to
+// the position it was at before. With no earlier position remembered, it
+// steps to the snapshot preceding the current one instead, so repeated
+// calls keep walking back through history. Returns the new position.
+func (s *Store) StepBack() (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	stack := s.loadPositions()
+	if len(stack.Positions) == 0 {
+		latest, err := s.Repo.GetLatestTagNumber()
+		if err != nil {
+			return 0, err
+		}
+		stack.Positions = []int{latest}
+	}
+
+	if stack.Cursor > 0 {
+		stack.Cursor--
+	} else {
+		prev, ok := s.previousSnapshot(stack.Positions[0])
+		if !ok {
+			return 0, ErrVersionNotFound
+		}
+		stack.Positions = append([]int{prev}, stack.Positions...)
+	}
+	return s.moveTo(stack)
+}
+
+// CanRedo reports whether Redo has an undone position to return to
+func (s *Store) CanRedo() bool {
+	stack := s.loadPositions()
+	return stack.Cursor+1 < len(stack.Positions)
+}
+
+// Redo returns to the position most recently undone by StepBack
+func (s *Store) Redo() (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	stack := s.loadPositions()
+	if stack.Cursor+1 >= len(stack.Positions) {
+		return 0, ErrNothingToRedo
+	}
+	stack.Cursor++
+	return s.moveTo(stack)
+}
+
+// CanForward reports whether Forward has a position to return to
+func (s *Store) CanForward() bool {
+	stack := s.loadPositions()
+	return stack.forward() >= 0
+}
+
+// Forward reverses the most recent step backwards: it redoes what StepBack
+// undid or, with nothing to redo, returns from a restore to the later
+// snapshot the file was at before it. Returns the new position.
+func (s *Store) Forward() (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	stack := s.loadPositions()
+	cursor := stack.forward()
+	if cursor < 0 {
+		return 0, ErrNothingToRedo
+	}
+	if cursor < stack.Cursor {
+		// Returning from a restore is a restore of its own, recorded as a
+		// new position so that oops! undoes it
+		target := stack.Positions[cursor]
+		if err := s.Back(target, true); err != nil {
+			return 0, err
+		}
+		return target, nil
+	}
+	stack.Cursor = cursor
+	return s.moveTo(stack)
+}
+
+// forward returns the cursor Forward moves to, or -1 if there is none
+func (stack *positionStack) forward() int {
+	switch c := stack.Cursor; {
+	case c+1 < len(stack.Positions):
+		return c + 1
+	case c > 0 && stack.Positions[c-1] > stack.Positions[c]:
+		return c - 1
+	}
+	return -1
+}
+
+// moveTo restores the working file to the position at the stack's cursor
+// and saves the stack
+func (s *Store) moveTo(stack *positionStack) (int, error) {
+	target := stack.Positions[stack.Cursor]
+	if err := s.Repo.Checkout(versionTag(target)); err != nil {
+		return 0, err
+	}
+	s.restoreFileMeta(target)
+	if err := s.savePositions(stack); err != nil {
+		return 0, err
+	}
+	s.emit(events.Restored, target, "")
+	s.restoreArtifacts(target)
+	return target, nil
+}
+
+// previousSnapshot finds the closest existing snapshot before num
+func (s *Store) previousSnapshot(num int) (int, bool) {
+	for n := num - 1; n >= 1; n-- {
+		if s.Repo.HasTag(versionTag(n)) {
+			return n, true
+		}
+	}
+	return 0, false
+}
+
+// changedSince reports whether the working file differs from snapshot num
+func (s *Store) changedSince(num int) (bool, error) {
+	if num < 1 {
+		return s.Repo.HasChanges()
+	}
+	saved, err := s.Repo.Show(versionTag(num))
+	if err != nil {
+		return false, err
+	}
+	current, err := s.Repo.ReadWorkFile()
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return true, nil
+		}
+		return false, err
+	}
+	return !bytes.Equal(saved, current), nil
+}
@@ -0,0 +1,189 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func setupPositionStore(t *testing.T) (*Store, string) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	t.Cleanup(cleanup)
+
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for _, content := range []string{"v2", "v3", "v4"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		if _, err := s.Save(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return s, testFile
+}
+
+func assertContent(t *testing.T, path, want string) {
+	t.Helper()
+	content, _ := os.ReadFile(path)
+	if string(content) != want {
+		t.Errorf("content = %q, want %q", content, want)
+	}
+}
+
+func TestStepBackRepeats(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+
+	for _, want := range []int{3, 2, 1} {
+		num, err := s.StepBack()
+		if err != nil {
+			t.Fatalf("StepBack failed: %v", err)
+		}
+		if num != want {
+			t.Errorf("StepBack = #%d, want #%d", num, want)
+		}
+	}
+	assertContent(t, testFile, "v1")
+
+	if _, err := s.StepBack(); err != ErrVersionNotFound {
+		t.Errorf("StepBack at #1 = %v, want ErrVersionNotFound", err)
+	}
+
+	for _, want := range []int{2, 3, 4} {
+		num, err := s.Redo()
+		if err != nil {
+			t.Fatalf("Redo failed: %v", err)
+		}
+		if num != want {
+			t.Errorf("Redo = #%d, want #%d", num, want)
+		}
+	}
+	assertContent(t, testFile, "v4")
+
+	if _, err := s.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Redo at end = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestStepBackUndoesRestore(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+
+	s.Back(1, false)
+	s.Back(3, false)
+
+	current, _, hasChanges, _ := s.Now()
+	if current != 3 || hasChanges {
+		t.Errorf("Now = #%d changed=%v, want #3 clean", current, hasChanges)
+	}
+
+	// Undo walks back through the restores, not the snapshot numbers
+	for _, want := range []int{1, 4} {
+		num, err := s.StepBack()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if num != want {
+			t.Errorf("StepBack = #%d, want #%d", num, want)
+		}
+	}
+	assertContent(t, testFile, "v4")
+
+	// A new restore discards the redo history
+	s.Back(2, false)
+	if _, err := s.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Redo after Back = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestForwardUndoesRestore(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+
+	if s.CanForward() {
+		t.Error("CanForward before any restore")
+	}
+	s.Back(2, false)
+
+	// Forward returns to where the file was before the restore
+	num, err := s.Forward()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if num != 4 {
+		t.Errorf("Forward = #%d, want #4", num)
+	}
+	assertContent(t, testFile, "v4")
+
+	// as a move of its own: there is nothing left to redo, and oops!
+	// undoes it
+	if _, err := s.Redo(); err != ErrNothingToRedo {
+		t.Errorf("Redo after Forward = %v, want ErrNothingToRedo", err)
+	}
+	if num, _ := s.StepBack(); num != 2 {
+		t.Errorf("StepBack after Forward = #%d, want #2", num)
+	}
+	assertContent(t, testFile, "v2")
+
+	// After stepping back, it redoes first
+	s.Back(4, false)
+	s.StepBack()
+	if num, _ := s.Forward(); num != 4 {
+		t.Errorf("Forward after StepBack = #%d, want #4", num)
+	}
+	if _, err := s.Forward(); err != ErrNothingToRedo {
+		t.Errorf("Forward at a newer position = %v, want ErrNothingToRedo", err)
+	}
+}
+
+func TestUndoRestoresPosition(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+
+	s.Back(2, false)
+	os.WriteFile(testFile, []byte("edited"), 0644)
+
+	if err := s.Undo(); err != nil {
+		t.Fatal(err)
+	}
+	assertContent(t, testFile, "v2")
+}
+
+func TestStoreBackupChanges(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	t.Cleanup(cleanup)
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("")
+	s.Back(1, false)
+
+	os.WriteFile(testFile, []byte("unsaved"), 0644)
+	snap, err := s.BackupChanges("backup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap.Number != 3 {
+		t.Errorf("backup = #%d, want #3", snap.Number)
+	}
+
+	// The backup does not move the undo position
+	if pos, _ := s.Position(); pos != 1 {
+		t.Errorf("Position = %d after backup, want 1", pos)
+	}
+}
+
+func TestBackRestoresDeletedFile(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+	os.Remove(testFile)
+
+	_, _, hasChanges, err := s.Now()
+	if err != nil {
+		t.Fatalf("Now with the file deleted: %v", err)
+	}
+	if !hasChanges {
+		t.Error("a deleted file should count as unsaved changes")
+	}
+
+	if err := s.Back(1, true); err != nil {
+		t.Fatalf("Back with the file deleted: %v", err)
+	}
+	assertContent(t, testFile, "v1")
+}
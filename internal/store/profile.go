@@ -0,0 +1,90 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// DefaultProfile names the file's main history. Stores opened without a
+// profile use it.
+const DefaultProfile = "default"
+
+// profilesDirName is the metadata directory of the main history holding
+// the repositories of the other profiles
+const profilesDirName = "profiles"
+
+// ValidateProfileName checks that a profile name is usable as a directory
+// name: letters, digits, '-', '_' and '.', not starting with '.'
+func ValidateProfileName(name string) error {
+	if name == "" {
+		return fmt.Errorf("profile name is empty")
+	}
+	if strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid profile name %q: cannot start with '.'", name)
+	}
+	for _, r := range name {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.':
+		default:
+			return fmt.Errorf("invalid profile name %q: use letters, digits, '-', '_' and '.'", name)
+		}
+	}
+	return nil
+}
+
+// profilesDir returns the directory holding the profiles of the history
+// kept in mainGitDir
+func profilesDir(mainGitDir string) string {
+	return filepath.Join(git.NewRepo(mainGitDir, "", "").MetaDir(), profilesDirName)
+}
+
+// IsDefaultProfile reports whether the store holds the file's main history
+func (s *Store) IsDefaultProfile() bool {
+	return s.Profile == "" || s.Profile == DefaultProfile
+}
+
+// OpenProfile returns the store of another profile of the same file. The
+// profile's history need not exist yet; Initialize starts it.
+func (s *Store) OpenProfile(name string) (*Store, error) {
+	return NewStoreWithOptions(s.FilePath, StoreOptions{Global: s.Global, Profile: name})
+}
+
+// Profiles returns the names of the file's profiles other than the
+// default one, sorted
+func (s *Store) Profiles() ([]string, error) {
+	if s.Memory {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(profilesDir(s.mainGitDir))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var names []string
+	for _, entry := range entries {
+		name, ok := strings.CutSuffix(entry.Name(), ".git")
+		if !entry.IsDir() || !ok || ValidateProfileName(name) != nil {
+			continue
+		}
+		if git.NewRepo(filepath.Join(profilesDir(s.mainGitDir), entry.Name()), "", "").Exists() {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// mainExists reports whether the file's main history exists, which a
+// profile needs before it can be started
+func (s *Store) mainExists() bool {
+	return git.NewRepo(s.mainGitDir, "", "").Exists()
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestProfiles(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "draft\n")
+	defer cleanup()
+
+	main, _ := NewStore(testFile)
+	experiments, err := main.OpenProfile("experiments")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := experiments.Initialize(); err != ErrNotTracked {
+		t.Fatalf("Initialize before the main history = %v, want ErrNotTracked", err)
+	}
+
+	if err := main.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if err := experiments.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Each profile numbers its own snapshots
+	os.WriteFile(testFile, []byte("try A\n"), 0644)
+	if snap, err := experiments.Save("try A"); err != nil || snap.Number != 2 {
+		t.Fatalf("experiments Save = %v, %v", snap, err)
+	}
+	os.WriteFile(testFile, []byte("try B\n"), 0644)
+	experiments.Save("try B")
+
+	os.WriteFile(testFile, []byte("second draft\n"), 0644)
+	if snap, err := main.Save("second draft"); err != nil || snap.Number != 2 {
+		t.Fatalf("main Save = %v, %v", snap, err)
+	}
+	if latest, _ := experiments.GetLatestVersion(); latest != 3 {
+		t.Errorf("experiments latest = %d, want 3", latest)
+	}
+	if stored, _ := experiments.Repo.Show("v3"); string(stored) != "try B\n" {
+		t.Errorf("experiments v3 = %q", stored)
+	}
+
+	if names, _ := main.Profiles(); !slices.Equal(names, []string{"experiments"}) {
+		t.Errorf("Profiles = %v", names)
+	}
+	if same, _ := main.OpenProfile(DefaultProfile); same.GitDir != main.GitDir {
+		t.Errorf("default profile GitDir = %s, want %s", same.GitDir, main.GitDir)
+	}
+	if _, err := main.OpenProfile("../x"); err == nil {
+		t.Error("OpenProfile accepted an invalid name")
+	}
+
+	// Deleting a profile keeps the main history
+	if err := experiments.Delete(); err != nil {
+		t.Fatal(err)
+	}
+	if names, _ := main.Profiles(); len(names) != 0 {
+		t.Errorf("Profiles after delete = %v", names)
+	}
+	if !main.Exists() {
+		t.Error("main history removed with its profile")
+	}
+}
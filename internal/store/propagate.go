@@ -0,0 +1,92 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// propagatedMeta lists the metadata a propagated copy takes along with
+// the history: what describes the snapshots, not where the history is
+// synced or what the template's own file was doing
+var propagatedMeta = []string{
+	regionMeta, notesMeta, fileMetaMeta, imagesMeta, labelsMeta, templateMeta, blessedMeta,
+}
+
+// Propagate starts tracking the file of target with a copy of the history
+// of s, for copies of one file such as handed-out worksheets. Each copy's
+// snapshots then diverge on their own. If the copy differs from the
+// template's latest snapshot, it is saved as its first own snapshot,
+// which is returned.
+func (s *Store) Propagate(target *Store) (*Snapshot, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if target.Exists() {
+		return nil, ErrAlreadyTracked
+	}
+	if s.Memory || target.Memory {
+		return nil, errors.New("histories kept in memory cannot be propagated")
+	}
+	if !target.Repo.WorkFileExists() {
+		return nil, fmt.Errorf("file not found: %s", target.FilePath)
+	}
+
+	// Hold the template still while it is copied
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	if target.contentKeyed() {
+		globalDir, err := GetGlobalOopsDir()
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := indexedStoreDir(globalDir, target.FilePath); !ok {
+			content, _, err := target.snapshotContent()
+			if err != nil {
+				return nil, err
+			}
+			target.useStoreDir(filepath.Join(globalDir, newContentKey(content)))
+		}
+	}
+	if err := os.MkdirAll(filepath.Dir(target.GitDir), 0755); err != nil {
+		return nil, err
+	}
+	if err := copyTreeSkipping(s.GitDir, target.GitDir, s.Repo.MetaDir()); err != nil {
+		os.RemoveAll(target.GitDir)
+		return nil, err
+	}
+	for _, name := range propagatedMeta {
+		if data, err := s.Repo.ReadMeta(name); err == nil {
+			if err := target.Repo.WriteMeta(name, data); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := target.saveMetadata(); err != nil {
+		return nil, err
+	}
+	if target.contentKeyed() {
+		if err := target.indexPath(); err != nil {
+			return nil, err
+		}
+	}
+	target.loadRegion()
+
+	latest, err := target.Repo.GetLatestTagNumber()
+	if err != nil {
+		return nil, err
+	}
+	if err := target.recordPosition(latest); err != nil {
+		return nil, err
+	}
+	snap, err := target.Save("Changes made to the copy")
+	if err == ErrNoChanges {
+		return nil, nil
+	}
+	return snap, err
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestPropagate(t *testing.T) {
+	t.Setenv("HOME", t.TempDir())
+	testFile, cleanup := setupTestFile(t, "Q1:\n")
+	defer cleanup()
+	tmpl, _ := NewStore(testFile)
+	if err := tmpl.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(testFile, []byte("Q1:\nQ2:\n"), 0644)
+	tmpl.Save("add Q2")
+	tmpl.SetNote(2, "handed out")
+
+	// One copy as handed out, one already worked on
+	root := t.TempDir()
+	same := filepath.Join(root, "ann", "test.txt")
+	edited := filepath.Join(root, "bob", "test.txt")
+	for path, content := range map[string]string{same: "Q1:\nQ2:\n", edited: "Q1: 42\nQ2:\n"} {
+		os.MkdirAll(filepath.Dir(path), 0755)
+		os.WriteFile(path, []byte(content), 0644)
+	}
+
+	copyStore, _ := NewStore(same)
+	if snap, err := tmpl.Propagate(copyStore); err != nil || snap != nil {
+		t.Fatalf("Propagate to an unchanged copy = %+v, %v", snap, err)
+	}
+	if latest, _ := copyStore.GetLatestVersion(); latest != 2 {
+		t.Errorf("copy latest = %d, want 2", latest)
+	}
+	if _, _, hasChanges, _ := copyStore.Now(); hasChanges {
+		t.Error("unchanged copy reports changes")
+	}
+	history, _ := copyStore.History()
+	for _, snap := range history {
+		if snap.Number == 2 && copyStore.Note(snap) != "handed out" {
+			t.Errorf("copy note on #2 = %q", copyStore.Note(snap))
+		}
+	}
+
+	editedStore, _ := NewStore(edited)
+	snap, err := tmpl.Propagate(editedStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if snap == nil || snap.Number != 3 {
+		t.Fatalf("Propagate to an edited copy saved %+v, want #3", snap)
+	}
+
+	// The copies diverge without touching the template
+	os.WriteFile(same, []byte("Q1: 7\nQ2:\n"), 0644)
+	copyStore.Save("answer Q1")
+	if latest, _ := tmpl.GetLatestVersion(); latest != 2 {
+		t.Errorf("template latest = %d, want 2", latest)
+	}
+
+	if _, err := tmpl.Propagate(copyStore); err != ErrAlreadyTracked {
+		t.Errorf("Propagate to a tracked copy = %v, want ErrAlreadyTracked", err)
+	}
+}
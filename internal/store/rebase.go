@@ -0,0 +1,224 @@
+package store
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// ReplayConflictError reports local snapshots that changed lines the
+// remote changed as well, so they cannot be replayed after it
+type ReplayConflictError struct {
+	Numbers []int
+}
+
+func (e *ReplayConflictError) Error() string {
+	return fmt.Sprintf("%s changed the same lines as the remote", tagList(e.Numbers))
+}
+
+// replayContent applies the change from base to ours on top of theirs
+func replayContent(base, ours, theirs []byte) ([]byte, bool) {
+	if eol.IsBinary(ours) || eol.IsBinary(theirs) {
+		switch string(base) {
+		case string(theirs):
+			return ours, true
+		case string(ours):
+			return theirs, true
+		}
+		return nil, string(ours) == string(theirs)
+	}
+	merged, ok := git.Merge3(string(base), string(ours), string(theirs))
+	return []byte(merged), ok
+}
+
+// PullRebase pulls from remote name ("" as for ResolveRemote) when both
+// this copy and the remote saved snapshots since they last synced. The
+// snapshots only this copy has are replayed after the remote's, with the
+// remote's changes merged into each, and renumbered. Nothing changes if a
+// replayed snapshot changed lines the remote changed too. Histories that
+// did not diverge are pulled as by Pull. Returns the number of snapshots
+// downloaded and the new number of each replayed snapshot, by old number.
+func (s *Store) PullRebase(name string, auth git.Auth) (int, map[int]int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, nil, err
+	}
+	defer unlock()
+	target, err := s.ResolveRemote(name)
+	if err != nil {
+		return 0, nil, err
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return 0, nil, err
+	}
+	remote, err := s.Repo.Fetch(target.Name, auth)
+	if err != nil {
+		return 0, nil, err
+	}
+	if remote.Head == "" {
+		return 0, nil, nil
+	}
+	head, err := s.Repo.HeadCommit()
+	if err != nil {
+		return 0, nil, err
+	}
+	if ok, err := s.Repo.IsAncestor(head, remote.Head); err != nil {
+		return 0, nil, err
+	} else if ok {
+		n, err := s.Pull(target.Name, auth)
+		return n, nil, err
+	}
+	if ok, err := s.Repo.IsAncestor(remote.Head, head); err != nil || ok {
+		return 0, nil, err // nothing new on the remote
+	}
+
+	position, err := s.Position()
+	if err != nil {
+		return 0, nil, err
+	}
+	if changed, err := s.changedSince(position); err != nil {
+		return 0, nil, err
+	} else if changed {
+		return 0, nil, ErrUncommittedChanges
+	}
+
+	// The shared snapshots are those the remote descends from; the rest
+	// are replayed
+	local, err := s.Repo.VersionTags()
+	if err != nil {
+		return 0, nil, err
+	}
+	var nums []int
+	for tag := range local {
+		num, _ := git.ParseVersionTag(tag)
+		nums = append(nums, num)
+	}
+	sort.Ints(nums)
+	base := 0
+	var replayed []int
+	for i := len(nums) - 1; i >= 0; i-- {
+		ok, err := s.Repo.IsAncestor(local[versionTag(nums[i])], remote.Head)
+		if err != nil {
+			return 0, nil, err
+		}
+		if ok {
+			base = nums[i]
+			replayed = nums[i+1:]
+			break
+		}
+	}
+	if base == 0 {
+		return 0, nil, ErrHistoryDiverged // no snapshot in common
+	}
+
+	var conflicts []int
+	for tag, hash := range remote.Tags {
+		num, _ := git.ParseVersionTag(tag)
+		if mine, ok := local[tag]; ok && mine != hash && num <= base {
+			conflicts = append(conflicts, num)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Ints(conflicts)
+		return 0, nil, &TagConflictError{Numbers: conflicts}
+	}
+
+	// Merge every replayed snapshot before changing anything
+	baseContent, err := s.Repo.Show(versionTag(base))
+	if err != nil {
+		return 0, nil, err
+	}
+	remoteContent, err := s.Repo.ShowCommit(remote.Head)
+	if err != nil {
+		return 0, nil, err
+	}
+	history, err := s.History()
+	if err != nil {
+		return 0, nil, err
+	}
+	byHash := map[string]Snapshot{}
+	for _, snap := range history {
+		byHash[snap.Hash] = snap
+	}
+	var entries []historyEntry
+	for _, num := range replayed {
+		content, err := s.Repo.Show(versionTag(num))
+		if err != nil {
+			return 0, nil, err
+		}
+		merged, ok := replayContent(baseContent, content, remoteContent)
+		if !ok {
+			conflicts = append(conflicts, num)
+			continue
+		}
+		if merged, _, err = s.fitContent(merged); err != nil {
+			return 0, nil, err
+		}
+		entries = append(entries, historyEntry{snap: byHash[shortHash(local[versionTag(num)])], content: merged})
+	}
+	if len(conflicts) > 0 {
+		return 0, nil, &ReplayConflictError{Numbers: conflicts}
+	}
+
+	for _, num := range replayed {
+		if err := s.Repo.DeleteTag(versionTag(num)); err != nil {
+			return 0, nil, err
+		}
+	}
+	added := map[string]string{}
+	next := 0
+	for tag, hash := range remote.Tags {
+		num, _ := git.ParseVersionTag(tag)
+		if _, ok := local[tag]; !ok || slices.Contains(replayed, num) {
+			added[tag] = hash
+		}
+		next = max(next, num)
+	}
+	if err := s.Repo.FastForward(remote.Head, added); err != nil {
+		return 0, nil, err
+	}
+
+	rebuilt := map[string]string{} // old commit hash to new, shortened
+	renumbered := map[int]int{}
+	for i, e := range entries {
+		hash, err := s.Repo.CommitContent(e.content, e.snap.Message, e.snap.Timestamp)
+		if err != nil {
+			return 0, nil, err
+		}
+		next++
+		if err := s.Repo.Tag(versionTag(next)); err != nil {
+			return 0, nil, err
+		}
+		rebuilt[e.snap.Hash] = shortHash(hash)
+		renumbered[replayed[i]] = next
+	}
+
+	// Notes, origins and the blessed snapshot follow the replayed
+	// snapshots; the others keep theirs
+	for _, snap := range history {
+		if _, ok := rebuilt[snap.Hash]; !ok {
+			rebuilt[snap.Hash] = snap.Hash
+		}
+	}
+	for _, name := range hashKeyedMeta {
+		if err := rekeyMeta(s.Repo, name, rebuilt); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	if err := s.resetPositions(); err != nil {
+		return 0, nil, err
+	}
+	if err := s.Repo.CheckoutHead(); err != nil {
+		return 0, nil, err
+	}
+	if err := s.recordPosition(next); err != nil {
+		return 0, nil, err
+	}
+	s.emit(events.Restored, next, "")
+	return len(added), renumbered, nil
+}
@@ -0,0 +1,106 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+func TestPullRebase(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "backup.git")
+
+	fileA, _ := setupTestFile(t, "title\none\ntwo\nthree\n")
+	a, _ := NewStore(fileA)
+	a.Initialize()
+	a.SetRemote(git.RemoteName, remote, RemoteAuth{})
+	a.Push("", git.Auth{})
+
+	fileB := filepath.Join(t.TempDir(), "test.txt")
+	b, _ := NewStore(fileB)
+	if _, err := b.Clone(remote, git.Auth{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Edited on the desktop, then on the laptop before pulling
+	os.WriteFile(fileA, []byte("Title\none\ntwo\nthree\n"), 0644)
+	a.Save("desktop")
+	a.Push("", git.Auth{})
+
+	os.WriteFile(fileB, []byte("title\none\ntwo\nTHREE\n"), 0644)
+	b.Save("laptop")
+	b.SetNote(2, "from the laptop")
+	os.WriteFile(fileB, []byte("title\none\ntwo\nTHREE\nfour\n"), 0644)
+	b.Save("laptop again")
+
+	if _, err := b.Pull("", git.Auth{}); err == nil {
+		t.Fatal("Pull of diverged histories succeeded")
+	}
+	n, renumbered, err := b.PullRebase("", git.Auth{})
+	if err != nil || n != 1 {
+		t.Fatalf("PullRebase = %d, %v; want 1 snapshot", n, err)
+	}
+	if renumbered[2] != 3 || renumbered[3] != 4 || len(renumbered) != 2 {
+		t.Errorf("renumbered = %v, want #2 as #3 and #3 as #4", renumbered)
+	}
+
+	want := map[string]string{
+		"v1": "title\none\ntwo\nthree\n",
+		"v2": "Title\none\ntwo\nthree\n",
+		"v3": "Title\none\ntwo\nTHREE\n",
+		"v4": "Title\none\ntwo\nTHREE\nfour\n",
+	}
+	for tag, content := range want {
+		if stored, _ := b.Repo.Show(tag); string(stored) != content {
+			t.Errorf("%s = %q, want %q", tag, stored, content)
+		}
+	}
+	if content, _ := os.ReadFile(fileB); string(content) != want["v4"] {
+		t.Errorf("file after rebase = %q", content)
+	}
+	if pos, _ := b.Position(); pos != 4 {
+		t.Errorf("position = %d, want 4", pos)
+	}
+	if d, err := b.Details(3); err != nil || d.Message != "laptop" || d.Note != "from the laptop" {
+		t.Errorf("replayed #3 = %+v, %v", d, err)
+	}
+
+	// The replayed snapshots can now be pushed
+	if n, err := b.Push("", git.Auth{}); err != nil || n != 2 {
+		t.Errorf("Push after rebase = %d, %v; want 2", n, err)
+	}
+}
+
+func TestPullRebaseConflict(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "backup.git")
+
+	fileA, _ := setupTestFile(t, "one\ntwo\n")
+	a, _ := NewStore(fileA)
+	a.Initialize()
+	a.SetRemote(git.RemoteName, remote, RemoteAuth{})
+	a.Push("", git.Auth{})
+
+	fileB := filepath.Join(t.TempDir(), "test.txt")
+	b, _ := NewStore(fileB)
+	b.Clone(remote, git.Auth{})
+
+	os.WriteFile(fileA, []byte("one\nTWO\n"), 0644)
+	a.Save("desktop")
+	a.Push("", git.Auth{})
+
+	os.WriteFile(fileB, []byte("one\nDos\n"), 0644)
+	b.Save("laptop")
+
+	var conflict *ReplayConflictError
+	if _, _, err := b.PullRebase("", git.Auth{}); !errors.As(err, &conflict) || len(conflict.Numbers) != 1 || conflict.Numbers[0] != 2 {
+		t.Fatalf("PullRebase = %v, want a conflict on #2", err)
+	}
+	if stored, _ := b.Repo.Show("v2"); string(stored) != "one\nDos\n" {
+		t.Errorf("v2 after refused rebase = %q", stored)
+	}
+	if content, _ := os.ReadFile(fileB); string(content) != "one\nDos\n" {
+		t.Errorf("file after refused rebase = %q", content)
+	}
+}
@@ -0,0 +1,173 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// rebuildAside is added to the name of a history while a rebuilt one
+// takes its place
+const rebuildAside = ".old"
+
+// historyEntry is one snapshot to write when rebuilding a history
+type historyEntry struct {
+	snap    Snapshot // Number, Message and Timestamp are preserved
+	content []byte
+}
+
+// rebuildHistory replaces the store's history with entries, in order.
+// Each entry is tagged with its snapshot number. The new history is built
+// next to the old one and swapped in only once complete.
+func (s *Store) rebuildHistory(entries []historyEntry) error {
+	if s.Memory {
+		return fmt.Errorf("cannot rebuild an in-memory store")
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("cannot rebuild an empty history")
+	}
+
+	tmpDir := s.GitDir + ".rebuild"
+	os.RemoveAll(tmpDir)
+	repo := s.newRepo(tmpDir)
+	if err := repo.Init(); err != nil {
+		return err
+	}
+	rebuilt := map[string]string{} // old commit hash to new, shortened
+	for _, e := range entries {
+		content, _, err := s.fitContent(e.content)
+		if err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+		hash, err := repo.CommitContent(content, e.snap.Message, e.snap.Timestamp)
+		if err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+		rebuilt[shortHash(e.snap.Hash)] = shortHash(hash)
+		if err := repo.Tag(versionTag(e.snap.Number)); err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+	}
+
+	// Keep syncing with the same remotes
+	if urls, err := s.Repo.Remotes(); err == nil {
+		for name, url := range urls {
+			if err := repo.SetRemote(name, url); err != nil {
+				os.RemoveAll(tmpDir)
+				return err
+			}
+		}
+	}
+
+	// Carry oops metadata over to the new repository, including the
+	// directories under it such as the file's profiles
+	if entries, err := os.ReadDir(s.Repo.MetaDir()); err == nil {
+		for _, entry := range entries {
+			if entry.IsDir() {
+				src := filepath.Join(s.Repo.MetaDir(), entry.Name())
+				if err := copyTreeSkipping(src, filepath.Join(repo.MetaDir(), entry.Name()), ""); err != nil {
+					os.RemoveAll(tmpDir)
+					return err
+				}
+				continue
+			}
+			data, err := s.Repo.ReadMeta(entry.Name())
+			if err != nil {
+				continue
+			}
+			if err := repo.WriteMeta(entry.Name(), data); err != nil {
+				os.RemoveAll(tmpDir)
+				return err
+			}
+		}
+	}
+
+	// Notes, origins and the blessed snapshot follow their snapshots to
+	// the new commits
+	for _, name := range hashKeyedMeta {
+		if err := rekeyMeta(repo, name, rebuilt); err != nil {
+			os.RemoveAll(tmpDir)
+			return err
+		}
+	}
+
+	if err := swapHistory(s.GitDir, tmpDir); err != nil {
+		os.RemoveAll(tmpDir)
+		return err
+	}
+	s.Repo = s.newRepo(s.GitDir)
+	return nil
+}
+
+// swapHistory replaces the history in gitDir with the one built in
+// tmpDir. The old history is renamed aside first and removed only once the
+// new one is in place, so at every moment one of them is complete on disk;
+// recoverRebuild finishes or undoes a swap that was interrupted. The store
+// lock is kept beside gitDir, so it stays put while the directories move.
+func swapHistory(gitDir, tmpDir string) error {
+	old := gitDir + rebuildAside
+	os.RemoveAll(old)
+	if err := os.Rename(gitDir, old); err != nil {
+		return err
+	}
+	err := os.Rename(tmpDir, gitDir)
+	if err != nil && !git.NewRepo(gitDir, "", "").Exists() {
+		// A process writing metadata without the lock, such as a
+		// deferred save, recreated the directory in between
+		if err = adoptStray(gitDir, tmpDir); err == nil {
+			err = os.Rename(tmpDir, gitDir)
+		}
+	}
+	if err != nil {
+		recoverRebuild(gitDir)
+		return err
+	}
+	os.RemoveAll(old)
+	return nil
+}
+
+// recoverRebuild cleans up after a rebuild of the history in gitDir that
+// was interrupted while swapping: the old history is put back if the new
+// one never made it into place, and removed if it did.
+func recoverRebuild(gitDir string) {
+	old := gitDir + rebuildAside
+	if _, err := os.Stat(old); err != nil {
+		return
+	}
+	if !git.NewRepo(gitDir, "", "").Exists() {
+		if adoptStray(gitDir, old) != nil || os.Rename(old, gitDir) != nil {
+			return
+		}
+	}
+	os.RemoveAll(old)
+}
+
+// adoptStray moves the metadata written into dir while it held no history
+// into the history in into, keeping what into already has, and removes
+// dir. It does nothing if dir does not exist.
+func adoptStray(dir, into string) error {
+	stray := git.NewRepo(dir, "", "").MetaDir()
+	entries, err := os.ReadDir(stray)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	meta := git.NewRepo(into, "", "").MetaDir()
+	if err := os.MkdirAll(meta, 0755); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		target := filepath.Join(meta, entry.Name())
+		if _, err := os.Lstat(target); err == nil {
+			continue
+		}
+		if err := os.Rename(filepath.Join(stray, entry.Name()), target); err != nil {
+			return err
+		}
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,83 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRecoverInterruptedRebuild(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("")
+
+	// Interrupted after the old history was renamed aside, with a
+	// deferred save written where it was meanwhile
+	if err := os.Rename(s.GitDir, s.GitDir+rebuildAside); err != nil {
+		t.Fatal(err)
+	}
+	os.MkdirAll(s.Repo.MetaDir(), 0755)
+	os.WriteFile(filepath.Join(s.Repo.MetaDir(), pendingData("1")), []byte("v3"), 0644)
+
+	reopened, err := NewStore(testFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if latest, _ := reopened.GetLatestVersion(); latest != 2 {
+		t.Errorf("latest after recovery = %d, want 2", latest)
+	}
+	if _, err := os.Stat(s.GitDir + rebuildAside); !os.IsNotExist(err) {
+		t.Error("old history left aside after recovery")
+	}
+	if data, err := reopened.Repo.ReadMeta(pendingData("1")); string(data) != "v3" {
+		t.Errorf("deferred save after recovery = %q, %v; want it kept", data, err)
+	}
+
+	// Interrupted after the new history was in place
+	os.MkdirAll(filepath.Join(s.GitDir+rebuildAside, "objects"), 0755)
+	reopened, _ = NewStore(testFile)
+	if _, err := os.Stat(s.GitDir + rebuildAside); !os.IsNotExist(err) {
+		t.Error("old history not removed once the swap was done")
+	}
+	if latest, _ := reopened.GetLatestVersion(); latest != 2 {
+		t.Errorf("latest = %d, want 2", latest)
+	}
+}
+
+func TestRebuildKeepsStoreLock(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2", "v3"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save("")
+	}
+
+	// The lock held across the rebuild stays where waiters look for it
+	unlock, err := s.lock()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Drop(2); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(s.lockPath()); err != nil {
+		t.Fatalf("lock gone after the rebuild: %v", err)
+	}
+	other, _ := NewStore(testFile)
+	var locked *LockedError
+	if _, err := other.Save("meanwhile"); !errors.As(err, &locked) {
+		t.Errorf("Save during the lock = %v, want LockedError", err)
+	}
+	unlock()
+	if _, err := os.Stat(s.lockPath()); !os.IsNotExist(err) {
+		t.Error("lock left behind after release")
+	}
+}
@@ -0,0 +1,111 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+
+	"github.com/go-git/go-billy/v5/util"
+	"github.com/iyulab/oops/internal/region"
+)
+
+// regionMeta is the metadata file holding the markers of a file tracked
+// by region
+const regionMeta = "region.json"
+
+// ErrRegionNotFound is returned when saving a file tracked by region whose
+// markers are missing
+var ErrRegionNotFound = errors.New("region markers not found in the file")
+
+// Region returns the markers delimiting the tracked part of the file, or
+// nil if the whole file is tracked
+func (s *Store) Region() *region.Markers {
+	return s.regionMarkers
+}
+
+// SetRegion tracks only the lines between markers instead of the whole
+// file; nil tracks the whole file. Snapshots hold just the region, and
+// restoring splices it back into the current file, leaving the rest of it
+// as it is.
+func (s *Store) SetRegion(m *region.Markers) error {
+	if m != nil {
+		if err := m.Validate(); err != nil {
+			return err
+		}
+	}
+	s.regionMarkers = m
+	s.applyFilters(s.Repo)
+	if !s.Exists() {
+		return nil
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	return s.saveRegion()
+}
+
+func (s *Store) saveRegion() error {
+	if s.regionMarkers == nil {
+		return s.Repo.RemoveMeta(regionMeta)
+	}
+	data, err := json.Marshal(s.regionMarkers)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(regionMeta, data)
+}
+
+// loadRegion reads the region markers recorded for an existing store
+func (s *Store) loadRegion() {
+	data, err := s.Repo.ReadMeta(regionMeta)
+	if err != nil {
+		return
+	}
+	var m region.Markers
+	if json.Unmarshal(data, &m) == nil && m.Validate() == nil {
+		s.regionMarkers = &m
+		s.applyFilters(s.Repo)
+	}
+}
+
+// readRawWorkFile reads the work file without clean filters
+func (s *Store) readRawWorkFile() ([]byte, error) {
+	return util.ReadFile(s.Repo.WorkFS(), s.FileName)
+}
+
+// checkRegion returns ErrRegionNotFound if the file is tracked by region
+// and its markers are missing, so a snapshot would not hold the region
+func (s *Store) checkRegion() error {
+	if s.regionMarkers == nil {
+		return nil
+	}
+	content, err := s.readRawWorkFile()
+	if err != nil {
+		return err
+	}
+	if _, ok := s.regionMarkers.Extract(content); !ok {
+		return ErrRegionNotFound
+	}
+	return nil
+}
+
+// extractRegion is the clean filter for files tracked by region. Content
+// without markers is passed through; saves refuse it before it is stored.
+func (s *Store) extractRegion(content []byte) []byte {
+	if r, ok := s.regionMarkers.Extract(content); ok {
+		return r
+	}
+	return content
+}
+
+// spliceRegion is the smudge filter for files tracked by region: it puts
+// a stored region back into the current work file
+func (s *Store) spliceRegion(r []byte) []byte {
+	current, err := s.readRawWorkFile()
+	if err != nil && !os.IsNotExist(err) {
+		current = nil
+	}
+	return s.regionMarkers.Splice(current, r)
+}
@@ -0,0 +1,56 @@
+package store
+
+import (
+	"os"
+	"testing"
+
+	"github.com/iyulab/oops/internal/region"
+)
+
+func TestRegionTracking(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "managed=1\n# oops:start\nmine=a\n# oops:end\ntail=1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	if err := s.SetRegion(&region.Default); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if stored, _ := s.Repo.Show("v1"); string(stored) != "mine=a\n" {
+		t.Fatalf("v1 = %q, want only the region", stored)
+	}
+
+	// Changes outside the region are not changes
+	os.WriteFile(testFile, []byte("managed=2\n# oops:start\nmine=a\n# oops:end\ntail=2\n"), 0644)
+	if _, err := s.Save(""); err != ErrNoChanges {
+		t.Errorf("Save after outside change = %v, want ErrNoChanges", err)
+	}
+
+	os.WriteFile(testFile, []byte("managed=2\n# oops:start\nmine=b\n# oops:end\ntail=2\n"), 0644)
+	if _, err := s.Save("edit region"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Restoring splices the region into the current file
+	os.WriteFile(testFile, []byte("managed=3\n# oops:start\nmine=b\n# oops:end\ntail=3\n"), 0644)
+	if err := s.Back(1, true); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := os.ReadFile(testFile)
+	if want := "managed=3\n# oops:start\nmine=a\n# oops:end\ntail=3\n"; string(content) != want {
+		t.Errorf("after Back = %q, want %q", content, want)
+	}
+
+	// The region is remembered when the store is opened again
+	reopened, _ := NewStore(testFile)
+	if m := reopened.Region(); m == nil || *m != region.Default {
+		t.Fatalf("Region after reopen = %v", m)
+	}
+
+	os.WriteFile(testFile, []byte("markers removed\n"), 0644)
+	if _, err := reopened.Save(""); err != ErrRegionNotFound {
+		t.Errorf("Save without markers = %v, want ErrRegionNotFound", err)
+	}
+}
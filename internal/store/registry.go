@@ -0,0 +1,111 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// localRegistryName is the file in the global oops directory listing the
+// local tracked files, so background jobs can find them. Global stores
+// are found through their metadata instead.
+const localRegistryName = "tracked"
+
+// TrackedFile is a tracked file found by ListTrackedFiles
+type TrackedFile struct {
+	FilePath string
+	Global   bool
+}
+
+func localRegistryPath() (string, error) {
+	dir, err := GetGlobalOopsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, localRegistryName), nil
+}
+
+func readLocalRegistry() ([]string, error) {
+	path, err := localRegistryPath()
+	if err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	var paths []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if line = strings.TrimRight(line, "\r"); line != "" {
+			paths = append(paths, line)
+		}
+	}
+	return paths, nil
+}
+
+func writeLocalRegistry(paths []string) error {
+	path, err := localRegistryPath()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	content := strings.Join(paths, "\n")
+	if len(paths) > 0 {
+		content += "\n"
+	}
+	return os.WriteFile(path, []byte(content), 0644)
+}
+
+// RegisterLocal records a locally tracked file so ListTrackedFiles finds
+// it. Global and in-memory stores need no registration.
+func (s *Store) RegisterLocal() error {
+	if s.Global || s.Memory {
+		return nil
+	}
+	paths, err := readLocalRegistry()
+	if err != nil {
+		return err
+	}
+	if slices.Contains(paths, s.FilePath) {
+		return nil
+	}
+	return writeLocalRegistry(append(paths, s.FilePath))
+}
+
+// ListTrackedFiles returns every global tracked file and the registered
+// local ones that are still tracked. Local files that are no longer
+// tracked are dropped from the registry.
+func ListTrackedFiles() ([]TrackedFile, error) {
+	globals, err := ListGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+	var files []TrackedFile
+	for _, g := range globals {
+		files = append(files, TrackedFile{FilePath: g.FilePath, Global: true})
+	}
+
+	paths, err := readLocalRegistry()
+	if err != nil {
+		return nil, err
+	}
+	var kept []string
+	for _, path := range paths {
+		s, err := NewStore(path)
+		if err != nil || !s.Exists() {
+			continue
+		}
+		kept = append(kept, path)
+		files = append(files, TrackedFile{FilePath: path})
+	}
+	if len(kept) != len(paths) {
+		writeLocalRegistry(kept)
+	}
+	return files, nil
+}
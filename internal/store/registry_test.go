@@ -0,0 +1,42 @@
+package store
+
+import (
+	"testing"
+)
+
+func TestListTrackedFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	localFile, _ := setupTestFile(t, "local")
+	local, _ := NewStore(localFile)
+	local.Initialize()
+	if err := local.RegisterLocal(); err != nil {
+		t.Fatal(err)
+	}
+	local.RegisterLocal() // Registering twice keeps one entry
+
+	globalFile, _ := setupTestFile(t, "global")
+	global, _ := NewGlobalStore(globalFile)
+	global.Initialize()
+	global.RegisterLocal() // No effect for global stores
+
+	files, err := ListTrackedFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 2 {
+		t.Fatalf("ListTrackedFiles = %+v, want the local and the global file", files)
+	}
+
+	// Untracked local files are dropped
+	local.Delete()
+	files, _ = ListTrackedFiles()
+	if len(files) != 1 || !files[0].Global {
+		t.Errorf("after Delete, ListTrackedFiles = %+v, want only the global file", files)
+	}
+	if paths, _ := readLocalRegistry(); len(paths) != 0 {
+		t.Errorf("registry = %v, want empty", paths)
+	}
+}
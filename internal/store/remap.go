@@ -0,0 +1,124 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// RemapEntry describes one global store affected by a prefix remap
+type RemapEntry struct {
+	OldPath string
+	NewPath string
+	Err     error // non-nil if this store was not moved
+}
+
+// RemapGlobalStores moves every global store whose file lies under oldPrefix
+// so that it follows the file to newPrefix. Metadata is rewritten and the
+// hash directory renamed to match the new path. With dryRun, nothing is
+// changed and the planned moves are returned.
+func RemapGlobalStores(oldPrefix, newPrefix string, dryRun bool) ([]RemapEntry, error) {
+	oldAbs, err := filepath.Abs(oldPrefix)
+	if err != nil {
+		return nil, err
+	}
+	newAbs, err := filepath.Abs(newPrefix)
+	if err != nil {
+		return nil, err
+	}
+	if oldAbs == newAbs {
+		return nil, fmt.Errorf("old and new prefix are the same")
+	}
+
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return nil, err
+	}
+	stores, err := ListGlobalStores()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []RemapEntry
+	for _, info := range stores {
+		newPath := remapPathPrefix(info.FilePath, oldAbs, newAbs)
+		if newPath == info.FilePath {
+			continue
+		}
+
+		entry := RemapEntry{OldPath: info.FilePath, NewPath: newPath}
+		entry.Err = remapGlobalStore(globalDir, info, newPath, dryRun)
+		entries = append(entries, entry)
+	}
+
+	return entries, nil
+}
+
+// remapGlobalStore moves a single global store to follow newPath
+func remapGlobalStore(globalDir string, info GlobalStoreInfo, newPath string, dryRun bool) error {
+	if filepath.Base(newPath) != info.FileName {
+		return fmt.Errorf("file name would change to %s", filepath.Base(newPath))
+	}
+	if err := checkGlobalTarget(globalDir, info, newPath); err != nil {
+		return err
+	}
+	if dryRun {
+		return nil
+	}
+	return relocateGlobalStore(globalDir, info, newPath)
+}
+
+// checkGlobalTarget checks that the global store in info can move to
+// newPath without taking over another file's history
+func checkGlobalTarget(globalDir string, info GlobalStoreInfo, newPath string) error {
+	if err := validateMetadataPath(newPath); err != nil {
+		return err
+	}
+	index := loadPathIndex(globalDir)
+	if key, ok := index[normalizePath(newPath)]; ok && key != info.HashDir {
+		return fmt.Errorf("%s is already tracked globally", newPath)
+	}
+	target := filepath.Join(globalDir, globalKey(newPath))
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("%s is already tracked globally", newPath)
+	}
+	return nil
+}
+
+// relocateGlobalStore points the global store in info at newPath,
+// renaming its history when the file name changes. Metadata is rewritten
+// and the hash directory renamed to match the new path.
+func relocateGlobalStore(globalDir string, info GlobalStoreInfo, newPath string) error {
+	source := filepath.Join(globalDir, info.HashDir)
+	if newName := filepath.Base(newPath); newName != info.FileName {
+		if err := os.Rename(filepath.Join(source, info.FileName+".git"), filepath.Join(source, newName+".git")); err != nil {
+			return err
+		}
+	}
+
+	// Indexed stores stay where they are; only the index changes
+	index := loadPathIndex(globalDir)
+	if index[normalizePath(info.FilePath)] == info.HashDir {
+		if err := os.WriteFile(filepath.Join(source, "metadata.txt"), []byte(newPath), 0644); err != nil {
+			return err
+		}
+		if err := writeVolume(source, newPath); err != nil {
+			return err
+		}
+		return updatePathIndex(globalDir, func(index map[string]string) {
+			delete(index, normalizePath(info.FilePath))
+			index[normalizePath(newPath)] = info.HashDir
+		})
+	}
+
+	target := filepath.Join(globalDir, globalKey(newPath))
+	if target != source {
+		if err := os.Rename(source, target); err != nil {
+			return err
+		}
+	}
+	if err := os.WriteFile(filepath.Join(target, "metadata.txt"), []byte(newPath), 0644); err != nil {
+		return err
+	}
+	return writeVolume(target, newPath)
+}
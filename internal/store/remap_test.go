@@ -0,0 +1,58 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRemapGlobalStores(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	oldDir := filepath.Join(home, "Documents")
+	newDir := filepath.Join(home, "Archive", "Documents")
+	os.MkdirAll(oldDir, 0755)
+	testFile := filepath.Join(oldDir, "notes.txt")
+	os.WriteFile(testFile, []byte("v1"), 0644)
+
+	s, _ := NewGlobalStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	// Dry run changes nothing
+	entries, err := RemapGlobalStores(oldDir, newDir, true)
+	if err != nil {
+		t.Fatalf("RemapGlobalStores failed: %v", err)
+	}
+	if len(entries) != 1 || entries[0].Err != nil {
+		t.Fatalf("entries = %+v, want one successful entry", entries)
+	}
+	if !s.Exists() {
+		t.Error("Dry run should not move the store")
+	}
+
+	// Move the file and remap
+	os.MkdirAll(filepath.Dir(newDir), 0755)
+	os.Rename(oldDir, newDir)
+	entries, err = RemapGlobalStores(oldDir, newDir, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Err != nil {
+		t.Fatalf("entries = %+v, want one successful entry", entries)
+	}
+
+	moved, _ := NewGlobalStore(filepath.Join(newDir, "notes.txt"))
+	if !moved.Exists() {
+		t.Fatal("Store should exist at the new path")
+	}
+	if _, _, hasChanges, err := moved.Now(); err != nil || hasChanges {
+		t.Errorf("Now() hasChanges=%v err=%v, want clean", hasChanges, err)
+	}
+	if s.Exists() {
+		t.Error("Store should no longer exist at the old path")
+	}
+}
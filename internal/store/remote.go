@@ -0,0 +1,458 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"sort"
+	"strings"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/events"
+	"github.com/iyulab/oops/internal/git"
+)
+
+var (
+	ErrNoRemote        = errors.New("no remote set")
+	ErrRemoteNotNamed  = errors.New("there are several remotes and none is named origin")
+	ErrRemoteAhead     = errors.New("the remote has snapshots that are not here yet")
+	ErrHistoryDiverged = errors.New("this copy and the remote both have snapshots the other lacks")
+)
+
+// UnknownRemoteError reports a remote name the store does not have
+type UnknownRemoteError struct {
+	Name string
+}
+
+func (e *UnknownRemoteError) Error() string {
+	return fmt.Sprintf("no remote named %s", e.Name)
+}
+
+// TagConflictError reports snapshot numbers that name different snapshots
+// here and on the remote
+type TagConflictError struct {
+	Numbers []int
+}
+
+func (e *TagConflictError) Error() string {
+	verb := "names"
+	if len(e.Numbers) > 1 {
+		verb = "name"
+	}
+	return fmt.Sprintf("%s %s different snapshots here and on the remote", tagList(e.Numbers), verb)
+}
+
+// remotesMeta is the metadata file holding how credentials are found for
+// each named remote. Tokens themselves are never stored. Token commands
+// are not stored here either but in the user's config under the file's
+// path, as validate hooks are (see SetValidateHook), so that a history
+// copied from elsewhere cannot run one.
+const remotesMeta = "remotes.json"
+
+// RemoteAuth says where the credentials of a remote come from. Fields
+// left empty fall back to the credentials passed to Push and Pull.
+type RemoteAuth struct {
+	Username string `json:"username,omitempty"`  // HTTPS user name
+	TokenEnv string `json:"token_env,omitempty"` // environment variable holding the token
+	TokenCmd string `json:"-"`                   // command printing the token, e.g. a keyring lookup
+	SSHKey   string `json:"ssh_key,omitempty"`   // path to a private key
+}
+
+// IsZero reports whether a has no credential sources
+func (a RemoteAuth) IsZero() bool {
+	return a == RemoteAuth{}
+}
+
+// Apply fills auth from a's sources, which take precedence. The token
+// command is split on spaces and run without a shell.
+func (a RemoteAuth) Apply(auth git.Auth) (git.Auth, error) {
+	if a.Username != "" {
+		auth.Username = a.Username
+	}
+	if a.SSHKey != "" {
+		auth.SSHKey = a.SSHKey
+	}
+	if a.TokenEnv != "" {
+		if token := os.Getenv(a.TokenEnv); token != "" {
+			auth.Token = token
+		}
+	}
+	if args := strings.Fields(a.TokenCmd); len(args) > 0 {
+		out, err := exec.Command(args[0], args[1:]...).Output()
+		if err != nil {
+			return auth, fmt.Errorf("token command %q failed: %w", a.TokenCmd, err)
+		}
+		auth.Token = strings.TrimSpace(string(out))
+	}
+	return auth, nil
+}
+
+// Remote is a named repository the history is synced with
+type Remote struct {
+	Name string
+	URL  string
+	Auth RemoteAuth
+}
+
+func (s *Store) loadRemoteAuths() map[string]RemoteAuth {
+	auths := map[string]RemoteAuth{}
+	if data, err := s.Repo.ReadMeta(remotesMeta); err == nil {
+		json.Unmarshal(data, &auths)
+	}
+	if cfg, err := config.Load(); err == nil {
+		for name, command := range cfg.TokenCmds[normalizePath(s.FilePath)] {
+			a := auths[name]
+			a.TokenCmd = command
+			auths[name] = a
+		}
+	}
+	return auths
+}
+
+func (s *Store) saveRemoteAuths(auths map[string]RemoteAuth) error {
+	cmds := map[string]string{}
+	for name, a := range auths {
+		if a.TokenCmd != "" {
+			cmds[name] = a.TokenCmd
+		}
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	if path := normalizePath(s.FilePath); !maps.Equal(cfg.TokenCmds[path], cmds) {
+		cfg.SetTokenCmds(path, cmds)
+		if err := cfg.Save(); err != nil {
+			return err
+		}
+	}
+
+	stored := map[string]RemoteAuth{}
+	for name, a := range auths {
+		if a.TokenCmd = ""; !a.IsZero() {
+			stored[name] = a
+		}
+	}
+	if len(stored) == 0 {
+		return s.Repo.RemoveMeta(remotesMeta)
+	}
+	data, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(remotesMeta, data)
+}
+
+// SetRemote sets remote name to a Git repository the history is pushed to
+// and pulled from, e.g. a GitHub URL or a path to a bare repository, with
+// where its credentials come from
+func (s *Store) SetRemote(name, url string, auth RemoteAuth) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	if s.Memory {
+		return fmt.Errorf("in-memory stores cannot have a remote")
+	}
+	if err := ValidateRemoteName(name); err != nil {
+		return err
+	}
+	if err := s.Repo.SetRemote(name, url); err != nil {
+		return err
+	}
+	auths := s.loadRemoteAuths()
+	if auth.IsZero() {
+		delete(auths, name)
+	} else {
+		auths[name] = auth
+	}
+	return s.saveRemoteAuths(auths)
+}
+
+// ValidateRemoteName checks that name can name a remote
+func ValidateRemoteName(name string) error {
+	if name == "" || strings.ContainsAny(name, "/\\:*?[ ~^") || strings.HasPrefix(name, "-") || strings.HasPrefix(name, ".") {
+		return fmt.Errorf("invalid remote name %q", name)
+	}
+	return nil
+}
+
+// Remotes returns the remotes of the store, sorted by name
+func (s *Store) Remotes() ([]Remote, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if s.Memory {
+		return nil, nil
+	}
+	urls, err := s.Repo.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	auths := s.loadRemoteAuths()
+	var remotes []Remote
+	for name, url := range urls {
+		remotes = append(remotes, Remote{Name: name, URL: url, Auth: auths[name]})
+	}
+	sort.Slice(remotes, func(i, j int) bool { return remotes[i].Name < remotes[j].Name })
+	return remotes, nil
+}
+
+// ResolveRemote returns the remote meant by name. An empty name is the
+// only remote, or "origin" among several.
+func (s *Store) ResolveRemote(name string) (*Remote, error) {
+	remotes, err := s.Remotes()
+	if err != nil {
+		return nil, err
+	}
+	if name == "" {
+		switch len(remotes) {
+		case 0:
+			return nil, ErrNoRemote
+		case 1:
+			return &remotes[0], nil
+		}
+		name = git.RemoteName
+	}
+	for i := range remotes {
+		if remotes[i].Name == name {
+			return &remotes[i], nil
+		}
+	}
+	if len(remotes) > 0 && name == git.RemoteName {
+		return nil, ErrRemoteNotNamed
+	}
+	return nil, &UnknownRemoteError{Name: name}
+}
+
+// RemoveRemote stops syncing with remote name ("" as for ResolveRemote).
+// The remote keeps its copy.
+func (s *Store) RemoveRemote(name string) error {
+	remote, err := s.ResolveRemote(name)
+	if err != nil {
+		return err
+	}
+	if err := s.Repo.RemoveRemote(remote.Name); err != nil {
+		return err
+	}
+	if settings := s.SyncSettings(); settings.AutoPush && settings.Remote == remote.Name {
+		if err := s.SetSyncSettings(SyncSettings{}); err != nil {
+			return err
+		}
+	}
+	auths := s.loadRemoteAuths()
+	delete(auths, remote.Name)
+	return s.saveRemoteAuths(auths)
+}
+
+// fetchRemote downloads the history of remote name and checks that every
+// snapshot number both sides have names the same snapshot
+func (s *Store) fetchRemote(name string, auth git.Auth) (*git.RemoteState, map[string]string, error) {
+	target, err := s.ResolveRemote(name)
+	if err != nil {
+		return nil, nil, err
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return nil, nil, err
+	}
+
+	remote, err := s.Repo.Fetch(target.Name, auth)
+	if err != nil {
+		return nil, nil, err
+	}
+	local, err := s.Repo.VersionTags()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var conflicts []int
+	for name, hash := range remote.Tags {
+		if mine, ok := local[name]; ok && mine != hash {
+			num, _ := git.ParseVersionTag(name)
+			conflicts = append(conflicts, num)
+		}
+	}
+	if len(conflicts) > 0 {
+		sort.Ints(conflicts)
+		return nil, nil, &TagConflictError{Numbers: conflicts}
+	}
+	return remote, local, nil
+}
+
+// Push uploads snapshots remote name ("" as for ResolveRemote) does not
+// have yet. It refuses when the remote has snapshots of its own, which
+// must be pulled first. Returns the number of snapshots uploaded.
+func (s *Store) Push(name string, auth git.Auth) (int, error) {
+	target, err := s.ResolveRemote(name)
+	if err != nil {
+		return 0, err
+	}
+	remote, local, err := s.fetchRemote(target.Name, auth)
+	if err != nil {
+		return 0, err
+	}
+
+	if remote.Head != "" {
+		head, err := s.Repo.HeadCommit()
+		if err != nil {
+			return 0, err
+		}
+		if err := s.checkDescends(head, remote.Head); err != nil {
+			return 0, err
+		}
+	}
+
+	if err := s.Repo.Push(target.Name, auth); err != nil {
+		return 0, err
+	}
+	pushed := 0
+	for name := range local {
+		if _, ok := remote.Tags[name]; !ok {
+			pushed++
+		}
+	}
+	return pushed, nil
+}
+
+// checkDescends returns nil if commit descends from base, and otherwise
+// whether base is ahead or the two have diverged
+func (s *Store) checkDescends(commit, base string) error {
+	ok, err := s.Repo.IsAncestor(base, commit)
+	if err != nil || ok {
+		return err
+	}
+	behind, err := s.Repo.IsAncestor(commit, base)
+	if err != nil {
+		return err
+	}
+	if behind {
+		return ErrRemoteAhead
+	}
+	return ErrHistoryDiverged
+}
+
+// Pull downloads snapshots saved elsewhere from remote name ("" as for
+// ResolveRemote) and restores the latest one. Unsaved changes are
+// protected as by Back. It refuses when this copy has snapshots the remote
+// lacks as well. Returns the number of snapshots downloaded.
+func (s *Store) Pull(name string, auth git.Auth) (int, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	remote, local, err := s.fetchRemote(name, auth)
+	if err != nil {
+		return 0, err
+	}
+	if remote.Head == "" {
+		return 0, nil
+	}
+
+	head, err := s.Repo.HeadCommit()
+	if err != nil {
+		return 0, err
+	}
+	if ok, err := s.Repo.IsAncestor(remote.Head, head); err != nil || ok {
+		return 0, err // nothing new on the remote
+	}
+	if ok, err := s.Repo.IsAncestor(head, remote.Head); err != nil {
+		return 0, err
+	} else if !ok {
+		return 0, ErrHistoryDiverged
+	}
+
+	position, err := s.Position()
+	if err != nil {
+		return 0, err
+	}
+	if changed, err := s.changedSince(position); err != nil {
+		return 0, err
+	} else if changed {
+		return 0, ErrUncommittedChanges
+	}
+
+	added := map[string]string{}
+	for name, hash := range remote.Tags {
+		if _, ok := local[name]; !ok {
+			added[name] = hash
+		}
+	}
+	if err := s.Repo.FastForward(remote.Head, added); err != nil {
+		return 0, err
+	}
+
+	if err := s.Repo.CheckoutHead(); err != nil {
+		return 0, err
+	}
+	latest, err := s.GetLatestVersion()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.recordPosition(latest); err != nil {
+		return 0, err
+	}
+	s.emit(events.Restored, latest, "")
+	return len(added), nil
+}
+
+// Clone starts tracking the file from the history at url, restoring its
+// latest snapshot. The file must not exist yet. Returns the number of
+// snapshots downloaded.
+func (s *Store) Clone(url string, auth git.Auth) (int, error) {
+	if s.Exists() {
+		return 0, ErrAlreadyTracked
+	}
+	if s.Memory {
+		return 0, fmt.Errorf("in-memory stores cannot have a remote")
+	}
+	if s.Repo.WorkFileExists() {
+		return 0, fmt.Errorf("%s already exists", s.FileName)
+	}
+
+	if err := os.MkdirAll(s.OopsDirPath(), 0755); err != nil {
+		return 0, err
+	}
+	if err := s.saveMetadata(); err != nil {
+		return 0, err
+	}
+	if err := s.Repo.Init(); err != nil {
+		return 0, err
+	}
+	n, err := s.cloneFrom(url, auth)
+	if err != nil {
+		os.RemoveAll(s.GitDir)
+		return 0, err
+	}
+	return n, nil
+}
+
+func (s *Store) cloneFrom(url string, auth git.Auth) (int, error) {
+	if err := s.Repo.SetRemote(git.RemoteName, url); err != nil {
+		return 0, err
+	}
+	remote, err := s.Repo.Fetch(git.RemoteName, auth)
+	if err != nil {
+		return 0, err
+	}
+	if remote.Head == "" {
+		return 0, fmt.Errorf("the remote has no snapshots of %s", s.FileName)
+	}
+	if err := s.Repo.FastForward(remote.Head, remote.Tags); err != nil {
+		return 0, err
+	}
+	if err := s.Repo.CheckoutHead(); err != nil {
+		return 0, err
+	}
+	latest, err := s.GetLatestVersion()
+	if err != nil {
+		return 0, err
+	}
+	if err := s.recordPosition(latest); err != nil {
+		return 0, err
+	}
+	s.emit(events.Restored, latest, "")
+	return len(remote.Tags), nil
+}
@@ -0,0 +1,247 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/git"
+)
+
+func TestRemoteSync(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "backup.git")
+
+	fileA, _ := setupTestFile(t, "one\n")
+	a, _ := NewStore(fileA)
+	if err := a.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := a.Push("", git.Auth{}); err != ErrNoRemote {
+		t.Fatalf("Push without remote = %v, want ErrNoRemote", err)
+	}
+	if err := a.SetRemote(git.RemoteName, remote, RemoteAuth{}); err != nil {
+		t.Fatal(err)
+	}
+	os.WriteFile(fileA, []byte("two\n"), 0644)
+	a.Save("two")
+
+	// The bare repository is created on the first push
+	if n, err := a.Push("", git.Auth{}); err != nil || n != 2 {
+		t.Fatalf("Push = %d, %v; want 2 snapshots", n, err)
+	}
+	if n, err := a.Push("", git.Auth{}); err != nil || n != 0 {
+		t.Fatalf("second Push = %d, %v; want nothing to push", n, err)
+	}
+
+	// Another machine starts from the remote
+	fileB := filepath.Join(t.TempDir(), "test.txt")
+	b, _ := NewStore(fileB)
+	if n, err := b.Clone(remote, git.Auth{}); err != nil || n != 2 {
+		t.Fatalf("Clone = %d, %v", n, err)
+	}
+	if content, _ := os.ReadFile(fileB); string(content) != "two\n" {
+		t.Errorf("cloned file = %q", content)
+	}
+	if pos, _ := b.Position(); pos != 2 {
+		t.Errorf("cloned position = %d, want 2", pos)
+	}
+
+	// Snapshots saved on one side arrive on the other
+	os.WriteFile(fileB, []byte("three\n"), 0644)
+	if snap, err := b.Save("three"); err != nil || snap.Number != 3 {
+		t.Fatalf("Save on clone = %v, %v", snap, err)
+	}
+	if _, err := b.Push("", git.Auth{}); err != nil {
+		t.Fatal(err)
+	}
+	if n, err := a.Pull("", git.Auth{}); err != nil || n != 1 {
+		t.Fatalf("Pull = %d, %v; want 1", n, err)
+	}
+	if content, _ := os.ReadFile(fileA); string(content) != "three\n" {
+		t.Errorf("pulled file = %q", content)
+	}
+	if stored, _ := a.Repo.Show("v3"); string(stored) != "three\n" {
+		t.Errorf("pulled v3 = %q", stored)
+	}
+
+	// Saving after a pull continues the shared numbering
+	os.WriteFile(fileA, []byte("four\n"), 0644)
+	if snap, err := a.Save("four"); err != nil || snap.Number != 4 {
+		t.Fatalf("Save after pull = %v, %v", snap, err)
+	}
+
+	// Both sides saving snapshot #4 is a conflict
+	os.WriteFile(fileB, []byte("four on b\n"), 0644)
+	b.Save("four on b")
+	if _, err := b.Push("", git.Auth{}); err != nil {
+		t.Fatal(err)
+	}
+	var conflict *TagConflictError
+	if _, err := a.Push("", git.Auth{}); !errors.As(err, &conflict) || len(conflict.Numbers) != 1 || conflict.Numbers[0] != 4 {
+		t.Fatalf("Push after both saved = %v, want a conflict on #4", err)
+	}
+	if _, err := a.Pull("", git.Auth{}); !errors.As(err, &conflict) {
+		t.Fatalf("Pull after both saved = %v, want a conflict", err)
+	}
+}
+
+func TestRemotePullProtectsChanges(t *testing.T) {
+	remote := filepath.Join(t.TempDir(), "backup.git")
+
+	fileA, _ := setupTestFile(t, "one\n")
+	a, _ := NewStore(fileA)
+	a.Initialize()
+	a.SetRemote(git.RemoteName, remote, RemoteAuth{})
+	a.Push("", git.Auth{})
+
+	fileB := filepath.Join(t.TempDir(), "test.txt")
+	b, _ := NewStore(fileB)
+	if _, err := b.Clone(remote, git.Auth{}); err != nil {
+		t.Fatal(err)
+	}
+
+	// A pull is refused while the remote is behind on the pusher's side
+	os.WriteFile(fileA, []byte("two\n"), 0644)
+	a.Save("two")
+	a.Push("", git.Auth{})
+
+	os.WriteFile(fileB, []byte("unsaved\n"), 0644)
+	if _, err := b.Pull("", git.Auth{}); err != ErrUncommittedChanges {
+		t.Fatalf("Pull with unsaved changes = %v, want ErrUncommittedChanges", err)
+	}
+
+	// A copy with its own new snapshot cannot push until it pulls
+	os.WriteFile(fileB, []byte("one\n"), 0644)
+	b2, _ := NewStore(fileB)
+	if _, err := b2.Push("", git.Auth{}); err != ErrRemoteAhead {
+		t.Fatalf("Push when behind = %v, want ErrRemoteAhead", err)
+	}
+
+	if err := b2.RemoveRemote(""); err != nil {
+		t.Fatal(err)
+	}
+	if remotes, _ := b2.Remotes(); len(remotes) != 0 {
+		t.Errorf("Remotes after remove = %v", remotes)
+	}
+}
+
+func TestNamedRemotes(t *testing.T) {
+	backup := filepath.Join(t.TempDir(), "backup.git")
+	laptop := filepath.Join(t.TempDir(), "laptop.git")
+
+	file, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(file)
+	s.Initialize()
+	if err := s.SetRemote("backup", backup, RemoteAuth{TokenEnv: "BACKUP_TOKEN"}); err != nil {
+		t.Fatal(err)
+	}
+
+	// With one remote, it is the default
+	if n, err := s.Push("", git.Auth{}); err != nil || n != 1 {
+		t.Fatalf("Push to the only remote = %d, %v", n, err)
+	}
+
+	if err := s.SetRemote("laptop", laptop, RemoteAuth{}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Push("", git.Auth{}); err != ErrRemoteNotNamed {
+		t.Fatalf("Push among several = %v, want ErrRemoteNotNamed", err)
+	}
+	var unknown *UnknownRemoteError
+	if _, err := s.Push("usb", git.Auth{}); !errors.As(err, &unknown) {
+		t.Fatalf("Push to usb = %v, want UnknownRemoteError", err)
+	}
+	if err := s.SetRemote("a/b", laptop, RemoteAuth{}); err == nil {
+		t.Error("SetRemote accepted the name a/b")
+	}
+
+	os.WriteFile(file, []byte("two\n"), 0644)
+	s.Save("two")
+	if n, err := s.Push("laptop", git.Auth{}); err != nil || n != 2 {
+		t.Fatalf("Push to laptop = %d, %v", n, err)
+	}
+	if n, err := s.Push("backup", git.Auth{}); err != nil || n != 1 {
+		t.Fatalf("Push to backup = %d, %v", n, err)
+	}
+
+	// Rewriting history keeps every remote and its credentials
+	if _, err := s.Drop(1); err != nil {
+		t.Fatal(err)
+	}
+	remotes, err := s.Remotes()
+	if err != nil || len(remotes) != 2 {
+		t.Fatalf("Remotes after drop = %v, %v", remotes, err)
+	}
+	if remotes[0].Name != "backup" || remotes[0].Auth.TokenEnv != "BACKUP_TOKEN" || remotes[1].Name != "laptop" {
+		t.Errorf("Remotes = %+v", remotes)
+	}
+
+	if err := s.RemoveRemote("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if remote, err := s.ResolveRemote(""); err != nil || remote.Name != "laptop" {
+		t.Errorf("ResolveRemote after removing backup = %v, %v", remote, err)
+	}
+	if _, err := s.Repo.ReadMeta(remotesMeta); !os.IsNotExist(err) {
+		t.Errorf("credentials of the removed remote are kept: %v", err)
+	}
+}
+
+func TestRemoteAuthApply(t *testing.T) {
+	t.Setenv("BACKUP_TOKEN", "from-env")
+	base := git.Auth{Username: "me", Token: "default"}
+
+	auth, err := RemoteAuth{TokenEnv: "BACKUP_TOKEN"}.Apply(base)
+	if err != nil || auth.Token != "from-env" || auth.Username != "me" {
+		t.Errorf("Apply(token_env) = %+v, %v", auth, err)
+	}
+	if auth, _ := (RemoteAuth{TokenEnv: "UNSET_TOKEN"}).Apply(base); auth.Token != "default" {
+		t.Errorf("an unset variable replaced the token: %+v", auth)
+	}
+	if _, err := (RemoteAuth{TokenCmd: "oops-no-such-helper"}).Apply(base); err == nil {
+		t.Error("a failing token command was not reported")
+	}
+}
+
+func TestRemoteTokenCmdStaysWithUser(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	file, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(file)
+	s.Initialize()
+	remote := filepath.Join(t.TempDir(), "backup.git")
+
+	auth := RemoteAuth{TokenEnv: "BACKUP_TOKEN", TokenCmd: "pass show backup"}
+	if err := s.SetRemote("backup", remote, auth); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.ResolveRemote("backup"); got.Auth != auth {
+		t.Errorf("Auth = %+v, want %+v", got.Auth, auth)
+	}
+	if data, _ := s.Repo.ReadMeta(remotesMeta); strings.Contains(string(data), "pass show") {
+		t.Errorf("token command kept with the history: %s", data)
+	}
+
+	// One that came with the history is not run
+	s.Repo.WriteMeta(remotesMeta, []byte(`{"laptop":{"token_cmd":"rm -rf /"}}`))
+	if err := s.SetRemote("laptop", remote, RemoteAuth{}); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := s.ResolveRemote("laptop"); got.Auth.TokenCmd != "" {
+		t.Errorf("token command read from the history: %q", got.Auth.TokenCmd)
+	}
+	if got, _ := s.ResolveRemote("backup"); got.Auth.TokenCmd != auth.TokenCmd {
+		t.Errorf("user's token command lost: %+v", got.Auth)
+	}
+
+	if err := s.RemoveRemote("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, _ := config.Load(); len(cfg.TokenCmds) != 0 {
+		t.Errorf("token commands of the removed remote kept: %v", cfg.TokenCmds)
+	}
+}
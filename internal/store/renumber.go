@@ -0,0 +1,215 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+// Renumbering describes how Renumber tags one commit
+type Renumbering struct {
+	Hash    string // short commit hash
+	Message string
+	Old     []int // numbers the commit was tagged with, ascending; empty if untagged
+	New     int   // its number afterwards, 0 if the commit is not in the history
+}
+
+// Changed reports whether Renumber changes the commit's tags
+func (r Renumbering) Changed() bool {
+	return len(r.Old) != 1 || r.Old[0] != r.New
+}
+
+// numbering is the snapshot numbering of a history as found
+type numbering struct {
+	order    []string         // commits reachable from HEAD, oldest first
+	tags     map[string][]int // numbers by commit hash, ascending
+	messages map[string]string
+}
+
+func (s *Store) loadNumbering() (*numbering, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	order, err := s.Repo.CommitOrder()
+	if err != nil {
+		return nil, err
+	}
+	versionTags, err := s.Repo.VersionTags()
+	if err != nil {
+		return nil, err
+	}
+	log, err := s.Repo.Log()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &numbering{order: order, tags: map[string][]int{}, messages: map[string]string{}}
+	for name, hash := range versionTags {
+		num, _ := git.ParseVersionTag(name)
+		n.tags[hash] = append(n.tags[hash], num)
+	}
+	for _, nums := range n.tags {
+		sort.Ints(nums)
+	}
+	for _, snap := range log {
+		n.messages[snap.Hash] = snap.Message
+	}
+	return n, nil
+}
+
+// CheckNumbering returns the problems with the store's snapshot numbers:
+// commits with no number or several, numbers out of commit order, gaps in
+// the sequence, and tags on commits that are not in the history. Gaps are
+// also left behind by compaction, which keeps snapshot numbers.
+func (s *Store) CheckNumbering() ([]string, error) {
+	n, err := s.loadNumbering()
+	if err != nil {
+		return nil, err
+	}
+
+	var problems []string
+	inHistory := map[string]bool{}
+	var used []int
+	last := 0
+	for _, hash := range n.order {
+		inHistory[hash] = true
+		nums := n.tags[hash]
+		switch {
+		case len(nums) == 0:
+			problems = append(problems, fmt.Sprintf("commit %s (%s) has no snapshot number",
+				shortHash(hash), n.messages[shortHash(hash)]))
+			continue
+		case len(nums) > 1:
+			problems = append(problems, fmt.Sprintf("%s point to the same commit %s",
+				tagList(nums), shortHash(hash)))
+		}
+		if nums[0] < last {
+			problems = append(problems, fmt.Sprintf("v%d comes after v%d in the history", nums[0], last))
+		}
+		last = max(last, nums[len(nums)-1])
+		used = append(used, nums...)
+	}
+
+	sort.Ints(used)
+	expect := 1
+	for _, num := range used {
+		switch {
+		case num == expect+1:
+			problems = append(problems, fmt.Sprintf("v%d is missing", expect))
+		case num > expect+1:
+			problems = append(problems, fmt.Sprintf("v%d to v%d are missing", expect, num-1))
+		}
+		expect = max(expect, num+1)
+	}
+
+	var outside []int
+	for hash, nums := range n.tags {
+		if !inHistory[hash] {
+			outside = append(outside, nums...)
+		}
+	}
+	sort.Ints(outside)
+	for _, num := range outside {
+		problems = append(problems, fmt.Sprintf("v%d points to a commit that is not in the history", num))
+	}
+	return problems, nil
+}
+
+// PlanRenumber returns how Renumber would tag the history, oldest commit
+// first, followed by tagged commits that are not in the history
+func (s *Store) PlanRenumber() ([]Renumbering, error) {
+	n, err := s.loadNumbering()
+	if err != nil {
+		return nil, err
+	}
+	return n.plan(), nil
+}
+
+func (n *numbering) plan() []Renumbering {
+	var plan []Renumbering
+	inHistory := map[string]bool{}
+	for i, hash := range n.order {
+		inHistory[hash] = true
+		plan = append(plan, Renumbering{
+			Hash:    shortHash(hash),
+			Message: n.messages[shortHash(hash)],
+			Old:     n.tags[hash],
+			New:     i + 1,
+		})
+	}
+
+	var outside []Renumbering
+	for hash, nums := range n.tags {
+		if !inHistory[hash] {
+			outside = append(outside, Renumbering{Hash: shortHash(hash), Old: nums})
+		}
+	}
+	sort.Slice(outside, func(i, j int) bool { return outside[i].Old[0] < outside[j].Old[0] })
+	return append(plan, outside...)
+}
+
+// Renumber retags the history so its commits are v1..vN in commit order,
+// removing tags on commits outside the history. The undo stack follows
+// the new numbers. Returns the plan that was applied.
+func (s *Store) Renumber() ([]Renumbering, error) {
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+	n, err := s.loadNumbering()
+	if err != nil {
+		return nil, err
+	}
+	plan := n.plan()
+	stack := s.loadPositions()
+
+	// Remove every tag that changes before creating any, since a new
+	// number may still be held by another commit
+	renumbered := map[int]int{}
+	for _, r := range plan {
+		if !r.Changed() {
+			continue
+		}
+		for _, num := range r.Old {
+			if err := s.Repo.DeleteTag(versionTag(num)); err != nil {
+				return nil, err
+			}
+		}
+	}
+	for i, r := range plan {
+		for _, num := range r.Old {
+			renumbered[num] = r.New
+		}
+		if r.Changed() && r.New > 0 {
+			if err := s.Repo.TagAt(versionTag(r.New), n.order[i]); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.savePositions(stack.renumber(renumbered)); err != nil {
+		return nil, err
+	}
+	return plan, nil
+}
+
+// tagList formats numbers as "v3, v5 and v7"
+func tagList(nums []int) string {
+	out := ""
+	for i, num := range nums {
+		switch {
+		case i == 0:
+		case i == len(nums)-1:
+			out += " and "
+		default:
+			out += ", "
+		}
+		out += versionTag(num)
+	}
+	return out
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"os"
+	"slices"
+	"testing"
+)
+
+func TestCheckAndRenumber(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2\n", "v3\n", "v4\n"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save("")
+	}
+	if problems, _ := s.CheckNumbering(); len(problems) != 0 {
+		t.Fatalf("fresh history has problems: %v", problems)
+	}
+
+	// Move v2 to v7, tag #3 twice and leave #4 untagged
+	hash2, _ := s.Repo.TagCommit("v2")
+	hash3, _ := s.Repo.TagCommit("v3")
+	s.Repo.DeleteTag("v2")
+	s.Repo.TagAt("v7", hash2)
+	s.Repo.TagAt("v9", hash3)
+	s.Repo.DeleteTag("v4")
+
+	problems, err := s.CheckNumbering()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{
+		"v3 comes after v7 in the history",
+		"v3 and v9 point to the same commit " + hash3[:7],
+	}
+	for _, w := range want {
+		if !slices.Contains(problems, w) {
+			t.Errorf("problems %q missing %q", problems, w)
+		}
+	}
+	if len(problems) < 4 { // also the untagged commit and the gaps
+		t.Errorf("problems = %q", problems)
+	}
+
+	plan, err := s.Renumber()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(plan) != 4 || plan[1].New != 2 || len(plan[1].Old) != 1 || plan[1].Old[0] != 7 {
+		t.Errorf("plan = %+v", plan)
+	}
+	if problems, _ := s.CheckNumbering(); len(problems) != 0 {
+		t.Errorf("problems after Renumber: %v", problems)
+	}
+	for num, want := range map[int]string{2: "v2\n", 3: "v3\n", 4: "v4\n"} {
+		if got, _ := s.Repo.Show(versionTag(num)); string(got) != want {
+			t.Errorf("snapshot #%d = %q, want %q", num, got, want)
+		}
+	}
+	if s.Repo.HasTag("v7") || s.Repo.HasTag("v9") {
+		t.Error("old tags should be removed")
+	}
+}
@@ -0,0 +1,61 @@
+package store
+
+import (
+	"compress/zlib"
+	"fmt"
+)
+
+// repackThreshold is how many loose objects, three per snapshot, a store
+// collects before a save packs its history
+const repackThreshold = 60
+
+// Repack stores the history in a single pack, each version of the file as
+// a delta against the one before it (see git.Repo.Repack), and returns the
+// size of the store before and after. Stores created before saves packed
+// their history are migrated this way.
+func (s *Store) Repack() (before, after int64, err error) {
+	return s.Recompress(zlib.DefaultCompression)
+}
+
+// Recompress is Repack compressing at the given zlib level, from 0 (none)
+// to 9 (smallest), e.g. to apply stronger compression to an old history
+func (s *Store) Recompress(level int) (before, after int64, err error) {
+	if level != zlib.DefaultCompression && (level < zlib.NoCompression || level > zlib.BestCompression) {
+		return 0, 0, fmt.Errorf("compression level must be 0 to 9, not %d", level)
+	}
+	if !s.Exists() {
+		return 0, 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, 0, err
+	}
+	defer unlock()
+	if s.Memory {
+		return 0, 0, nil
+	}
+	before = dirSize(s.GitDir)
+	err = s.Repo.Repack(level)
+	return before, dirSize(s.GitDir), err
+}
+
+// NeedsRepack reports whether the store has loose objects Repack would
+// pack
+func (s *Store) NeedsRepack() bool {
+	if s.Memory || !s.Exists() {
+		return false
+	}
+	n, err := s.Repo.LooseObjects()
+	return err == nil && n > 0
+}
+
+// repackIfLoose packs the history once enough snapshots are stored loose.
+// If packing fails the loose objects stay, which read back just as well.
+func (s *Store) repackIfLoose() {
+	if s.Memory {
+		return
+	}
+	if n, err := s.Repo.LooseObjects(); err == nil && n >= repackThreshold {
+		s.Repo.Repack(zlib.DefaultCompression)
+	}
+}
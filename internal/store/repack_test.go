@@ -0,0 +1,65 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestSavesPackHistory(t *testing.T) {
+	var lines []string
+	for i := 0; i < 2000; i++ {
+		lines = append(lines, fmt.Sprintf("%d: a long configuration line", i))
+	}
+	testFile, cleanup := setupTestFile(t, strings.Join(lines, "\n"))
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]string{1: strings.Join(lines, "\n")}
+	for v := 2; v <= 25; v++ {
+		lines[v*50] = fmt.Sprintf("edited in #%d", v)
+		want[v] = strings.Join(lines, "\n")
+		os.WriteFile(testFile, []byte(want[v]), 0644)
+		if _, err := s.Save(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if n, _ := s.Repo.LooseObjects(); n >= repackThreshold {
+		t.Errorf("%d loose objects after 25 saves, want the history packed", n)
+	}
+	for v, content := range want {
+		if got, err := s.Content(v); err != nil || string(got) != content {
+			t.Errorf("snapshot #%d after packing differs: %v", v, err)
+		}
+	}
+
+	// A store is migrated by packing what is still loose
+	os.WriteFile(testFile, []byte("short now"), 0644)
+	s.Save("")
+	if !s.NeedsRepack() {
+		t.Fatal("NeedsRepack = false with a loose snapshot")
+	}
+	if before, after, err := s.Repack(); err != nil || after > before {
+		t.Errorf("Repack = %d -> %d bytes, %v", before, after, err)
+	}
+	if s.NeedsRepack() {
+		t.Error("NeedsRepack = true after Repack")
+	}
+	if _, _, err := s.Recompress(9); err != nil {
+		t.Errorf("Recompress(9) = %v", err)
+	}
+	if _, _, err := s.Recompress(10); err == nil {
+		t.Error("Recompress(10) succeeded")
+	}
+	if err := s.Back(3, true); err != nil {
+		t.Fatal(err)
+	}
+	if content, _ := os.ReadFile(testFile); string(content) != want[3] {
+		t.Error("restored #3 differs after Repack")
+	}
+}
@@ -0,0 +1,76 @@
+package store
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/iyulab/oops/internal/doctext"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// RangeEntry is one snapshot of a history range with its line changes
+type RangeEntry struct {
+	Snapshot
+	Added   int // lines added since the previous entry
+	Removed int // lines removed since the previous entry
+}
+
+// HistoryBetween returns the snapshots numbered from to to, oldest first.
+// Each entry counts the lines changed since the entry before it; the first
+// entry is the baseline and counts nothing. Documents are compared by
+// their text; images are not counted.
+func (s *Store) HistoryBetween(from, to int) ([]RangeEntry, error) {
+	if from > to {
+		return nil, fmt.Errorf("range #%d to #%d is reversed", from, to)
+	}
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []RangeEntry
+	for _, snap := range history {
+		if snap.Number >= from && snap.Number <= to {
+			entries = append(entries, RangeEntry{Snapshot: snap})
+		}
+	}
+	if len(entries) == 0 || !s.Repo.HasTag(versionTag(from)) || !s.Repo.HasTag(versionTag(to)) {
+		return nil, ErrVersionNotFound
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Number < entries[j].Number })
+
+	if s.IsImage() {
+		return entries, nil
+	}
+	prev, err := s.lineText(from)
+	if err != nil {
+		return nil, err
+	}
+	for i := 1; i < len(entries); i++ {
+		text, err := s.lineText(entries[i].Number)
+		if err != nil {
+			return nil, err
+		}
+		entries[i].Added, entries[i].Removed = git.CountLineChanges(prev, text)
+		prev = text
+	}
+	return entries, nil
+}
+
+// lineText returns the content of snapshot num as compared line by line
+func (s *Store) lineText(num int) (string, error) {
+	content, err := s.Repo.Show(versionTag(num))
+	if err != nil {
+		return "", err
+	}
+	return s.contentText(content)
+}
+
+// contentText returns content as compared line by line: the text of a
+// document, or the content itself
+func (s *Store) contentText(content []byte) (string, error) {
+	if s.IsDocument() {
+		return doctext.Extract(s.FileName, content)
+	}
+	return string(content), nil
+}
@@ -0,0 +1,40 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestStoreHistoryBetween(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "a\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"a\nb\n", "a\nb\nc\nd\n", "x\n"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		if _, err := s.Save(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	entries, err := s.HistoryBetween(2, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []struct{ num, added, removed int }{{2, 0, 0}, {3, 2, 0}, {4, 1, 4}}
+	if len(entries) != len(want) {
+		t.Fatalf("got %d entries, want %d", len(entries), len(want))
+	}
+	for i, w := range want {
+		e := entries[i]
+		if e.Number != w.num || e.Added != w.added || e.Removed != w.removed {
+			t.Errorf("entry %d = #%d +%d -%d, want #%d +%d -%d",
+				i, e.Number, e.Added, e.Removed, w.num, w.added, w.removed)
+		}
+	}
+
+	if _, err := s.HistoryBetween(2, 9); err != ErrVersionNotFound {
+		t.Errorf("HistoryBetween past the end = %v, want ErrVersionNotFound", err)
+	}
+}
@@ -0,0 +1,167 @@
+package store
+
+import (
+	"sort"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+// PrunePolicy limits how much history is kept. The latest snapshot is
+// always kept.
+type PrunePolicy struct {
+	KeepLast  int           // keep at most this many snapshots, newest first; 0 for no limit
+	OlderThan time.Duration // remove snapshots older than this; 0 for no limit
+}
+
+// IsZero reports whether the policy removes nothing
+func (p PrunePolicy) IsZero() bool {
+	return p.KeepLast <= 0 && p.OlderThan <= 0
+}
+
+// Retention combines history thinning and limits into one policy
+type Retention struct {
+	Compact *CompactPolicy // nil to keep every snapshot within the limits
+	Prune   PrunePolicy
+}
+
+// IsZero reports whether the retention removes nothing
+func (r Retention) IsZero() bool {
+	return r.Compact == nil && r.Prune.IsZero()
+}
+
+// RetentionFromConfig returns the retention configured in cfg: its
+// prune_* limits, plus thinning if compact is on
+func RetentionFromConfig(cfg *config.Config) Retention {
+	r := Retention{Prune: PrunePolicy{KeepLast: cfg.PruneKeepLast, OlderThan: cfg.PruneOlderThan}}
+	if cfg.Compact {
+		r.Compact = &CompactPolicy{
+			KeepAll:    cfg.CompactKeepAll,
+			KeepHourly: cfg.CompactKeepHourly,
+			KeepDaily:  cfg.CompactKeepDaily,
+		}
+	}
+	return r
+}
+
+// PlanRetention returns the snapshots that Retain would remove, oldest first
+func (s *Store) PlanRetention(r Retention, now time.Time) ([]Snapshot, error) {
+	snaps, err := s.taggedSnapshots()
+	if err != nil {
+		return nil, err
+	}
+	return selectRetention(snaps, r, now), nil
+}
+
+// ReclaimEstimate returns about how many bytes of disk removing drop
+// frees. See git.Repo.ReclaimableSize for how it is counted.
+func (s *Store) ReclaimEstimate(drop []Snapshot) (int64, error) {
+	snaps, err := s.taggedSnapshots()
+	if err != nil {
+		return 0, err
+	}
+	dropped := make(map[int]bool, len(drop))
+	var dropTags, keepTags []string
+	for _, snap := range drop {
+		dropped[snap.Number] = true
+		dropTags = append(dropTags, versionTag(snap.Number))
+	}
+	for _, snap := range snaps {
+		if !dropped[snap.Number] {
+			keepTags = append(keepTags, versionTag(snap.Number))
+		}
+	}
+	return s.Repo.ReclaimableSize(dropTags, keepTags)
+}
+
+// Retain removes the snapshots that r does not keep. Kept snapshots retain
+// their numbers. Returns the number of snapshots removed.
+func (s *Store) Retain(r Retention, now time.Time) (int, error) {
+	if !s.Exists() {
+		return 0, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return 0, err
+	}
+	defer unlock()
+	snaps, err := s.taggedSnapshots()
+	if err != nil {
+		return 0, err
+	}
+	drop := selectRetention(snaps, r, now)
+	if len(drop) == 0 {
+		return 0, nil
+	}
+	if err := s.removeSnapshots(snaps, drop); err != nil {
+		return 0, err
+	}
+	return len(drop), nil
+}
+
+// autoRetain applies the configured retention after an automatic
+// snapshot, if auto_prune is on. Failing to prune is not an error: the
+// snapshot is saved.
+func (s *Store) autoRetain() {
+	if s.autoRetention == nil {
+		return
+	}
+	s.Retain(*s.autoRetention, time.Now())
+}
+
+// taggedSnapshots returns the numbered snapshots, oldest first
+func (s *Store) taggedSnapshots() ([]Snapshot, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	var snaps []Snapshot
+	for _, snap := range history {
+		if snap.Number != 0 {
+			snaps = append(snaps, snap)
+		}
+	}
+	sort.Slice(snaps, func(i, j int) bool { return snaps[i].Number < snaps[j].Number })
+	return snaps, nil
+}
+
+// selectRetention picks the snapshots to drop from snaps (oldest first)
+func selectRetention(snaps []Snapshot, r Retention, now time.Time) []Snapshot {
+	dropped := make(map[int]bool)
+	if r.Compact != nil {
+		for _, snap := range selectCompaction(snaps, *r.Compact, now) {
+			dropped[snap.Number] = true
+		}
+	}
+	for _, snap := range selectPrune(snaps, r.Prune, now) {
+		dropped[snap.Number] = true
+	}
+
+	var drop []Snapshot
+	for _, snap := range snaps {
+		if dropped[snap.Number] {
+			drop = append(drop, snap)
+		}
+	}
+	return drop
+}
+
+// selectPrune picks the snapshots beyond the policy's limits from snaps
+// (oldest first). The latest snapshot is always kept.
+func selectPrune(snaps []Snapshot, p PrunePolicy, now time.Time) []Snapshot {
+	var drop []Snapshot
+	for i, snap := range snaps {
+		newer := len(snaps) - 1 - i
+		if newer == 0 {
+			break
+		}
+		switch {
+		case p.KeepLast > 0 && newer >= p.KeepLast:
+		case p.OlderThan > 0 && now.Sub(snap.Timestamp) > p.OlderThan:
+		default:
+			continue
+		}
+		drop = append(drop, snap)
+	}
+	return drop
+}
@@ -0,0 +1,144 @@
+package store
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+func TestSelectPrune(t *testing.T) {
+	now := time.Date(2026, 10, 16, 12, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	var snaps []Snapshot
+	for i, age := range []time.Duration{100 * day, 95 * day, 60 * day, 10 * day, day} {
+		snaps = append(snaps, Snapshot{Number: i + 1, Timestamp: now.Add(-age)})
+	}
+
+	tests := []struct {
+		policy PrunePolicy
+		want   []int
+	}{
+		{PrunePolicy{KeepLast: 3}, []int{1, 2}},
+		{PrunePolicy{OlderThan: 90 * day}, []int{1, 2}},
+		{PrunePolicy{KeepLast: 4, OlderThan: 30 * day}, []int{1, 2, 3}},
+		{PrunePolicy{KeepLast: 10}, nil},
+		{PrunePolicy{OlderThan: 0}, nil},
+	}
+	for _, tt := range tests {
+		var got []int
+		for _, snap := range selectPrune(snaps, tt.policy, now) {
+			got = append(got, snap.Number)
+		}
+		if len(got) != len(tt.want) {
+			t.Errorf("%+v: dropped %v, want %v", tt.policy, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%+v: dropped %v, want %v", tt.policy, got, tt.want)
+				break
+			}
+		}
+	}
+
+	// The latest snapshot survives any age limit
+	if drop := selectPrune(snaps, PrunePolicy{OlderThan: time.Hour}, now); len(drop) != 4 {
+		t.Errorf("dropped %d, want all but the latest", len(drop))
+	}
+}
+
+func TestRetainKeepsNumbers(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2", "v3", "v4"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save(content)
+	}
+
+	removed, err := s.Retain(Retention{Prune: PrunePolicy{KeepLast: 2}}, time.Now())
+	if err != nil {
+		t.Fatalf("Retain failed: %v", err)
+	}
+	if removed != 2 {
+		t.Errorf("removed = %d, want 2", removed)
+	}
+	if content, err := s.Content(3); err != nil || string(content) != "v3" {
+		t.Errorf("#3 = %q, %v; want v3", content, err)
+	}
+	if _, err := s.Content(1); err != ErrVersionNotFound {
+		t.Errorf("#1 = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestAutoPrune(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	cfg := config.DefaultConfig()
+	cfg.AutoPrune = true
+	cfg.PruneKeepLast = 2
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	// Saving by hand keeps everything
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("v2")
+	os.WriteFile(testFile, []byte("v3"), 0644)
+	s.Save("v3")
+	if history, _ := s.History(); len(history) != 3 {
+		t.Fatalf("history has %d snapshots after manual saves, want 3", len(history))
+	}
+
+	os.WriteFile(testFile, []byte("v4"), 0644)
+	if _, err := s.SaveAs("auto", OriginAuto); err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := s.History(); len(history) != 2 {
+		t.Errorf("history has %d snapshots after an auto save, want 2", len(history))
+	}
+}
+
+func TestReclaimEstimate(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "shared content\n")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	unique := make([]byte, 64<<10)
+	for i := range unique {
+		unique[i] = byte(i*7 + i/251)
+	}
+	os.WriteFile(testFile, unique, 0644)
+	s.Save("unique")
+	os.WriteFile(testFile, []byte("shared content\n"), 0644)
+	s.Save("same as #1")
+
+	snaps, _ := s.taggedSnapshots()
+	shared, err := s.ReclaimEstimate(snaps[:1])
+	if err != nil {
+		t.Fatal(err)
+	}
+	only, err := s.ReclaimEstimate(snaps[1:2])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// #1's content stays with #3, so only its commit goes
+	if shared <= 0 || only <= shared {
+		t.Errorf("estimates: #1 %d, #2 %d; want #2's own content to count", shared, only)
+	}
+	if all := dirSize(s.GitDir); only > all {
+		t.Errorf("estimate %d is more than the whole store, %d", only, all)
+	}
+}
@@ -0,0 +1,72 @@
+package store
+
+import (
+	"strings"
+
+	"github.com/iyulab/oops/internal/eol"
+)
+
+// LineMatch is a line of a snapshot containing the search text
+type LineMatch struct {
+	Line int // 1-based
+	Text string
+}
+
+// SearchMatch is a snapshot whose message, note or content contains the
+// search text
+type SearchMatch struct {
+	Snapshot
+	InMessage bool // the message or note matched
+	Lines     []LineMatch
+}
+
+// Search finds the snapshots whose message, note or content contains
+// query, newest first like History. Binary content is not searched.
+func (s *Store) Search(query string, ignoreCase bool) ([]SearchMatch, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+
+	contains := strings.Contains
+	if ignoreCase {
+		query = strings.ToLower(query)
+		contains = func(text, query string) bool {
+			return strings.Contains(strings.ToLower(text), query)
+		}
+	}
+
+	lines := make(map[int][]LineMatch)
+	err = s.Repo.ForEachVersion(func(num int, content []byte) error {
+		if eol.IsBinary(content) {
+			return nil
+		}
+		for i, line := range strings.Split(string(content), "\n") {
+			line = strings.TrimSuffix(line, "\r")
+			if contains(line, query) {
+				lines[num] = append(lines[num], LineMatch{Line: i + 1, Text: line})
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	notes := s.loadNotes()
+	var matches []SearchMatch
+	for _, snap := range history {
+		if snap.Number == 0 {
+			continue // Untagged commit
+		}
+		match := SearchMatch{
+			Snapshot:  snap,
+			InMessage: contains(snap.Message, query) || contains(notes[snap.Hash], query),
+			Lines:     lines[snap.Number],
+		}
+		if match.InMessage || len(match.Lines) > 0 {
+			matches = append(matches, match)
+		}
+	}
+	return matches, nil
+}
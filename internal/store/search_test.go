@@ -0,0 +1,45 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "intro\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("intro\nTODO: outline\n"), 0644)
+	s.Save("add outline")
+	os.WriteFile(testFile, []byte("intro\ndone\n"), 0644)
+	s.Save("finish the todo")
+	s.SetNote(1, "todo list started here")
+
+	matches, err := s.Search("TODO", false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(matches) != 1 || matches[0].Number != 2 || matches[0].InMessage {
+		t.Fatalf("Search(TODO) = %+v", matches)
+	}
+	if lines := matches[0].Lines; len(lines) != 1 || lines[0].Line != 2 || lines[0].Text != "TODO: outline" {
+		t.Errorf("lines = %+v", lines)
+	}
+
+	matches, _ = s.Search("todo", true)
+	var got []int
+	for _, m := range matches {
+		got = append(got, m.Number)
+	}
+	if len(got) != 3 || got[0] != 3 || got[1] != 2 || got[2] != 1 {
+		t.Errorf("Search(todo, ignoreCase) matched %v, want [3 2 1]", got)
+	}
+	if !matches[0].InMessage || len(matches[0].Lines) != 0 {
+		t.Errorf("#3 should match only by message: %+v", matches[0])
+	}
+	if !matches[2].InMessage {
+		t.Errorf("#1 should match by note: %+v", matches[2])
+	}
+}
@@ -0,0 +1,13 @@
+package store
+
+import "github.com/iyulab/oops/internal/secrets"
+
+// ScanSecrets returns likely credentials in the content the next snapshot
+// would store. Text masked by redact patterns is not reported.
+func (s *Store) ScanSecrets() ([]secrets.Finding, error) {
+	content, err := s.Repo.ReadWorkFile()
+	if err != nil {
+		return nil, err
+	}
+	return secrets.Scan(content), nil
+}
@@ -0,0 +1,82 @@
+package store
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/iyulab/oops/internal/compress"
+	"github.com/iyulab/oops/internal/git"
+)
+
+// SnapshotTooLargeError is returned when a snapshot exceeds the size cap
+// even after compression
+type SnapshotTooLargeError struct {
+	Size       int64 // size of the file
+	Compressed int64 // size after the strongest compression
+	Limit      int64 // configured cap
+}
+
+func (e *SnapshotTooLargeError) Error() string {
+	return fmt.Sprintf("snapshot is %d bytes (%d compressed), over the %d byte limit",
+		e.Size, e.Compressed, e.Limit)
+}
+
+// snapshotContent returns the work file's content in the form it should
+// be stored, and whether it had to be packed to fit the size cap
+func (s *Store) snapshotContent() ([]byte, bool, error) {
+	content, err := s.Repo.ReadWorkFile()
+	if err != nil {
+		return nil, false, err
+	}
+	return s.fitContent(content)
+}
+
+// stageSnapshot stores the work file as the content of the next snapshot,
+// reporting whether it was packed. A file stored as it is streams into
+// the history, and so does one packed to fit MaxSnapshotSize when it is
+// over git.MaxInMemorySize; a filtered one passes through memory.
+func (s *Store) stageSnapshot() (bool, error) {
+	if !s.Repo.HasCleanFilter() {
+		info, err := os.Stat(s.FilePath)
+		switch {
+		case err != nil:
+		case s.MaxSnapshotSize <= 0 || info.Size() <= s.MaxSnapshotSize:
+			return false, s.Repo.Add()
+		case info.Size() > git.MaxInMemorySize:
+			packed, err := s.Repo.AddPacked(s.MaxSnapshotSize)
+			if err != nil {
+				return false, err
+			}
+			if packed > s.MaxSnapshotSize {
+				return false, &SnapshotTooLargeError{Size: info.Size(), Compressed: packed, Limit: s.MaxSnapshotSize}
+			}
+			return true, nil
+		}
+	}
+	content, packed, err := s.snapshotContent()
+	if err != nil {
+		return false, err
+	}
+	return packed, s.Repo.AddContent(content)
+}
+
+// fitContent packs content that exceeds MaxSnapshotSize with the strongest
+// compression, refusing it if it is still too large
+func (s *Store) fitContent(content []byte) ([]byte, bool, error) {
+	if s.MaxSnapshotSize <= 0 || int64(len(content)) <= s.MaxSnapshotSize {
+		return content, false, nil
+	}
+
+	packed, err := compress.Pack(content)
+	if err != nil {
+		return nil, false, err
+	}
+	if int64(len(packed)) > s.MaxSnapshotSize {
+		return nil, false, &SnapshotTooLargeError{
+			Size:       int64(len(content)),
+			Compressed: int64(len(packed)),
+			Limit:      s.MaxSnapshotSize,
+		}
+	}
+	return packed, true, nil
+}
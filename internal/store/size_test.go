@@ -0,0 +1,107 @@
+package store
+
+import (
+	"crypto/rand"
+	"errors"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/iyulab/oops/internal/git"
+)
+
+func TestSavePacksOversizedSnapshot(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "small")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.MaxSnapshotSize = 4096
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	big := strings.Repeat("generated line of output\n", 1000)
+	os.WriteFile(testFile, []byte(big), 0644)
+	snap, err := s.Save("")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !snap.Packed {
+		t.Error("oversized snapshot should be packed")
+	}
+
+	// Packed snapshots read back as the original content
+	if hasChanges, _ := s.Repo.HasChanges(); hasChanges {
+		t.Error("packed snapshot should match the work file")
+	}
+	s.Back(1, false)
+	s.Back(2, false)
+	content, _ := os.ReadFile(testFile)
+	if string(content) != big {
+		t.Error("Back did not restore the original content")
+	}
+}
+
+func TestSaveRefusesSnapshotOverCap(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "small")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.MaxSnapshotSize = 4096
+	s.Initialize()
+
+	noise := make([]byte, 8192)
+	rand.Read(noise)
+	os.WriteFile(testFile, noise, 0644)
+
+	_, err := s.Save("")
+	var tooLarge *SnapshotTooLargeError
+	if !errors.As(err, &tooLarge) {
+		t.Fatalf("Save = %v, want SnapshotTooLargeError", err)
+	}
+	if tooLarge.Size != 8192 || tooLarge.Limit != 4096 {
+		t.Errorf("error = %+v", tooLarge)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 1 {
+		t.Errorf("latest = #%d, want #1 (nothing saved)", latest)
+	}
+}
+
+func TestSaveStreamsPackedSnapshotTooLargeForMemory(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "small")
+	defer cleanup()
+	defer func(max int64) { git.MaxInMemorySize = max }(git.MaxInMemorySize)
+	git.MaxInMemorySize = 8192
+
+	s, _ := NewStore(testFile)
+	s.MaxSnapshotSize = 4096
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	big := strings.Repeat("generated line of output\n", 1000)
+	os.WriteFile(testFile, []byte(big), 0644)
+	snap, err := s.Save("")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if !snap.Packed {
+		t.Error("oversized snapshot should be packed")
+	}
+	s.Back(1, false)
+	s.Back(2, false)
+	if content, _ := os.ReadFile(testFile); string(content) != big {
+		t.Error("Back did not restore the original content")
+	}
+
+	noise := make([]byte, 16384)
+	rand.Read(noise)
+	os.WriteFile(testFile, noise, 0644)
+	var tooLarge *SnapshotTooLargeError
+	if _, err := s.Save(""); !errors.As(err, &tooLarge) {
+		t.Fatalf("Save = %v, want SnapshotTooLargeError", err)
+	}
+	if tooLarge.Size != 16384 || tooLarge.Compressed <= 4096 {
+		t.Errorf("error = %+v", tooLarge)
+	}
+}
@@ -0,0 +1,85 @@
+package store
+
+import "time"
+
+// StorageUsage compares the disk a store takes with what its snapshots
+// would take kept in full
+type StorageUsage struct {
+	Snapshots int
+	FullSize  int64          // content of every snapshot, summed
+	DiskSize  int64          // the store's files on disk
+	Sizes     []SnapshotSize // oldest first
+}
+
+// SnapshotSize is the size of one snapshot
+type SnapshotSize struct {
+	Number    int
+	Timestamp time.Time
+	Size      int64 // content as restored
+	Stored    int64 // as stored before git's compression: smaller when packed or a delta
+}
+
+// Saved returns how many bytes packing, compression and binary deltas
+// save, negative when the store takes more than its snapshots in full
+func (u StorageUsage) Saved() int64 {
+	return u.FullSize - u.DiskSize
+}
+
+// Ratio returns how many times smaller the store is on disk than its
+// snapshots in full, 0 when nothing is on disk
+func (u StorageUsage) Ratio() float64 {
+	if u.DiskSize == 0 {
+		return 0
+	}
+	return float64(u.FullSize) / float64(u.DiskSize)
+}
+
+// Add adds the usage of another store, for totals across stores. Sizes
+// are not combined.
+func (u *StorageUsage) Add(other *StorageUsage) {
+	u.Snapshots += other.Snapshots
+	u.FullSize += other.FullSize
+	u.DiskSize += other.DiskSize
+}
+
+// Storage measures the store's disk use against its snapshots' full size
+func (s *Store) Storage() (*StorageUsage, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+	times := make(map[int]time.Time, len(history))
+	for _, snap := range history {
+		times[snap.Number] = snap.Timestamp
+	}
+
+	usage := &StorageUsage{}
+	err = s.Repo.ForEachVersion(func(num int, content []byte) error {
+		stored, _, err := s.Repo.StoredSize(versionTag(num))
+		if err != nil {
+			return err
+		}
+		usage.Snapshots++
+		usage.FullSize += int64(len(content))
+		usage.Sizes = append(usage.Sizes, SnapshotSize{
+			Number:    num,
+			Timestamp: times[num],
+			Size:      int64(len(content)),
+			Stored:    stored,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if !s.Memory {
+		usage.DiskSize = dirSize(s.GitDir)
+	}
+	return usage, nil
+}
@@ -0,0 +1,50 @@
+package store
+
+import (
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestStoreStorageBinaryDeltas(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "")
+	defer cleanup()
+
+	data := make([]byte, 512<<10)
+	rand.New(rand.NewSource(1)).Read(data) // incompressible
+	data[0] = 0                            // binary
+	os.WriteFile(testFile, data, 0644)
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	for v := 2; v <= 5; v++ {
+		copy(data[v*4096:], fmt.Sprintf("record %d updated", v))
+		os.WriteFile(testFile, data, 0644)
+		if _, err := s.Save(""); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	usage, err := s.Storage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if usage.Snapshots != 5 || usage.FullSize != 5*int64(len(data)) {
+		t.Errorf("Storage = %d snapshots, %d bytes; want 5 of %d", usage.Snapshots, usage.FullSize, len(data))
+	}
+	if usage.Saved() < 3*int64(len(data)) {
+		t.Errorf("saved %d of %d bytes, want the later snapshots stored as deltas", usage.Saved(), usage.FullSize)
+	}
+	if len(usage.Sizes) != 5 || usage.Sizes[2].Number != 3 || usage.Sizes[2].Size != int64(len(data)) {
+		t.Fatalf("Sizes = %+v", usage.Sizes)
+	}
+	if stored := usage.Sizes[2].Stored; stored >= int64(len(data))/2 {
+		t.Errorf("snapshot #3 stored in %d bytes, want a delta", stored)
+	}
+	if got, err := s.Content(3); err != nil || !strings.Contains(string(got), "record 3 updated") {
+		t.Errorf("snapshot #3 not restored from its delta: %v", err)
+	}
+}
@@ -6,11 +6,21 @@ import (
 	"errors"
 	"fmt"
 	"os"
+	"path"
 	"path/filepath"
 	"strings"
+	"time"
 
+	"github.com/go-git/go-billy/v5"
 	"github.com/iyulab/oops/internal/compress"
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/eol"
+	"github.com/iyulab/oops/internal/events"
 	"github.com/iyulab/oops/internal/git"
+	"github.com/iyulab/oops/internal/netfs"
+	"github.com/iyulab/oops/internal/redact"
+	"github.com/iyulab/oops/internal/region"
+	"github.com/iyulab/oops/internal/timing"
 )
 
 const (
@@ -24,11 +34,22 @@ var (
 	ErrNoChanges          = errors.New("no changes to save")
 	ErrVersionNotFound    = errors.New("version not found")
 	ErrUncommittedChanges = errors.New("uncommitted changes exist")
+	ErrConcurrentSave     = errors.New("another process kept saving the file at the same time")
+
+	// Reading the work file failed because it vanished or would not hold
+	// still, e.g. while an editor replaced it
+	ErrFileMissing  = git.ErrFileMissing
+	ErrFileChanging = git.ErrFileChanging
 )
 
+// saveAttempts is how many times a save starts over when another process
+// saves the file between its change check and its commit
+const saveAttempts = 3
+
 // StoreOptions configures Store behavior
 type StoreOptions struct {
-	Global bool // Use global storage in user home directory
+	Global  bool   // Use global storage in user home directory
+	Profile string // Named history to use; DefaultProfile for the main one, "" for the one switched to
 }
 
 // Store manages versioning for a single file using Git backend
@@ -38,7 +59,38 @@ type Store struct {
 	BaseDir  string
 	GitDir   string
 	Repo     *git.Repo
-	Global   bool // true if using global storage
+	Global   bool   // true if using global storage
+	Memory   bool   // true if history is kept in memory only
+	Profile  string // named history this store holds, "" for the default
+
+	// MaxSnapshotSize caps the stored size of one snapshot in bytes.
+	// Larger snapshots are compressed harder or refused. 0 means no limit.
+	MaxSnapshotSize int64
+
+	// LockWait is how long operations that change the history wait for
+	// another process to finish with the store, DefaultLockWait unless set
+	LockWait time.Duration
+
+	// Recovered is set when opening the store finished or undid a save
+	// that was interrupted, e.g. by a crash between commit and tag
+	Recovered *Recovery
+
+	// ChainFailures lists the chained files the last save or restore
+	// could not snapshot or restore along with the tracked file
+	ChainFailures []error
+
+	redact        func([]byte) []byte // masks redact patterns, nil if none are set
+	eolMode       string              // line ending mode, see package eol
+	regionMarkers *region.Markers     // tracked part of the file, nil for all of it
+	mainGitDir    string              // GitDir of the default profile
+	network       netfs.Info          // filesystem holding the store
+	globalLayout  string              // layout of new global stores, see config.LayoutContent
+	autoRetention *Retention          // applied after automatic snapshots, nil unless auto_prune
+	restoreMtime  bool                // restores set the modification time recorded with the snapshot
+	restoreOwner  bool                // restores set the owner recorded with the snapshot
+	maxDiffSize   int64               // larger files get a summary instead of a line diff, 0 for no limit
+	validateHook  string              // command snapshots must pass, "" for none; see SetValidateHook
+	locked        int                 // depth of nested operations holding the store lock
 }
 
 // Snapshot represents a version snapshot (re-exported from git package)
@@ -54,10 +106,11 @@ func GetGlobalOopsDir() (string, error) {
 }
 
 // normalizePath normalizes file path for cross-platform compatibility
-// Converts backslashes to forward slashes and lowercases drive letters on Windows
+// Converts backslashes to forward slashes, resolves dot segments and
+// lowercases drive letters on Windows
 func normalizePath(absPath string) string {
 	// Convert backslashes to forward slashes for consistency
-	normalized := strings.ReplaceAll(absPath, "\\", "/")
+	normalized := path.Clean(strings.ReplaceAll(absPath, "\\", "/"))
 	// Lowercase drive letter on Windows (e.g., C: -> c:)
 	if len(normalized) >= 2 && normalized[1] == ':' {
 		normalized = strings.ToLower(normalized[:1]) + normalized[1:]
@@ -84,6 +137,12 @@ func NewGlobalStore(filePath string) (*Store, error) {
 
 // NewStoreWithOptions creates a store instance with specified options
 func NewStoreWithOptions(filePath string, opts StoreOptions) (*Store, error) {
+	defer timing.Start(timing.StoreResolution)()
+
+	if err := validateFilePath(filePath); err != nil {
+		return nil, err
+	}
+
 	absPath, err := filepath.Abs(filePath)
 	if err != nil {
 		return nil, err
@@ -91,6 +150,9 @@ func NewStoreWithOptions(filePath string, opts StoreOptions) (*Store, error) {
 
 	baseDir := filepath.Dir(absPath)
 	fileName := filepath.Base(absPath)
+	if err := ValidateFileName(fileName); err != nil {
+		return nil, err
+	}
 
 	var gitDir string
 	if opts.Global {
@@ -98,31 +160,181 @@ func NewStoreWithOptions(filePath string, opts StoreOptions) (*Store, error) {
 		if err != nil {
 			return nil, err
 		}
-		// Use hash of full path to create unique directory
-		pathHash := hashFilePath(absPath)
-		gitDir = filepath.Join(globalDir, pathHash, fileName+".git")
+		// Key by a hash of the full path, or by volume on removable media,
+		// unless the path index names the store
+		hashDir, ok := indexedStoreDir(globalDir, absPath)
+		if !ok {
+			hashDir = globalStoreDir(globalDir, absPath)
+		}
+		gitDir = filepath.Join(hashDir, fileName+".git")
 	} else {
 		gitDir = filepath.Join(baseDir, OopsDir, fileName+".git")
 	}
 
+	mainGitDir := gitDir
+	recoverRebuild(mainGitDir)
+	profile := opts.Profile
+	if profile == "" {
+		profile = activeProfile(mainGitDir)
+	}
+	if profile == DefaultProfile {
+		profile = ""
+	}
+	if profile != "" {
+		if err := ValidateProfileName(profile); err != nil {
+			return nil, err
+		}
+		gitDir = filepath.Join(profilesDir(mainGitDir), profile+".git")
+		recoverRebuild(gitDir)
+	}
+
 	s := &Store{
 		FilePath: absPath,
 		FileName: fileName,
 		BaseDir:  baseDir,
 		GitDir:   gitDir,
-		Repo:     git.NewRepo(gitDir, baseDir, fileName),
 		Global:   opts.Global,
+		Profile:  profile,
+
+		MaxSnapshotSize: config.DefaultMaxSnapshotSize,
+		LockWait:        DefaultLockWait,
+		maxDiffSize:     config.DefaultMaxDiffSize,
+		mainGitDir:      mainGitDir,
+	}
+	if cfg, err := config.Load(); err == nil {
+		s.MaxSnapshotSize = cfg.MaxSnapshotSize
+		s.maxDiffSize = cfg.MaxDiffSize
+		if err := s.SetRedactPatterns(cfg.Redact); err != nil {
+			return nil, err
+		}
+		if err := s.SetEOL(cfg.EOL); err != nil {
+			return nil, err
+		}
+		s.globalLayout = cfg.GlobalLayout
+		s.restoreMtime, s.restoreOwner = cfg.RestoreMtime, cfg.RestoreOwner
+		s.validateHook = cfg.ValidateHook(normalizePath(absPath))
+		if r := RetentionFromConfig(cfg); cfg.AutoPrune && !r.IsZero() {
+			s.autoRetention = &r
+		}
+	}
+	s.network = netfs.Detect(mainGitDir)
+	s.Repo = s.newRepo(gitDir)
+
+	if s.Exists() {
+		s.loadRegion()
+		// A save in progress in another process is not interrupted
+		if unlock, ok := s.tryLock(); ok {
+			s.Recovered, err = s.recoverSave()
+			unlock()
+			if err != nil {
+				return nil, fmt.Errorf("recovering interrupted save: %w", err)
+			}
+		}
 	}
 
 	return s, nil
 }
 
+// SetRedactPatterns masks matches of patterns in content before it is
+// stored. The work file keeps the original text.
+func (s *Store) SetRedactPatterns(patterns []string) error {
+	r, err := redact.New(patterns)
+	if err != nil {
+		return err
+	}
+	s.redact = nil
+	if !r.Empty() {
+		s.redact = r.Apply
+	}
+	s.applyFilters(s.Repo)
+	return nil
+}
+
+// SetEOL sets how line endings are converted when content is stored and
+// restored. See package eol for the modes.
+func (s *Store) SetEOL(mode string) error {
+	if err := eol.Validate(mode); err != nil {
+		return err
+	}
+	s.eolMode = mode
+	s.applyFilters(s.Repo)
+	return nil
+}
+
+// applyFilters sets the store's clean and smudge filters on repo. Content
+// is cut to its region first, then normalized and redacted; restores
+// convert line endings before splicing the region back.
+func (s *Store) applyFilters(repo *git.Repo) {
+	if repo == nil {
+		return
+	}
+
+	var extract, splice func([]byte) []byte
+	if s.regionMarkers != nil {
+		extract, splice = s.extractRegion, s.spliceRegion
+	}
+	repo.SetCleanFilter(chainFilters(extract, eol.Clean(s.eolMode), s.redact))
+	repo.SetSmudgeFilter(chainFilters(eol.Smudge(s.eolMode), splice))
+}
+
+// chainFilters applies the non-nil filters in order, returning nil if
+// there are none
+func chainFilters(filters ...func([]byte) []byte) func([]byte) []byte {
+	var active []func([]byte) []byte
+	for _, f := range filters {
+		if f != nil {
+			active = append(active, f)
+		}
+	}
+	switch len(active) {
+	case 0:
+		return nil
+	case 1:
+		return active[0]
+	}
+	return func(data []byte) []byte {
+		for _, f := range active {
+			data = f(data)
+		}
+		return data
+	}
+}
+
+// newRepo returns a disk repository for this store's file at gitDir
+func (s *Store) newRepo(gitDir string) *git.Repo {
+	repo := git.NewRepo(gitDir, s.BaseDir, s.FileName)
+	// Network filesystems may cache writes past a crash or power loss, so
+	// the save journal and other metadata are flushed as they are written
+	repo.SyncMeta = s.network.Network
+	repo.MaxDiffSize = s.maxDiffSize
+	s.applyFilters(repo)
+	return repo
+}
+
+// NewMemoryStore creates a store whose history is kept in memory.
+// The tracked file is read from and restored to fs, which may itself be
+// an in-memory filesystem such as memfs. Nothing is written to disk.
+func NewMemoryStore(fs billy.Filesystem, fileName string) (*Store, error) {
+	if err := ValidateFileName(fileName); err != nil {
+		return nil, err
+	}
+
+	return &Store{
+		FilePath: fs.Join(fs.Root(), fileName),
+		FileName: fileName,
+		BaseDir:  fs.Root(),
+		Repo:     git.NewMemoryRepo(fs, fileName),
+		Memory:   true,
+	}, nil
+}
+
 // OopsDirPath returns the path to .oops directory
 func (s *Store) OopsDirPath() string {
+	if s.Memory {
+		return ""
+	}
 	if s.Global {
-		globalDir, _ := GetGlobalOopsDir()
-		pathHash := hashFilePath(s.FilePath)
-		return filepath.Join(globalDir, pathHash)
+		return filepath.Dir(s.mainGitDir)
 	}
 	return filepath.Join(s.BaseDir, OopsDir)
 }
@@ -134,36 +346,71 @@ func (s *Store) Exists() bool {
 
 // Initialize creates a new store for tracking (start/track)
 func (s *Store) Initialize() error {
+	return s.InitializeWithMessage("")
+}
+
+// InitializeWithMessage is Initialize with a message for snapshot #1,
+// "Initial snapshot" if empty
+func (s *Store) InitializeWithMessage(message string) error {
+	if message == "" {
+		message = "Initial snapshot"
+	}
 	if s.Exists() {
 		return ErrAlreadyTracked
 	}
+	if !s.IsDefaultProfile() && !s.mainExists() {
+		return ErrNotTracked // profiles belong to a tracked file
+	}
 
 	// Check if file exists
-	if _, err := os.Stat(s.FilePath); err != nil {
+	if !s.Repo.WorkFileExists() {
 		return fmt.Errorf("file not found: %s", s.FilePath)
 	}
-
-	// Create .oops directory
-	if err := os.MkdirAll(s.OopsDirPath(), 0755); err != nil {
+	if err := s.checkRegion(); err != nil {
 		return err
 	}
 
-	// Save metadata for global stores
-	if err := s.saveMetadata(); err != nil {
+	content, _, err := s.snapshotContent()
+	if err != nil {
 		return err
 	}
 
+	if s.contentKeyed() {
+		globalDir, err := GetGlobalOopsDir()
+		if err != nil {
+			return err
+		}
+		if _, ok := indexedStoreDir(globalDir, s.FilePath); !ok {
+			s.useStoreDir(filepath.Join(globalDir, newContentKey(content)))
+		}
+	}
+
+	if !s.Memory {
+		// Create .oops directory
+		if err := os.MkdirAll(s.OopsDirPath(), 0755); err != nil {
+			return err
+		}
+
+		// Save metadata for global stores
+		if err := s.saveMetadata(); err != nil {
+			return err
+		}
+	}
+
 	// Initialize bare Git repository
 	if err := s.Repo.Init(); err != nil {
 		return err
 	}
 
 	// Stage and commit initial version
-	if err := s.Repo.Add(); err != nil {
+	if err := s.Repo.AddContent(content); err != nil {
 		return err
 	}
 
-	if _, err := s.Repo.Commit("Initial snapshot"); err != nil {
+	if _, err := s.Repo.Commit(message); err != nil {
+		return err
+	}
+	if err := s.saveRegion(); err != nil {
 		return err
 	}
 
@@ -171,15 +418,67 @@ func (s *Store) Initialize() error {
 	if err := s.Repo.Tag("v1"); err != nil {
 		return err
 	}
+	if s.contentKeyed() {
+		if err := s.indexPath(); err != nil {
+			return err
+		}
+	}
 
+	s.recordImageInfo(1)
+	s.recordFileMeta(1)
+	s.emit(events.SnapshotCreated, 1, message)
 	return nil
 }
 
 // Save creates a new snapshot (save/commit)
 func (s *Store) Save(message string) (*Snapshot, error) {
+	return s.save(message, true)
+}
+
+// BackupChanges saves unsaved changes as a snapshot before they are
+// overwritten. Unlike Save it leaves the undo position alone, so the
+// restore that follows can still be undone and redone as usual.
+func (s *Store) BackupChanges(message string) (*Snapshot, error) {
+	return s.save(message, false)
+}
+
+func (s *Store) save(message string, updatePosition bool) (*Snapshot, error) {
 	if !s.Exists() {
 		return nil, ErrNotTracked
 	}
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+
+	// Commit deferred saves first so numbering follows capture order
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+	if err := s.checkRegion(); err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		snap, err := s.saveOnto(message, updatePosition)
+		if err != git.ErrHeadMoved {
+			return snap, err
+		}
+		if attempt == saveAttempts {
+			return nil, ErrConcurrentSave
+		}
+	}
+}
+
+// saveOnto makes one attempt at a save. The commit only lands on the
+// version the changes were checked against; git.ErrHeadMoved means
+// another process saved first and the attempt left nothing behind.
+func (s *Store) saveOnto(message string, updatePosition bool) (*Snapshot, error) {
+	expected, err := s.Repo.HeadCommit()
+	if err != nil {
+		return nil, err
+	}
 
 	// Check for changes
 	hasChanges, err := s.Repo.HasChanges()
@@ -189,6 +488,9 @@ func (s *Store) Save(message string) (*Snapshot, error) {
 	if !hasChanges {
 		return nil, ErrNoChanges
 	}
+	if err := s.validateWorkFile(); err != nil {
+		return nil, err
+	}
 
 	// Get next version number
 	latestNum, err := s.Repo.GetLatestTagNumber()
@@ -202,27 +504,49 @@ func (s *Store) Save(message string) (*Snapshot, error) {
 		message = fmt.Sprintf("Snapshot #%d", nextNum)
 	}
 
-	// Stage and commit
-	if err := s.Repo.Add(); err != nil {
+	// Stage and commit, compressing harder if the snapshot is over the cap
+	packed, err := s.stageSnapshot()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.beginSave(nextNum, message, updatePosition); err != nil {
 		return nil, err
 	}
 
-	if _, err := s.Repo.Commit(message); err != nil {
+	if _, err := s.Repo.CommitOnto(expected, message); err != nil {
+		s.endSave()
+		if err == git.ErrHeadMoved {
+			return nil, err
+		}
 		if strings.Contains(err.Error(), "no changes") {
 			return nil, ErrNoChanges
 		}
 		return nil, err
 	}
 
-	// Tag with version number
+	// Tag with version number. If this fails the journal stays, and the
+	// save is finished the next time the store is opened.
 	tag := fmt.Sprintf("v%d", nextNum)
 	if err := s.Repo.Tag(tag); err != nil {
 		return nil, err
 	}
 
+	if updatePosition {
+		if err := s.recordPosition(nextNum); err != nil {
+			return nil, err
+		}
+	}
+	s.endSave()
+	s.repackIfLoose()
+	s.recordImageInfo(nextNum)
+	s.recordFileMeta(nextNum)
+	s.emit(events.SnapshotCreated, nextNum, message)
+	s.saveArtifacts(nextNum, message)
+
 	return &Snapshot{
 		Number:  nextNum,
 		Message: message,
+		Packed:  packed,
 	}, nil
 }
 
@@ -231,6 +555,14 @@ func (s *Store) Back(num int, force bool) error {
 	if !s.Exists() {
 		return ErrNotTracked
 	}
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	if _, err := s.FlushPending(); err != nil {
+		return err
+	}
 
 	// Validate version exists
 	latestNum, err := s.Repo.GetLatestTagNumber()
@@ -241,9 +573,19 @@ func (s *Store) Back(num int, force bool) error {
 		return ErrVersionNotFound
 	}
 
+	// Numbers may have gaps after compaction
+	tag := versionTag(num)
+	if !s.Repo.HasTag(tag) {
+		return ErrVersionNotFound
+	}
+
 	// Check for uncommitted changes
 	if !force {
-		hasChanges, err := s.Repo.HasChanges()
+		position, err := s.Position()
+		if err != nil {
+			return err
+		}
+		hasChanges, err := s.changedSince(position)
 		if err != nil {
 			return err
 		}
@@ -253,16 +595,62 @@ func (s *Store) Back(num int, force bool) error {
 	}
 
 	// Checkout the version
-	tag := fmt.Sprintf("v%d", num)
-	return s.Repo.Checkout(tag)
+	if err := s.Repo.Checkout(tag); err != nil {
+		return err
+	}
+	s.restoreFileMeta(num)
+	if err := s.recordPosition(num); err != nil {
+		return err
+	}
+	s.emit(events.Restored, num, "")
+	s.restoreArtifacts(num)
+	return nil
+}
+
+// Content returns snapshot num as Back would restore it, leaving the
+// working file alone (cat/export)
+func (s *Store) Content(num int) ([]byte, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	if pending, err := s.Pending(); err == nil && len(pending) > 0 {
+		// Flushing commits snapshots, so the lock is held through the read
+		unlock, err := s.lock()
+		if err != nil {
+			return nil, err
+		}
+		defer unlock()
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+	tag := versionTag(num)
+	if num < 1 || !s.Repo.HasTag(tag) {
+		return nil, ErrVersionNotFound
+	}
+	return s.Repo.ShowWork(tag)
 }
 
-// Undo restores to HEAD (undo unsaved changes)
+// Undo discards unsaved changes, restoring the current position
 func (s *Store) Undo() error {
 	if !s.Exists() {
 		return ErrNotTracked
 	}
-	return s.Repo.CheckoutHead()
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+	position, err := s.Position()
+	if err != nil || position < 1 {
+		return s.Repo.CheckoutHead()
+	}
+	if err := s.Repo.Checkout(versionTag(position)); err != nil {
+		return err
+	}
+	s.restoreFileMeta(position)
+	s.emit(events.Restored, position, "")
+	return nil
 }
 
 // Changes returns diff output (changes/diff)
@@ -294,6 +682,29 @@ func (s *Store) History() ([]Snapshot, error) {
 	return s.Repo.Log()
 }
 
+// SnapshotBefore returns the newest snapshot taken before t
+func (s *Store) SnapshotBefore(t time.Time) (*Snapshot, error) {
+	history, err := s.History()
+	if err != nil {
+		return nil, err
+	}
+
+	var found *Snapshot
+	for i := range history {
+		snap := history[i]
+		if snap.Number == 0 || !snap.Timestamp.Before(t) {
+			continue
+		}
+		if found == nil || snap.Timestamp.After(found.Timestamp) {
+			found = &snap
+		}
+	}
+	if found == nil {
+		return nil, ErrVersionNotFound
+	}
+	return found, nil
+}
+
 // Now returns current status (now/status)
 func (s *Store) Now() (current int, latest int, hasChanges bool, err error) {
 	if !s.Exists() {
@@ -306,23 +717,83 @@ func (s *Store) Now() (current int, latest int, hasChanges bool, err error) {
 		return
 	}
 
-	current, err = s.Repo.GetCurrentTag()
-	if err != nil {
-		current = latest // Default to latest if no current tag
+	current, err = s.Position()
+	if err != nil || current < 1 {
+		current = latest // Default to latest if no position is known
 		err = nil
 	}
 
-	hasChanges, err = s.Repo.HasChanges()
+	if pending, perr := s.Pending(); perr == nil && len(pending) > 0 {
+		// Compare with what will be committed, not the older history
+		var content []byte
+		if content, err = s.Repo.ReadWorkFile(); err != nil {
+			if errors.Is(err, os.ErrNotExist) {
+				return current, latest, true, nil
+			}
+			return
+		}
+		hasChanges, err = s.changedSincePending(content)
+		return
+	}
+
+	hasChanges, err = s.changedSince(current)
 	return
 }
 
 // Delete removes the store (done/untrack)
 func (s *Store) Delete() error {
-	if s.Global {
+	unlock, err := s.lock()
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	switch {
+	case s.Memory:
+		s.Repo.Discard()
+	case !s.IsDefaultProfile():
+		err = os.RemoveAll(s.GitDir)
+	case s.Global:
 		// Remove the entire hash directory for global stores
-		return os.RemoveAll(s.OopsDirPath())
+		err = os.RemoveAll(s.OopsDirPath())
+		if err == nil {
+			err = s.unindexPath()
+		}
+	default:
+		err = os.RemoveAll(s.GitDir)
+	}
+	if err != nil {
+		return err
 	}
-	return os.RemoveAll(s.GitDir)
+	s.emit(events.StoreDeleted, 0, "")
+	return nil
+}
+
+// Kind describes where the store keeps its history
+func (s *Store) Kind() string {
+	switch {
+	case s.Memory:
+		return "memory"
+	case s.Global:
+		return "global"
+	default:
+		return "local"
+	}
+}
+
+// emit publishes an event about this store
+func (s *Store) emit(typ string, num int, message string) {
+	e := events.Event{
+		Type:     typ,
+		File:     s.FilePath,
+		Store:    s.Kind(),
+		Snapshot: num,
+		Message:  message,
+	}
+	if !s.IsDefaultProfile() {
+		e.Profile = s.Profile
+	}
+	events.Emit(e)
 }
 
 // saveMetadata saves file path metadata for global stores
@@ -331,7 +802,10 @@ func (s *Store) saveMetadata() error {
 		return nil
 	}
 	metaFile := filepath.Join(s.OopsDirPath(), "metadata.txt")
-	return os.WriteFile(metaFile, []byte(s.FilePath), 0644)
+	if err := os.WriteFile(metaFile, []byte(s.FilePath), 0644); err != nil {
+		return err
+	}
+	return s.saveVolume()
 }
 
 // GlobalStoreInfo represents info about a globally tracked file
@@ -339,6 +813,10 @@ type GlobalStoreInfo struct {
 	FilePath string
 	FileName string
 	HashDir  string
+
+	// Removable is set for files on removable media, which may simply be
+	// unplugged when the file is missing
+	Removable bool
 }
 
 // ListGlobalStores returns all globally tracked files
@@ -371,10 +849,15 @@ func ListGlobalStores() ([]GlobalStoreInfo, error) {
 		}
 
 		filePath := string(data)
+		if validateMetadataPath(filePath) != nil {
+			continue // Skip corrupt or hostile metadata
+		}
+		_, removable := readVolume(filepath.Join(globalDir, entry.Name()))
 		stores = append(stores, GlobalStoreInfo{
-			FilePath: filePath,
-			FileName: filepath.Base(filePath),
-			HashDir:  entry.Name(),
+			FilePath:  filePath,
+			FileName:  filepath.Base(filePath),
+			HashDir:   entry.Name(),
+			Removable: removable,
 		})
 	}
 
@@ -3,7 +3,12 @@ package store
 import (
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/go-git/go-billy/v5/memfs"
+	"github.com/go-git/go-billy/v5/util"
 )
 
 func setupTestFile(t *testing.T, content string) (string, func()) {
@@ -47,6 +52,24 @@ func TestStoreInitialize(t *testing.T) {
 	}
 }
 
+func TestStoreInitializeWithMessage(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "content")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	if err := s.InitializeWithMessage("before edit"); err != nil {
+		t.Fatal(err)
+	}
+
+	history, err := s.History()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || history[0].Message != "before edit" {
+		t.Errorf("history = %+v, want snapshot #1 'before edit'", history)
+	}
+}
+
 func TestStoreInitializeAlreadyTracked(t *testing.T) {
 	testFile, cleanup := setupTestFile(t, "content")
 	defer cleanup()
@@ -156,6 +179,36 @@ func TestStoreBackUncommittedChanges(t *testing.T) {
 	}
 }
 
+func TestStoreContent(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1 content")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	os.WriteFile(testFile, []byte("v2 content"), 0644)
+	s.Save("v2")
+	os.WriteFile(testFile, []byte("unsaved"), 0644)
+
+	content, err := s.Content(1)
+	if err != nil {
+		t.Fatalf("Content failed: %v", err)
+	}
+	if string(content) != "v1 content" {
+		t.Errorf("Content(1) = %q, want %q", content, "v1 content")
+	}
+
+	// The working file is left alone
+	working, _ := os.ReadFile(testFile)
+	if string(working) != "unsaved" {
+		t.Errorf("Working file = %q, want %q", working, "unsaved")
+	}
+
+	if _, err := s.Content(999); err != ErrVersionNotFound {
+		t.Errorf("Expected ErrVersionNotFound, got %v", err)
+	}
+}
+
 func TestStoreUndo(t *testing.T) {
 	testFile, cleanup := setupTestFile(t, "original")
 	defer cleanup()
@@ -197,6 +250,35 @@ func TestStoreChanges(t *testing.T) {
 	}
 }
 
+func TestStoreChangesAgainst(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "one\ntwo\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("one\nthree\n"), 0644)
+
+	other := filepath.Join(t.TempDir(), "other.txt")
+	os.WriteFile(other, []byte("one\nthree\n"), 0644)
+
+	if diff, err := s.ChangesAgainst(other); err != nil || diff != "" {
+		t.Errorf("ChangesAgainst of the same content = %q, %v", diff, err)
+	}
+	diff, err := s.ChangesAgainst(other, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(diff, "+++ "+other) || !strings.Contains(diff, "-two") || !strings.Contains(diff, "+three") {
+		t.Errorf("ChangesAgainst(1) = %q", diff)
+	}
+	if _, err := s.ChangesAgainst(other, 9); err != ErrVersionNotFound {
+		t.Errorf("ChangesAgainst of a missing snapshot = %v", err)
+	}
+	if _, err := s.ChangesAgainst(filepath.Join(t.TempDir(), "missing.txt")); err == nil {
+		t.Error("ChangesAgainst of a missing file succeeded")
+	}
+}
+
 func TestStoreHistory(t *testing.T) {
 	testFile, cleanup := setupTestFile(t, "v1")
 	defer cleanup()
@@ -477,3 +559,114 @@ func TestGlobalStoreDelete(t *testing.T) {
 		t.Error("Hash directory should be removed after Delete")
 	}
 }
+
+// --- Memory Store Tests ---
+
+func setupMemoryStore(t *testing.T, content string) *Store {
+	fs := memfs.New()
+	if err := util.WriteFile(fs, "test.txt", []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMemoryStore(fs, "test.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestMemoryStoreWorkflow(t *testing.T) {
+	s := setupMemoryStore(t, "v1 content")
+
+	if err := s.Initialize(); err != nil {
+		t.Fatalf("Initialize failed: %v", err)
+	}
+	if s.OopsDirPath() != "" {
+		t.Errorf("OopsDirPath = %q, want empty", s.OopsDirPath())
+	}
+
+	fs := s.Repo.WorkFS()
+	util.WriteFile(fs, "test.txt", []byte("v2 content"), 0644)
+
+	snapshot, err := s.Save("v2")
+	if err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if snapshot.Number != 2 {
+		t.Errorf("Snapshot number = %d, want 2", snapshot.Number)
+	}
+
+	if err := s.Back(1, false); err != nil {
+		t.Fatalf("Back failed: %v", err)
+	}
+	content, _ := util.ReadFile(fs, "test.txt")
+	if string(content) != "v1 content" {
+		t.Errorf("Content = %q, want %q", string(content), "v1 content")
+	}
+
+	if err := s.Delete(); err != nil {
+		t.Fatalf("Delete failed: %v", err)
+	}
+	if s.Exists() {
+		t.Error("Store should not exist after Delete")
+	}
+}
+
+func TestMemoryStoreMissingFile(t *testing.T) {
+	s, err := NewMemoryStore(memfs.New(), "missing.txt")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Initialize(); err == nil {
+		t.Error("Expected error initializing store for missing file")
+	}
+}
+
+func TestStoreSnapshotBefore(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	yesterday := time.Now().Add(-24 * time.Hour)
+	err := s.rebuildHistory([]historyEntry{
+		{Snapshot{Number: 1, Message: "old", Timestamp: yesterday.Add(-time.Hour)}, []byte("v1")},
+		{Snapshot{Number: 2, Message: "last yesterday", Timestamp: yesterday}, []byte("v2")},
+		{Snapshot{Number: 3, Message: "today", Timestamp: time.Now()}, []byte("v3")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	snap, err := s.SnapshotBefore(yesterday.Add(time.Minute))
+	if err != nil {
+		t.Fatalf("SnapshotBefore failed: %v", err)
+	}
+	if snap.Number != 2 {
+		t.Errorf("SnapshotBefore = #%d, want #2", snap.Number)
+	}
+
+	if _, err := s.SnapshotBefore(yesterday.Add(-2 * time.Hour)); err != ErrVersionNotFound {
+		t.Errorf("SnapshotBefore(too early) = %v, want ErrVersionNotFound", err)
+	}
+}
+
+func TestStoreSaveAs(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2\n"), 0644)
+	s.SaveAs("auto", OriginAuto)
+	os.WriteFile(testFile, []byte("v3\n"), 0644)
+	s.Save("by hand")
+
+	want := map[int]Origin{1: OriginManual, 2: OriginAuto, 3: OriginManual}
+	history, _ := s.History()
+	for _, snap := range history {
+		if got := s.Origin(snap); got != want[snap.Number] {
+			t.Errorf("Origin(#%d) = %s, want %s", snap.Number, got, want[snap.Number])
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package store
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// syncMeta is the metadata file holding the store's sync settings
+const syncMeta = "sync.json"
+
+// failedPushMeta is the metadata file recording an automatic push that
+// failed, which marks the store for the daemon to retry
+const failedPushMeta = "push-failed.json"
+
+// SyncSettings controls how the store is kept in step with its remotes
+type SyncSettings struct {
+	AutoPush bool   `json:"auto_push"`        // push after every snapshot
+	Remote   string `json:"remote,omitempty"` // remote to push to, "" as for ResolveRemote
+}
+
+// FailedPush is an automatic push that has not succeeded yet
+type FailedPush struct {
+	Remote   string    `json:"remote"`
+	Since    time.Time `json:"since"` // time of the first failure
+	Attempts int       `json:"attempts"`
+	Error    string    `json:"error"`
+}
+
+// SyncSettings returns the store's sync settings; all off if none are set
+func (s *Store) SyncSettings() SyncSettings {
+	var settings SyncSettings
+	if data, err := s.Repo.ReadMeta(syncMeta); err == nil {
+		json.Unmarshal(data, &settings)
+	}
+	return settings
+}
+
+// SetSyncSettings replaces the store's sync settings. Turning auto-push
+// on needs the remote to exist; turning it off forgets a failed push.
+func (s *Store) SetSyncSettings(settings SyncSettings) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	if !settings.AutoPush {
+		if err := s.Repo.RemoveMeta(failedPushMeta); err != nil {
+			return err
+		}
+		return s.Repo.RemoveMeta(syncMeta)
+	}
+	if _, err := s.ResolveRemote(settings.Remote); err != nil {
+		return err
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(syncMeta, data)
+}
+
+// FailedPush returns the automatic push waiting to be retried, or nil
+func (s *Store) FailedPush() *FailedPush {
+	data, err := s.Repo.ReadMeta(failedPushMeta)
+	if err != nil {
+		return nil
+	}
+	var failed FailedPush
+	if json.Unmarshal(data, &failed) != nil {
+		return nil
+	}
+	return &failed
+}
+
+// RecordPush records the outcome of an automatic push to remote: a
+// failure is kept for retrying, and a success clears it
+func (s *Store) RecordPush(remote string, pushErr error) error {
+	if pushErr == nil {
+		return s.Repo.RemoveMeta(failedPushMeta)
+	}
+	failed := FailedPush{Remote: remote, Since: time.Now()}
+	if prev := s.FailedPush(); prev != nil {
+		failed.Since, failed.Attempts = prev.Since, prev.Attempts
+	}
+	failed.Attempts++
+	failed.Error = pushErr.Error()
+	data, err := json.Marshal(failed)
+	if err != nil {
+		return err
+	}
+	return s.Repo.WriteMeta(failedPushMeta, data)
+}
+
+// FailedPushes returns the tracked stores, profiles included, whose
+// automatic push failed and is waiting to be retried
+func FailedPushes() ([]*Store, error) {
+	tracked, err := ListTrackedFiles()
+	if err != nil {
+		return nil, err
+	}
+	var stores []*Store
+	for _, file := range tracked {
+		s, err := NewStoreWithOptions(file.FilePath, StoreOptions{Global: file.Global, Profile: DefaultProfile})
+		if err != nil || !s.Exists() {
+			continue
+		}
+		candidates := []*Store{s}
+		profiles, _ := s.Profiles()
+		for _, name := range profiles {
+			if p, err := s.OpenProfile(name); err == nil && p.Exists() {
+				candidates = append(candidates, p)
+			}
+		}
+		for _, c := range candidates {
+			if c.SyncSettings().AutoPush && c.FailedPush() != nil {
+				stores = append(stores, c)
+			}
+		}
+	}
+	return stores, nil
+}
@@ -0,0 +1,68 @@
+package store
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestSyncSettings(t *testing.T) {
+	file, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(file)
+	s.Initialize()
+
+	if err := s.SetSyncSettings(SyncSettings{AutoPush: true}); err != ErrNoRemote {
+		t.Fatalf("auto-push without a remote = %v, want ErrNoRemote", err)
+	}
+	s.SetRemote("backup", filepath.Join(t.TempDir(), "backup.git"), RemoteAuth{})
+	if err := s.SetSyncSettings(SyncSettings{AutoPush: true, Remote: "backup"}); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.SyncSettings(); !got.AutoPush || got.Remote != "backup" {
+		t.Errorf("SyncSettings = %+v", got)
+	}
+
+	// Removing the remote turns auto-push off
+	s.RecordPush("backup", errors.New("offline"))
+	if err := s.RemoveRemote("backup"); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.SyncSettings(); got.AutoPush {
+		t.Errorf("SyncSettings after removing the remote = %+v", got)
+	}
+	if s.FailedPush() != nil {
+		t.Error("the failed push of a removed remote is kept")
+	}
+}
+
+func TestFailedPushes(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	file, _ := setupTestFile(t, "one\n")
+	s, _ := NewStore(file)
+	s.Initialize()
+	s.RegisterLocal()
+	s.SetRemote("backup", filepath.Join(t.TempDir(), "backup.git"), RemoteAuth{})
+	s.SetSyncSettings(SyncSettings{AutoPush: true})
+
+	s.RecordPush("backup", errors.New("network down"))
+	s.RecordPush("backup", errors.New("still down"))
+	failed := s.FailedPush()
+	if failed == nil || failed.Attempts != 2 || failed.Error != "still down" {
+		t.Fatalf("FailedPush = %+v", failed)
+	}
+
+	stores, err := FailedPushes()
+	if err != nil || len(stores) != 1 || stores[0].FilePath != file {
+		t.Fatalf("FailedPushes = %v, %v", stores, err)
+	}
+
+	if err := s.RecordPush("backup", nil); err != nil {
+		t.Fatal(err)
+	}
+	if stores, _ := FailedPushes(); len(stores) != 0 {
+		t.Errorf("FailedPushes after a successful push = %v", stores)
+	}
+}
@@ -0,0 +1,59 @@
+package store
+
+// templateMeta is the metadata file recording the template a file was created from
+const templateMeta = "template"
+
+// SetTemplate records the template the tracked file was created from
+func (s *Store) SetTemplate(name string) error {
+	return s.Repo.WriteMeta(templateMeta, []byte(name))
+}
+
+// Template returns the template the tracked file was created from, or ""
+func (s *Store) Template() string {
+	data, err := s.Repo.ReadMeta(templateMeta)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// templateSnapshot is the snapshot reset-to-template restores
+const templateSnapshot = 1
+
+// ResetToTemplate restores the file to snapshot #1, for files whose first
+// snapshot is a canonical baseline, e.g. a config put back on every lab
+// machine. With save, changes since the current position are saved as a
+// snapshot first and stay reachable with Forward. Returns that snapshot,
+// nil if nothing was saved.
+func (s *Store) ResetToTemplate(save bool) (*Snapshot, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if !s.Repo.HasTag(versionTag(templateSnapshot)) {
+		return nil, ErrVersionNotFound
+	}
+
+	var saved *Snapshot
+	if save {
+		position, err := s.Position()
+		if err != nil {
+			return nil, err
+		}
+		changed, err := s.changedSince(position)
+		if err != nil {
+			return nil, err
+		}
+		if changed {
+			saved, err = s.save("Before reset to template", true)
+			if err != nil && err != ErrNoChanges {
+				return nil, err
+			}
+		}
+	}
+	return saved, s.Back(templateSnapshot, true)
+}
@@ -0,0 +1,60 @@
+package store
+
+import (
+	"os"
+	"testing"
+)
+
+func TestTemplateMetadata(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "# Week")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	if got := s.Template(); got != "" {
+		t.Errorf("Template = %q before SetTemplate, want empty", got)
+	}
+
+	if err := s.SetTemplate("weekly-report"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A fresh Store for the same file sees the recorded template
+	reopened, _ := NewStore(testFile)
+	if got := reopened.Template(); got != "weekly-report" {
+		t.Errorf("Template = %q, want weekly-report", got)
+	}
+}
+
+func TestResetToTemplate(t *testing.T) {
+	s, testFile := setupPositionStore(t)
+
+	os.WriteFile(testFile, []byte("student edits"), 0644)
+	saved, err := s.ResetToTemplate(true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if saved == nil || saved.Number != 5 {
+		t.Fatalf("saved = %+v, want snapshot #5", saved)
+	}
+	assertContent(t, testFile, "v1")
+
+	// The pre-reset state is one step forward
+	if num, _ := s.Forward(); num != 5 {
+		t.Errorf("Forward = #%d, want #5", num)
+	}
+	assertContent(t, testFile, "student edits")
+
+	// Without save, or with nothing to save, no snapshot is added
+	if saved, err := s.ResetToTemplate(true); err != nil || saved != nil {
+		t.Errorf("ResetToTemplate on a saved file = %+v, %v", saved, err)
+	}
+	os.WriteFile(testFile, []byte("more edits"), 0644)
+	if saved, _ := s.ResetToTemplate(false); saved != nil {
+		t.Errorf("ResetToTemplate(false) saved %+v", saved)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 5 {
+		t.Errorf("latest = %d, want 5", latest)
+	}
+	assertContent(t, testFile, "v1")
+}
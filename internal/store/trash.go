@@ -0,0 +1,228 @@
+package store
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Stores removed by gc are moved to the trash in the global directory
+// rather than deleted, one directory per run, and kept for TrashGrace so
+// the most recent run can be undone
+const (
+	trashDirName     = "trash"
+	trashJournalFile = "journal.json"
+	TrashGrace       = 7 * 24 * time.Hour
+)
+
+var ErrTrashEmpty = errors.New("nothing to restore")
+
+// TrashEntry records one store moved to the trash
+type TrashEntry struct {
+	Path     string `json:"path"`      // where the store directory was
+	FilePath string `json:"file_path"` // the file it tracked
+	Size     int64  `json:"size"`      // bytes on disk
+}
+
+// TrashRun is the set of stores removed by one gc run
+type TrashRun struct {
+	Time    time.Time    `json:"time"`
+	Entries []TrashEntry `json:"entries"`
+
+	dir string
+}
+
+func trashDir() (string, error) {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(globalDir, trashDirName), nil
+}
+
+// NewTrashRun starts recording a gc run. Nothing is written until the
+// first store is removed.
+func NewTrashRun() (*TrashRun, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	return &TrashRun{
+		Time: now,
+		dir:  filepath.Join(dir, strconv.FormatInt(now.UnixNano(), 10)),
+	}, nil
+}
+
+// Remove moves the store directory at path to the trash. The journal is
+// written before each move, so an interrupted run can still be undone.
+func (r *TrashRun) Remove(path, filePath string) error {
+	entry := TrashEntry{Path: path, FilePath: filePath, Size: dirSize(path)}
+	r.Entries = append(r.Entries, entry)
+	if err := r.save(); err != nil {
+		r.Entries = r.Entries[:len(r.Entries)-1]
+		return err
+	}
+	if err := moveDir(path, r.entryDir(len(r.Entries)-1)); err != nil {
+		r.Entries = r.Entries[:len(r.Entries)-1]
+		r.save()
+		return err
+	}
+	return nil
+}
+
+// Size returns the bytes the run moved to the trash
+func (r *TrashRun) Size() int64 {
+	var total int64
+	for _, e := range r.Entries {
+		total += e.Size
+	}
+	return total
+}
+
+func (r *TrashRun) entryDir(i int) string {
+	return filepath.Join(r.dir, strconv.Itoa(i))
+}
+
+func (r *TrashRun) save() error {
+	if err := os.MkdirAll(r.dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(r.dir, trashJournalFile), data, 0644)
+}
+
+// trashRuns returns the recorded runs, oldest first
+func trashRuns() ([]*TrashRun, error) {
+	dir, err := trashDir()
+	if err != nil {
+		return nil, err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var runs []*TrashRun
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		run := &TrashRun{dir: filepath.Join(dir, entry.Name())}
+		data, err := os.ReadFile(filepath.Join(run.dir, trashJournalFile))
+		if err != nil || json.Unmarshal(data, run) != nil {
+			continue
+		}
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool { return runs[i].Time.Before(runs[j].Time) })
+	return runs, nil
+}
+
+// LastTrashRun returns the most recent gc run still in the trash
+func LastTrashRun() (*TrashRun, error) {
+	runs, err := trashRuns()
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) == 0 {
+		return nil, ErrTrashEmpty
+	}
+	return runs[len(runs)-1], nil
+}
+
+// Restore moves the run's stores back where they were. A store whose
+// place has been taken again is left in the trash and reported in the
+// error; the run is dropped once everything is restored.
+func (r *TrashRun) Restore() ([]TrashEntry, error) {
+	var restored, left []TrashEntry
+	var errs []error
+	for i, e := range r.Entries {
+		src := r.entryDir(i)
+		if _, err := os.Stat(src); os.IsNotExist(err) {
+			continue // never moved, or restored before
+		}
+		if _, err := os.Stat(e.Path); err == nil {
+			errs = append(errs, fmt.Errorf("%s exists again", e.Path))
+			left = append(left, e)
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(e.Path), 0755); err != nil {
+			errs = append(errs, err)
+			left = append(left, e)
+			continue
+		}
+		if err := moveDir(src, e.Path); err != nil {
+			errs = append(errs, err)
+			left = append(left, e)
+			continue
+		}
+		restored = append(restored, e)
+	}
+
+	if len(left) == 0 {
+		os.RemoveAll(r.dir)
+	}
+	return restored, errors.Join(errs...)
+}
+
+// PurgeTrash deletes gc runs older than TrashGrace, returning how many
+// were deleted
+func PurgeTrash(now time.Time) (int, error) {
+	runs, err := trashRuns()
+	if err != nil {
+		return 0, err
+	}
+	purged := 0
+	for _, run := range runs {
+		if now.Sub(run.Time) < TrashGrace {
+			continue
+		}
+		if err := os.RemoveAll(run.dir); err != nil {
+			return purged, err
+		}
+		purged++
+	}
+	return purged, nil
+}
+
+// moveDir moves a directory, copying it when it is on another filesystem
+func moveDir(src, dst string) error {
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	if err := copyTree(src, dst); err != nil {
+		os.RemoveAll(dst)
+		return err
+	}
+	return os.RemoveAll(src)
+}
+
+// dirSize returns the bytes of the files under dir
+func dirSize(dir string) int64 {
+	var size int64
+	filepath.WalkDir(dir, func(_ string, d fs.DirEntry, err error) error {
+		if err == nil && d.Type().IsRegular() {
+			if info, err := d.Info(); err == nil {
+				size += info.Size()
+			}
+		}
+		return nil
+	})
+	return size
+}
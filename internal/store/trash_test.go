@@ -0,0 +1,91 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestTrashUndo(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+	s, _ := NewStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	run, err := NewTrashRun()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := run.Remove(s.GitDir, testFile); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if s.Exists() {
+		t.Fatal("store should be gone after Remove")
+	}
+	if run.Size() == 0 {
+		t.Error("the run should record the store's size")
+	}
+
+	last, err := LastTrashRun()
+	if err != nil || len(last.Entries) != 1 || last.Entries[0].FilePath != testFile {
+		t.Fatalf("LastTrashRun = %+v, %v", last, err)
+	}
+	restored, err := last.Restore()
+	if err != nil || len(restored) != 1 {
+		t.Fatalf("Restore = %+v, %v", restored, err)
+	}
+	if snaps, _ := s.History(); len(snaps) != 1 {
+		t.Errorf("restored store has %d snapshots, want 1", len(snaps))
+	}
+	if _, err := LastTrashRun(); err != ErrTrashEmpty {
+		t.Errorf("trash after restore = %v, want ErrTrashEmpty", err)
+	}
+}
+
+func TestTrashRestoreKeepsTakenPlaces(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := filepath.Join(t.TempDir(), "store")
+	os.MkdirAll(dir, 0755)
+	run, _ := NewTrashRun()
+	run.Remove(dir, "/gone/file.txt")
+	os.MkdirAll(dir, 0755) // something new lives there now
+
+	last, _ := LastTrashRun()
+	if restored, err := last.Restore(); err == nil || len(restored) != 0 {
+		t.Errorf("Restore = %+v, %v; want an error and nothing restored", restored, err)
+	}
+	if _, err := LastTrashRun(); err != nil {
+		t.Error("a run not fully restored should stay in the trash")
+	}
+}
+
+func TestPurgeTrash(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	dir := filepath.Join(t.TempDir(), "store")
+	os.MkdirAll(dir, 0755)
+	run, _ := NewTrashRun()
+	run.Remove(dir, "/gone/file.txt")
+
+	if n, _ := PurgeTrash(time.Now()); n != 0 {
+		t.Errorf("PurgeTrash purged %d fresh runs", n)
+	}
+	if n, _ := PurgeTrash(time.Now().Add(TrashGrace + time.Hour)); n != 1 {
+		t.Errorf("PurgeTrash purged %d runs, want 1", n)
+	}
+	if _, err := LastTrashRun(); err != ErrTrashEmpty {
+		t.Errorf("trash after purge = %v, want ErrTrashEmpty", err)
+	}
+}
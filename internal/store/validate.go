@@ -0,0 +1,117 @@
+package store
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+// ValidationError is returned when the validate hook rejects the content
+// of a snapshot, which is then not saved
+type ValidationError struct {
+	Command string // hook command as run
+	Output  string // what the tool printed
+	Err     error  // how it failed, usually an *exec.ExitError
+}
+
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("validate hook %q failed: %v", e.Command, e.Err)
+}
+
+func (e *ValidationError) Unwrap() error { return e.Err }
+
+// SetValidateHook sets a command every snapshot must pass before it is
+// saved, e.g. "yamllint {}" or "nginx -t -c {}"; "" removes it. The
+// command is split on spaces and run without a shell, with {} replaced
+// by a file holding the candidate content, or that file appended if the
+// command has no {}.
+//
+// Hooks are kept in the user's config, keyed by the file's path, and not
+// with the history: a history can come from someone else, in a committed
+// .oops folder, a bundle or a backup, and must not bring commands along.
+func (s *Store) SetValidateHook(command string) error {
+	if !s.Exists() {
+		return ErrNotTracked
+	}
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	command = strings.TrimSpace(command)
+	cfg.SetValidateHook(normalizePath(s.FilePath), command)
+	if err := cfg.Save(); err != nil {
+		return err
+	}
+	s.validateHook = command
+	return nil
+}
+
+// ValidateHook returns the validate hook command, or "" if none is set
+func (s *Store) ValidateHook() string {
+	return s.validateHook
+}
+
+// Validate runs the validate hook against content, returning a
+// *ValidationError if the hook rejects it. Without a hook all content
+// passes.
+func (s *Store) Validate(content []byte) error {
+	args := strings.Fields(s.ValidateHook())
+	if len(args) == 0 {
+		return nil
+	}
+
+	// Keep the extension, which tools use to tell the format
+	tmp, err := os.CreateTemp("", "oops-validate-*"+filepath.Ext(s.FileName))
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(content); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+
+	replaced := false
+	for i, arg := range args {
+		if strings.Contains(arg, "{}") {
+			args[i] = strings.ReplaceAll(arg, "{}", tmp.Name())
+			replaced = true
+		}
+	}
+	if !replaced {
+		args = append(args, tmp.Name())
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	if !s.Memory {
+		cmd.Dir = s.BaseDir // relative includes resolve as for the file
+	}
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		return &ValidationError{
+			Command: strings.Join(args, " "),
+			Output:  strings.TrimSpace(string(out)),
+			Err:     err,
+		}
+	}
+	return nil
+}
+
+// validateWorkFile runs the validate hook against the work file
+func (s *Store) validateWorkFile() error {
+	if s.ValidateHook() == "" {
+		return nil
+	}
+	content, err := s.Repo.ReadWorkFile()
+	if err != nil {
+		return err
+	}
+	return s.Validate(content)
+}
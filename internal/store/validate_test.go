@@ -0,0 +1,97 @@
+package store
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+func TestValidateHook(t *testing.T) {
+	if _, err := exec.LookPath("grep"); err != nil {
+		t.Skip("grep not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	testFile, cleanup := setupTestFile(t, "ok: 1\n")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	if err := s.SetValidateHook("grep -q ok: {}"); err != nil {
+		t.Fatalf("SetValidateHook failed: %v", err)
+	}
+
+	os.WriteFile(testFile, []byte("broken\n"), 0644)
+	_, err := s.Save("bad")
+	var invalid *ValidationError
+	if !errors.As(err, &invalid) {
+		t.Fatalf("Save of rejected content = %v, want ValidationError", err)
+	}
+	if _, err := s.SaveDeferred("bad"); !errors.As(err, &invalid) {
+		t.Errorf("SaveDeferred of rejected content = %v, want ValidationError", err)
+	}
+	if latest, _ := s.GetLatestVersion(); latest != 1 {
+		t.Errorf("latest = %d after rejected saves, want 1", latest)
+	}
+
+	os.WriteFile(testFile, []byte("ok: 2\n"), 0644)
+	if _, err := s.Save("good"); err != nil {
+		t.Errorf("Save of accepted content failed: %v", err)
+	}
+
+	// The copy is appended when the command has no {}
+	s.SetValidateHook("grep -q ok:")
+	if err := s.Validate([]byte("nope\n")); !errors.As(err, &invalid) {
+		t.Errorf("Validate without {} = %v, want ValidationError", err)
+	}
+
+	s.SetValidateHook("")
+	if s.ValidateHook() != "" {
+		t.Error("hook should be removed")
+	}
+	if err := s.Validate([]byte("nope\n")); err != nil {
+		t.Errorf("Validate without a hook = %v, want nil", err)
+	}
+}
+
+func TestValidateHookStaysWithUser(t *testing.T) {
+	if _, err := exec.LookPath("false"); err != nil {
+		t.Skip("false not available")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	// A command left in a history that came from someone else is not run
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	s.Repo.WriteMeta("validate", []byte("false"))
+	reopened, _ := NewStore(testFile)
+	if hook := reopened.ValidateHook(); hook != "" {
+		t.Errorf("hook read from the history: %q", hook)
+	}
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	if _, err := reopened.Save(""); err != nil {
+		t.Errorf("Save ran a command from the history: %v", err)
+	}
+
+	// The user's hook follows the file when it is moved
+	if err := reopened.SetValidateHook("false"); err != nil {
+		t.Fatal(err)
+	}
+	moved, err := reopened.Move(filepath.Join(filepath.Dir(testFile), "renamed.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if hook := moved.ValidateHook(); hook != "false" {
+		t.Errorf("hook after Move = %q, want %q", hook, "false")
+	}
+	if again, _ := NewStore(testFile); again.ValidateHook() != "" {
+		t.Error("hook left on the old path")
+	}
+}
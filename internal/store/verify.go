@@ -0,0 +1,246 @@
+package store
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// HealthState classifies a store's condition, from best to worst
+type HealthState int
+
+const (
+	HealthOK HealthState = iota
+	HealthStaleLock
+	HealthMissingMetadata
+	HealthCorrupt
+)
+
+func (h HealthState) String() string {
+	switch h {
+	case HealthOK:
+		return "OK"
+	case HealthStaleLock:
+		return "stale lock"
+	case HealthMissingMetadata:
+		return "missing metadata"
+	default:
+		return "corrupt"
+	}
+}
+
+// Health is the result of Verify
+type Health struct {
+	State  HealthState
+	Detail string // what was found, empty when OK
+}
+
+// staleLockAge is how old a lock file must be before it is assumed to be
+// left behind by a crashed process
+const staleLockAge = 10 * time.Minute
+
+// Verify runs a lightweight consistency check of the store: every snapshot
+// must resolve to a commit holding the file's content, global stores must
+// have matching metadata, and no lock file may be left behind. The most
+// severe problem found is reported.
+func (s *Store) Verify() Health {
+	if s.Memory {
+		return Health{State: HealthOK}
+	}
+	if !s.Exists() {
+		return Health{State: HealthCorrupt, Detail: "repository cannot be opened"}
+	}
+	if err := s.Repo.Verify(); err != nil {
+		return Health{State: HealthCorrupt, Detail: err.Error()}
+	}
+
+	if s.Global {
+		data, err := os.ReadFile(filepath.Join(s.OopsDirPath(), "metadata.txt"))
+		switch {
+		case err != nil:
+			return Health{State: HealthMissingMetadata, Detail: "metadata.txt not found"}
+		case string(data) != s.FilePath:
+			return Health{State: HealthMissingMetadata, Detail: "metadata.txt names " + string(data)}
+		}
+	}
+
+	if lock := s.staleLock(time.Now()); lock != "" {
+		return Health{State: HealthStaleLock, Detail: lock}
+	}
+
+	return Health{State: HealthOK}
+}
+
+var errNotRepaired = errors.New("not repaired")
+
+// Problem is something Fsck found wrong with a store
+type Problem struct {
+	Check    string // what failed: "objects", "numbering", "metadata", "checkout" or "lock"
+	Detail   string
+	Repaired bool
+}
+
+// Fsck checks the store thoroughly where Verify is quick: every object
+// must match its hash and every snapshot must read back, snapshots must
+// be numbered v1..vN without gaps, a global store must have its
+// metadata.txt, and the history's own copy of the file must match its
+// latest commit. With repair, what can be fixed is: snapshots are
+// renumbered, metadata.txt is rewritten, the copy is reset and stale
+// locks are removed. Damaged objects can only be reported.
+func (s *Store) Fsck(repair bool) ([]Problem, error) {
+	if !s.Exists() {
+		return nil, ErrNotTracked
+	}
+	unlock, err := s.lock()
+	if err != nil {
+		return nil, err
+	}
+	defer unlock()
+	if s.Memory {
+		return nil, nil
+	}
+	if _, err := s.FlushPending(); err != nil {
+		return nil, err
+	}
+
+	var problems []Problem
+	found := func(check, detail string, fix func() error) {
+		p := Problem{Check: check, Detail: detail}
+		if repair && fix != nil {
+			if err := fix(); err != nil {
+				p.Detail += fmt.Sprintf(" (repair failed: %v)", err)
+			} else {
+				p.Repaired = true
+			}
+		}
+		problems = append(problems, p)
+	}
+
+	damaged, err := s.Repo.CheckObjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, detail := range damaged {
+		found("objects", detail, nil)
+	}
+
+	numbering, err := s.CheckNumbering()
+	if err != nil {
+		return nil, err
+	}
+	if len(numbering) > 0 {
+		// One renumbering fixes every numbering problem
+		var renumbered error = errNotRepaired
+		if repair {
+			_, renumbered = s.Renumber()
+		}
+		for _, detail := range numbering {
+			p := Problem{Check: "numbering", Detail: detail, Repaired: renumbered == nil}
+			if repair && renumbered != nil {
+				p.Detail += fmt.Sprintf(" (repair failed: %v)", renumbered)
+			}
+			problems = append(problems, p)
+		}
+	}
+
+	if s.Global {
+		data, err := os.ReadFile(filepath.Join(s.OopsDirPath(), "metadata.txt"))
+		switch {
+		case err != nil:
+			found("metadata", "metadata.txt not found", s.saveMetadata)
+		case string(data) != s.FilePath:
+			found("metadata", "metadata.txt names "+string(data), s.saveMetadata)
+		}
+	}
+
+	if s.Repo.HasCheckout() {
+		found("checkout", "the history keeps a second copy of the file, left by an older version", s.Repo.RemoveCheckout)
+	}
+
+	if lock := s.staleLock(time.Now()); lock != "" {
+		found("lock", lock+" was left behind", func() error {
+			return os.Remove(filepath.Join(filepath.Dir(s.GitDir), lock))
+		})
+	}
+	return problems, nil
+}
+
+// staleLock returns the path of a lock file older than staleLockAge inside
+// the store or beside it, relative to the directory holding GitDir, or ""
+// if there is none
+func (s *Store) staleLock(now time.Time) string {
+	dir := filepath.Dir(s.GitDir)
+	if info, err := os.Stat(s.lockPath()); err == nil && now.Sub(info.ModTime()) >= s.staleLockAge() {
+		return filepath.Base(s.lockPath())
+	}
+	var found string
+	filepath.WalkDir(s.GitDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !strings.HasSuffix(d.Name(), ".lock") {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil || now.Sub(info.ModTime()) < s.staleLockAge() {
+			return nil
+		}
+		found, _ = filepath.Rel(dir, path)
+		return filepath.SkipAll
+	})
+	return found
+}
+
+// ListUnlistedGlobalStores returns global store directories that hold a
+// history but have no usable metadata, so ListGlobalStores skips them.
+// FilePath is empty; FileName comes from the history directory name.
+func ListUnlistedGlobalStores() ([]GlobalStoreInfo, error) {
+	globalDir, err := GetGlobalOopsDir()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(globalDir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stores []GlobalStoreInfo
+	for _, entry := range entries {
+		if !entry.IsDir() || !isHashDirName(entry.Name()) {
+			continue
+		}
+		hashDir := filepath.Join(globalDir, entry.Name())
+		if data, err := os.ReadFile(filepath.Join(hashDir, "metadata.txt")); err == nil && validateMetadataPath(string(data)) == nil {
+			continue
+		}
+		subEntries, err := os.ReadDir(hashDir)
+		if err != nil {
+			continue
+		}
+		for _, sub := range subEntries {
+			if sub.IsDir() && strings.HasSuffix(sub.Name(), ".git") {
+				stores = append(stores, GlobalStoreInfo{
+					FileName: strings.TrimSuffix(sub.Name(), ".git"),
+					HashDir:  entry.Name(),
+				})
+			}
+		}
+	}
+
+	return stores, nil
+}
+
+// isHashDirName reports whether name has the form produced by hashFilePath
+func isHashDirName(name string) bool {
+	if len(name) != 16 {
+		return false
+	}
+	_, err := hex.DecodeString(name)
+	return err == nil
+}
@@ -0,0 +1,148 @@
+package store
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestVerifyOK(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	os.WriteFile(testFile, []byte("v2"), 0644)
+	s.Save("")
+
+	if h := s.Verify(); h.State != HealthOK {
+		t.Errorf("Verify = %v (%s), want OK", h.State, h.Detail)
+	}
+}
+
+func TestVerifyStaleLock(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	lock := filepath.Join(s.GitDir, ".git", "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	if h := s.Verify(); h.State != HealthOK {
+		t.Errorf("fresh lock: Verify = %v, want OK", h.State)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(lock, old, old)
+	if h := s.Verify(); h.State != HealthStaleLock {
+		t.Errorf("old lock: Verify = %v, want stale lock", h.State)
+	}
+}
+
+func TestVerifyCorrupt(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+
+	// Remove every loose object, losing the snapshot content
+	objects := filepath.Join(s.GitDir, ".git", "objects")
+	filepath.WalkDir(objects, func(path string, d fs.DirEntry, err error) error {
+		if err == nil && !d.IsDir() && !strings.Contains(path, "pack") {
+			os.Remove(path)
+		}
+		return nil
+	})
+
+	if h := s.Verify(); h.State != HealthCorrupt {
+		t.Errorf("Verify = %v, want corrupt", h.State)
+	}
+}
+
+func TestVerifyMissingMetadata(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	testFile := filepath.Join(home, "notes.txt")
+	os.WriteFile(testFile, []byte("v1"), 0644)
+	s, _ := NewGlobalStore(testFile)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+
+	os.Remove(filepath.Join(s.OopsDirPath(), "metadata.txt"))
+	if h := s.Verify(); h.State != HealthMissingMetadata {
+		t.Errorf("Verify = %v, want missing metadata", h.State)
+	}
+
+	unlisted, err := ListUnlistedGlobalStores()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(unlisted) != 1 || unlisted[0].FileName != "notes.txt" {
+		t.Errorf("unlisted = %+v, want notes.txt", unlisted)
+	}
+}
+
+func TestFsckRepairs(t *testing.T) {
+	testFile, cleanup := setupTestFile(t, "v1")
+	defer cleanup()
+
+	s, _ := NewStore(testFile)
+	s.Initialize()
+	for _, content := range []string{"v2", "v3"} {
+		os.WriteFile(testFile, []byte(content), 0644)
+		s.Save("")
+	}
+	if problems, err := s.Fsck(false); err != nil || len(problems) != 0 {
+		t.Fatalf("healthy store: Fsck = %v, %v", problems, err)
+	}
+
+	// A gap in the numbers, a damaged copy of the file and a stale lock
+	s.Repo.DeleteTag("v2")
+	os.WriteFile(filepath.Join(s.GitDir, "test.txt"), []byte("garbage"), 0644)
+	lock := filepath.Join(s.GitDir, ".git", "index.lock")
+	os.WriteFile(lock, nil, 0644)
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(lock, old, old)
+
+	problems, err := s.Fsck(false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	checks := map[string]bool{}
+	for _, p := range problems {
+		checks[p.Check] = true
+		if p.Repaired {
+			t.Errorf("%s repaired without repair", p.Check)
+		}
+	}
+	for _, check := range []string{"numbering", "checkout", "lock"} {
+		if !checks[check] {
+			t.Errorf("no %s problem found in %v", check, problems)
+		}
+	}
+
+	if problems, err = s.Fsck(true); err != nil {
+		t.Fatal(err)
+	}
+	for _, p := range problems {
+		if !p.Repaired {
+			t.Errorf("%s not repaired: %s", p.Check, p.Detail)
+		}
+	}
+	if problems, err := s.Fsck(false); err != nil || len(problems) != 0 {
+		t.Errorf("after repair: Fsck = %v, %v", problems, err)
+	}
+	for num, want := range map[int]string{2: "v2", 3: "v3"} {
+		if got, err := s.Content(num); err != nil || string(got) != want {
+			t.Errorf("#%d after renumbering = %q, %v; want %q", num, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package store
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// volumeMetaFile names the file in a global store's directory recording the
+// removable volume the tracked file lives on, as "label\npath"
+const volumeMetaFile = "volume.txt"
+
+// Volume locates a file on removable media independently of where the
+// media is mounted: by the volume's label and the path within it
+type Volume struct {
+	Label string
+	Path  string // slash-separated, relative to the volume root
+}
+
+// key returns the global store directory name for files on the volume.
+// It has the same form as hashFilePath, so tools listing stores treat
+// both alike.
+func (v Volume) key() string {
+	hash := sha256.Sum256([]byte("volume:" + v.Label + ":" + v.Path))
+	return hex.EncodeToString(hash[:8])
+}
+
+// removableVolume returns the volume of a file on removable media, or
+// false for files on fixed disks and unlabeled media
+func removableVolume(absPath string) (Volume, bool) {
+	root, label, ok := volumeRoot(absPath)
+	if !ok || label == "" {
+		return Volume{}, false
+	}
+	rel, err := filepath.Rel(root, absPath)
+	if err != nil || !filepath.IsLocal(rel) {
+		return Volume{}, false
+	}
+	return Volume{Label: label, Path: filepath.ToSlash(rel)}, true
+}
+
+// mountedVolume recognizes the usual mount points of removable media by
+// path alone: /media/<user>/<label>, /run/media/<user>/<label> and
+// /media/<label> on Linux, /Volumes/<label> on macOS
+func mountedVolume(absPath, goos, user string) (root, label string, ok bool) {
+	parts := strings.Split(filepath.ToSlash(absPath), "/")
+	if len(parts) < 2 || parts[0] != "" {
+		return "", "", false
+	}
+	parts = parts[1:]
+
+	var depth int
+	switch {
+	case goos == "darwin" && parts[0] == "Volumes":
+		depth = 2
+	case goos == "linux" && parts[0] == "run" && len(parts) > 1 && parts[1] == "media":
+		depth = 4
+	case goos == "linux" && parts[0] == "media":
+		depth = 2
+		if user != "" && len(parts) > 1 && parts[1] == user {
+			depth = 3
+		}
+	default:
+		return "", "", false
+	}
+	if len(parts) <= depth { // the path must lie inside the volume
+		return "", "", false
+	}
+	return "/" + strings.Join(parts[:depth], "/"), parts[depth-1], true
+}
+
+// globalKey returns the global store directory name for a file: its
+// volume's key on removable media, a hash of its path otherwise
+func globalKey(absPath string) string {
+	if v, ok := removableVolume(absPath); ok {
+		return v.key()
+	}
+	return hashFilePath(absPath)
+}
+
+// globalStoreDir picks the directory of a file's global store. Files on
+// removable media use their volume key, unless they were tracked by path
+// before volumes were recognized.
+func globalStoreDir(globalDir, absPath string) string {
+	dir := filepath.Join(globalDir, globalKey(absPath))
+	if _, err := os.Stat(dir); err == nil {
+		return dir
+	}
+	legacy := filepath.Join(globalDir, hashFilePath(absPath))
+	if _, err := os.Stat(legacy); err == nil {
+		return legacy
+	}
+	return dir
+}
+
+// saveVolume records the volume of a global store on removable media
+func (s *Store) saveVolume() error {
+	return writeVolume(s.OopsDirPath(), s.FilePath)
+}
+
+// writeVolume records the volume of absPath in a global store directory,
+// or removes the record when absPath is not on removable media
+func writeVolume(hashDir, absPath string) error {
+	v, ok := removableVolume(absPath)
+	if !ok {
+		err := os.Remove(filepath.Join(hashDir, volumeMetaFile))
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.WriteFile(filepath.Join(hashDir, volumeMetaFile), []byte(v.Label+"\n"+v.Path), 0644)
+}
+
+// followVolume updates the metadata of a global store on removable media
+// that was opened through a different mount point than it was saved
+// under, such as another drive letter
+func (s *Store) followVolume() {
+	if !s.Global {
+		return
+	}
+	if _, ok := removableVolume(s.FilePath); !ok {
+		return
+	}
+	data, err := os.ReadFile(filepath.Join(s.OopsDirPath(), "metadata.txt"))
+	if err == nil && string(data) == s.FilePath {
+		return
+	}
+	s.saveMetadata()
+}
+
+// readVolume returns the volume recorded in a global store directory
+func readVolume(hashDir string) (Volume, bool) {
+	data, err := os.ReadFile(filepath.Join(hashDir, volumeMetaFile))
+	if err != nil {
+		return Volume{}, false
+	}
+	label, path, ok := strings.Cut(string(data), "\n")
+	if !ok || label == "" || path == "" {
+		return Volume{}, false
+	}
+	return Volume{Label: label, Path: path}, true
+}
@@ -0,0 +1,62 @@
+package store
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestMountedVolume(t *testing.T) {
+	tests := []struct {
+		path, goos    string
+		root, label   string
+		wantRemovable bool
+	}{
+		{"/media/alice/USB/notes/a.txt", "linux", "/media/alice/USB", "USB", true},
+		{"/run/media/alice/STICK/a.txt", "linux", "/run/media/alice/STICK", "STICK", true},
+		{"/media/USB/a.txt", "linux", "/media/USB", "USB", true},
+		{"/Volumes/Backup/docs/a.txt", "darwin", "/Volumes/Backup", "Backup", true},
+		{"/media/alice/USB", "linux", "", "", false}, // the mount point itself
+		{"/home/alice/a.txt", "linux", "", "", false},
+		{"/Volumes/Backup/a.txt", "linux", "", "", false},
+		{"/media/USB/a.txt", "darwin", "", "", false},
+	}
+	for _, tt := range tests {
+		root, label, ok := mountedVolume(tt.path, tt.goos, "alice")
+		if ok != tt.wantRemovable || root != tt.root || label != tt.label {
+			t.Errorf("mountedVolume(%q, %s) = %q, %q, %v; want %q, %q, %v",
+				tt.path, tt.goos, root, label, ok, tt.root, tt.label, tt.wantRemovable)
+		}
+	}
+}
+
+func TestVolumeKeyIgnoresMountPoint(t *testing.T) {
+	a := Volume{Label: "USB", Path: "notes/a.txt"}
+	b := Volume{Label: "USB", Path: "notes/a.txt"}
+	if a.key() != b.key() {
+		t.Error("the same volume path should have one key")
+	}
+	if !isHashDirName(a.key()) {
+		t.Errorf("volume key %q should look like a store directory", a.key())
+	}
+	if a.key() == (Volume{Label: "OTHER", Path: "notes/a.txt"}).key() {
+		t.Error("volumes with different labels should have different keys")
+	}
+}
+
+func TestVolumeRecord(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, volumeMetaFile), []byte("USB\nnotes/a.txt"), 0644)
+	v, ok := readVolume(dir)
+	if !ok || v.Label != "USB" || v.Path != "notes/a.txt" {
+		t.Fatalf("readVolume = %+v, %v", v, ok)
+	}
+
+	// A store moved off removable media drops its record
+	if err := writeVolume(dir, filepath.Join(dir, "a.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := readVolume(dir); ok {
+		t.Error("volume record should be removed for a fixed-disk path")
+	}
+}
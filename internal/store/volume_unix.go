@@ -0,0 +1,14 @@
+//go:build !windows
+
+package store
+
+import (
+	"os"
+	"runtime"
+)
+
+// volumeRoot returns the mount point and label of the removable volume
+// holding absPath
+func volumeRoot(absPath string) (root, label string, ok bool) {
+	return mountedVolume(absPath, runtime.GOOS, os.Getenv("USER"))
+}
@@ -0,0 +1,42 @@
+//go:build windows
+
+package store
+
+import (
+	"path/filepath"
+	"syscall"
+	"unsafe"
+)
+
+var (
+	kernel32                 = syscall.NewLazyDLL("kernel32.dll")
+	procGetDriveType         = kernel32.NewProc("GetDriveTypeW")
+	procGetVolumeInformation = kernel32.NewProc("GetVolumeInformationW")
+)
+
+const driveRemovable = 2 // DRIVE_REMOVABLE
+
+// volumeRoot returns the drive root and label of the removable drive
+// holding absPath
+func volumeRoot(absPath string) (root, label string, ok bool) {
+	drive := filepath.VolumeName(absPath)
+	if len(drive) != 2 || drive[1] != ':' {
+		return "", "", false
+	}
+	root = drive + `\`
+	rootPtr, err := syscall.UTF16PtrFromString(root)
+	if err != nil {
+		return "", "", false
+	}
+	if t, _, _ := procGetDriveType.Call(uintptr(unsafe.Pointer(rootPtr))); t != driveRemovable {
+		return "", "", false
+	}
+
+	name := make([]uint16, syscall.MAX_PATH+1)
+	r, _, _ := procGetVolumeInformation.Call(uintptr(unsafe.Pointer(rootPtr)),
+		uintptr(unsafe.Pointer(&name[0])), uintptr(len(name)), 0, 0, 0, 0, 0)
+	if r == 0 {
+		return "", "", false
+	}
+	return root, syscall.UTF16ToString(name), true
+}
@@ -0,0 +1,109 @@
+// Package templates finds user templates for new tracked files.
+// Templates are plain files in ~/.oops/templates; a template is named by
+// its file name, with or without the extension.
+package templates
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/iyulab/oops/internal/config"
+)
+
+// DirName is the templates directory inside ~/.oops
+const DirName = "templates"
+
+var ErrNotFound = errors.New("template not found")
+
+// Template is a file that new tracked files can be created from
+type Template struct {
+	Name string // file name without extension
+	Path string
+}
+
+// Dir returns the templates directory path (~/.oops/templates)
+func Dir() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, DirName), nil
+}
+
+// List returns the available templates sorted by name
+func List() ([]Template, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return listIn(dir)
+}
+
+// Find looks up a template by name. "weekly-report" matches
+// weekly-report.md; an exact file name also matches.
+func Find(name string) (*Template, error) {
+	dir, err := Dir()
+	if err != nil {
+		return nil, err
+	}
+	return findIn(dir, name)
+}
+
+func listIn(dir string) ([]Template, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var list []Template
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+		list = append(list, Template{
+			Name: strings.TrimSuffix(entry.Name(), filepath.Ext(entry.Name())),
+			Path: filepath.Join(dir, entry.Name()),
+		})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].Name < list[j].Name
+	})
+	return list, nil
+}
+
+func findIn(dir, name string) (*Template, error) {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return nil, fmt.Errorf("invalid template name %q", name)
+	}
+
+	list, err := listIn(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var matches []Template
+	for _, t := range list {
+		if filepath.Base(t.Path) == name {
+			return &t, nil
+		}
+		if t.Name == name {
+			matches = append(matches, t)
+		}
+	}
+	switch len(matches) {
+	case 0:
+		return nil, fmt.Errorf("%w: %s", ErrNotFound, name)
+	case 1:
+		return &matches[0], nil
+	default:
+		return nil, fmt.Errorf("template %q is ambiguous: use the full file name, e.g. %s",
+			name, filepath.Base(matches[0].Path))
+	}
+}
@@ -0,0 +1,44 @@
+package templates
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFindIn(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "weekly-report.md"), []byte("# Week"), 0644)
+	os.WriteFile(filepath.Join(dir, "notes.md"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), nil, 0644)
+
+	tmpl, err := findIn(dir, "weekly-report")
+	if err != nil {
+		t.Fatalf("findIn failed: %v", err)
+	}
+	if filepath.Base(tmpl.Path) != "weekly-report.md" {
+		t.Errorf("Path = %s, want weekly-report.md", tmpl.Path)
+	}
+
+	if _, err := findIn(dir, "notes"); err == nil {
+		t.Error("ambiguous name should fail")
+	}
+	if tmpl, err := findIn(dir, "notes.txt"); err != nil || tmpl.Name != "notes" {
+		t.Errorf("exact name: %v, %v", tmpl, err)
+	}
+	if _, err := findIn(dir, "missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("missing: err = %v, want ErrNotFound", err)
+	}
+	if _, err := findIn(dir, "../notes.md"); err == nil {
+		t.Error("path traversal should fail")
+	}
+}
+
+func TestListInMissingDir(t *testing.T) {
+	list, err := listIn(filepath.Join(t.TempDir(), "none"))
+	if err != nil || len(list) != 0 {
+		t.Errorf("listIn = %v, %v; want empty", list, err)
+	}
+}
@@ -0,0 +1,132 @@
+// Package textenc detects the text encoding of file content and decodes
+// it to UTF-8 for display. Stored content is never transcoded.
+package textenc
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encoding names a detected text encoding
+type Encoding string
+
+// Detected encodings
+const (
+	UTF8        Encoding = "UTF-8"
+	UTF8BOM     Encoding = "UTF-8 with BOM"
+	UTF16LE     Encoding = "UTF-16LE"
+	UTF16BE     Encoding = "UTF-16BE"
+	Windows1252 Encoding = "Windows-1252" // any other 8-bit legacy encoding
+)
+
+var (
+	bomUTF8    = []byte{0xEF, 0xBB, 0xBF}
+	bomUTF16LE = []byte{0xFF, 0xFE}
+	bomUTF16BE = []byte{0xFE, 0xFF}
+)
+
+// Detect guesses the encoding of data from its byte order mark, or from
+// the pattern of zero bytes that UTF-16 leaves in mostly-ASCII text.
+// Content that is not valid UTF-8 is assumed to be Windows-1252.
+func Detect(data []byte) Encoding {
+	switch {
+	case bytes.HasPrefix(data, bomUTF8):
+		return UTF8BOM
+	case bytes.HasPrefix(data, bomUTF16LE):
+		return UTF16LE
+	case bytes.HasPrefix(data, bomUTF16BE):
+		return UTF16BE
+	}
+
+	if enc, ok := detectUTF16(data); ok {
+		return enc
+	}
+	if utf8.Valid(data) {
+		return UTF8
+	}
+	return Windows1252
+}
+
+// detectUTF16 recognizes UTF-16 without a byte order mark: in text that
+// is mostly ASCII, every other byte is zero
+func detectUTF16(data []byte) (Encoding, bool) {
+	const sniffLen = 1024
+	if len(data) > sniffLen {
+		data = data[:sniffLen]
+	}
+	pairs := len(data) / 2
+	if pairs < 2 {
+		return "", false
+	}
+
+	var evenZeros, oddZeros int
+	for i := 0; i+1 < len(data); i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+
+	switch {
+	case oddZeros*10 >= pairs*4 && evenZeros*20 < pairs:
+		return UTF16LE, true
+	case evenZeros*10 >= pairs*4 && oddZeros*20 < pairs:
+		return UTF16BE, true
+	}
+	return "", false
+}
+
+// Decode returns data as UTF-8 text along with its detected encoding.
+// A byte order mark is dropped from the text.
+func Decode(data []byte) (string, Encoding) {
+	enc := Detect(data)
+	switch enc {
+	case UTF8BOM:
+		return string(data[len(bomUTF8):]), enc
+	case UTF16LE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16LE), false), enc
+	case UTF16BE:
+		return decodeUTF16(bytes.TrimPrefix(data, bomUTF16BE), true), enc
+	case Windows1252:
+		return decodeWindows1252(data), enc
+	}
+	return string(data), enc
+}
+
+func decodeUTF16(data []byte, bigEndian bool) string {
+	units := make([]uint16, 0, len(data)/2)
+	for i := 0; i+1 < len(data); i += 2 {
+		if bigEndian {
+			units = append(units, uint16(data[i])<<8|uint16(data[i+1]))
+		} else {
+			units = append(units, uint16(data[i+1])<<8|uint16(data[i]))
+		}
+	}
+	return string(utf16.Decode(units))
+}
+
+// windows1252 maps bytes 0x80-0x9F, where Windows-1252 differs from
+// Latin-1. Unassigned bytes map to the matching C1 control.
+var windows1252 = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+func decodeWindows1252(data []byte) string {
+	var b strings.Builder
+	b.Grow(len(data))
+	for _, c := range data {
+		if c >= 0x80 && c <= 0x9F {
+			b.WriteRune(windows1252[c-0x80])
+		} else {
+			b.WriteRune(rune(c))
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,28 @@
+package textenc
+
+import "testing"
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+		want  string
+		enc   Encoding
+	}{
+		{"utf8", []byte("héllo\n"), "héllo\n", UTF8},
+		{"utf8 bom", []byte("\xEF\xBB\xBFkey=1\n"), "key=1\n", UTF8BOM},
+		{"utf16le bom", []byte("\xFF\xFEa\x00=\x001\x00"), "a=1", UTF16LE},
+		{"utf16be bom", []byte("\xFE\xFF\x00a\x00=\x001"), "a=1", UTF16BE},
+		{"utf16le no bom", []byte("n\x00a\x00m\x00e\x00,\x00\xE9\x00"), "name,é", UTF16LE},
+		{"windows-1252", []byte("caf\xE9 \x93quoted\x94 \x80"), "café “quoted” €", Windows1252},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, enc := Decode(tt.input)
+			if got != tt.want || enc != tt.enc {
+				t.Errorf("Decode = %q (%s), want %q (%s)", got, enc, tt.want, tt.enc)
+			}
+		})
+	}
+}
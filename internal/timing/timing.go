@@ -0,0 +1,117 @@
+// Package timing measures where a command spends its time, for the
+// --timings flag. Phases such as opening the store or committing are
+// timed where they happen; a phase started inside another is taken out
+// of the outer one, so each moment is counted once. Nothing is recorded
+// until Enable is called.
+package timing
+
+import (
+	"sync"
+	"time"
+)
+
+// Phase names shared by the packages that report them
+const (
+	StoreResolution = "store resolution"
+	RepoOpen        = "repo open"
+	Status          = "status"
+	Diff            = "diff"
+	Stage           = "stage"
+	Commit          = "commit"
+	Tag             = "tag"
+	Restore         = "restore"
+)
+
+// Phase is the time spent in one phase
+type Phase struct {
+	Name  string
+	Time  time.Duration // excluding phases started inside it
+	Calls int
+}
+
+type frame struct {
+	name  string
+	start time.Time
+	inner time.Duration
+}
+
+var (
+	mu      sync.Mutex
+	enabled bool
+	started time.Time
+	stack   []*frame
+	phases  []*Phase
+)
+
+// Enable starts recording, counting the total from now
+func Enable() {
+	mu.Lock()
+	defer mu.Unlock()
+	enabled = true
+	started = time.Now()
+	stack, phases = nil, nil
+}
+
+// Enabled reports whether phases are being recorded
+func Enabled() bool {
+	mu.Lock()
+	defer mu.Unlock()
+	return enabled
+}
+
+// Start begins timing a phase and returns the function that ends it,
+// meant to be deferred:
+//
+//	defer timing.Start(timing.Commit)()
+func Start(name string) func() {
+	mu.Lock()
+	defer mu.Unlock()
+	if !enabled {
+		return func() {}
+	}
+	f := &frame{name: name, start: time.Now()}
+	stack = append(stack, f)
+	return func() { end(f) }
+}
+
+func end(f *frame) {
+	mu.Lock()
+	defer mu.Unlock()
+	elapsed := time.Since(f.start)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == f {
+			stack = append(stack[:i], stack[i+1:]...)
+			if i > 0 {
+				stack[i-1].inner += elapsed
+			}
+			break
+		}
+	}
+	p := phase(f.name)
+	p.Time += elapsed - f.inner
+	p.Calls++
+}
+
+// phase returns the record of the named phase, adding it if needed
+func phase(name string) *Phase {
+	for _, p := range phases {
+		if p.Name == name {
+			return p
+		}
+	}
+	p := &Phase{Name: name}
+	phases = append(phases, p)
+	return p
+}
+
+// Report returns the phases in the order they first ran and the total
+// time since Enable
+func Report() ([]Phase, time.Duration) {
+	mu.Lock()
+	defer mu.Unlock()
+	report := make([]Phase, len(phases))
+	for i, p := range phases {
+		report[i] = *p
+	}
+	return report, time.Since(started)
+}
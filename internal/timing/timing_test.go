@@ -0,0 +1,43 @@
+package timing
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNestedPhasesCountedOnce(t *testing.T) {
+	Enable()
+	endCommit := Start(Commit)
+	time.Sleep(20 * time.Millisecond)
+	endTag := Start(Tag)
+	time.Sleep(30 * time.Millisecond)
+	endTag()
+	endCommit()
+	Start(Tag)()
+
+	phases, total := Report()
+	if len(phases) != 2 || phases[0].Name != Tag || phases[1].Name != Commit {
+		t.Fatalf("phases = %+v, want tag then commit", phases)
+	}
+	if phases[0].Calls != 2 || phases[1].Calls != 1 {
+		t.Errorf("calls = %d, %d, want 2, 1", phases[0].Calls, phases[1].Calls)
+	}
+	if got := phases[1].Time; got < 20*time.Millisecond || got >= 30*time.Millisecond+20*time.Millisecond {
+		t.Errorf("commit took %v, want about 20ms without the inner tag", got)
+	}
+	if sum := phases[0].Time + phases[1].Time; sum > total {
+		t.Errorf("phases add up to %v, more than the total %v", sum, total)
+	}
+}
+
+func TestDisabledRecordsNothing(t *testing.T) {
+	enabled = false
+	phases = nil
+	Start(Commit)()
+	if Enabled() {
+		t.Fatal("Enabled() = true before Enable")
+	}
+	if got, _ := Report(); len(got) != 0 {
+		t.Errorf("recorded %+v while disabled", got)
+	}
+}
@@ -0,0 +1,5 @@
+//go:build apt
+
+package updater
+
+func init() { builtFor = Apt }
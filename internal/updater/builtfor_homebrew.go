@@ -0,0 +1,5 @@
+//go:build homebrew
+
+package updater
+
+func init() { builtFor = Homebrew }
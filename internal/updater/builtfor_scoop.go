@@ -0,0 +1,5 @@
+//go:build scoop
+
+package updater
+
+func init() { builtFor = Scoop }
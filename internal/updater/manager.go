@@ -0,0 +1,74 @@
+package updater
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// PackageManager is a package manager that installed oops and should be
+// the one to update it
+type PackageManager struct {
+	Name          string
+	UpdateCommand string
+}
+
+// Package managers oops knows how to spot
+var (
+	Homebrew = &PackageManager{Name: "Homebrew", UpdateCommand: "brew upgrade oops"}
+	Scoop    = &PackageManager{Name: "Scoop", UpdateCommand: "scoop update oops"}
+	Apt      = &PackageManager{Name: "apt", UpdateCommand: "sudo apt update && sudo apt install --only-upgrade oops"}
+)
+
+// builtFor is the package manager a packaged build was made for, set by
+// the homebrew, scoop and apt build tags
+var builtFor *PackageManager
+
+// dpkgInfoDir is where dpkg lists the files of installed packages
+var dpkgInfoDir = "/var/lib/dpkg/info"
+
+// InstalledBy returns the package manager that installed the running
+// executable, or nil if it was installed some other way
+func InstalledBy() *PackageManager {
+	if builtFor != nil {
+		return builtFor
+	}
+	execPath, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	if resolved, err := filepath.EvalSymlinks(execPath); err == nil {
+		execPath = resolved
+	}
+	return detectPackageManager(execPath)
+}
+
+// detectPackageManager guesses the package manager from where the
+// executable is installed
+func detectPackageManager(execPath string) *PackageManager {
+	p := strings.ToLower(strings.ReplaceAll(execPath, "\\", "/"))
+	switch {
+	case strings.Contains(p, "/cellar/") || strings.Contains(p, "/homebrew/") || strings.Contains(p, "/linuxbrew/"):
+		return Homebrew
+	case strings.Contains(p, "/scoop/apps/") || strings.Contains(p, "/scoop/shims/"):
+		return Scoop
+	}
+	if dpkgOwns(execPath) {
+		return Apt
+	}
+	return nil
+}
+
+// dpkgOwns reports whether a dpkg package lists path as one of its files
+func dpkgOwns(path string) bool {
+	data, err := os.ReadFile(filepath.Join(dpkgInfoDir, "oops.list"))
+	if err != nil {
+		return false
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if line == path {
+			return true
+		}
+	}
+	return false
+}
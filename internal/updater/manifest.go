@@ -0,0 +1,220 @@
+package updater
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"text/template"
+)
+
+// Project details shared by the package manifests
+const (
+	projectHomepage    = "https://github.com/" + GitHubRepo
+	projectDescription = "Simple file versioning for everyone"
+	projectLicense     = "MIT"
+)
+
+// Platform is an OS and architecture oops is released for
+type Platform struct {
+	OS, Arch string
+}
+
+// Binary returns the name of the executable in the platform's release
+// archive, as built by the release workflow
+func (p Platform) Binary() string {
+	name := fmt.Sprintf("oops-%s-%s", p.OS, p.Arch)
+	if p.OS == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// Platforms packaged for Homebrew and Scoop
+var (
+	BrewPlatforms  = []Platform{{"darwin", "arm64"}, {"darwin", "amd64"}, {"linux", "arm64"}, {"linux", "amd64"}}
+	ScoopPlatforms = []Platform{{"windows", "amd64"}}
+)
+
+// ManifestAsset is a release asset with its checksum
+type ManifestAsset struct {
+	Platform
+	URL    string
+	SHA256 string
+}
+
+// ReleaseAssets finds the asset of each platform in release, named as
+// AssetName expects, with its SHA-256. Checksums come from a checksums
+// file in the release if there is one; other assets are downloaded.
+func ReleaseAssets(release *Release, platforms []Platform) ([]ManifestAsset, error) {
+	sums := map[string]string{}
+	for _, asset := range release.Assets {
+		name := strings.ToLower(asset.Name)
+		if strings.Contains(name, "checksums") || strings.Contains(name, "sha256sums") {
+			data, err := download(asset.BrowserDownloadURL)
+			if err != nil {
+				return nil, err
+			}
+			for name, sum := range parseChecksums(data) {
+				sums[name] = sum
+			}
+		}
+	}
+
+	var assets []ManifestAsset
+	for _, p := range platforms {
+		name := AssetName(p.OS, p.Arch)
+		var found *Asset
+		for i := range release.Assets {
+			if release.Assets[i].Name == name {
+				found = &release.Assets[i]
+			}
+		}
+		if found == nil {
+			return nil, fmt.Errorf("release %s has no %s", release.TagName, name)
+		}
+
+		sum, ok := sums[name]
+		if !ok {
+			data, err := download(found.BrowserDownloadURL)
+			if err != nil {
+				return nil, err
+			}
+			digest := sha256.Sum256(data)
+			sum = hex.EncodeToString(digest[:])
+		}
+		assets = append(assets, ManifestAsset{Platform: p, URL: found.BrowserDownloadURL, SHA256: sum})
+	}
+	return assets, nil
+}
+
+// parseChecksums reads "<sha256>  <file name>" lines, as written by
+// sha256sum
+func parseChecksums(data []byte) map[string]string {
+	sums := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && len(fields[0]) == 64 {
+			sums[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return sums
+}
+
+func download(url string) ([]byte, error) {
+	resp, err := http.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download %s: %v", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("failed to download %s: %s", url, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+var brewTemplate = template.Must(template.New("brew").Parse(`class Oops < Formula
+  desc "{{.Description}}"
+  homepage "{{.Homepage}}"
+  version "{{.Version}}"
+  license "{{.License}}"
+{{range $os := .OSes}}
+  on_{{index $.OSNames $os}} do
+{{- range $.Assets}}{{if eq .OS $os}}
+    on_{{index $.ArchNames .Arch}} do
+      url "{{.URL}}"
+      sha256 "{{.SHA256}}"
+    end
+{{- end}}{{end}}
+  end
+{{end}}
+  def install
+    bin.install Dir["oops-*"].first => "oops"
+  end
+
+  test do
+    assert_match version.to_s, shell_output("#{bin}/oops --version")
+  end
+end
+`))
+
+// BrewFormula returns a Homebrew formula installing the assets of release
+func BrewFormula(release *Release, assets []ManifestAsset) (string, error) {
+	var oses []string
+	for _, a := range assets {
+		if len(oses) == 0 || oses[len(oses)-1] != a.OS {
+			oses = append(oses, a.OS)
+		}
+	}
+
+	var out bytes.Buffer
+	err := brewTemplate.Execute(&out, map[string]any{
+		"Description": projectDescription,
+		"Homepage":    projectHomepage,
+		"License":     projectLicense,
+		"Version":     strings.TrimPrefix(release.TagName, "v"),
+		"OSes":        oses,
+		"OSNames":     map[string]string{"darwin": "macos", "linux": "linux"},
+		"ArchNames":   map[string]string{"arm64": "arm", "amd64": "intel"},
+		"Assets":      assets,
+	})
+	return out.String(), err
+}
+
+// scoopArchitectures maps Go architectures to Scoop's names
+var scoopArchitectures = map[string]string{"amd64": "64bit", "386": "32bit", "arm64": "arm64"}
+
+// ScoopManifest returns a Scoop manifest installing the assets of release
+func ScoopManifest(release *Release, assets []ManifestAsset) (string, error) {
+	type archEntry struct {
+		URL  string     `json:"url"`
+		Hash string     `json:"hash"`
+		Bin  [][]string `json:"bin"`
+	}
+	type autoEntry struct {
+		URL string `json:"url"`
+	}
+	arch := map[string]archEntry{}
+	autoArch := map[string]autoEntry{}
+	for _, a := range assets {
+		name, ok := scoopArchitectures[a.Arch]
+		if !ok || a.OS != "windows" {
+			return "", fmt.Errorf("scoop has no architecture for %s/%s", a.OS, a.Arch)
+		}
+		arch[name] = archEntry{URL: a.URL, Hash: a.SHA256, Bin: [][]string{{a.Binary(), "oops"}}}
+		autoArch[name] = autoEntry{URL: strings.ReplaceAll(a.URL, release.TagName, "v$version")}
+	}
+
+	manifest := struct {
+		Version      string                    `json:"version"`
+		Description  string                    `json:"description"`
+		Homepage     string                    `json:"homepage"`
+		License      string                    `json:"license"`
+		Architecture map[string]archEntry      `json:"architecture"`
+		Checkver     string                    `json:"checkver"`
+		Autoupdate   map[string]map[string]any `json:"autoupdate"`
+	}{
+		Version:      strings.TrimPrefix(release.TagName, "v"),
+		Description:  projectDescription,
+		Homepage:     projectHomepage,
+		License:      projectLicense,
+		Architecture: arch,
+		Checkver:     "github",
+		Autoupdate:   map[string]map[string]any{"architecture": {}},
+	}
+	for name, entry := range autoArch {
+		manifest.Autoupdate["architecture"][name] = entry
+	}
+
+	data, err := json.MarshalIndent(manifest, "", "    ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
@@ -0,0 +1,110 @@
+package updater
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestParseChecksums(t *testing.T) {
+	sum := strings.Repeat("ab", 32)
+	sums := parseChecksums([]byte(sum + "  oops-linux-amd64.tar.gz\n" + sum + " *oops-windows-amd64.zip\nnot a checksum\n"))
+	if len(sums) != 2 || sums["oops-linux-amd64.tar.gz"] != sum || sums["oops-windows-amd64.zip"] != sum {
+		t.Errorf("sums = %v", sums)
+	}
+}
+
+func TestReleaseAssets(t *testing.T) {
+	sum := strings.Repeat("0", 64)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/checksums.txt":
+			w.Write([]byte(sum + "  oops-linux-amd64.tar.gz\n"))
+		case "/oops-darwin-arm64.tar.gz":
+			w.Write([]byte("archive"))
+		default:
+			http.NotFound(w, r)
+		}
+	}))
+	defer server.Close()
+
+	release := &Release{TagName: "v1.2.0", Assets: []Asset{
+		{Name: "checksums.txt", BrowserDownloadURL: server.URL + "/checksums.txt"},
+		{Name: "oops-linux-amd64.tar.gz", BrowserDownloadURL: server.URL + "/oops-linux-amd64.tar.gz"},
+		{Name: "oops-darwin-arm64.tar.gz", BrowserDownloadURL: server.URL + "/oops-darwin-arm64.tar.gz"},
+	}}
+	assets, err := ReleaseAssets(release, []Platform{{"linux", "amd64"}, {"darwin", "arm64"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if assets[0].SHA256 != sum {
+		t.Errorf("linux sha256 = %s, want the listed checksum", assets[0].SHA256)
+	}
+	// sha256("archive"), computed from the download
+	if assets[1].SHA256 != "0eb3e36bfb24dcd9bb1d1bece1531216b59539a8fde17ee80224af0653c92aa3" {
+		t.Errorf("darwin sha256 = %q", assets[1].SHA256)
+	}
+
+	if _, err := ReleaseAssets(release, []Platform{{"windows", "amd64"}}); err == nil {
+		t.Error("a missing platform asset should be an error")
+	}
+}
+
+func TestBrewFormula(t *testing.T) {
+	release := &Release{TagName: "v1.2.0"}
+	var assets []ManifestAsset
+	for _, p := range BrewPlatforms {
+		assets = append(assets, ManifestAsset{Platform: p, URL: "https://example.com/" + AssetName(p.OS, p.Arch), SHA256: p.OS + p.Arch})
+	}
+	formula, err := BrewFormula(release, assets)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		`version "1.2.0"`,
+		"on_macos do\n    on_arm do\n      url \"https://example.com/oops-darwin-arm64.tar.gz\"\n      sha256 \"darwinarm64\"\n    end",
+		"on_linux do",
+		"on_intel do",
+		`bin.install Dir["oops-*"].first => "oops"`,
+	} {
+		if !strings.Contains(formula, want) {
+			t.Errorf("formula lacks %q:\n%s", want, formula)
+		}
+	}
+}
+
+func TestScoopManifest(t *testing.T) {
+	release := &Release{TagName: "v1.2.0"}
+	url := "https://github.com/iyulab/oops/releases/download/v1.2.0/oops-windows-amd64.zip"
+	text, err := ScoopManifest(release, []ManifestAsset{{Platform: Platform{"windows", "amd64"}, URL: url, SHA256: "abc"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var manifest struct {
+		Version      string
+		Architecture map[string]struct {
+			URL  string
+			Hash string
+			Bin  [][]string
+		}
+		Autoupdate struct {
+			Architecture map[string]struct{ URL string }
+		}
+	}
+	if err := json.Unmarshal([]byte(text), &manifest); err != nil {
+		t.Fatalf("invalid JSON: %v\n%s", err, text)
+	}
+	arch := manifest.Architecture["64bit"]
+	if manifest.Version != "1.2.0" || arch.URL != url || arch.Hash != "abc" {
+		t.Errorf("manifest = %+v", manifest)
+	}
+	if len(arch.Bin) != 1 || arch.Bin[0][0] != "oops-windows-amd64.exe" || arch.Bin[0][1] != "oops" {
+		t.Errorf("bin = %v", arch.Bin)
+	}
+	if got := manifest.Autoupdate.Architecture["64bit"].URL; !strings.Contains(got, "/v$version/") {
+		t.Errorf("autoupdate url = %q", got)
+	}
+}
@@ -4,19 +4,26 @@ import (
 	"archive/tar"
 	"archive/zip"
 	"compress/gzip"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
 )
 
 const (
-	GitHubRepo   = "iyulab/oops"
-	GitHubAPIURL = "https://api.github.com/repos/" + GitHubRepo + "/releases/latest"
+	GitHubRepo        = "iyulab/oops"
+	GitHubReleasesURL = "https://api.github.com/repos/" + GitHubRepo + "/releases"
+	GitHubAPIURL      = GitHubReleasesURL + "/latest"
 )
 
 // Release represents a GitHub release
@@ -52,12 +59,41 @@ func CheckForUpdate(currentVersion string) (*Release, bool, error) {
 
 // getLatestRelease fetches the latest release from GitHub
 func getLatestRelease() (*Release, error) {
-	req, err := http.NewRequest("GET", GitHubAPIURL, nil)
+	return fetchRelease(GitHubAPIURL, cachePath(), time.Now())
+}
+
+// GetRelease fetches the release tagged tag, or the latest with tag ""
+func GetRelease(tag string) (*Release, error) {
+	if tag == "" {
+		return getLatestRelease()
+	}
+	if !strings.HasPrefix(tag, "v") {
+		tag = "v" + tag
+	}
+	return fetchRelease(GitHubReleasesURL+"/tags/"+tag, "", time.Now())
+}
+
+// fetchRelease fetches a release from url, revalidating
+// the cached response at cachePath and backing off while rate limited.
+// While backing off the cached release is returned, if there is one.
+func fetchRelease(url, cachePath string, now time.Time) (*Release, error) {
+	cache := loadCache(cachePath)
+	if now.Before(cache.BackoffUntil) {
+		if cache.Release != nil {
+			return cache.Release, nil
+		}
+		return nil, &RateLimitError{Until: cache.BackoffUntil}
+	}
+
+	req, err := http.NewRequest("GET", url, nil)
 	if err != nil {
 		return nil, err
 	}
 	req.Header.Set("Accept", "application/vnd.github.v3+json")
 	req.Header.Set("User-Agent", "oops-updater")
+	if cache.ETag != "" && cache.Release != nil {
+		req.Header.Set("If-None-Match", cache.ETag)
+	}
 
 	client := &http.Client{}
 	resp, err := client.Do(req)
@@ -66,6 +102,18 @@ func getLatestRelease() (*Release, error) {
 	}
 	defer resp.Body.Close()
 
+	if resp.StatusCode == http.StatusNotModified && cache.Release != nil {
+		return cache.Release, nil
+	}
+	if until, limited := rateLimitReset(resp, now); limited {
+		cache.BackoffUntil = until
+		saveCache(cachePath, cache)
+		if cache.Release != nil {
+			return cache.Release, nil
+		}
+		return nil, &RateLimitError{Until: until}
+	}
+
 	if resp.StatusCode == 404 {
 		return nil, fmt.Errorf("no releases found")
 	}
@@ -79,14 +127,96 @@ func getLatestRelease() (*Release, error) {
 		return nil, fmt.Errorf("failed to parse release info: %v", err)
 	}
 
+	saveCache(cachePath, releaseCache{ETag: resp.Header.Get("ETag"), Release: &release})
 	return &release, nil
 }
 
+// releaseCacheFile holds the last latest-release response, in ~/.oops
+const releaseCacheFile = "update-cache.json"
+
+// defaultBackoff is how long checks pause after a rate limit response
+// that does not say when the limit resets
+const defaultBackoff = time.Hour
+
+// RateLimitError is returned while GitHub is rate limiting update checks
+type RateLimitError struct {
+	Until time.Time // when checking again is allowed
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("GitHub API rate limit reached; checking again after %s",
+		e.Until.Local().Format("15:04"))
+}
+
+// releaseCache is the saved latest-release response. The ETag lets GitHub
+// answer "not modified" without counting against the rate limit.
+type releaseCache struct {
+	ETag         string    `json:"etag,omitempty"`
+	Release      *Release  `json:"release,omitempty"`
+	BackoffUntil time.Time `json:"backoff_until,omitempty"`
+}
+
+// cachePath returns the path of the release cache, "" if there is no
+// home directory
+func cachePath() string {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, releaseCacheFile)
+}
+
+func loadCache(path string) releaseCache {
+	var cache releaseCache
+	if data, err := os.ReadFile(path); err == nil {
+		json.Unmarshal(data, &cache)
+	}
+	return cache
+}
+
+// saveCache writes the cache, ignoring failures: it only saves requests
+func saveCache(path string, cache releaseCache) {
+	if path == "" {
+		return
+	}
+	data, err := json.Marshal(cache)
+	if err != nil {
+		return
+	}
+	os.MkdirAll(filepath.Dir(path), 0755)
+	os.WriteFile(path, data, 0644)
+}
+
+// rateLimitReset returns when a 403 or 429 response's rate limit ends,
+// or false if it is not a rate limit response
+func rateLimitReset(resp *http.Response, now time.Time) (time.Time, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return time.Time{}, false
+	}
+	if s := resp.Header.Get("Retry-After"); s != "" {
+		if secs, err := strconv.Atoi(s); err == nil {
+			return now.Add(time.Duration(secs) * time.Second), true
+		}
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") == "0" {
+		if reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64); err == nil {
+			return time.Unix(reset, 0), true
+		}
+		return now.Add(defaultBackoff), true
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return now.Add(defaultBackoff), true
+	}
+	return time.Time{}, false // some other 403
+}
+
 // GetAssetName returns the expected asset name for current OS/arch
 func GetAssetName() string {
-	os := runtime.GOOS
-	arch := runtime.GOARCH
+	return AssetName(runtime.GOOS, runtime.GOARCH)
+}
 
+// AssetName returns the release asset name for an OS and architecture
+func AssetName(os, arch string) string {
 	var ext string
 	if os == "windows" {
 		ext = ".zip"
@@ -176,32 +306,82 @@ func DownloadAndInstall(asset *Asset) error {
 	}
 	defer os.Remove(newBinary)
 
-	// Replace the current executable
-	// On Windows, we need to rename the old one first
-	if runtime.GOOS == "windows" {
-		oldPath := execPath + ".old"
-		os.Remove(oldPath) // Remove any existing .old file
-		if err := os.Rename(execPath, oldPath); err != nil {
-			return fmt.Errorf("failed to backup old version: %v", err)
-		}
-		defer os.Remove(oldPath)
-	}
+	return install(newBinary, execPath)
+}
 
-	// Copy new binary to exec path
-	if err := copyFile(newBinary, execPath); err != nil {
+// checkTimeout bounds how long a newly installed binary may take to
+// report its version
+const checkTimeout = 10 * time.Second
+
+// install replaces the executable at execPath with newBinary. The new
+// binary is staged next to it, swapped in, and must then run "--version";
+// if it does not (a corrupt download, the wrong architecture), the
+// previous executable is put back.
+func install(newBinary, execPath string) error {
+	staged := execPath + ".new"
+	if err := copyFile(newBinary, staged); err != nil {
+		os.Remove(staged)
 		return fmt.Errorf("failed to install update: %v", err)
 	}
+	defer os.Remove(staged)
 
 	// Make executable on Unix
 	if runtime.GOOS != "windows" {
-		if err := os.Chmod(execPath, 0755); err != nil {
+		if err := os.Chmod(staged, 0755); err != nil {
 			return fmt.Errorf("failed to set permissions: %v", err)
 		}
 	}
 
+	// Keep the old executable until the new one has been checked. On
+	// Windows a running executable can be renamed but not replaced.
+	oldPath := execPath + ".old"
+	os.Remove(oldPath) // Remove any existing .old file
+	if err := os.Rename(execPath, oldPath); err != nil {
+		return fmt.Errorf("failed to backup old version: %v", err)
+	}
+	if err := os.Rename(staged, execPath); err != nil {
+		os.Rename(oldPath, execPath)
+		return fmt.Errorf("failed to install update: %v", err)
+	}
+
+	if err := checkBinary(execPath); err != nil {
+		os.Remove(execPath)
+		if rerr := os.Rename(oldPath, execPath); rerr != nil {
+			return fmt.Errorf("the new version does not run (%v) and the previous one could not be restored from %s: %v",
+				err, oldPath, rerr)
+		}
+		return fmt.Errorf("the new version does not run (%v); kept the current version", err)
+	}
+
+	os.Remove(oldPath) // Fails on Windows while the old one is running
+	return nil
+}
+
+// checkBinary runs path --version and fails unless it exits successfully
+func checkBinary(path string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+	defer cancel()
+	out, err := exec.CommandContext(ctx, path, "--version").CombinedOutput()
+	if ctx.Err() != nil {
+		return fmt.Errorf("no answer to --version within %s", checkTimeout)
+	}
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%v: %s", err, firstLine(msg))
+		}
+		return err
+	}
 	return nil
 }
 
+// firstLine returns s up to its first line break
+func firstLine(s string) string {
+	if i := strings.IndexByte(s, '\n'); i >= 0 {
+		return s[:i]
+	}
+	return s
+}
+
 func extractZip(zipPath string) (string, error) {
 	r, err := zip.OpenReader(zipPath)
 	if err != nil {
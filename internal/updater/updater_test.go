@@ -1,13 +1,16 @@
 package updater
 
 import (
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 )
 
 func TestGetAssetName(t *testing.T) {
@@ -310,3 +313,142 @@ func TestVersionComparison(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchLatestReleaseETag(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"abc"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		w.Header().Set("ETag", `"abc"`)
+		w.Write([]byte(`{"tag_name": "v1.0.0", "assets": []}`))
+	}))
+	defer server.Close()
+
+	cache := filepath.Join(t.TempDir(), releaseCacheFile)
+	for i := 0; i < 2; i++ {
+		release, err := fetchRelease(server.URL, cache, time.Now())
+		if err != nil {
+			t.Fatal(err)
+		}
+		if release.TagName != "v1.0.0" {
+			t.Errorf("request %d: TagName = %q, want v1.0.0", i+1, release.TagName)
+		}
+	}
+	if requests != 2 {
+		t.Errorf("requests = %d, want 2", requests)
+	}
+}
+
+func TestFetchLatestReleaseRateLimited(t *testing.T) {
+	now := time.Now()
+	reset := now.Add(30 * time.Minute).Truncate(time.Second)
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	cache := filepath.Join(t.TempDir(), releaseCacheFile)
+	_, err := fetchRelease(server.URL, cache, now)
+	var limited *RateLimitError
+	if !errors.As(err, &limited) || !limited.Until.Equal(reset) {
+		t.Fatalf("err = %v, want rate limit until %v", err, reset)
+	}
+
+	// No requests until the limit resets
+	if _, err := fetchRelease(server.URL, cache, now.Add(time.Minute)); !errors.As(err, &limited) {
+		t.Errorf("err = %v, want rate limit", err)
+	}
+	if requests != 1 {
+		t.Errorf("requests = %d, want 1", requests)
+	}
+	fetchRelease(server.URL, cache, reset.Add(time.Second))
+	if requests != 2 {
+		t.Errorf("requests after reset = %d, want 2", requests)
+	}
+}
+
+func TestFetchLatestReleaseOtherForbidden(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := fetchRelease(server.URL, filepath.Join(t.TempDir(), releaseCacheFile), time.Now())
+	var limited *RateLimitError
+	if err == nil || errors.As(err, &limited) {
+		t.Errorf("err = %v, want a plain API error", err)
+	}
+}
+
+func TestInstallChecksNewBinary(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses shell scripts as binaries")
+	}
+	tmpDir := t.TempDir()
+	execPath := filepath.Join(tmpDir, "oops")
+	writeScript := func(path, body string) {
+		if err := os.WriteFile(path, []byte("#!/bin/sh\n"+body+"\n"), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeScript(execPath, "echo old")
+
+	// A binary that does not run is rolled back
+	broken := filepath.Join(tmpDir, "broken")
+	writeScript(broken, "echo 'exec format error' >&2; exit 1")
+	err := install(broken, execPath)
+	if err == nil || !strings.Contains(err.Error(), "exec format error") {
+		t.Errorf("install(broken) = %v, want the check's error", err)
+	}
+	if data, _ := os.ReadFile(execPath); !strings.Contains(string(data), "echo old") {
+		t.Errorf("executable = %q, want the old one restored", data)
+	}
+
+	// A working binary replaces the old one, leaving nothing behind
+	working := filepath.Join(tmpDir, "working")
+	writeScript(working, "echo new")
+	if err := install(working, execPath); err != nil {
+		t.Fatalf("install(working) failed: %v", err)
+	}
+	if data, _ := os.ReadFile(execPath); !strings.Contains(string(data), "echo new") {
+		t.Errorf("executable = %q, want the new one", data)
+	}
+	for _, leftover := range []string{execPath + ".old", execPath + ".new"} {
+		if _, err := os.Stat(leftover); err == nil {
+			t.Errorf("%s was left behind", leftover)
+		}
+	}
+}
+
+func TestDetectPackageManager(t *testing.T) {
+	dpkg := t.TempDir()
+	old := dpkgInfoDir
+	dpkgInfoDir = dpkg
+	defer func() { dpkgInfoDir = old }()
+	os.WriteFile(filepath.Join(dpkg, "oops.list"), []byte("/.\n/usr\n/usr/bin\n/usr/bin/oops\n"), 0644)
+
+	tests := []struct {
+		path string
+		want *PackageManager
+	}{
+		{"/opt/homebrew/Cellar/oops/0.3.0/bin/oops", Homebrew},
+		{"/usr/local/Cellar/oops/0.3.0/bin/oops", Homebrew},
+		{"/home/linuxbrew/.linuxbrew/bin/oops", Homebrew},
+		{`C:\Users\me\scoop\apps\oops\current\oops.exe`, Scoop},
+		{"/usr/bin/oops", Apt},
+		{"/usr/local/bin/oops", nil},
+		{"/home/me/go/bin/oops", nil},
+	}
+	for _, tt := range tests {
+		if got := detectPackageManager(tt.path); got != tt.want {
+			t.Errorf("detectPackageManager(%q) = %v, want %v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,131 @@
+// Package usage keeps opt-in counts of how oops is used: which commands
+// run and how many snapshots and restores they make. The counts are kept
+// in a file in ~/.oops and are never sent anywhere; this package does no
+// network I/O.
+package usage
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/events"
+)
+
+// FileName is the usage file in the config directory
+const FileName = "usage.json"
+
+// Stats are the counts recorded since Since
+type Stats struct {
+	Since     time.Time      `json:"since"`
+	LastUsed  time.Time      `json:"last_used"`
+	Commands  map[string]int `json:"commands"`
+	Snapshots int            `json:"snapshots"`
+	Restores  int            `json:"restores"`
+}
+
+// CommandCount is how often one command ran
+type CommandCount struct {
+	Command string
+	Count   int
+}
+
+// TopCommands returns the commands by how often they ran, most first
+func (s *Stats) TopCommands() []CommandCount {
+	var counts []CommandCount
+	for command, n := range s.Commands {
+		counts = append(counts, CommandCount{command, n})
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Command < counts[j].Command
+	})
+	return counts
+}
+
+// Path returns the path of the usage file
+func Path() (string, error) {
+	dir, err := config.GetConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, FileName), nil
+}
+
+// Load reads the stats at path. With no file yet, the stats are empty.
+func Load(path string) (*Stats, error) {
+	stats := &Stats{Commands: map[string]int{}}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return stats, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, stats); err != nil {
+		return nil, err
+	}
+	if stats.Commands == nil {
+		stats.Commands = map[string]int{}
+	}
+	return stats, nil
+}
+
+// update applies fn to the stats at path and writes them back. Counting
+// is best effort: a failure never stops a command.
+func update(path string, now time.Time, fn func(*Stats)) {
+	stats, err := Load(path)
+	if err != nil {
+		stats = &Stats{Commands: map[string]int{}} // Start over from a damaged file
+	}
+	if stats.Since.IsZero() {
+		stats.Since = now
+	}
+	stats.LastUsed = now
+	fn(stats)
+
+	data, err := json.MarshalIndent(stats, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+	}
+}
+
+// RecordCommand counts one run of command
+func RecordCommand(path, command string) {
+	update(path, time.Now(), func(s *Stats) { s.Commands[command]++ })
+}
+
+// Sink returns an event sink counting snapshots and restores
+func Sink(path string) events.Sink {
+	return func(e events.Event) {
+		switch e.Type {
+		case events.SnapshotCreated:
+			update(path, e.Time, func(s *Stats) { s.Snapshots++ })
+		case events.Restored:
+			update(path, e.Time, func(s *Stats) { s.Restores++ })
+		}
+	}
+}
+
+// Reset deletes the stats at path
+func Reset(path string) error {
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,43 @@
+package usage
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/events"
+)
+
+func TestRecord(t *testing.T) {
+	path := filepath.Join(t.TempDir(), FileName)
+
+	RecordCommand(path, "save")
+	RecordCommand(path, "save")
+	RecordCommand(path, "history")
+	sink := Sink(path)
+	sink(events.Event{Type: events.SnapshotCreated, Time: time.Now()})
+	sink(events.Event{Type: events.Restored, Time: time.Now()})
+	sink(events.Event{Type: events.StoreDeleted, Time: time.Now()})
+
+	stats, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stats.Snapshots != 1 || stats.Restores != 1 {
+		t.Errorf("snapshots, restores = %d, %d; want 1, 1", stats.Snapshots, stats.Restores)
+	}
+	top := stats.TopCommands()
+	if len(top) != 2 || top[0] != (CommandCount{"save", 2}) || top[1] != (CommandCount{"history", 1}) {
+		t.Errorf("top commands = %v", top)
+	}
+	if stats.Since.IsZero() || stats.LastUsed.Before(stats.Since) {
+		t.Errorf("since %v, last used %v", stats.Since, stats.LastUsed)
+	}
+
+	if err := Reset(path); err != nil {
+		t.Fatal(err)
+	}
+	if stats, _ := Load(path); len(stats.Commands) != 0 || stats.Snapshots != 0 {
+		t.Errorf("stats after reset = %+v", stats)
+	}
+}
@@ -0,0 +1,26 @@
+package utils
+
+import (
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// Reveal opens the system file manager on the folder holding path, with
+// the file selected where the file manager supports it
+func Reveal(path string) error {
+	args := revealCommand(runtime.GOOS, path)
+	return exec.Command(args[0], args[1:]...).Start()
+}
+
+// revealCommand returns the command that reveals path on goos
+func revealCommand(goos, path string) []string {
+	switch goos {
+	case "windows":
+		return []string{"explorer", "/select," + path}
+	case "darwin":
+		return []string{"open", "-R", path}
+	default:
+		return []string{"xdg-open", filepath.Dir(path)}
+	}
+}
@@ -0,0 +1,24 @@
+package utils
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+func TestRevealCommand(t *testing.T) {
+	tests := []struct {
+		goos string
+		path string
+		want []string
+	}{
+		{"windows", `C:\Temp\oops\report #3\report.docx`, []string{"explorer", `/select,C:\Temp\oops\report #3\report.docx`}},
+		{"darwin", "/tmp/oops/notes #2/notes.md", []string{"open", "-R", "/tmp/oops/notes #2/notes.md"}},
+		{"linux", "/tmp/oops/notes #2/notes.md", []string{"xdg-open", filepath.Dir("/tmp/oops/notes #2/notes.md")}},
+	}
+	for _, tt := range tests {
+		if got := revealCommand(tt.goos, tt.path); !slices.Equal(got, tt.want) {
+			t.Errorf("revealCommand(%q) = %q, want %q", tt.goos, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package utils
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// timeRefLayouts are the absolute date formats accepted by ParseTimeRef
+var timeRefLayouts = []string{
+	"2006-01-02 15:04",
+	"2006-01-02T15:04",
+	"2006-01-02",
+}
+
+// ParseTimeRef parses a human point in time relative to now:
+// "today" and "yesterday" (their start, local time), an age such as
+// "2h", "30m" or "3d", or a local date like "2026-10-15" or
+// "2026-10-15 14:00".
+func ParseTimeRef(value string, now time.Time) (time.Time, error) {
+	v := strings.ToLower(strings.TrimSpace(value))
+	startOfDay := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+
+	switch v {
+	case "today":
+		return startOfDay, nil
+	case "yesterday":
+		return startOfDay.AddDate(0, 0, -1), nil
+	}
+
+	if days, ok := strings.CutSuffix(v, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(v); err == nil && d >= 0 {
+		return now.Add(-d), nil
+	}
+
+	for _, layout := range timeRefLayouts {
+		if t, err := time.ParseInLocation(layout, v, now.Location()); err == nil {
+			return t, nil
+		}
+	}
+
+	return time.Time{}, fmt.Errorf("invalid time %q (try today, yesterday, 2h, 3d or 2026-10-15)", value)
+}
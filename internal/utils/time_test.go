@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParseTimeRef(t *testing.T) {
+	now := time.Date(2026, 10, 16, 15, 30, 0, 0, time.Local)
+
+	tests := []struct {
+		input string
+		want  time.Time
+	}{
+		{"today", time.Date(2026, 10, 16, 0, 0, 0, 0, time.Local)},
+		{"Yesterday", time.Date(2026, 10, 15, 0, 0, 0, 0, time.Local)},
+		{"2h", now.Add(-2 * time.Hour)},
+		{"3d", now.AddDate(0, 0, -3)},
+		{"2026-10-01", time.Date(2026, 10, 1, 0, 0, 0, 0, time.Local)},
+		{"2026-10-01 09:15", time.Date(2026, 10, 1, 9, 15, 0, 0, time.Local)},
+	}
+	for _, tt := range tests {
+		got, err := ParseTimeRef(tt.input, now)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("ParseTimeRef(%q) = %v, %v; want %v", tt.input, got, err, tt.want)
+		}
+	}
+
+	for _, bad := range []string{"", "soon", "-2h", "xd"} {
+		if _, err := ParseTimeRef(bad, now); err == nil {
+			t.Errorf("ParseTimeRef(%q) should fail", bad)
+		}
+	}
+}
@@ -0,0 +1,136 @@
+package watch
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/netfs"
+	"github.com/iyulab/oops/internal/store"
+)
+
+// AutoMessage is the message of snapshots saved after a change
+const AutoMessage = "Auto snapshot"
+
+// NetworkInterval is how often a file on a network share is checked;
+// each check is a round trip to the server
+const NetworkInterval = 2 * time.Second
+
+// DefaultReload is how often Service re-reads the config
+const DefaultReload = time.Minute
+
+// Save saves an auto snapshot of s once a change has settled. The
+// snapshot is nil when the file matches the latest one. ErrRetry is
+// returned while the file is still being written or replaced.
+func Save(s *store.Store) (*store.Snapshot, error) {
+	snap, err := s.SaveAs(AutoMessage, store.OriginAuto)
+	switch {
+	case err == store.ErrNoChanges:
+		return nil, nil
+	case errors.Is(err, store.ErrFileChanging), errors.Is(err, store.ErrFileMissing):
+		return nil, ErrRetry
+	}
+	return snap, err
+}
+
+// IntervalFor returns how often the file at path should be checked, 0
+// for DefaultInterval
+func IntervalFor(path string) time.Duration {
+	if netfs.Detect(path).Network {
+		return NetworkInterval
+	}
+	return 0
+}
+
+// Service watches the files listed in the config (watch keys) inside the
+// daemon, saving each once it settles after a change. The config is
+// re-read every Reload, so files added or removed apply without
+// restarting the daemon.
+type Service struct {
+	Reload time.Duration // 0 for DefaultReload
+}
+
+func (Service) Name() string { return "watcher" }
+
+func (s Service) Run(ctx context.Context, logger *log.Logger) error {
+	reload := s.Reload
+	if reload <= 0 {
+		reload = DefaultReload
+	}
+
+	var wg sync.WaitGroup
+	running := map[config.Watch]context.CancelFunc{}
+	defer func() {
+		for _, cancel := range running {
+			cancel()
+		}
+		wg.Wait() // Changes still settling are saved before returning
+	}()
+
+	for {
+		cfg, err := config.Load()
+		if err != nil {
+			logger.Printf("[%s] failed to load config: %v", s.Name(), err)
+		} else {
+			wanted := map[config.Watch]bool{}
+			for _, w := range cfg.Watches {
+				if w.Debounce <= 0 {
+					w.Debounce = cfg.WatchDebounce
+				}
+				wanted[w] = true
+				if running[w] != nil {
+					continue
+				}
+				fileCtx, cancel := context.WithCancel(ctx)
+				running[w] = cancel
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					s.watchFile(fileCtx, w, logger)
+				}()
+			}
+			for w, cancel := range running {
+				if !wanted[w] {
+					cancel()
+					delete(running, w)
+				}
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(reload):
+		}
+	}
+}
+
+// watchFile saves the file of w as it changes until ctx is done
+func (s Service) watchFile(ctx context.Context, w config.Watch, logger *log.Logger) {
+	st, err := store.NewStoreWithOptions(w.FilePath, store.StoreOptions{Global: w.Global})
+	if err != nil || !st.Exists() {
+		logger.Printf("[%s] %s is not tracked; skipped", s.Name(), w.FilePath)
+		return
+	}
+	logger.Printf("[%s] watching %s", s.Name(), w.FilePath)
+	Watch(ctx, w.FilePath, Options{
+		Interval: IntervalFor(w.FilePath),
+		Debounce: w.Debounce,
+		OnChange: func() error {
+			snap, err := Save(st)
+			if snap != nil {
+				logger.Printf("[%s] %s: saved snapshot #%d", s.Name(), w.FilePath, snap.Number)
+			}
+			return err
+		},
+		OnError: func(err error) {
+			logger.Printf("[%s] %s: %v", s.Name(), w.FilePath, err)
+		},
+		OnMissing: func() {
+			logger.Printf("[%s] %s is gone; waiting for it to come back", s.Name(), w.FilePath)
+		},
+	})
+}
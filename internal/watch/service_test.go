@@ -0,0 +1,80 @@
+package watch
+
+import (
+	"context"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/iyulab/oops/internal/config"
+	"github.com/iyulab/oops/internal/store"
+)
+
+func TestServiceSavesConfiguredFiles(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	path := filepath.Join(t.TempDir(), "notes.md")
+	os.WriteFile(path, []byte("v1"), 0644)
+	s, _ := store.NewStore(path)
+	if err := s.Initialize(); err != nil {
+		t.Fatal(err)
+	}
+	cfg := config.DefaultConfig()
+	cfg.Watches = []config.Watch{
+		{FilePath: path, Debounce: 50 * time.Millisecond},
+		{FilePath: filepath.Join(t.TempDir(), "untracked.md")},
+	}
+	if err := cfg.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() {
+		done <- Service{Reload: 50 * time.Millisecond}.Run(ctx, log.New(io.Discard, "", 0))
+	}()
+
+	time.Sleep(200 * time.Millisecond)
+	os.WriteFile(path, []byte("v2"), 0644)
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if latest, _ := s.GetLatestVersion(); latest == 2 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("change was not saved")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	snaps, _ := s.History()
+	for _, snap := range snaps {
+		if snap.Number == 2 && snap.Message != AutoMessage {
+			t.Errorf("message = %q, want %q", snap.Message, AutoMessage)
+		}
+	}
+
+	// A file taken out of the config is no longer watched
+	cfg.Watches = nil
+	cfg.Save()
+	time.Sleep(300 * time.Millisecond)
+	os.WriteFile(path, []byte("v3"), 0644)
+	time.Sleep(time.Second)
+	if latest, _ := s.GetLatestVersion(); latest != 2 {
+		t.Errorf("latest = %d after the watch was removed, want 2", latest)
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Run returned %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("service did not stop")
+	}
+}
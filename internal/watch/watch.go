@@ -0,0 +1,137 @@
+// Package watch detects changes to a file and reports them once the file
+// has stopped changing, so an editor's burst of writes becomes one event.
+//
+// The file is polled rather than subscribed to, which works the same on
+// every platform and filesystem, including network shares, and survives
+// editors that save by replacing the file: there is no subscription on
+// the old file to lose, and writing a temporary file, moving the old one
+// aside and renaming the new one into place settles into one change.
+package watch
+
+import (
+	"context"
+	"errors"
+	"os"
+	"time"
+)
+
+// Defaults used when Options leaves a field zero
+const (
+	DefaultInterval = 500 * time.Millisecond
+	DefaultDebounce = 2 * time.Second
+)
+
+// Options configures Watch
+type Options struct {
+	Interval time.Duration // how often the file is checked
+	Debounce time.Duration // quiet time after the last change before OnChange runs
+
+	// OnChange is called once the file has settled after changing. Its
+	// error is passed to OnError and watching continues, except ErrRetry,
+	// which reports the change again after another Debounce.
+	OnChange func() error
+	OnError  func(error)
+
+	// OnMissing, if set, is called once the file has been missing for
+	// Debounce, as when it was deleted or renamed rather than replaced by
+	// an editor. It is called again only after the file has come back.
+	OnMissing func()
+}
+
+// ErrRetry is returned by OnChange when the file could not be handled yet,
+// e.g. because it was still being written
+var ErrRetry = errors.New("retry the change later")
+
+// state identifies a version of the file without reading it
+type state struct {
+	info os.FileInfo // nil while the file is missing
+}
+
+func stat(path string) state {
+	info, err := os.Stat(path)
+	if err != nil {
+		return state{}
+	}
+	return state{info: info}
+}
+
+func (s state) exists() bool {
+	return s.info != nil
+}
+
+// same reports whether two states are of the same version of the file.
+// Editors that save by renaming a new file over the old one replace the
+// file itself, which is a change even if its size and time match, as
+// with tools that keep the original modification time.
+func (s state) same(other state) bool {
+	if !s.exists() || !other.exists() {
+		return s.exists() == other.exists()
+	}
+	return s.info.Size() == other.info.Size() &&
+		s.info.ModTime().Equal(other.info.ModTime()) &&
+		os.SameFile(s.info, other.info)
+}
+
+// Watch polls path until ctx is done. A change still settling when ctx is
+// done is reported before Watch returns, so nothing is lost on shutdown.
+// A missing file, as while an editor replaces it, is not a change by
+// itself; the file reappearing with new content is. A change waiting to
+// be reported waits for the file to come back.
+func Watch(ctx context.Context, path string, opts Options) {
+	if opts.Interval <= 0 {
+		opts.Interval = DefaultInterval
+	}
+	if opts.Debounce <= 0 {
+		opts.Debounce = DefaultDebounce
+	}
+
+	ticker := time.NewTicker(opts.Interval)
+	defer ticker.Stop()
+
+	last := stat(path)
+	var changedAt time.Time // zero when nothing is waiting to be reported
+	var missingAt time.Time // zero while the file is there
+	missingReported := false
+
+	report := func(now time.Time) {
+		changedAt = time.Time{}
+		err := opts.OnChange()
+		switch {
+		case errors.Is(err, ErrRetry):
+			changedAt = now
+		case err != nil && opts.OnError != nil:
+			opts.OnError(err)
+		}
+	}
+
+	for {
+		select {
+		case <-ctx.Done():
+			if !changedAt.IsZero() {
+				report(time.Now())
+			}
+			return
+		case now := <-ticker.C:
+			current := stat(path)
+			if !current.exists() {
+				if missingAt.IsZero() {
+					missingAt = now
+				}
+				if !missingReported && opts.OnMissing != nil && now.Sub(missingAt) >= opts.Debounce {
+					missingReported = true
+					opts.OnMissing()
+				}
+				continue
+			}
+			missingAt, missingReported = time.Time{}, false
+			if !current.same(last) {
+				last = current
+				changedAt = now
+				continue
+			}
+			if !changedAt.IsZero() && now.Sub(changedAt) >= opts.Debounce {
+				report(now)
+			}
+		}
+	}
+}
@@ -0,0 +1,145 @@
+package watch
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestWatchDebounces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	os.WriteFile(path, []byte("a"), 0644)
+
+	var calls atomic.Int32
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Watch(ctx, path, Options{
+			Interval: 10 * time.Millisecond,
+			Debounce: 100 * time.Millisecond,
+			OnChange: func() error { calls.Add(1); return nil },
+		})
+		close(done)
+	}()
+
+	// A burst of writes is reported once
+	time.Sleep(30 * time.Millisecond)
+	for i := 0; i < 5; i++ {
+		os.WriteFile(path, []byte("burst"+string(rune('0'+i))), 0644)
+		time.Sleep(20 * time.Millisecond)
+	}
+	time.Sleep(300 * time.Millisecond)
+	if got := calls.Load(); got != 1 {
+		t.Errorf("OnChange called %d times after a burst, want 1", got)
+	}
+
+	// A change still settling is reported on shutdown
+	os.WriteFile(path, []byte("last change"), 0644)
+	time.Sleep(30 * time.Millisecond)
+	cancel()
+	<-done
+	if got := calls.Load(); got != 2 {
+		t.Errorf("OnChange called %d times after shutdown, want 2", got)
+	}
+}
+
+func TestWatchIgnoresMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	os.WriteFile(path, []byte("a"), 0644)
+
+	var calls atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+	go os.Remove(path)
+	Watch(ctx, path, Options{
+		Interval: 10 * time.Millisecond,
+		Debounce: 20 * time.Millisecond,
+		OnChange: func() error { calls.Add(1); return nil },
+	})
+	if got := calls.Load(); got != 0 {
+		t.Errorf("OnChange called %d times for a removed file, want 0", got)
+	}
+}
+
+func TestWatchReportsMissingFileOnce(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	os.WriteFile(path, []byte("a"), 0644)
+
+	var missing, calls atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	go os.Rename(path, path+".renamed")
+	Watch(ctx, path, Options{
+		Interval:  10 * time.Millisecond,
+		Debounce:  30 * time.Millisecond,
+		OnChange:  func() error { calls.Add(1); return nil },
+		OnMissing: func() { missing.Add(1) },
+	})
+	if got := missing.Load(); got != 1 {
+		t.Errorf("OnMissing called %d times, want 1", got)
+	}
+	if got := calls.Load(); got != 0 {
+		t.Errorf("OnChange called %d times for a renamed file, want 0", got)
+	}
+}
+
+func TestWatchRetries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "f.txt")
+	os.WriteFile(path, []byte("a"), 0644)
+
+	var calls atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Millisecond)
+	defer cancel()
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		os.WriteFile(path, []byte("changed"), 0644)
+	}()
+	Watch(ctx, path, Options{
+		Interval: 10 * time.Millisecond,
+		Debounce: 30 * time.Millisecond,
+		OnChange: func() error {
+			if calls.Add(1) == 1 {
+				return ErrRetry
+			}
+			return nil
+		},
+		OnError: func(err error) { t.Errorf("OnError(%v) for a retry", err) },
+	})
+	if got := calls.Load(); got != 2 {
+		t.Errorf("OnChange called %d times, want 2 after one retry", got)
+	}
+}
+
+func TestWatchAtomicSave(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "f.txt")
+	os.WriteFile(path, []byte("aaaa"), 0644)
+	info, _ := os.Stat(path)
+
+	var calls atomic.Int32
+	ctx, cancel := context.WithTimeout(context.Background(), 400*time.Millisecond)
+	defer cancel()
+	go func() {
+		// Write a temporary file, move the original aside and rename the
+		// new one over it, keeping the size and modification time
+		time.Sleep(30 * time.Millisecond)
+		tmp := filepath.Join(dir, ".f.txt.tmp")
+		os.WriteFile(tmp, []byte("bbbb"), 0644)
+		os.Chtimes(tmp, info.ModTime(), info.ModTime())
+		os.Rename(path, path+"~")
+		time.Sleep(25 * time.Millisecond)
+		os.Rename(tmp, path)
+		os.Remove(path + "~")
+	}()
+	Watch(ctx, path, Options{
+		Interval: 10 * time.Millisecond,
+		Debounce: 50 * time.Millisecond,
+		OnChange: func() error { calls.Add(1); return nil },
+	})
+	if got := calls.Load(); got != 1 {
+		t.Errorf("OnChange called %d times for an atomic save, want 1", got)
+	}
+}